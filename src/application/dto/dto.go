@@ -1,24 +1,45 @@
 package dto
 
 import (
+	"encoding/json"
 	"orderease/domain/order"
+	"orderease/domain/payment"
 	"orderease/domain/product"
+	"orderease/domain/promotion"
 	"orderease/domain/shared"
+	"orderease/domain/shop"
+	"orderease/domain/table"
 	"orderease/domain/user"
 	"time"
 )
 
 type CreateOrderRequest struct {
-	UserID shared.ID                `json:"user_id"`
-	ShopID shared.ID                `json:"shop_id"`
-	Items  []CreateOrderItemRequest `json:"items"`
-	Remark string                   `json:"remark"`
+	UserID     shared.ID                `json:"user_id"`
+	ShopID     shared.ID                `json:"shop_id"`
+	Items      []CreateOrderItemRequest `json:"items"`
+	Remark     string                   `json:"remark"`
+	CouponCode string                   `json:"coupon_code"` // 优惠券码，可选
+	Takeaway   bool                     `json:"takeaway"`    // 外带，店铺设置了打包费时按件收取
+	// ScheduledTime 预约取餐/送达时间，不传表示尽快制作；店铺需开放预约，时间需在可预约时段内
+	ScheduledTime *time.Time `json:"scheduled_time"`
+	// TableCode 桌台二维码内容，扫码点餐时传入，订单归入该桌台进行中的用餐
+	TableCode string `json:"table_code"`
+	// TableID 店员代客下单时指定的桌台，顾客下单时忽略，需使用 TableCode
+	TableID shared.ID `json:"table_id"`
+	// FulfillmentType 取餐方式，不传时桌台订单为堂食，其他订单为自取
+	FulfillmentType order.FulfillmentType `json:"fulfillment_type"`
+	// 收货人信息，不传时使用下单用户资料中的姓名、电话和地址；配送订单必须有收货人、电话和地址
+	RecipientName   string `json:"recipient_name"`
+	RecipientPhone  string `json:"recipient_phone"`
+	DeliveryAddress string `json:"delivery_address"`
+	// 下单操作人，由处理器根据登录信息填写
+	Actor order.StatusActor `json:"-"`
 }
 
 type CreateOrderItemRequest struct {
 	ProductID shared.ID               `json:"product_id"`
 	Quantity  int                     `json:"quantity"`
-	Price     float64                 `json:"price"`
+	Price     shared.Price            `json:"price"`
 	Options   []CreateOrderItemOption `json:"options"`
 }
 
@@ -34,29 +55,107 @@ type UpdateOrderRequest struct {
 	Items  []CreateOrderItemRequest `json:"items" binding:"required"`
 	Remark string                   `json:"remark"`
 	Status order.OrderStatus        `json:"status"`
+	Reason string                   `json:"reason"`
+	// Takeaway 不传时保持不变
+	Takeaway *bool `json:"takeaway"`
+	// 修改操作人，由处理器根据登录信息填写
+	Actor order.StatusActor `json:"-"`
 }
 
 type OrderResponse struct {
-	ID         shared.ID         `json:"id"`
-	UserID     shared.ID         `json:"user_id"`
-	ShopID     shared.ID         `json:"shop_id"`
-	TotalPrice shared.Price      `json:"total_price"`
-	Status     order.OrderStatus `json:"status"`
-	Remark     string            `json:"remark"`
-	CreatedAt  time.Time         `json:"created_at"`
-	UpdatedAt  time.Time         `json:"updated_at"`
+	ID              shared.ID               `json:"id"`
+	UserID          shared.ID               `json:"user_id"`
+	ShopID          shared.ID               `json:"shop_id"`
+	Subtotal        shared.Price            `json:"subtotal"`
+	DiscountAmount  shared.Price            `json:"discount_amount"`
+	ServiceCharge   shared.Price            `json:"service_charge"`
+	PackagingFee    shared.Price            `json:"packaging_fee"`
+	DeliveryFee     shared.Price            `json:"delivery_fee"`
+	TaxAmount       shared.Price            `json:"tax_amount"`
+	TaxRate         shared.Rate             `json:"tax_rate"`
+	TaxInclusive    bool                    `json:"tax_inclusive"`
+	TotalPrice      shared.Price            `json:"total_price"`
+	Takeaway        bool                    `json:"takeaway"`
+	FulfillmentType order.FulfillmentType   `json:"fulfillment_type"`
+	RecipientName   string                  `json:"recipient_name"`
+	RecipientPhone  string                  `json:"recipient_phone"`
+	DeliveryAddress string                  `json:"delivery_address"`
+	ScheduledTime   *time.Time              `json:"scheduled_time"`
+	PickupNumber    string                  `json:"pickup_number"`
+	TableID         shared.ID               `json:"table_id"`
+	TableSessionID  shared.ID               `json:"table_session_id"`
+	PaymentStatus   order.PaymentStatus     `json:"payment_status"`
+	PaidAmount      shared.Price            `json:"paid_amount"`
+	RefundedAmount  shared.Price            `json:"refunded_amount"`
+	Status          order.OrderStatus       `json:"status"`
+	Remark          string                  `json:"remark"`
+	CreatedAt       time.Time               `json:"created_at"`
+	UpdatedAt       time.Time               `json:"updated_at"`
+	Discounts       []OrderDiscountResponse `json:"discounts,omitempty"`
 }
 
 type OrderDetailResponse struct {
-	ID         shared.ID           `json:"id"`
-	UserID     shared.ID           `json:"user_id"`
-	ShopID     shared.ID           `json:"shop_id"`
-	TotalPrice shared.Price        `json:"total_price"`
-	Status     order.OrderStatus   `json:"status"`
-	Remark     string              `json:"remark"`
-	CreatedAt  time.Time           `json:"created_at"`
-	UpdatedAt  time.Time           `json:"updated_at"`
-	Items      []OrderItemResponse `json:"items"`
+	ID              shared.ID               `json:"id"`
+	UserID          shared.ID               `json:"user_id"`
+	ShopID          shared.ID               `json:"shop_id"`
+	Subtotal        shared.Price            `json:"subtotal"`
+	DiscountAmount  shared.Price            `json:"discount_amount"`
+	ServiceCharge   shared.Price            `json:"service_charge"`
+	PackagingFee    shared.Price            `json:"packaging_fee"`
+	DeliveryFee     shared.Price            `json:"delivery_fee"`
+	TaxAmount       shared.Price            `json:"tax_amount"`
+	TaxRate         shared.Rate             `json:"tax_rate"`
+	TaxInclusive    bool                    `json:"tax_inclusive"`
+	TotalPrice      shared.Price            `json:"total_price"`
+	Takeaway        bool                    `json:"takeaway"`
+	FulfillmentType order.FulfillmentType   `json:"fulfillment_type"`
+	RecipientName   string                  `json:"recipient_name"`
+	RecipientPhone  string                  `json:"recipient_phone"`
+	DeliveryAddress string                  `json:"delivery_address"`
+	ScheduledTime   *time.Time              `json:"scheduled_time"`
+	PickupNumber    string                  `json:"pickup_number"`
+	TableID         shared.ID               `json:"table_id"`
+	TableSessionID  shared.ID               `json:"table_session_id"`
+	PaymentStatus   order.PaymentStatus     `json:"payment_status"`
+	PaidAmount      shared.Price            `json:"paid_amount"`
+	RefundedAmount  shared.Price            `json:"refunded_amount"`
+	Status          order.OrderStatus       `json:"status"`
+	Remark          string                  `json:"remark"`
+	CreatedAt       time.Time               `json:"created_at"`
+	UpdatedAt       time.Time               `json:"updated_at"`
+	Items           []OrderItemResponse     `json:"items"`
+	Discounts       []OrderDiscountResponse `json:"discounts"`
+}
+
+// OrderDiscountResponse 订单优惠快照
+type OrderDiscountResponse struct {
+	PromotionID shared.ID    `json:"promotion_id"`
+	Name        string       `json:"name"`
+	Code        string       `json:"code,omitempty"`
+	Type        string       `json:"type"`
+	Amount      shared.Price `json:"amount"`
+}
+
+// OrderTimelineEntry 订单状态变更记录
+type OrderTimelineEntry struct {
+	OldStatus      order.OrderStatus `json:"old_status"`
+	OldStatusLabel string            `json:"old_status_label"`
+	NewStatus      order.OrderStatus `json:"new_status"`
+	NewStatusLabel string            `json:"new_status_label"`
+	Action         string            `json:"action"`
+	ActorType      string            `json:"actor_type"`
+	ActorID        uint64            `json:"actor_id"`
+	ActorName      string            `json:"actor_name"`
+	Reason         string            `json:"reason"`
+	ChangedTime    time.Time         `json:"changed_time"`
+}
+
+// OrderTimelineResponse 订单状态时间线
+type OrderTimelineResponse struct {
+	OrderID     shared.ID            `json:"order_id"`
+	Status      order.OrderStatus    `json:"status"`
+	StatusLabel string               `json:"status_label"`
+	Timeline    []OrderTimelineEntry `json:"timeline"`
 }
 
 type OrderItemResponse struct {
@@ -72,12 +171,12 @@ type OrderItemResponse struct {
 }
 
 type OrderItemOptionResponse struct {
-	ID              shared.ID `json:"id"`
-	CategoryID      shared.ID `json:"category_id"`
-	OptionID        shared.ID `json:"option_id"`
-	OptionName      string    `json:"option_name"`
-	CategoryName    string    `json:"category_name"`
-	PriceAdjustment float64   `json:"price_adjustment"`
+	ID              shared.ID    `json:"id"`
+	CategoryID      shared.ID    `json:"category_id"`
+	OptionID        shared.ID    `json:"option_id"`
+	OptionName      string       `json:"option_name"`
+	CategoryName    string       `json:"category_name"`
+	PriceAdjustment shared.Price `json:"price_adjustment"`
 }
 
 type OrderListResponse struct {
@@ -87,9 +186,62 @@ type OrderListResponse struct {
 	Data     []OrderResponse `json:"data"`
 }
 
+type OrderEventResponse struct {
+	ID         uint64            `json:"id"`
+	Type       string            `json:"type"`
+	ShopID     shared.ID         `json:"shop_id"`
+	OrderID    shared.ID         `json:"order_id"`
+	UserID     shared.ID         `json:"user_id"`
+	OldStatus  order.OrderStatus `json:"old_status"`
+	NewStatus  order.OrderStatus `json:"new_status"`
+	TotalPrice shared.Price      `json:"total_price"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	// Product 库存事件的商品信息，订单事件不返回
+	Product *StockEventResponse `json:"product,omitempty"`
+}
+
+// StockEventResponse 低库存、售罄、补货事件中的商品库存信息
+type StockEventResponse struct {
+	ProductID   shared.ID             `json:"product_id"`
+	ProductName string                `json:"product_name"`
+	Stock       int                   `json:"stock"`
+	Threshold   int                   `json:"low_stock_threshold"`
+	Status      product.ProductStatus `json:"status"`
+}
+
+// ScheduleSlotResponse 一个预约时段，Remaining 为剩余可预约订单数，时段容量不限时为 null
+type ScheduleSlotResponse struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Remaining *int      `json:"remaining"`
+	Available bool      `json:"available"`
+}
+
+type ScheduleSlotsResponse struct {
+	ShopID      shared.ID              `json:"shop_id"`
+	Timezone    string                 `json:"timezone"`
+	SlotMinutes int                    `json:"slot_minutes"`
+	LeadMinutes int                    `json:"lead_minutes"`
+	Slots       []ScheduleSlotResponse `json:"slots"`
+}
+
+// PickupBoardEntry 叫号屏上的一个可取餐订单，只显示取餐号
+type PickupBoardEntry struct {
+	PickupNumber string    `json:"pickup_number"`
+	ReadyAt      time.Time `json:"ready_at"`
+}
+
+// PickupBoardResponse 店铺当前营业日的取餐叫号屏
+type PickupBoardResponse struct {
+	ShopID       shared.ID          `json:"shop_id"`
+	BusinessDate string             `json:"business_date"`
+	Ready        []PickupBoardEntry `json:"ready"`
+}
+
 type SearchOrdersRequest struct {
 	ShopID       shared.ID           `json:"shop_id"`
 	UserID       string              `json:"user_id"`
+	PickupNumber string              `json:"pickup_number"` // 取餐号，不区分大小写；取餐号每天重新编号，可配合时间范围使用
 	Statuses     []order.OrderStatus `json:"statuses"`
 	StartTime    time.Time           `json:"start_time"`
 	EndTime      time.Time           `json:"end_time"`
@@ -100,38 +252,46 @@ type SearchOrdersRequest struct {
 }
 
 type AdvanceSearchOrderRequest struct {
-	ShopID    shared.ID           `json:"shop_id"`
-	UserID    string              `json:"user_id"`
-	Status    []int               `json:"status"`
-	StartTime string              `json:"start_time"`
-	EndTime   string              `json:"end_time"`
-	Page      int                 `json:"page"`
-	PageSize  int                 `json:"page_size"`
+	ShopID shared.ID `json:"shop_id"`
+	UserID string    `json:"user_id"`
+	Status []int     `json:"status"`
+	// FulfillmentType 按取餐方式筛选，支持多个
+	FulfillmentType []order.FulfillmentType `json:"fulfillment_type"`
+	// Recipient 按收货人姓名、电话或配送地址模糊搜索
+	Recipient string `json:"recipient"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Page      int    `json:"page"`
+	PageSize  int    `json:"page_size"`
 }
 
 type CreateProductRequest struct {
 	ShopID           shared.ID                            `json:"shop_id"`
 	Name             string                               `json:"name"`
 	Description      string                               `json:"description"`
-	Price            float64                              `json:"price"`
+	Price            shared.Price                         `json:"price"`
 	Stock            int                                  `json:"stock"`
 	ImageURL         string                               `json:"image_url"`
 	OptionCategories []CreateProductOptionCategoryRequest `json:"option_categories"`
+	// LowStockThreshold 库存预警值，0 表示不预警
+	LowStockThreshold int `json:"low_stock_threshold"`
 }
 
 type CreateProductOptionCategoryRequest struct {
 	Name         string                       `json:"name"`
 	IsRequired   bool                         `json:"is_required"`
 	IsMultiple   bool                         `json:"is_multiple"`
+	MinSelect    int                          `json:"min_select"` // 多选类别的最少选择数量
+	MaxSelect    int                          `json:"max_select"` // 多选类别的最多选择数量，0 表示不限
 	DisplayOrder int                          `json:"display_order"`
 	Options      []CreateProductOptionRequest `json:"options"`
 }
 
 type CreateProductOptionRequest struct {
-	Name            string  `json:"name"`
-	PriceAdjustment float64 `json:"price_adjustment"`
-	IsDefault       bool    `json:"is_default"`
-	DisplayOrder    int     `json:"display_order"`
+	Name            string       `json:"name"`
+	PriceAdjustment shared.Price `json:"price_adjustment"`
+	IsDefault       bool         `json:"is_default"`
+	DisplayOrder    int          `json:"display_order"`
 }
 
 type ProductResponse struct {
@@ -146,6 +306,10 @@ type ProductResponse struct {
 	CreatedAt        time.Time                       `json:"created_at"`
 	UpdatedAt        time.Time                       `json:"updated_at"`
 	OptionCategories []ProductOptionCategoryResponse `json:"option_categories"`
+
+	LowStockThreshold int  `json:"low_stock_threshold"`
+	LowStock          bool `json:"low_stock"`
+	AutoOffline       bool `json:"auto_offline"`
 }
 
 type ProductOptionCategoryResponse struct {
@@ -154,17 +318,19 @@ type ProductOptionCategoryResponse struct {
 	Name         string                  `json:"name"`
 	IsRequired   bool                    `json:"is_required"`
 	IsMultiple   bool                    `json:"is_multiple"`
+	MinSelect    int                     `json:"min_select"`
+	MaxSelect    int                     `json:"max_select"`
 	DisplayOrder int                     `json:"display_order"`
 	Options      []ProductOptionResponse `json:"options"`
 }
 
 type ProductOptionResponse struct {
-	ID              shared.ID `json:"id"`
-	CategoryID      shared.ID `json:"category_id"`
-	Name            string    `json:"name"`
-	PriceAdjustment float64   `json:"price_adjustment"`
-	DisplayOrder    int       `json:"display_order"`
-	IsDefault       bool      `json:"is_default"`
+	ID              shared.ID    `json:"id"`
+	CategoryID      shared.ID    `json:"category_id"`
+	Name            string       `json:"name"`
+	PriceAdjustment shared.Price `json:"price_adjustment"`
+	DisplayOrder    int          `json:"display_order"`
+	IsDefault       bool         `json:"is_default"`
 }
 
 type ProductListResponse struct {
@@ -179,6 +345,52 @@ type UpdateProductStatusRequest struct {
 	Status product.ProductStatus `json:"status"`
 }
 
+// AdjustStockRequest 手工调整库存：adjustment 的 quantity 为变化量，stocktake 的 quantity 为实盘数量
+type AdjustStockRequest struct {
+	ID       shared.ID                 `json:"id"`
+	Type     product.StockMovementType `json:"type"`
+	Quantity int                       `json:"quantity"`
+	Reason   string                    `json:"reason"`
+}
+
+type LowStockListResponse struct {
+	Total int               `json:"total"`
+	Data  []ProductResponse `json:"data"`
+}
+
+type StockMovementQuery struct {
+	ProductID shared.ID
+	ShopID    shared.ID
+	Type      product.StockMovementType
+	StartTime time.Time
+	EndTime   time.Time
+	Page      int
+	PageSize  int
+}
+
+type StockMovementResponse struct {
+	ID           shared.ID                 `json:"id"`
+	ProductID    shared.ID                 `json:"product_id"`
+	Type         product.StockMovementType `json:"type"`
+	Quantity     int                       `json:"quantity"`
+	BalanceAfter int                       `json:"balance_after"`
+	OrderID      shared.ID                 `json:"order_id,omitempty"`
+	ActorType    string                    `json:"actor_type"`
+	ActorID      uint64                    `json:"actor_id"`
+	ActorName    string                    `json:"actor_name"`
+	Reason       string                    `json:"reason"`
+	CreatedAt    time.Time                 `json:"created_at"`
+}
+
+type StockMovementListResponse struct {
+	ProductID shared.ID               `json:"product_id"`
+	Stock     int                     `json:"stock"`
+	Total     int64                   `json:"total"`
+	Page      int                     `json:"page"`
+	PageSize  int                     `json:"page_size"`
+	Data      []StockMovementResponse `json:"data"`
+}
+
 type CreateShopRequest struct {
 	Name            string                 `json:"name"`
 	OwnerUsername   string                 `json:"owner_username"`
@@ -190,6 +402,11 @@ type CreateShopRequest struct {
 	Address         string                 `json:"address"`
 	Settings        string                 `json:"settings"`
 	OrderStatusFlow *order.OrderStatusFlow `json:"order_status_flow"`
+	// AutoOfflineOnZeroStock 商品库存为0时自动下架，补货后自动上架
+	AutoOfflineOnZeroStock bool `json:"auto_offline_on_zero_stock"`
+	// Timezone 店铺时区（IANA 名称），不传时使用 Asia/Shanghai
+	Timezone      string              `json:"timezone"`
+	BusinessHours *shop.BusinessHours `json:"business_hours"`
 }
 
 type UpdateShopRequest struct {
@@ -204,6 +421,12 @@ type UpdateShopRequest struct {
 	Address         string                 `json:"address"`
 	Settings        string                 `json:"settings"`
 	OrderStatusFlow *order.OrderStatusFlow `json:"order_status_flow"`
+	// AutoOfflineOnZeroStock 不传时保持不变
+	AutoOfflineOnZeroStock *bool `json:"auto_offline_on_zero_stock"`
+	// Timezone、BusinessHours、OrderingPaused 不传时保持不变
+	Timezone       string              `json:"timezone"`
+	BusinessHours  *shop.BusinessHours `json:"business_hours"`
+	OrderingPaused *bool               `json:"ordering_paused"`
 }
 
 type ShopResponse struct {
@@ -220,6 +443,15 @@ type ShopResponse struct {
 	Settings        string                `json:"settings"`
 	ImageURL        string                `json:"image_url"`
 	OrderStatusFlow order.OrderStatusFlow `json:"order_status_flow"`
+
+	AutoOfflineOnZeroStock bool `json:"auto_offline_on_zero_stock"`
+
+	Timezone       string             `json:"timezone"`
+	BusinessHours  shop.BusinessHours `json:"business_hours"`
+	OrderingPaused bool               `json:"ordering_paused"`
+	// IsOpen 当前是否接单，NextOpenAt 下次开始接单的时间（正在接单时为当前时间，暂停接单时为空）
+	IsOpen     bool       `json:"is_open"`
+	NextOpenAt *time.Time `json:"next_open_at"`
 }
 
 type ShopListResponse struct {
@@ -229,6 +461,35 @@ type ShopListResponse struct {
 	Data     []ShopResponse `json:"data"`
 }
 
+type CreateStaffRequest struct {
+	ShopID   shared.ID      `json:"shop_id"`
+	Username string         `json:"username"`
+	Password string         `json:"password"`
+	Name     string         `json:"name"`
+	Role     shop.StaffRole `json:"role"`
+}
+
+type UpdateStaffRequest struct {
+	ID       shared.ID      `json:"id"`
+	ShopID   shared.ID      `json:"shop_id"`
+	Name     string         `json:"name"`
+	Role     shop.StaffRole `json:"role"`
+	Active   *bool          `json:"active"`
+	Password *string        `json:"password"`
+}
+
+type StaffResponse struct {
+	ID          shared.ID      `json:"id"`
+	ShopID      shared.ID      `json:"shop_id"`
+	Username    string         `json:"username"`
+	Name        string         `json:"name"`
+	Role        shop.StaffRole `json:"role"`
+	Permissions []string       `json:"permissions"`
+	Active      bool           `json:"active"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
 type CreateTagRequest struct {
 	ShopID      shared.ID `json:"shop_id"`
 	Name        string    `json:"name"`
@@ -285,3 +546,345 @@ type UserListResponse struct {
 	PageSize int            `json:"page_size"`
 	Data     []UserResponse `json:"data"`
 }
+
+type SessionResponse struct {
+	ID          string    `json:"id"`
+	DeviceLabel string    `json:"device_label"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"user_agent"`
+	Current     bool      `json:"current"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type AuditLogQuery struct {
+	ShopID     shared.ID
+	ActorType  string
+	ActorID    uint64
+	EntityType string
+	EntityID   string
+	Action     string
+	StartTime  time.Time
+	EndTime    time.Time
+	Page       int
+	PageSize   int
+}
+
+type AuditLogResponse struct {
+	ID         shared.ID       `json:"id"`
+	ShopID     shared.ID       `json:"shop_id"`
+	ActorType  string          `json:"actor_type"`
+	ActorID    uint64          `json:"actor_id"`
+	ActorName  string          `json:"actor_name"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Action     string          `json:"action"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	Diff       json.RawMessage `json:"diff,omitempty"`
+	IP         string          `json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type AuditLogListResponse struct {
+	Total    int64              `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+	Data     []AuditLogResponse `json:"data"`
+}
+
+// CreatePromotionRequest 创建优惠，填写券码为优惠券，不填为自动促销
+type CreatePromotionRequest struct {
+	ShopID       shared.ID              `json:"shop_id"`
+	Name         string                 `json:"name"`
+	Code         string                 `json:"code"`
+	Type         promotion.DiscountType `json:"type"`
+	Amount       shared.Price           `json:"amount"`
+	Percent      int                    `json:"percent"`
+	NthItem      int                    `json:"nth_item"`
+	MaxDiscount  shared.Price           `json:"max_discount"`
+	MinSpend     shared.Price           `json:"min_spend"`
+	UsageLimit   int                    `json:"usage_limit"`
+	PerUserLimit int                    `json:"per_user_limit"`
+	ProductIDs   []shared.ID            `json:"product_ids"`
+	TagIDs       []int                  `json:"tag_ids"`
+	StartAt      *time.Time             `json:"start_at"`
+	EndAt        *time.Time             `json:"end_at"`
+}
+
+// UpdatePromotionRequest 整体更新优惠配置，Active 为空时保持原启用状态
+type UpdatePromotionRequest struct {
+	ID shared.ID `json:"id"`
+	CreatePromotionRequest
+	Active *bool `json:"active"`
+}
+
+type PromotionResponse struct {
+	ID           shared.ID              `json:"id"`
+	ShopID       shared.ID              `json:"shop_id"`
+	Name         string                 `json:"name"`
+	Code         string                 `json:"code"`
+	Type         promotion.DiscountType `json:"type"`
+	Amount       shared.Price           `json:"amount"`
+	Percent      int                    `json:"percent"`
+	NthItem      int                    `json:"nth_item"`
+	MaxDiscount  shared.Price           `json:"max_discount"`
+	MinSpend     shared.Price           `json:"min_spend"`
+	UsageLimit   int                    `json:"usage_limit"`
+	PerUserLimit int                    `json:"per_user_limit"`
+	UsedCount    int                    `json:"used_count"`
+	ProductIDs   []shared.ID            `json:"product_ids"`
+	TagIDs       []int                  `json:"tag_ids"`
+	StartAt      *time.Time             `json:"start_at"`
+	EndAt        *time.Time             `json:"end_at"`
+	Active       bool                   `json:"active"`
+	CreatedAt    time.Time              `json:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+}
+
+// CreatePaymentRequest 为订单发起支付，金额为订单未付清的部分
+type CreatePaymentRequest struct {
+	ShopID  shared.ID      `json:"shop_id"`
+	OrderID shared.ID      `json:"order_id"`
+	Method  payment.Method `json:"method"`
+	UserID  shared.ID      `json:"-"` // 前端顾客只能为自己的订单发起支付
+}
+
+// ConfirmPaymentRequest 店员确认线下收款
+// 传入 PaymentID 时确认顾客已发起的到店付款，否则按 Method 直接为订单登记一笔线下收款
+type ConfirmPaymentRequest struct {
+	ShopID    shared.ID      `json:"shop_id"`
+	PaymentID shared.ID      `json:"payment_id"`
+	OrderID   shared.ID      `json:"order_id"`
+	Method    payment.Method `json:"method"`
+}
+
+type RefundPaymentRequest struct {
+	ShopID    shared.ID    `json:"shop_id"`
+	PaymentID shared.ID    `json:"payment_id"`
+	Amount    shared.Price `json:"amount"`
+	Reason    string       `json:"reason"`
+}
+
+// MockPayRequest 模拟顾客在支付网关完成或放弃支付，仅在启用模拟支付时可用
+type MockPayRequest struct {
+	ShopID    shared.ID `json:"shop_id"`
+	PaymentID shared.ID `json:"payment_id"`
+	Success   bool      `json:"success"`
+}
+
+type PaymentResponse struct {
+	ID              shared.ID         `json:"id"`
+	ShopID          shared.ID         `json:"shop_id"`
+	OrderID         shared.ID         `json:"order_id"`
+	Method          payment.Method    `json:"method"`
+	Amount          shared.Price      `json:"amount"`
+	RefundedAmount  shared.Price      `json:"refunded_amount"`
+	Status          payment.Status    `json:"status"`
+	ProviderTradeNo string            `json:"provider_trade_no"`
+	FailureReason   string            `json:"failure_reason,omitempty"`
+	ConfirmedBy     string            `json:"confirmed_by,omitempty"`
+	PaidAt          *time.Time        `json:"paid_at"`
+	CreatedAt       time.Time         `json:"created_at"`
+	PayParams       map[string]string `json:"pay_params,omitempty"` // 拉起支付所需的参数，仅发起线上支付时返回
+}
+
+type PaymentRefundResponse struct {
+	ID               shared.ID            `json:"id"`
+	PaymentID        shared.ID            `json:"payment_id"`
+	OrderID          shared.ID            `json:"order_id"`
+	Amount           shared.Price         `json:"amount"`
+	Reason           string               `json:"reason"`
+	Status           payment.RefundStatus `json:"status"`
+	ProviderRefundNo string               `json:"provider_refund_no"`
+	Operator         string               `json:"operator"`
+	CreatedAt        time.Time            `json:"created_at"`
+}
+
+// OrderPaymentsResponse 订单的支付汇总、支付单和退款记录
+type OrderPaymentsResponse struct {
+	OrderID        shared.ID               `json:"order_id"`
+	TotalPrice     shared.Price            `json:"total_price"`
+	PaymentStatus  order.PaymentStatus     `json:"payment_status"`
+	PaidAmount     shared.Price            `json:"paid_amount"`
+	RefundedAmount shared.Price            `json:"refunded_amount"`
+	Payments       []PaymentResponse       `json:"payments"`
+	Refunds        []PaymentRefundResponse `json:"refunds"`
+}
+
+// RefundOrderRequest 已完成订单的退款，Items 为空时全额退款
+type RefundOrderRequest struct {
+	ShopID       shared.ID                `json:"shop_id"`
+	OrderID      shared.ID                `json:"order_id"`
+	Items        []RefundOrderItemRequest `json:"items"`
+	Reason       string                   `json:"reason"`
+	RestoreStock bool                     `json:"restore_stock"` // 退回的商品重新入库
+}
+
+type RefundOrderItemRequest struct {
+	OrderItemID shared.ID `json:"order_item_id"`
+	Quantity    int       `json:"quantity"`
+}
+
+type OrderRefundItemResponse struct {
+	OrderItemID shared.ID    `json:"order_item_id"`
+	ProductID   shared.ID    `json:"product_id"`
+	ProductName string       `json:"product_name"`
+	Quantity    int          `json:"quantity"`
+	Amount      shared.Price `json:"amount"`
+}
+
+type OrderRefundResponse struct {
+	ID           shared.ID                 `json:"id"`
+	OrderID      shared.ID                 `json:"order_id"`
+	Amount       shared.Price              `json:"amount"`
+	Reason       string                    `json:"reason"`
+	RestoreStock bool                      `json:"restore_stock"`
+	ActorType    string                    `json:"actor_type"`
+	ActorID      uint64                    `json:"actor_id"`
+	ActorName    string                    `json:"actor_name"`
+	Items        []OrderRefundItemResponse `json:"items"`
+	CreatedAt    time.Time                 `json:"created_at"`
+}
+
+// OrderRefundListResponse 订单的退款记录和剩余可退金额
+type OrderRefundListResponse struct {
+	OrderID          shared.ID             `json:"order_id"`
+	TotalPrice       shared.Price          `json:"total_price"`
+	RefundedAmount   shared.Price          `json:"refunded_amount"`
+	RefundableAmount shared.Price          `json:"refundable_amount"`
+	Refunds          []OrderRefundResponse `json:"refunds"`
+}
+
+// RevenueSummaryResponse 营业额汇总，按下单时间统计已完成的订单，实收金额扣除退款
+type RevenueSummaryResponse struct {
+	OrderCount     int64        `json:"order_count"`
+	GrossAmount    shared.Price `json:"gross_amount"`
+	RefundedAmount shared.Price `json:"refunded_amount"`
+	NetAmount      shared.Price `json:"net_amount"`
+}
+
+// AddCartItemRequest 加入购物车，商品和选项都相同时合并数量
+type AddCartItemRequest struct {
+	ShopID    shared.ID               `json:"shop_id"`
+	ProductID shared.ID               `json:"product_id"`
+	Quantity  int                     `json:"quantity"`
+	Options   []CreateOrderItemOption `json:"options"`
+}
+
+// UpdateCartItemRequest 修改购物车项数量，Options 不传时保持原选项
+type UpdateCartItemRequest struct {
+	ShopID   shared.ID               `json:"shop_id"`
+	ItemID   shared.ID               `json:"item_id"`
+	Quantity int                     `json:"quantity"`
+	Options  []CreateOrderItemOption `json:"options"`
+}
+
+// CheckoutCartRequest 购物车结算，商品取自购物车
+type CheckoutCartRequest struct {
+	ShopID        shared.ID  `json:"shop_id"`
+	Remark        string     `json:"remark"`
+	CouponCode    string     `json:"coupon_code"`
+	Takeaway      bool       `json:"takeaway"`
+	ScheduledTime *time.Time `json:"scheduled_time"`
+	TableCode     string     `json:"table_code"` // 桌台二维码内容，扫码点餐时传入
+	// 取餐方式和收货人信息，同 CreateOrderRequest
+	FulfillmentType order.FulfillmentType `json:"fulfillment_type"`
+	RecipientName   string                `json:"recipient_name"`
+	RecipientPhone  string                `json:"recipient_phone"`
+	DeliveryAddress string                `json:"delivery_address"`
+}
+
+type CartItemResponse struct {
+	ID              shared.ID                 `json:"id"`
+	ProductID       shared.ID                 `json:"product_id"`
+	ProductName     string                    `json:"product_name"`
+	ProductImageURL string                    `json:"product_image_url"`
+	Quantity        int                       `json:"quantity"`
+	Price           shared.Price              `json:"price"`       // 商品当前单价
+	TotalPrice      shared.Price              `json:"total_price"` // 含选项加价的小计
+	Stock           int                       `json:"stock"`
+	Options         []OrderItemOptionResponse `json:"options"`
+	Available       bool                      `json:"available"`
+	Problem         string                    `json:"problem,omitempty"` // 不能下单的原因
+}
+
+// CartResponse 购物车按商品当前价格计价，Subtotal 只包含可以下单的商品，未计算优惠和费用
+type CartResponse struct {
+	ShopID    shared.ID          `json:"shop_id"`
+	Items     []CartItemResponse `json:"items"`
+	Subtotal  shared.Price       `json:"subtotal"`
+	Ready     bool               `json:"ready"` // 购物车不为空且所有商品都可以下单
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+type CreateTableRequest struct {
+	ShopID shared.ID `json:"shop_id"`
+	Name   string    `json:"name"`
+	Seats  int       `json:"seats"`
+}
+
+// UpdateTableRequest 修改桌台，未传的字段保持不变
+type UpdateTableRequest struct {
+	ID     shared.ID `json:"id"`
+	ShopID shared.ID `json:"shop_id"`
+	Name   string    `json:"name"`
+	Seats  *int      `json:"seats"`
+	Active *bool     `json:"active"`
+}
+
+// TableResponse 桌台，QRCode 为桌台二维码的内容，由前端编码成二维码打印
+type TableResponse struct {
+	ID        shared.ID             `json:"id"`
+	ShopID    shared.ID             `json:"shop_id"`
+	Name      string                `json:"name"`
+	Seats     int                   `json:"seats"`
+	Active    bool                  `json:"active"`
+	QRCode    string                `json:"qr_code"`
+	Session   *TableSessionResponse `json:"session"` // 进行中的用餐，空闲时为 null
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+type TableSessionResponse struct {
+	ID          shared.ID           `json:"id"`
+	TableID     shared.ID           `json:"table_id"`
+	Status      table.SessionStatus `json:"status"`
+	Guests      int                 `json:"guests"`
+	OpenedAt    time.Time           `json:"opened_at"`
+	ClosedAt    *time.Time          `json:"closed_at"`
+	CloseReason table.CloseReason   `json:"close_reason,omitempty"`
+	MergedInto  shared.ID           `json:"merged_into,omitempty"` // 并台后目标用餐的ID
+}
+
+type OpenTableSessionRequest struct {
+	ShopID  shared.ID `json:"shop_id"`
+	TableID shared.ID `json:"table_id"`
+	Guests  int       `json:"guests"`
+}
+
+// MoveTableRequest 换台或并台：TableID 上进行中的用餐移到 TargetTableID
+type MoveTableRequest struct {
+	ShopID        shared.ID `json:"shop_id"`
+	TableID       shared.ID `json:"table_id"`
+	TargetTableID shared.ID `json:"target_table_id"`
+}
+
+// SettleTableSessionRequest 整桌结账，店员收款后按线下支付确认全部未付清的订单
+type SettleTableSessionRequest struct {
+	ShopID    shared.ID      `json:"shop_id"`
+	SessionID shared.ID      `json:"session_id"`
+	Method    payment.Method `json:"method"`
+}
+
+// TableBillResponse 一次用餐的账单，金额不含已取消的订单
+type TableBillResponse struct {
+	Session     TableSessionResponse `json:"session"`
+	TableName   string               `json:"table_name"`
+	Orders      []OrderResponse      `json:"orders"`
+	TotalAmount shared.Price         `json:"total_amount"`
+	PaidAmount  shared.Price         `json:"paid_amount"`
+	DueAmount   shared.Price         `json:"due_amount"` // 还需支付的金额
+}
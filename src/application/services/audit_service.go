@@ -0,0 +1,276 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"orderease/application/dto"
+	"orderease/domain/shared"
+	"orderease/models"
+	"orderease/utils"
+	"orderease/utils/log2"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/gorm"
+)
+
+// 审计对象类型
+const (
+	AuditEntityProduct         = "product"
+	AuditEntityOrder           = "order"
+	AuditEntityTag             = "tag"
+	AuditEntityShop            = "shop"
+	AuditEntityUser            = "user"
+	AuditEntityStaff           = "staff"
+	AuditEntityOrderStatusFlow = "order_status_flow"
+	AuditEntityPromotion       = "promotion"
+	AuditEntityPayment         = "payment"
+	AuditEntityTable           = "table"
+)
+
+// 审计动作
+const (
+	AuditActionCreate        = "create"
+	AuditActionUpdate        = "update"
+	AuditActionDelete        = "delete"
+	AuditActionStatusChange  = "status_change"
+	AuditActionUploadImage   = "upload_image"
+	AuditActionBindTag       = "bind_tag"
+	AuditActionUnbindTag     = "unbind_tag"
+	AuditActionAdjustStock   = "adjust_stock"
+	AuditActionConfirmPay    = "confirm_payment"
+	AuditActionRefund        = "refund"
+	AuditActionRegenerateQR  = "regenerate_qr"
+	AuditActionTransferTable = "transfer_table"
+	AuditActionMergeTable    = "merge_table"
+	AuditActionCloseSession  = "close_session"
+)
+
+// diff 中忽略的字段，这些字段每次更新都会变化，没有审计意义
+var auditIgnoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+// AuditActor 操作人
+type AuditActor struct {
+	Type string // admin/shop/staff/user
+	ID   uint64
+	Name string
+}
+
+// AuditEntry 一条待记录的审计日志
+type AuditEntry struct {
+	Actor      AuditActor
+	ShopID     shared.ID
+	EntityType string
+	EntityID   string
+	Action     string
+	Before     interface{}
+	After      interface{}
+	IP         string
+	UserAgent  string
+}
+
+// AuditService 后台操作审计服务
+type AuditService struct {
+	db *gorm.DB
+}
+
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// Record 记录审计日志
+// 审计写入失败只记录错误日志，不影响已经完成的业务操作
+func (s *AuditService) Record(entry AuditEntry) {
+	before, err := marshalAuditData(entry.Before)
+	if err != nil {
+		log2.Errorf("序列化审计数据失败: %v", err)
+	}
+	after, err := marshalAuditData(entry.After)
+	if err != nil {
+		log2.Errorf("序列化审计数据失败: %v", err)
+	}
+
+	diff, err := diffAuditData(before, after)
+	if err != nil {
+		log2.Errorf("计算审计差异失败: %v", err)
+	}
+
+	record := models.AuditLog{
+		ID:         utils.GenerateSnowflakeID(),
+		ShopID:     snowflake.ID(entry.ShopID.ToUint64()),
+		ActorType:  entry.Actor.Type,
+		ActorID:    entry.Actor.ID,
+		ActorName:  entry.Actor.Name,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		Action:     entry.Action,
+		Before:     before,
+		After:      after,
+		Diff:       diff,
+		IP:         entry.IP,
+		UserAgent:  truncate(entry.UserAgent, 255),
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.db.Create(&record).Error; err != nil {
+		log2.Errorf("记录审计日志失败: %s %s %s, 错误: %v", entry.EntityType, entry.EntityID, entry.Action, err)
+	}
+}
+
+// QueryAuditLogs 分页查询审计日志，ShopID 为空时查询全部（仅管理员）
+func (s *AuditService) QueryAuditLogs(query dto.AuditLogQuery) (*dto.AuditLogListResponse, error) {
+	db := s.db.Model(&models.AuditLog{})
+
+	if !query.ShopID.IsZero() {
+		db = db.Where("shop_id = ?", query.ShopID.ToUint64())
+	}
+	if query.ActorType != "" {
+		db = db.Where("actor_type = ?", query.ActorType)
+	}
+	if query.ActorID != 0 {
+		db = db.Where("actor_id = ?", query.ActorID)
+	}
+	if query.EntityType != "" {
+		db = db.Where("entity_type = ?", query.EntityType)
+	}
+	if query.EntityID != "" {
+		db = db.Where("entity_id = ?", query.EntityID)
+	}
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	if !query.StartTime.IsZero() {
+		db = db.Where("created_at >= ?", query.StartTime)
+	}
+	if !query.EndTime.IsZero() {
+		db = db.Where("created_at <= ?", query.EndTime)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		log2.Errorf("查询审计日志总数失败: %v", err)
+		return nil, errors.New("查询审计日志失败")
+	}
+
+	var records []models.AuditLog
+	offset := (query.Page - 1) * query.PageSize
+	if err := db.Order("created_at DESC").Offset(offset).Limit(query.PageSize).Find(&records).Error; err != nil {
+		log2.Errorf("查询审计日志失败: %v", err)
+		return nil, errors.New("查询审计日志失败")
+	}
+
+	data := make([]dto.AuditLogResponse, len(records))
+	for i, record := range records {
+		data[i] = dto.AuditLogResponse{
+			ID:         shared.ID(record.ID),
+			ShopID:     shared.ID(record.ShopID),
+			ActorType:  record.ActorType,
+			ActorID:    record.ActorID,
+			ActorName:  record.ActorName,
+			EntityType: record.EntityType,
+			EntityID:   record.EntityID,
+			Action:     record.Action,
+			Before:     rawJSON(record.Before),
+			After:      rawJSON(record.After),
+			Diff:       rawJSON(record.Diff),
+			IP:         record.IP,
+			UserAgent:  record.UserAgent,
+			CreatedAt:  record.CreatedAt,
+		}
+	}
+
+	return &dto.AuditLogListResponse{
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+		Data:     data,
+	}, nil
+}
+
+func marshalAuditData(data interface{}) (string, error) {
+	if data == nil {
+		return "", nil
+	}
+	value := reflect.ValueOf(data)
+	if value.Kind() == reflect.Ptr && value.IsNil() {
+		return "", nil
+	}
+
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// diffAuditData 对比前后快照的顶层字段，返回 {"字段": {"before": 旧值, "after": 新值}}
+func diffAuditData(before, after string) (string, error) {
+	beforeFields, err := decodeAuditFields(before)
+	if err != nil {
+		return "", err
+	}
+	afterFields, err := decodeAuditFields(after)
+	if err != nil {
+		return "", err
+	}
+	if beforeFields == nil && afterFields == nil {
+		return "", nil
+	}
+
+	diff := make(map[string]map[string]interface{})
+	for field, oldValue := range beforeFields {
+		if auditIgnoredFields[field] {
+			continue
+		}
+		newValue, exists := afterFields[field]
+		if !exists || !reflect.DeepEqual(oldValue, newValue) {
+			diff[field] = map[string]interface{}{"before": oldValue, "after": newValue}
+		}
+	}
+	for field, newValue := range afterFields {
+		if auditIgnoredFields[field] {
+			continue
+		}
+		if _, exists := beforeFields[field]; !exists {
+			diff[field] = map[string]interface{}{"before": nil, "after": newValue}
+		}
+	}
+
+	if len(diff) == 0 {
+		return "", nil
+	}
+
+	bytes, err := json.Marshal(diff)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// decodeAuditFields 快照不是 JSON 对象时（例如数组），整体作为 value 字段比较
+func decodeAuditFields(data string) (map[string]interface{}, error) {
+	if data == "" {
+		return nil, nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return nil, err
+	}
+
+	if fields, ok := value.(map[string]interface{}); ok {
+		return fields, nil
+	}
+	return map[string]interface{}{"value": value}, nil
+}
+
+func rawJSON(data string) json.RawMessage {
+	if data == "" {
+		return nil
+	}
+	return json.RawMessage(data)
+}
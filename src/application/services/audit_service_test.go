@@ -0,0 +1,156 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"orderease/application/dto"
+	"orderease/domain/shared"
+	"orderease/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupAuditDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.AuditLog{}))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}
+
+func TestDiffAuditData(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   map[string]map[string]interface{}
+	}{
+		{
+			name:   "create",
+			before: "",
+			after:  `{"name":"奶茶","stock":10}`,
+			want: map[string]map[string]interface{}{
+				"name":  {"before": nil, "after": "奶茶"},
+				"stock": {"before": nil, "after": float64(10)},
+			},
+		},
+		{
+			name:   "update ignores unchanged and updated_at",
+			before: `{"name":"奶茶","stock":10,"updated_at":"2024-01-01T00:00:00Z"}`,
+			after:  `{"name":"奶茶","stock":8,"updated_at":"2024-01-02T00:00:00Z"}`,
+			want: map[string]map[string]interface{}{
+				"stock": {"before": float64(10), "after": float64(8)},
+			},
+		},
+		{
+			name:   "delete",
+			before: `{"name":"奶茶"}`,
+			after:  "",
+			want: map[string]map[string]interface{}{
+				"name": {"before": "奶茶", "after": nil},
+			},
+		},
+		{
+			name:   "no change",
+			before: `{"name":"奶茶"}`,
+			after:  `{"name":"奶茶"}`,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := diffAuditData(tt.before, tt.after)
+			require.NoError(t, err)
+
+			if tt.want == nil {
+				assert.Empty(t, diff)
+				return
+			}
+
+			var got map[string]map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(diff), &got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAuditService_RecordAndQuery(t *testing.T) {
+	db := setupAuditDB(t)
+	service := NewAuditService(db)
+
+	shopA := shared.ID(1001)
+	shopB := shared.ID(2002)
+
+	service.Record(AuditEntry{
+		Actor:      AuditActor{Type: PrincipalStaff, ID: 7, Name: "cashier01"},
+		ShopID:     shopA,
+		EntityType: AuditEntityProduct,
+		EntityID:   "42",
+		Action:     AuditActionUpdate,
+		Before:     map[string]interface{}{"stock": 10},
+		After:      map[string]interface{}{"stock": 8},
+		IP:         "127.0.0.1",
+		UserAgent:  "test-agent",
+	})
+	service.Record(AuditEntry{
+		Actor:      AuditActor{Type: PrincipalAdmin, ID: 1, Name: "admin"},
+		ShopID:     shopB,
+		EntityType: AuditEntityOrder,
+		EntityID:   "99",
+		Action:     AuditActionDelete,
+		Before:     map[string]interface{}{"status": 1},
+	})
+
+	t.Run("shop scope", func(t *testing.T) {
+		resp, err := service.QueryAuditLogs(dto.AuditLogQuery{ShopID: shopA, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), resp.Total)
+
+		log := resp.Data[0]
+		assert.Equal(t, PrincipalStaff, log.ActorType)
+		assert.Equal(t, uint64(7), log.ActorID)
+		assert.Equal(t, "127.0.0.1", log.IP)
+		assert.JSONEq(t, `{"stock":{"before":10,"after":8}}`, string(log.Diff))
+	})
+
+	t.Run("admin sees all shops", func(t *testing.T) {
+		resp, err := service.QueryAuditLogs(dto.AuditLogQuery{Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), resp.Total)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		resp, err := service.QueryAuditLogs(dto.AuditLogQuery{
+			EntityType: AuditEntityOrder,
+			Action:     AuditActionDelete,
+			Page:       1,
+			PageSize:   10,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), resp.Total)
+		assert.Equal(t, "99", resp.Data[0].EntityID)
+		assert.Nil(t, resp.Data[0].After)
+
+		resp, err = service.QueryAuditLogs(dto.AuditLogQuery{
+			StartTime: time.Now().Add(time.Hour),
+			Page:      1,
+			PageSize:  10,
+		})
+		require.NoError(t, err)
+		assert.Zero(t, resp.Total)
+	})
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"errors"
+
+	"orderease/application/dto"
+	"orderease/domain/cart"
+	"orderease/domain/order"
+	"orderease/domain/product"
+	"orderease/domain/shared"
+	"orderease/utils/log2"
+)
+
+// CartService 顾客的服务端购物车，按顾客和店铺保存，在多个设备间同步
+// 加购和修改时按商品当前的状态、库存和选项校验；结算时通过 OrderService.CreateOrder 下单并清空购物车
+type CartService struct {
+	cartRepo                  cart.CartRepository
+	productRepo               product.ProductRepository
+	productOptionRepo         product.ProductOptionRepository
+	productOptionCategoryRepo product.ProductOptionCategoryRepository
+	orderService              *OrderService
+}
+
+func NewCartService(
+	cartRepo cart.CartRepository,
+	productRepo product.ProductRepository,
+	productOptionRepo product.ProductOptionRepository,
+	productOptionCategoryRepo product.ProductOptionCategoryRepository,
+	orderService *OrderService,
+) *CartService {
+	return &CartService{
+		cartRepo:                  cartRepo,
+		productRepo:               productRepo,
+		productOptionRepo:         productOptionRepo,
+		productOptionCategoryRepo: productOptionCategoryRepo,
+		orderService:              orderService,
+	}
+}
+
+// loadCart 查询顾客在店铺的购物车，没有时返回新的空购物车（未保存）
+func (s *CartService) loadCart(userID, shopID shared.ID) (*cart.Cart, error) {
+	c, err := s.cartRepo.FindByUserAndShop(userID, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		c = cart.NewCart(userID, shopID.ToUint64())
+	}
+	return c, nil
+}
+
+func (s *CartService) quote(c *cart.Cart) *cart.Quote {
+	return c.Quote(NewProductFinderAdapter(s.productRepo, s.productOptionRepo, s.productOptionCategoryRepo, c.ShopID))
+}
+
+// GetCart 查询购物车，价格、库存和可下单状态按商品当前信息计算
+func (s *CartService) GetCart(userID, shopID shared.ID) (*dto.CartResponse, error) {
+	c, err := s.loadCart(userID, shopID)
+	if err != nil {
+		return nil, err
+	}
+	return toCartResponse(c, s.quote(c)), nil
+}
+
+// AddItem 加入购物车，商品已下架、库存不足或选项无效时拒绝
+func (s *CartService) AddItem(userID shared.ID, req *dto.AddCartItemRequest) (*dto.CartResponse, error) {
+	c, err := s.loadCart(userID, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.AddItem(req.ProductID, req.Quantity, toCartItemOptions(req.Options)); err != nil {
+		return nil, err
+	}
+	return s.saveChecked(c, req.ProductID)
+}
+
+// UpdateItem 修改购物车项的数量和选项
+func (s *CartService) UpdateItem(userID shared.ID, req *dto.UpdateCartItemRequest) (*dto.CartResponse, error) {
+	c, err := s.loadCart(userID, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	var options []cart.CartItemOption
+	if req.Options != nil {
+		options = toCartItemOptions(req.Options)
+	}
+	if err := c.UpdateItem(req.ItemID, req.Quantity, options); err != nil {
+		return nil, err
+	}
+
+	var productID shared.ID
+	for _, item := range c.Items {
+		if item.ID == req.ItemID {
+			productID = item.ProductID
+		}
+	}
+	return s.saveChecked(c, productID)
+}
+
+// RemoveItem 从购物车删除商品
+func (s *CartService) RemoveItem(userID, shopID, itemID shared.ID) (*dto.CartResponse, error) {
+	c, err := s.loadCart(userID, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.RemoveItem(itemID); err != nil {
+		return nil, err
+	}
+	if err := s.cartRepo.Save(c); err != nil {
+		return nil, err
+	}
+	return toCartResponse(c, s.quote(c)), nil
+}
+
+// ClearCart 清空购物车
+func (s *CartService) ClearCart(userID, shopID shared.ID) error {
+	c, err := s.cartRepo.FindByUserAndShop(userID, shopID.ToUint64())
+	if err != nil || c == nil {
+		return err
+	}
+
+	c.Clear()
+	return s.cartRepo.Save(c)
+}
+
+// saveChecked 校验变更的商品可以下单后保存购物车，其他商品的问题不影响本次修改
+func (s *CartService) saveChecked(c *cart.Cart, productID shared.ID) (*dto.CartResponse, error) {
+	quote := s.quote(c)
+	if problem := quote.ProductProblem(productID); problem != "" {
+		return nil, errors.New(problem)
+	}
+
+	if err := s.cartRepo.Save(c); err != nil {
+		return nil, err
+	}
+	return toCartResponse(c, quote), nil
+}
+
+// Checkout 将购物车转为订单，有不能下单的商品时拒绝结算
+// 下单成功后清空购物车，清空失败不影响已创建的订单
+func (s *CartService) Checkout(userID shared.ID, req *dto.CheckoutCartRequest, actor order.StatusActor) (*dto.OrderResponse, error) {
+	c, err := s.loadCart(userID, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	if c.IsEmpty() {
+		return nil, cart.ErrEmpty
+	}
+
+	if problem := s.quote(c).Problem(); problem != "" {
+		return nil, errors.New(problem)
+	}
+
+	orderItems := c.ToOrderItems()
+	items := make([]dto.CreateOrderItemRequest, len(orderItems))
+	for i, item := range orderItems {
+		options := make([]dto.CreateOrderItemOption, len(item.Options))
+		for j, opt := range item.Options {
+			options[j] = dto.CreateOrderItemOption{CategoryID: opt.CategoryID, OptionID: opt.OptionID}
+		}
+		items[i] = dto.CreateOrderItemRequest{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Options:   options,
+		}
+	}
+
+	resp, err := s.orderService.CreateOrder(&dto.CreateOrderRequest{
+		UserID:          userID,
+		ShopID:          req.ShopID,
+		Items:           items,
+		Remark:          req.Remark,
+		CouponCode:      req.CouponCode,
+		Takeaway:        req.Takeaway,
+		ScheduledTime:   req.ScheduledTime,
+		TableCode:       req.TableCode,
+		FulfillmentType: req.FulfillmentType,
+		RecipientName:   req.RecipientName,
+		RecipientPhone:  req.RecipientPhone,
+		DeliveryAddress: req.DeliveryAddress,
+		Actor:           actor,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.Clear()
+	if err := s.cartRepo.Save(c); err != nil {
+		log2.Errorf("结算后清空购物车失败, 订单ID: %s, 错误: %v", resp.ID, err)
+	}
+	return resp, nil
+}
+
+func toCartItemOptions(reqOptions []dto.CreateOrderItemOption) []cart.CartItemOption {
+	options := make([]cart.CartItemOption, len(reqOptions))
+	for i, opt := range reqOptions {
+		options[i] = cart.CartItemOption{CategoryID: opt.CategoryID, OptionID: opt.OptionID}
+	}
+	return options
+}
+
+func toCartResponse(c *cart.Cart, quote *cart.Quote) *dto.CartResponse {
+	items := make([]dto.CartItemResponse, len(quote.Lines))
+	for i, line := range quote.Lines {
+		options := make([]dto.OrderItemOptionResponse, len(line.Priced.Options))
+		for j, opt := range line.Priced.Options {
+			options[j] = dto.OrderItemOptionResponse{
+				CategoryID:      opt.CategoryID,
+				OptionID:        opt.OptionID,
+				OptionName:      opt.OptionName,
+				CategoryName:    opt.CategoryName,
+				PriceAdjustment: opt.PriceAdjustment,
+			}
+		}
+
+		items[i] = dto.CartItemResponse{
+			ID:              line.Item.ID,
+			ProductID:       line.Item.ProductID,
+			ProductName:     line.Priced.ProductName,
+			ProductImageURL: line.Priced.ProductImageURL,
+			Quantity:        line.Item.Quantity,
+			Price:           line.Priced.Price,
+			TotalPrice:      line.Priced.TotalPrice,
+			Stock:           line.Stock,
+			Options:         options,
+			Available:       line.Problem == "",
+			Problem:         line.Problem,
+		}
+	}
+
+	return &dto.CartResponse{
+		ShopID:    shared.ParseIDFromUint64(c.ShopID),
+		Items:     items,
+		Subtotal:  quote.Subtotal,
+		Ready:     quote.Ready(),
+		UpdatedAt: c.UpdatedAt,
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"testing"
+
+	"orderease/application/dto"
+	"orderease/domain/cart"
+	"orderease/domain/order"
+	"orderease/domain/shared"
+	"orderease/infrastructure/repositories"
+	"orderease/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestCartService(db *gorm.DB, orderService *OrderService) *CartService {
+	return NewCartService(
+		repositories.NewCartRepository(db),
+		repositories.NewProductRepository(db),
+		repositories.NewProductOptionRepository(db),
+		repositories.NewProductOptionCategoryRepository(db),
+		orderService,
+	)
+}
+
+func TestCartService(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	userID := shared.ID(9001)
+
+	t.Run("cart is kept on the server and checked out", func(t *testing.T) {
+		orderService, db, productID := setupStockTest(t, 5)
+		service := newTestCartService(db, orderService)
+
+		resp, err := service.AddItem(userID, &dto.AddCartItemRequest{ShopID: shopID, ProductID: productID, Quantity: 2})
+		require.NoError(t, err)
+		require.Len(t, resp.Items, 1)
+		assert.Equal(t, "24.00", resp.Subtotal.String())
+		assert.True(t, resp.Ready)
+
+		_, err = service.AddItem(userID, &dto.AddCartItemRequest{ShopID: shopID, ProductID: productID, Quantity: 4})
+		assert.EqualError(t, err, "商品 招牌奶茶 库存不足")
+
+		// 商品改价后购物车按新价格计算
+		require.NoError(t, db.Model(&models.Product{}).Where("id = ?", productID.Value()).Update("price", shared.NewPrice(15)).Error)
+		resp, err = service.GetCart(userID, shopID)
+		require.NoError(t, err)
+		assert.Equal(t, "30.00", resp.Subtotal.String())
+		itemID := resp.Items[0].ID
+
+		resp, err = service.UpdateItem(userID, &dto.UpdateCartItemRequest{ShopID: shopID, ItemID: itemID, Quantity: 3})
+		require.NoError(t, err)
+		assert.Equal(t, 3, resp.Items[0].Quantity)
+
+		ord, err := service.Checkout(userID, &dto.CheckoutCartRequest{ShopID: shopID, Remark: "少冰"}, order.StatusActor{Type: PrincipalUser, ID: 9001})
+		require.NoError(t, err)
+		assert.Equal(t, "45.00", ord.TotalPrice.String())
+		assert.Equal(t, userID, ord.UserID)
+		assert.Equal(t, 2, currentStock(t, db, productID))
+
+		resp, err = service.GetCart(userID, shopID)
+		require.NoError(t, err)
+		assert.Empty(t, resp.Items)
+
+		_, err = service.Checkout(userID, &dto.CheckoutCartRequest{ShopID: shopID}, order.StatusActor{})
+		assert.ErrorIs(t, err, cart.ErrEmpty)
+	})
+
+	t.Run("offline products block checkout", func(t *testing.T) {
+		orderService, db, productID := setupStockTest(t, 5)
+		service := newTestCartService(db, orderService)
+
+		_, err := service.AddItem(userID, &dto.AddCartItemRequest{ShopID: shopID, ProductID: productID, Quantity: 1})
+		require.NoError(t, err)
+		require.NoError(t, db.Model(&models.Product{}).Where("id = ?", productID.Value()).Update("status", models.ProductStatusOffline).Error)
+
+		resp, err := service.GetCart(userID, shopID)
+		require.NoError(t, err)
+		assert.False(t, resp.Ready)
+		assert.Equal(t, "商品 招牌奶茶 已下架", resp.Items[0].Problem)
+
+		_, err = service.Checkout(userID, &dto.CheckoutCartRequest{ShopID: shopID}, order.StatusActor{})
+		assert.EqualError(t, err, "商品 招牌奶茶 已下架")
+		assert.Equal(t, 5, currentStock(t, db, productID))
+
+		resp, err = service.RemoveItem(userID, shopID, resp.Items[0].ID)
+		require.NoError(t, err)
+		assert.Empty(t, resp.Items)
+	})
+
+	t.Run("carts are separated by user", func(t *testing.T) {
+		orderService, db, productID := setupStockTest(t, 5)
+		service := newTestCartService(db, orderService)
+
+		_, err := service.AddItem(userID, &dto.AddCartItemRequest{ShopID: shopID, ProductID: productID, Quantity: 1})
+		require.NoError(t, err)
+
+		resp, err := service.GetCart(shared.ID(9002), shopID)
+		require.NoError(t, err)
+		assert.Empty(t, resp.Items)
+
+		require.NoError(t, service.ClearCart(userID, shopID))
+		resp, err = service.GetCart(userID, shopID)
+		require.NoError(t, err)
+		assert.Empty(t, resp.Items)
+	})
+}
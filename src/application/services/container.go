@@ -1,13 +1,26 @@
 package services
 
+import "orderease/infrastructure/events"
+
 // ServiceContainer 服务容器
 // 注意：实例化请使用 wire.InitializeServiceContainer()
 type ServiceContainer struct {
-	OrderService     *OrderService
-	ProductService   *ProductService
-	ShopService      *ShopService
-	UserService      *UserService
-	TempTokenService *TempTokenService
+	OrderService          *OrderService
+	ProductService        *ProductService
+	ShopService           *ShopService
+	UserService           *UserService
+	TempTokenService      *TempTokenService
+	TokenBlacklistService *TokenBlacklistService
+	RefreshTokenService   *RefreshTokenService
+	StaffService          *StaffService
+	AuditService          *AuditService
+	PromotionService      *PromotionService
+	PaymentService        *PaymentService
+	RefundService         *RefundService
+	IdempotencyService    *IdempotencyService
+	CartService           *CartService
+	TableService          *TableService
+	OrderEventBroker      *events.OrderEventBroker
 }
 
 // NewServiceContainer 创建服务容器（由 Wire 调用）
@@ -17,12 +30,34 @@ func NewServiceContainer(
 	shopService *ShopService,
 	userService *UserService,
 	tempTokenService *TempTokenService,
+	tokenBlacklistService *TokenBlacklistService,
+	refreshTokenService *RefreshTokenService,
+	staffService *StaffService,
+	auditService *AuditService,
+	promotionService *PromotionService,
+	paymentService *PaymentService,
+	refundService *RefundService,
+	idempotencyService *IdempotencyService,
+	cartService *CartService,
+	tableService *TableService,
+	orderEventBroker *events.OrderEventBroker,
 ) *ServiceContainer {
 	return &ServiceContainer{
-		OrderService:     orderService,
-		ProductService:   productService,
-		ShopService:      shopService,
-		UserService:      userService,
-		TempTokenService: tempTokenService,
+		OrderService:          orderService,
+		ProductService:        productService,
+		ShopService:           shopService,
+		UserService:           userService,
+		TempTokenService:      tempTokenService,
+		TokenBlacklistService: tokenBlacklistService,
+		RefreshTokenService:   refreshTokenService,
+		StaffService:          staffService,
+		AuditService:          auditService,
+		PromotionService:      promotionService,
+		PaymentService:        paymentService,
+		RefundService:         refundService,
+		IdempotencyService:    idempotencyService,
+		CartService:           cartService,
+		TableService:          tableService,
+		OrderEventBroker:      orderEventBroker,
 	}
 }
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"orderease/config"
+	"orderease/models"
+	"orderease/utils/log2"
+)
+
+// defaultIdempotencyExpiration 未配置 idempotency.expiration 时幂等键的保留时间
+const defaultIdempotencyExpiration = 24 * time.Hour
+
+// idempotencyProcessingTimeout 处理中的幂等键超过该时间仍未完成，视为请求中断，允许重试接管
+const idempotencyProcessingTimeout = time.Minute
+
+const (
+	idempotencyStatusProcessing = "processing"
+	idempotencyStatusCompleted  = "completed"
+)
+
+// 支持幂等键的接口范围
+const (
+	ScopeOrderCreate  = "order_create"
+	ScopeCartCheckout = "cart_checkout"
+)
+
+var (
+	ErrIdempotencyKeyMismatch   = errors.New("幂等键已用于内容不同的请求")
+	ErrIdempotencyKeyInProgress = errors.New("相同幂等键的请求正在处理中，请稍后重试")
+)
+
+// IdempotentRequest 携带幂等键的请求
+type IdempotentRequest struct {
+	PrincipalType string
+	PrincipalID   uint64
+	Scope         string
+	Key           string
+	RequestHash   string // 请求内容的哈希，相同幂等键的重试必须内容一致
+}
+
+// IdempotencyService 写接口的幂等键服务
+// 首次请求占用幂等键并在成功后保存响应，有效期内的重试直接回放该响应；
+// 请求失败时释放幂等键，客户端可以用同一个键重试
+type IdempotencyService struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyService 创建幂等键服务实例
+func NewIdempotencyService(db *gorm.DB) *IdempotencyService {
+	return &IdempotencyService{db: db}
+}
+
+func idempotencyExpiration() time.Duration {
+	if seconds := config.AppConfig.Idempotency.Expiration; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultIdempotencyExpiration
+}
+
+// Begin 占用幂等键。replay 为 true 时返回的记录是已完成的首次请求，调用方应回放其响应；
+// 否则调用方获得幂等键，处理完成后必须调用 Complete 或 Release
+func (s *IdempotencyService) Begin(req IdempotentRequest) (record *models.IdempotencyKey, replay bool, err error) {
+	now := time.Now()
+	record = &models.IdempotencyKey{
+		PrincipalType: req.PrincipalType,
+		PrincipalID:   req.PrincipalID,
+		Scope:         req.Scope,
+		Key:           req.Key,
+		RequestHash:   req.RequestHash,
+		Status:        idempotencyStatusProcessing,
+		ExpiresAt:     now.Add(idempotencyExpiration()),
+	}
+
+	// 唯一索引保证并发的相同请求只有一个能占用幂等键；已过期或中断的记录清除后重试一次
+	for attempt := 0; attempt < 2; attempt++ {
+		if err := s.db.Create(record).Error; err == nil {
+			return record, false, nil
+		}
+
+		var existing models.IdempotencyKey
+		if err := s.db.Where("principal_type = ? AND principal_id = ? AND scope = ? AND idempotency_key = ?",
+			req.PrincipalType, req.PrincipalID, req.Scope, req.Key).
+			First(&existing).Error; err != nil {
+			log2.Errorf("查询幂等键失败, 幂等键: %s, 错误: %v", req.Key, err)
+			return nil, false, errors.New("处理幂等键失败")
+		}
+
+		abandoned := existing.Status == idempotencyStatusProcessing && existing.UpdatedAt.Before(now.Add(-idempotencyProcessingTimeout))
+		if existing.ExpiresAt.After(now) && !abandoned {
+			switch {
+			case existing.RequestHash != req.RequestHash:
+				return nil, false, ErrIdempotencyKeyMismatch
+			case existing.Status == idempotencyStatusCompleted:
+				return &existing, true, nil
+			default:
+				return nil, false, ErrIdempotencyKeyInProgress
+			}
+		}
+
+		if err := s.db.Where("id = ? AND updated_at = ?", existing.ID, existing.UpdatedAt).
+			Delete(&models.IdempotencyKey{}).Error; err != nil {
+			log2.Errorf("清除过期幂等键失败, 幂等键: %s, 错误: %v", req.Key, err)
+			return nil, false, errors.New("处理幂等键失败")
+		}
+		record.ID = 0
+	}
+
+	return nil, false, ErrIdempotencyKeyInProgress
+}
+
+// Complete 保存首次请求的响应，有效期内的重试将回放该响应
+func (s *IdempotencyService) Complete(record *models.IdempotencyKey, statusCode int, body []byte) error {
+	if err := s.db.Model(record).Updates(map[string]interface{}{
+		"status":        idempotencyStatusCompleted,
+		"status_code":   statusCode,
+		"response_body": string(body),
+	}).Error; err != nil {
+		log2.Errorf("保存幂等响应失败, 幂等键: %s, 错误: %v", record.Key, err)
+		return errors.New("保存幂等响应失败")
+	}
+	return nil
+}
+
+// Release 请求未成功时释放幂等键，允许客户端使用同一个键重试
+func (s *IdempotencyService) Release(record *models.IdempotencyKey) error {
+	if err := s.db.Where("id = ? AND status = ?", record.ID, idempotencyStatusProcessing).
+		Delete(&models.IdempotencyKey{}).Error; err != nil {
+		log2.Errorf("释放幂等键失败, 幂等键: %s, 错误: %v", record.Key, err)
+		return errors.New("释放幂等键失败")
+	}
+	return nil
+}
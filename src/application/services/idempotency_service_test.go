@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"orderease/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyService_Begin(t *testing.T) {
+	newRequest := func(hash string) IdempotentRequest {
+		return IdempotentRequest{PrincipalType: PrincipalUser, PrincipalID: 42, Scope: ScopeOrderCreate, Key: "retry-1", RequestHash: hash}
+	}
+
+	t.Run("completed request is replayed", func(t *testing.T) {
+		service := NewIdempotencyService(openStockTestDB(t, 1))
+
+		record, replay, err := service.Begin(newRequest("hash-a"))
+		require.NoError(t, err)
+		assert.False(t, replay)
+
+		_, _, err = service.Begin(newRequest("hash-a"))
+		assert.ErrorIs(t, err, ErrIdempotencyKeyInProgress)
+
+		require.NoError(t, service.Complete(record, 200, []byte(`{"id":"1"}`)))
+
+		replayed, replay, err := service.Begin(newRequest("hash-a"))
+		require.NoError(t, err)
+		assert.True(t, replay)
+		assert.Equal(t, 200, replayed.StatusCode)
+		assert.Equal(t, `{"id":"1"}`, replayed.ResponseBody)
+
+		_, _, err = service.Begin(newRequest("hash-b"))
+		assert.ErrorIs(t, err, ErrIdempotencyKeyMismatch)
+	})
+
+	t.Run("keys are scoped to the principal", func(t *testing.T) {
+		service := NewIdempotencyService(openStockTestDB(t, 1))
+
+		_, _, err := service.Begin(newRequest("hash-a"))
+		require.NoError(t, err)
+
+		other := newRequest("hash-b")
+		other.PrincipalID = 43
+		_, replay, err := service.Begin(other)
+		require.NoError(t, err)
+		assert.False(t, replay)
+	})
+
+	t.Run("released key can be retried", func(t *testing.T) {
+		service := NewIdempotencyService(openStockTestDB(t, 1))
+
+		record, _, err := service.Begin(newRequest("hash-a"))
+		require.NoError(t, err)
+		require.NoError(t, service.Release(record))
+
+		_, replay, err := service.Begin(newRequest("hash-b"))
+		require.NoError(t, err)
+		assert.False(t, replay)
+	})
+
+	t.Run("expired and abandoned keys are taken over", func(t *testing.T) {
+		db := openStockTestDB(t, 1)
+		service := NewIdempotencyService(db)
+
+		record, _, err := service.Begin(newRequest("hash-a"))
+		require.NoError(t, err)
+		require.NoError(t, service.Complete(record, 200, []byte(`{}`)))
+		require.NoError(t, db.Model(&models.IdempotencyKey{}).Where("id = ?", record.ID).
+			Update("expires_at", time.Now().Add(-time.Second)).Error)
+
+		record, replay, err := service.Begin(newRequest("hash-b"))
+		require.NoError(t, err)
+		assert.False(t, replay)
+
+		// 处理中断的请求超时后允许重试
+		require.NoError(t, db.Model(&models.IdempotencyKey{}).Where("id = ?", record.ID).
+			UpdateColumn("updated_at", time.Now().Add(-2*idempotencyProcessingTimeout)).Error)
+		_, replay, err = service.Begin(newRequest("hash-b"))
+		require.NoError(t, err)
+		assert.False(t, replay)
+	})
+}
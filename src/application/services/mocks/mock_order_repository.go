@@ -50,22 +50,46 @@ func (m *MockOrderRepository) FindByUserID(userID shared.ID, shopID uint64, page
 	return args.Get(0).([]order.Order), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockOrderRepository) FindUnfinishedByShopID(shopID uint64, flow order.OrderStatusFlow, page, pageSize int) ([]order.Order, int64, error) {
-	args := m.Called(shopID, flow, page, pageSize)
+func (m *MockOrderRepository) FindUnfinishedByShopID(shopID uint64, flow order.OrderStatusFlow, filter order.UnfinishedFilter, page, pageSize int) ([]order.Order, int64, error) {
+	args := m.Called(shopID, flow, filter, page, pageSize)
 	if args.Get(0) == nil {
 		return nil, args.Get(1).(int64), args.Error(2)
 	}
 	return args.Get(0).([]order.Order), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockOrderRepository) Search(shopID uint64, userID string, statuses []order.OrderStatus, startTime, endTime time.Time, page, pageSize int) ([]order.Order, int64, error) {
-	args := m.Called(shopID, userID, statuses, startTime, endTime, page, pageSize)
+func (m *MockOrderRepository) Search(shopID uint64, userID, pickupNumber string, statuses []order.OrderStatus, startTime, endTime time.Time, page, pageSize int) ([]order.Order, int64, error) {
+	args := m.Called(shopID, userID, pickupNumber, statuses, startTime, endTime, page, pageSize)
 	if args.Get(0) == nil {
 		return nil, args.Get(1).(int64), args.Error(2)
 	}
 	return args.Get(0).([]order.Order), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockOrderRepository) AdvanceSearch(shopID uint64, filter order.AdvanceSearchFilter, page, pageSize int) ([]order.Order, int64, error) {
+	args := m.Called(shopID, filter, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]order.Order), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockOrderRepository) FindReadyForPickup(shopID uint64, businessDate string, statuses []order.OrderStatus) ([]order.Order, error) {
+	args := m.Called(shopID, businessDate, statuses)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]order.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) FindByTableSessionID(sessionID shared.ID, shopID uint64) ([]order.Order, error) {
+	args := m.Called(sessionID, shopID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]order.Order), args.Error(1)
+}
+
 func (m *MockOrderRepository) Delete(id shared.ID) error {
 	args := m.Called(id)
 	return args.Error(0)
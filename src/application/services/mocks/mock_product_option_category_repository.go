@@ -17,8 +17,8 @@ func (m *MockProductOptionCategoryRepository) Save(category *product.ProductOpti
 	return args.Error(0)
 }
 
-func (m *MockProductOptionCategoryRepository) FindByID(id shared.ID) (*product.ProductOptionCategory, error) {
-	args := m.Called(id)
+func (m *MockProductOptionCategoryRepository) FindByID(id shared.ID, shopID uint64) (*product.ProductOptionCategory, error) {
+	args := m.Called(id, shopID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
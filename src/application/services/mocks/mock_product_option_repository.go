@@ -17,8 +17,8 @@ func (m *MockProductOptionRepository) Save(option *product.ProductOption) error
 	return args.Error(0)
 }
 
-func (m *MockProductOptionRepository) FindByID(id shared.ID) (*product.ProductOption, error) {
-	args := m.Called(id)
+func (m *MockProductOptionRepository) FindByID(id shared.ID, shopID uint64) (*product.ProductOption, error) {
+	args := m.Called(id, shopID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -17,14 +17,6 @@ func (m *MockProductRepository) Save(prod *product.Product) error {
 	return args.Error(0)
 }
 
-func (m *MockProductRepository) FindByID(id shared.ID) (*product.Product, error) {
-	args := m.Called(id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*product.Product), args.Error(1)
-}
-
 func (m *MockProductRepository) FindByIDAndShopID(id shared.ID, shopID uint64) (*product.Product, error) {
 	args := m.Called(id, shopID)
 	if args.Get(0) == nil {
@@ -41,16 +33,24 @@ func (m *MockProductRepository) FindByShopID(shopID uint64, page, pageSize int,
 	return args.Get(0).([]product.Product), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockProductRepository) FindByIDs(ids []shared.ID) ([]product.Product, error) {
-	args := m.Called(ids)
+func (m *MockProductRepository) FindByIDs(ids []shared.ID, shopID uint64) ([]product.Product, error) {
+	args := m.Called(ids, shopID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]product.Product), args.Error(1)
 }
 
-func (m *MockProductRepository) Delete(id shared.ID) error {
-	args := m.Called(id)
+func (m *MockProductRepository) FindLowStock(shopID uint64) ([]product.Product, error) {
+	args := m.Called(shopID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]product.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) Delete(id shared.ID, shopID uint64) error {
+	args := m.Called(id, shopID)
 	return args.Error(0)
 }
 
@@ -59,21 +59,21 @@ func (m *MockProductRepository) Update(prod *product.Product) error {
 	return args.Error(0)
 }
 
-func (m *MockProductRepository) CountByProductID(productID shared.ID) (int64, error) {
-	args := m.Called(productID)
+func (m *MockProductRepository) CountByProductID(productID shared.ID, shopID uint64) (int64, error) {
+	args := m.Called(productID, shopID)
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockProductRepository) FindOptionByID(id shared.ID) (*product.ProductOption, error) {
-	args := m.Called(id)
+func (m *MockProductRepository) FindOptionByID(id shared.ID, shopID uint64) (*product.ProductOption, error) {
+	args := m.Called(id, shopID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*product.ProductOption), args.Error(1)
 }
 
-func (m *MockProductRepository) FindOptionCategoryByID(id shared.ID) (*product.ProductOptionCategory, error) {
-	args := m.Called(id)
+func (m *MockProductRepository) FindOptionCategoryByID(id shared.ID, shopID uint64) (*product.ProductOptionCategory, error) {
+	args := m.Called(id, shopID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"errors"
+
+	"orderease/application/dto"
+	"orderease/domain/order"
+	"orderease/domain/shared"
+	"orderease/domain/user"
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+)
+
+// resolveOrderFulfillment 确定订单的取餐方式并保存收货信息快照
+// 未指定取餐方式时桌台订单为堂食，其他订单为自取；桌台订单只能堂食
+// 自取和配送订单未填写的收货信息取自下单用户的资料，桌台、临时令牌等系统用户除外
+func resolveOrderFulfillment(db *gorm.DB, ord *order.Order, req *dto.CreateOrderRequest, atTable bool) error {
+	fulfillment := req.FulfillmentType
+	switch {
+	case atTable && fulfillment != "" && fulfillment != order.FulfillmentDineIn:
+		return errors.New("桌台点餐只能堂食")
+	case atTable:
+		fulfillment = order.FulfillmentDineIn
+	case fulfillment == "":
+		fulfillment = order.FulfillmentPickup
+	}
+
+	name, phone, address := req.RecipientName, req.RecipientPhone, req.DeliveryAddress
+	if fulfillment != order.FulfillmentDineIn && (name == "" || phone == "" || address == "") {
+		profile, err := loadRecipientProfile(db, ord.UserID)
+		if err != nil {
+			return err
+		}
+		if profile != nil {
+			if name == "" {
+				name = profile.Name
+			}
+			if phone == "" {
+				phone = profile.Phone
+			}
+			if address == "" {
+				address = profile.Address
+			}
+		}
+	}
+
+	return ord.SetFulfillment(fulfillment, name, phone, address)
+}
+
+// loadRecipientProfile 查询下单用户的收货资料，用户不存在或为系统用户时返回 nil
+func loadRecipientProfile(db *gorm.DB, userID shared.ID) (*models.User, error) {
+	var profile models.User
+	if err := db.Select("id", "name", "phone", "address", "type").
+		Where("id = ?", userID.ToUint64()).Limit(1).Find(&profile).Error; err != nil {
+		log2.Errorf("查询用户收货信息失败, 用户ID: %s, 错误: %v", userID, err)
+		return nil, errors.New("查询用户收货信息失败")
+	}
+	if profile.ID == 0 || profile.Type == string(user.UserTypeSystem) {
+		return nil, nil
+	}
+	return &profile, nil
+}
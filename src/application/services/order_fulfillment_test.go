@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+
+	"orderease/application/dto"
+	"orderease/domain/order"
+	"orderease/domain/shared"
+	"orderease/models"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOrder_Fulfillment(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	orderService, db, productID := setupStockTest(t, 20)
+
+	require.NoError(t, db.Create(&models.Shop{
+		ID:            snowflake.ID(stockTestShopID),
+		Name:          "测试店铺",
+		OwnerUsername: "owner",
+		Settings:      []byte(`{"fulfillment":{"types":["pickup","delivery"],"delivery_min_amount":20,"delivery_fee":5,"free_delivery_amount":48}}`),
+	}).Error)
+	customer := models.User{ID: 9001, Name: "张三", Phone: "13800000000", Address: "中山路1号", Type: models.UserTypeDelivery}
+	require.NoError(t, db.Create(&customer).Error)
+
+	createOrder := func(quantity int, fulfillment order.FulfillmentType, address string) (*dto.OrderResponse, error) {
+		return orderService.CreateOrder(&dto.CreateOrderRequest{
+			UserID:          shared.ID(customer.ID),
+			ShopID:          shopID,
+			Items:           []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: quantity}},
+			FulfillmentType: fulfillment,
+			DeliveryAddress: address,
+		})
+	}
+
+	_, err := createOrder(1, order.FulfillmentDelivery, "")
+	assert.EqualError(t, err, "配送订单需满 20.00 元起送")
+	_, err = createOrder(2, order.FulfillmentDineIn, "")
+	assert.EqualError(t, err, "店铺暂不支持堂食")
+
+	// 未填写的收货信息取自用户资料，之后修改资料不影响已有订单
+	delivery, err := createOrder(2, order.FulfillmentDelivery, "")
+	require.NoError(t, err)
+	assert.Equal(t, "张三", delivery.RecipientName)
+	assert.Equal(t, "中山路1号", delivery.DeliveryAddress)
+	assert.Equal(t, "5.00", delivery.DeliveryFee.String())
+	assert.Equal(t, "29.00", delivery.TotalPrice.String())
+	assert.True(t, delivery.Takeaway)
+
+	require.NoError(t, db.Model(&customer).Update("address", "解放路2号").Error)
+	detail, err := orderService.GetOrder(delivery.ID, shopID)
+	require.NoError(t, err)
+	assert.Equal(t, "中山路1号", detail.DeliveryAddress)
+
+	free, err := createOrder(4, order.FulfillmentDelivery, "人民路3号")
+	require.NoError(t, err)
+	assert.Equal(t, "人民路3号", free.DeliveryAddress)
+	assert.True(t, free.DeliveryFee.IsZero(), "满额免配送费")
+
+	pickup, err := createOrder(1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, order.FulfillmentPickup, pickup.FulfillmentType)
+	assert.Equal(t, "13800000000", pickup.RecipientPhone)
+	assert.Empty(t, pickup.DeliveryAddress)
+
+	t.Run("advance search filters", func(t *testing.T) {
+		search := func(req dto.AdvanceSearchOrderRequest) []shared.ID {
+			req.ShopID, req.Page, req.PageSize = shopID, 1, 10
+			resp, err := orderService.AdvanceSearchOrders(&req)
+			require.NoError(t, err)
+			ids := make([]shared.ID, len(resp.Data))
+			for i, o := range resp.Data {
+				ids[i] = o.ID
+			}
+			return ids
+		}
+
+		assert.ElementsMatch(t, []shared.ID{delivery.ID, free.ID},
+			search(dto.AdvanceSearchOrderRequest{FulfillmentType: []order.FulfillmentType{order.FulfillmentDelivery}}))
+		assert.ElementsMatch(t, []shared.ID{free.ID}, search(dto.AdvanceSearchOrderRequest{Recipient: "人民路"}))
+		assert.ElementsMatch(t, []shared.ID{delivery.ID, free.ID, pickup.ID}, search(dto.AdvanceSearchOrderRequest{Recipient: "1380000"}))
+	})
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"orderease/application/dto"
+	"orderease/domain/order"
+	"orderease/domain/shared"
+	"orderease/domain/shop"
+	"orderease/infrastructure/persistence"
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// assignPickupNumber 在下单事务内为订单发放取餐号
+// 取餐号按出餐时间所在的营业日编号，预约订单使用预约当天的编号；店铺不存在时使用默认设置
+func assignPickupNumber(tx *gorm.DB, ord *order.Order, now time.Time) error {
+	var shopModel models.Shop
+	if err := tx.Select("id", "settings", "timezone").Where("id = ?", ord.ShopID).Limit(1).Find(&shopModel).Error; err != nil {
+		log2.Errorf("查询店铺设置失败, 店铺ID: %d, 错误: %v", ord.ShopID, err)
+		return errors.New("生成取餐号失败")
+	}
+	shopEntity := &shop.Shop{Settings: string(shopModel.Settings), Timezone: shopModel.Timezone}
+
+	settings, err := shopEntity.PickupSettings()
+	if err != nil {
+		log2.Errorf("店铺取餐号设置无效, 店铺ID: %d, 错误: %v", ord.ShopID, err)
+		return err
+	}
+
+	at := now
+	if ord.ScheduledTime != nil {
+		at = *ord.ScheduledTime
+	}
+	businessDate := shopEntity.BusinessDate(at, settings)
+
+	seq, err := nextPickupSequence(tx, ord.ShopID, businessDate)
+	if err != nil {
+		return err
+	}
+
+	ord.BusinessDate = businessDate
+	ord.PickupNumber = settings.FormatNumber(seq)
+	return nil
+}
+
+// nextPickupSequence 递增并返回店铺营业日的取餐号序号
+// 依靠 (shop_id, business_date) 主键冲突时原地加一，并发下单时行锁保证序号不重复
+func nextPickupSequence(tx *gorm.DB, shopID uint64, businessDate string) (int, error) {
+	seq := models.PickupSequence{ShopID: snowflake.ID(shopID), BusinessDate: businessDate, LastNumber: 1}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "shop_id"}, {Name: "business_date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"last_number": gorm.Expr("last_number + 1"), "updated_at": time.Now()}),
+	}).Create(&seq).Error; err != nil {
+		log2.Errorf("递增取餐号失败, 店铺ID: %d, 营业日: %s, 错误: %v", shopID, businessDate, err)
+		return 0, errors.New("生成取餐号失败")
+	}
+
+	if err := tx.Where("shop_id = ? AND business_date = ?", shopID, businessDate).First(&seq).Error; err != nil {
+		log2.Errorf("查询取餐号失败, 店铺ID: %d, 营业日: %s, 错误: %v", shopID, businessDate, err)
+		return 0, errors.New("生成取餐号失败")
+	}
+	return seq.LastNumber, nil
+}
+
+// GetPickupBoard 店铺当前营业日处于可取餐状态的取餐号，供叫号屏公开展示
+// 可取餐的状态由店铺订单流转配置中的 ReadyForPickup 决定
+func (s *OrderService) GetPickupBoard(shopID shared.ID) (*dto.PickupBoardResponse, error) {
+	var shopModel models.Shop
+	if err := s.db.Select("id", "settings", "timezone", "order_status_flow").
+		Where("id = ?", shopID.ToUint64()).Limit(1).Find(&shopModel).Error; err != nil {
+		log2.Errorf("查询店铺失败, 店铺ID: %s, 错误: %v", shopID, err)
+		return nil, errors.New("查询店铺失败")
+	}
+	if shopModel.ID == 0 {
+		return nil, errors.New("店铺不存在")
+	}
+	shopEntity := persistence.ShopToDomain(shopModel)
+
+	settings, err := shopEntity.PickupSettings()
+	if err != nil {
+		return nil, err
+	}
+	businessDate := shopEntity.BusinessDate(time.Now(), settings)
+
+	orders, err := s.orderRepo.FindReadyForPickup(shopID.ToUint64(), businessDate, shopEntity.OrderStatusFlow.ReadyForPickupStatuses())
+	if err != nil {
+		return nil, err
+	}
+
+	ready := make([]dto.PickupBoardEntry, 0, len(orders))
+	for _, ord := range orders {
+		if ord.PickupNumber == "" {
+			continue
+		}
+		ready = append(ready, dto.PickupBoardEntry{PickupNumber: ord.PickupNumber, ReadyAt: ord.UpdatedAt})
+	}
+
+	return &dto.PickupBoardResponse{
+		ShopID:       shopID,
+		BusinessDate: businessDate,
+		Ready:        ready,
+	}, nil
+}
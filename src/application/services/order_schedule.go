@@ -0,0 +1,173 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"orderease/application/dto"
+	"orderease/domain/order"
+	"orderease/domain/shared"
+	"orderease/domain/shop"
+	"orderease/infrastructure/persistence"
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrScheduleSlotFull = errors.New("该时段的预约已满，请选择其他时段")
+
+// loadOrderingShop 查询下单需要的店铺设置，店铺不存在时返回 nil，由商品校验拒绝下单
+// withFlow 为 true 时同时读取订单流转配置，用于统计占用时段容量的预约订单
+func loadOrderingShop(db *gorm.DB, shopID uint64, withFlow bool) (*shop.Shop, error) {
+	columns := []string{"id", "settings", "timezone", "business_hours", "ordering_paused"}
+	if withFlow {
+		columns = append(columns, "order_status_flow")
+	}
+
+	var model models.Shop
+	if err := db.Select(columns).Where("id = ?", shopID).Limit(1).Find(&model).Error; err != nil {
+		log2.Errorf("查询店铺营业设置失败, 店铺ID: %d, 错误: %v", shopID, err)
+		return nil, errors.New("查询店铺营业状态失败")
+	}
+	if model.ID == 0 {
+		return nil, nil
+	}
+	return persistence.ShopToDomain(model), nil
+}
+
+// slotReservation 预约订单占用的时段，在下单事务内检查时段容量
+type slotReservation struct {
+	slot     shop.ScheduleSlot
+	capacity int
+	flow     order.OrderStatusFlow
+}
+
+// checkShopAcceptingOrders 检查店铺是否接单
+// 立即制作的订单按店铺时区的营业时间和暂停接单开关检查；预约订单校验预约时间，
+// 时段有容量限制时返回需要在事务内占用的时段
+func checkShopAcceptingOrders(db *gorm.DB, shopID uint64, scheduled *time.Time, now time.Time) (*slotReservation, error) {
+	shopEntity, err := loadOrderingShop(db, shopID, scheduled != nil)
+	if err != nil || shopEntity == nil {
+		return nil, err
+	}
+
+	if scheduled == nil {
+		return nil, shopEntity.CheckAcceptingOrders(now)
+	}
+
+	settings, err := shopEntity.SchedulingSettings()
+	if err != nil {
+		log2.Errorf("店铺预约设置无效, 店铺ID: %d, 错误: %v", shopID, err)
+		return nil, err
+	}
+	slot, err := shopEntity.ValidateScheduledTime(*scheduled, now, settings)
+	if err != nil {
+		return nil, err
+	}
+	if settings.SlotCapacity == 0 {
+		return nil, nil
+	}
+	return &slotReservation{slot: slot, capacity: settings.SlotCapacity, flow: shopEntity.OrderStatusFlow}, nil
+}
+
+// scheduledOrdersQuery 占用时段容量的预约订单：已取消等归还库存状态的订单不再占用
+func scheduledOrdersQuery(db *gorm.DB, shopID uint64, from, to time.Time, flow order.OrderStatusFlow) *gorm.DB {
+	query := db.Model(&models.Order{}).
+		Where("shop_id = ? AND scheduled_time >= ? AND scheduled_time < ?", shopID, from, to)
+
+	var released []int
+	for _, status := range flow.Statuses {
+		if status.ReleaseStock {
+			released = append(released, int(status.Value))
+		}
+	}
+	if len(released) > 0 {
+		query = query.Where("status NOT IN (?)", released)
+	}
+	return query
+}
+
+// reserve 锁定店铺后检查时段内的预约订单数，同一店铺的预约下单依次执行，避免超出容量
+func (r *slotReservation) reserve(tx *gorm.DB, shopID uint64) error {
+	if r == nil {
+		return nil
+	}
+
+	var locked models.Shop
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		Where("id = ?", shopID).Limit(1).Find(&locked).Error; err != nil {
+		log2.Errorf("锁定店铺失败, 店铺ID: %d, 错误: %v", shopID, err)
+		return errors.New("检查预约时段失败")
+	}
+
+	var count int64
+	if err := scheduledOrdersQuery(tx, shopID, r.slot.Start, r.slot.End, r.flow).Count(&count).Error; err != nil {
+		log2.Errorf("查询时段预约数量失败, 店铺ID: %d, 错误: %v", shopID, err)
+		return errors.New("检查预约时段失败")
+	}
+	if int(count) >= r.capacity {
+		return ErrScheduleSlotFull
+	}
+	return nil
+}
+
+// GetScheduleSlots 查询店铺当前可以预约的时段和剩余容量
+func (s *OrderService) GetScheduleSlots(shopID shared.ID) (*dto.ScheduleSlotsResponse, error) {
+	shopEntity, err := loadOrderingShop(s.db, shopID.ToUint64(), true)
+	if err != nil {
+		return nil, err
+	}
+	if shopEntity == nil {
+		return nil, errors.New("店铺不存在")
+	}
+
+	settings, err := shopEntity.SchedulingSettings()
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, shop.ErrSchedulingDisabled
+	}
+
+	slots := shopEntity.ScheduleSlots(time.Now(), settings)
+	counts := make([]int, len(slots))
+	if settings.SlotCapacity > 0 && len(slots) > 0 {
+		var scheduled []time.Time
+		if err := scheduledOrdersQuery(s.db, shopID.ToUint64(), slots[0].Start, slots[len(slots)-1].End, shopEntity.OrderStatusFlow).
+			Pluck("scheduled_time", &scheduled).Error; err != nil {
+			log2.Errorf("查询预约订单失败, 店铺ID: %s, 错误: %v", shopID, err)
+			return nil, errors.New("查询预约时段失败")
+		}
+		for _, t := range scheduled {
+			for i, slot := range slots {
+				if !t.Before(slot.Start) && t.Before(slot.End) {
+					counts[i]++
+					break
+				}
+			}
+		}
+	}
+
+	data := make([]dto.ScheduleSlotResponse, len(slots))
+	for i, slot := range slots {
+		data[i] = dto.ScheduleSlotResponse{Start: slot.Start, End: slot.End, Available: true}
+		if settings.SlotCapacity > 0 {
+			remaining := settings.SlotCapacity - counts[i]
+			if remaining < 0 {
+				remaining = 0
+			}
+			data[i].Remaining = &remaining
+			data[i].Available = remaining > 0
+		}
+	}
+
+	return &dto.ScheduleSlotsResponse{
+		ShopID:      shopEntity.ID,
+		Timezone:    shopEntity.Location().String(),
+		SlotMinutes: settings.SlotMinutes,
+		LeadMinutes: settings.LeadMinutes,
+		Slots:       data,
+	}, nil
+}
@@ -6,8 +6,12 @@ import (
 	"orderease/domain/order"
 	"orderease/domain/product"
 	"orderease/domain/shared"
+	"orderease/domain/shop"
+	"orderease/infrastructure/repositories"
+	"orderease/models"
 	"orderease/utils"
 	"orderease/utils/log2"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -22,6 +26,8 @@ type OrderService struct {
 	orderItemRepo             order.OrderItemRepository
 	orderItemOptionRepo       order.OrderItemOptionRepository
 	orderStatusLogRepo        order.OrderStatusLogRepository
+	eventPublisher            order.EventPublisher
+	stockEventPublisher       product.StockEventPublisher
 }
 
 // NewOrderService 创建 OrderService 实例
@@ -34,6 +40,8 @@ func NewOrderService(
 	orderItemRepo order.OrderItemRepository,
 	orderItemOptionRepo order.OrderItemOptionRepository,
 	orderStatusLogRepo order.OrderStatusLogRepository,
+	eventPublisher order.EventPublisher,
+	stockEventPublisher product.StockEventPublisher,
 ) *OrderService {
 	return &OrderService{
 		db:                        db,
@@ -44,9 +52,19 @@ func NewOrderService(
 		orderItemRepo:             orderItemRepo,
 		orderItemOptionRepo:       orderItemOptionRepo,
 		orderStatusLogRepo:        orderStatusLogRepo,
+		eventPublisher:            eventPublisher,
+		stockEventPublisher:       stockEventPublisher,
 	}
 }
 
+// publishEvent 发布订单事件（未配置事件发布者时忽略）
+func (s *OrderService) publishEvent(eventType order.OrderEventType, ord *order.Order, oldStatus order.OrderStatus) {
+	if s.eventPublisher == nil {
+		return
+	}
+	s.eventPublisher.Publish(order.NewOrderEvent(eventType, ord, oldStatus))
+}
+
 // buildOrderItems 从 DTO 构建订单项
 func (s *OrderService) buildOrderItems(reqItems []dto.CreateOrderItemRequest) []order.OrderItem {
 	items := make([]order.OrderItem, len(reqItems))
@@ -65,7 +83,7 @@ func (s *OrderService) buildOrderItems(reqItems []dto.CreateOrderItemRequest) []
 		items[i] = order.OrderItem{
 			ProductID: itemReq.ProductID,
 			Quantity:  itemReq.Quantity,
-			Price:     shared.Price(itemReq.Price),
+			Price:     itemReq.Price,
 			Options:   options,
 		}
 	}
@@ -75,15 +93,35 @@ func (s *OrderService) buildOrderItems(reqItems []dto.CreateOrderItemRequest) []
 // CreateOrder 创建订单（重构版本）
 // 业务逻辑已迁移到领域层，应用层只负责编排
 func (s *OrderService) CreateOrder(req *dto.CreateOrderRequest) (*dto.OrderResponse, error) {
+	// 0. 店铺暂停接单或不在营业时间内时拒绝下单，预约订单校验预约时间
+	reservation, err := checkShopAcceptingOrders(s.db, req.ShopID.ToUint64(), req.ScheduledTime, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// 扫码点餐或代客下单指定了桌台时，订单归入桌台进行中的用餐
+	dineIn, err := resolveOrderTable(req.ShopID, req.TableCode, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+	if dineIn != nil && req.ScheduledTime != nil {
+		return nil, errors.New("桌台点餐不能预约")
+	}
+
 	// 1. 构建 Order 对象
 	items := s.buildOrderItems(req.Items)
 	ord, err := order.NewOrder(req.UserID, req.ShopID.ToUint64(), items, req.Remark)
 	if err != nil {
 		return nil, err
 	}
+	ord.Takeaway = req.Takeaway
+	ord.ScheduledTime = req.ScheduledTime
+	if err := resolveOrderFulfillment(s.db, ord, req, dineIn != nil); err != nil {
+		return nil, err
+	}
 
 	// 2. 创建 finder 适配器
-	finder := NewProductFinderAdapter(s.productRepo, s.productOptionRepo, s.productOptionCategoryRepo)
+	finder := NewProductFinderAdapter(s.productRepo, s.productOptionRepo, s.productOptionCategoryRepo, ord.ShopID)
 
 	// 3. 领域验证和价格计算（业务逻辑在领域层）
 	if err := ord.ValidateItems(finder); err != nil {
@@ -94,54 +132,70 @@ func (s *OrderService) CreateOrder(req *dto.CreateOrderRequest) (*dto.OrderRespo
 		return nil, err
 	}
 
-	// 4. 执行事务（应用层职责）
-	return s.executeCreateOrderTransaction(ord, finder)
+	// 4. 执行事务（应用层职责），优惠在事务内计算，使用次数与订单一起提交
+	return s.executeCreateOrderTransaction(ord, req.CouponCode, req.Actor, reservation, dineIn)
 }
 
 // executeCreateOrderTransaction 执行订单创建的事务
-func (s *OrderService) executeCreateOrderTransaction(ord *order.Order, finder order.ProductFinder) (*dto.OrderResponse, error) {
+func (s *OrderService) executeCreateOrderTransaction(ord *order.Order, couponCode string, actor order.StatusActor, reservation *slotReservation, dineIn *tableRef) (*dto.OrderResponse, error) {
 	var savedOrder *order.Order
+	var stock stockChanges
 	var err error
 
 	// 使用事务模板
 	err = WithTx(s.db, func(tx *gorm.DB) error {
-		// 扣减库存
-		for i := range ord.Items {
-			prod, findErr := finder.FindProduct(ord.Items[i].ProductID)
-			if findErr != nil {
-				return findErr
-			}
+		repos := newOrderTxRepos(tx)
 
-			if decreaseErr := prod.DecreaseStock(ord.Items[i].Quantity); decreaseErr != nil {
-				return decreaseErr
-			}
+		// 预约订单占用时段容量
+		if err := reservation.reserve(tx, ord.ShopID); err != nil {
+			return err
+		}
 
-			if updateErr := s.productRepo.Update(prod); updateErr != nil {
-				return errors.New("更新商品库存失败")
-			}
+		// 归入桌台进行中的用餐
+		if err := attachTableSession(tx, ord, dineIn); err != nil {
+			return err
+		}
+
+		// 发放当天的取餐号
+		if err := assignPickupNumber(tx, ord, time.Now()); err != nil {
+			return err
 		}
 
-		// 设置订单ID
+		// 设置订单ID，库存流水需要关联订单
 		ord.ID = shared.ID(utils.GenerateSnowflakeID())
 
+		// 条件扣减库存
+		if err := stock.adjustOrder(tx, ord, stockDeltas(nil, ord.ItemQuantities()), AuditActor(actor), ""); err != nil {
+			return err
+		}
+
+		// 计算优惠券和自动促销
+		if err := applyOrderPromotions(tx, ord, couponCode, time.Now()); err != nil {
+			return err
+		}
+
+		// 计算配送费、服务费、打包费和税额，校验店铺是否支持该取餐方式
+		fulfillment, err := applyShopCharges(tx, ord)
+		if err != nil {
+			return err
+		}
+		if err := fulfillment.Check(ord); err != nil {
+			return err
+		}
+
 		// 保存订单
-		if err := s.orderRepo.Save(ord); err != nil {
+		if err := repos.orders.Save(ord); err != nil {
 			return errors.New("创建订单失败")
 		}
 
 		// 保存订单项
-		for i := range ord.Items {
-			ord.Items[i].OrderID = ord.ID
-			if err := s.orderItemRepo.Save(&ord.Items[i]); err != nil {
-				return errors.New("创建订单项失败")
-			}
+		if err := saveOrderItems(repos, ord.ID, ord.Items); err != nil {
+			return err
+		}
 
-			for j := range ord.Items[i].Options {
-				ord.Items[i].Options[j].OrderItemID = ord.Items[i].ID
-				if err := s.orderItemOptionRepo.Save(&ord.Items[i].Options[j]); err != nil {
-					return errors.New("创建订单项选项失败")
-				}
-			}
+		// 保存优惠快照
+		if err := saveOrderDiscounts(repos, ord.ID, ord.Discounts); err != nil {
+			return err
 		}
 
 		// 保存状态日志
@@ -149,9 +203,10 @@ func (s *OrderService) executeCreateOrderTransaction(ord *order.Order, finder or
 			OrderID:     ord.ID,
 			OldStatus:   0,
 			NewStatus:   ord.Status,
+			Actor:       actor,
 			ChangedTime: time.Now(),
 		}
-		if err := s.orderStatusLogRepo.Save(statusLog); err != nil {
+		if err := repos.logs.Save(statusLog); err != nil {
 			return errors.New("创建订单状态日志失败")
 		}
 
@@ -164,20 +219,72 @@ func (s *OrderService) executeCreateOrderTransaction(ord *order.Order, finder or
 	}
 
 	log2.Infof("订单创建成功: %+v", savedOrder)
+	s.publishEvent(order.OrderEventCreated, savedOrder, savedOrder.Status)
+	stock.publish(s.stockEventPublisher)
 
-	return &dto.OrderResponse{
-		ID:         savedOrder.ID,
-		UserID:     savedOrder.UserID,
-		ShopID:     shared.ParseIDFromUint64(savedOrder.ShopID),
-		TotalPrice: savedOrder.TotalPrice,
-		Status:     savedOrder.Status,
-		Remark:     savedOrder.Remark,
-		CreatedAt:  savedOrder.CreatedAt,
-		UpdatedAt:  savedOrder.UpdatedAt,
-	}, nil
+	response := toOrderResponse(savedOrder)
+	response.Discounts = toOrderDiscountResponses(savedOrder.Discounts)
+	return &response, nil
+}
+
+// saveOrderItems 保存订单项及其选项
+func saveOrderItems(repos orderTxRepos, orderID shared.ID, items []order.OrderItem) error {
+	for i := range items {
+		items[i].OrderID = orderID
+		if err := repos.items.Save(&items[i]); err != nil {
+			return errors.New("创建订单项失败")
+		}
+
+		for j := range items[i].Options {
+			items[i].Options[j].OrderItemID = items[i].ID
+			if err := repos.options.Save(&items[i].Options[j]); err != nil {
+				return errors.New("创建订单项选项失败")
+			}
+		}
+	}
+	return nil
+}
+
+// applyShopCharges 按店铺当前的设置计算配送费、服务费、打包费和税额，需在计算优惠之后调用
+// 返回店铺的取餐方式设置，供下单时校验
+func applyShopCharges(tx *gorm.DB, ord *order.Order) (order.FulfillmentSettings, error) {
+	var shopModel models.Shop
+	if err := tx.Select("id", "settings").Where("id = ?", ord.ShopID).Limit(1).Find(&shopModel).Error; err != nil {
+		log2.Errorf("查询店铺设置失败, 店铺ID: %d, 错误: %v", ord.ShopID, err)
+		return order.FulfillmentSettings{}, errors.New("计算订单费用失败")
+	}
+
+	settings, err := shop.ParseChargeSettings(string(shopModel.Settings))
+	if err != nil {
+		log2.Errorf("店铺费用设置无效, 店铺ID: %d, 错误: %v", ord.ShopID, err)
+		return order.FulfillmentSettings{}, err
+	}
+	fulfillment, err := shop.ParseFulfillmentSettings(string(shopModel.Settings))
+	if err != nil {
+		log2.Errorf("店铺取餐方式设置无效, 店铺ID: %d, 错误: %v", ord.ShopID, err)
+		return order.FulfillmentSettings{}, err
+	}
+
+	ord.ApplyDeliveryFee(fulfillment)
+	ord.ApplyCharges(settings)
+	return fulfillment, nil
+}
+
+// deleteOrderItems 删除订单项及其选项
+func deleteOrderItems(repos orderTxRepos, orderID shared.ID, items []order.OrderItem) error {
+	for _, item := range items {
+		if err := repos.options.DeleteByOrderItemID(item.ID); err != nil {
+			return errors.New("删除订单项选项失败")
+		}
+	}
+	if err := repos.items.DeleteByOrderID(orderID); err != nil {
+		return errors.New("删除订单项失败")
+	}
+	return nil
 }
+
 func (s *OrderService) GetOrder(id shared.ID, shopID shared.ID) (*dto.OrderDetailResponse, error) {
-	ord, err := s.orderRepo.FindByID(id)
+	ord, err := s.orderRepo.FindByIDAndShopID(id, shopID.ToUint64())
 	if err != nil {
 		return nil, err
 	}
@@ -210,15 +317,79 @@ func (s *OrderService) GetOrder(id shared.ID, shopID shared.ID) (*dto.OrderDetai
 	}
 
 	return &dto.OrderDetailResponse{
-		ID:         ord.ID,
-		UserID:     ord.UserID,
-		ShopID:     shared.ParseIDFromUint64(ord.ShopID),
-		TotalPrice: ord.TotalPrice,
-		Status:     ord.Status,
-		Remark:     ord.Remark,
-		CreatedAt:  ord.CreatedAt,
-		UpdatedAt:  ord.UpdatedAt,
-		Items:      items,
+		ID:              ord.ID,
+		UserID:          ord.UserID,
+		ShopID:          shared.ParseIDFromUint64(ord.ShopID),
+		Subtotal:        ord.Subtotal,
+		DiscountAmount:  ord.DiscountAmount,
+		ServiceCharge:   ord.ServiceCharge,
+		PackagingFee:    ord.PackagingFee,
+		DeliveryFee:     ord.DeliveryFee,
+		TaxAmount:       ord.TaxAmount,
+		TaxRate:         ord.TaxRate,
+		TaxInclusive:    ord.TaxInclusive,
+		TotalPrice:      ord.TotalPrice,
+		Takeaway:        ord.Takeaway,
+		FulfillmentType: ord.FulfillmentType,
+		RecipientName:   ord.RecipientName,
+		RecipientPhone:  ord.RecipientPhone,
+		DeliveryAddress: ord.DeliveryAddress,
+		ScheduledTime:   ord.ScheduledTime,
+		PickupNumber:    ord.PickupNumber,
+		TableID:         ord.TableID,
+		TableSessionID:  ord.TableSessionID,
+		PaymentStatus:   ord.PaymentStatus,
+		PaidAmount:      ord.PaidAmount,
+		RefundedAmount:  ord.RefundedAmount,
+		Status:          ord.Status,
+		Remark:          ord.Remark,
+		CreatedAt:       ord.CreatedAt,
+		UpdatedAt:       ord.UpdatedAt,
+		Items:           items,
+		Discounts:       toOrderDiscountResponses(ord.Discounts),
+	}, nil
+}
+
+// GetOrderTimeline 获取订单状态时间线，状态名称取自店铺的流转配置
+func (s *OrderService) GetOrderTimeline(id shared.ID, shopID shared.ID, flow order.OrderStatusFlow) (*dto.OrderTimelineResponse, error) {
+	ord, err := s.orderRepo.FindByIDAndShopID(id, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := s.orderStatusLogRepo.FindByOrderID(ord.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := make([]dto.OrderTimelineEntry, len(logs))
+	for i, l := range logs {
+		entry := dto.OrderTimelineEntry{
+			OldStatus:      l.OldStatus,
+			OldStatusLabel: flow.StatusLabel(l.OldStatus),
+			NewStatus:      l.NewStatus,
+			NewStatusLabel: flow.StatusLabel(l.NewStatus),
+			ActorType:      l.Actor.Type,
+			ActorID:        l.Actor.ID,
+			ActorName:      l.Actor.Name,
+			Reason:         l.Reason,
+			ChangedTime:    l.ChangedTime,
+		}
+		// 第一条记录为下单
+		if i == 0 && l.OldStatus == l.NewStatus {
+			entry.OldStatusLabel = ""
+			entry.Action = "下单"
+		} else if action, ok := flow.FindTransition(l.OldStatus, l.NewStatus); ok {
+			entry.Action = action.Name
+		}
+		timeline[i] = entry
+	}
+
+	return &dto.OrderTimelineResponse{
+		OrderID:     ord.ID,
+		Status:      ord.Status,
+		StatusLabel: flow.StatusLabel(ord.Status),
+		Timeline:    timeline,
 	}, nil
 }
 
@@ -230,16 +401,7 @@ func (s *OrderService) GetOrders(shopID shared.ID, page, pageSize int) (*dto.Ord
 
 	data := make([]dto.OrderResponse, len(orders))
 	for i, ord := range orders {
-		data[i] = dto.OrderResponse{
-			ID:         ord.ID,
-			UserID:     ord.UserID,
-			ShopID:     shared.ParseIDFromUint64(ord.ShopID),
-			TotalPrice: ord.TotalPrice,
-			Status:     ord.Status,
-			Remark:     ord.Remark,
-			CreatedAt:  ord.CreatedAt,
-			UpdatedAt:  ord.UpdatedAt,
-		}
+		data[i] = toOrderResponse(&ord)
 	}
 
 	return &dto.OrderListResponse{
@@ -258,16 +420,7 @@ func (s *OrderService) GetOrdersByUser(userID shared.ID, shopID shared.ID, page,
 
 	data := make([]dto.OrderResponse, len(orders))
 	for i, ord := range orders {
-		data[i] = dto.OrderResponse{
-			ID:         ord.ID,
-			UserID:     ord.UserID,
-			ShopID:     shared.ParseIDFromUint64(ord.ShopID),
-			TotalPrice: ord.TotalPrice,
-			Status:     ord.Status,
-			Remark:     ord.Remark,
-			CreatedAt:  ord.CreatedAt,
-			UpdatedAt:  ord.UpdatedAt,
-		}
+		data[i] = toOrderResponse(&ord)
 	}
 
 	return &dto.OrderListResponse{
@@ -278,24 +431,44 @@ func (s *OrderService) GetOrdersByUser(userID shared.ID, shopID shared.ID, page,
 	}, nil
 }
 
-func (s *OrderService) GetUnfinishedOrders(shopID shared.ID, flow order.OrderStatusFlow, page, pageSize int) (*dto.OrderListResponse, error) {
-	orders, total, err := s.orderRepo.FindUnfinishedByShopID(shopID.ToUint64(), flow, page, pageSize)
+// GetTableGuestOrders 查询扫码点餐顾客在所属用餐中下的订单，按下单时间排列
+func (s *OrderService) GetTableGuestOrders(userID shared.ID, shopID shared.ID, sessionID shared.ID, page, pageSize int) (*dto.OrderListResponse, error) {
+	orders, err := s.orderRepo.FindByTableSessionID(sessionID, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]dto.OrderResponse, 0, len(orders))
+	for i := range orders {
+		if orders[i].UserID == userID {
+			data = append(data, toOrderResponse(&orders[i]))
+		}
+	}
+	total := len(data)
+
+	// 一次用餐的订单不多，查出后再分页
+	size := max(pageSize, 0)
+	start := min(max(page-1, 0)*size, total)
+	end := min(start+size, total)
+
+	return &dto.OrderListResponse{
+		Total:    int64(total),
+		Page:     page,
+		PageSize: pageSize,
+		Data:     data[start:end],
+	}, nil
+}
+
+// GetUnfinishedOrders 查询未完成订单，可以按出餐时间排序和筛选，方便后厨按预约时间备餐
+func (s *OrderService) GetUnfinishedOrders(shopID shared.ID, flow order.OrderStatusFlow, filter order.UnfinishedFilter, page, pageSize int) (*dto.OrderListResponse, error) {
+	orders, total, err := s.orderRepo.FindUnfinishedByShopID(shopID.ToUint64(), flow, filter, page, pageSize)
 	if err != nil {
 		return nil, err
 	}
 
 	data := make([]dto.OrderResponse, len(orders))
 	for i, ord := range orders {
-		data[i] = dto.OrderResponse{
-			ID:         ord.ID,
-			UserID:     ord.UserID,
-			ShopID:     shared.ParseIDFromUint64(ord.ShopID),
-			TotalPrice: ord.TotalPrice,
-			Status:     ord.Status,
-			Remark:     ord.Remark,
-			CreatedAt:  ord.CreatedAt,
-			UpdatedAt:  ord.UpdatedAt,
-		}
+		data[i] = toOrderResponse(&ord)
 	}
 
 	return &dto.OrderListResponse{
@@ -326,23 +499,14 @@ func (s *OrderService) SearchOrders(req *dto.SearchOrdersRequest) (*dto.OrderLis
 		endTime = parsedTime
 	}
 
-	orders, total, err := s.orderRepo.Search(req.ShopID.ToUint64(), req.UserID, req.Statuses, startTime, endTime, req.Page, req.PageSize)
+	orders, total, err := s.orderRepo.Search(req.ShopID.ToUint64(), req.UserID, strings.ToUpper(strings.TrimSpace(req.PickupNumber)), req.Statuses, startTime, endTime, req.Page, req.PageSize)
 	if err != nil {
 		return nil, err
 	}
 
 	data := make([]dto.OrderResponse, len(orders))
 	for i, ord := range orders {
-		data[i] = dto.OrderResponse{
-			ID:         ord.ID,
-			UserID:     ord.UserID,
-			ShopID:     shared.ParseIDFromUint64(ord.ShopID),
-			TotalPrice: ord.TotalPrice,
-			Status:     ord.Status,
-			Remark:     ord.Remark,
-			CreatedAt:  ord.CreatedAt,
-			UpdatedAt:  ord.UpdatedAt,
-		}
+		data[i] = toOrderResponse(&ord)
 	}
 
 	return &dto.OrderListResponse{
@@ -353,8 +517,10 @@ func (s *OrderService) SearchOrders(req *dto.SearchOrdersRequest) (*dto.OrderLis
 	}, nil
 }
 
-func (s *OrderService) UpdateOrderStatus(id shared.ID, shopID shared.ID, newStatus order.OrderStatus, flow order.OrderStatusFlow) error {
-	ord, err := s.orderRepo.FindByID(id)
+// UpdateOrderStatus 按店铺流转配置变更订单状态，并记录操作人和原因
+// 订单进入归还库存的状态（如取消、拒单）时，在同一事务内归还库存
+func (s *OrderService) UpdateOrderStatus(id shared.ID, shopID shared.ID, newStatus order.OrderStatus, flow order.OrderStatusFlow, actor order.StatusActor, reason string) error {
+	ord, err := s.orderRepo.FindByIDAndShopID(id, shopID.ToUint64())
 	if err != nil {
 		return err
 	}
@@ -364,127 +530,127 @@ func (s *OrderService) UpdateOrderStatus(id shared.ID, shopID shared.ID, newStat
 	}
 
 	oldStatus := ord.Status
-	if err := ord.TransitionTo(newStatus, flow); err != nil {
+	if err := ord.TransitionTo(newStatus, flow, reason); err != nil {
 		return err
 	}
 
-	tx := s.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	var stock stockChanges
+	err = WithTx(s.db, func(tx *gorm.DB) error {
+		// 以原状态为条件更新，并发变更同一订单时只有一个成功，库存不会被重复归还
+		result := tx.Model(&models.Order{}).
+			Where("id = ? AND shop_id = ? AND status = ?", ord.ID.Value(), ord.ShopID, int(oldStatus)).
+			Updates(map[string]interface{}{"status": int(newStatus), "updated_at": ord.UpdatedAt})
+		if result.Error != nil {
+			log2.Errorf("更新订单状态失败: %v", result.Error)
+			return errors.New("更新订单状态失败")
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("订单状态已变更，请刷新后重试")
 		}
-	}()
 
-	if err := s.orderRepo.Update(ord); err != nil {
-		tx.Rollback()
-		return errors.New("更新订单状态失败")
-	}
+		statusLog := &order.OrderStatusLog{
+			OrderID:     ord.ID,
+			OldStatus:   oldStatus,
+			NewStatus:   newStatus,
+			Actor:       actor,
+			Reason:      strings.TrimSpace(reason),
+			ChangedTime: time.Now(),
+		}
+		if err := newOrderTxRepos(tx).logs.Save(statusLog); err != nil {
+			return errors.New("记录状态变更失败")
+		}
 
-	statusLog := &order.OrderStatusLog{
-		OrderID:     ord.ID,
-		OldStatus:   oldStatus,
-		NewStatus:   newStatus,
-		ChangedTime: time.Now(),
-	}
-	if err := s.orderStatusLogRepo.Save(statusLog); err != nil {
-		tx.Rollback()
-		return errors.New("记录状态变更失败")
+		if !flow.ReleasesStock(oldStatus) && flow.ReleasesStock(newStatus) {
+			if err := stock.adjustOrder(tx, ord, stockDeltas(ord.ItemQuantities(), nil), AuditActor(actor), reason); err != nil {
+				return err
+			}
+			if err := releaseOrderPromotions(tx, ord.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	tx.Commit()
+	s.publishEvent(order.OrderEventStatusChanged, ord, oldStatus)
+	stock.publish(s.stockEventPublisher)
 
 	return nil
 }
 
-func (s *OrderService) DeleteOrder(id shared.ID, shopID shared.ID) error {
-	ord, err := s.orderRepo.FindByID(id)
+// DeleteOrder 删除订单，未结束的订单在同一事务内归还库存
+// 已支付或已退款的订单不能删除，售后通过退款处理
+func (s *OrderService) DeleteOrder(id shared.ID, shopID shared.ID, flow order.OrderStatusFlow, actor order.StatusActor) error {
+	ord, err := s.orderRepo.FindByIDAndShopID(id, shopID.ToUint64())
 	if err != nil {
 		return err
 	}
 
-	tx := s.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	if err := ord.CheckDeletable(); err != nil {
+		return err
+	}
+
+	var stock stockChanges
+	err = WithTx(s.db, func(tx *gorm.DB) error {
+		repos := newOrderTxRepos(tx)
+
+		if err := deleteOrderItems(repos, id, ord.Items); err != nil {
+			return err
 		}
-	}()
 
-	if err := s.orderItemOptionRepo.DeleteByOrderItemID(id); err != nil {
-		tx.Rollback()
-		return errors.New("删除订单项选项失败")
-	}
+		if err := repos.discounts.DeleteByOrderID(id); err != nil {
+			return errors.New("删除订单优惠失败")
+		}
 
-	if err := s.orderItemRepo.DeleteByOrderID(id); err != nil {
-		tx.Rollback()
-		return errors.New("删除订单项失败")
-	}
+		if err := repos.logs.DeleteByOrderID(id); err != nil {
+			return errors.New("删除订单状态日志失败")
+		}
 
-	if err := s.orderStatusLogRepo.DeleteByOrderID(id); err != nil {
-		tx.Rollback()
-		return errors.New("删除订单状态日志失败")
-	}
+		// 订单不存在时（已被并发删除）整个事务回滚，库存不会被重复归还
+		if err := repos.orders.Delete(id, ord.ShopID); err != nil {
+			return err
+		}
 
-	if err := s.orderRepo.Delete(id); err != nil {
-		tx.Rollback()
-		return errors.New("删除订单失败")
+		if !flow.IsFinalStatus(ord.Status) {
+			if err := stock.adjustOrder(tx, ord, stockDeltas(ord.ItemQuantities(), nil), AuditActor(actor), "删除订单"); err != nil {
+				return err
+			}
+			if err := releaseOrderPromotions(tx, ord.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	tx.Commit()
-
 	log2.Infof("订单删除成功: %+v", ord)
+	s.publishEvent(order.OrderEventDeleted, ord, ord.Status)
+	stock.publish(s.stockEventPublisher)
 
 	return nil
 }
 
 func (s *OrderService) AdvanceSearchOrders(req *dto.AdvanceSearchOrderRequest) (*dto.OrderListResponse, error) {
-	query := s.db.Model(&order.Order{}).Where("shop_id = ?", req.ShopID.ToUint64())
-
-	// 添加用户ID筛选
-	if req.UserID != "" {
-		query = query.Where("user_id = ?", req.UserID)
-	}
-
-	// 添加状态筛选（支持多个状态）
-	if len(req.Status) > 0 {
-		query = query.Where("status IN ?", req.Status)
-	}
-
-	// 添加时间范围筛选
-	if req.StartTime != "" {
-		query = query.Where("created_at >= ?", req.StartTime)
-	}
-	if req.EndTime != "" {
-		query = query.Where("created_at <= ?", req.EndTime)
-	}
-
-	// 获取总数
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, errors.New("获取订单总数失败")
-	}
-
-	// 分页查询
-	offset := (req.Page - 1) * req.PageSize
-	var orders []order.Order
-	if err := query.Offset(offset).Limit(req.PageSize).
-		Order("created_at DESC").
-		Find(&orders).Error; err != nil {
-		return nil, errors.New("查询订单列表失败")
+	orders, total, err := s.orderRepo.AdvanceSearch(req.ShopID.ToUint64(), order.AdvanceSearchFilter{
+		UserID:           req.UserID,
+		Statuses:         req.Status,
+		FulfillmentTypes: req.FulfillmentType,
+		Recipient:        req.Recipient,
+		StartTime:        req.StartTime,
+		EndTime:          req.EndTime,
+	}, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
 	}
 
 	// 转换为响应格式
 	data := make([]dto.OrderResponse, len(orders))
 	for i, ord := range orders {
-		data[i] = dto.OrderResponse{
-			ID:         ord.ID,
-			UserID:     ord.UserID,
-			ShopID:     shared.ParseIDFromUint64(uint64(ord.ShopID)),
-			TotalPrice: ord.TotalPrice,
-			Status:     ord.Status,
-			Remark:     ord.Remark,
-			CreatedAt:  ord.CreatedAt,
-			UpdatedAt:  ord.UpdatedAt,
-		}
+		data[i] = toOrderResponse(&ord)
 	}
 
 	return &dto.OrderListResponse{
@@ -495,110 +661,180 @@ func (s *OrderService) AdvanceSearchOrders(req *dto.AdvanceSearchOrderRequest) (
 	}, nil
 }
 
-// UpdateOrder 更新订单
-func (s *OrderService) UpdateOrder(req *dto.UpdateOrderRequest) (*dto.OrderDetailResponse, error) {
-	ord, err := s.orderRepo.FindByID(req.ID)
+// UpdateOrder 修改订单，按新旧订单项的数量差在同一事务内调整库存
+func (s *OrderService) UpdateOrder(req *dto.UpdateOrderRequest, flow order.OrderStatusFlow) (*dto.OrderDetailResponse, error) {
+	ord, err := s.orderRepo.FindByIDAndShopID(req.ID, req.ShopID.ToUint64())
 	if err != nil {
 		return nil, err
 	}
-
-	// 开启事务
-	tx := s.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// 删除原有的订单项和选项
-	if err := s.orderItemOptionRepo.DeleteByOrderItemID(req.ID); err != nil {
-		tx.Rollback()
-		return nil, errors.New("删除原有订单项选项失败")
+	if err := ord.CheckEditable(flow); err != nil {
+		return nil, err
 	}
 
-	if err := s.orderItemRepo.DeleteByOrderID(req.ID); err != nil {
-		tx.Rollback()
-		return nil, errors.New("删除原有订单项失败")
+	oldStatus := ord.Status
+	oldItems := ord.Items
+	oldDiscounts := ord.Discounts
+	oldTotal := ord.TotalPrice
+
+	// 已占用的库存：归还库存的状态（取消、拒单）不再占用
+	var oldReserved, newReserved map[shared.ID]int
+	if !flow.ReleasesStock(oldStatus) {
+		oldReserved = ord.ItemQuantities()
 	}
 
-	totalPrice := float64(0.0)
+	totalPrice := shared.Price(0)
 
-	// 创建新的订单项
+	// 构建新的订单项，选项按商品的参数类别规则校验
+	finder := NewProductFinderAdapter(s.productRepo, s.productOptionRepo, s.productOptionCategoryRepo, ord.ShopID)
+	items := make([]order.OrderItem, 0, len(req.Items))
 	for _, itemReq := range req.Items {
-		prod, err := s.productRepo.FindByID(itemReq.ProductID)
+		prod, err := finder.FindProduct(itemReq.ProductID)
 		if err != nil {
-			tx.Rollback()
 			return nil, errors.New("商品不存在")
 		}
 
-		orderItem := &order.OrderItem{
+		orderItem := order.OrderItem{
 			OrderID:   ord.ID,
 			ProductID: itemReq.ProductID,
 			Quantity:  itemReq.Quantity,
-			Price:     prod.Price,
 		}
-
-		// 处理选中的选项
-		var options []order.OrderItemOption
-		itemTotalPrice := float64(orderItem.Quantity) * prod.Price.ToFloat64()
-
 		for _, optionReq := range itemReq.Options {
-			// 获取参数选项信息
-			opt, err := s.productOptionRepo.FindByID(optionReq.OptionID)
-			if err != nil {
-				tx.Rollback()
-				return nil, errors.New("商品参数选项不存在")
-			}
-
-			// 获取参数类别信息
-			cat, err := s.productOptionCategoryRepo.FindByID(optionReq.CategoryID)
-			if err != nil {
-				tx.Rollback()
-				return nil, errors.New("商品参数类别不存在")
-			}
-
-			// 保存参数选项快照
-			options = append(options, order.OrderItemOption{
-				OrderItemID:     orderItem.ID,
-				OptionID:        optionReq.OptionID,
-				CategoryID:      optionReq.CategoryID,
-				OptionName:      opt.Name,
-				CategoryName:    cat.Name,
-				PriceAdjustment: opt.PriceAdjustment,
+			orderItem.Options = append(orderItem.Options, order.OrderItemOption{
+				OptionID:   optionReq.OptionID,
+				CategoryID: optionReq.CategoryID,
 			})
-			itemTotalPrice += float64(orderItem.Quantity) * opt.PriceAdjustment
 		}
 
-		// 设置订单项总价
-		orderItem.Options = options
-		orderItem.TotalPrice = shared.Price(itemTotalPrice)
+		orderItem.SetProductSnapshot(prod)
+		if err := orderItem.ApplyOptionRules(prod); err != nil {
+			return nil, err
+		}
+		itemTotalPrice, err := orderItem.CalculatePrice(finder)
+		if err != nil {
+			return nil, err
+		}
 
-		if err := s.orderItemRepo.Save(orderItem); err != nil {
-			tx.Rollback()
-			return nil, errors.New("创建新订单项失败")
+		items = append(items, orderItem)
+		totalPrice = totalPrice.Add(itemTotalPrice)
+	}
+
+	// 修改状态时与 UpdateOrderStatus 一样按流转配置校验，需填写原因的动作必须提供原因
+	if req.Status != oldStatus {
+		if err := ord.TransitionTo(req.Status, flow, req.Reason); err != nil {
+			return nil, err
 		}
-		totalPrice += itemTotalPrice
 	}
 
 	// 更新订单信息
 	ord.ShopID = req.ShopID.ToUint64()
 	ord.Remark = req.Remark
-	ord.Status = req.Status
-	ord.TotalPrice = shared.Price(totalPrice)
+	ord.TotalPrice = totalPrice
+	ord.Items = items
+	if req.Takeaway != nil {
+		ord.Takeaway = *req.Takeaway
+	}
 
-	if err := s.orderRepo.Update(ord); err != nil {
-		tx.Rollback()
-		return nil, errors.New("更新订单信息失败")
+	if !flow.ReleasesStock(ord.Status) {
+		newReserved = ord.ItemQuantities()
 	}
 
-	tx.Commit()
+	var stock stockChanges
+	err = WithTx(s.db, func(tx *gorm.DB) error {
+		repos := newOrderTxRepos(tx)
+
+		// 删除原有的订单项和选项
+		if err := deleteOrderItems(repos, ord.ID, oldItems); err != nil {
+			return err
+		}
+
+		// 创建新的订单项
+		if err := saveOrderItems(repos, ord.ID, ord.Items); err != nil {
+			return err
+		}
+
+		// 按新的订单项重新计算已享受的优惠和各项费用
+		if err := recalculateOrderDiscounts(tx, ord, oldDiscounts); err != nil {
+			return err
+		}
+		if _, err := applyShopCharges(tx, ord); err != nil {
+			return err
+		}
+		if err := repos.discounts.DeleteByOrderID(ord.ID); err != nil {
+			return errors.New("删除订单优惠失败")
+		}
+		if err := saveOrderDiscounts(repos, ord.ID, ord.Discounts); err != nil {
+			return err
+		}
+
+		// 已支付的订单金额不能再变化；未支付的订单金额变化后，按原金额发起的支付单作废
+		if ord.TotalPrice != oldTotal {
+			if ord.PaymentStatus.IsPaid() {
+				return errors.New("订单已支付，不能修改订单金额")
+			}
+			if err := repositories.NewPaymentRepository(tx).CloseByOrderID(ord.ID, req.ShopID); err != nil {
+				return err
+			}
+		}
+
+		// 订单直接改为取消、拒单等状态时归还优惠使用次数
+		if !flow.ReleasesStock(oldStatus) && flow.ReleasesStock(ord.Status) {
+			if err := releaseOrderPromotions(tx, ord.ID); err != nil {
+				return err
+			}
+		}
+
+		// 修改状态时以原状态为条件加锁，并发变更同一订单时只有一个成功，库存不会被重复归还
+		if ord.Status != oldStatus {
+			result := tx.Model(&models.Order{}).
+				Where("id = ? AND shop_id = ? AND status = ?", ord.ID.Value(), ord.ShopID, int(oldStatus)).
+				Update("status", int(ord.Status))
+			if result.Error != nil {
+				log2.Errorf("更新订单状态失败: %v", result.Error)
+				return errors.New("更新订单状态失败")
+			}
+			if result.RowsAffected == 0 {
+				return errors.New("订单状态已变更，请刷新后重试")
+			}
+		}
+
+		if err := repos.orders.Update(ord); err != nil {
+			return errors.New("更新订单信息失败")
+		}
+
+		// 修改订单状态时同样记录状态变更
+		if ord.Status != oldStatus {
+			statusLog := &order.OrderStatusLog{
+				OrderID:     ord.ID,
+				OldStatus:   oldStatus,
+				NewStatus:   ord.Status,
+				Actor:       req.Actor,
+				Reason:      strings.TrimSpace(req.Reason),
+				ChangedTime: time.Now(),
+			}
+			if err := repos.logs.Save(statusLog); err != nil {
+				return errors.New("记录状态变更失败")
+			}
+		}
+
+		// 只调整数量差：增加的部分条件扣减，减少的部分归还
+		return stock.adjustOrder(tx, ord, stockDeltas(oldReserved, newReserved), AuditActor(req.Actor), "修改订单")
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	// 重新获取更新后的订单信息
-	ord, err = s.orderRepo.FindByID(req.ID)
+	ord, err = s.orderRepo.FindByIDAndShopID(req.ID, ord.ShopID)
 	if err != nil {
 		return nil, errors.New("获取更新后的订单信息失败")
 	}
 
+	s.publishEvent(order.OrderEventUpdated, ord, oldStatus)
+	if ord.Status != oldStatus {
+		s.publishEvent(order.OrderEventStatusChanged, ord, oldStatus)
+	}
+	stock.publish(s.stockEventPublisher)
+
 	return s.toOrderDetailResponse(ord), nil
 }
 
@@ -627,14 +863,83 @@ func (s *OrderService) toOrderDetailResponse(ord *order.Order) *dto.OrderDetailR
 	}
 
 	return &dto.OrderDetailResponse{
-		ID:         ord.ID,
-		UserID:     ord.UserID,
-		ShopID:     shared.ParseIDFromUint64(ord.ShopID),
-		TotalPrice: ord.TotalPrice,
-		Status:     ord.Status,
-		Remark:     ord.Remark,
-		CreatedAt:  ord.CreatedAt,
-		UpdatedAt:  ord.UpdatedAt,
-		Items:      items,
+		ID:              ord.ID,
+		UserID:          ord.UserID,
+		ShopID:          shared.ParseIDFromUint64(ord.ShopID),
+		Subtotal:        ord.Subtotal,
+		DiscountAmount:  ord.DiscountAmount,
+		ServiceCharge:   ord.ServiceCharge,
+		PackagingFee:    ord.PackagingFee,
+		DeliveryFee:     ord.DeliveryFee,
+		TaxAmount:       ord.TaxAmount,
+		TaxRate:         ord.TaxRate,
+		TaxInclusive:    ord.TaxInclusive,
+		TotalPrice:      ord.TotalPrice,
+		Takeaway:        ord.Takeaway,
+		FulfillmentType: ord.FulfillmentType,
+		RecipientName:   ord.RecipientName,
+		RecipientPhone:  ord.RecipientPhone,
+		DeliveryAddress: ord.DeliveryAddress,
+		ScheduledTime:   ord.ScheduledTime,
+		PickupNumber:    ord.PickupNumber,
+		TableID:         ord.TableID,
+		TableSessionID:  ord.TableSessionID,
+		PaymentStatus:   ord.PaymentStatus,
+		PaidAmount:      ord.PaidAmount,
+		RefundedAmount:  ord.RefundedAmount,
+		Status:          ord.Status,
+		Remark:          ord.Remark,
+		CreatedAt:       ord.CreatedAt,
+		UpdatedAt:       ord.UpdatedAt,
+		Items:           items,
+		Discounts:       toOrderDiscountResponses(ord.Discounts),
+	}
+}
+
+// toOrderResponse 转换为订单列表项响应
+func toOrderResponse(ord *order.Order) dto.OrderResponse {
+	return dto.OrderResponse{
+		ID:              ord.ID,
+		UserID:          ord.UserID,
+		ShopID:          shared.ParseIDFromUint64(ord.ShopID),
+		Subtotal:        ord.Subtotal,
+		DiscountAmount:  ord.DiscountAmount,
+		ServiceCharge:   ord.ServiceCharge,
+		PackagingFee:    ord.PackagingFee,
+		DeliveryFee:     ord.DeliveryFee,
+		TaxAmount:       ord.TaxAmount,
+		TaxRate:         ord.TaxRate,
+		TaxInclusive:    ord.TaxInclusive,
+		TotalPrice:      ord.TotalPrice,
+		Takeaway:        ord.Takeaway,
+		FulfillmentType: ord.FulfillmentType,
+		RecipientName:   ord.RecipientName,
+		RecipientPhone:  ord.RecipientPhone,
+		DeliveryAddress: ord.DeliveryAddress,
+		ScheduledTime:   ord.ScheduledTime,
+		PickupNumber:    ord.PickupNumber,
+		TableID:         ord.TableID,
+		TableSessionID:  ord.TableSessionID,
+		PaymentStatus:   ord.PaymentStatus,
+		PaidAmount:      ord.PaidAmount,
+		RefundedAmount:  ord.RefundedAmount,
+		Status:          ord.Status,
+		Remark:          ord.Remark,
+		CreatedAt:       ord.CreatedAt,
+		UpdatedAt:       ord.UpdatedAt,
+	}
+}
+
+func toOrderDiscountResponses(discounts []order.OrderDiscount) []dto.OrderDiscountResponse {
+	result := make([]dto.OrderDiscountResponse, len(discounts))
+	for i, discount := range discounts {
+		result[i] = dto.OrderDiscountResponse{
+			PromotionID: discount.PromotionID,
+			Name:        discount.Name,
+			Code:        discount.Code,
+			Type:        discount.Type,
+			Amount:      discount.Amount,
+		}
 	}
+	return result
 }
@@ -23,14 +23,6 @@ func (m *MockOrderRepository) Save(ord *order.Order) error {
 	return args.Error(0)
 }
 
-func (m *MockOrderRepository) FindByID(id shared.ID) (*order.Order, error) {
-	args := m.Called(id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*order.Order), args.Error(1)
-}
-
 func (m *MockOrderRepository) FindByIDAndShopID(id shared.ID, shopID uint64) (*order.Order, error) {
 	args := m.Called(id, shopID)
 	if args.Get(0) == nil {
@@ -55,24 +47,48 @@ func (m *MockOrderRepository) FindByUserID(userID shared.ID, shopID uint64, page
 	return args.Get(0).([]order.Order), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockOrderRepository) FindUnfinishedByShopID(shopID uint64, flow order.OrderStatusFlow, page, pageSize int) ([]order.Order, int64, error) {
-	args := m.Called(shopID, flow, page, pageSize)
+func (m *MockOrderRepository) FindUnfinishedByShopID(shopID uint64, flow order.OrderStatusFlow, filter order.UnfinishedFilter, page, pageSize int) ([]order.Order, int64, error) {
+	args := m.Called(shopID, flow, filter, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]order.Order), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockOrderRepository) Search(shopID uint64, userID, pickupNumber string, statuses []order.OrderStatus, startTime, endTime time.Time, page, pageSize int) ([]order.Order, int64, error) {
+	args := m.Called(shopID, userID, pickupNumber, statuses, startTime, endTime, page, pageSize)
 	if args.Get(0) == nil {
 		return nil, args.Get(1).(int64), args.Error(2)
 	}
 	return args.Get(0).([]order.Order), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockOrderRepository) Search(shopID uint64, userID string, statuses []order.OrderStatus, startTime, endTime time.Time, page, pageSize int) ([]order.Order, int64, error) {
-	args := m.Called(shopID, userID, statuses, startTime, endTime, page, pageSize)
+func (m *MockOrderRepository) AdvanceSearch(shopID uint64, filter order.AdvanceSearchFilter, page, pageSize int) ([]order.Order, int64, error) {
+	args := m.Called(shopID, filter, page, pageSize)
 	if args.Get(0) == nil {
 		return nil, args.Get(1).(int64), args.Error(2)
 	}
 	return args.Get(0).([]order.Order), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockOrderRepository) Delete(id shared.ID) error {
-	args := m.Called(id)
+func (m *MockOrderRepository) FindReadyForPickup(shopID uint64, businessDate string, statuses []order.OrderStatus) ([]order.Order, error) {
+	args := m.Called(shopID, businessDate, statuses)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]order.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) FindByTableSessionID(sessionID shared.ID, shopID uint64) ([]order.Order, error) {
+	args := m.Called(sessionID, shopID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]order.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) Delete(id shared.ID, shopID uint64) error {
+	args := m.Called(id, shopID)
 	return args.Error(0)
 }
 
@@ -169,6 +185,8 @@ func TestNewOrderService(t *testing.T) {
 		mockOrderItemRepo,
 		mockOrderItemOptionRepo,
 		mockOrderStatusLogRepo,
+		nil,
+		nil,
 	)
 
 	assert.NotNil(t, service)
@@ -192,6 +210,8 @@ func TestOrderService_buildOrderItems(t *testing.T) {
 		mockOrderItemRepo,
 		mockOrderItemOptionRepo,
 		mockOrderStatusLogRepo,
+		nil,
+		nil,
 	)
 
 	tests := []struct {
@@ -206,7 +226,7 @@ func TestOrderService_buildOrderItems(t *testing.T) {
 				{
 					ProductID: shared.ID(1),
 					Quantity:  2,
-					Price:     100,
+					Price:     shared.NewPrice(100),
 					Options:   []dto.CreateOrderItemOption{},
 				},
 			},
@@ -214,7 +234,7 @@ func TestOrderService_buildOrderItems(t *testing.T) {
 			validate: func(t *testing.T, items []order.OrderItem) {
 				assert.Equal(t, shared.ID(1), items[0].ProductID)
 				assert.Equal(t, 2, items[0].Quantity)
-				assert.Equal(t, shared.Price(100), items[0].Price)
+				assert.Equal(t, shared.NewPrice(100), items[0].Price)
 				assert.Empty(t, items[0].Options)
 			},
 		},
@@ -224,7 +244,7 @@ func TestOrderService_buildOrderItems(t *testing.T) {
 				{
 					ProductID: shared.ID(2),
 					Quantity:  1,
-					Price:     50,
+					Price:     shared.NewPrice(50),
 					Options: []dto.CreateOrderItemOption{
 						{CategoryID: shared.ID(10), OptionID: shared.ID(20)},
 						{CategoryID: shared.ID(11), OptionID: shared.ID(21)},
@@ -242,8 +262,8 @@ func TestOrderService_buildOrderItems(t *testing.T) {
 		{
 			name: "multiple items",
 			reqItems: []dto.CreateOrderItemRequest{
-				{ProductID: shared.ID(1), Quantity: 2, Price: 100, Options: []dto.CreateOrderItemOption{}},
-				{ProductID: shared.ID(2), Quantity: 1, Price: 50, Options: []dto.CreateOrderItemOption{}},
+				{ProductID: shared.ID(1), Quantity: 2, Price: shared.NewPrice(100), Options: []dto.CreateOrderItemOption{}},
+				{ProductID: shared.ID(2), Quantity: 1, Price: shared.NewPrice(50), Options: []dto.CreateOrderItemOption{}},
 			},
 			wantLen: 2,
 			validate: func(t *testing.T, items []order.OrderItem) {
@@ -282,6 +302,8 @@ func TestOrderService_GetOrder(t *testing.T) {
 		mockOrderItemRepo,
 		mockOrderItemOptionRepo,
 		mockOrderStatusLogRepo,
+		nil,
+		nil,
 	)
 
 	tests := []struct {
@@ -302,7 +324,7 @@ func TestOrderService_GetOrder(t *testing.T) {
 					ID:         shared.ID(123),
 					UserID:     shared.ID(789),
 					ShopID:     456,
-					TotalPrice: shared.Price(200),
+					TotalPrice: shared.NewPrice(200),
 					Status:     order.OrderStatusPending,
 					Remark:     "测试订单",
 					Items: []order.OrderItem{
@@ -310,8 +332,8 @@ func TestOrderService_GetOrder(t *testing.T) {
 							ID:          shared.ID(1),
 							ProductID:   shared.ID(10),
 							Quantity:    2,
-							Price:       shared.Price(100),
-							TotalPrice:  shared.Price(200),
+							Price:       shared.NewPrice(100),
+							TotalPrice:  shared.NewPrice(200),
 							ProductName: "商品1",
 							Options: []order.OrderItemOption{
 								{
@@ -332,7 +354,7 @@ func TestOrderService_GetOrder(t *testing.T) {
 				assert.Equal(t, shared.ID(123), resp.ID)
 				assert.Equal(t, shared.ID(789), resp.UserID)
 				assert.Equal(t, shared.ID(456), resp.ShopID)
-				assert.Equal(t, shared.Price(200), resp.TotalPrice)
+				assert.Equal(t, shared.NewPrice(200), resp.TotalPrice)
 				assert.Equal(t, order.OrderStatusPending, resp.Status)
 				assert.Len(t, resp.Items, 1)
 				assert.Equal(t, "商品1", resp.Items[0].ProductName)
@@ -375,6 +397,161 @@ func TestOrderService_GetOrder(t *testing.T) {
 	}
 }
 
+func TestOrderService_CrossShopAccess(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+
+	service := NewOrderService(
+		nil,
+		new(MockProductRepository),
+		new(MockProductOptionRepository),
+		new(MockProductOptionCategoryRepository),
+		mockOrderRepo,
+		new(MockOrderItemRepository),
+		new(MockOrderItemOptionRepository),
+		new(MockOrderStatusLogRepository),
+		nil,
+		nil,
+	)
+
+	orderID := shared.ID(123)
+	otherShopID := shared.ID(999)
+
+	// 订单属于店铺 456，按其他店铺查询时仓储层返回不存在
+	mockOrderRepo.On("FindByIDAndShopID", orderID, otherShopID.ToUint64()).Return(nil, errors.New("订单不存在"))
+
+	_, err := service.GetOrder(orderID, otherShopID)
+	assert.EqualError(t, err, "订单不存在")
+
+	err = service.DeleteOrder(orderID, otherShopID, order.OrderStatusFlow{}, order.StatusActor{})
+	assert.EqualError(t, err, "订单不存在")
+
+	err = service.UpdateOrderStatus(orderID, otherShopID, order.OrderStatusAccepted, order.OrderStatusFlow{}, order.StatusActor{}, "")
+	assert.EqualError(t, err, "订单不存在")
+
+	mockOrderRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	mockOrderRepo.AssertNotCalled(t, "Update", mock.Anything)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+func TestOrderService_UpdateOrderStatus_RequiresReason(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockOrderStatusLogRepo := new(MockOrderStatusLogRepository)
+
+	service := NewOrderService(
+		nil,
+		new(MockProductRepository),
+		new(MockProductOptionRepository),
+		new(MockProductOptionCategoryRepository),
+		mockOrderRepo,
+		new(MockOrderItemRepository),
+		new(MockOrderItemOptionRepository),
+		mockOrderStatusLogRepo,
+		nil,
+		nil,
+	)
+
+	orderID := shared.ID(123)
+	shopID := shared.ID(456)
+	flow := order.OrderStatusFlow{
+		Statuses: []order.OrderStatusConfig{
+			{
+				Value: order.OrderStatusPending,
+				Actions: []order.OrderStatusTransition{
+					{Name: "取消", NextStatus: order.OrderStatusCanceled, RequireReason: true},
+				},
+			},
+			{Value: order.OrderStatusCanceled, IsFinal: true},
+		},
+	}
+
+	mockOrderRepo.On("FindByIDAndShopID", orderID, shopID.ToUint64()).Return(&order.Order{
+		ID:     orderID,
+		ShopID: shopID.ToUint64(),
+		Status: order.OrderStatusPending,
+	}, nil)
+
+	actor := order.StatusActor{Type: PrincipalStaff, ID: 7, Name: "cashier01"}
+	err := service.UpdateOrderStatus(orderID, shopID, order.OrderStatusCanceled, flow, actor, "")
+	assert.EqualError(t, err, "取消订单必须填写原因")
+
+	mockOrderRepo.AssertNotCalled(t, "Update", mock.Anything)
+	mockOrderStatusLogRepo.AssertNotCalled(t, "Save", mock.Anything)
+}
+
+func TestOrderService_GetOrderTimeline(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockOrderStatusLogRepo := new(MockOrderStatusLogRepository)
+
+	service := NewOrderService(
+		nil,
+		new(MockProductRepository),
+		new(MockProductOptionRepository),
+		new(MockProductOptionCategoryRepository),
+		mockOrderRepo,
+		new(MockOrderItemRepository),
+		new(MockOrderItemOptionRepository),
+		mockOrderStatusLogRepo,
+		nil,
+		nil,
+	)
+
+	orderID := shared.ID(123)
+	shopID := shared.ID(456)
+	flow := order.OrderStatusFlow{
+		Statuses: []order.OrderStatusConfig{
+			{
+				Value: order.OrderStatusPending,
+				Label: "待处理",
+				Actions: []order.OrderStatusTransition{
+					{Name: "取消", NextStatus: order.OrderStatusCanceled, RequireReason: true},
+				},
+			},
+			{Value: order.OrderStatusCanceled, Label: "已取消", IsFinal: true},
+		},
+	}
+	now := time.Now()
+
+	mockOrderRepo.On("FindByIDAndShopID", orderID, shopID.ToUint64()).Return(&order.Order{
+		ID:     orderID,
+		ShopID: shopID.ToUint64(),
+		Status: order.OrderStatusCanceled,
+	}, nil)
+	mockOrderStatusLogRepo.On("FindByOrderID", orderID).Return([]order.OrderStatusLog{
+		{
+			OrderID:     orderID,
+			OldStatus:   order.OrderStatusPending,
+			NewStatus:   order.OrderStatusPending,
+			Actor:       order.StatusActor{Type: PrincipalUser, ID: 9001, Name: "顾客"},
+			ChangedTime: now,
+		},
+		{
+			OrderID:     orderID,
+			OldStatus:   order.OrderStatusPending,
+			NewStatus:   order.OrderStatusCanceled,
+			Actor:       order.StatusActor{Type: PrincipalStaff, ID: 7, Name: "cashier01"},
+			Reason:      "商品售罄",
+			ChangedTime: now.Add(time.Minute),
+		},
+	}, nil)
+
+	response, err := service.GetOrderTimeline(orderID, shopID, flow)
+	assert.NoError(t, err)
+	assert.Equal(t, "已取消", response.StatusLabel)
+	assert.Len(t, response.Timeline, 2)
+
+	created := response.Timeline[0]
+	assert.Equal(t, "下单", created.Action)
+	assert.Equal(t, "待处理", created.NewStatusLabel)
+	assert.Equal(t, PrincipalUser, created.ActorType)
+
+	canceled := response.Timeline[1]
+	assert.Equal(t, "取消", canceled.Action)
+	assert.Equal(t, "待处理", canceled.OldStatusLabel)
+	assert.Equal(t, "已取消", canceled.NewStatusLabel)
+	assert.Equal(t, "cashier01", canceled.ActorName)
+	assert.Equal(t, "商品售罄", canceled.Reason)
+}
+
 func TestOrderService_GetOrders(t *testing.T) {
 	mockProductRepo := new(MockProductRepository)
 	mockProductOptionRepo := new(MockProductOptionRepository)
@@ -393,6 +570,8 @@ func TestOrderService_GetOrders(t *testing.T) {
 		mockOrderItemRepo,
 		mockOrderItemOptionRepo,
 		mockOrderStatusLogRepo,
+		nil,
+		nil,
 	)
 
 	tests := []struct {
@@ -411,8 +590,8 @@ func TestOrderService_GetOrders(t *testing.T) {
 			pageSize: 10,
 			setupMock: func(shopID shared.ID, page, pageSize int) {
 				orders := []order.Order{
-					{ID: shared.ID(1), UserID: shared.ID(10), ShopID: 456, TotalPrice: shared.Price(100), Status: order.OrderStatusPending},
-					{ID: shared.ID(2), UserID: shared.ID(11), ShopID: 456, TotalPrice: shared.Price(200), Status: order.OrderStatusAccepted},
+					{ID: shared.ID(1), UserID: shared.ID(10), ShopID: 456, TotalPrice: shared.NewPrice(100), Status: order.OrderStatusPending},
+					{ID: shared.ID(2), UserID: shared.ID(11), ShopID: 456, TotalPrice: shared.NewPrice(200), Status: order.OrderStatusAccepted},
 				}
 				mockOrderRepo.On("FindByShopID", shopID.ToUint64(), page, pageSize).Return(orders, int64(2), nil)
 			},
@@ -482,6 +661,8 @@ func TestOrderService_GetOrdersByUser(t *testing.T) {
 		mockOrderItemRepo,
 		mockOrderItemOptionRepo,
 		mockOrderStatusLogRepo,
+		nil,
+		nil,
 	)
 
 	tests := []struct {
@@ -502,7 +683,7 @@ func TestOrderService_GetOrdersByUser(t *testing.T) {
 			pageSize: 10,
 			setupMock: func(userID, shopID shared.ID, page, pageSize int) {
 				orders := []order.Order{
-					{ID: shared.ID(1), UserID: shared.ID(100), ShopID: 456, TotalPrice: shared.Price(100), Status: order.OrderStatusPending},
+					{ID: shared.ID(1), UserID: shared.ID(100), ShopID: 456, TotalPrice: shared.NewPrice(100), Status: order.OrderStatusPending},
 				}
 				mockOrderRepo.On("FindByUserID", userID, shopID.ToUint64(), page, pageSize).Return(orders, int64(1), nil)
 			},
@@ -555,6 +736,8 @@ func TestOrderService_GetUnfinishedOrders(t *testing.T) {
 		mockOrderItemRepo,
 		mockOrderItemOptionRepo,
 		mockOrderStatusLogRepo,
+		nil,
+		nil,
 	)
 
 	flow := order.OrderStatusFlow{
@@ -583,7 +766,7 @@ func TestOrderService_GetUnfinishedOrders(t *testing.T) {
 					{ID: shared.ID(1), ShopID: 456, Status: order.OrderStatusPending},
 					{ID: shared.ID(2), ShopID: 456, Status: order.OrderStatusAccepted},
 				}
-				mockOrderRepo.On("FindUnfinishedByShopID", shopID.ToUint64(), flow, page, pageSize).Return(orders, int64(2), nil)
+				mockOrderRepo.On("FindUnfinishedByShopID", shopID.ToUint64(), flow, order.UnfinishedFilter{}, page, pageSize).Return(orders, int64(2), nil)
 			},
 			wantErr: false,
 			validate: func(t *testing.T, resp *dto.OrderListResponse) {
@@ -597,7 +780,7 @@ func TestOrderService_GetUnfinishedOrders(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setupMock(tt.shopID, flow, tt.page, tt.pageSize)
 
-			got, err := service.GetUnfinishedOrders(tt.shopID, flow, tt.page, tt.pageSize)
+			got, err := service.GetUnfinishedOrders(tt.shopID, flow, order.UnfinishedFilter{}, tt.page, tt.pageSize)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+
+	"orderease/config"
+	"orderease/domain/order"
+	"orderease/domain/shared"
+	"orderease/domain/table"
+	"orderease/infrastructure/repositories"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+)
+
+// tableQRSecret 桌台二维码的签名密钥，未单独配置时使用 JWT 密钥
+func tableQRSecret() []byte {
+	if secret := config.AppConfig.Table.QRSecret; secret != "" {
+		return []byte(secret)
+	}
+	return []byte(config.AppConfig.JWT.Secret)
+}
+
+// tableRef 下单时指定的桌台，扫码点餐时带有二维码中的签名信息
+type tableRef struct {
+	id     shared.ID
+	claims *table.QRClaims
+}
+
+// resolveOrderTable 解析下单请求中的桌台，二维码优先；二维码需属于下单的店铺
+func resolveOrderTable(shopID shared.ID, tableCode string, tableID shared.ID) (*tableRef, error) {
+	if tableCode != "" {
+		claims, err := table.ParseQRCode(tableQRSecret(), tableCode)
+		if err != nil {
+			return nil, err
+		}
+		if claims.ShopID != shopID.ToUint64() {
+			return nil, table.ErrInvalidQRCode
+		}
+		return &tableRef{id: claims.TableID, claims: &claims}, nil
+	}
+	if !tableID.IsZero() {
+		return &tableRef{id: tableID}, nil
+	}
+	return nil, nil
+}
+
+// openTableSession 返回桌台进行中的用餐，没有时开台，调用方需已锁定桌台
+func openTableSession(sessions table.SessionRepository, t *table.Table, guests int) (*table.Session, bool, error) {
+	session, err := sessions.FindOpenByTableID(t.ID, t.ShopID)
+	if err != nil {
+		return nil, false, err
+	}
+	if session != nil {
+		return session, false, nil
+	}
+
+	session, err = table.NewSession(t, guests)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := sessions.Save(session); err != nil {
+		return nil, false, err
+	}
+	return session, true, nil
+}
+
+// currentGuestSession 扫码点餐顾客所属的用餐，并台后为并入的目标用餐，不是扫码点餐的顾客时返回 nil
+func currentGuestSession(sessions table.SessionRepository, userID shared.ID, shopID uint64) (*table.Session, error) {
+	session, err := sessions.FindByGuestUserID(userID, shopID)
+	if err != nil || session == nil {
+		return session, err
+	}
+	// 并台时订单移到目标用餐，目标用餐之后还可能再并台
+	for !session.MergedInto.IsZero() {
+		if session, err = sessions.FindByIDAndShopID(session.MergedInto, shopID); err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// attachTableSession 锁定桌台后把订单归入桌台进行中的用餐，桌台空闲时自动开台
+func attachTableSession(tx *gorm.DB, ord *order.Order, ref *tableRef) error {
+	if ref == nil {
+		return nil
+	}
+
+	t, err := repositories.NewTableRepository(tx).FindByIDForUpdate(ref.id, ord.ShopID)
+	if err != nil {
+		if ref.claims != nil && errors.Is(err, table.ErrTableNotFound) {
+			return table.ErrInvalidQRCode
+		}
+		return err
+	}
+	if ref.claims != nil {
+		if err := t.CheckQRClaims(*ref.claims); err != nil {
+			return err
+		}
+	} else if !t.Active {
+		return table.ErrTableInactive
+	}
+
+	sessions := repositories.NewTableSessionRepository(tx)
+	session, opened, err := openTableSession(sessions, t, 0)
+	if err != nil {
+		return err
+	}
+
+	// 扫码点餐顾客的身份只在本次用餐内有效，结账后不能再向该桌台之后的用餐下单
+	guest, err := currentGuestSession(sessions, ord.UserID, ord.ShopID)
+	if err != nil {
+		return err
+	}
+	if guest != nil && guest.ID != session.ID {
+		return table.ErrGuestExpired
+	}
+	if opened {
+		log2.Infof("桌台自动开台: 店铺 %d, 桌台 %s, 用餐 %s", t.ShopID, t.Name, session.ID)
+	}
+
+	ord.TableID = t.ID
+	ord.TableSessionID = session.ID
+	return nil
+}
+
+// orderSettled 订单已付清、无需支付或已取消（归还库存的终态）时不再影响桌台结账
+func orderSettled(ord *order.Order, flow order.OrderStatusFlow) bool {
+	return ord.PaymentStatus.IsPaid() || !ord.TotalPrice.IsPositive() || flow.ReleasesStock(ord.Status)
+}
+
+// closeSettledTableSession 订单付清后检查所属的桌台用餐，全部订单都已结清时结束用餐
+// 需在更新订单支付状态的同一事务内调用
+func closeSettledTableSession(tx *gorm.DB, ord *order.Order) error {
+	if ord.TableSessionID.IsZero() {
+		return nil
+	}
+
+	sessions := repositories.NewTableSessionRepository(tx)
+	session, err := sessions.FindByIDAndShopID(ord.TableSessionID, ord.ShopID)
+	if err != nil {
+		return err
+	}
+	if !session.IsOpen() {
+		return nil
+	}
+
+	shopEntity, err := loadOrderingShop(tx, ord.ShopID, true)
+	if err != nil || shopEntity == nil {
+		return err
+	}
+
+	orders, err := repositories.NewOrderRepository(tx).FindByTableSessionID(session.ID, ord.ShopID)
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		if !orderSettled(&orders[i], shopEntity.OrderStatusFlow) {
+			return nil
+		}
+	}
+
+	if err := session.Close(table.CloseReasonPaid); err != nil {
+		return err
+	}
+	if err := sessions.Update(session); err != nil {
+		return err
+	}
+	log2.Infof("桌台用餐已结清: 店铺 %d, 用餐 %s", ord.ShopID, session.ID)
+	return nil
+}
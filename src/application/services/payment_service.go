@@ -0,0 +1,632 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"orderease/application/dto"
+	"orderease/domain/order"
+	"orderease/domain/payment"
+	"orderease/domain/shared"
+	"orderease/infrastructure/repositories"
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PaymentService 订单支付：发起支付、处理网关回调、确认线下收款和退款
+type PaymentService struct {
+	db             *gorm.DB
+	paymentRepo    payment.PaymentRepository
+	refundRepo     payment.RefundRepository
+	orderRepo      order.OrderRepository
+	gateways       map[payment.Method]payment.Gateway
+	eventPublisher order.EventPublisher
+}
+
+func NewPaymentService(
+	db *gorm.DB,
+	paymentRepo payment.PaymentRepository,
+	refundRepo payment.RefundRepository,
+	orderRepo order.OrderRepository,
+	gateways []payment.Gateway,
+	eventPublisher order.EventPublisher,
+) *PaymentService {
+	byMethod := make(map[payment.Method]payment.Gateway, len(gateways))
+	for _, g := range gateways {
+		byMethod[g.Method()] = g
+	}
+	return &PaymentService{
+		db:             db,
+		paymentRepo:    paymentRepo,
+		refundRepo:     refundRepo,
+		orderRepo:      orderRepo,
+		gateways:       byMethod,
+		eventPublisher: eventPublisher,
+	}
+}
+
+// paymentSimulator 可以生成已签名回调的网关（模拟支付）
+type paymentSimulator interface {
+	Simulate(p *payment.Payment, success bool) (payment.Headers, []byte, error)
+}
+
+// paymentTxRepos 绑定到同一事务的支付仓储
+type paymentTxRepos struct {
+	payments payment.PaymentRepository
+	refunds  payment.RefundRepository
+}
+
+func newPaymentTxRepos(tx *gorm.DB) paymentTxRepos {
+	return paymentTxRepos{
+		payments: repositories.NewPaymentRepository(tx),
+		refunds:  repositories.NewPaymentRefundRepository(tx),
+	}
+}
+
+func (s *PaymentService) publishEvent(eventType order.OrderEventType, ord *order.Order) {
+	if s.eventPublisher == nil {
+		return
+	}
+	s.eventPublisher.Publish(order.NewOrderEvent(eventType, ord, ord.Status))
+}
+
+// gateway 返回线上支付方式对应的网关，线下支付不需要网关
+func (s *PaymentService) gateway(method payment.Method) (payment.Gateway, error) {
+	if !method.IsValid() {
+		return nil, payment.ErrInvalidMethod
+	}
+	g, ok := s.gateways[method]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", payment.ErrGatewayNotEnabled, method)
+	}
+	return g, nil
+}
+
+// lockOrder 在事务内锁定订单行并读取订单，同一订单的支付状态变更依次执行
+func lockOrder(tx *gorm.DB, orderID shared.ID, shopID shared.ID) (*order.Order, error) {
+	var locked models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		Where("id = ? AND shop_id = ?", orderID.Value(), shopID.Value()).First(&locked).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("订单不存在")
+		}
+		log2.Errorf("锁定订单失败, 订单ID: %s, 错误: %v", orderID, err)
+		return nil, errors.New("查询订单失败")
+	}
+	return repositories.NewOrderRepository(tx).FindByIDAndShopID(orderID, shopID.ToUint64())
+}
+
+// syncOrderPayment 按订单的全部支付单重新汇总已支付和已退款金额，更新订单的支付状态
+func syncOrderPayment(tx *gorm.DB, repos paymentTxRepos, ord *order.Order) error {
+	payments, err := repos.payments.FindByOrderID(ord.ID, shared.ParseIDFromUint64(ord.ShopID))
+	if err != nil {
+		return err
+	}
+
+	paid, refunded := shared.Price(0), shared.Price(0)
+	for _, p := range payments {
+		if p.Status == payment.StatusSucceeded {
+			paid = paid.Add(p.Amount)
+			refunded = refunded.Add(p.RefundedAmount)
+		}
+	}
+	ord.ApplyPayments(paid, refunded)
+
+	if err := tx.Model(&models.Order{}).
+		Where("id = ? AND shop_id = ?", ord.ID.Value(), ord.ShopID).
+		Updates(map[string]interface{}{
+			"payment_status":  string(ord.PaymentStatus),
+			"paid_amount":     ord.PaidAmount,
+			"refunded_amount": ord.RefundedAmount,
+		}).Error; err != nil {
+		log2.Errorf("更新订单支付状态失败, 订单ID: %s, 错误: %v", ord.ID, err)
+		return errors.New("更新订单支付状态失败")
+	}
+	return nil
+}
+
+// checkPayable 订单未结束且未付清时才能收款，返回待支付金额
+func checkPayable(ord *order.Order, flow order.OrderStatusFlow) (shared.Price, error) {
+	if flow.IsFinalStatus(ord.Status) {
+		return 0, errors.New("订单已结束，不能支付")
+	}
+	if ord.PaymentStatus.IsPaid() {
+		return 0, errors.New("订单已支付")
+	}
+	due := ord.TotalPrice.Sub(ord.PaidAmount)
+	if !due.IsPositive() {
+		return 0, errors.New("订单无需支付")
+	}
+	return due, nil
+}
+
+// CreatePayment 为订单发起支付，同一订单之前未完成的支付单会被关闭
+// 线上支付返回网关的拉起参数；线下支付（现金、到店付款）生成待支付的支付单，由店员收款后确认
+func (s *PaymentService) CreatePayment(req *dto.CreatePaymentRequest, flow order.OrderStatusFlow) (*dto.PaymentResponse, error) {
+	var g payment.Gateway
+	if !req.Method.IsOffline() {
+		var err error
+		if g, err = s.gateway(req.Method); err != nil {
+			return nil, err
+		}
+	}
+
+	var p *payment.Payment
+	err := WithTx(s.db, func(tx *gorm.DB) error {
+		repos := newPaymentTxRepos(tx)
+		ord, err := lockOrder(tx, req.OrderID, req.ShopID)
+		if err != nil {
+			return err
+		}
+		if !req.UserID.IsZero() && ord.UserID != req.UserID {
+			return errors.New("订单不存在")
+		}
+
+		due, err := checkPayable(ord, flow)
+		if err != nil {
+			return err
+		}
+
+		if err := repos.payments.CloseByOrderID(ord.ID, req.ShopID); err != nil {
+			return err
+		}
+
+		p, err = payment.NewPayment(req.ShopID, ord.ID, ord.UserID, req.Method, due)
+		if err != nil {
+			return err
+		}
+		return repos.payments.Save(p)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := toPaymentResponse(p)
+	if g == nil {
+		return resp, nil
+	}
+
+	// 网关下单在事务外进行，失败时把支付单标记为失败
+	intent, err := g.CreateIntent(p)
+	if err != nil {
+		log2.Errorf("支付网关下单失败, 支付单: %s, 错误: %v", p.ID, err)
+		p.Fail(err.Error())
+		if updateErr := s.paymentRepo.Update(p); updateErr != nil {
+			log2.Errorf("更新支付单失败, 支付单: %s, 错误: %v", p.ID, updateErr)
+		}
+		return nil, errors.New("发起支付失败，请稍后重试")
+	}
+	resp.PayParams = intent.PayParams
+
+	log2.Infof("发起支付: 订单 %s, 支付单 %s, 方式 %s, 金额 %s", p.OrderID, p.ID, p.Method, p.Amount)
+	return resp, nil
+}
+
+// HandleNotification 处理支付网关的支付结果回调，返回需要应答给网关的内容
+// 签名无效时返回 payment.ErrInvalidSignature；网关会重复通知，同一支付单只处理一次
+func (s *PaymentService) HandleNotification(method payment.Method, headers payment.Headers, body []byte) ([]byte, error) {
+	g, err := s.gateway(method)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := g.ParseNotification(headers, body)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.paymentRepo.FindByID(n.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.Method != method {
+		return nil, errors.New("支付方式与支付单不一致")
+	}
+
+	var ord *order.Order
+	becamePaid := false
+	err = WithTx(s.db, func(tx *gorm.DB) error {
+		repos := newPaymentTxRepos(tx)
+		ord, err = lockOrder(tx, existing.OrderID, existing.ShopID)
+		if err != nil {
+			return err
+		}
+		p, err := repos.payments.FindByIDAndShopID(existing.ID, existing.ShopID)
+		if err != nil {
+			return err
+		}
+
+		if !n.Success {
+			if p.Fail(n.FailureReason) {
+				return repos.payments.Update(p)
+			}
+			return nil
+		}
+
+		changed, err := p.Succeed(n.ProviderTradeNo, n.Amount, n.PaidAt)
+		if err != nil || !changed {
+			return err
+		}
+		if err := repos.payments.Update(p); err != nil {
+			return err
+		}
+
+		wasPaid := ord.PaymentStatus.IsPaid()
+		if err := syncOrderPayment(tx, repos, ord); err != nil {
+			return err
+		}
+		becamePaid = !wasPaid && ord.PaymentStatus.IsPaid()
+		if becamePaid {
+			return closeSettledTableSession(tx, ord)
+		}
+		return nil
+	})
+	if err != nil {
+		log2.Errorf("处理支付回调失败, 支付单: %s, 错误: %v", n.PaymentID, err)
+		return nil, err
+	}
+
+	if becamePaid {
+		log2.Infof("订单支付成功: 订单 %s, 支付单 %s", ord.ID, n.PaymentID)
+		s.publishEvent(order.OrderEventPaid, ord)
+	}
+	return g.AckBody(), nil
+}
+
+// SimulatePayment 模拟顾客在支付网关完成或放弃支付，生成签名回调后按正常回调流程处理
+func (s *PaymentService) SimulatePayment(req *dto.MockPayRequest, userID shared.ID) (*dto.PaymentResponse, error) {
+	p, err := s.paymentRepo.FindByIDAndShopID(req.PaymentID, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	if !userID.IsZero() && p.UserID != userID {
+		return nil, errors.New("支付单不存在")
+	}
+
+	g, err := s.gateway(p.Method)
+	if err != nil {
+		return nil, err
+	}
+	simulator, ok := g.(paymentSimulator)
+	if !ok {
+		return nil, errors.New("该支付方式不支持模拟支付")
+	}
+
+	headers, body, err := simulator.Simulate(p, req.Success)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.HandleNotification(p.Method, headers, body); err != nil {
+		return nil, err
+	}
+
+	p, err = s.paymentRepo.FindByIDAndShopID(req.PaymentID, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	return toPaymentResponse(p), nil
+}
+
+// ConfirmOfflinePayment 店员确认收到现金或到店付款
+func (s *PaymentService) ConfirmOfflinePayment(req *dto.ConfirmPaymentRequest, flow order.OrderStatusFlow, actor AuditActor) (*dto.PaymentResponse, error) {
+	var p *payment.Payment
+	var ord *order.Order
+	becamePaid := false
+	err := WithTx(s.db, func(tx *gorm.DB) error {
+		repos := newPaymentTxRepos(tx)
+
+		orderID := req.OrderID
+		if !req.PaymentID.IsZero() {
+			pending, err := repos.payments.FindByIDAndShopID(req.PaymentID, req.ShopID)
+			if err != nil {
+				return err
+			}
+			orderID = pending.OrderID
+		}
+
+		var err error
+		ord, err = lockOrder(tx, orderID, req.ShopID)
+		if err != nil {
+			return err
+		}
+		due, err := checkPayable(ord, flow)
+		if err != nil {
+			return err
+		}
+
+		if req.PaymentID.IsZero() {
+			// 直接登记线下收款，同时关闭顾客未完成的支付
+			if !req.Method.IsOffline() {
+				return errors.New("只能确认现金或到店付款")
+			}
+			if err := repos.payments.CloseByOrderID(ord.ID, req.ShopID); err != nil {
+				return err
+			}
+			if p, err = payment.NewPayment(req.ShopID, ord.ID, ord.UserID, req.Method, due); err != nil {
+				return err
+			}
+			if err := repos.payments.Save(p); err != nil {
+				return err
+			}
+		} else {
+			// 加锁后重新读取，避免与关闭支付单的操作交错
+			if p, err = repos.payments.FindByIDAndShopID(req.PaymentID, req.ShopID); err != nil {
+				return err
+			}
+			if !p.Method.IsOffline() {
+				return errors.New("线上支付以支付网关的通知为准，不能手动确认")
+			}
+			if p.Status != payment.StatusPending {
+				return payment.ErrNotPending
+			}
+		}
+
+		if _, err := p.Succeed("", p.Amount, time.Now()); err != nil {
+			return err
+		}
+		p.ConfirmedBy = actor.Name
+		if err := repos.payments.Update(p); err != nil {
+			return err
+		}
+
+		if err := syncOrderPayment(tx, repos, ord); err != nil {
+			return err
+		}
+		becamePaid = ord.PaymentStatus.IsPaid()
+		if becamePaid {
+			return closeSettledTableSession(tx, ord)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log2.Infof("确认线下收款: 订单 %s, 支付单 %s, 方式 %s, 金额 %s, 操作人 %s", ord.ID, p.ID, p.Method, p.Amount, actor.Name)
+	if becamePaid {
+		s.publishEvent(order.OrderEventPaid, ord)
+	}
+	return toPaymentResponse(p), nil
+}
+
+// RefundPayment 为支付成功的支付单退款，可多次部分退款，合计不超过支付金额
+// 线上支付通过网关原路退回，线下支付由店员退还现金后登记
+func (s *PaymentService) RefundPayment(req *dto.RefundPaymentRequest, actor AuditActor) (*dto.PaymentRefundResponse, error) {
+	existing, err := s.paymentRepo.FindByIDAndShopID(req.PaymentID, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	var refund *payment.Refund
+	var pending []gatewayRefund
+	var ord *order.Order
+	err = WithTx(s.db, func(tx *gorm.DB) error {
+		repos := newPaymentTxRepos(tx)
+		ord, err = lockOrder(tx, existing.OrderID, req.ShopID)
+		if err != nil {
+			return err
+		}
+		p, err := repos.payments.FindByIDAndShopID(req.PaymentID, req.ShopID)
+		if err != nil {
+			return err
+		}
+
+		if refund, err = s.refundPayment(repos, p, req.Amount, req.Reason, actor.Name); err != nil {
+			return err
+		}
+		if refund.Status == payment.RefundStatusPending {
+			pending = append(pending, gatewayRefund{payment: *p, refund: refund})
+		}
+		return syncOrderPayment(tx, repos, ord)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.submitRefunds(pending); err != nil {
+		return nil, err
+	}
+
+	log2.Infof("退款成功: 订单 %s, 支付单 %s, 金额 %s, 操作人 %s", ord.ID, req.PaymentID, refund.Amount, actor.Name)
+	s.publishEvent(order.OrderEventRefunded, ord)
+	return toPaymentRefundResponse(refund), nil
+}
+
+// gatewayRefund 已登记、等待事务提交后向支付网关发起的退款
+type gatewayRefund struct {
+	payment payment.Payment
+	refund  *payment.Refund
+}
+
+// refundPayment 在事务内登记支付单退款，线下支付直接退款成功，线上支付登记为待退回，由 submitRefunds 在事务提交后原路退回
+// 调用方必须持有订单锁，同一支付单的退款不会并发超退
+func (s *PaymentService) refundPayment(repos paymentTxRepos, p *payment.Payment, amount shared.Price, reason, operator string) (*payment.Refund, error) {
+	refund, err := p.NewRefund(amount, reason, operator)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Method.IsOffline() {
+		p.ApplyRefund(refund)
+	} else {
+		if _, err := s.gateway(p.Method); err != nil {
+			return nil, err
+		}
+		p.ReserveRefund(refund)
+	}
+
+	if err := repos.refunds.Save(refund); err != nil {
+		return nil, err
+	}
+	if err := repos.payments.Update(p); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// submitRefunds 事务提交后逐笔向支付网关发起退款
+// 网关调用不能放在事务内：多笔退款中后面一笔失败导致回滚时，前面已经退回的款项会丢失记录
+// 失败的退款标记为失败并恢复可退金额，其余退款不受影响；进程在提交后中断时退款记录停留在待退回状态，需人工核对
+func (s *PaymentService) submitRefunds(refunds []gatewayRefund) error {
+	var failed error
+	for _, r := range refunds {
+		if err := s.submitRefund(r.payment, r.refund); err != nil {
+			failed = err
+		}
+	}
+	return failed
+}
+
+func (s *PaymentService) submitRefund(p payment.Payment, refund *payment.Refund) error {
+	g, err := s.gateway(p.Method)
+	if err == nil {
+		var result *payment.RefundResult
+		if result, err = g.Refund(&p, refund); err == nil {
+			refund.Succeed(result.ProviderRefundNo)
+			if err := s.refundRepo.Update(refund); err != nil {
+				// 款项已经退回，记录停留在待退回状态，不能恢复可退金额
+				log2.Errorf("更新退款记录失败, 退款: %s, 网关退款单号: %s, 错误: %v", refund.ID, result.ProviderRefundNo, err)
+			}
+			return nil
+		}
+	}
+	log2.Errorf("支付网关退款失败, 支付单: %s, 退款: %s, 错误: %v", p.ID, refund.ID, err)
+
+	reason := err.Error()
+	if err := WithTx(s.db, func(tx *gorm.DB) error {
+		repos := newPaymentTxRepos(tx)
+		ord, err := lockOrder(tx, p.OrderID, p.ShopID)
+		if err != nil {
+			return err
+		}
+		locked, err := repos.payments.FindByIDAndShopID(p.ID, p.ShopID)
+		if err != nil {
+			return err
+		}
+
+		locked.FailRefund(refund, reason)
+		if err := repos.refunds.Update(refund); err != nil {
+			return err
+		}
+		if err := repos.payments.Update(locked); err != nil {
+			return err
+		}
+		return syncOrderPayment(tx, repos, ord)
+	}); err != nil {
+		log2.Errorf("恢复退款失败的可退金额失败, 退款: %s, 错误: %v", refund.ID, err)
+	}
+	return errors.New("退款失败，请稍后重试")
+}
+
+// refundOrder 在事务内把订单退款金额分摊到订单的支付单上登记退款，从最近一笔支付开始退，并重新汇总订单的支付状态
+// 返回待原路退回的线上退款，调用方在事务提交后交给 submitRefunds；调用方必须已通过 lockOrder 锁定订单
+func (s *PaymentService) refundOrder(tx *gorm.DB, ord *order.Order, amount shared.Price, reason, operator string) ([]gatewayRefund, error) {
+	repos := newPaymentTxRepos(tx)
+	shopID := shared.ParseIDFromUint64(ord.ShopID)
+	payments, err := repos.payments.FindByOrderID(ord.ID, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	refundable := shared.Price(0)
+	for _, p := range payments {
+		refundable = refundable.Add(p.Refundable())
+	}
+	if amount > refundable {
+		return nil, fmt.Errorf("%w，最多可退 %s 元", payment.ErrRefundExceeds, refundable)
+	}
+
+	var pending []gatewayRefund
+	for i := len(payments) - 1; i >= 0 && amount.IsPositive(); i-- {
+		part := payments[i].Refundable().Min(amount)
+		if !part.IsPositive() {
+			continue
+		}
+		refund, err := s.refundPayment(repos, &payments[i], part, reason, operator)
+		if err != nil {
+			return nil, err
+		}
+		if refund.Status == payment.RefundStatusPending {
+			pending = append(pending, gatewayRefund{payment: payments[i], refund: refund})
+		}
+		amount = amount.Sub(part)
+	}
+
+	return pending, syncOrderPayment(tx, repos, ord)
+}
+
+// GetOrderPayments 查询订单的支付汇总、支付单和退款记录，userID 不为空时只能查询该用户的订单
+func (s *PaymentService) GetOrderPayments(orderID shared.ID, shopID shared.ID, userID shared.ID) (*dto.OrderPaymentsResponse, error) {
+	ord, err := s.orderRepo.FindByIDAndShopID(orderID, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+	if !userID.IsZero() && ord.UserID != userID {
+		return nil, errors.New("订单不存在")
+	}
+
+	payments, err := s.paymentRepo.FindByOrderID(orderID, shopID)
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := s.refundRepo.FindByOrderID(orderID, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.OrderPaymentsResponse{
+		OrderID:        ord.ID,
+		TotalPrice:     ord.TotalPrice,
+		PaymentStatus:  ord.PaymentStatus,
+		PaidAmount:     ord.PaidAmount,
+		RefundedAmount: ord.RefundedAmount,
+		Payments:       make([]dto.PaymentResponse, len(payments)),
+		Refunds:        make([]dto.PaymentRefundResponse, len(refunds)),
+	}
+	for i := range payments {
+		resp.Payments[i] = *toPaymentResponse(&payments[i])
+	}
+	for i := range refunds {
+		resp.Refunds[i] = *toPaymentRefundResponse(&refunds[i])
+	}
+	return resp, nil
+}
+
+func toPaymentResponse(p *payment.Payment) *dto.PaymentResponse {
+	resp := &dto.PaymentResponse{
+		ID:              p.ID,
+		ShopID:          p.ShopID,
+		OrderID:         p.OrderID,
+		Method:          p.Method,
+		Amount:          p.Amount,
+		RefundedAmount:  p.RefundedAmount,
+		Status:          p.Status,
+		ProviderTradeNo: p.ProviderTradeNo,
+		FailureReason:   p.FailureReason,
+		ConfirmedBy:     p.ConfirmedBy,
+		CreatedAt:       p.CreatedAt,
+	}
+	if !p.PaidAt.IsZero() {
+		paidAt := p.PaidAt
+		resp.PaidAt = &paidAt
+	}
+	return resp
+}
+
+func toPaymentRefundResponse(r *payment.Refund) *dto.PaymentRefundResponse {
+	return &dto.PaymentRefundResponse{
+		ID:               r.ID,
+		PaymentID:        r.PaymentID,
+		OrderID:          r.OrderID,
+		Amount:           r.Amount,
+		Reason:           r.Reason,
+		Status:           r.Status,
+		ProviderRefundNo: r.ProviderRefundNo,
+		Operator:         r.Operator,
+		CreatedAt:        r.CreatedAt,
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"orderease/application/dto"
+	"orderease/domain/order"
+	"orderease/domain/payment"
+	"orderease/domain/shared"
+	"orderease/infrastructure/gateways"
+	"orderease/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestPaymentService(db *gorm.DB) *PaymentService {
+	return NewPaymentService(
+		db,
+		repositories.NewPaymentRepository(db),
+		repositories.NewPaymentRefundRepository(db),
+		repositories.NewOrderRepository(db),
+		[]payment.Gateway{gateways.NewMockGateway("test-secret")},
+		nil,
+	)
+}
+
+func TestPaymentService_MockGateway(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	flow := stockTestFlow()
+	flow.RequirePayment = true
+
+	orderService, db, productID := setupStockTest(t, 10)
+	paymentService := newTestPaymentService(db)
+	orderID := createStockTestOrder(t, orderService, productID, 2)
+
+	t.Run("unpaid order cannot be accepted", func(t *testing.T) {
+		err := orderService.UpdateOrderStatus(orderID, shopID, order.OrderStatusAccepted, flow, order.StatusActor{}, "")
+		assert.ErrorIs(t, err, order.ErrOrderNotPaid)
+	})
+
+	t.Run("customer cannot pay another user's order", func(t *testing.T) {
+		_, err := paymentService.CreatePayment(&dto.CreatePaymentRequest{
+			ShopID: shopID, OrderID: orderID, Method: payment.MethodMock, UserID: shared.ID(9999),
+		}, flow)
+		assert.EqualError(t, err, "订单不存在")
+	})
+
+	t.Run("disabled gateway", func(t *testing.T) {
+		_, err := paymentService.CreatePayment(&dto.CreatePaymentRequest{
+			ShopID: shopID, OrderID: orderID, Method: payment.MethodWechatPay,
+		}, flow)
+		assert.ErrorIs(t, err, payment.ErrGatewayNotEnabled)
+	})
+
+	var first *dto.PaymentResponse
+	t.Run("failed payment keeps order unpaid", func(t *testing.T) {
+		var err error
+		first, err = paymentService.CreatePayment(&dto.CreatePaymentRequest{
+			ShopID: shopID, OrderID: orderID, Method: payment.MethodMock, UserID: shared.ID(9001),
+		}, flow)
+		require.NoError(t, err)
+		assert.Equal(t, "24.00", first.Amount.String())
+		assert.Equal(t, first.ID.String(), first.PayParams["payment_id"])
+
+		resp, err := paymentService.SimulatePayment(&dto.MockPayRequest{ShopID: shopID, PaymentID: first.ID}, shared.ID(9001))
+		require.NoError(t, err)
+		assert.Equal(t, payment.StatusFailed, resp.Status)
+		assert.Equal(t, "用户取消支付", resp.FailureReason)
+	})
+
+	t.Run("invalid signature is rejected", func(t *testing.T) {
+		headers := http.Header{}
+		headers.Set(gateways.MockSignatureHeader, "forged")
+		body := []byte(`{"payment_id":"` + first.ID.String() + `","status":"SUCCESS","amount":"24.00"}`)
+		_, err := paymentService.HandleNotification(payment.MethodMock, headers, body)
+		assert.ErrorIs(t, err, payment.ErrInvalidSignature)
+	})
+
+	var paid *dto.PaymentResponse
+	t.Run("successful payment marks order paid", func(t *testing.T) {
+		second, err := paymentService.CreatePayment(&dto.CreatePaymentRequest{
+			ShopID: shopID, OrderID: orderID, Method: payment.MethodMock,
+		}, flow)
+		require.NoError(t, err)
+
+		paid, err = paymentService.SimulatePayment(&dto.MockPayRequest{ShopID: shopID, PaymentID: second.ID, Success: true}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, payment.StatusSucceeded, paid.Status)
+		require.NotNil(t, paid.PaidAt)
+
+		// 网关重复通知不会重复入账
+		_, err = paymentService.SimulatePayment(&dto.MockPayRequest{ShopID: shopID, PaymentID: second.ID, Success: true}, 0)
+		require.NoError(t, err)
+
+		summary, err := paymentService.GetOrderPayments(orderID, shopID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, order.PaymentStatusPaid, summary.PaymentStatus)
+		assert.Equal(t, "24.00", summary.PaidAmount.String())
+		assert.Len(t, summary.Payments, 2)
+
+		_, err = paymentService.CreatePayment(&dto.CreatePaymentRequest{
+			ShopID: shopID, OrderID: orderID, Method: payment.MethodMock,
+		}, flow)
+		assert.EqualError(t, err, "订单已支付")
+
+		require.NoError(t, orderService.UpdateOrderStatus(orderID, shopID, order.OrderStatusAccepted, flow, order.StatusActor{}, ""))
+	})
+
+	t.Run("partial refunds", func(t *testing.T) {
+		actor := AuditActor{Name: "owner"}
+		refund, err := paymentService.RefundPayment(&dto.RefundPaymentRequest{
+			ShopID: shopID, PaymentID: paid.ID, Amount: shared.NewPrice(4), Reason: "少一份小料",
+		}, actor)
+		require.NoError(t, err)
+		assert.Equal(t, payment.RefundStatusSucceeded, refund.Status)
+		assert.NotEmpty(t, refund.ProviderRefundNo)
+
+		_, err = paymentService.RefundPayment(&dto.RefundPaymentRequest{
+			ShopID: shopID, PaymentID: paid.ID, Amount: shared.NewPrice(20.01), Reason: "超退",
+		}, actor)
+		assert.ErrorIs(t, err, payment.ErrRefundExceeds)
+
+		summary, err := paymentService.GetOrderPayments(orderID, shopID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, order.PaymentStatusPartiallyRefunded, summary.PaymentStatus)
+		assert.Equal(t, "4.00", summary.RefundedAmount.String())
+		assert.Len(t, summary.Refunds, 1)
+	})
+}
+
+func TestPaymentService_OfflinePayment(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	flow := stockTestFlow()
+	flow.RequirePayment = true
+	actor := AuditActor{Name: "cashier01"}
+
+	orderService, db, productID := setupStockTest(t, 10)
+	paymentService := newTestPaymentService(db)
+
+	t.Run("confirm pay at counter started by customer", func(t *testing.T) {
+		orderID := createStockTestOrder(t, orderService, productID, 1)
+		pending, err := paymentService.CreatePayment(&dto.CreatePaymentRequest{
+			ShopID: shopID, OrderID: orderID, Method: payment.MethodPayAtCounter, UserID: shared.ID(9001),
+		}, flow)
+		require.NoError(t, err)
+		assert.Equal(t, payment.StatusPending, pending.Status)
+		assert.Empty(t, pending.PayParams)
+
+		resp, err := paymentService.ConfirmOfflinePayment(&dto.ConfirmPaymentRequest{ShopID: shopID, PaymentID: pending.ID}, flow, actor)
+		require.NoError(t, err)
+		assert.Equal(t, payment.StatusSucceeded, resp.Status)
+		assert.Equal(t, "cashier01", resp.ConfirmedBy)
+
+		_, err = paymentService.ConfirmOfflinePayment(&dto.ConfirmPaymentRequest{ShopID: shopID, PaymentID: pending.ID}, flow, actor)
+		assert.EqualError(t, err, "订单已支付")
+	})
+
+	t.Run("record cash directly", func(t *testing.T) {
+		orderID := createStockTestOrder(t, orderService, productID, 1)
+		_, err := paymentService.ConfirmOfflinePayment(&dto.ConfirmPaymentRequest{ShopID: shopID, OrderID: orderID, Method: payment.MethodMock}, flow, actor)
+		assert.EqualError(t, err, "只能确认现金或到店付款")
+
+		resp, err := paymentService.ConfirmOfflinePayment(&dto.ConfirmPaymentRequest{ShopID: shopID, OrderID: orderID, Method: payment.MethodCash}, flow, actor)
+		require.NoError(t, err)
+		assert.Equal(t, "12.00", resp.Amount.String())
+
+		require.NoError(t, orderService.UpdateOrderStatus(orderID, shopID, order.OrderStatusAccepted, flow, order.StatusActor{}, ""))
+	})
+
+	t.Run("paid order amount cannot change", func(t *testing.T) {
+		orderID := createStockTestOrder(t, orderService, productID, 1)
+		_, err := paymentService.ConfirmOfflinePayment(&dto.ConfirmPaymentRequest{ShopID: shopID, OrderID: orderID, Method: payment.MethodCash}, flow, actor)
+		require.NoError(t, err)
+
+		_, err = orderService.UpdateOrder(&dto.UpdateOrderRequest{
+			ID:     orderID,
+			ShopID: shopID,
+			Items:  []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 2}},
+			Status: order.OrderStatusPending,
+		}, flow)
+		assert.EqualError(t, err, "订单已支付，不能修改订单金额")
+	})
+}
@@ -7,11 +7,12 @@ import (
 )
 
 // ProductFinderAdapter 实现 order.ProductFinder 接口
-// 将 Repository 接口适配为 Domain Service 需要的接口
+// 将 Repository 接口适配为 Domain Service 需要的接口，商品查询限定在下单店铺内
 type ProductFinderAdapter struct {
 	productRepo   product.ProductRepository
 	optionRepo    product.ProductOptionRepository
 	categoryRepo  product.ProductOptionCategoryRepository
+	shopID        uint64
 }
 
 // NewProductFinderAdapter 创建 ProductFinder 适配器
@@ -19,25 +20,27 @@ func NewProductFinderAdapter(
 	productRepo product.ProductRepository,
 	optionRepo product.ProductOptionRepository,
 	categoryRepo product.ProductOptionCategoryRepository,
+	shopID uint64,
 ) order.ProductFinder {
 	return &ProductFinderAdapter{
 		productRepo:  productRepo,
 		optionRepo:   optionRepo,
 		categoryRepo: categoryRepo,
+		shopID:       shopID,
 	}
 }
 
 // FindProduct 查找商品
 func (a *ProductFinderAdapter) FindProduct(id shared.ID) (*product.Product, error) {
-	return a.productRepo.FindByID(id)
+	return a.productRepo.FindByIDAndShopID(id, a.shopID)
 }
 
 // FindOption 查找商品选项
 func (a *ProductFinderAdapter) FindOption(id shared.ID) (*product.ProductOption, error) {
-	return a.optionRepo.FindByID(id)
+	return a.optionRepo.FindByID(id, a.shopID)
 }
 
 // FindOptionCategory 查找选项类别
 func (a *ProductFinderAdapter) FindOptionCategory(id shared.ID) (*product.ProductOptionCategory, error) {
-	return a.categoryRepo.FindByID(id)
+	return a.categoryRepo.FindByID(id, a.shopID)
 }
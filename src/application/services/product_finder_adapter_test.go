@@ -21,14 +21,6 @@ func (m *MockProductRepository) Save(prod *product.Product) error {
 	return args.Error(0)
 }
 
-func (m *MockProductRepository) FindByID(id shared.ID) (*product.Product, error) {
-	args := m.Called(id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*product.Product), args.Error(1)
-}
-
 func (m *MockProductRepository) FindByIDAndShopID(id shared.ID, shopID uint64) (*product.Product, error) {
 	args := m.Called(id, shopID)
 	if args.Get(0) == nil {
@@ -45,16 +37,24 @@ func (m *MockProductRepository) FindByShopID(shopID uint64, page, pageSize int,
 	return args.Get(0).([]product.Product), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockProductRepository) FindByIDs(ids []shared.ID) ([]product.Product, error) {
-	args := m.Called(ids)
+func (m *MockProductRepository) FindByIDs(ids []shared.ID, shopID uint64) ([]product.Product, error) {
+	args := m.Called(ids, shopID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]product.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) FindLowStock(shopID uint64) ([]product.Product, error) {
+	args := m.Called(shopID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]product.Product), args.Error(1)
 }
 
-func (m *MockProductRepository) Delete(id shared.ID) error {
-	args := m.Called(id)
+func (m *MockProductRepository) Delete(id shared.ID, shopID uint64) error {
+	args := m.Called(id, shopID)
 	return args.Error(0)
 }
 
@@ -63,21 +63,21 @@ func (m *MockProductRepository) Update(prod *product.Product) error {
 	return args.Error(0)
 }
 
-func (m *MockProductRepository) CountByProductID(productID shared.ID) (int64, error) {
-	args := m.Called(productID)
+func (m *MockProductRepository) CountByProductID(productID shared.ID, shopID uint64) (int64, error) {
+	args := m.Called(productID, shopID)
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockProductRepository) FindOptionByID(id shared.ID) (*product.ProductOption, error) {
-	args := m.Called(id)
+func (m *MockProductRepository) FindOptionByID(id shared.ID, shopID uint64) (*product.ProductOption, error) {
+	args := m.Called(id, shopID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*product.ProductOption), args.Error(1)
 }
 
-func (m *MockProductRepository) FindOptionCategoryByID(id shared.ID) (*product.ProductOptionCategory, error) {
-	args := m.Called(id)
+func (m *MockProductRepository) FindOptionCategoryByID(id shared.ID, shopID uint64) (*product.ProductOptionCategory, error) {
+	args := m.Called(id, shopID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -94,8 +94,8 @@ func (m *MockProductOptionRepository) Save(option *product.ProductOption) error
 	return args.Error(0)
 }
 
-func (m *MockProductOptionRepository) FindByID(id shared.ID) (*product.ProductOption, error) {
-	args := m.Called(id)
+func (m *MockProductOptionRepository) FindByID(id shared.ID, shopID uint64) (*product.ProductOption, error) {
+	args := m.Called(id, shopID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -125,8 +125,8 @@ func (m *MockProductOptionCategoryRepository) Save(category *product.ProductOpti
 	return args.Error(0)
 }
 
-func (m *MockProductOptionCategoryRepository) FindByID(id shared.ID) (*product.ProductOptionCategory, error) {
-	args := m.Called(id)
+func (m *MockProductOptionCategoryRepository) FindByID(id shared.ID, shopID uint64) (*product.ProductOptionCategory, error) {
+	args := m.Called(id, shopID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -151,7 +151,7 @@ func TestNewProductFinderAdapter(t *testing.T) {
 	mockOptionRepo := new(MockProductOptionRepository)
 	mockCategoryRepo := new(MockProductOptionCategoryRepository)
 
-	adapter := NewProductFinderAdapter(mockProductRepo, mockOptionRepo, mockCategoryRepo)
+	adapter := NewProductFinderAdapter(mockProductRepo, mockOptionRepo, mockCategoryRepo, 456)
 
 	assert.NotNil(t, adapter)
 	assert.IsType(t, &ProductFinderAdapter{}, adapter)
@@ -176,7 +176,7 @@ func TestProductFinderAdapter_FindProduct(t *testing.T) {
 					Name:   "测试商品",
 					Stock:  10,
 				}
-				m.On("FindByID", shared.ID(123)).Return(prod, nil)
+				m.On("FindByIDAndShopID", shared.ID(123), uint64(456)).Return(prod, nil)
 			},
 			wantProduct: &product.Product{
 				ID:     shared.ID(123),
@@ -190,7 +190,7 @@ func TestProductFinderAdapter_FindProduct(t *testing.T) {
 			name:      "product not found",
 			productID: shared.ID(999),
 			setupMock: func(m *MockProductRepository) {
-				m.On("FindByID", shared.ID(999)).Return(nil, errors.New("not found"))
+				m.On("FindByIDAndShopID", shared.ID(999), uint64(456)).Return(nil, errors.New("not found"))
 			},
 			wantProduct: nil,
 			wantErr:     true,
@@ -206,7 +206,7 @@ func TestProductFinderAdapter_FindProduct(t *testing.T) {
 
 			tt.setupMock(mockProductRepo)
 
-			adapter := NewProductFinderAdapter(mockProductRepo, mockOptionRepo, mockCategoryRepo)
+			adapter := NewProductFinderAdapter(mockProductRepo, mockOptionRepo, mockCategoryRepo, 456)
 			got, err := adapter.FindProduct(tt.productID)
 
 			if tt.wantErr {
@@ -242,15 +242,15 @@ func TestProductFinderAdapter_FindOption(t *testing.T) {
 					ID:              shared.ID(1),
 					CategoryID:      shared.ID(10),
 					Name:            "大",
-					PriceAdjustment: 10,
+					PriceAdjustment: shared.NewPrice(10),
 				}
-				m.On("FindByID", shared.ID(1)).Return(opt, nil)
+				m.On("FindByID", shared.ID(1), uint64(456)).Return(opt, nil)
 			},
 			wantOption: &product.ProductOption{
 				ID:              shared.ID(1),
 				CategoryID:      shared.ID(10),
 				Name:            "大",
-				PriceAdjustment: 10,
+				PriceAdjustment: shared.NewPrice(10),
 			},
 			wantErr: false,
 		},
@@ -258,7 +258,7 @@ func TestProductFinderAdapter_FindOption(t *testing.T) {
 			name:      "option not found",
 			optionID:  shared.ID(999),
 			setupMock: func(m *MockProductOptionRepository) {
-				m.On("FindByID", shared.ID(999)).Return(nil, errors.New("option not found"))
+				m.On("FindByID", shared.ID(999), uint64(456)).Return(nil, errors.New("option not found"))
 			},
 			wantOption:  nil,
 			wantErr:     true,
@@ -274,7 +274,7 @@ func TestProductFinderAdapter_FindOption(t *testing.T) {
 
 			tt.setupMock(mockOptionRepo)
 
-			adapter := NewProductFinderAdapter(mockProductRepo, mockOptionRepo, mockCategoryRepo)
+			adapter := NewProductFinderAdapter(mockProductRepo, mockOptionRepo, mockCategoryRepo, 456)
 			got, err := adapter.FindOption(tt.optionID)
 
 			if tt.wantErr {
@@ -310,7 +310,7 @@ func TestProductFinderAdapter_FindOptionCategory(t *testing.T) {
 					ID:     shared.ID(10),
 					Name:   "尺寸",
 				}
-				m.On("FindByID", shared.ID(10)).Return(cat, nil)
+				m.On("FindByID", shared.ID(10), uint64(456)).Return(cat, nil)
 			},
 			wantCategory: &product.ProductOptionCategory{
 				ID:     shared.ID(10),
@@ -322,7 +322,7 @@ func TestProductFinderAdapter_FindOptionCategory(t *testing.T) {
 			name:       "category not found",
 			categoryID: shared.ID(999),
 			setupMock: func(m *MockProductOptionCategoryRepository) {
-				m.On("FindByID", shared.ID(999)).Return(nil, errors.New("category not found"))
+				m.On("FindByID", shared.ID(999), uint64(456)).Return(nil, errors.New("category not found"))
 			},
 			wantCategory: nil,
 			wantErr:      true,
@@ -338,7 +338,7 @@ func TestProductFinderAdapter_FindOptionCategory(t *testing.T) {
 
 			tt.setupMock(mockCategoryRepo)
 
-			adapter := NewProductFinderAdapter(mockProductRepo, mockOptionRepo, mockCategoryRepo)
+			adapter := NewProductFinderAdapter(mockProductRepo, mockOptionRepo, mockCategoryRepo, 456)
 			got, err := adapter.FindOptionCategory(tt.categoryID)
 
 			if tt.wantErr {
@@ -363,7 +363,7 @@ func TestProductFinderAdapter_AllOperations(t *testing.T) {
 	mockCategoryRepo := new(MockProductOptionCategoryRepository)
 
 	// Setup product mock
-	mockProductRepo.On("FindByID", shared.ID(1)).Return(&product.Product{
+	mockProductRepo.On("FindByIDAndShopID", shared.ID(1), uint64(456)).Return(&product.Product{
 		ID:     shared.ID(1),
 		ShopID: 456,
 		Name:   "商品1",
@@ -371,20 +371,20 @@ func TestProductFinderAdapter_AllOperations(t *testing.T) {
 	}, nil)
 
 	// Setup option mock
-	mockOptionRepo.On("FindByID", shared.ID(10)).Return(&product.ProductOption{
+	mockOptionRepo.On("FindByID", shared.ID(10), uint64(456)).Return(&product.ProductOption{
 		ID:              shared.ID(10),
 		CategoryID:      shared.ID(100),
 		Name:            "大",
-		PriceAdjustment: 10,
+		PriceAdjustment: shared.NewPrice(10),
 	}, nil)
 
 	// Setup category mock
-	mockCategoryRepo.On("FindByID", shared.ID(100)).Return(&product.ProductOptionCategory{
+	mockCategoryRepo.On("FindByID", shared.ID(100), uint64(456)).Return(&product.ProductOptionCategory{
 		ID:   shared.ID(100),
 		Name: "尺寸",
 	}, nil)
 
-	adapter := NewProductFinderAdapter(mockProductRepo, mockOptionRepo, mockCategoryRepo)
+	adapter := NewProductFinderAdapter(mockProductRepo, mockOptionRepo, mockCategoryRepo, 456)
 
 	// Find product
 	prod, err := adapter.FindProduct(shared.ID(1))
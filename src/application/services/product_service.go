@@ -7,6 +7,7 @@ import (
 	"orderease/application/dto"
 	"orderease/domain/product"
 	"orderease/domain/shared"
+	"orderease/models"
 	"orderease/utils"
 	"orderease/utils/log2"
 	"os"
@@ -22,6 +23,7 @@ type ProductService struct {
 	productOptionRepo   product.ProductOptionRepository
 	productTagRepo      product.ProductTagRepository
 	db                  *gorm.DB
+	stockEventPublisher product.StockEventPublisher
 }
 
 func NewProductService(
@@ -30,6 +32,7 @@ func NewProductService(
 	productOptionRepo product.ProductOptionRepository,
 	productTagRepo product.ProductTagRepository,
 	db *gorm.DB,
+	stockEventPublisher product.StockEventPublisher,
 ) *ProductService {
 	return &ProductService{
 		productRepo:         productRepo,
@@ -37,11 +40,12 @@ func NewProductService(
 		productOptionRepo:   productOptionRepo,
 		productTagRepo:      productTagRepo,
 		db:                  db,
+		stockEventPublisher: stockEventPublisher,
 	}
 }
 
-func (s *ProductService) CreateProduct(req *dto.CreateProductRequest) (*dto.ProductResponse, error) {
-	prod, err := product.NewProduct(req.ShopID.ToUint64(), req.Name, req.Description, shared.Price(req.Price), req.Stock)
+func (s *ProductService) CreateProduct(req *dto.CreateProductRequest, actor AuditActor) (*dto.ProductResponse, error) {
+	prod, err := product.NewProduct(req.ShopID.ToUint64(), req.Name, req.Description, req.Price, req.Stock)
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +53,9 @@ func (s *ProductService) CreateProduct(req *dto.CreateProductRequest) (*dto.Prod
 	prod.ID = shared.ID(utils.GenerateSnowflakeID())
 	prod.Status = product.ProductStatusPending
 	prod.ImageURL = req.ImageURL
+	if err := prod.SetLowStockThreshold(req.LowStockThreshold); err != nil {
+		return nil, err
+	}
 
 	tx := s.db.Begin()
 	defer func() {
@@ -62,39 +69,56 @@ func (s *ProductService) CreateProduct(req *dto.CreateProductRequest) (*dto.Prod
 		return nil, errors.New("创建商品失败")
 	}
 
-	for _, catReq := range req.OptionCategories {
-		cat, err := product.NewProductOptionCategory(prod.ID, catReq.Name, catReq.IsRequired, catReq.IsMultiple, catReq.DisplayOrder)
-		if err != nil {
+	// 初始库存作为第一条库存流水
+	if prod.Stock > 0 {
+		movement := stockMovement{Type: product.StockMovementInitial, Actor: actor}
+		if _, err := saveStockMovement(s.db, prod.ShopID, prod.ID, prod.Stock, prod.Stock, movement); err != nil {
 			tx.Rollback()
 			return nil, err
 		}
+	}
 
-		cat.ID = shared.ID(utils.GenerateSnowflakeID())
-
-		if err := s.productCategoryRepo.Save(cat); err != nil {
+	for _, catReq := range req.OptionCategories {
+		if err := s.saveOptionCategory(prod.ID, catReq); err != nil {
 			tx.Rollback()
-			return nil, errors.New("创建商品参数类别失败")
+			return nil, err
 		}
+	}
 
-		for _, optReq := range catReq.Options {
-			opt, err := product.NewProductOption(cat.ID, optReq.Name, optReq.PriceAdjustment, optReq.IsDefault, optReq.DisplayOrder)
-			if err != nil {
-				tx.Rollback()
-				return nil, err
-			}
+	tx.Commit()
+
+	return s.getProductResponse(prod.ID, prod.ShopID)
+}
 
-			opt.ID = shared.ID(utils.GenerateSnowflakeID())
+// saveOptionCategory 创建商品的参数类别和选项，保存前校验选择数量和默认选项的设置
+func (s *ProductService) saveOptionCategory(productID shared.ID, catReq dto.CreateProductOptionCategoryRequest) error {
+	cat, err := product.NewProductOptionCategory(productID, catReq.Name, catReq.IsRequired, catReq.IsMultiple, catReq.DisplayOrder)
+	if err != nil {
+		return err
+	}
+	if err := cat.SetSelectionLimits(catReq.MinSelect, catReq.MaxSelect); err != nil {
+		return err
+	}
+	cat.ID = shared.ID(utils.GenerateSnowflakeID())
 
-			if err := s.productOptionRepo.Save(opt); err != nil {
-				tx.Rollback()
-				return nil, errors.New("创建商品参数选项失败")
-			}
+	for _, optReq := range catReq.Options {
+		opt, err := product.NewProductOption(cat.ID, optReq.Name, optReq.PriceAdjustment, optReq.IsDefault, optReq.DisplayOrder)
+		if err != nil {
+			return err
 		}
+		opt.ID = shared.ID(utils.GenerateSnowflakeID())
+		cat.Options = append(cat.Options, *opt)
 	}
 
-	tx.Commit()
+	if err := cat.ValidateOptions(); err != nil {
+		return err
+	}
 
-	return s.getProductResponse(prod.ID)
+	// 类别和选项一起保存
+	if err := s.productCategoryRepo.Save(cat); err != nil {
+		return errors.New("创建商品参数类别失败")
+	}
+	return nil
 }
 
 func (s *ProductService) GetProduct(id shared.ID, shopID shared.ID) (*dto.ProductResponse, error) {
@@ -125,17 +149,41 @@ func (s *ProductService) GetProducts(shopID shared.ID, page, pageSize int, searc
 	}, nil
 }
 
-func (s *ProductService) UpdateProduct(id shared.ID, shopID shared.ID, req *dto.CreateProductRequest) (*dto.ProductResponse, error) {
+// GetLowStockProducts 查询店铺内已售罄或库存不高于预警值的商品
+func (s *ProductService) GetLowStockProducts(shopID shared.ID) (*dto.LowStockListResponse, error) {
+	products, err := s.productRepo.FindLowStock(shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]dto.ProductResponse, len(products))
+	for i, prod := range products {
+		data[i] = *s.toProductResponse(&prod)
+	}
+
+	return &dto.LowStockListResponse{
+		Total: len(data),
+		Data:  data,
+	}, nil
+}
+
+func (s *ProductService) UpdateProduct(id shared.ID, shopID shared.ID, req *dto.CreateProductRequest, actor AuditActor) (*dto.ProductResponse, error) {
 	prod, err := s.productRepo.FindByIDAndShopID(id, shopID.ToUint64())
 	if err != nil {
 		return nil, err
 	}
 
+	if req.Stock < 0 {
+		return nil, errors.New("商品库存不能为负数")
+	}
+
 	prod.Name = req.Name
 	prod.Description = req.Description
-	prod.Price = shared.Price(req.Price)
-	prod.Stock = req.Stock
+	prod.Price = req.Price
 	prod.ImageURL = req.ImageURL
+	if err := prod.SetLowStockThreshold(req.LowStockThreshold); err != nil {
+		return nil, err
+	}
 
 	tx := s.db.Begin()
 	defer func() {
@@ -149,44 +197,36 @@ func (s *ProductService) UpdateProduct(id shared.ID, shopID shared.ID, req *dto.
 		return nil, errors.New("更新商品失败")
 	}
 
+	// 编辑商品时修改了库存，按手工调整记录差额
+	var stock stockChanges
+	if req.Stock != prod.Stock {
+		err := WithTx(s.db, func(tx *gorm.DB) error {
+			movement := stockMovement{Type: product.StockMovementAdjustment, Actor: actor, Reason: "编辑商品"}
+			_, err := stock.set(tx, prod.ShopID, prod.ID, req.Stock, movement)
+			return err
+		})
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
 	if err := s.productCategoryRepo.DeleteByProductID(prod.ID); err != nil {
 		tx.Rollback()
 		return nil, errors.New("删除商品参数类别失败")
 	}
 
 	for _, catReq := range req.OptionCategories {
-		cat, err := product.NewProductOptionCategory(prod.ID, catReq.Name, catReq.IsRequired, catReq.IsMultiple, catReq.DisplayOrder)
-		if err != nil {
+		if err := s.saveOptionCategory(prod.ID, catReq); err != nil {
 			tx.Rollback()
 			return nil, err
 		}
-
-		cat.ID = shared.ID(utils.GenerateSnowflakeID())
-
-		if err := s.productCategoryRepo.Save(cat); err != nil {
-			tx.Rollback()
-			return nil, errors.New("创建商品参数类别失败")
-		}
-
-		for _, optReq := range catReq.Options {
-			opt, err := product.NewProductOption(cat.ID, optReq.Name, optReq.PriceAdjustment, optReq.IsDefault, optReq.DisplayOrder)
-			if err != nil {
-				tx.Rollback()
-				return nil, err
-			}
-
-			opt.ID = shared.ID(utils.GenerateSnowflakeID())
-
-			if err := s.productOptionRepo.Save(opt); err != nil {
-				tx.Rollback()
-				return nil, errors.New("创建商品参数选项失败")
-			}
-		}
 	}
 
 	tx.Commit()
+	stock.publish(s.stockEventPublisher)
 
-	return s.getProductResponse(prod.ID)
+	return s.getProductResponse(prod.ID, prod.ShopID)
 }
 
 func (s *ProductService) DeleteProduct(id shared.ID, shopID shared.ID) error {
@@ -195,7 +235,7 @@ func (s *ProductService) DeleteProduct(id shared.ID, shopID shared.ID) error {
 		return err
 	}
 
-	count, err := s.productRepo.CountByProductID(prod.ID)
+	count, err := s.productRepo.CountByProductID(prod.ID, shopID.ToUint64())
 	if err != nil {
 		return err
 	}
@@ -233,7 +273,7 @@ func (s *ProductService) DeleteProduct(id shared.ID, shopID shared.ID) error {
 		return errors.New("删除商品标签关联失败")
 	}
 
-	if err := s.productRepo.Delete(prod.ID); err != nil {
+	if err := s.productRepo.Delete(prod.ID, prod.ShopID); err != nil {
 		tx.Rollback()
 		return errors.New("删除商品失败: " + err.Error())
 	}
@@ -252,6 +292,8 @@ func (s *ProductService) UpdateProductStatus(req *dto.UpdateProductStatusRequest
 	if err := prod.ChangeStatus(req.Status); err != nil {
 		return err
 	}
+	// 手工上下架后不再由库存自动恢复
+	prod.AutoOffline = false
 
 	if err := s.productRepo.Update(prod); err != nil {
 		return errors.New("更新商品状态失败")
@@ -303,8 +345,8 @@ func (s *ProductService) UploadProductImage(id shared.ID, shopID shared.ID, file
 	return newFilename, nil
 }
 
-func (s *ProductService) getProductResponse(id shared.ID) (*dto.ProductResponse, error) {
-	prod, err := s.productRepo.FindByID(id)
+func (s *ProductService) getProductResponse(id shared.ID, shopID uint64) (*dto.ProductResponse, error) {
+	prod, err := s.productRepo.FindByIDAndShopID(id, shopID)
 	if err != nil {
 		return nil, err
 	}
@@ -332,6 +374,8 @@ func (s *ProductService) toProductResponse(prod *product.Product) *dto.ProductRe
 			Name:         cat.Name,
 			IsRequired:   cat.IsRequired,
 			IsMultiple:   cat.IsMultiple,
+			MinSelect:    cat.MinSelect,
+			MaxSelect:    cat.MaxSelect,
 			DisplayOrder: cat.DisplayOrder,
 			Options:      options,
 		}
@@ -349,5 +393,91 @@ func (s *ProductService) toProductResponse(prod *product.Product) *dto.ProductRe
 		CreatedAt:        prod.CreatedAt,
 		UpdatedAt:        prod.UpdatedAt,
 		OptionCategories: categories,
+
+		LowStockThreshold: prod.LowStockThreshold,
+		LowStock:          prod.IsLowStock(),
+		AutoOffline:       prod.AutoOffline,
+	}
+}
+
+// AdjustStock 手工调整库存或提交盘点结果，必须填写原因
+func (s *ProductService) AdjustStock(shopID shared.ID, req *dto.AdjustStockRequest, actor AuditActor) (*dto.StockMovementResponse, error) {
+	if err := product.ValidateManualStockChange(req.Type, req.Quantity, req.Reason); err != nil {
+		return nil, err
+	}
+
+	prod, err := s.productRepo.FindByIDAndShopID(req.ID, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	var record *models.StockMovement
+	var stock stockChanges
+	movement := stockMovement{Type: req.Type, Actor: actor, Reason: req.Reason}
+	err = WithTx(s.db, func(tx *gorm.DB) error {
+		var err error
+		if req.Type == product.StockMovementStocktake {
+			record, err = stock.set(tx, prod.ShopID, prod.ID, req.Quantity, movement)
+		} else {
+			record, err = stock.change(tx, prod.ShopID, prod.ID, req.Quantity, movement)
+		}
+		if err == nil && record == nil {
+			err = errors.New("商品不存在")
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	stock.publish(s.stockEventPublisher)
+
+	response := toStockMovementResponse(*record)
+	return &response, nil
+}
+
+// GetStockMovements 分页查询商品的库存流水，按时间倒序
+func (s *ProductService) GetStockMovements(query dto.StockMovementQuery) (*dto.StockMovementListResponse, error) {
+	prod, err := s.productRepo.FindByIDAndShopID(query.ProductID, query.ShopID.ToUint64())
+	if err != nil {
+		return nil, err
 	}
+
+	db := s.db.Model(&models.StockMovement{}).
+		Where("shop_id = ? AND product_id = ?", prod.ShopID, prod.ID.Value())
+	if query.Type != "" {
+		db = db.Where("type = ?", string(query.Type))
+	}
+	if !query.StartTime.IsZero() {
+		db = db.Where("created_at >= ?", query.StartTime)
+	}
+	if !query.EndTime.IsZero() {
+		db = db.Where("created_at <= ?", query.EndTime)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		log2.Errorf("查询库存流水总数失败: %v", err)
+		return nil, errors.New("查询库存流水失败")
+	}
+
+	var records []models.StockMovement
+	offset := (query.Page - 1) * query.PageSize
+	if err := db.Order("created_at DESC, id DESC").Offset(offset).Limit(query.PageSize).Find(&records).Error; err != nil {
+		log2.Errorf("查询库存流水失败: %v", err)
+		return nil, errors.New("查询库存流水失败")
+	}
+
+	data := make([]dto.StockMovementResponse, len(records))
+	for i, record := range records {
+		data[i] = toStockMovementResponse(record)
+	}
+
+	return &dto.StockMovementListResponse{
+		ProductID: prod.ID,
+		Stock:     prod.Stock,
+		Total:     total,
+		Page:      query.Page,
+		PageSize:  query.PageSize,
+		Data:      data,
+	}, nil
 }
@@ -30,8 +30,8 @@ func (m *MockProductTagRepository) FindByProductID(productID shared.ID) ([]int,
 	return args.Get(0).([]int), args.Error(1)
 }
 
-func (m *MockProductTagRepository) FindByTagID(tagID int) ([]shared.ID, error) {
-	args := m.Called(tagID)
+func (m *MockProductTagRepository) FindByTagID(tagID int, shopID uint64) ([]shared.ID, error) {
+	args := m.Called(tagID, shopID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -56,6 +56,7 @@ func TestNewProductService(t *testing.T) {
 		mockProductOptionRepo,
 		mockProductTagRepo,
 		nil,
+		nil,
 	)
 
 	assert.NotNil(t, service)
@@ -73,6 +74,7 @@ func TestProductService_GetProduct(t *testing.T) {
 		mockProductOptionRepo,
 		mockProductTagRepo,
 		nil,
+		nil,
 	)
 
 	tests := []struct {
@@ -94,7 +96,7 @@ func TestProductService_GetProduct(t *testing.T) {
 					ShopID:      456,
 					Name:        "测试商品",
 					Description: "这是一个测试商品",
-					Price:       shared.Price(100),
+					Price:       shared.NewPrice(100),
 					Stock:       10,
 					ImageURL:    "test.jpg",
 					Status:      product.ProductStatusOnline,
@@ -103,7 +105,7 @@ func TestProductService_GetProduct(t *testing.T) {
 							ID:     shared.ID(1),
 							Name:   "尺寸",
 							Options: []product.ProductOption{
-								{ID: shared.ID(10), Name: "大", PriceAdjustment: 10},
+								{ID: shared.ID(10), Name: "大", PriceAdjustment: shared.NewPrice(10)},
 							},
 						},
 					},
@@ -116,7 +118,7 @@ func TestProductService_GetProduct(t *testing.T) {
 				assert.Equal(t, shared.ID(456), resp.ShopID)
 				assert.Equal(t, "测试商品", resp.Name)
 				assert.Equal(t, "这是一个测试商品", resp.Description)
-				assert.Equal(t, shared.Price(100), resp.Price)
+				assert.Equal(t, shared.NewPrice(100), resp.Price)
 				assert.Equal(t, 10, resp.Stock)
 				assert.Equal(t, "test.jpg", resp.ImageURL)
 				assert.Equal(t, product.ProductStatusOnline, resp.Status)
@@ -173,6 +175,7 @@ func TestProductService_GetProducts(t *testing.T) {
 		mockProductOptionRepo,
 		mockProductTagRepo,
 		nil,
+		nil,
 	)
 
 	tests := []struct {
@@ -193,8 +196,8 @@ func TestProductService_GetProducts(t *testing.T) {
 			search:   "测试",
 			setupMock: func(shopID shared.ID, page, pageSize int, search string) {
 				products := []product.Product{
-					{ID: shared.ID(1), ShopID: 456, Name: "测试商品1", Price: shared.Price(100)},
-					{ID: shared.ID(2), ShopID: 456, Name: "测试商品2", Price: shared.Price(200)},
+					{ID: shared.ID(1), ShopID: 456, Name: "测试商品1", Price: shared.NewPrice(100)},
+					{ID: shared.ID(2), ShopID: 456, Name: "测试商品2", Price: shared.NewPrice(200)},
 				}
 				mockProductRepo.On("FindByShopID", shopID.ToUint64(), page, pageSize, search, true).Return(products, int64(2), nil)
 			},
@@ -259,6 +262,7 @@ func TestProductService_UpdateProductStatus(t *testing.T) {
 		mockProductOptionRepo,
 		mockProductTagRepo,
 		nil,
+		nil,
 	)
 
 	tests := []struct {
@@ -350,6 +354,7 @@ func TestProductService_toProductResponse(t *testing.T) {
 		mockProductOptionRepo,
 		mockProductTagRepo,
 		nil,
+		nil,
 	)
 
 	tests := []struct {
@@ -364,7 +369,7 @@ func TestProductService_toProductResponse(t *testing.T) {
 				ShopID:      456,
 				Name:        "测试商品",
 				Description: "测试描述",
-				Price:       shared.Price(100),
+				Price:       shared.NewPrice(100),
 				Stock:       10,
 				ImageURL:    "test.jpg",
 				Status:      product.ProductStatusOnline,
@@ -380,14 +385,14 @@ func TestProductService_toProductResponse(t *testing.T) {
 								ID:              shared.ID(10),
 								CategoryID:      shared.ID(1),
 								Name:            "大",
-								PriceAdjustment: 10,
+								PriceAdjustment: shared.NewPrice(10),
 								IsDefault:       true,
 							},
 							{
 								ID:              shared.ID(11),
 								CategoryID:      shared.ID(1),
 								Name:            "小",
-								PriceAdjustment: 0,
+								PriceAdjustment: shared.NewPrice(0),
 								IsDefault:       false,
 							},
 						},
@@ -406,7 +411,7 @@ func TestProductService_toProductResponse(t *testing.T) {
 				assert.Equal(t, shared.ID(456), resp.ShopID)
 				assert.Equal(t, "测试商品", resp.Name)
 				assert.Equal(t, "测试描述", resp.Description)
-				assert.Equal(t, shared.Price(100), resp.Price)
+				assert.Equal(t, shared.NewPrice(100), resp.Price)
 				assert.Equal(t, 10, resp.Stock)
 				assert.Equal(t, "test.jpg", resp.ImageURL)
 				assert.Equal(t, product.ProductStatusOnline, resp.Status)
@@ -419,7 +424,7 @@ func TestProductService_toProductResponse(t *testing.T) {
 				assert.False(t, resp.OptionCategories[0].IsMultiple)
 				assert.Len(t, resp.OptionCategories[0].Options, 2)
 				assert.Equal(t, "大", resp.OptionCategories[0].Options[0].Name)
-				assert.Equal(t, 10.0, resp.OptionCategories[0].Options[0].PriceAdjustment)
+				assert.Equal(t, shared.NewPrice(10.0), resp.OptionCategories[0].Options[0].PriceAdjustment)
 				assert.True(t, resp.OptionCategories[0].Options[0].IsDefault)
 
 				// Second category
@@ -436,7 +441,7 @@ func TestProductService_toProductResponse(t *testing.T) {
 				ShopID:           789,
 				Name:             "简单商品",
 				Description:      "",
-				Price:            shared.Price(50),
+				Price:            shared.NewPrice(50),
 				Stock:            5,
 				ImageURL:         "",
 				Status:           product.ProductStatusPending,
@@ -446,7 +451,7 @@ func TestProductService_toProductResponse(t *testing.T) {
 				assert.Equal(t, shared.ID(456), resp.ID)
 				assert.Equal(t, "简单商品", resp.Name)
 				assert.Empty(t, resp.Description)
-				assert.Equal(t, shared.Price(50), resp.Price)
+				assert.Equal(t, shared.NewPrice(50), resp.Price)
 				assert.Len(t, resp.OptionCategories, 0)
 			},
 		},
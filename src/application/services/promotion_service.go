@@ -0,0 +1,421 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"orderease/application/dto"
+	"orderease/domain/order"
+	"orderease/domain/promotion"
+	"orderease/domain/shared"
+	"orderease/infrastructure/repositories"
+	"orderease/models"
+	"orderease/utils"
+	"orderease/utils/log2"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PromotionService 店铺优惠券和自动促销管理
+type PromotionService struct {
+	promotionRepo promotion.PromotionRepository
+	db            *gorm.DB
+}
+
+func NewPromotionService(promotionRepo promotion.PromotionRepository, db *gorm.DB) *PromotionService {
+	return &PromotionService{
+		promotionRepo: promotionRepo,
+		db:            db,
+	}
+}
+
+func (s *PromotionService) CreatePromotion(req *dto.CreatePromotionRequest) (*dto.PromotionResponse, error) {
+	p, err := promotion.NewPromotion(req.ShopID, req.Name, req.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(p, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.promotionRepo.Save(p); err != nil {
+		return nil, errors.New("创建优惠失败")
+	}
+
+	log2.Infof("创建优惠成功: 店铺 %s, 优惠 %s, 券码 %q", req.ShopID.String(), p.Name, p.Code)
+	return toPromotionResponse(p), nil
+}
+
+// UpdatePromotion 整体更新优惠配置，已使用次数保持不变
+func (s *PromotionService) UpdatePromotion(req *dto.UpdatePromotionRequest) (*dto.PromotionResponse, error) {
+	p, err := s.promotionRepo.FindByIDAndShopID(req.ID, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Name = req.Name
+	p.Type = req.Type
+	if err := s.applyRequest(p, &req.CreatePromotionRequest); err != nil {
+		return nil, err
+	}
+	if req.Active != nil {
+		p.Active = *req.Active
+	}
+	p.UpdatedAt = time.Now()
+
+	if err := s.promotionRepo.Update(p); err != nil {
+		return nil, err
+	}
+	return toPromotionResponse(p), nil
+}
+
+func (s *PromotionService) DeletePromotion(id shared.ID, shopID shared.ID) error {
+	if _, err := s.promotionRepo.FindByIDAndShopID(id, shopID); err != nil {
+		return err
+	}
+	// 已下单的优惠快照保存在订单中，删除优惠不影响历史订单
+	return s.promotionRepo.Delete(id, shopID)
+}
+
+func (s *PromotionService) GetPromotion(id shared.ID, shopID shared.ID) (*dto.PromotionResponse, error) {
+	p, err := s.promotionRepo.FindByIDAndShopID(id, shopID)
+	if err != nil {
+		return nil, err
+	}
+	return toPromotionResponse(p), nil
+}
+
+func (s *PromotionService) GetPromotions(shopID shared.ID) ([]dto.PromotionResponse, error) {
+	promotions, err := s.promotionRepo.FindByShopID(shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.PromotionResponse, len(promotions))
+	for i := range promotions {
+		result[i] = *toPromotionResponse(&promotions[i])
+	}
+	return result, nil
+}
+
+// applyRequest 把请求中的优惠配置写入实体并校验，券码在店铺内不能重复
+func (s *PromotionService) applyRequest(p *promotion.Promotion, req *dto.CreatePromotionRequest) error {
+	p.Name = req.Name
+	p.Code = promotion.NormalizeCode(req.Code)
+	p.Amount = req.Amount
+	p.Percent = req.Percent
+	p.NthItem = req.NthItem
+	p.MaxDiscount = req.MaxDiscount
+	p.MinSpend = req.MinSpend
+	p.UsageLimit = req.UsageLimit
+	p.PerUserLimit = req.PerUserLimit
+	p.ProductIDs = req.ProductIDs
+	p.TagIDs = req.TagIDs
+	p.StartAt = time.Time{}
+	if req.StartAt != nil {
+		p.StartAt = *req.StartAt
+	}
+	p.EndAt = time.Time{}
+	if req.EndAt != nil {
+		p.EndAt = *req.EndAt
+	}
+
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	if p.IsCoupon() {
+		if existing, err := s.promotionRepo.FindByCode(p.ShopID, p.Code); err == nil && existing.ID != p.ID {
+			return errors.New("券码已存在")
+		}
+	}
+	return nil
+}
+
+func toPromotionResponse(p *promotion.Promotion) *dto.PromotionResponse {
+	resp := &dto.PromotionResponse{
+		ID:           p.ID,
+		ShopID:       p.ShopID,
+		Name:         p.Name,
+		Code:         p.Code,
+		Type:         p.Type,
+		Amount:       p.Amount,
+		Percent:      p.Percent,
+		NthItem:      p.NthItem,
+		MaxDiscount:  p.MaxDiscount,
+		MinSpend:     p.MinSpend,
+		UsageLimit:   p.UsageLimit,
+		PerUserLimit: p.PerUserLimit,
+		UsedCount:    p.UsedCount,
+		ProductIDs:   p.ProductIDs,
+		TagIDs:       p.TagIDs,
+		Active:       p.Active,
+		CreatedAt:    p.CreatedAt,
+		UpdatedAt:    p.UpdatedAt,
+	}
+	if !p.StartAt.IsZero() {
+		startAt := p.StartAt
+		resp.StartAt = &startAt
+	}
+	if !p.EndAt.IsZero() {
+		endAt := p.EndAt
+		resp.EndAt = &endAt
+	}
+	return resp
+}
+
+// ============ 下单时的优惠计算 ============
+
+// promotionLines 把订单项转换为优惠计算的订单行，并带上商品标签用于按标签限定范围
+func promotionLines(tx *gorm.DB, ord *order.Order) ([]promotion.Line, error) {
+	productIDs := make([]uint64, 0, len(ord.Items))
+	for _, item := range ord.Items {
+		productIDs = append(productIDs, item.ProductID.ToUint64())
+	}
+
+	var productTags []models.ProductTag
+	if err := tx.Where("product_id IN ?", productIDs).Find(&productTags).Error; err != nil {
+		log2.Errorf("查询商品标签失败: %v", err)
+		return nil, errors.New("计算订单优惠失败")
+	}
+	tagIDs := make(map[shared.ID][]int)
+	for _, pt := range productTags {
+		tagIDs[shared.ID(pt.ProductID)] = append(tagIDs[shared.ID(pt.ProductID)], pt.TagID)
+	}
+
+	lines := make([]promotion.Line, 0, len(ord.Items))
+	for _, item := range ord.Items {
+		if item.Quantity <= 0 {
+			continue
+		}
+		lines = append(lines, promotion.Line{
+			ProductID: item.ProductID,
+			TagIDs:    tagIDs[item.ProductID],
+			Quantity:  item.Quantity,
+			UnitPrice: shared.PriceFromCents(item.TotalPrice.Cents() / int64(item.Quantity)),
+		})
+	}
+	return lines, nil
+}
+
+// applyOrderPromotions 在下单事务内计算订单优惠：先校验优惠券，再叠加店铺的自动促销
+// 优惠券不可用时返回明确的原因；自动促销不满足条件时直接跳过
+// 命中的优惠在同一事务内累加使用次数并记录使用人，订单回滚时一并撤销
+func applyOrderPromotions(tx *gorm.DB, ord *order.Order, couponCode string, now time.Time) error {
+	lines, err := promotionLines(tx, ord)
+	if err != nil {
+		return err
+	}
+
+	shopID := shared.ParseIDFromUint64(ord.ShopID)
+	repo := repositories.NewPromotionRepository(tx)
+
+	var candidates []promotion.Promotion
+	if code := promotion.NormalizeCode(couponCode); code != "" {
+		coupon, err := repo.FindByCode(shopID, code)
+		if err != nil {
+			return err
+		}
+		candidates = append(candidates, *coupon)
+	}
+	automatic, err := repo.FindAutomatic(shopID)
+	if err != nil {
+		return err
+	}
+	candidates = append(candidates, automatic...)
+
+	remaining := ord.ItemsTotal()
+	var discounts []order.OrderDiscount
+	for i := range candidates {
+		p := &candidates[i]
+
+		err := p.CheckAvailable(now)
+		var amount shared.Price
+		if err == nil {
+			amount, err = p.Calculate(lines)
+		}
+		if err == nil {
+			// 多个优惠叠加时合计不超过商品小计，订单已全额减免时不再占用后续优惠
+			amount = amount.Min(remaining)
+			if !amount.IsPositive() {
+				continue
+			}
+			err = redeemPromotion(tx, p, ord)
+		}
+		if err != nil {
+			if p.IsCoupon() {
+				return fmt.Errorf("优惠券 %s 不可用: %w", p.Code, err)
+			}
+			continue
+		}
+
+		remaining = remaining.Sub(amount)
+		discounts = append(discounts, order.OrderDiscount{
+			OrderID:     ord.ID,
+			PromotionID: p.ID,
+			Name:        p.Name,
+			Code:        p.Code,
+			Type:        string(p.Type),
+			Amount:      amount,
+			CreatedAt:   now,
+		})
+	}
+
+	ord.ApplyDiscounts(discounts)
+	return nil
+}
+
+// redeemPromotion 占用一次优惠使用次数并记录使用人
+// 以 used_count < usage_limit 为条件累加，同时锁定优惠行，同一用户并发下单时每人次数的统计不会重复
+func redeemPromotion(tx *gorm.DB, p *promotion.Promotion, ord *order.Order) error {
+	result := tx.Model(&models.Promotion{}).
+		Where("id = ? AND shop_id = ? AND (usage_limit = 0 OR used_count < usage_limit)", p.ID.Value(), ord.ShopID).
+		Update("used_count", gorm.Expr("used_count + 1"))
+	if result.Error != nil {
+		log2.Errorf("更新优惠使用次数失败, 优惠ID: %s, 错误: %v", p.ID, result.Error)
+		return errors.New("更新优惠使用次数失败")
+	}
+	if result.RowsAffected == 0 {
+		return promotion.ErrUsageLimitReached
+	}
+
+	if p.PerUserLimit > 0 {
+		var used int64
+		if err := tx.Model(&models.PromotionRedemption{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("promotion_id = ? AND user_id = ?", p.ID.Value(), ord.UserID.Value()).
+			Count(&used).Error; err != nil {
+			log2.Errorf("查询优惠使用记录失败, 优惠ID: %s, 错误: %v", p.ID, err)
+			return errors.New("查询优惠使用记录失败")
+		}
+		if used >= int64(p.PerUserLimit) {
+			// 自动促销跳过后事务仍会提交，需要撤销刚才累加的次数
+			if err := tx.Model(&models.Promotion{}).Where("id = ?", p.ID.Value()).
+				Update("used_count", gorm.Expr("used_count - 1")).Error; err != nil {
+				log2.Errorf("撤销优惠使用次数失败, 优惠ID: %s, 错误: %v", p.ID, err)
+				return errors.New("更新优惠使用次数失败")
+			}
+			return promotion.ErrUserLimitReached
+		}
+	}
+
+	redemption := models.PromotionRedemption{
+		ID:          utils.GenerateSnowflakeID(),
+		ShopID:      snowflake.ID(ord.ShopID),
+		PromotionID: p.ID.Value(),
+		UserID:      ord.UserID.Value(),
+		OrderID:     ord.ID.Value(),
+		CreatedAt:   time.Now(),
+	}
+	if err := tx.Create(&redemption).Error; err != nil {
+		log2.Errorf("记录优惠使用失败, 优惠ID: %s, 错误: %v", p.ID, err)
+		return errors.New("记录优惠使用失败")
+	}
+	return nil
+}
+
+// releaseOrderPromotions 订单取消或删除时归还优惠使用次数，promotionIDs 为空时归还订单使用的全部优惠
+func releaseOrderPromotions(tx *gorm.DB, orderID shared.ID, promotionIDs ...shared.ID) error {
+	query := tx.Where("order_id = ?", orderID.Value())
+	if len(promotionIDs) > 0 {
+		ids := make([]uint64, len(promotionIDs))
+		for i, id := range promotionIDs {
+			ids[i] = id.ToUint64()
+		}
+		query = query.Where("promotion_id IN ?", ids)
+	}
+
+	var redemptions []models.PromotionRedemption
+	if err := query.Find(&redemptions).Error; err != nil {
+		log2.Errorf("查询优惠使用记录失败, 订单ID: %s, 错误: %v", orderID, err)
+		return errors.New("归还优惠使用次数失败")
+	}
+
+	for _, redemption := range redemptions {
+		if err := tx.Model(&models.Promotion{}).
+			Where("id = ? AND used_count > 0", redemption.PromotionID).
+			Update("used_count", gorm.Expr("used_count - 1")).Error; err != nil {
+			log2.Errorf("归还优惠使用次数失败, 优惠ID: %d, 错误: %v", redemption.PromotionID, err)
+			return errors.New("归还优惠使用次数失败")
+		}
+		if err := tx.Delete(&models.PromotionRedemption{}, redemption.ID).Error; err != nil {
+			log2.Errorf("删除优惠使用记录失败, 订单ID: %s, 错误: %v", orderID, err)
+			return errors.New("归还优惠使用次数失败")
+		}
+	}
+	return nil
+}
+
+// recalculateOrderDiscounts 修改订单后按新的订单项重新计算已享受的优惠，不再叠加新的自动促销
+// 优惠券不再满足条件时拒绝修改；自动促销不再满足条件时取消该优惠并归还使用次数
+// 优惠已被删除时保留下单时的减免金额，减免为0的优惠不再保留并归还使用次数
+func recalculateOrderDiscounts(tx *gorm.DB, ord *order.Order, previous []order.OrderDiscount) error {
+	if len(previous) == 0 {
+		ord.ApplyDiscounts(nil)
+		return nil
+	}
+
+	lines, err := promotionLines(tx, ord)
+	if err != nil {
+		return err
+	}
+
+	repo := repositories.NewPromotionRepository(tx)
+	shopID := shared.ParseIDFromUint64(ord.ShopID)
+
+	var discounts []order.OrderDiscount
+	var dropped []shared.ID
+	for _, discount := range previous {
+		p, err := repo.FindByIDAndShopID(discount.PromotionID, shopID)
+		if err != nil {
+			discounts = append(discounts, discount)
+			continue
+		}
+
+		amount, err := p.Calculate(lines)
+		if err != nil {
+			if discount.Code != "" {
+				return fmt.Errorf("修改后的订单不满足优惠券 %s 的使用条件: %w", discount.Code, err)
+			}
+			dropped = append(dropped, discount.PromotionID)
+			continue
+		}
+		discount.Amount = amount
+		discounts = append(discounts, discount)
+	}
+
+	ord.ApplyDiscounts(discounts)
+
+	// 优惠合计超过新的商品小计时，被减免为0而不再保留的优惠同样归还使用次数
+	kept := make(map[shared.ID]bool, len(ord.Discounts))
+	for _, discount := range ord.Discounts {
+		kept[discount.PromotionID] = true
+	}
+	for _, discount := range discounts {
+		if !kept[discount.PromotionID] {
+			dropped = append(dropped, discount.PromotionID)
+		}
+	}
+
+	if len(dropped) > 0 {
+		if err := releaseOrderPromotions(tx, ord.ID, dropped...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveOrderDiscounts 保存订单优惠快照
+func saveOrderDiscounts(repos orderTxRepos, orderID shared.ID, discounts []order.OrderDiscount) error {
+	for i := range discounts {
+		discounts[i].ID = 0
+		discounts[i].OrderID = orderID
+		if err := repos.discounts.Save(&discounts[i]); err != nil {
+			return errors.New("保存订单优惠失败")
+		}
+	}
+	return nil
+}
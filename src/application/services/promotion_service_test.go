@@ -0,0 +1,279 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"orderease/application/dto"
+	"orderease/domain/order"
+	"orderease/domain/promotion"
+	"orderease/domain/shared"
+	"orderease/infrastructure/repositories"
+	"orderease/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func createTestPromotion(t *testing.T, db *gorm.DB, req dto.CreatePromotionRequest) *dto.PromotionResponse {
+	req.ShopID = shared.ParseIDFromUint64(stockTestShopID)
+	resp, err := NewPromotionService(repositories.NewPromotionRepository(db), db).CreatePromotion(&req)
+	require.NoError(t, err)
+	return resp
+}
+
+func promotionUsedCount(t *testing.T, db *gorm.DB, id shared.ID) int {
+	var p models.Promotion
+	require.NoError(t, db.First(&p, id.Value()).Error)
+	return p.UsedCount
+}
+
+func placeOrder(service *OrderService, userID shared.ID, productID shared.ID, quantity int, couponCode string) (*dto.OrderResponse, error) {
+	return service.CreateOrder(&dto.CreateOrderRequest{
+		UserID:     userID,
+		ShopID:     shared.ParseIDFromUint64(stockTestShopID),
+		Items:      []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: quantity}},
+		CouponCode: couponCode,
+	})
+}
+
+func TestOrderService_Coupons(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	flow := stockTestFlow()
+	alice, bob, carol := shared.ID(9001), shared.ID(9002), shared.ID(9003)
+
+	service, db, productID := setupStockTest(t, 100)
+	coupon := createTestPromotion(t, db, dto.CreatePromotionRequest{
+		Name:         "满20减5",
+		Code:         "save5",
+		Type:         promotion.DiscountFixedAmount,
+		Amount:       shared.NewPrice(5),
+		MinSpend:     shared.NewPrice(20),
+		UsageLimit:   2,
+		PerUserLimit: 1,
+	})
+	assert.Equal(t, "SAVE5", coupon.Code)
+
+	t.Run("unknown coupon", func(t *testing.T) {
+		_, err := placeOrder(service, alice, productID, 2, "NOPE")
+		assert.EqualError(t, err, "优惠券不存在")
+		assert.Equal(t, 100, currentStock(t, db, productID))
+	})
+
+	t.Run("min spend not reached", func(t *testing.T) {
+		_, err := placeOrder(service, alice, productID, 1, "SAVE5")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "优惠券 SAVE5 不可用: 未达到优惠最低消费 20.00 元")
+		assert.Equal(t, 100, currentStock(t, db, productID))
+		assert.Equal(t, 0, promotionUsedCount(t, db, coupon.ID))
+	})
+
+	var aliceOrder shared.ID
+	t.Run("applies coupon and snapshots the discount", func(t *testing.T) {
+		resp, err := placeOrder(service, alice, productID, 2, " save5 ")
+		require.NoError(t, err)
+		aliceOrder = resp.ID
+		assert.Equal(t, "19.00", resp.TotalPrice.String())
+		assert.Equal(t, "5.00", resp.DiscountAmount.String())
+
+		detail, err := service.GetOrder(resp.ID, shopID)
+		require.NoError(t, err)
+		assert.Equal(t, "19.00", detail.TotalPrice.String())
+		require.Len(t, detail.Discounts, 1)
+		assert.Equal(t, coupon.ID, detail.Discounts[0].PromotionID)
+		assert.Equal(t, "满20减5", detail.Discounts[0].Name)
+		assert.Equal(t, "SAVE5", detail.Discounts[0].Code)
+		assert.Equal(t, "5.00", detail.Discounts[0].Amount.String())
+		assert.Equal(t, 1, promotionUsedCount(t, db, coupon.ID))
+	})
+
+	t.Run("per user limit", func(t *testing.T) {
+		_, err := placeOrder(service, alice, productID, 2, "SAVE5")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), promotion.ErrUserLimitReached.Error())
+		assert.Equal(t, 1, promotionUsedCount(t, db, coupon.ID))
+	})
+
+	t.Run("update keeps used count", func(t *testing.T) {
+		_, err := NewPromotionService(repositories.NewPromotionRepository(db), db).UpdatePromotion(&dto.UpdatePromotionRequest{
+			ID: coupon.ID,
+			CreatePromotionRequest: dto.CreatePromotionRequest{
+				ShopID:       shopID,
+				Name:         "满20减5",
+				Code:         "SAVE5",
+				Type:         promotion.DiscountFixedAmount,
+				Amount:       shared.NewPrice(5),
+				MinSpend:     shared.NewPrice(20),
+				UsageLimit:   2,
+				PerUserLimit: 1,
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, promotionUsedCount(t, db, coupon.ID))
+	})
+
+	t.Run("total usage limit", func(t *testing.T) {
+		_, err := placeOrder(service, bob, productID, 2, "SAVE5")
+		require.NoError(t, err)
+		_, err = placeOrder(service, carol, productID, 2, "SAVE5")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), promotion.ErrUsageLimitReached.Error())
+		assert.Equal(t, 2, promotionUsedCount(t, db, coupon.ID))
+	})
+
+	t.Run("cancel releases usage", func(t *testing.T) {
+		require.NoError(t, service.UpdateOrderStatus(aliceOrder, shopID, order.OrderStatusCanceled, flow, order.StatusActor{Type: "user"}, "不要了"))
+		assert.Equal(t, 1, promotionUsedCount(t, db, coupon.ID))
+
+		var redemptions int64
+		require.NoError(t, db.Model(&models.PromotionRedemption{}).Where("order_id = ?", aliceOrder.Value()).Count(&redemptions).Error)
+		assert.Zero(t, redemptions)
+
+		// 归还后本人和其他用户都可以再次使用
+		_, err := placeOrder(service, carol, productID, 2, "SAVE5")
+		require.NoError(t, err)
+		assert.Equal(t, 2, promotionUsedCount(t, db, coupon.ID))
+	})
+
+	t.Run("expired coupon", func(t *testing.T) {
+		start := time.Now().Add(-48 * time.Hour)
+		end := time.Now().Add(-24 * time.Hour)
+		createTestPromotion(t, db, dto.CreatePromotionRequest{
+			Name:    "过期券",
+			Code:    "OLD",
+			Type:    promotion.DiscountPercentage,
+			Percent: 10,
+			StartAt: &start,
+			EndAt:   &end,
+		})
+
+		_, err := placeOrder(service, alice, productID, 2, "OLD")
+		assert.EqualError(t, err, "优惠券 OLD 不可用: 优惠已过期")
+	})
+}
+
+func TestOrderService_AutomaticPromotions(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	flow := stockTestFlow()
+
+	service, db, productID := setupStockTest(t, 100)
+	halfPrice := createTestPromotion(t, db, dto.CreatePromotionRequest{
+		Name:    "第二杯半价",
+		Type:    promotion.DiscountNthItem,
+		NthItem: 2,
+		Percent: 50,
+	})
+	createTestPromotion(t, db, dto.CreatePromotionRequest{
+		Name:   "立减3元",
+		Code:   "TAKE3",
+		Type:   promotion.DiscountFixedAmount,
+		Amount: shared.NewPrice(3),
+	})
+
+	t.Run("not applicable is skipped", func(t *testing.T) {
+		resp, err := placeOrder(service, shared.ID(9001), productID, 1, "")
+		require.NoError(t, err)
+		assert.Equal(t, "12.00", resp.TotalPrice.String())
+		assert.True(t, resp.DiscountAmount.IsZero())
+		assert.Equal(t, 0, promotionUsedCount(t, db, halfPrice.ID))
+	})
+
+	t.Run("stacks with coupon", func(t *testing.T) {
+		// 12 * 3 = 36，第二杯减 6，再用券减 3
+		resp, err := placeOrder(service, shared.ID(9001), productID, 3, "TAKE3")
+		require.NoError(t, err)
+		assert.Equal(t, "27.00", resp.TotalPrice.String())
+		assert.Equal(t, "9.00", resp.DiscountAmount.String())
+		require.Len(t, resp.Discounts, 2)
+		assert.Equal(t, "TAKE3", resp.Discounts[0].Code)
+		assert.Equal(t, "第二杯半价", resp.Discounts[1].Name)
+	})
+
+	t.Run("update recalculates discounts", func(t *testing.T) {
+		resp, err := placeOrder(service, shared.ID(9002), productID, 2, "")
+		require.NoError(t, err)
+		assert.Equal(t, "18.00", resp.TotalPrice.String())
+		assert.Equal(t, 2, promotionUsedCount(t, db, halfPrice.ID))
+
+		// 改为4杯：两组各减 6
+		detail, err := service.UpdateOrder(&dto.UpdateOrderRequest{
+			ID:     resp.ID,
+			ShopID: shopID,
+			Items:  []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 4}},
+			Status: order.OrderStatusPending,
+		}, flow)
+		require.NoError(t, err)
+		assert.Equal(t, "36.00", detail.TotalPrice.String())
+		assert.Equal(t, "12.00", detail.DiscountAmount.String())
+
+		// 改为1杯：不再满足条件，取消优惠并归还次数
+		used := promotionUsedCount(t, db, halfPrice.ID)
+		detail, err = service.UpdateOrder(&dto.UpdateOrderRequest{
+			ID:     resp.ID,
+			ShopID: shopID,
+			Items:  []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 1}},
+			Status: order.OrderStatusPending,
+		}, flow)
+		require.NoError(t, err)
+		assert.Equal(t, "12.00", detail.TotalPrice.String())
+		assert.Empty(t, detail.Discounts)
+		assert.Equal(t, used-1, promotionUsedCount(t, db, halfPrice.ID))
+	})
+
+	t.Run("update rejects order that no longer meets coupon", func(t *testing.T) {
+		createTestPromotion(t, db, dto.CreatePromotionRequest{
+			Name:     "满30减5",
+			Code:     "BIG5",
+			Type:     promotion.DiscountFixedAmount,
+			Amount:   shared.NewPrice(5),
+			MinSpend: shared.NewPrice(30),
+		})
+		resp, err := placeOrder(service, shared.ID(9003), productID, 3, "BIG5")
+		require.NoError(t, err)
+
+		_, err = service.UpdateOrder(&dto.UpdateOrderRequest{
+			ID:     resp.ID,
+			ShopID: shopID,
+			Items:  []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 1}},
+			Status: order.OrderStatusPending,
+		}, flow)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "修改后的订单不满足优惠券 BIG5 的使用条件")
+	})
+}
+
+func TestOrderService_UpdateReleasesClampedDiscounts(t *testing.T) {
+	service, db, productID := setupStockTest(t, 100)
+	first := createTestPromotion(t, db, dto.CreatePromotionRequest{
+		Name:   "立减12元",
+		Type:   promotion.DiscountFixedAmount,
+		Amount: shared.NewPrice(12),
+	})
+	second := createTestPromotion(t, db, dto.CreatePromotionRequest{
+		Name:   "再减12元",
+		Type:   promotion.DiscountFixedAmount,
+		Amount: shared.NewPrice(12),
+	})
+
+	resp, err := placeOrder(service, shared.ID(9001), productID, 3, "")
+	require.NoError(t, err)
+	require.Len(t, resp.Discounts, 2)
+	assert.Equal(t, "12.00", resp.TotalPrice.String())
+
+	// 改为1杯：小计只够第一个优惠减免，另一个减为0后不再保留并归还次数
+	detail, err := service.UpdateOrder(&dto.UpdateOrderRequest{
+		ID:     resp.ID,
+		ShopID: shared.ParseIDFromUint64(stockTestShopID),
+		Items:  []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 1}},
+		Status: order.OrderStatusPending,
+	}, stockTestFlow())
+	require.NoError(t, err)
+	require.Len(t, detail.Discounts, 1)
+	assert.True(t, detail.TotalPrice.IsZero())
+	assert.Equal(t, 1, promotionUsedCount(t, db, first.ID)+promotionUsedCount(t, db, second.ID))
+
+	var redemptions int64
+	require.NoError(t, db.Model(&models.PromotionRedemption{}).Where("order_id = ?", resp.ID.Value()).Count(&redemptions).Error)
+	assert.Equal(t, int64(1), redemptions)
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"orderease/application/dto"
+	"orderease/config"
+	"orderease/domain/shop"
+	"orderease/models"
+	"orderease/utils"
+	"orderease/utils/log2"
+)
+
+// defaultRefreshExpiration 未配置 jwt.refreshExpiration 时刷新令牌的有效期
+const defaultRefreshExpiration = 24 * time.Hour
+
+// SessionPrincipal 登录会话所属的主体
+// 员工子账号的 ID 为员工ID，ShopID 为所属店铺；其他主体不使用 ShopID
+type SessionPrincipal struct {
+	Type     string
+	ID       uint64
+	Username string
+	ShopID   uint64
+	Role     shop.StaffRole
+}
+
+// claims 构建访问令牌的业务声明
+func (p SessionPrincipal) claims(sessionID string) *utils.Claims {
+	claims := &utils.Claims{
+		UserID:    p.ID,
+		Username:  p.Username,
+		IsAdmin:   p.Type == PrincipalAdmin,
+		SessionID: sessionID,
+	}
+
+	switch p.Type {
+	case PrincipalShop:
+		claims.Role = string(shop.StaffRoleOwner)
+		claims.Permissions = shop.StaffRoleOwner.Permissions()
+	case PrincipalStaff:
+		// 员工令牌的 UserID 为店铺ID，店铺范围的接口无需区分店主和员工
+		claims.UserID = p.ShopID
+		claims.StaffID = p.ID
+		claims.Role = string(p.Role)
+		// 不可分配给员工的角色（如历史数据中的 owner）不授予任何权限
+		if p.Role.IsValid() {
+			claims.Permissions = p.Role.Permissions()
+		}
+	}
+
+	return claims
+}
+
+// SessionDevice 登录设备信息
+type SessionDevice struct {
+	Label     string
+	IP        string
+	UserAgent string
+}
+
+// TokenPair 访问令牌与刷新令牌
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+	SessionID        string
+}
+
+// PrincipalValidator 刷新前校验主体是否仍然有效（如店铺是否到期、员工是否停用），
+// 返回主体的最新信息，角色变更在下一次刷新时生效
+type PrincipalValidator func(principalID uint64) (SessionPrincipal, error)
+
+// RefreshTokenService 刷新令牌服务
+// 刷新令牌为不透明随机串，服务端只保存 SHA-256 哈希；每次使用都会轮换，
+// 旧令牌被重复使用时吊销整个令牌家族及其最近签发的访问令牌
+type RefreshTokenService struct {
+	db             *gorm.DB
+	tokenBlacklist *TokenBlacklistService
+}
+
+// NewRefreshTokenService 创建刷新令牌服务实例
+func NewRefreshTokenService(db *gorm.DB, tokenBlacklist *TokenBlacklistService) *RefreshTokenService {
+	return &RefreshTokenService{
+		db:             db,
+		tokenBlacklist: tokenBlacklist,
+	}
+}
+
+func refreshExpiration() time.Duration {
+	if seconds := config.AppConfig.JWT.RefreshExpiration; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultRefreshExpiration
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
+
+// IssueTokenPair 登录成功后签发令牌对，开启一个新的会话（令牌家族）
+func (s *RefreshTokenService) IssueTokenPair(principal SessionPrincipal, device SessionDevice) (*TokenPair, error) {
+	return s.issue(s.db, principal, device, utils.RandomHex(16))
+}
+
+func (s *RefreshTokenService) issue(db *gorm.DB, principal SessionPrincipal, device SessionDevice, familyID string) (*TokenPair, error) {
+	accessToken, jti, accessExpiresAt, err := utils.GenerateClaimsToken(principal.claims(familyID))
+	if err != nil {
+		log2.Errorf("生成访问令牌失败: %v", err)
+		return nil, errors.New("生成令牌失败")
+	}
+
+	refreshToken := utils.RandomHex(32)
+	if refreshToken == "" {
+		return nil, errors.New("生成令牌失败")
+	}
+
+	now := time.Now()
+	record := models.RefreshToken{
+		FamilyID:      familyID,
+		TokenHash:     hashRefreshToken(refreshToken),
+		PrincipalType: principal.Type,
+		PrincipalID:   principal.ID,
+		Username:      principal.Username,
+		DeviceLabel:   truncate(device.Label, 100),
+		IP:            truncate(device.IP, 64),
+		UserAgent:     truncate(device.UserAgent, 255),
+		AccessJTI:     jti,
+		LastUsedAt:    now,
+		ExpiresAt:     now.Add(refreshExpiration()),
+	}
+	if err := db.Create(&record).Error; err != nil {
+		log2.Errorf("保存刷新令牌失败: %v", err)
+		return nil, errors.New("生成令牌失败")
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: record.ExpiresAt,
+		SessionID:        familyID,
+	}, nil
+}
+
+// Rotate 使用刷新令牌换取新的令牌对，旧刷新令牌随即失效
+// validators 决定该入口接受哪些主体类型；设备标签沿用登录时的值，IP 和 User-Agent 更新为本次请求
+func (s *RefreshTokenService) Rotate(refreshToken string, device SessionDevice, validators map[string]PrincipalValidator) (*TokenPair, error) {
+	var current models.RefreshToken
+	if err := s.db.Where("token_hash = ?", hashRefreshToken(refreshToken)).First(&current).Error; err != nil {
+		return nil, errors.New("无效的刷新令牌")
+	}
+
+	validate, ok := validators[current.PrincipalType]
+	if !ok {
+		return nil, errors.New("无效的刷新令牌")
+	}
+
+	if current.RotatedAt != nil || current.RevokedAt != nil {
+		if current.RotatedAt != nil && current.RevokedAt == nil {
+			// 已轮换的令牌被再次使用，说明令牌可能已泄露
+			log2.Warnf("检测到刷新令牌重复使用，吊销会话: %s %d, family: %s, ip: %s",
+				current.PrincipalType, current.PrincipalID, current.FamilyID, device.IP)
+			s.revokeFamilies(s.db.Where("family_id = ?", current.FamilyID))
+		}
+		return nil, errors.New("刷新令牌已失效，请重新登录")
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, errors.New("刷新令牌已过期，请重新登录")
+	}
+
+	principal, err := validate(current.PrincipalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if device.Label == "" {
+		device.Label = current.DeviceLabel
+	}
+
+	var pair *TokenPair
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 条件更新保证并发请求中只有一个能完成轮换
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", current.ID).
+			Updates(map[string]interface{}{"rotated_at": time.Now(), "last_used_at": time.Now()})
+		if result.Error != nil {
+			log2.Errorf("轮换刷新令牌失败: %v", result.Error)
+			return errors.New("刷新令牌失败")
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("刷新令牌已失效，请重新登录")
+		}
+
+		var err error
+		pair, err = s.issue(tx, principal, device, current.FamilyID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// ListSessions 列出主体当前有效的会话
+// 每个会话以家族中最新的刷新令牌为准：设备、IP 取最近一次刷新时的值，创建时间取首次登录时间
+func (s *RefreshTokenService) ListSessions(principalType string, principalID uint64, currentSessionID string) ([]dto.SessionResponse, error) {
+	var active []models.RefreshToken
+	err := s.db.Where("principal_type = ? AND principal_id = ?", principalType, principalID).
+		Where("rotated_at IS NULL AND revoked_at IS NULL AND expires_at > ?", time.Now()).
+		Order("last_used_at DESC").
+		Find(&active).Error
+	if err != nil {
+		log2.Errorf("查询会话列表失败: %v", err)
+		return nil, errors.New("查询会话列表失败")
+	}
+
+	sessions := make([]dto.SessionResponse, 0, len(active))
+	if len(active) == 0 {
+		return sessions, nil
+	}
+
+	familyIDs := make([]string, 0, len(active))
+	for _, record := range active {
+		familyIDs = append(familyIDs, record.FamilyID)
+	}
+
+	var firsts []struct {
+		FamilyID  string
+		CreatedAt time.Time
+	}
+	err = s.db.Model(&models.RefreshToken{}).
+		Select("family_id, MIN(created_at) AS created_at").
+		Where("family_id IN ?", familyIDs).
+		Group("family_id").
+		Scan(&firsts).Error
+	if err != nil {
+		log2.Errorf("查询会话创建时间失败: %v", err)
+		return nil, errors.New("查询会话列表失败")
+	}
+	createdAt := make(map[string]time.Time, len(firsts))
+	for _, first := range firsts {
+		createdAt[first.FamilyID] = first.CreatedAt
+	}
+
+	for _, record := range active {
+		session := dto.SessionResponse{
+			ID:          record.FamilyID,
+			DeviceLabel: record.DeviceLabel,
+			IP:          record.IP,
+			UserAgent:   record.UserAgent,
+			Current:     record.FamilyID == currentSessionID,
+			CreatedAt:   record.CreatedAt,
+			LastSeenAt:  record.LastUsedAt,
+			ExpiresAt:   record.ExpiresAt,
+		}
+		if t, ok := createdAt[record.FamilyID]; ok {
+			session.CreatedAt = t
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// RevokePrincipalSession 吊销主体名下的指定会话，不能吊销其他账号的会话
+func (s *RefreshTokenService) RevokePrincipalSession(principalType string, principalID uint64, sessionID string) error {
+	var count int64
+	err := s.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND principal_type = ? AND principal_id = ? AND revoked_at IS NULL", sessionID, principalType, principalID).
+		Count(&count).Error
+	if err != nil {
+		log2.Errorf("查询会话失败: %v", err)
+		return errors.New("吊销会话失败")
+	}
+	if count == 0 {
+		return errors.New("会话不存在")
+	}
+
+	return s.RevokeSession(sessionID)
+}
+
+// RevokeSession 吊销一个会话（令牌家族）
+func (s *RefreshTokenService) RevokeSession(sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+	return s.revokeFamilies(s.db.Where("family_id = ?", sessionID))
+}
+
+// RevokeOtherSessions 吊销主体除当前会话外的所有会话
+func (s *RefreshTokenService) RevokeOtherSessions(principalType string, principalID uint64, currentSessionID string) error {
+	query := s.db.Where("principal_type = ? AND principal_id = ?", principalType, principalID)
+	if currentSessionID != "" {
+		query = query.Where("family_id <> ?", currentSessionID)
+	}
+	return s.revokeFamilies(query)
+}
+
+// revokeFamilies 吊销匹配条件的全部刷新令牌，并将仍可能有效的访问令牌加入黑名单
+func (s *RefreshTokenService) revokeFamilies(query *gorm.DB) error {
+	var records []models.RefreshToken
+	if err := query.Where("revoked_at IS NULL").Find(&records).Error; err != nil {
+		log2.Errorf("查询刷新令牌失败: %v", err)
+		return errors.New("吊销会话失败")
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	if err := s.db.Model(&models.RefreshToken{}).Where("id IN ?", ids).Update("revoked_at", time.Now()).Error; err != nil {
+		log2.Errorf("吊销刷新令牌失败: %v", err)
+		return errors.New("吊销会话失败")
+	}
+
+	// 访问令牌与刷新令牌同时签发，签发时间早于访问令牌有效期的已自然过期，无需加入黑名单
+	accessTTL := time.Duration(viper.GetInt("jwt.expiration")) * time.Second
+	for _, record := range records {
+		accessExpiresAt := record.CreatedAt.Add(accessTTL)
+		if record.AccessJTI != "" && time.Now().Before(accessExpiresAt) {
+			if err := s.tokenBlacklist.RevokeJTI(record.AccessJTI, accessExpiresAt); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
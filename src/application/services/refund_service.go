@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"orderease/application/dto"
+	"orderease/domain/order"
+	"orderease/domain/product"
+	"orderease/domain/shared"
+	"orderease/infrastructure/repositories"
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+)
+
+// RefundService 订单退款（售后）：已完成的订单按订单项部分退款或全额退款，订单和历史保留
+// 在系统内支付的订单通过支付单原路退回，线下结算的订单只登记退款金额
+type RefundService struct {
+	db                  *gorm.DB
+	orderRepo           order.OrderRepository
+	refundRepo          order.OrderRefundRepository
+	paymentService      *PaymentService
+	eventPublisher      order.EventPublisher
+	stockEventPublisher product.StockEventPublisher
+}
+
+func NewRefundService(
+	db *gorm.DB,
+	orderRepo order.OrderRepository,
+	refundRepo order.OrderRefundRepository,
+	paymentService *PaymentService,
+	eventPublisher order.EventPublisher,
+	stockEventPublisher product.StockEventPublisher,
+) *RefundService {
+	return &RefundService{
+		db:                  db,
+		orderRepo:           orderRepo,
+		refundRepo:          refundRepo,
+		paymentService:      paymentService,
+		eventPublisher:      eventPublisher,
+		stockEventPublisher: stockEventPublisher,
+	}
+}
+
+// RefundOrder 为已完成的订单退款，同一订单的退款依次执行，退款数量和金额不会超出
+// 退款记录、库存入库和支付单退款登记在同一事务内提交，提交后再向支付网关原路退回，前面的步骤失败时不会退出款项
+func (s *RefundService) RefundOrder(req *dto.RefundOrderRequest, flow order.OrderStatusFlow, actor order.StatusActor) (*dto.OrderRefundResponse, error) {
+	lines := make([]order.RefundLine, len(req.Items))
+	for i, item := range req.Items {
+		lines[i] = order.RefundLine{OrderItemID: item.OrderItemID, Quantity: item.Quantity}
+	}
+
+	var ord *order.Order
+	var refund *order.OrderRefund
+	var stock stockChanges
+	var pending []gatewayRefund
+	err := WithTx(s.db, func(tx *gorm.DB) error {
+		refundRepo := repositories.NewOrderRefundRepository(tx)
+
+		var err error
+		ord, err = lockOrder(tx, req.OrderID, req.ShopID)
+		if err != nil {
+			return err
+		}
+
+		previous, err := refundRepo.FindByOrderID(ord.ID, ord.ShopID)
+		if err != nil {
+			return err
+		}
+
+		refund, err = ord.NewRefund(flow, lines, previous, req.Reason, req.RestoreStock, actor)
+		if err != nil {
+			return err
+		}
+		if err := refundRepo.Save(refund); err != nil {
+			return err
+		}
+
+		if refund.RestoreStock {
+			if err := stock.returnRefund(tx, refund); err != nil {
+				return err
+			}
+		}
+
+		if ord.PaidAmount.IsPositive() {
+			pending, err = s.paymentService.refundOrder(tx, ord, refund.Amount, refund.Reason, actor.Name)
+			return err
+		}
+
+		ord.ApplyRefund(refund)
+		if err := tx.Model(&models.Order{}).
+			Where("id = ? AND shop_id = ?", ord.ID.Value(), ord.ShopID).
+			Updates(map[string]interface{}{"refunded_amount": ord.RefundedAmount, "updated_at": ord.UpdatedAt}).Error; err != nil {
+			log2.Errorf("更新订单退款金额失败, 订单ID: %s, 错误: %v", ord.ID, err)
+			return errors.New("更新订单退款金额失败")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 退款记录和入库已经提交，网关退回失败时仍通知订单和库存变更
+	gatewayErr := s.paymentService.submitRefunds(pending)
+	if s.eventPublisher != nil {
+		s.eventPublisher.Publish(order.NewOrderEvent(order.OrderEventRefunded, ord, ord.Status))
+	}
+	stock.publish(s.stockEventPublisher)
+	if gatewayErr != nil {
+		return nil, errors.New("退款已登记，但款项原路退回失败，请稍后在支付记录中重新退款")
+	}
+
+	log2.Infof("订单退款成功: 订单 %s, 退款 %s, 金额 %s, 操作人 %s", ord.ID, refund.ID, refund.Amount, actor.Name)
+	return toOrderRefundResponse(refund), nil
+}
+
+// GetOrderRefunds 查询订单的退款记录和剩余可退金额
+func (s *RefundService) GetOrderRefunds(orderID shared.ID, shopID shared.ID) (*dto.OrderRefundListResponse, error) {
+	ord, err := s.orderRepo.FindByIDAndShopID(orderID, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	refunds, err := s.refundRepo.FindByOrderID(orderID, ord.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.OrderRefundListResponse{
+		OrderID:          ord.ID,
+		TotalPrice:       ord.TotalPrice,
+		RefundedAmount:   ord.RefundedAmount,
+		RefundableAmount: ord.RefundableAmount(),
+		Refunds:          make([]dto.OrderRefundResponse, len(refunds)),
+	}
+	for i := range refunds {
+		resp.Refunds[i] = *toOrderRefundResponse(&refunds[i])
+	}
+	return resp, nil
+}
+
+// GetRevenueSummary 统计时间范围内下单的已完成订单营业额，实收金额扣除已退款金额
+// 取消、拒单等归还库存的终态不计入营业额
+func (s *RefundService) GetRevenueSummary(shopID shared.ID, flow order.OrderStatusFlow, startTime, endTime time.Time) (*dto.RevenueSummaryResponse, error) {
+	var statuses []int
+	for _, status := range flow.Statuses {
+		if status.IsFinal && !status.ReleaseStock {
+			statuses = append(statuses, int(status.Value))
+		}
+	}
+	if len(statuses) == 0 {
+		return &dto.RevenueSummaryResponse{}, nil
+	}
+
+	query := s.db.Model(&models.Order{}).
+		Where("shop_id = ? AND status IN ?", shopID.Value(), statuses)
+	if !startTime.IsZero() {
+		query = query.Where("created_at >= ?", startTime)
+	}
+	if !endTime.IsZero() {
+		query = query.Where("created_at <= ?", endTime)
+	}
+
+	var summary struct {
+		OrderCount     int64
+		GrossAmount    models.Price
+		RefundedAmount models.Price
+	}
+	if err := query.Select("COUNT(*) AS order_count, COALESCE(SUM(total_price), 0) AS gross_amount, COALESCE(SUM(refunded_amount), 0) AS refunded_amount").
+		Scan(&summary).Error; err != nil {
+		log2.Errorf("统计营业额失败, 店铺ID: %s, 错误: %v", shopID, err)
+		return nil, errors.New("统计营业额失败")
+	}
+
+	return &dto.RevenueSummaryResponse{
+		OrderCount:     summary.OrderCount,
+		GrossAmount:    summary.GrossAmount,
+		RefundedAmount: summary.RefundedAmount,
+		NetAmount:      summary.GrossAmount.Sub(summary.RefundedAmount),
+	}, nil
+}
+
+func toOrderRefundResponse(refund *order.OrderRefund) *dto.OrderRefundResponse {
+	items := make([]dto.OrderRefundItemResponse, len(refund.Items))
+	for i, item := range refund.Items {
+		items[i] = dto.OrderRefundItemResponse{
+			OrderItemID: item.OrderItemID,
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			Amount:      item.Amount,
+		}
+	}
+
+	return &dto.OrderRefundResponse{
+		ID:           refund.ID,
+		OrderID:      refund.OrderID,
+		Amount:       refund.Amount,
+		Reason:       refund.Reason,
+		RestoreStock: refund.RestoreStock,
+		ActorType:    refund.Actor.Type,
+		ActorID:      refund.Actor.ID,
+		ActorName:    refund.Actor.Name,
+		Items:        items,
+		CreatedAt:    refund.CreatedAt,
+	}
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"orderease/application/dto"
+	"orderease/domain/order"
+	"orderease/domain/payment"
+	"orderease/domain/product"
+	"orderease/domain/shared"
+	"orderease/infrastructure/gateways"
+	"orderease/infrastructure/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestRefundService(db *gorm.DB) *RefundService {
+	return NewRefundService(
+		db,
+		repositories.NewOrderRepository(db),
+		repositories.NewOrderRefundRepository(db),
+		newTestPaymentService(db),
+		nil,
+		nil,
+	)
+}
+
+// refundFailingGateway 模拟支付网关退款失败
+type refundFailingGateway struct {
+	payment.Gateway
+}
+
+func (g refundFailingGateway) Refund(*payment.Payment, *payment.Refund) (*payment.RefundResult, error) {
+	return nil, errors.New("网关超时")
+}
+
+func completeStockTestOrder(t *testing.T, service *OrderService, orderID shared.ID, flow order.OrderStatusFlow) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	require.NoError(t, service.UpdateOrderStatus(orderID, shopID, order.OrderStatusAccepted, flow, order.StatusActor{}, ""))
+	require.NoError(t, service.UpdateOrderStatus(orderID, shopID, order.OrderStatusComplete, flow, order.StatusActor{}, ""))
+}
+
+func TestRefundService_RefundOrder(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	flow := stockTestFlow()
+	actor := order.StatusActor{Type: PrincipalStaff, ID: 7, Name: "manager01"}
+
+	t.Run("partial refund restores stock and keeps the order", func(t *testing.T) {
+		orderService, db, productID := setupStockTest(t, 10)
+		refundService := newTestRefundService(db)
+		orderID := createStockTestOrder(t, orderService, productID, 3)
+
+		_, err := refundService.RefundOrder(&dto.RefundOrderRequest{ShopID: shopID, OrderID: orderID, Reason: "退菜"}, flow, actor)
+		assert.ErrorIs(t, err, order.ErrRefundNotAllowed)
+
+		completeStockTestOrder(t, orderService, orderID, flow)
+		detail, err := orderService.GetOrder(orderID, shopID)
+		require.NoError(t, err)
+		itemID := detail.Items[0].ID
+
+		refund, err := refundService.RefundOrder(&dto.RefundOrderRequest{
+			ShopID:       shopID,
+			OrderID:      orderID,
+			Items:        []dto.RefundOrderItemRequest{{OrderItemID: itemID, Quantity: 1}},
+			Reason:       "做错口味",
+			RestoreStock: true,
+		}, flow, actor)
+		require.NoError(t, err)
+		assert.Equal(t, "12.00", refund.Amount.String())
+		assert.Equal(t, "manager01", refund.ActorName)
+		assert.Equal(t, 8, currentStock(t, db, productID))
+
+		movements := stockMovements(t, db, productID)
+		last := movements[len(movements)-1]
+		assert.Equal(t, string(product.StockMovementRefundReturn), last.Type)
+		assert.Equal(t, 1, last.Quantity)
+		assert.Equal(t, "做错口味", last.Reason)
+
+		// 剩余商品整单退款，不入库
+		refund, err = refundService.RefundOrder(&dto.RefundOrderRequest{ShopID: shopID, OrderID: orderID, Reason: "顾客投诉"}, flow, actor)
+		require.NoError(t, err)
+		assert.Equal(t, "24.00", refund.Amount.String())
+		require.Len(t, refund.Items, 1)
+		assert.Equal(t, 2, refund.Items[0].Quantity)
+		assert.Equal(t, 8, currentStock(t, db, productID))
+
+		refunds, err := refundService.GetOrderRefunds(orderID, shopID)
+		require.NoError(t, err)
+		assert.Len(t, refunds.Refunds, 2)
+		assert.Equal(t, "36.00", refunds.RefundedAmount.String())
+		assert.Equal(t, "0.00", refunds.RefundableAmount.String())
+
+		_, err = refundService.RefundOrder(&dto.RefundOrderRequest{ShopID: shopID, OrderID: orderID, Reason: "再退"}, flow, actor)
+		assert.ErrorIs(t, err, order.ErrFullyRefunded)
+
+		err = orderService.DeleteOrder(orderID, shopID, flow, order.StatusActor{})
+		assert.ErrorIs(t, err, order.ErrOrderNotDeletable)
+	})
+
+	t.Run("paid order is refunded through its payments", func(t *testing.T) {
+		orderService, db, productID := setupStockTest(t, 10)
+		refundService := newTestRefundService(db)
+		paymentService := newTestPaymentService(db)
+		orderID := createStockTestOrder(t, orderService, productID, 2)
+
+		pending, err := paymentService.CreatePayment(&dto.CreatePaymentRequest{ShopID: shopID, OrderID: orderID, Method: payment.MethodMock}, flow)
+		require.NoError(t, err)
+		_, err = paymentService.SimulatePayment(&dto.MockPayRequest{ShopID: shopID, PaymentID: pending.ID, Success: true}, 0)
+		require.NoError(t, err)
+		completeStockTestOrder(t, orderService, orderID, flow)
+
+		detail, err := orderService.GetOrder(orderID, shopID)
+		require.NoError(t, err)
+		_, err = refundService.RefundOrder(&dto.RefundOrderRequest{
+			ShopID:  shopID,
+			OrderID: orderID,
+			Items:   []dto.RefundOrderItemRequest{{OrderItemID: detail.Items[0].ID, Quantity: 1}},
+			Reason:  "少送一杯",
+		}, flow, actor)
+		require.NoError(t, err)
+
+		summary, err := paymentService.GetOrderPayments(orderID, shopID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, order.PaymentStatusPartiallyRefunded, summary.PaymentStatus)
+		assert.Equal(t, "12.00", summary.RefundedAmount.String())
+		require.Len(t, summary.Refunds, 1)
+		assert.Equal(t, "少送一杯", summary.Refunds[0].Reason)
+		assert.Equal(t, "manager01", summary.Refunds[0].Operator)
+	})
+
+	t.Run("gateway failure after commit releases the reserved amount", func(t *testing.T) {
+		orderService, db, productID := setupStockTest(t, 10)
+		paymentService := newTestPaymentService(db)
+		orderID := createStockTestOrder(t, orderService, productID, 2)
+
+		pending, err := paymentService.CreatePayment(&dto.CreatePaymentRequest{ShopID: shopID, OrderID: orderID, Method: payment.MethodMock}, flow)
+		require.NoError(t, err)
+		_, err = paymentService.SimulatePayment(&dto.MockPayRequest{ShopID: shopID, PaymentID: pending.ID, Success: true}, 0)
+		require.NoError(t, err)
+		completeStockTestOrder(t, orderService, orderID, flow)
+
+		failing := NewPaymentService(
+			db,
+			repositories.NewPaymentRepository(db),
+			repositories.NewPaymentRefundRepository(db),
+			repositories.NewOrderRepository(db),
+			[]payment.Gateway{refundFailingGateway{gateways.NewMockGateway("test-secret")}},
+			nil,
+		)
+		refundService := NewRefundService(db, repositories.NewOrderRepository(db), repositories.NewOrderRefundRepository(db), failing, nil, nil)
+
+		_, err = refundService.RefundOrder(&dto.RefundOrderRequest{ShopID: shopID, OrderID: orderID, Reason: "顾客投诉"}, flow, actor)
+		assert.EqualError(t, err, "退款已登记，但款项原路退回失败，请稍后在支付记录中重新退款")
+
+		summary, err := paymentService.GetOrderPayments(orderID, shopID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, order.PaymentStatusPaid, summary.PaymentStatus)
+		assert.Equal(t, "0.00", summary.RefundedAmount.String())
+		require.Len(t, summary.Refunds, 1)
+		assert.Equal(t, payment.RefundStatusFailed, summary.Refunds[0].Status)
+
+		_, err = failing.RefundPayment(&dto.RefundPaymentRequest{
+			ShopID: shopID, PaymentID: pending.ID, Amount: shared.NewPrice(5), Reason: "少一份小料",
+		}, AuditActor{Name: "owner"})
+		assert.EqualError(t, err, "退款失败，请稍后重试")
+
+		// 原网关恢复后可以对同一支付单重新退款
+		refund, err := paymentService.RefundPayment(&dto.RefundPaymentRequest{
+			ShopID: shopID, PaymentID: pending.ID, Amount: shared.NewPrice(24), Reason: "顾客投诉",
+		}, AuditActor{Name: "owner"})
+		require.NoError(t, err)
+		assert.Equal(t, payment.RefundStatusSucceeded, refund.Status)
+		assert.NotEmpty(t, refund.ProviderRefundNo)
+	})
+}
+
+func TestRefundService_GetRevenueSummary(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	flow := stockTestFlow()
+
+	orderService, db, productID := setupStockTest(t, 10)
+	refundService := newTestRefundService(db)
+
+	completed := createStockTestOrder(t, orderService, productID, 2)
+	completeStockTestOrder(t, orderService, completed, flow)
+	createStockTestOrder(t, orderService, productID, 1) // 未完成的订单不计入
+	canceled := createStockTestOrder(t, orderService, productID, 1)
+	require.NoError(t, orderService.UpdateOrderStatus(canceled, shopID, order.OrderStatusCanceled, flow, order.StatusActor{}, "顾客取消"))
+
+	_, err := refundService.RefundOrder(&dto.RefundOrderRequest{ShopID: shopID, OrderID: completed, Items: []dto.RefundOrderItemRequest{}, Reason: "整单退款"}, flow, order.StatusActor{})
+	require.NoError(t, err)
+
+	summary, err := refundService.GetRevenueSummary(shopID, flow, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), summary.OrderCount)
+	assert.Equal(t, "24.00", summary.GrossAmount.String())
+	assert.Equal(t, "24.00", summary.RefundedAmount.String())
+	assert.Equal(t, "0.00", summary.NetAmount.String())
+}
@@ -9,14 +9,12 @@ import (
 	"orderease/domain/product"
 	"orderease/domain/shared"
 	"orderease/domain/shop"
-	"orderease/models"
 	"orderease/utils"
 	"orderease/utils/log2"
 	"os"
 	"path/filepath"
 	"time"
 
-	"github.com/bwmarrin/snowflake"
 	"gorm.io/gorm"
 )
 
@@ -57,6 +55,30 @@ func (s *ShopService) CreateShop(req *dto.CreateShopRequest) (*dto.ShopResponse,
 	shopEntity.Description = req.Description
 	shopEntity.Address = req.Address
 	shopEntity.Settings = req.Settings
+	shopEntity.AutoOfflineOnZeroStock = req.AutoOfflineOnZeroStock
+
+	if _, err := shopEntity.ChargeSettings(); err != nil {
+		return nil, err
+	}
+	if _, err := shopEntity.SchedulingSettings(); err != nil {
+		return nil, err
+	}
+	if _, err := shopEntity.PickupSettings(); err != nil {
+		return nil, err
+	}
+	if _, err := shopEntity.FulfillmentSettings(); err != nil {
+		return nil, err
+	}
+	if req.Timezone != "" {
+		if err := shopEntity.SetTimezone(req.Timezone); err != nil {
+			return nil, err
+		}
+	}
+	if req.BusinessHours != nil {
+		if err := shopEntity.UpdateBusinessHours(*req.BusinessHours); err != nil {
+			return nil, err
+		}
+	}
 
 	if req.OrderStatusFlow != nil {
 		shopEntity.OrderStatusFlow = *req.OrderStatusFlow
@@ -69,6 +91,31 @@ func (s *ShopService) CreateShop(req *dto.CreateShopRequest) (*dto.ShopResponse,
 	return s.toShopResponse(shopEntity), nil
 }
 
+// ChangeOwnerPassword 店主修改密码
+func (s *ShopService) ChangeOwnerPassword(id shared.ID, oldPassword, newPassword string) error {
+	shopEntity, err := s.shopRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := shopEntity.CheckPassword(oldPassword); err != nil {
+		return errors.New("原密码错误")
+	}
+	if err := validateNewPassword(oldPassword, newPassword); err != nil {
+		return err
+	}
+
+	if err := shopEntity.UpdatePassword(newPassword); err != nil {
+		return err
+	}
+	if err := s.shopRepo.Update(shopEntity); err != nil {
+		return errors.New("修改密码失败")
+	}
+
+	log2.Infof("店主修改密码成功, shopID: %s", id.String())
+	return nil
+}
+
 func (s *ShopService) GetShop(id shared.ID) (*dto.ShopResponse, error) {
 	shopEntity, err := s.shopRepo.FindByID(id)
 	if err != nil {
@@ -119,6 +166,18 @@ func (s *ShopService) UpdateShop(req *dto.UpdateShopRequest) (*dto.ShopResponse,
 		shopEntity.Address = req.Address
 	}
 	if req.Settings != "" {
+		if _, err := shop.ParseChargeSettings(req.Settings); err != nil {
+			return nil, err
+		}
+		if _, err := shop.ParseSchedulingSettings(req.Settings); err != nil {
+			return nil, err
+		}
+		if _, err := shop.ParsePickupSettings(req.Settings); err != nil {
+			return nil, err
+		}
+		if _, err := shop.ParseFulfillmentSettings(req.Settings); err != nil {
+			return nil, err
+		}
 		shopEntity.Settings = req.Settings
 	}
 	if req.OwnerUsername != "" {
@@ -127,6 +186,22 @@ func (s *ShopService) UpdateShop(req *dto.UpdateShopRequest) (*dto.ShopResponse,
 	if req.OwnerPassword != nil {
 		shopEntity.OwnerPassword = *req.OwnerPassword
 	}
+	if req.AutoOfflineOnZeroStock != nil {
+		shopEntity.AutoOfflineOnZeroStock = *req.AutoOfflineOnZeroStock
+	}
+	if req.Timezone != "" {
+		if err := shopEntity.SetTimezone(req.Timezone); err != nil {
+			return nil, err
+		}
+	}
+	if req.BusinessHours != nil {
+		if err := shopEntity.UpdateBusinessHours(*req.BusinessHours); err != nil {
+			return nil, err
+		}
+	}
+	if req.OrderingPaused != nil {
+		shopEntity.SetOrderingPaused(*req.OrderingPaused)
+	}
 
 	if !req.ValidUntil.IsZero() {
 		if err := shopEntity.UpdateValidUntil(req.ValidUntil); err != nil {
@@ -231,6 +306,22 @@ func (s *ShopService) UpdateOrderStatusFlow(shopID shared.ID, flow order.OrderSt
 	return nil
 }
 
+// SetOrderingPaused 手动暂停或恢复接单
+func (s *ShopService) SetOrderingPaused(shopID shared.ID, paused bool) (*dto.ShopResponse, error) {
+	shopEntity, err := s.shopRepo.FindByID(shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	shopEntity.SetOrderingPaused(paused)
+	if err := s.shopRepo.Update(shopEntity); err != nil {
+		return nil, errors.New("更新店铺接单状态失败")
+	}
+
+	log2.Infof("店铺接单状态已更新, shopID: %s, 暂停接单: %v", shopID.String(), paused)
+	return s.toShopResponse(shopEntity), nil
+}
+
 func (s *ShopService) GetShopTags(shopID shared.ID) (*dto.TagListResponse, error) {
 	tags, err := s.tagRepo.FindByShopID(shopID)
 	if err != nil {
@@ -276,7 +367,7 @@ func (s *ShopService) CreateTag(req *dto.CreateTagRequest) (*dto.TagResponse, er
 }
 
 func (s *ShopService) UpdateTag(id int, req *dto.CreateTagRequest) (*dto.TagResponse, error) {
-	tagEntity, err := s.tagRepo.FindByID(id)
+	tagEntity, err := s.tagRepo.FindByIDAndShopID(id, req.ShopID)
 	if err != nil {
 		return nil, err
 	}
@@ -299,13 +390,13 @@ func (s *ShopService) UpdateTag(id int, req *dto.CreateTagRequest) (*dto.TagResp
 	}, nil
 }
 
-func (s *ShopService) DeleteTag(id int) error {
+func (s *ShopService) DeleteTag(id int, shopID shared.ID) error {
 	// TODO: 检查是否有关联商品
-	return s.tagRepo.Delete(id)
+	return s.tagRepo.Delete(id, shopID)
 }
 
-func (s *ShopService) GetTag(id int) (*dto.TagResponse, error) {
-	tagEntity, err := s.tagRepo.FindByID(id)
+func (s *ShopService) GetTag(id int, shopID shared.ID) (*dto.TagResponse, error) {
+	tagEntity, err := s.tagRepo.FindByIDAndShopID(id, shopID)
 	if err != nil {
 		return nil, err
 	}
@@ -321,6 +412,12 @@ func (s *ShopService) GetTag(id int) (*dto.TagResponse, error) {
 }
 
 func (s *ShopService) toShopResponse(shopEntity *shop.Shop) *dto.ShopResponse {
+	now := time.Now()
+	var nextOpenAt *time.Time
+	if next, ok := shopEntity.NextOpenAt(now); ok {
+		nextOpenAt = &next
+	}
+
 	return &dto.ShopResponse{
 		ID:              shopEntity.ID,
 		Name:            shopEntity.Name,
@@ -335,6 +432,14 @@ func (s *ShopService) toShopResponse(shopEntity *shop.Shop) *dto.ShopResponse {
 		Settings:        shopEntity.Settings,
 		ImageURL:        shopEntity.ImageURL,
 		OrderStatusFlow: shopEntity.OrderStatusFlow,
+
+		AutoOfflineOnZeroStock: shopEntity.AutoOfflineOnZeroStock,
+
+		Timezone:       shopEntity.Timezone,
+		BusinessHours:  shopEntity.BusinessHours,
+		OrderingPaused: shopEntity.OrderingPaused,
+		IsOpen:         shopEntity.IsOpen(now),
+		NextOpenAt:     nextOpenAt,
 	}
 }
 
@@ -473,14 +578,14 @@ func (s *ShopService) BatchTagProduct(productID string, tagIDs []string, shopID
 // GetTagBoundProducts 获取标签已绑定的商品列表
 func (s *ShopService) GetTagBoundProducts(tagID string, shopID uint64, page, pageSize int) (map[string]interface{}, error) {
 	type ProductResult struct {
-		ID          uint64  `json:"id"`
-		ShopID      uint64  `json:"shop_id"`
-		Name        string  `json:"name"`
-		Description string  `json:"description"`
-		Price       float64 `json:"price"`
-		Stock       int     `json:"stock"`
-		Status      string  `json:"status"`
-		ImageURL    string  `json:"image_url"`
+		ID          uint64       `json:"id"`
+		ShopID      uint64       `json:"shop_id"`
+		Name        string       `json:"name"`
+		Description string       `json:"description"`
+		Price       shared.Price `json:"price"`
+		Stock       int          `json:"stock"`
+		Status      string       `json:"status"`
+		ImageURL    string       `json:"image_url"`
 	}
 
 	offset := (page - 1) * pageSize
@@ -543,42 +648,17 @@ func (s *ShopService) GetTagBoundProducts(tagID string, shopID uint64, page, pag
 	}, nil
 }
 
-func (h *Handler) getUnboundProducts(shopID snowflake.ID, page int, pageSize int) ([]models.Product, int64, error) {
-	offset := (page - 1) * pageSize
-	var products []models.Product
-	var total int64
-
-	query := h.DB.Model(&models.Product{}).
-		Where("shop_id = ? AND id NOT IN (SELECT product_id FROM product_tags WHERE shop_id = ?)", shopID, shopID)
-
-	// 获取总数
-	if err := query.
-		Model(&models.Product{}).
-		Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	if err := query.Offset(offset).
-		Limit(pageSize).Order("created_at DESC").
-		Preload("OptionCategories.Options").
-		Find(&products).Error; err != nil {
-		return nil, 0, err
-	}
-
-	return products, total, nil
-}
-
 // GetUnboundProductsForTag 获取标签未绑定的商品列表
 func (s *ShopService) GetUnboundProductsForTag(tagID string, shopID uint64, page, pageSize int) (map[string]interface{}, error) {
 	type ProductResult struct {
-		ID          uint64  `json:"id"`
-		ShopID      uint64  `json:"shop_id"`
-		Name        string  `json:"name"`
-		Description string  `json:"description"`
-		Price       float64 `json:"price"`
-		Stock       int     `json:"stock"`
-		Status      string  `json:"status"`
-		ImageURL    string  `json:"image_url"`
+		ID          uint64       `json:"id"`
+		ShopID      uint64       `json:"shop_id"`
+		Name        string       `json:"name"`
+		Description string       `json:"description"`
+		Price       shared.Price `json:"price"`
+		Stock       int          `json:"stock"`
+		Status      string       `json:"status"`
+		ImageURL    string       `json:"image_url"`
 	}
 
 	offset := (page - 1) * pageSize
@@ -650,14 +730,14 @@ func (s *ShopService) GetUnboundTagsList(shopID uint64) ([]interface{}, error) {
 // GetTagOnlineProducts 获取标签关联的已上架商品
 func (s *ShopService) GetTagOnlineProducts(tagID string, shopID uint64) ([]interface{}, error) {
 	type ProductResult struct {
-		ID          uint64  `json:"id"`
-		ShopID      uint64  `json:"shop_id"`
-		Name        string  `json:"name"`
-		Description string  `json:"description"`
-		Price       float64 `json:"price"`
-		Stock       int     `json:"stock"`
-		Status      string  `json:"status"`
-		ImageURL    string  `json:"image_url"`
+		ID          uint64       `json:"id"`
+		ShopID      uint64       `json:"shop_id"`
+		Name        string       `json:"name"`
+		Description string       `json:"description"`
+		Price       shared.Price `json:"price"`
+		Stock       int          `json:"stock"`
+		Status      string       `json:"status"`
+		ImageURL    string       `json:"image_url"`
 	}
 
 	var products []ProductResult
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"errors"
+	"orderease/application/dto"
+	"orderease/domain/shared"
+	"orderease/domain/shop"
+	"orderease/models"
+	"orderease/utils"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+)
+
+// StaffService 店铺员工子账号服务
+type StaffService struct {
+	staffRepo      shop.StaffRepository
+	shopRepo       shop.ShopRepository
+	tokenBlacklist *TokenBlacklistService
+	refreshTokens  *RefreshTokenService
+	db             *gorm.DB
+}
+
+func NewStaffService(
+	staffRepo shop.StaffRepository,
+	shopRepo shop.ShopRepository,
+	tokenBlacklist *TokenBlacklistService,
+	refreshTokens *RefreshTokenService,
+	db *gorm.DB,
+) *StaffService {
+	return &StaffService{
+		staffRepo:      staffRepo,
+		shopRepo:       shopRepo,
+		tokenBlacklist: tokenBlacklist,
+		refreshTokens:  refreshTokens,
+		db:             db,
+	}
+}
+
+// checkUsernameAvailable 员工与管理员、店主共用登录入口，用户名不能与任何账号重复
+func (s *StaffService) checkUsernameAvailable(username string) error {
+	if _, err := s.staffRepo.FindByUsername(username); err == nil {
+		return errors.New("用户名已存在")
+	}
+	if _, err := s.shopRepo.FindByOwnerUsername(username); err == nil {
+		return errors.New("用户名已存在")
+	}
+
+	var count int64
+	if err := s.db.Model(&models.Admin{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		log2.Errorf("检查用户名失败: %v", err)
+		return errors.New("检查用户名失败")
+	}
+	if count > 0 {
+		return errors.New("用户名已存在")
+	}
+	return nil
+}
+
+func (s *StaffService) CreateStaff(req *dto.CreateStaffRequest) (*dto.StaffResponse, error) {
+	if err := utils.ValidatePassword(req.Password); err != nil {
+		return nil, err
+	}
+	if err := s.checkUsernameAvailable(req.Username); err != nil {
+		return nil, err
+	}
+
+	staff, err := shop.NewStaff(req.ShopID, req.Username, req.Password, req.Name, req.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.staffRepo.Save(staff); err != nil {
+		return nil, errors.New("创建员工失败")
+	}
+
+	log2.Infof("创建员工成功: 店铺 %s, 员工 %s, 角色 %s", req.ShopID.String(), staff.Username, staff.Role)
+	return s.toStaffResponse(staff), nil
+}
+
+func (s *StaffService) UpdateStaff(req *dto.UpdateStaffRequest) (*dto.StaffResponse, error) {
+	staff, err := s.staffRepo.FindByIDAndShopID(req.ID, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 角色、状态或密码变化后，已签发令牌中的权限不再可信，需要重新登录
+	revokeSessions := false
+
+	if req.Name != "" {
+		staff.Name = req.Name
+	}
+	if req.Role != "" && req.Role != staff.Role {
+		if err := staff.UpdateRole(req.Role); err != nil {
+			return nil, err
+		}
+		revokeSessions = true
+	}
+	if req.Active != nil && *req.Active != staff.Active {
+		staff.SetActive(*req.Active)
+		revokeSessions = revokeSessions || !staff.Active
+	}
+	if req.Password != nil {
+		if err := utils.ValidatePassword(*req.Password); err != nil {
+			return nil, err
+		}
+		if err := staff.UpdatePassword(*req.Password); err != nil {
+			return nil, err
+		}
+		revokeSessions = true
+	}
+
+	if err := s.staffRepo.Update(staff); err != nil {
+		return nil, errors.New("更新员工失败")
+	}
+
+	if revokeSessions {
+		if err := s.revokeAllSessions(staff.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.toStaffResponse(staff), nil
+}
+
+func (s *StaffService) DeleteStaff(id shared.ID, shopID shared.ID) error {
+	if _, err := s.staffRepo.FindByIDAndShopID(id, shopID); err != nil {
+		return err
+	}
+
+	if err := s.staffRepo.Delete(id, shopID); err != nil {
+		return errors.New("删除员工失败")
+	}
+
+	return s.revokeAllSessions(id)
+}
+
+func (s *StaffService) GetStaff(id shared.ID, shopID shared.ID) (*dto.StaffResponse, error) {
+	staff, err := s.staffRepo.FindByIDAndShopID(id, shopID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toStaffResponse(staff), nil
+}
+
+func (s *StaffService) GetStaffList(shopID shared.ID) ([]dto.StaffResponse, error) {
+	staffs, err := s.staffRepo.FindByShopID(shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.StaffResponse, len(staffs))
+	for i := range staffs {
+		result[i] = *s.toStaffResponse(&staffs[i])
+	}
+	return result, nil
+}
+
+// Authenticate 员工登录校验，返回员工及所属店铺
+func (s *StaffService) Authenticate(username, password string) (*shop.Staff, *shop.Shop, error) {
+	staff, err := s.staffRepo.FindByUsername(username)
+	if err != nil {
+		return nil, nil, errors.New("用户名或密码错误")
+	}
+	if err := staff.CheckPassword(password); err != nil {
+		return nil, nil, errors.New("用户名或密码错误")
+	}
+
+	shopEntity, err := s.GetActiveStaffShop(staff)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return staff, shopEntity, nil
+}
+
+// GetActiveStaff 获取仍可登录的员工（用于刷新令牌时校验）
+func (s *StaffService) GetActiveStaff(id shared.ID) (*shop.Staff, error) {
+	staff, err := s.staffRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.GetActiveStaffShop(staff); err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+// GetActiveStaffShop 校验员工账号已启用且所属店铺未到期
+func (s *StaffService) GetActiveStaffShop(staff *shop.Staff) (*shop.Shop, error) {
+	if !staff.Active {
+		return nil, errors.New("员工账号已停用")
+	}
+
+	shopEntity, err := s.shopRepo.FindByID(staff.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	if shopEntity.IsExpired() {
+		return nil, errors.New("店铺已到期")
+	}
+	return shopEntity, nil
+}
+
+// ChangePassword 员工修改自己的密码
+func (s *StaffService) ChangePassword(id shared.ID, oldPassword, newPassword string) error {
+	staff, err := s.staffRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := staff.CheckPassword(oldPassword); err != nil {
+		return errors.New("原密码错误")
+	}
+	if err := validateNewPassword(oldPassword, newPassword); err != nil {
+		return err
+	}
+
+	if err := staff.UpdatePassword(newPassword); err != nil {
+		return err
+	}
+	if err := s.staffRepo.Update(staff); err != nil {
+		return errors.New("修改密码失败")
+	}
+
+	log2.Infof("员工修改密码成功, staffID: %s", id.String())
+	return nil
+}
+
+func (s *StaffService) revokeAllSessions(id shared.ID) error {
+	if err := s.tokenBlacklist.RevokeOtherSessions(PrincipalStaff, id.ToUint64(), ""); err != nil {
+		return err
+	}
+	return s.refreshTokens.RevokeOtherSessions(PrincipalStaff, id.ToUint64(), "")
+}
+
+func (s *StaffService) toStaffResponse(staff *shop.Staff) *dto.StaffResponse {
+	return &dto.StaffResponse{
+		ID:          staff.ID,
+		ShopID:      staff.ShopID,
+		Username:    staff.Username,
+		Name:        staff.Name,
+		Role:        staff.Role,
+		Permissions: staff.Role.Permissions(),
+		Active:      staff.Active,
+		CreatedAt:   staff.CreatedAt,
+		UpdatedAt:   staff.UpdatedAt,
+	}
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"orderease/application/dto"
+	"orderease/domain/order"
+	"orderease/domain/product"
+	"orderease/domain/shared"
+	"orderease/infrastructure/repositories"
+	"orderease/models"
+	"orderease/utils"
+	"orderease/utils/log2"
+
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// orderTxRepos 绑定到同一事务的订单仓储，保证订单、订单项、优惠、状态日志和库存一起提交或回滚
+type orderTxRepos struct {
+	orders    order.OrderRepository
+	items     order.OrderItemRepository
+	options   order.OrderItemOptionRepository
+	discounts order.OrderDiscountRepository
+	logs      order.OrderStatusLogRepository
+}
+
+func newOrderTxRepos(tx *gorm.DB) orderTxRepos {
+	return orderTxRepos{
+		orders:    repositories.NewOrderRepository(tx),
+		items:     repositories.NewOrderItemRepository(tx),
+		options:   repositories.NewOrderItemOptionRepository(tx),
+		discounts: repositories.NewOrderDiscountRepository(tx),
+		logs:      repositories.NewOrderStatusLogRepository(tx),
+	}
+}
+
+// stockDeltas 计算库存变化量：释放 released 中的数量，占用 reserved 中的数量
+// 结果为正表示归还库存，为负表示扣减库存
+func stockDeltas(released, reserved map[shared.ID]int) map[shared.ID]int {
+	deltas := make(map[shared.ID]int)
+	for productID, quantity := range released {
+		deltas[productID] += quantity
+	}
+	for productID, quantity := range reserved {
+		deltas[productID] -= quantity
+	}
+	return deltas
+}
+
+// stockMovement 一次库存变化的来源，随库存变化一起写入库存流水
+type stockMovement struct {
+	Type    product.StockMovementType
+	OrderID shared.ID
+	Actor   AuditActor
+	Reason  string
+}
+
+// stockChanges 收集一次操作中的库存变化
+// 库存跨越0或预警值时在同一事务内处理自动上下架，事件在事务提交后统一发布，回滚时不会发出
+type stockChanges struct {
+	events []product.StockEvent
+}
+
+// adjustOrder 在事务内按变化量调整订单占用的库存，扣减记为销售，归还记为取消归还
+func (c *stockChanges) adjustOrder(tx *gorm.DB, ord *order.Order, deltas map[shared.ID]int, actor AuditActor, reason string) error {
+	// 按商品ID顺序加锁，避免并发事务互相等待
+	productIDs := make([]shared.ID, 0, len(deltas))
+	for productID, delta := range deltas {
+		if delta != 0 {
+			productIDs = append(productIDs, productID)
+		}
+	}
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+
+	for _, productID := range productIDs {
+		delta := deltas[productID]
+		movement := stockMovement{
+			Type:    product.StockMovementSale,
+			OrderID: ord.ID,
+			Actor:   actor,
+			Reason:  reason,
+		}
+		if delta > 0 {
+			movement.Type = product.StockMovementCancelReturn
+		}
+
+		if _, err := c.change(tx, ord.ShopID, productID, delta, movement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// returnRefund 在事务内把退款退回的商品重新入库，记为退款入库
+func (c *stockChanges) returnRefund(tx *gorm.DB, refund *order.OrderRefund) error {
+	quantities := make(map[shared.ID]int)
+	for _, item := range refund.Items {
+		quantities[item.ProductID] += item.Quantity
+	}
+
+	productIDs := make([]shared.ID, 0, len(quantities))
+	for productID := range quantities {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+
+	for _, productID := range productIDs {
+		movement := stockMovement{
+			Type:    product.StockMovementRefundReturn,
+			OrderID: refund.OrderID,
+			Actor:   AuditActor(refund.Actor),
+			Reason:  refund.Reason,
+		}
+		if _, err := c.change(tx, refund.ShopID, productID, quantities[productID], movement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// change 在事务内按变化量更新库存并记录流水
+// 扣减使用 stock >= ? 条件更新而不是先读后写，并发下单时不会超卖
+// 商品已被删除时不更新也不记录，返回的流水为 nil
+func (c *stockChanges) change(tx *gorm.DB, shopID uint64, productID shared.ID, delta int, movement stockMovement) (*models.StockMovement, error) {
+	if delta < 0 {
+		result := tx.Model(&models.Product{}).
+			Where("id = ? AND shop_id = ? AND stock >= ?", productID.Value(), shopID, -delta).
+			Update("stock", gorm.Expr("stock - ?", -delta))
+		if result.Error != nil {
+			log2.Errorf("扣减商品库存失败, 商品ID: %s, 错误: %v", productID, result.Error)
+			return nil, errors.New("更新商品库存失败")
+		}
+		if result.RowsAffected == 0 {
+			return nil, insufficientStockError(tx, productID, shopID)
+		}
+	} else {
+		result := tx.Model(&models.Product{}).
+			Where("id = ? AND shop_id = ?", productID.Value(), shopID).
+			Update("stock", gorm.Expr("stock + ?", delta))
+		if result.Error != nil {
+			log2.Errorf("归还商品库存失败, 商品ID: %s, 错误: %v", productID, result.Error)
+			return nil, errors.New("更新商品库存失败")
+		}
+		if result.RowsAffected == 0 {
+			// 商品已被删除，无需归还
+			log2.Warnf("归还库存时商品不存在, 商品ID: %s", productID)
+			return nil, nil
+		}
+	}
+
+	// 更新语句已锁定该行，事务内读到的就是本次变化后的余额
+	var balance int
+	if err := tx.Model(&models.Product{}).
+		Where("id = ? AND shop_id = ?", productID.Value(), shopID).
+		Pluck("stock", &balance).Error; err != nil {
+		log2.Errorf("查询商品库存失败, 商品ID: %s, 错误: %v", productID, err)
+		return nil, errors.New("更新商品库存失败")
+	}
+
+	record, err := saveStockMovement(tx, shopID, productID, delta, balance, movement)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.afterChange(tx, shopID, productID, balance-delta, balance); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// set 在事务内把库存设置为指定数量并记录差额，用于盘点和编辑商品
+// 先锁定商品行再计算差额，避免与并发下单的扣减互相覆盖；数量未变化且不是盘点时返回的流水为 nil
+func (c *stockChanges) set(tx *gorm.DB, shopID uint64, productID shared.ID, target int, movement stockMovement) (*models.StockMovement, error) {
+	var prod models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "stock").
+		Where("id = ? AND shop_id = ?", productID.Value(), shopID).
+		First(&prod).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("商品不存在")
+		}
+		log2.Errorf("查询商品库存失败, 商品ID: %s, 错误: %v", productID, err)
+		return nil, errors.New("更新商品库存失败")
+	}
+
+	delta := target - prod.Stock
+	// 盘点数量与账面一致时也记录一条，作为盘点凭证
+	if delta == 0 && movement.Type != product.StockMovementStocktake {
+		return nil, nil
+	}
+
+	if err := tx.Model(&models.Product{}).
+		Where("id = ? AND shop_id = ?", productID.Value(), shopID).
+		Update("stock", target).Error; err != nil {
+		log2.Errorf("更新商品库存失败, 商品ID: %s, 错误: %v", productID, err)
+		return nil, errors.New("更新商品库存失败")
+	}
+
+	record, err := saveStockMovement(tx, shopID, productID, delta, target, movement)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.afterChange(tx, shopID, productID, prod.Stock, target); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// afterChange 库存跨越0或预警值时记录事件
+// 店铺开启了售罄自动下架时，库存降为0的在售商品自动下架，补货后只恢复被自动下架的商品，手工下架的保持不变
+func (c *stockChanges) afterChange(tx *gorm.DB, shopID uint64, productID shared.ID, before, after int) error {
+	var prod models.Product
+	if err := tx.Select("id", "name", "status", "low_stock_threshold", "auto_offline").
+		Where("id = ? AND shop_id = ?", productID.Value(), shopID).
+		First(&prod).Error; err != nil {
+		log2.Errorf("查询商品失败, 商品ID: %s, 错误: %v", productID, err)
+		return errors.New("更新商品库存失败")
+	}
+
+	eventType, ok := product.DetectStockEvent(before, after, prod.LowStockThreshold)
+	if !ok {
+		return nil
+	}
+
+	updates := map[string]interface{}{}
+	switch {
+	case eventType == product.StockEventOutOfStock && prod.Status == models.ProductStatusOnline:
+		var autoOffline bool
+		if err := tx.Model(&models.Shop{}).Where("id = ?", shopID).
+			Pluck("auto_offline_on_zero_stock", &autoOffline).Error; err != nil {
+			log2.Errorf("查询店铺设置失败, 店铺ID: %d, 错误: %v", shopID, err)
+			return errors.New("更新商品库存失败")
+		}
+		if autoOffline {
+			updates["status"] = models.ProductStatusOffline
+			updates["auto_offline"] = true
+		}
+	case before <= 0 && after > 0 && prod.AutoOffline && prod.Status == models.ProductStatusOffline:
+		updates["status"] = models.ProductStatusOnline
+		updates["auto_offline"] = false
+	}
+	if len(updates) > 0 {
+		if err := tx.Model(&models.Product{}).
+			Where("id = ? AND shop_id = ?", productID.Value(), shopID).
+			Updates(updates).Error; err != nil {
+			log2.Errorf("自动更新商品状态失败, 商品ID: %s, 错误: %v", productID, err)
+			return errors.New("更新商品状态失败")
+		}
+		prod.Status = updates["status"].(string)
+	}
+
+	c.events = append(c.events, product.StockEvent{
+		Type:        eventType,
+		ShopID:      shopID,
+		ProductID:   productID,
+		ProductName: prod.Name,
+		Stock:       after,
+		Threshold:   prod.LowStockThreshold,
+		Status:      product.ProductStatus(prod.Status),
+		OccurredAt:  time.Now(),
+	})
+	return nil
+}
+
+// publish 事务提交后发布收集到的库存事件
+func (c *stockChanges) publish(publisher product.StockEventPublisher) {
+	if publisher == nil {
+		return
+	}
+	for _, event := range c.events {
+		publisher.PublishStockEvent(event)
+	}
+}
+
+func saveStockMovement(tx *gorm.DB, shopID uint64, productID shared.ID, delta, balance int, movement stockMovement) (*models.StockMovement, error) {
+	record := models.StockMovement{
+		ID:           utils.GenerateSnowflakeID(),
+		ShopID:       snowflake.ID(shopID),
+		ProductID:    productID.Value(),
+		Type:         string(movement.Type),
+		Quantity:     delta,
+		BalanceAfter: balance,
+		OrderID:      movement.OrderID.Value(),
+		ActorType:    movement.Actor.Type,
+		ActorID:      movement.Actor.ID,
+		ActorName:    movement.Actor.Name,
+		Reason:       strings.TrimSpace(movement.Reason),
+		CreatedAt:    time.Now(),
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		log2.Errorf("记录库存流水失败, 商品ID: %s, 错误: %v", productID, err)
+		return nil, errors.New("记录库存流水失败")
+	}
+	return &record, nil
+}
+
+func toStockMovementResponse(record models.StockMovement) dto.StockMovementResponse {
+	return dto.StockMovementResponse{
+		ID:           shared.ID(record.ID),
+		ProductID:    shared.ID(record.ProductID),
+		Type:         product.StockMovementType(record.Type),
+		Quantity:     record.Quantity,
+		BalanceAfter: record.BalanceAfter,
+		OrderID:      shared.ID(record.OrderID),
+		ActorType:    record.ActorType,
+		ActorID:      record.ActorID,
+		ActorName:    record.ActorName,
+		Reason:       record.Reason,
+		CreatedAt:    record.CreatedAt,
+	}
+}
+
+func insufficientStockError(tx *gorm.DB, productID shared.ID, shopID uint64) error {
+	var prod models.Product
+	if err := tx.Select("name").Where("id = ? AND shop_id = ?", productID.Value(), shopID).First(&prod).Error; err != nil {
+		return errors.New("商品不存在")
+	}
+	return fmt.Errorf("商品 %s 库存不足", prod.Name)
+}
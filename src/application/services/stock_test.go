@@ -0,0 +1,794 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"orderease/application/dto"
+	"orderease/domain/order"
+	"orderease/domain/product"
+	"orderease/domain/shared"
+	"orderease/domain/shop"
+	"orderease/infrastructure/repositories"
+	"orderease/models"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const stockTestShopID uint64 = 1001
+
+// openStockTestDB 每个测试使用独立的共享内存库，连接数大于1时多个连接看到同一份数据
+func openStockTestDB(t *testing.T, maxOpenConns int) *gorm.DB {
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Order{},
+		&models.OrderItem{},
+		&models.OrderItemOption{},
+		&models.OrderStatusLog{},
+		&models.OrderDiscount{},
+		&models.Product{},
+		&models.ProductOptionCategory{},
+		&models.ProductOption{},
+		&models.ProductTag{},
+		&models.StockMovement{},
+		&models.Shop{},
+		&models.Promotion{},
+		&models.PromotionRedemption{},
+		&models.Payment{},
+		&models.PaymentRefund{},
+		&models.OrderRefund{},
+		&models.OrderRefundItem{},
+		&models.IdempotencyKey{},
+		&models.Cart{},
+		&models.CartItem{},
+		&models.CartItemOption{},
+		&models.PickupSequence{},
+		&models.DiningTable{},
+		&models.TableSession{},
+	))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(maxOpenConns)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}
+
+func setupStockTest(t *testing.T, stock int) (*OrderService, *gorm.DB, shared.ID) {
+	db := openStockTestDB(t, 1)
+
+	productID := shared.ID(6001)
+	require.NoError(t, db.Create(&models.Product{
+		ID:     snowflake.ID(productID),
+		ShopID: snowflake.ID(stockTestShopID),
+		Name:   "招牌奶茶",
+		Price:  shared.NewPrice(12),
+		Stock:  stock,
+		Status: models.ProductStatusOnline,
+	}).Error)
+
+	service := NewOrderService(
+		db,
+		repositories.NewProductRepository(db),
+		repositories.NewProductOptionRepository(db),
+		repositories.NewProductOptionCategoryRepository(db),
+		repositories.NewOrderRepository(db),
+		repositories.NewOrderItemRepository(db),
+		repositories.NewOrderItemOptionRepository(db),
+		repositories.NewOrderStatusLogRepository(db),
+		nil,
+		nil,
+	)
+
+	return service, db, productID
+}
+
+func stockTestFlow() order.OrderStatusFlow {
+	return order.OrderStatusFlow{
+		Statuses: []order.OrderStatusConfig{
+			{
+				Value: order.OrderStatusPending,
+				Actions: []order.OrderStatusTransition{
+					{Name: "接单", NextStatus: order.OrderStatusAccepted},
+					{Name: "取消", NextStatus: order.OrderStatusCanceled},
+				},
+			},
+			{
+				Value: order.OrderStatusAccepted,
+				Actions: []order.OrderStatusTransition{
+					{Name: "完成", NextStatus: order.OrderStatusComplete},
+				},
+			},
+			{Value: order.OrderStatusComplete, IsFinal: true},
+			{Value: order.OrderStatusCanceled, IsFinal: true, ReleaseStock: true},
+		},
+	}
+}
+
+func currentStock(t *testing.T, db *gorm.DB, productID shared.ID) int {
+	var prod models.Product
+	require.NoError(t, db.First(&prod, productID.Value()).Error)
+	return prod.Stock
+}
+
+func stockMovements(t *testing.T, db *gorm.DB, productID shared.ID) []models.StockMovement {
+	var movements []models.StockMovement
+	require.NoError(t, db.Where("product_id = ?", productID.Value()).Order("id ASC").Find(&movements).Error)
+	return movements
+}
+
+func createStockTestOrder(t *testing.T, service *OrderService, productID shared.ID, quantity int) shared.ID {
+	resp, err := service.CreateOrder(&dto.CreateOrderRequest{
+		UserID: shared.ID(9001),
+		ShopID: shared.ParseIDFromUint64(stockTestShopID),
+		Items:  []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: quantity}},
+	})
+	require.NoError(t, err)
+	return resp.ID
+}
+
+func TestOrderService_StockReservation(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	flow := stockTestFlow()
+
+	t.Run("cancel restores stock once", func(t *testing.T) {
+		service, db, productID := setupStockTest(t, 10)
+		orderID := createStockTestOrder(t, service, productID, 3)
+		assert.Equal(t, 7, currentStock(t, db, productID))
+
+		actor := order.StatusActor{Type: PrincipalStaff, ID: 7, Name: "cashier01"}
+		require.NoError(t, service.UpdateOrderStatus(orderID, shopID, order.OrderStatusCanceled, flow, actor, "顾客取消"))
+		assert.Equal(t, 10, currentStock(t, db, productID))
+
+		movements := stockMovements(t, db, productID)
+		require.Len(t, movements, 2)
+		assert.Equal(t, string(product.StockMovementSale), movements[0].Type)
+		assert.Equal(t, -3, movements[0].Quantity)
+		assert.Equal(t, 7, movements[0].BalanceAfter)
+		assert.Equal(t, orderID.Value(), movements[0].OrderID)
+		assert.Equal(t, string(product.StockMovementCancelReturn), movements[1].Type)
+		assert.Equal(t, 3, movements[1].Quantity)
+		assert.Equal(t, 10, movements[1].BalanceAfter)
+		assert.Equal(t, "cashier01", movements[1].ActorName)
+		assert.Equal(t, "顾客取消", movements[1].Reason)
+
+		// 已取消的订单删除时不再归还
+		require.NoError(t, service.DeleteOrder(orderID, shopID, flow, order.StatusActor{}))
+		assert.Equal(t, 10, currentStock(t, db, productID))
+	})
+
+	t.Run("complete keeps stock deducted", func(t *testing.T) {
+		service, db, productID := setupStockTest(t, 10)
+		orderID := createStockTestOrder(t, service, productID, 3)
+
+		require.NoError(t, service.UpdateOrderStatus(orderID, shopID, order.OrderStatusAccepted, flow, order.StatusActor{}, ""))
+		require.NoError(t, service.UpdateOrderStatus(orderID, shopID, order.OrderStatusComplete, flow, order.StatusActor{}, ""))
+		assert.Equal(t, 7, currentStock(t, db, productID))
+
+		require.NoError(t, service.DeleteOrder(orderID, shopID, flow, order.StatusActor{}))
+		assert.Equal(t, 7, currentStock(t, db, productID))
+	})
+
+	t.Run("delete active order restores stock", func(t *testing.T) {
+		service, db, productID := setupStockTest(t, 10)
+		orderID := createStockTestOrder(t, service, productID, 4)
+		assert.Equal(t, 6, currentStock(t, db, productID))
+
+		require.NoError(t, service.DeleteOrder(orderID, shopID, flow, order.StatusActor{}))
+		assert.Equal(t, 10, currentStock(t, db, productID))
+	})
+
+	t.Run("update applies quantity delta", func(t *testing.T) {
+		service, db, productID := setupStockTest(t, 10)
+		orderID := createStockTestOrder(t, service, productID, 3)
+
+		update := func(quantity int) error {
+			_, err := service.UpdateOrder(&dto.UpdateOrderRequest{
+				ID:     orderID,
+				ShopID: shopID,
+				Items:  []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: quantity}},
+				Status: order.OrderStatusPending,
+			}, flow)
+			return err
+		}
+
+		require.NoError(t, update(5))
+		assert.Equal(t, 5, currentStock(t, db, productID))
+
+		require.NoError(t, update(1))
+		assert.Equal(t, 9, currentStock(t, db, productID))
+
+		// 库存不足时整个修改回滚，订单项保持不变
+		assert.EqualError(t, update(20), "商品 招牌奶茶 库存不足")
+		assert.Equal(t, 9, currentStock(t, db, productID))
+
+		detail, err := service.GetOrder(orderID, shopID)
+		require.NoError(t, err)
+		require.Len(t, detail.Items, 1)
+		assert.Equal(t, 1, detail.Items[0].Quantity)
+	})
+
+	t.Run("update changes status through the flow", func(t *testing.T) {
+		service, db, productID := setupStockTest(t, 10)
+		orderID := createStockTestOrder(t, service, productID, 3)
+
+		reasonFlow := stockTestFlow()
+		reasonFlow.Statuses[0].Actions[1].RequireReason = true
+		update := func(status order.OrderStatus, reason string) error {
+			_, err := service.UpdateOrder(&dto.UpdateOrderRequest{
+				ID:     orderID,
+				ShopID: shopID,
+				Items:  []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 3}},
+				Status: status,
+				Reason: reason,
+			}, reasonFlow)
+			return err
+		}
+
+		assert.Error(t, update(order.OrderStatusComplete, ""), "不能跳过流转配置")
+		assert.EqualError(t, update(order.OrderStatusCanceled, " "), "取消订单必须填写原因")
+		assert.Equal(t, 7, currentStock(t, db, productID))
+
+		require.NoError(t, update(order.OrderStatusCanceled, "顾客要求"))
+		assert.Equal(t, 10, currentStock(t, db, productID))
+
+		timeline, err := service.GetOrderTimeline(orderID, shopID, reasonFlow)
+		require.NoError(t, err)
+		last := timeline.Timeline[len(timeline.Timeline)-1]
+		assert.Equal(t, order.OrderStatusCanceled, last.NewStatus)
+		assert.Equal(t, "顾客要求", last.Reason)
+
+		// 已取消的订单不能再修改，库存不会被重复占用
+		assert.ErrorIs(t, update(order.OrderStatusCanceled, ""), order.ErrOrderNotEditable)
+		assert.Equal(t, 10, currentStock(t, db, productID))
+	})
+
+	t.Run("concurrent orders cannot oversell", func(t *testing.T) {
+		service, db, productID := setupStockTest(t, 5)
+
+		var wg sync.WaitGroup
+		results := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := service.CreateOrder(&dto.CreateOrderRequest{
+					UserID: shared.ID(9001),
+					ShopID: shopID,
+					Items:  []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 1}},
+				})
+				results <- err
+			}()
+		}
+		wg.Wait()
+		close(results)
+
+		succeeded := 0
+		for err := range results {
+			if err == nil {
+				succeeded++
+			}
+		}
+		assert.Equal(t, 5, succeeded)
+		assert.Equal(t, 0, currentStock(t, db, productID))
+	})
+}
+
+func TestOrderService_PriceTotals(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	service, db, productID := setupStockTest(t, 10)
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", productID.Value()).
+		Update("price", shared.NewPrice(9.9)).Error)
+
+	// 9.9 * 3 用浮点计算为 29.700000000000003
+	orderID := createStockTestOrder(t, service, productID, 3)
+	detail, err := service.GetOrder(orderID, shopID)
+	require.NoError(t, err)
+	assert.Equal(t, "29.70", detail.TotalPrice.String())
+	assert.Equal(t, "9.90", detail.Items[0].Price.String())
+
+	_, err = service.UpdateOrder(&dto.UpdateOrderRequest{
+		ID:     orderID,
+		ShopID: shopID,
+		Items:  []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 7}},
+		Status: order.OrderStatusPending,
+	}, stockTestFlow())
+	require.NoError(t, err)
+
+	var stored models.Order
+	require.NoError(t, db.First(&stored, orderID.Value()).Error)
+	assert.Equal(t, shared.PriceFromCents(6930), stored.TotalPrice)
+}
+
+func TestOrderService_Charges(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	service, db, productID := setupStockTest(t, 10)
+	require.NoError(t, db.Create(&models.Shop{
+		ID:            snowflake.ID(stockTestShopID),
+		Name:          "测试店铺",
+		OwnerUsername: "owner",
+		Settings:      []byte(`{"charges": {"tax_rate": 6, "service_charge_rate": 10, "packaging_fee": 1}}`),
+	}).Error)
+
+	// 24 + 服务费 2.40，税 (24 + 2.40) * 6% = 1.58
+	dineIn, err := service.CreateOrder(&dto.CreateOrderRequest{
+		UserID: shared.ID(9001),
+		ShopID: shopID,
+		Items:  []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 2}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "24.00", dineIn.Subtotal.String())
+	assert.Equal(t, "2.40", dineIn.ServiceCharge.String())
+	assert.True(t, dineIn.PackagingFee.IsZero())
+	assert.Equal(t, "1.58", dineIn.TaxAmount.String())
+	assert.Equal(t, "27.98", dineIn.TotalPrice.String())
+
+	// 外带每件加收打包费 1 元，打包费同样计税
+	takeaway, err := service.CreateOrder(&dto.CreateOrderRequest{
+		UserID:   shared.ID(9001),
+		ShopID:   shopID,
+		Items:    []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 2}},
+		Takeaway: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "2.00", takeaway.PackagingFee.String())
+	assert.Equal(t, "1.70", takeaway.TaxAmount.String())
+	assert.Equal(t, "30.10", takeaway.TotalPrice.String())
+
+	var stored models.Order
+	require.NoError(t, db.First(&stored, takeaway.ID.Value()).Error)
+	assert.Equal(t, "24.00", stored.Subtotal.String())
+	assert.Equal(t, "2.40", stored.ServiceCharge.String())
+	assert.Equal(t, "2.00", stored.PackagingFee.String())
+	assert.Equal(t, "1.70", stored.TaxAmount.String())
+	assert.Equal(t, "6.00", stored.TaxRate.String())
+	assert.True(t, stored.Takeaway)
+
+	// 修改订单时重新计算，改为堂食后不再收取打包费
+	dineInNow := false
+	detail, err := service.UpdateOrder(&dto.UpdateOrderRequest{
+		ID:       takeaway.ID,
+		ShopID:   shopID,
+		Items:    []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 3}},
+		Status:   order.OrderStatusPending,
+		Takeaway: &dineInNow,
+	}, stockTestFlow())
+	require.NoError(t, err)
+	assert.Equal(t, "36.00", detail.Subtotal.String())
+	assert.Equal(t, "3.60", detail.ServiceCharge.String())
+	assert.True(t, detail.PackagingFee.IsZero())
+	assert.Equal(t, "2.38", detail.TaxAmount.String())
+	assert.Equal(t, "41.98", detail.TotalPrice.String())
+	assert.False(t, detail.Takeaway)
+}
+
+func TestOrderService_ShopAcceptingOrders(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	service, db, productID := setupStockTest(t, 10)
+	require.NoError(t, db.Create(&models.Shop{
+		ID:             snowflake.ID(stockTestShopID),
+		Name:           "测试店铺",
+		OwnerUsername:  "owner",
+		OrderingPaused: true,
+	}).Error)
+
+	create := func() error {
+		_, err := service.CreateOrder(&dto.CreateOrderRequest{
+			UserID: shared.ID(9001),
+			ShopID: shopID,
+			Items:  []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 1}},
+		})
+		return err
+	}
+
+	// 暂停接单
+	assert.ErrorIs(t, create(), shop.ErrOrderingPaused)
+
+	// 恢复接单但没有任何营业时段
+	require.NoError(t, db.Model(&models.Shop{}).Where("id = ?", stockTestShopID).Updates(map[string]interface{}{
+		"ordering_paused": false,
+		"business_hours":  []byte(`{"weekly": [{"weekday": 1, "periods": []}]}`),
+	}).Error)
+	assert.ErrorIs(t, create(), shop.ErrShopClosed)
+	assert.Equal(t, 10, currentStock(t, db, productID))
+
+	// 全天营业
+	require.NoError(t, db.Model(&models.Shop{}).Where("id = ?", stockTestShopID).
+		Update("business_hours", []byte(`{"weekly": [], "special": []}`)).Error)
+	assert.NoError(t, create())
+	assert.Equal(t, 9, currentStock(t, db, productID))
+}
+
+func TestOrderService_ScheduledOrders(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	service, db, productID := setupStockTest(t, 10)
+	require.NoError(t, db.Create(&models.Shop{
+		ID:            snowflake.ID(stockTestShopID),
+		Name:          "测试店铺",
+		OwnerUsername: "owner",
+		Timezone:      "UTC",
+		Settings:      []byte(`{"scheduling": {"enabled": true, "slot_minutes": 60, "slot_capacity": 1, "max_days_ahead": 1}}`),
+		OrderStatusFlow: models.OrderStatusFlow{Statuses: []models.OrderStatus{
+			{Value: int(order.OrderStatusCanceled), Label: "已取消", IsFinal: true, ReleaseStock: true},
+		}},
+	}).Error)
+
+	create := func(scheduled *time.Time) (*dto.OrderResponse, error) {
+		return service.CreateOrder(&dto.CreateOrderRequest{
+			UserID:        shared.ID(9001),
+			ShopID:        shopID,
+			Items:         []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 1}},
+			ScheduledTime: scheduled,
+		})
+	}
+
+	slotStart := time.Now().UTC().Truncate(time.Hour).Add(2 * time.Hour)
+	scheduled := slotStart.Add(10 * time.Minute)
+	first, err := create(&scheduled)
+	require.NoError(t, err)
+
+	// 同一时段容量已满
+	sameSlot := slotStart.Add(40 * time.Minute)
+	_, err = create(&sameSlot)
+	assert.ErrorIs(t, err, ErrScheduleSlotFull)
+	assert.Equal(t, 9, currentStock(t, db, productID))
+
+	past := time.Now().Add(-time.Hour)
+	_, err = create(&past)
+	assert.EqualError(t, err, "预约时间不能早于当前时间")
+
+	slots, err := service.GetScheduleSlots(shopID)
+	require.NoError(t, err)
+	assert.Equal(t, "UTC", slots.Timezone)
+	var found bool
+	for _, slot := range slots.Slots {
+		if slot.Start.Equal(slotStart) {
+			found = true
+			require.NotNil(t, slot.Remaining)
+			assert.Equal(t, 0, *slot.Remaining)
+			assert.False(t, slot.Available)
+		}
+	}
+	assert.True(t, found, "应包含已预约的时段")
+
+	// 立即制作的订单排在预约订单之前
+	asap, err := create(nil)
+	require.NoError(t, err)
+	flow := stockTestFlow()
+	list, err := service.GetUnfinishedOrders(shopID, flow, order.UnfinishedFilter{SortByFulfillment: true}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, list.Data, 2)
+	assert.Equal(t, asap.ID, list.Data[0].ID)
+	assert.Equal(t, first.ID, list.Data[1].ID)
+
+	list, err = service.GetUnfinishedOrders(shopID, flow, order.UnfinishedFilter{FulfillFrom: slotStart, FulfillTo: slotStart.Add(time.Hour)}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, first.ID, list.Data[0].ID)
+
+	// 取消后释放时段
+	require.NoError(t, db.Model(&models.Order{}).Where("id = ?", first.ID.Value()).
+		Update("status", int(order.OrderStatusCanceled)).Error)
+	_, err = create(&sameSlot)
+	assert.NoError(t, err)
+}
+
+func TestOrderService_PickupNumbers(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	service, db, productID := setupStockTest(t, 10)
+
+	// 店铺不存在时使用默认前缀
+	first := createStockTestOrder(t, service, productID, 1)
+	var stored models.Order
+	require.NoError(t, db.First(&stored, first.Value()).Error)
+	assert.Equal(t, "A001", stored.PickupNumber)
+	assert.Equal(t, time.Now().In(time.FixedZone(shop.DefaultTimezone, 8*60*60)).Format("2006-01-02"), stored.BusinessDate)
+
+	require.NoError(t, db.Create(&models.Shop{
+		ID:            snowflake.ID(stockTestShopID),
+		Name:          "测试店铺",
+		OwnerUsername: "owner",
+		Settings:      []byte(`{"pickup": {"prefix": "C"}}`),
+		OrderStatusFlow: models.OrderStatusFlow{Statuses: []models.OrderStatus{
+			{Value: int(order.OrderStatusPending), Label: "待处理"},
+			{Value: int(order.OrderStatusAccepted), Label: "待取餐", ReadyForPickup: true},
+		}},
+	}).Error)
+
+	second, err := service.CreateOrder(&dto.CreateOrderRequest{
+		UserID: shared.ID(9001),
+		ShopID: shopID,
+		Items:  []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 1}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "C002", second.PickupNumber, "同一营业日继续编号，前缀使用店铺设置")
+	third := createStockTestOrder(t, service, productID, 1)
+
+	board, err := service.GetPickupBoard(shopID)
+	require.NoError(t, err)
+	assert.Equal(t, stored.BusinessDate, board.BusinessDate)
+	assert.Empty(t, board.Ready)
+
+	require.NoError(t, db.Model(&models.Order{}).Where("id = ?", third.Value()).
+		Update("status", int(order.OrderStatusAccepted)).Error)
+	board, err = service.GetPickupBoard(shopID)
+	require.NoError(t, err)
+	require.Len(t, board.Ready, 1)
+	assert.Equal(t, "C003", board.Ready[0].PickupNumber)
+
+	list, err := service.SearchOrders(&dto.SearchOrdersRequest{ShopID: shopID, PickupNumber: " c003 ", Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, third, list.Data[0].ID)
+}
+
+// recordingStockPublisher 记录发布的库存事件
+type recordingStockPublisher struct {
+	mu     sync.Mutex
+	events []product.StockEvent
+}
+
+func (p *recordingStockPublisher) PublishStockEvent(event product.StockEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+}
+
+func (p *recordingStockPublisher) types() []product.StockEventType {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	types := make([]product.StockEventType, len(p.events))
+	for i, event := range p.events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func productStatus(t *testing.T, db *gorm.DB, productID shared.ID) (string, bool) {
+	var prod models.Product
+	require.NoError(t, db.First(&prod, productID.Value()).Error)
+	return prod.Status, prod.AutoOffline
+}
+
+func TestOrderService_LowStockAlerts(t *testing.T) {
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	flow := stockTestFlow()
+
+	setup := func(t *testing.T, autoOffline bool) (*OrderService, *gorm.DB, shared.ID, *recordingStockPublisher) {
+		service, db, productID := setupStockTest(t, 3)
+		require.NoError(t, db.Create(&models.Shop{
+			ID:                     snowflake.ID(stockTestShopID),
+			Name:                   "测试店铺",
+			OwnerUsername:          "owner",
+			AutoOfflineOnZeroStock: autoOffline,
+		}).Error)
+		require.NoError(t, db.Model(&models.Product{}).Where("id = ?", productID.Value()).
+			Update("low_stock_threshold", 2).Error)
+
+		publisher := &recordingStockPublisher{}
+		service.stockEventPublisher = publisher
+		return service, db, productID, publisher
+	}
+
+	t.Run("auto offline at zero and back online on restock", func(t *testing.T) {
+		service, db, productID, publisher := setup(t, true)
+
+		createStockTestOrder(t, service, productID, 1)
+		assert.Equal(t, []product.StockEventType{product.StockEventLowStock}, publisher.types())
+
+		orderID := createStockTestOrder(t, service, productID, 2)
+		status, autoOffline := productStatus(t, db, productID)
+		assert.Equal(t, models.ProductStatusOffline, status)
+		assert.True(t, autoOffline)
+
+		// 下单失败回滚时不发布事件
+		_, err := service.CreateOrder(&dto.CreateOrderRequest{
+			UserID: shared.ID(9001),
+			ShopID: shopID,
+			Items:  []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 1}},
+		})
+		assert.Error(t, err)
+
+		require.NoError(t, service.UpdateOrderStatus(orderID, shopID, order.OrderStatusCanceled, flow, order.StatusActor{}, "顾客取消"))
+		status, autoOffline = productStatus(t, db, productID)
+		assert.Equal(t, models.ProductStatusOnline, status)
+		assert.False(t, autoOffline)
+
+		assert.Equal(t, []product.StockEventType{
+			product.StockEventLowStock,
+			product.StockEventOutOfStock,
+			product.StockEventRestocked,
+		}, publisher.types())
+		event := publisher.events[1]
+		assert.Equal(t, stockTestShopID, event.ShopID)
+		assert.Equal(t, productID, event.ProductID)
+		assert.Equal(t, "招牌奶茶", event.ProductName)
+		assert.Equal(t, 0, event.Stock)
+		assert.Equal(t, 2, event.Threshold)
+		assert.Equal(t, product.ProductStatusOffline, event.Status)
+	})
+
+	t.Run("shop setting disabled keeps product online", func(t *testing.T) {
+		service, db, productID, publisher := setup(t, false)
+
+		createStockTestOrder(t, service, productID, 3)
+		status, autoOffline := productStatus(t, db, productID)
+		assert.Equal(t, models.ProductStatusOnline, status)
+		assert.False(t, autoOffline)
+		assert.Equal(t, []product.StockEventType{product.StockEventOutOfStock}, publisher.types())
+	})
+
+	t.Run("manually offline product stays offline after restock", func(t *testing.T) {
+		service, db, productID, _ := setup(t, true)
+
+		orderID := createStockTestOrder(t, service, productID, 3)
+		require.NoError(t, db.Model(&models.Product{}).Where("id = ?", productID.Value()).
+			Updates(map[string]interface{}{"status": models.ProductStatusOffline, "auto_offline": false}).Error)
+
+		require.NoError(t, service.DeleteOrder(orderID, shopID, flow, order.StatusActor{}))
+		assert.Equal(t, 3, currentStock(t, db, productID))
+		status, _ := productStatus(t, db, productID)
+		assert.Equal(t, models.ProductStatusOffline, status)
+	})
+
+	t.Run("low stock list", func(t *testing.T) {
+		_, db, productID, _ := setup(t, false)
+		require.NoError(t, db.Create(&models.Product{
+			ID:                snowflake.ID(6002),
+			ShopID:            snowflake.ID(stockTestShopID),
+			Name:              "柠檬茶",
+			Stock:             50,
+			LowStockThreshold: 10,
+			Status:            models.ProductStatusOnline,
+		}).Error)
+		require.NoError(t, db.Create(&models.Product{
+			ID:     snowflake.ID(6003),
+			ShopID: snowflake.ID(stockTestShopID),
+			Name:   "椰子冻",
+			Stock:  0,
+			Status: models.ProductStatusOnline,
+		}).Error)
+
+		productService := NewProductService(
+			repositories.NewProductRepository(db),
+			repositories.NewProductOptionCategoryRepository(db),
+			repositories.NewProductOptionRepository(db),
+			repositories.NewProductTagRepository(db),
+			db,
+			nil,
+		)
+		list, err := productService.GetLowStockProducts(shopID)
+		require.NoError(t, err)
+		require.Equal(t, 1, list.Total)
+		assert.Equal(t, shared.ID(6003), list.Data[0].ID)
+		assert.True(t, list.Data[0].LowStock)
+
+		require.NoError(t, db.Model(&models.Product{}).Where("id = ?", productID.Value()).Update("stock", 2).Error)
+		list, err = productService.GetLowStockProducts(shopID)
+		require.NoError(t, err)
+		require.Equal(t, 2, list.Total)
+		assert.Equal(t, shared.ID(6003), list.Data[0].ID)
+		assert.Equal(t, productID, list.Data[1].ID)
+	})
+}
+
+func TestProductService_StockLedger(t *testing.T) {
+	// ProductService 在未使用的事务之外通过仓储读写，需要第二个连接
+	db := openStockTestDB(t, 2)
+	service := NewProductService(
+		repositories.NewProductRepository(db),
+		repositories.NewProductOptionCategoryRepository(db),
+		repositories.NewProductOptionRepository(db),
+		repositories.NewProductTagRepository(db),
+		db,
+		nil,
+	)
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	actor := AuditActor{Type: PrincipalShop, ID: stockTestShopID, Name: "owner"}
+
+	created, err := service.CreateProduct(&dto.CreateProductRequest{
+		ShopID: shopID,
+		Name:   "芝士蛋糕",
+		Price:  shared.NewPrice(28),
+		Stock:  10,
+	}, actor)
+	require.NoError(t, err)
+	productID := created.ID
+
+	adjust := func(typ product.StockMovementType, quantity int, reason string) (*dto.StockMovementResponse, error) {
+		return service.AdjustStock(shopID, &dto.AdjustStockRequest{
+			ID:       productID,
+			Type:     typ,
+			Quantity: quantity,
+			Reason:   reason,
+		}, actor)
+	}
+
+	movement, err := adjust(product.StockMovementAdjustment, 5, "供应商补货")
+	require.NoError(t, err)
+	assert.Equal(t, 5, movement.Quantity)
+	assert.Equal(t, 15, movement.BalanceAfter)
+
+	_, err = adjust(product.StockMovementAdjustment, 5, "")
+	assert.EqualError(t, err, "库存调整必须填写原因")
+
+	_, err = adjust(product.StockMovementAdjustment, -20, "破损报废")
+	assert.EqualError(t, err, "商品 芝士蛋糕 库存不足")
+
+	// 盘点按实盘数量校正，记录差额
+	movement, err = adjust(product.StockMovementStocktake, 12, "月末盘点")
+	require.NoError(t, err)
+	assert.Equal(t, -3, movement.Quantity)
+	assert.Equal(t, 12, movement.BalanceAfter)
+
+	// 编辑商品不修改库存时不产生流水，修改库存时按调整记录差额
+	update := func(stock int) {
+		_, err := service.UpdateProduct(productID, shopID, &dto.CreateProductRequest{
+			Name:  "芝士蛋糕",
+			Price: shared.NewPrice(30),
+			Stock: stock,
+		}, actor)
+		require.NoError(t, err)
+	}
+	update(12)
+	update(20)
+	assert.Equal(t, 20, currentStock(t, db, productID))
+
+	history, err := service.GetStockMovements(dto.StockMovementQuery{
+		ProductID: productID,
+		ShopID:    shopID,
+		Page:      1,
+		PageSize:  10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 20, history.Stock)
+	require.Equal(t, int64(4), history.Total)
+
+	types := make([]product.StockMovementType, len(history.Data))
+	sum := 0
+	for i, m := range history.Data {
+		types[i] = m.Type
+		sum += m.Quantity
+	}
+	assert.Equal(t, []product.StockMovementType{
+		product.StockMovementAdjustment,
+		product.StockMovementStocktake,
+		product.StockMovementAdjustment,
+		product.StockMovementInitial,
+	}, types)
+	assert.Equal(t, "编辑商品", history.Data[0].Reason)
+	// 流水累加与最新余额都能和当前库存对上
+	assert.Equal(t, history.Stock, sum)
+	assert.Equal(t, history.Stock, history.Data[0].BalanceAfter)
+
+	filtered, err := service.GetStockMovements(dto.StockMovementQuery{
+		ProductID: productID,
+		ShopID:    shopID,
+		Type:      product.StockMovementStocktake,
+		Page:      1,
+		PageSize:  10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), filtered.Total)
+
+	_, err = service.GetStockMovements(dto.StockMovementQuery{
+		ProductID: productID,
+		ShopID:    shared.ID(2002),
+		Page:      1,
+		PageSize:  10,
+	})
+	assert.Error(t, err)
+}
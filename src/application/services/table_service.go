@@ -0,0 +1,548 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"orderease/application/dto"
+	"orderease/config"
+	"orderease/domain/order"
+	"orderease/domain/shared"
+	"orderease/domain/table"
+	"orderease/infrastructure/repositories"
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+)
+
+// TableService 堂食桌台：桌台和二维码管理、开台、换台、并台和整桌结账
+type TableService struct {
+	db             *gorm.DB
+	tableRepo      table.TableRepository
+	sessionRepo    table.SessionRepository
+	orderRepo      order.OrderRepository
+	paymentService *PaymentService
+}
+
+func NewTableService(
+	db *gorm.DB,
+	tableRepo table.TableRepository,
+	sessionRepo table.SessionRepository,
+	orderRepo order.OrderRepository,
+	paymentService *PaymentService,
+) *TableService {
+	if config.AppConfig.Table.QRSecret == "" {
+		log2.Warnf("未配置桌台二维码签名密钥（环境变量 TABLE_QR_SECRET），使用 JWT 密钥签名")
+	}
+	return &TableService{
+		db:             db,
+		tableRepo:      tableRepo,
+		sessionRepo:    sessionRepo,
+		orderRepo:      orderRepo,
+		paymentService: paymentService,
+	}
+}
+
+// checkNameAvailable 同一店铺内桌号不能重复，excludeID 为修改中的桌台
+func (s *TableService) checkNameAvailable(shopID uint64, name string, excludeID shared.ID) error {
+	tables, err := s.tableRepo.FindByShopID(shopID)
+	if err != nil {
+		return err
+	}
+	name = strings.TrimSpace(name)
+	for _, t := range tables {
+		if t.Name == name && t.ID != excludeID {
+			return errors.New("桌号已存在")
+		}
+	}
+	return nil
+}
+
+func (s *TableService) CreateTable(req *dto.CreateTableRequest) (*dto.TableResponse, error) {
+	t, err := table.NewTable(req.ShopID.ToUint64(), req.Name, req.Seats)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNameAvailable(t.ShopID, t.Name, 0); err != nil {
+		return nil, err
+	}
+
+	if err := s.tableRepo.Save(t); err != nil {
+		return nil, errors.New("创建桌台失败")
+	}
+
+	log2.Infof("创建桌台成功: 店铺 %s, 桌号 %s", req.ShopID, t.Name)
+	return toTableResponse(t, nil), nil
+}
+
+func (s *TableService) UpdateTable(req *dto.UpdateTableRequest) (*dto.TableResponse, error) {
+	t, err := s.tableRepo.FindByIDAndShopID(req.ID, req.ShopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	name, seats := t.Name, t.Seats
+	if req.Name != "" {
+		name = req.Name
+	}
+	if req.Seats != nil {
+		seats = *req.Seats
+	}
+	if err := t.Update(name, seats); err != nil {
+		return nil, err
+	}
+	if err := s.checkNameAvailable(t.ShopID, t.Name, t.ID); err != nil {
+		return nil, err
+	}
+	if req.Active != nil {
+		t.SetActive(*req.Active)
+	}
+
+	if err := s.tableRepo.Update(t); err != nil {
+		return nil, errors.New("更新桌台失败")
+	}
+
+	session, err := s.sessionRepo.FindOpenByTableID(t.ID, t.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	return toTableResponse(t, session), nil
+}
+
+// DeleteTable 删除桌台，正在用餐的桌台需先结账或换台
+func (s *TableService) DeleteTable(id shared.ID, shopID shared.ID) error {
+	t, err := s.tableRepo.FindByIDAndShopID(id, shopID.ToUint64())
+	if err != nil {
+		return err
+	}
+	session, err := s.sessionRepo.FindOpenByTableID(t.ID, t.ShopID)
+	if err != nil {
+		return err
+	}
+	if session != nil {
+		return table.ErrSessionOpen
+	}
+
+	if err := s.tableRepo.Delete(id, t.ShopID); err != nil {
+		return errors.New("删除桌台失败")
+	}
+	log2.Infof("删除桌台成功: 店铺 %s, 桌号 %s", shopID, t.Name)
+	return nil
+}
+
+// GetTables 店铺的全部桌台及其进行中的用餐
+func (s *TableService) GetTables(shopID shared.ID) ([]dto.TableResponse, error) {
+	tables, err := s.tableRepo.FindByShopID(shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.sessionRepo.FindOpenByShopID(shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	openByTable := make(map[shared.ID]*table.Session, len(sessions))
+	for i := range sessions {
+		openByTable[sessions[i].TableID] = &sessions[i]
+	}
+
+	result := make([]dto.TableResponse, len(tables))
+	for i := range tables {
+		result[i] = *toTableResponse(&tables[i], openByTable[tables[i].ID])
+	}
+	return result, nil
+}
+
+// RegenerateQRCode 重新生成桌台二维码，已打印的旧二维码立即失效
+func (s *TableService) RegenerateQRCode(id shared.ID, shopID shared.ID) (*dto.TableResponse, error) {
+	t, err := s.tableRepo.FindByIDAndShopID(id, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+	t.RegenerateQRCode()
+	if err := s.tableRepo.Update(t); err != nil {
+		return nil, errors.New("更新桌台失败")
+	}
+
+	log2.Infof("重新生成桌台二维码: 店铺 %s, 桌号 %s, 版本 %d", shopID, t.Name, t.QRVersion)
+	session, err := s.sessionRepo.FindOpenByTableID(t.ID, t.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	return toTableResponse(t, session), nil
+}
+
+// ResolveTableCode 校验桌台二维码，返回二维码对应的桌台
+// 二维码已重新生成、桌台已删除或停用时拒绝
+func (s *TableService) ResolveTableCode(code string) (*table.Table, error) {
+	claims, err := table.ParseQRCode(tableQRSecret(), code)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.tableRepo.FindByIDAndShopID(claims.TableID, claims.ShopID)
+	if err != nil {
+		if errors.Is(err, table.ErrTableNotFound) {
+			return nil, table.ErrInvalidQRCode
+		}
+		return nil, err
+	}
+	if err := t.CheckQRClaims(claims); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// JoinTable 顾客扫码加入桌台进行中的用餐，桌台空闲时开台
+// 每次用餐创建单独的扫码点餐顾客身份，同一用餐的顾客共用购物车和订单，结账后再扫码是新的身份
+func (s *TableService) JoinTable(t *table.Table) (*table.Session, models.User, error) {
+	var session *table.Session
+	var guest models.User
+	err := WithTx(s.db, func(tx *gorm.DB) error {
+		locked, err := repositories.NewTableRepository(tx).FindByIDForUpdate(t.ID, t.ShopID)
+		if err != nil {
+			return err
+		}
+
+		sessions := repositories.NewTableSessionRepository(tx)
+		var opened bool
+		session, opened, err = openTableSession(sessions, locked, 0)
+		if err != nil {
+			return err
+		}
+		if opened {
+			log2.Infof("桌台扫码开台: 店铺 %d, 桌台 %s, 用餐 %s", locked.ShopID, locked.Name, session.ID)
+		}
+
+		guest, err = findOrCreateSystemUser(tx, fmt.Sprintf("shop_%d_table_session_%s", session.ShopID, session.ID))
+		if err != nil {
+			log2.Errorf("创建扫码点餐用户失败, 用餐ID: %s, 错误: %v", session.ID, err)
+			return errors.New("创建扫码点餐用户失败")
+		}
+		if session.GuestUserID.IsZero() {
+			session.GuestUserID = shared.ID(guest.ID)
+			return sessions.Update(session)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, models.User{}, err
+	}
+	return session, guest, nil
+}
+
+// GuestSession 扫码点餐顾客当前所属的用餐，顾客只能访问该用餐中的订单
+// 不是扫码点餐的顾客返回 nil
+func (s *TableService) GuestSession(userID shared.ID, shopID shared.ID) (*table.Session, error) {
+	return currentGuestSession(s.sessionRepo, userID, shopID.ToUint64())
+}
+
+// OpenSession 店员开台，顾客扫码下单时空闲桌台会自动开台
+func (s *TableService) OpenSession(req *dto.OpenTableSessionRequest) (*dto.TableSessionResponse, error) {
+	var session *table.Session
+	err := WithTx(s.db, func(tx *gorm.DB) error {
+		t, err := repositories.NewTableRepository(tx).FindByIDForUpdate(req.TableID, req.ShopID.ToUint64())
+		if err != nil {
+			return err
+		}
+
+		var opened bool
+		session, opened, err = openTableSession(repositories.NewTableSessionRepository(tx), t, req.Guests)
+		if err != nil {
+			return err
+		}
+		if !opened {
+			return table.ErrSessionOpen
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log2.Infof("开台成功: 店铺 %s, 桌台 %s, 用餐 %s, 人数 %d", req.ShopID, req.TableID, session.ID, session.Guests)
+	return toTableSessionResponse(session), nil
+}
+
+// lockTablePair 按ID顺序锁定换台、并台涉及的两张桌台，避免相互等待
+func lockTablePair(tables table.TableRepository, shopID uint64, a, b shared.ID) (*table.Table, *table.Table, error) {
+	first, second := a, b
+	if second < first {
+		first, second = second, first
+	}
+
+	locked := make(map[shared.ID]*table.Table, 2)
+	for _, id := range []shared.ID{first, second} {
+		if _, ok := locked[id]; ok {
+			continue
+		}
+		t, err := tables.FindByIDForUpdate(id, shopID)
+		if err != nil {
+			return nil, nil, err
+		}
+		locked[id] = t
+	}
+	return locked[a], locked[b], nil
+}
+
+// moveSessionOrders 把用餐的订单移到目标桌台和目标用餐
+func moveSessionOrders(tx *gorm.DB, shopID uint64, from shared.ID, tableID, sessionID shared.ID) error {
+	if err := tx.Model(&models.Order{}).
+		Where("shop_id = ? AND table_session_id = ?", shopID, from.Value()).
+		Updates(map[string]interface{}{
+			"table_id":         tableID.Value(),
+			"table_session_id": sessionID.Value(),
+		}).Error; err != nil {
+		log2.Errorf("移动桌台订单失败, 用餐ID: %s, 错误: %v", from, err)
+		return errors.New("移动桌台订单失败")
+	}
+	return nil
+}
+
+// TransferTable 换台，目标桌台需空闲，用餐的订单随之移到目标桌台
+func (s *TableService) TransferTable(req *dto.MoveTableRequest) (*dto.TableSessionResponse, error) {
+	var session *table.Session
+	err := WithTx(s.db, func(tx *gorm.DB) error {
+		sessions := repositories.NewTableSessionRepository(tx)
+		from, to, err := lockTablePair(repositories.NewTableRepository(tx), req.ShopID.ToUint64(), req.TableID, req.TargetTableID)
+		if err != nil {
+			return err
+		}
+
+		session, err = sessions.FindOpenByTableID(from.ID, from.ShopID)
+		if err != nil {
+			return err
+		}
+		if session == nil {
+			return table.ErrSessionNotFound
+		}
+		occupied, err := sessions.FindOpenByTableID(to.ID, to.ShopID)
+		if err != nil {
+			return err
+		}
+		if occupied != nil {
+			return errors.New("目标桌台正在用餐，请使用并台")
+		}
+
+		if err := session.TransferTo(to); err != nil {
+			return err
+		}
+		if err := sessions.Update(session); err != nil {
+			return err
+		}
+		return moveSessionOrders(tx, session.ShopID, session.ID, to.ID, session.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log2.Infof("换台成功: 店铺 %s, 用餐 %s, 桌台 %s -> %s", req.ShopID, session.ID, req.TableID, req.TargetTableID)
+	return toTableSessionResponse(session), nil
+}
+
+// MergeTables 并台，TableID 的用餐并入 TargetTableID 的用餐，之后两桌的订单合并结账
+func (s *TableService) MergeTables(req *dto.MoveTableRequest) (*dto.TableSessionResponse, error) {
+	var target *table.Session
+	err := WithTx(s.db, func(tx *gorm.DB) error {
+		sessions := repositories.NewTableSessionRepository(tx)
+		from, to, err := lockTablePair(repositories.NewTableRepository(tx), req.ShopID.ToUint64(), req.TableID, req.TargetTableID)
+		if err != nil {
+			return err
+		}
+
+		source, err := sessions.FindOpenByTableID(from.ID, from.ShopID)
+		if err != nil {
+			return err
+		}
+		if target, err = sessions.FindOpenByTableID(to.ID, to.ShopID); err != nil {
+			return err
+		}
+		if source == nil || target == nil {
+			return table.ErrSessionNotFound
+		}
+
+		if err := source.MergeInto(target); err != nil {
+			return err
+		}
+		if err := sessions.Update(source); err != nil {
+			return err
+		}
+		if err := sessions.Update(target); err != nil {
+			return err
+		}
+		return moveSessionOrders(tx, source.ShopID, source.ID, target.TableID, target.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log2.Infof("并台成功: 店铺 %s, 桌台 %s 并入 %s, 用餐 %s", req.ShopID, req.TableID, req.TargetTableID, target.ID)
+	return toTableSessionResponse(target), nil
+}
+
+// GetSessionBill 查询一次用餐的账单
+func (s *TableService) GetSessionBill(sessionID shared.ID, shopID shared.ID, flow order.OrderStatusFlow) (*dto.TableBillResponse, error) {
+	session, err := s.sessionRepo.FindByIDAndShopID(sessionID, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+	return s.buildBill(session, flow)
+}
+
+// GetTableBill 顾客扫码查看所在桌台当前用餐的账单
+// 扫码点餐的顾客只能查看自己所属的用餐，结账后看不到该桌台之后的用餐
+func (s *TableService) GetTableBill(t *table.Table, userID shared.ID, flow order.OrderStatusFlow) (*dto.TableBillResponse, error) {
+	session, err := currentGuestSession(s.sessionRepo, userID, t.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		if session, err = s.sessionRepo.FindOpenByTableID(t.ID, t.ShopID); err != nil {
+			return nil, err
+		}
+	}
+	if session == nil {
+		return nil, table.ErrSessionNotFound
+	}
+	return s.buildBill(session, flow)
+}
+
+func (s *TableService) buildBill(session *table.Session, flow order.OrderStatusFlow) (*dto.TableBillResponse, error) {
+	orders, err := s.orderRepo.FindByTableSessionID(session.ID, session.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	bill := &dto.TableBillResponse{
+		Session: *toTableSessionResponse(session),
+		Orders:  make([]dto.OrderResponse, len(orders)),
+	}
+	if t, err := s.tableRepo.FindByIDAndShopID(session.TableID, session.ShopID); err == nil {
+		bill.TableName = t.Name
+	}
+
+	for i := range orders {
+		ord := &orders[i]
+		bill.Orders[i] = toOrderResponse(ord)
+		if flow.ReleasesStock(ord.Status) {
+			continue
+		}
+		bill.TotalAmount = bill.TotalAmount.Add(ord.TotalPrice)
+		bill.PaidAmount = bill.PaidAmount.Add(ord.PaidAmount)
+		if due := ord.TotalPrice.Sub(ord.PaidAmount); !ord.PaymentStatus.IsPaid() && due.IsPositive() {
+			bill.DueAmount = bill.DueAmount.Add(due)
+		}
+	}
+	return bill, nil
+}
+
+// CloseSession 店员手动结束用餐，只能结束订单都已结清的用餐（如未下单的开台）
+// 全部订单付清后用餐会自动结束
+func (s *TableService) CloseSession(sessionID shared.ID, shopID shared.ID, flow order.OrderStatusFlow) (*dto.TableSessionResponse, error) {
+	var session *table.Session
+	err := WithTx(s.db, func(tx *gorm.DB) error {
+		sessions := repositories.NewTableSessionRepository(tx)
+		current, err := sessions.FindByIDAndShopID(sessionID, shopID.ToUint64())
+		if err != nil {
+			return err
+		}
+		// 锁定桌台后重新读取，避免与下单交错
+		if _, err := repositories.NewTableRepository(tx).FindByIDForUpdate(current.TableID, current.ShopID); err != nil {
+			return err
+		}
+		if session, err = sessions.FindByIDAndShopID(sessionID, shopID.ToUint64()); err != nil {
+			return err
+		}
+
+		orders, err := repositories.NewOrderRepository(tx).FindByTableSessionID(session.ID, session.ShopID)
+		if err != nil {
+			return err
+		}
+		for i := range orders {
+			if !orderSettled(&orders[i], flow) {
+				return errors.New("还有未结清的订单，请先结账")
+			}
+		}
+
+		if err := session.Close(table.CloseReasonManual); err != nil {
+			return err
+		}
+		return sessions.Update(session)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log2.Infof("结束用餐: 店铺 %s, 用餐 %s", shopID, sessionID)
+	return toTableSessionResponse(session), nil
+}
+
+// SettleSession 整桌结账，店员收款后逐单确认线下收款，最后一单付清后用餐自动结束
+func (s *TableService) SettleSession(req *dto.SettleTableSessionRequest, flow order.OrderStatusFlow, actor AuditActor) (*dto.TableBillResponse, error) {
+	if !req.Method.IsOffline() {
+		return nil, errors.New("只能确认现金或到店付款")
+	}
+
+	session, err := s.sessionRepo.FindByIDAndShopID(req.SessionID, req.ShopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+	if !session.IsOpen() {
+		return nil, table.ErrSessionClosed
+	}
+
+	orders, err := s.orderRepo.FindByTableSessionID(session.ID, session.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		ord := &orders[i]
+		if orderSettled(ord, flow) {
+			continue
+		}
+		if _, err := s.paymentService.ConfirmOfflinePayment(&dto.ConfirmPaymentRequest{
+			ShopID:  req.ShopID,
+			OrderID: ord.ID,
+			Method:  req.Method,
+		}, flow, actor); err != nil {
+			return nil, err
+		}
+	}
+
+	if session, err = s.sessionRepo.FindByIDAndShopID(req.SessionID, req.ShopID.ToUint64()); err != nil {
+		return nil, err
+	}
+	log2.Infof("整桌结账: 店铺 %s, 用餐 %s, 操作人 %s", req.ShopID, session.ID, actor.Name)
+	return s.buildBill(session, flow)
+}
+
+func toTableResponse(t *table.Table, session *table.Session) *dto.TableResponse {
+	resp := &dto.TableResponse{
+		ID:        t.ID,
+		ShopID:    shared.ParseIDFromUint64(t.ShopID),
+		Name:      t.Name,
+		Seats:     t.Seats,
+		Active:    t.Active,
+		QRCode:    t.QRCode(tableQRSecret()),
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+	if session != nil {
+		resp.Session = toTableSessionResponse(session)
+	}
+	return resp
+}
+
+func toTableSessionResponse(s *table.Session) *dto.TableSessionResponse {
+	return &dto.TableSessionResponse{
+		ID:          s.ID,
+		TableID:     s.TableID,
+		Status:      s.Status,
+		Guests:      s.Guests,
+		OpenedAt:    s.OpenedAt,
+		ClosedAt:    s.ClosedAt,
+		CloseReason: s.CloseReason,
+		MergedInto:  s.MergedInto,
+	}
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"testing"
+
+	"orderease/application/dto"
+	"orderease/config"
+	"orderease/domain/order"
+	"orderease/domain/payment"
+	"orderease/domain/shared"
+	"orderease/domain/table"
+	"orderease/infrastructure/repositories"
+	"orderease/models"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableService_DineIn(t *testing.T) {
+	previous := config.AppConfig.Table.QRSecret
+	config.AppConfig.Table.QRSecret = "test-table-secret"
+	t.Cleanup(func() { config.AppConfig.Table.QRSecret = previous })
+
+	shopID := shared.ParseIDFromUint64(stockTestShopID)
+	flow := stockTestFlow()
+	orderService, db, productID := setupStockTest(t, 20)
+	paymentService := newTestPaymentService(db)
+	service := NewTableService(
+		db,
+		repositories.NewTableRepository(db),
+		repositories.NewTableSessionRepository(db),
+		repositories.NewOrderRepository(db),
+		paymentService,
+	)
+
+	require.NoError(t, db.Create(&models.Shop{
+		ID:            snowflake.ID(stockTestShopID),
+		Name:          "测试店铺",
+		OwnerUsername: "owner",
+		OrderStatusFlow: models.OrderStatusFlow{Statuses: []models.OrderStatus{
+			{Value: int(order.OrderStatusPending), Label: "待处理"},
+			{Value: int(order.OrderStatusCanceled), Label: "已取消", IsFinal: true, ReleaseStock: true},
+		}},
+	}).Error)
+
+	a1, err := service.CreateTable(&dto.CreateTableRequest{ShopID: shopID, Name: "A1", Seats: 4})
+	require.NoError(t, err)
+	a2, err := service.CreateTable(&dto.CreateTableRequest{ShopID: shopID, Name: "A2", Seats: 2})
+	require.NoError(t, err)
+	_, err = service.CreateTable(&dto.CreateTableRequest{ShopID: shopID, Name: " A1 "})
+	assert.EqualError(t, err, "桌号已存在")
+
+	scanOrder := func(code string) (*dto.OrderResponse, error) {
+		return orderService.CreateOrder(&dto.CreateOrderRequest{
+			UserID:    shared.ID(9001),
+			ShopID:    shopID,
+			Items:     []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 1}},
+			TableCode: code,
+		})
+	}
+
+	// 扫码下单自动开台，同一桌台的订单归入同一次用餐
+	first, err := scanOrder(a1.QRCode)
+	require.NoError(t, err)
+	assert.Equal(t, a1.ID, first.TableID)
+	require.False(t, first.TableSessionID.IsZero())
+	second, err := scanOrder(a1.QRCode)
+	require.NoError(t, err)
+	assert.Equal(t, first.TableSessionID, second.TableSessionID)
+
+	t.Run("qr code", func(t *testing.T) {
+		resolved, err := service.ResolveTableCode(a1.QRCode)
+		require.NoError(t, err)
+		assert.Equal(t, "A1", resolved.Name)
+
+		tampered := a1.QRCode[:len(a1.QRCode)-1] + "0"
+		if tampered == a1.QRCode {
+			tampered = a1.QRCode[:len(a1.QRCode)-1] + "1"
+		}
+		_, err = scanOrder(tampered)
+		assert.ErrorIs(t, err, table.ErrInvalidQRCode)
+
+		regenerated, err := service.RegenerateQRCode(a1.ID, shopID)
+		require.NoError(t, err)
+		assert.NotEqual(t, a1.QRCode, regenerated.QRCode)
+		assert.Equal(t, first.TableSessionID, regenerated.Session.ID, "重新生成二维码不影响进行中的用餐")
+		_, err = service.ResolveTableCode(a1.QRCode)
+		assert.ErrorIs(t, err, table.ErrInvalidQRCode, "旧二维码失效")
+		a1 = regenerated
+	})
+
+	// 店员代客下单指定桌台
+	third, err := orderService.CreateOrder(&dto.CreateOrderRequest{
+		UserID:  shared.ID(9002),
+		ShopID:  shopID,
+		Items:   []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 1}},
+		TableID: a2.ID,
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.TableSessionID, third.TableSessionID)
+
+	t.Run("transfer to occupied table is rejected", func(t *testing.T) {
+		_, err := service.TransferTable(&dto.MoveTableRequest{ShopID: shopID, TableID: a1.ID, TargetTableID: a2.ID})
+		assert.EqualError(t, err, "目标桌台正在用餐，请使用并台")
+	})
+
+	merged, err := service.MergeTables(&dto.MoveTableRequest{ShopID: shopID, TableID: a1.ID, TargetTableID: a2.ID})
+	require.NoError(t, err)
+	assert.Equal(t, third.TableSessionID, merged.ID)
+
+	source, err := repositories.NewTableSessionRepository(db).FindByIDAndShopID(first.TableSessionID, stockTestShopID)
+	require.NoError(t, err)
+	assert.Equal(t, table.CloseReasonMerged, source.CloseReason)
+	assert.Equal(t, merged.ID, source.MergedInto)
+
+	bill, err := service.GetSessionBill(merged.ID, shopID, flow)
+	require.NoError(t, err)
+	assert.Equal(t, "A2", bill.TableName)
+	require.Len(t, bill.Orders, 3)
+	for _, o := range bill.Orders {
+		assert.Equal(t, a2.ID, o.TableID, "并台后订单移到目标桌台")
+	}
+	assert.Equal(t, "36.00", bill.TotalAmount.String())
+	assert.Equal(t, "36.00", bill.DueAmount.String())
+
+	_, err = service.CloseSession(merged.ID, shopID, flow)
+	assert.EqualError(t, err, "还有未结清的订单，请先结账")
+
+	err = service.DeleteTable(a2.ID, shopID)
+	assert.ErrorIs(t, err, table.ErrSessionOpen)
+
+	settled, err := service.SettleSession(&dto.SettleTableSessionRequest{
+		ShopID: shopID, SessionID: merged.ID, Method: payment.MethodCash,
+	}, flow, AuditActor{Name: "收银员"})
+	require.NoError(t, err)
+	assert.Equal(t, table.SessionStatusClosed, settled.Session.Status)
+	assert.Equal(t, table.CloseReasonPaid, settled.Session.CloseReason)
+	assert.Equal(t, "36.00", settled.PaidAmount.String())
+	assert.True(t, settled.DueAmount.IsZero())
+
+	t.Run("single payment closes settled session", func(t *testing.T) {
+		ord, err := scanOrder(a1.QRCode)
+		require.NoError(t, err)
+		assert.NotEqual(t, merged.ID, ord.TableSessionID, "结账后再次扫码开始新的用餐")
+
+		_, err = paymentService.ConfirmOfflinePayment(&dto.ConfirmPaymentRequest{
+			ShopID: shopID, OrderID: ord.ID, Method: payment.MethodCash,
+		}, flow, AuditActor{Name: "收银员"})
+		require.NoError(t, err)
+
+		session, err := repositories.NewTableSessionRepository(db).FindByIDAndShopID(ord.TableSessionID, stockTestShopID)
+		require.NoError(t, err)
+		assert.Equal(t, table.CloseReasonPaid, session.CloseReason)
+	})
+
+	t.Run("open, transfer and close", func(t *testing.T) {
+		opened, err := service.OpenSession(&dto.OpenTableSessionRequest{ShopID: shopID, TableID: a1.ID, Guests: 3})
+		require.NoError(t, err)
+		_, err = service.OpenSession(&dto.OpenTableSessionRequest{ShopID: shopID, TableID: a1.ID})
+		assert.ErrorIs(t, err, table.ErrSessionOpen)
+
+		moved, err := service.TransferTable(&dto.MoveTableRequest{ShopID: shopID, TableID: a1.ID, TargetTableID: a2.ID})
+		require.NoError(t, err)
+		assert.Equal(t, opened.ID, moved.ID)
+		assert.Equal(t, a2.ID, moved.TableID)
+
+		tables, err := service.GetTables(shopID)
+		require.NoError(t, err)
+		require.Len(t, tables, 2)
+		assert.Nil(t, tables[0].Session)
+		require.NotNil(t, tables[1].Session)
+		assert.Equal(t, 3, tables[1].Session.Guests)
+
+		closed, err := service.CloseSession(opened.ID, shopID, flow)
+		require.NoError(t, err)
+		assert.Equal(t, table.CloseReasonManual, closed.CloseReason)
+		require.NoError(t, service.DeleteTable(a2.ID, shopID))
+	})
+
+	t.Run("inactive table rejects orders", func(t *testing.T) {
+		inactive := false
+		_, err := service.UpdateTable(&dto.UpdateTableRequest{ID: a1.ID, ShopID: shopID, Active: &inactive})
+		require.NoError(t, err)
+		_, err = scanOrder(a1.QRCode)
+		assert.ErrorIs(t, err, table.ErrTableInactive)
+	})
+
+	t.Run("table guest per session", func(t *testing.T) {
+		b1, err := service.CreateTable(&dto.CreateTableRequest{ShopID: shopID, Name: "B1"})
+		require.NoError(t, err)
+		scanned, err := service.ResolveTableCode(b1.QRCode)
+		require.NoError(t, err)
+
+		// 同一用餐的顾客扫码得到同一个用户
+		session, guest, err := service.JoinTable(scanned)
+		require.NoError(t, err)
+		_, sameGuest, err := service.JoinTable(scanned)
+		require.NoError(t, err)
+		assert.Equal(t, guest.ID, sameGuest.ID)
+
+		guestOrder := func(userID shared.ID) (*dto.OrderResponse, error) {
+			return orderService.CreateOrder(&dto.CreateOrderRequest{
+				UserID:    userID,
+				ShopID:    shopID,
+				Items:     []dto.CreateOrderItemRequest{{ProductID: productID, Quantity: 1}},
+				TableCode: b1.QRCode,
+			})
+		}
+		ord, err := guestOrder(shared.ID(guest.ID))
+		require.NoError(t, err)
+		assert.Equal(t, session.ID, ord.TableSessionID)
+		_, err = scanOrder(b1.QRCode)
+		require.NoError(t, err)
+
+		list, err := orderService.GetTableGuestOrders(shared.ID(guest.ID), shopID, session.ID, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, list.Data, 1, "只返回自己下的订单")
+		assert.Equal(t, ord.ID, list.Data[0].ID)
+
+		_, err = service.SettleSession(&dto.SettleTableSessionRequest{
+			ShopID: shopID, SessionID: session.ID, Method: payment.MethodCash,
+		}, flow, AuditActor{Name: "收银员"})
+		require.NoError(t, err)
+
+		// 结账后原用户不能再向该桌台之后的用餐下单，账单只能看到自己的用餐
+		_, err = guestOrder(shared.ID(guest.ID))
+		assert.ErrorIs(t, err, table.ErrGuestExpired)
+
+		next, nextGuest, err := service.JoinTable(scanned)
+		require.NoError(t, err)
+		assert.NotEqual(t, session.ID, next.ID)
+		assert.NotEqual(t, guest.ID, nextGuest.ID, "新的用餐使用新的用户")
+
+		bill, err := service.GetTableBill(scanned, shared.ID(guest.ID), flow)
+		require.NoError(t, err)
+		assert.Equal(t, session.ID, bill.Session.ID)
+
+		// 并台后顾客所属的用餐为并入的目标用餐
+		b2, err := service.CreateTable(&dto.CreateTableRequest{ShopID: shopID, Name: "B2"})
+		require.NoError(t, err)
+		target, err := service.OpenSession(&dto.OpenTableSessionRequest{ShopID: shopID, TableID: b2.ID})
+		require.NoError(t, err)
+		_, err = service.MergeTables(&dto.MoveTableRequest{ShopID: shopID, TableID: b1.ID, TargetTableID: b2.ID})
+		require.NoError(t, err)
+
+		current, err := service.GuestSession(shared.ID(nextGuest.ID), shopID)
+		require.NoError(t, err)
+		require.NotNil(t, current)
+		assert.Equal(t, target.ID, current.ID)
+
+		regular, err := service.GuestSession(shared.ID(9001), shopID)
+		require.NoError(t, err)
+		assert.Nil(t, regular, "普通顾客不是扫码点餐用户")
+	})
+}
@@ -24,10 +24,14 @@ func NewTempTokenService(db *gorm.DB) *TempTokenService {
 
 // CreateShopSystemUser 为店铺创建系统用户
 func (s *TempTokenService) CreateShopSystemUser(shopID shared.ID) (models.User, error) {
+	return findOrCreateSystemUser(s.db, fmt.Sprintf("shop_%s_system", shopID.String()))
+}
+
+// findOrCreateSystemUser 按名称查询系统用户，不存在时创建
+func findOrCreateSystemUser(db *gorm.DB, expectedName string) (models.User, error) {
 	// 检查是否已存在系统用户
-	expectedName := fmt.Sprintf("shop_%s_system", shopID.String())
 	var existingUser models.User
-	if err := s.db.Where("type = ? AND name = ?", "system", expectedName).First(&existingUser).Error; err == nil {
+	if err := db.Where("type = ? AND name = ?", "system", expectedName).First(&existingUser).Error; err == nil {
 		return existingUser, nil
 	}
 
@@ -42,7 +46,7 @@ func (s *TempTokenService) CreateShopSystemUser(shopID shared.ID) (models.User,
 		Password: "", // 系统用户无需密码
 	}
 
-	if err := s.db.Create(&user).Error; err != nil {
+	if err := db.Create(&user).Error; err != nil {
 		return models.User{}, err
 	}
 
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"orderease/models"
+	"orderease/utils"
+	"orderease/utils/log2"
+)
+
+// 令牌主体类型，同一个 JWT 结构同时用于管理员、店主和前端用户，需要由认证入口区分
+const (
+	PrincipalAdmin = "admin"
+	PrincipalShop  = "shop"
+	PrincipalUser  = "user"
+	PrincipalStaff = "staff"
+)
+
+const (
+	// blacklistMissCacheTTL 未吊销结果的缓存时间，多实例部署时吊销最多延迟该时长生效
+	blacklistMissCacheTTL = 30 * time.Second
+	// blacklistCacheSweepSize 缓存条目超过该数量时清理过期条目
+	blacklistCacheSweepSize = 10000
+)
+
+type blacklistCacheEntry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+type revocationCacheEntry struct {
+	revokedBefore time.Time
+	exceptJTI     string
+	expiresAt     time.Time
+}
+
+// TokenBlacklistService 令牌黑名单服务
+// 吊销记录持久化在 blacklisted_tokens 表，认证中间件通过内存缓存查询，避免每个请求都访问数据库
+type TokenBlacklistService struct {
+	db          *gorm.DB
+	mu          sync.RWMutex
+	cache       map[string]blacklistCacheEntry
+	revocations map[string]revocationCacheEntry
+}
+
+// NewTokenBlacklistService 创建令牌黑名单服务实例
+func NewTokenBlacklistService(db *gorm.DB) *TokenBlacklistService {
+	return &TokenBlacklistService{
+		db:          db,
+		cache:       make(map[string]blacklistCacheEntry),
+		revocations: make(map[string]revocationCacheEntry),
+	}
+}
+
+// blacklistKey 优先使用 jti，旧令牌没有 jti 时退化为令牌本身
+func blacklistKey(claims *utils.Claims, token string) string {
+	if claims != nil && claims.ID != "" {
+		return "jti:" + claims.ID
+	}
+	return "token:" + token
+}
+
+// Revoke 吊销令牌，令牌原本的过期时间之后记录会被清理任务删除
+func (s *TokenBlacklistService) Revoke(token string) error {
+	claims, err := utils.ParseToken(token)
+	if err != nil {
+		return errors.New("无效的认证令牌")
+	}
+
+	expiredAt := time.Now()
+	if claims.ExpiresAt != nil {
+		expiredAt = claims.ExpiresAt.Time
+	}
+
+	entry := models.BlacklistedToken{
+		Token:     token,
+		JTI:       claims.ID,
+		ExpiredAt: expiredAt,
+	}
+	if err := s.db.Where("token = ?", token).FirstOrCreate(&entry).Error; err != nil {
+		log2.Errorf("令牌加入黑名单失败: %v", err)
+		return errors.New("令牌吊销失败")
+	}
+
+	s.setCache(blacklistKey(claims, token), blacklistCacheEntry{revoked: true, expiresAt: expiredAt})
+	return nil
+}
+
+// RevokeJTI 按 jti 吊销令牌，用于服务端只掌握令牌标识的场景（如吊销整个会话）
+func (s *TokenBlacklistService) RevokeJTI(jti string, expiredAt time.Time) error {
+	entry := models.BlacklistedToken{
+		// token 列唯一且非空，没有原始令牌时以 jti 占位
+		Token:     "jti:" + jti,
+		JTI:       jti,
+		ExpiredAt: expiredAt,
+	}
+	if err := s.db.Where("jti = ?", jti).FirstOrCreate(&entry).Error; err != nil {
+		log2.Errorf("令牌加入黑名单失败: %v", err)
+		return errors.New("令牌吊销失败")
+	}
+
+	s.setCache("jti:"+jti, blacklistCacheEntry{revoked: true, expiresAt: expiredAt})
+	return nil
+}
+
+func principalKey(principalType string, principalID uint64) string {
+	return fmt.Sprintf("%s:%d", principalType, principalID)
+}
+
+// RevokeOtherSessions 吊销主体在此之前签发的所有令牌，currentJTI 对应的当前会话除外
+func (s *TokenBlacklistService) RevokeOtherSessions(principalType string, principalID uint64, currentJTI string) error {
+	// JWT 的签发时间精确到秒，截断后比较，避免误伤同一秒内重新登录的令牌
+	revokedBefore := time.Now().Truncate(time.Second)
+
+	revocation := models.TokenRevocation{
+		PrincipalType: principalType,
+		PrincipalID:   principalID,
+	}
+	err := s.db.Where(&revocation).
+		Assign(models.TokenRevocation{RevokedBefore: revokedBefore, ExceptJTI: currentJTI}).
+		FirstOrCreate(&revocation).Error
+	if err != nil {
+		log2.Errorf("吊销会话失败, %s: %d, 错误: %v", principalType, principalID, err)
+		return errors.New("吊销会话失败")
+	}
+
+	s.mu.Lock()
+	s.revocations[principalKey(principalType, principalID)] = revocationCacheEntry{
+		revokedBefore: revokedBefore,
+		exceptJTI:     currentJTI,
+		expiresAt:     time.Now().Add(blacklistMissCacheTTL),
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// IsRevoked 检查令牌是否已被吊销（单个令牌登出，或主体的其他会话被批量吊销）
+// 数据库查询失败时沿用已过期的缓存结果；没有缓存时按已吊销处理（fail closed），避免故障期间放行已登出的令牌
+func (s *TokenBlacklistService) IsRevoked(principalType string, principalID uint64, claims *utils.Claims, token string) bool {
+	if s.isTokenRevoked(claims, token) {
+		return true
+	}
+	return s.isPrincipalRevoked(principalType, principalID, claims)
+}
+
+func (s *TokenBlacklistService) isPrincipalRevoked(principalType string, principalID uint64, claims *utils.Claims) bool {
+	if claims == nil || claims.IssuedAt == nil {
+		return false
+	}
+
+	key := principalKey(principalType, principalID)
+	now := time.Now()
+
+	s.mu.RLock()
+	entry, ok := s.revocations[key]
+	s.mu.RUnlock()
+
+	if !ok || !now.Before(entry.expiresAt) {
+		var revocation models.TokenRevocation
+		err := s.db.Where("principal_type = ? AND principal_id = ?", principalType, principalID).
+			Limit(1).Find(&revocation).Error
+		if err != nil {
+			log2.Errorf("查询会话吊销记录失败: %v", err)
+			if !ok {
+				return true
+			}
+			// 沿用上一次的吊销时间，不刷新缓存，下次请求继续查询数据库
+			return isRevokedBy(entry, claims)
+		}
+
+		entry = revocationCacheEntry{
+			revokedBefore: revocation.RevokedBefore,
+			exceptJTI:     revocation.ExceptJTI,
+			expiresAt:     now.Add(blacklistMissCacheTTL),
+		}
+		s.mu.Lock()
+		s.revocations[key] = entry
+		s.mu.Unlock()
+	}
+
+	return isRevokedBy(entry, claims)
+}
+
+// isRevokedBy 令牌签发于批量吊销之前，且不是吊销时保留的当前会话
+func isRevokedBy(entry revocationCacheEntry, claims *utils.Claims) bool {
+	if entry.revokedBefore.IsZero() || (entry.exceptJTI != "" && entry.exceptJTI == claims.ID) {
+		return false
+	}
+	return claims.IssuedAt.Time.Before(entry.revokedBefore)
+}
+
+func (s *TokenBlacklistService) isTokenRevoked(claims *utils.Claims, token string) bool {
+	key := blacklistKey(claims, token)
+	now := time.Now()
+
+	s.mu.RLock()
+	entry, ok := s.cache[key]
+	s.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.revoked
+	}
+
+	query := s.db.Model(&models.BlacklistedToken{})
+	if claims != nil && claims.ID != "" {
+		query = query.Where("jti = ?", claims.ID)
+	} else {
+		query = query.Where("token = ?", token)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		log2.Errorf("查询令牌黑名单失败: %v", err)
+		if ok {
+			return entry.revoked
+		}
+		return true
+	}
+
+	entry = blacklistCacheEntry{revoked: count > 0, expiresAt: now.Add(blacklistMissCacheTTL)}
+	if entry.revoked && claims != nil && claims.ExpiresAt != nil {
+		entry.expiresAt = claims.ExpiresAt.Time
+	}
+	s.setCache(key, entry)
+
+	return entry.revoked
+}
+
+func (s *TokenBlacklistService) setCache(key string, entry blacklistCacheEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.cache) >= blacklistCacheSweepSize {
+		now := time.Now()
+		for k, v := range s.cache {
+			if !now.Before(v.expiresAt) {
+				delete(s.cache, k)
+			}
+		}
+	}
+	s.cache[key] = entry
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"orderease/models"
+	"orderease/utils"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func blacklistClaims(jti string, issuedAt time.Time) *utils.Claims {
+	return &utils.Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(time.Hour)),
+		},
+	}
+}
+
+func TestTokenBlacklistService_DatabaseFailure(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.BlacklistedToken{}, &models.TokenRevocation{}))
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	s := NewTokenBlacklistService(db)
+	issuedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	revoked := blacklistClaims("revoked", issuedAt)
+	active := blacklistClaims("active", issuedAt)
+	other := blacklistClaims("other", issuedAt)
+
+	require.NoError(t, s.RevokeJTI("revoked", revoked.ExpiresAt.Time))
+	require.NoError(t, s.RevokeOtherSessions(PrincipalUser, 2, "active"))
+	assert.True(t, s.IsRevoked(PrincipalUser, 1, revoked, ""))
+	assert.False(t, s.IsRevoked(PrincipalUser, 1, active, ""))
+	assert.False(t, s.IsRevoked(PrincipalUser, 1, other, ""))
+	assert.True(t, s.IsRevoked(PrincipalUser, 2, revoked, ""))
+
+	// 缓存全部过期后数据库不可用
+	s.mu.Lock()
+	for k, v := range s.cache {
+		v.expiresAt = time.Now().Add(-time.Second)
+		s.cache[k] = v
+	}
+	for k, v := range s.revocations {
+		v.expiresAt = time.Now().Add(-time.Second)
+		s.revocations[k] = v
+	}
+	s.mu.Unlock()
+	require.NoError(t, sqlDB.Close())
+
+	t.Run("stale cache keeps revoked state", func(t *testing.T) {
+		assert.True(t, s.IsRevoked(PrincipalUser, 1, revoked, ""), "已吊销的令牌")
+		assert.False(t, s.IsRevoked(PrincipalUser, 2, active, ""), "吊销其他会话时保留的当前会话")
+		assert.True(t, s.IsRevoked(PrincipalUser, 2, other, ""), "被批量吊销的会话")
+	})
+
+	t.Run("uncached token fails closed", func(t *testing.T) {
+		assert.True(t, s.IsRevoked(PrincipalUser, 3, blacklistClaims("unknown", issuedAt), ""))
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"orderease/application/dto"
 	"orderease/domain/user"
 	"orderease/domain/shared"
+	"orderease/utils"
 	"orderease/utils/log2"
 
 	"gorm.io/gorm"
@@ -112,6 +113,31 @@ func (s *UserService) UpdateUser(req *dto.UpdateUserRequest) (*dto.UserResponse,
 	return s.toUserResponse(userEntity), nil
 }
 
+// ChangePassword 前端用户修改密码
+func (s *UserService) ChangePassword(id shared.ID, oldPassword, newPassword string) error {
+	userEntity, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := userEntity.CheckPassword(oldPassword); err != nil {
+		return errors.New("原密码错误")
+	}
+	if err := validateNewPassword(oldPassword, newPassword); err != nil {
+		return err
+	}
+
+	if err := userEntity.UpdatePassword(newPassword); err != nil {
+		return err
+	}
+	if err := s.userRepo.Update(userEntity); err != nil {
+		return errors.New("修改密码失败")
+	}
+
+	log2.Infof("用户修改密码成功, userID: %s", id.String())
+	return nil
+}
+
 func (s *UserService) DeleteUser(id shared.ID) error {
 	if err := s.userRepo.Delete(id); err != nil {
 		return errors.New("删除用户失败")
@@ -143,3 +169,11 @@ func (s *UserService) toUserResponse(userEntity *user.User) *dto.UserResponse {
 		UpdatedAt: userEntity.UpdatedAt,
 	}
 }
+
+// validateNewPassword 校验新密码强度，并要求与原密码不同
+func validateNewPassword(oldPassword, newPassword string) error {
+	if oldPassword == newPassword {
+		return errors.New("新密码不能与原密码相同")
+	}
+	return utils.ValidatePassword(newPassword)
+}
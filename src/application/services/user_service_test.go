@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"orderease/domain/shared"
+	"orderease/domain/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MockUserRepository is a mock for user.UserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Save(u *user.User) error {
+	args := m.Called(u)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FindByID(id shared.ID) (*user.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByName(name string) (*user.User, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindAll(page, pageSize int) ([]user.User, int64, error) {
+	args := m.Called(page, pageSize)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]user.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) Delete(id shared.ID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Update(u *user.User) error {
+	args := m.Called(u)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Exists(id shared.ID) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("OldPass#123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	userID := shared.ID(1001)
+
+	tests := []struct {
+		name        string
+		oldPassword string
+		newPassword string
+		setupMock   func(*MockUserRepository)
+		wantErr     bool
+		errMsg      string
+	}{
+		{
+			name:        "change password successfully",
+			oldPassword: "OldPass#123",
+			newPassword: "NewPass#456",
+			setupMock: func(m *MockUserRepository) {
+				m.On("FindByID", userID).Return(&user.User{ID: userID, Password: string(hashed)}, nil)
+				m.On("Update", mock.MatchedBy(func(u *user.User) bool {
+					return u.Password == "NewPass#456"
+				})).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:        "wrong old password",
+			oldPassword: "WrongPass#123",
+			newPassword: "NewPass#456",
+			setupMock: func(m *MockUserRepository) {
+				m.On("FindByID", userID).Return(&user.User{ID: userID, Password: string(hashed)}, nil)
+			},
+			wantErr: true,
+			errMsg:  "原密码错误",
+		},
+		{
+			name:        "weak new password",
+			oldPassword: "OldPass#123",
+			newPassword: "weak",
+			setupMock: func(m *MockUserRepository) {
+				m.On("FindByID", userID).Return(&user.User{ID: userID, Password: string(hashed)}, nil)
+			},
+			wantErr: true,
+			errMsg:  "密码长度至少为8位",
+		},
+		{
+			name:        "same as old password",
+			oldPassword: "OldPass#123",
+			newPassword: "OldPass#123",
+			setupMock: func(m *MockUserRepository) {
+				m.On("FindByID", userID).Return(&user.User{ID: userID, Password: string(hashed)}, nil)
+			},
+			wantErr: true,
+			errMsg:  "新密码不能与原密码相同",
+		},
+		{
+			name:        "user not found",
+			oldPassword: "OldPass#123",
+			newPassword: "NewPass#456",
+			setupMock: func(m *MockUserRepository) {
+				m.On("FindByID", userID).Return(nil, errors.New("用户不存在"))
+			},
+			wantErr: true,
+			errMsg:  "用户不存在",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := new(MockUserRepository)
+			tt.setupMock(mockUserRepo)
+
+			service := NewUserService(mockUserRepo, nil)
+			err := service.ChangePassword(userID, tt.oldPassword, tt.newPassword)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				mockUserRepo.AssertNotCalled(t, "Update", mock.Anything)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockUserRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -7,9 +7,13 @@ import (
 	"github.com/google/wire"
 	"gorm.io/gorm"
 	"orderease/domain/order"
+	"orderease/domain/payment"
 	"orderease/domain/product"
+	"orderease/domain/promotion"
 	"orderease/domain/shop"
 	"orderease/domain/user"
+	"orderease/infrastructure/events"
+	"orderease/infrastructure/gateways"
 	"orderease/infrastructure/repositories"
 )
 
@@ -21,6 +25,7 @@ func InitializeServiceContainer(db *gorm.DB) (*ServiceContainer, error) {
 		repositories.NewOrderItemRepository,
 		repositories.NewOrderItemOptionRepository,
 		repositories.NewOrderStatusLogRepository,
+		repositories.NewOrderRefundRepository,
 		repositories.NewProductRepository,
 		repositories.NewProductOptionCategoryRepository,
 		repositories.NewProductOptionRepository,
@@ -28,6 +33,21 @@ func InitializeServiceContainer(db *gorm.DB) (*ServiceContainer, error) {
 		repositories.NewShopRepository,
 		repositories.NewTagRepository,
 		repositories.NewUserRepository,
+		repositories.NewStaffRepository,
+		repositories.NewPromotionRepository,
+		repositories.NewPaymentRepository,
+		repositories.NewPaymentRefundRepository,
+		repositories.NewCartRepository,
+		repositories.NewTableRepository,
+		repositories.NewTableSessionRepository,
+
+		// 支付网关
+		gateways.NewGateways,
+
+		// 事件总线
+		events.NewOrderEventBroker,
+		wire.Bind(new(order.EventPublisher), new(*events.OrderEventBroker)),
+		wire.Bind(new(product.StockEventPublisher), new(*events.OrderEventBroker)),
 
 		// Service 层
 		NewOrderService,
@@ -35,6 +55,16 @@ func InitializeServiceContainer(db *gorm.DB) (*ServiceContainer, error) {
 		NewShopService,
 		NewUserService,
 		NewTempTokenService,
+		NewTokenBlacklistService,
+		NewRefreshTokenService,
+		NewStaffService,
+		NewAuditService,
+		NewPromotionService,
+		NewPaymentService,
+		NewRefundService,
+		NewIdempotencyService,
+		NewCartService,
+		NewTableService,
 
 		// Container
 		NewServiceContainer,
@@ -59,6 +89,9 @@ var RepositoryProviderSet = wire.NewSet(
 	wire.Bind(new(order.OrderStatusLogRepository), new(*repositories.OrderStatusLogRepository)),
 	repositories.NewOrderStatusLogRepository,
 
+	wire.Bind(new(order.OrderRefundRepository), new(*repositories.OrderRefundRepositoryImpl)),
+	repositories.NewOrderRefundRepository,
+
 	// Product 仓储
 	wire.Bind(new(product.ProductRepository), new(*repositories.ProductRepository)),
 	repositories.NewProductRepository,
@@ -79,11 +112,32 @@ var RepositoryProviderSet = wire.NewSet(
 	wire.Bind(new(shop.TagRepository), new(*repositories.TagRepository)),
 	repositories.NewTagRepository,
 
+	wire.Bind(new(shop.StaffRepository), new(*repositories.StaffRepositoryImpl)),
+	repositories.NewStaffRepository,
+
+	// Promotion 仓储
+	wire.Bind(new(promotion.PromotionRepository), new(*repositories.PromotionRepositoryImpl)),
+	repositories.NewPromotionRepository,
+
+	// Payment 仓储
+	wire.Bind(new(payment.PaymentRepository), new(*repositories.PaymentRepositoryImpl)),
+	repositories.NewPaymentRepository,
+
+	wire.Bind(new(payment.RefundRepository), new(*repositories.PaymentRefundRepositoryImpl)),
+	repositories.NewPaymentRefundRepository,
+
 	// User 仓储
 	wire.Bind(new(user.UserRepository), new(*repositories.UserRepository)),
 	repositories.NewUserRepository,
 )
 
+// EventProviderSet 事件总线的 Provider Set
+var EventProviderSet = wire.NewSet(
+	events.NewOrderEventBroker,
+	wire.Bind(new(order.EventPublisher), new(*events.OrderEventBroker)),
+	wire.Bind(new(product.StockEventPublisher), new(*events.OrderEventBroker)),
+)
+
 // ServiceProviderSet 所有服务的 Provider Set
 var ServiceProviderSet = wire.NewSet(
 	NewOrderService,
@@ -91,4 +145,11 @@ var ServiceProviderSet = wire.NewSet(
 	NewShopService,
 	NewUserService,
 	NewTempTokenService,
+	NewTokenBlacklistService,
+	NewRefreshTokenService,
+	NewStaffService,
+	NewAuditService,
+	NewPromotionService,
+	NewPaymentService,
+	NewRefundService,
 )
@@ -7,6 +7,8 @@ package services
 
 import (
 	"gorm.io/gorm"
+	"orderease/infrastructure/events"
+	"orderease/infrastructure/gateways"
 	"orderease/infrastructure/repositories"
 )
 
@@ -17,6 +19,7 @@ func InitializeServiceContainer(db *gorm.DB) (*ServiceContainer, error) {
 	orderItemRepository := repositories.NewOrderItemRepository(db)
 	orderItemOptionRepository := repositories.NewOrderItemOptionRepository(db)
 	orderStatusLogRepository := repositories.NewOrderStatusLogRepository(db)
+	orderRefundRepository := repositories.NewOrderRefundRepository(db)
 	productRepository := repositories.NewProductRepository(db)
 	productOptionCategoryRepository := repositories.NewProductOptionCategoryRepository(db)
 	productOptionRepository := repositories.NewProductOptionRepository(db)
@@ -24,13 +27,32 @@ func InitializeServiceContainer(db *gorm.DB) (*ServiceContainer, error) {
 	shopRepository := repositories.NewShopRepository(db)
 	tagRepository := repositories.NewTagRepository(db)
 	userRepository := repositories.NewUserRepository(db)
+	staffRepository := repositories.NewStaffRepository(db)
+	promotionRepository := repositories.NewPromotionRepository(db)
+	paymentRepository := repositories.NewPaymentRepository(db)
+	paymentRefundRepository := repositories.NewPaymentRefundRepository(db)
+	cartRepository := repositories.NewCartRepository(db)
+	tableRepository := repositories.NewTableRepository(db)
+	tableSessionRepository := repositories.NewTableSessionRepository(db)
+	paymentGateways := gateways.NewGateways()
+	orderEventBroker := events.NewOrderEventBroker()
 
-	orderService := NewOrderService(db, productRepository, productOptionRepository, productOptionCategoryRepository, orderRepository, orderItemRepository, orderItemOptionRepository, orderStatusLogRepository)
-	productService := NewProductService(productRepository, productOptionCategoryRepository, productOptionRepository, productTagRepository, db)
+	orderService := NewOrderService(db, productRepository, productOptionRepository, productOptionCategoryRepository, orderRepository, orderItemRepository, orderItemOptionRepository, orderStatusLogRepository, orderEventBroker, orderEventBroker)
+	productService := NewProductService(productRepository, productOptionCategoryRepository, productOptionRepository, productTagRepository, db, orderEventBroker)
 	shopService := NewShopService(shopRepository, tagRepository, productRepository, db)
 	userService := NewUserService(userRepository, db)
 	tempTokenService := NewTempTokenService(db)
+	tokenBlacklistService := NewTokenBlacklistService(db)
+	refreshTokenService := NewRefreshTokenService(db, tokenBlacklistService)
+	staffService := NewStaffService(staffRepository, shopRepository, tokenBlacklistService, refreshTokenService, db)
+	auditService := NewAuditService(db)
+	promotionService := NewPromotionService(promotionRepository, db)
+	paymentService := NewPaymentService(db, paymentRepository, paymentRefundRepository, orderRepository, paymentGateways, orderEventBroker)
+	refundService := NewRefundService(db, orderRepository, orderRefundRepository, paymentService, orderEventBroker, orderEventBroker)
+	idempotencyService := NewIdempotencyService(db)
+	cartService := NewCartService(cartRepository, productRepository, productOptionRepository, productOptionCategoryRepository, orderService)
+	tableService := NewTableService(db, tableRepository, tableSessionRepository, orderRepository, paymentService)
 
-	serviceContainer := NewServiceContainer(orderService, productService, shopService, userService, tempTokenService)
+	serviceContainer := NewServiceContainer(orderService, productService, shopService, userService, tempTokenService, tokenBlacklistService, refreshTokenService, staffService, auditService, promotionService, paymentService, refundService, idempotencyService, cartService, tableService, orderEventBroker)
 	return serviceContainer, nil
 }
@@ -31,9 +31,31 @@ type Config struct {
 	} `yaml:"database"`
 
 	JWT struct {
-		Secret     string `yaml:"secret"`
-		Expiration int    `yaml:"expiration"`
+		Secret            string `yaml:"secret"`
+		Expiration        int    `yaml:"expiration"`
+		RefreshExpiration int    `yaml:"refreshExpiration"`
 	} `yaml:"jwt"`
+
+	Events struct {
+		ReplayBufferSize  int `yaml:"replayBufferSize"`
+		HeartbeatInterval int `yaml:"heartbeatInterval"`
+	} `yaml:"events"`
+
+	Payment struct {
+		// Mock 本地模拟支付网关，用于开发和测试，生产环境应关闭
+		Mock struct {
+			Enabled bool   `yaml:"enabled"`
+			Secret  string `yaml:"-"` // 回调签名密钥，仅从环境变量 PAYMENT_MOCK_SECRET 读取
+		} `yaml:"mock"`
+	} `yaml:"payment"`
+
+	Idempotency struct {
+		Expiration int `yaml:"expiration"` // 幂等键保留时间，单位为秒
+	} `yaml:"idempotency"`
+
+	Table struct {
+		QRSecret string `yaml:"-"` // 桌台二维码签名密钥，仅从环境变量 TABLE_QR_SECRET 读取，为空时使用 JWT 密钥
+	} `yaml:"table"`
 }
 
 var AppConfig Config
@@ -95,6 +117,24 @@ func LoadConfig(configPath string) error {
 		}
 	}
 
+	if refreshExp := os.Getenv("JWT_REFRESH_EXPIRATION"); refreshExp != "" {
+		if exp, err := strconv.Atoi(refreshExp); err == nil {
+			AppConfig.JWT.RefreshExpiration = exp
+		}
+	}
+
+	// 支付配置
+	if mockEnabled := os.Getenv("PAYMENT_MOCK_ENABLED"); mockEnabled != "" {
+		if enabled, err := strconv.ParseBool(mockEnabled); err == nil {
+			AppConfig.Payment.Mock.Enabled = enabled
+		}
+	}
+
+	AppConfig.Payment.Mock.Secret = os.Getenv("PAYMENT_MOCK_SECRET")
+
+	// 桌台配置
+	AppConfig.Table.QRSecret = os.Getenv("TABLE_QR_SECRET")
+
 	return nil
 }
 
@@ -110,3 +150,8 @@ func GetDSN() string {
 		AppConfig.Database.Loc,
 	)
 }
+
+// MockPaymentEnabled 模拟支付网关是否可用，需开启配置并通过环境变量设置签名密钥
+func MockPaymentEnabled() bool {
+	return AppConfig.Payment.Mock.Enabled && AppConfig.Payment.Mock.Secret != ""
+}
@@ -39,6 +39,13 @@ func Init() (*gorm.DB, error) {
 	// 获取数据库连接
 	db := GetDB()
 
+	// 金额列由 double 改为 DECIMAL(10,2)，需在自动迁移前先对历史数据取整
+	if err := migratePriceColumns(db); err != nil {
+		log2.Fatalf("迁移金额列失败: %v", err)
+	}
+	backfillSubtotal := needsOrderSubtotalBackfill(db)
+	backfillFulfillment := needsOrderFulfillmentBackfill(db)
+
 	// 数据库迁移
 	tables := []interface{}{
 		&models.Product{},
@@ -52,10 +59,29 @@ func Init() (*gorm.DB, error) {
 		&models.ProductOptionCategory{},
 		&models.Shop{},
 		&models.TempToken{}, // 添加临时令牌表
+		&models.ShopStaff{}, // 店铺员工子账号
+		&models.Promotion{}, // 店铺优惠券和自动促销
 
-		&models.OrderStatusLog{},   // 不需要迁移数据
-		&models.Admin{},            // 不需要迁移数据
-		&models.BlacklistedToken{}, // 不需要迁移数据
+		&models.OrderStatusLog{},      // 不需要迁移数据
+		&models.Admin{},               // 不需要迁移数据
+		&models.BlacklistedToken{},    // 不需要迁移数据
+		&models.TokenRevocation{},     // 不需要迁移数据
+		&models.RefreshToken{},        // 不需要迁移数据
+		&models.AuditLog{},            // 不需要迁移数据
+		&models.StockMovement{},       // 不需要迁移数据
+		&models.OrderDiscount{},       // 不需要迁移数据
+		&models.PromotionRedemption{}, // 不需要迁移数据
+		&models.Payment{},             // 不需要迁移数据
+		&models.PaymentRefund{},       // 不需要迁移数据
+		&models.OrderRefund{},         // 不需要迁移数据
+		&models.OrderRefundItem{},     // 不需要迁移数据
+		&models.IdempotencyKey{},      // 不需要迁移数据
+		&models.Cart{},                // 不需要迁移数据
+		&models.CartItem{},            // 不需要迁移数据
+		&models.CartItemOption{},      // 不需要迁移数据
+		&models.PickupSequence{},      // 不需要迁移数据
+		&models.DiningTable{},         // 不需要迁移数据
+		&models.TableSession{},        // 不需要迁移数据
 	}
 	// 自动迁移数据库表结构
 	for _, table := range tables {
@@ -67,6 +93,20 @@ func Init() (*gorm.DB, error) {
 
 	log2.Infof("所有数据库表迁移完成")
 
+	if backfillSubtotal {
+		if err := backfillOrderSubtotal(db); err != nil {
+			log2.Fatalf("%v", err)
+		}
+	}
+	if backfillFulfillment {
+		if err := backfillOrderFulfillment(db); err != nil {
+			log2.Fatalf("%v", err)
+		}
+	}
+	if err := backfillReleaseStock(db); err != nil {
+		log2.Fatalf("%v", err)
+	}
+
 	// 初始化管理员账户
 	if err := InitAdminAccount(db); err != nil {
 		return nil, fmt.Errorf("初始化管理员账户失败: %v", err)
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"fmt"
+
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+)
+
+// needsOrderFulfillmentBackfill 订单表已存在但还没有 fulfillment_type 列时，迁移后需要回填历史订单的取餐方式
+func needsOrderFulfillmentBackfill(db *gorm.DB) bool {
+	migrator := db.Migrator()
+	return migrator.HasTable(&models.Order{}) && !migrator.HasColumn(&models.Order{}, "FulfillmentType")
+}
+
+// backfillOrderFulfillment 历史订单没有记录取餐方式：桌台订单为堂食，配送用户的订单为配送，其余为自取
+// 配送订单的收货信息只能取用户当前的资料，作为迁移时的快照保存
+func backfillOrderFulfillment(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		dineIn := tx.Model(&models.Order{}).Where("fulfillment_type = '' AND table_id <> 0").
+			UpdateColumn("fulfillment_type", "dine_in")
+		if dineIn.Error != nil {
+			return fmt.Errorf("回填堂食订单失败: %v", dineIn.Error)
+		}
+
+		deliveryUsers := tx.Model(&models.User{}).Select("id").Where("type = ?", models.UserTypeDelivery)
+		delivery := tx.Model(&models.Order{}).Where("fulfillment_type = '' AND user_id IN (?)", deliveryUsers).
+			UpdateColumns(map[string]interface{}{
+				"fulfillment_type": "delivery",
+				"recipient_name":   gorm.Expr("(SELECT name FROM users WHERE users.id = orders.user_id)"),
+				"recipient_phone":  gorm.Expr("(SELECT phone FROM users WHERE users.id = orders.user_id)"),
+				"delivery_address": gorm.Expr("(SELECT address FROM users WHERE users.id = orders.user_id)"),
+			})
+		if delivery.Error != nil {
+			return fmt.Errorf("回填配送订单失败: %v", delivery.Error)
+		}
+
+		pickup := tx.Model(&models.Order{}).Where("fulfillment_type = ''").
+			UpdateColumn("fulfillment_type", "pickup")
+		if pickup.Error != nil {
+			return fmt.Errorf("回填自取订单失败: %v", pickup.Error)
+		}
+
+		log2.Infof("已回填历史订单的取餐方式: 堂食 %d 个, 配送 %d 个, 自取 %d 个",
+			dineIn.RowsAffected, delivery.RowsAffected, pickup.RowsAffected)
+		return nil
+	})
+}
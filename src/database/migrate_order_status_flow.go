@@ -0,0 +1,73 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+)
+
+// cancelStatusKeywords 取消、拒单类状态的名称关键字，用于识别历史流转配置中归还库存的终态
+var cancelStatusKeywords = []string{"取消", "拒"}
+
+func isCancelStatusName(name string) bool {
+	for _, keyword := range cancelStatusKeywords {
+		if strings.Contains(name, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCancelStatus 状态名称或进入该状态的动作名称为取消、拒单类时视为未成交的终态
+func isCancelStatus(flow models.OrderStatusFlow, status models.OrderStatus) bool {
+	if !status.IsFinal {
+		return false
+	}
+	if isCancelStatusName(status.Label) {
+		return true
+	}
+	for _, s := range flow.Statuses {
+		for _, action := range s.Actions {
+			if action.NextStatus == status.Value && (isCancelStatusName(action.Name) || isCancelStatusName(action.NextStatusLabel)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// backfillReleaseStock 历史店铺的流转配置没有 releaseStock 字段，取消和拒单后不会归还库存，
+// 退款和营业额统计也会把已取消的订单当作成交订单。为取消、拒单类终态补上归还库存的标记
+// 只处理还没有该字段的配置，保存后所有状态都带上该字段，重复执行不会覆盖店主的设置
+func backfillReleaseStock(db *gorm.DB) error {
+	var shops []models.Shop
+	if err := db.Select("id", "order_status_flow").
+		Where("order_status_flow IS NOT NULL AND order_status_flow NOT LIKE ?", "%releaseStock%").
+		Find(&shops).Error; err != nil {
+		return fmt.Errorf("查询待回填的订单流转配置失败: %v", err)
+	}
+
+	for _, shop := range shops {
+		flow := shop.OrderStatusFlow
+		if len(flow.Statuses) == 0 {
+			continue
+		}
+		for i := range flow.Statuses {
+			if isCancelStatus(flow, flow.Statuses[i]) {
+				flow.Statuses[i].ReleaseStock = true
+			}
+		}
+		if err := db.Model(&models.Shop{}).Where("id = ?", shop.ID).
+			UpdateColumn("order_status_flow", flow).Error; err != nil {
+			return fmt.Errorf("回填店铺 %d 的订单流转配置失败: %v", shop.ID, err)
+		}
+	}
+	if len(shops) > 0 {
+		log2.Infof("已回填 %d 个店铺订单流转配置的归还库存标记", len(shops))
+	}
+	return nil
+}
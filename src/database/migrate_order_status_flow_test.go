@@ -0,0 +1,65 @@
+package database
+
+import (
+	"testing"
+
+	"orderease/domain/order"
+	"orderease/infrastructure/persistence"
+	"orderease/models"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// legacyOrderStatusFlow 引入 releaseStock 之前保存的流转配置
+const legacyOrderStatusFlow = `{"statuses":[
+	{"value":0,"label":"待处理","type":"warning","isFinal":false,"actions":[
+		{"name":"接单","nextStatus":1,"nextStatusLabel":"已接单"},
+		{"name":"拒单","nextStatus":2,"nextStatusLabel":"已拒绝"}]},
+	{"value":1,"label":"已接单","type":"primary","isFinal":false,"actions":[
+		{"name":"完成","nextStatus":9,"nextStatusLabel":"已完成"},
+		{"name":"取消","nextStatus":10,"nextStatusLabel":"已作废"}]},
+	{"value":2,"label":"已拒绝","type":"danger","isFinal":true,"actions":[]},
+	{"value":9,"label":"已完成","type":"success","isFinal":true,"actions":[]},
+	{"value":10,"label":"已作废","type":"info","isFinal":true,"actions":[]}]}`
+
+func TestBackfillReleaseStock(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:backfill_release_stock?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Shop{}))
+
+	createShop := func(id uint64, username, flow string) {
+		require.NoError(t, db.Create(&models.Shop{ID: snowflake.ID(id), Name: username, OwnerUsername: username}).Error)
+		require.NoError(t, db.Exec("UPDATE shops SET order_status_flow = ? WHERE id = ?", []byte(flow), id).Error)
+	}
+	loadFlow := func(id uint64) order.OrderStatusFlow {
+		var shop models.Shop
+		require.NoError(t, db.First(&shop, id).Error)
+		return persistence.ShopToDomain(shop).OrderStatusFlow
+	}
+
+	createShop(1, "legacy", legacyOrderStatusFlow)
+	// 店主明确设置为不归还库存的配置保持不变
+	createShop(2, "configured", `{"statuses":[{"value":10,"label":"已取消","type":"info","isFinal":true,"releaseStock":false,"actions":[]}]}`)
+
+	legacy := loadFlow(1)
+	assert.False(t, legacy.ReleasesStock(order.OrderStatus(10)), "回填前历史配置不归还库存")
+
+	require.NoError(t, backfillReleaseStock(db))
+	require.NoError(t, backfillReleaseStock(db), "重复执行不报错")
+
+	legacy = loadFlow(1)
+	assert.True(t, legacy.ReleasesStock(order.OrderStatus(2)), "拒单")
+	assert.True(t, legacy.ReleasesStock(order.OrderStatus(10)), "由取消动作进入的终态")
+	assert.False(t, legacy.ReleasesStock(order.OrderStatus(9)), "已完成不归还库存")
+	assert.False(t, legacy.ReleasesStock(order.OrderStatus(1)), "非终态不归还库存")
+
+	configured := loadFlow(2)
+	assert.False(t, configured.ReleasesStock(order.OrderStatus(10)))
+}
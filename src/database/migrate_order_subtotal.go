@@ -0,0 +1,27 @@
+package database
+
+import (
+	"fmt"
+
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+)
+
+// needsOrderSubtotalBackfill 订单表已存在但还没有 subtotal 列时，迁移后需要回填历史订单的商品小计
+func needsOrderSubtotalBackfill(db *gorm.DB) bool {
+	migrator := db.Migrator()
+	return migrator.HasTable(&models.Order{}) && !migrator.HasColumn(&models.Order{}, "Subtotal")
+}
+
+// backfillOrderSubtotal 历史订单没有服务费、打包费和税费，商品小计 = 应付金额 + 优惠减免
+func backfillOrderSubtotal(db *gorm.DB) error {
+	result := db.Model(&models.Order{}).Where("subtotal = 0").
+		UpdateColumn("subtotal", gorm.Expr("total_price + discount_amount"))
+	if result.Error != nil {
+		return fmt.Errorf("回填订单商品小计失败: %v", result.Error)
+	}
+	log2.Infof("已回填 %d 个历史订单的商品小计", result.RowsAffected)
+	return nil
+}
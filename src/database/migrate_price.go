@@ -0,0 +1,62 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// priceColumns 金额列，早期版本为 double，现统一为 DECIMAL(10,2)
+var priceColumns = []struct {
+	model  interface{}
+	field  string
+	column string
+}{
+	{&models.Product{}, "Price", "price"},
+	{&models.ProductOption{}, "PriceAdjustment", "price_adjustment"},
+	{&models.Order{}, "TotalPrice", "total_price"},
+	{&models.OrderItem{}, "Price", "price"},
+	{&models.OrderItem{}, "TotalPrice", "total_price"},
+	{&models.OrderItemOption{}, "PriceAdjustment", "price_adjustment"},
+}
+
+// migratePriceColumns 把旧的浮点金额列转换为 DECIMAL(10,2)
+// 先按分四舍五入历史数据（如 29.900000000000002），再修改列类型；已经是 decimal 的列跳过
+func migratePriceColumns(db *gorm.DB) error {
+	migrator := db.Migrator()
+	for _, col := range priceColumns {
+		if !migrator.HasTable(col.model) {
+			continue
+		}
+
+		columnTypes, err := migrator.ColumnTypes(col.model)
+		if err != nil {
+			return fmt.Errorf("读取 %T 列信息失败: %v", col.model, err)
+		}
+
+		for _, columnType := range columnTypes {
+			if columnType.Name() != col.column {
+				continue
+			}
+			if strings.EqualFold(columnType.DatabaseTypeName(), "decimal") {
+				break
+			}
+
+			if err := db.Model(col.model).Where("1 = 1").
+				UpdateColumn(col.column, gorm.Expr("ROUND(?, 2)", clause.Column{Name: col.column})).Error; err != nil {
+				return fmt.Errorf("金额列 %T.%s 取整失败: %v", col.model, col.column, err)
+			}
+			if err := migrator.AlterColumn(col.model, col.field); err != nil {
+				return fmt.Errorf("金额列 %T.%s 转换为 DECIMAL 失败: %v", col.model, col.column, err)
+			}
+			log2.Infof("金额列 %T.%s 已由 %s 转换为 DECIMAL(10,2)", col.model, col.column, columnType.DatabaseTypeName())
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package cart
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"orderease/domain/order"
+	"orderease/domain/shared"
+)
+
+// MaxItems 购物车最多保存的商品行数
+const MaxItems = 50
+
+var (
+	ErrEmpty        = errors.New("购物车为空")
+	ErrItemNotFound = errors.New("购物车商品不存在")
+	ErrTooManyItems = fmt.Errorf("购物车最多保存 %d 种商品", MaxItems)
+)
+
+// Cart 顾客在某个店铺的购物车，每个顾客每个店铺一个
+// 购物车只保存商品、数量和所选参数选项，价格、库存和上下架状态在查看和结算时按商品当前信息计算
+type Cart struct {
+	ID        shared.ID
+	UserID    shared.ID
+	ShopID    uint64
+	Items     []CartItem
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type CartItem struct {
+	ID        shared.ID
+	CartID    shared.ID
+	ProductID shared.ID
+	Quantity  int
+	Options   []CartItemOption
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CartItemOption 购物车项选择的商品参数选项
+type CartItemOption struct {
+	CategoryID shared.ID
+	OptionID   shared.ID
+}
+
+func NewCart(userID shared.ID, shopID uint64) *Cart {
+	now := time.Now()
+	return &Cart{
+		UserID:    userID,
+		ShopID:    shopID,
+		Items:     []CartItem{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func (c *Cart) IsEmpty() bool {
+	return len(c.Items) == 0
+}
+
+// AddItem 加入购物车，商品和所选选项都相同的购物车项合并数量
+func (c *Cart) AddItem(productID shared.ID, quantity int, options []CartItemOption) error {
+	if productID.IsZero() {
+		return errors.New("商品ID不能为空")
+	}
+	if quantity <= 0 {
+		return errors.New("商品数量必须大于0")
+	}
+
+	now := time.Now()
+	for i := range c.Items {
+		if c.Items[i].ProductID == productID && sameOptions(c.Items[i].Options, options) {
+			c.Items[i].Quantity += quantity
+			c.Items[i].UpdatedAt = now
+			c.UpdatedAt = now
+			return nil
+		}
+	}
+
+	if len(c.Items) >= MaxItems {
+		return ErrTooManyItems
+	}
+
+	c.Items = append(c.Items, CartItem{
+		CartID:    c.ID,
+		ProductID: productID,
+		Quantity:  quantity,
+		Options:   options,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	c.UpdatedAt = now
+	return nil
+}
+
+// UpdateItem 修改购物车项的数量，options 不为 nil 时同时替换所选选项
+func (c *Cart) UpdateItem(itemID shared.ID, quantity int, options []CartItemOption) error {
+	if quantity <= 0 {
+		return errors.New("商品数量必须大于0")
+	}
+
+	item := c.findItem(itemID)
+	if item == nil {
+		return ErrItemNotFound
+	}
+
+	now := time.Now()
+	item.Quantity = quantity
+	if options != nil {
+		item.Options = options
+	}
+	item.UpdatedAt = now
+	c.UpdatedAt = now
+	return nil
+}
+
+func (c *Cart) RemoveItem(itemID shared.ID) error {
+	for i := range c.Items {
+		if c.Items[i].ID == itemID {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			c.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return ErrItemNotFound
+}
+
+func (c *Cart) Clear() {
+	c.Items = []CartItem{}
+	c.UpdatedAt = time.Now()
+}
+
+// ProductQuantities 按商品汇总数量，同一商品选了不同选项时库存按合计校验
+func (c *Cart) ProductQuantities() map[shared.ID]int {
+	quantities := make(map[shared.ID]int, len(c.Items))
+	for _, item := range c.Items {
+		quantities[item.ProductID] += item.Quantity
+	}
+	return quantities
+}
+
+// ToOrderItems 转换为待下单的订单项，价格和快照由订单创建时重新计算
+func (c *Cart) ToOrderItems() []order.OrderItem {
+	items := make([]order.OrderItem, len(c.Items))
+	for i, item := range c.Items {
+		items[i] = item.toOrderItem()
+	}
+	return items
+}
+
+func (c *Cart) findItem(itemID shared.ID) *CartItem {
+	for i := range c.Items {
+		if c.Items[i].ID == itemID {
+			return &c.Items[i]
+		}
+	}
+	return nil
+}
+
+func (i CartItem) toOrderItem() order.OrderItem {
+	options := make([]order.OrderItemOption, len(i.Options))
+	for j, opt := range i.Options {
+		options[j] = order.OrderItemOption{CategoryID: opt.CategoryID, OptionID: opt.OptionID}
+	}
+	return order.OrderItem{
+		ProductID: i.ProductID,
+		Quantity:  i.Quantity,
+		Options:   options,
+	}
+}
+
+// sameOptions 比较两组选项是否相同，不区分选择顺序
+func sameOptions(a, b []CartItemOption) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	selected := make(map[shared.ID]int, len(a))
+	for _, opt := range a {
+		selected[opt.OptionID]++
+	}
+	for _, opt := range b {
+		if selected[opt.OptionID] == 0 {
+			return false
+		}
+		selected[opt.OptionID]--
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package cart
+
+import (
+	"errors"
+	"testing"
+
+	"orderease/domain/mocks"
+	"orderease/domain/product"
+	"orderease/domain/shared"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCart_AddItem(t *testing.T) {
+	c := NewCart(shared.ID(9001), 1001)
+	sweet := []CartItemOption{{CategoryID: 11, OptionID: 101}, {CategoryID: 12, OptionID: 201}}
+
+	require.NoError(t, c.AddItem(shared.ID(1), 1, sweet))
+	// 选项顺序不同也视为同一购物车项
+	require.NoError(t, c.AddItem(shared.ID(1), 2, []CartItemOption{sweet[1], sweet[0]}))
+	require.NoError(t, c.AddItem(shared.ID(1), 1, nil))
+	require.Len(t, c.Items, 2)
+	assert.Equal(t, 3, c.Items[0].Quantity)
+	assert.Equal(t, map[shared.ID]int{1: 4}, c.ProductQuantities())
+
+	assert.EqualError(t, c.AddItem(shared.ID(2), 0, nil), "商品数量必须大于0")
+	assert.EqualError(t, c.AddItem(shared.ID(0), 1, nil), "商品ID不能为空")
+
+	for len(c.Items) < MaxItems {
+		require.NoError(t, c.AddItem(shared.ID(len(c.Items)+100), 1, nil))
+	}
+	assert.ErrorIs(t, c.AddItem(shared.ID(999), 1, nil), ErrTooManyItems)
+}
+
+func TestCart_UpdateAndRemoveItem(t *testing.T) {
+	c := NewCart(shared.ID(9001), 1001)
+	require.NoError(t, c.AddItem(shared.ID(1), 1, []CartItemOption{{CategoryID: 11, OptionID: 101}}))
+	c.Items[0].ID = shared.ID(50)
+
+	require.NoError(t, c.UpdateItem(shared.ID(50), 5, nil))
+	assert.Equal(t, 5, c.Items[0].Quantity)
+	assert.Len(t, c.Items[0].Options, 1, "未传选项时保持原选项")
+
+	require.NoError(t, c.UpdateItem(shared.ID(50), 2, []CartItemOption{}))
+	assert.Empty(t, c.Items[0].Options)
+
+	assert.EqualError(t, c.UpdateItem(shared.ID(50), 0, nil), "商品数量必须大于0")
+	assert.ErrorIs(t, c.UpdateItem(shared.ID(51), 1, nil), ErrItemNotFound)
+	assert.ErrorIs(t, c.RemoveItem(shared.ID(51)), ErrItemNotFound)
+
+	require.NoError(t, c.RemoveItem(shared.ID(50)))
+	assert.True(t, c.IsEmpty())
+}
+
+func TestCart_Quote(t *testing.T) {
+	finder := new(mocks.MockProductFinder)
+	finder.On("FindProduct", shared.ID(1)).Return(&product.Product{
+		ID: 1, ShopID: 1001, Name: "奶茶", Price: shared.NewPrice(12), Stock: 5, Status: product.ProductStatusOnline,
+		OptionCategories: []product.ProductOptionCategory{
+			{ID: 11, ProductID: 1, Name: "杯型", Options: []product.ProductOption{{ID: 101, CategoryID: 11, Name: "大杯"}}},
+		},
+	}, nil)
+	finder.On("FindProduct", shared.ID(2)).Return(&product.Product{ID: 2, ShopID: 1001, Name: "蛋糕", Price: shared.NewPrice(20), Stock: 9, Status: product.ProductStatusOffline}, nil)
+	finder.On("FindProduct", shared.ID(3)).Return(nil, errors.New("商品不存在"))
+	finder.On("FindOption", shared.ID(101)).Return(&product.ProductOption{ID: 101, CategoryID: 11, Name: "大杯", PriceAdjustment: shared.NewPrice(3)}, nil)
+	finder.On("FindOptionCategory", shared.ID(11)).Return(&product.ProductOptionCategory{ID: 11, ProductID: 1, Name: "杯型"}, nil)
+
+	c := NewCart(shared.ID(9001), 1001)
+	require.NoError(t, c.AddItem(shared.ID(1), 2, []CartItemOption{{CategoryID: 11, OptionID: 101}}))
+
+	quote := c.Quote(finder)
+	assert.True(t, quote.Ready())
+	assert.Equal(t, shared.NewPrice(30), quote.Subtotal)
+	assert.Equal(t, "奶茶", quote.Lines[0].Priced.ProductName)
+	assert.Equal(t, "大杯", quote.Lines[0].Priced.Options[0].OptionName)
+
+	// 同一商品不同选项的数量合计超出库存
+	require.NoError(t, c.AddItem(shared.ID(1), 4, nil))
+	quote = c.Quote(finder)
+	assert.False(t, quote.Ready())
+	assert.Equal(t, "商品 奶茶 库存不足", quote.ProductProblem(shared.ID(1)))
+	assert.True(t, quote.Subtotal.IsZero())
+
+	c.Clear()
+	require.NoError(t, c.AddItem(shared.ID(1), 1, nil))
+	require.NoError(t, c.AddItem(shared.ID(2), 1, nil))
+	require.NoError(t, c.AddItem(shared.ID(3), 1, nil))
+	quote = c.Quote(finder)
+	assert.Equal(t, "", quote.Lines[0].Problem)
+	assert.Equal(t, "商品 蛋糕 已下架", quote.Lines[1].Problem)
+	assert.Equal(t, "商品不存在", quote.Lines[2].Problem)
+	assert.Equal(t, "商品 蛋糕 已下架", quote.Problem())
+	assert.Equal(t, shared.NewPrice(12), quote.Subtotal, "小计只包含可以下单的商品")
+
+	assert.False(t, NewCart(shared.ID(9001), 1001).Quote(finder).Ready(), "空购物车不能结算")
+}
@@ -0,0 +1,89 @@
+package cart
+
+import (
+	"fmt"
+
+	"orderease/domain/order"
+	"orderease/domain/shared"
+)
+
+// Line 购物车项按商品当前价格、库存和状态计算的结果
+type Line struct {
+	Item CartItem
+	// Priced 带商品和选项快照的订单项，价格为当前价格
+	Priced order.OrderItem
+	Stock  int
+	// Problem 不能下单的原因，为空表示可以下单
+	Problem string
+}
+
+// Quote 购物车计价结果，Subtotal 只汇总可以下单的商品
+type Quote struct {
+	Lines    []Line
+	Subtotal shared.Price
+}
+
+// Ready 购物车不为空且所有商品都可以下单
+func (q *Quote) Ready() bool {
+	return len(q.Lines) > 0 && q.Problem() == ""
+}
+
+// Problem 第一个不能下单的原因
+func (q *Quote) Problem() string {
+	for _, line := range q.Lines {
+		if line.Problem != "" {
+			return line.Problem
+		}
+	}
+	return ""
+}
+
+// ProductProblem 指定商品所在购物车项中第一个不能下单的原因，用于加购和修改时的校验
+func (q *Quote) ProductProblem(productID shared.ID) string {
+	for _, line := range q.Lines {
+		if line.Item.ProductID == productID && line.Problem != "" {
+			return line.Problem
+		}
+	}
+	return ""
+}
+
+// Quote 按商品当前信息为购物车计价，并校验商品状态、库存和所选选项
+// 单个商品的问题记录在对应的行上，不影响其他商品的计价
+func (c *Cart) Quote(finder order.ProductFinder) *Quote {
+	quote := &Quote{Lines: make([]Line, len(c.Items))}
+	quantities := c.ProductQuantities()
+
+	for i, item := range c.Items {
+		line := Line{Item: item, Priced: item.toOrderItem()}
+
+		prod, err := finder.FindProduct(item.ProductID)
+		if err != nil {
+			line.Problem = "商品不存在"
+			quote.Lines[i] = line
+			continue
+		}
+		line.Priced.SetProductSnapshot(prod)
+		line.Stock = prod.Stock
+
+		if err := line.Priced.ApplyOptionRules(prod); err != nil {
+			line.Problem = err.Error()
+		} else if _, err := line.Priced.CalculatePrice(finder); err != nil {
+			line.Problem = err.Error()
+		}
+
+		switch {
+		case !prod.IsAvailable():
+			line.Problem = fmt.Sprintf("商品 %s 已下架", prod.Name)
+		case !prod.HasStock(quantities[prod.ID]):
+			line.Problem = fmt.Sprintf("商品 %s 库存不足", prod.Name)
+		}
+
+		if line.Problem == "" {
+			quote.Subtotal = quote.Subtotal.Add(line.Priced.TotalPrice)
+		}
+		quote.Lines[i] = line
+	}
+
+	return quote
+}
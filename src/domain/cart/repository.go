@@ -0,0 +1,12 @@
+package cart
+
+import (
+	"orderease/domain/shared"
+)
+
+type CartRepository interface {
+	// FindByUserAndShop 查询顾客在店铺的购物车，没有购物车时返回 nil
+	FindByUserAndShop(userID shared.ID, shopID uint64) (*Cart, error)
+	// Save 保存购物车及全部购物车项，不在 Items 中的购物车项会被删除
+	Save(cart *Cart) error
+}
@@ -0,0 +1,67 @@
+package order
+
+import (
+	"errors"
+
+	"orderease/domain/shared"
+)
+
+// ChargeSettings 店铺的税费和附加费设置，下单和修改订单时按此计算费用明细
+type ChargeSettings struct {
+	TaxRate           shared.Rate  // 税率，0 表示不计税
+	TaxInclusive      bool         // 商品价格已含税：税额从合计中拆分，不额外加收
+	ServiceChargeRate shared.Rate  // 服务费比例，按优惠后的商品金额计算
+	PackagingFee      shared.Price // 外带订单每件商品的打包费
+}
+
+func (c ChargeSettings) Validate() error {
+	if !c.TaxRate.IsValid() {
+		return errors.New("税率必须在0到100之间")
+	}
+	if !c.ServiceChargeRate.IsValid() {
+		return errors.New("服务费比例必须在0到100之间")
+	}
+	if c.PackagingFee.IsNegative() {
+		return errors.New("打包费不能为负数")
+	}
+	return nil
+}
+
+// ApplyCharges 在优惠之后计算服务费、打包费和税额，并重新计算应付金额
+// 服务费按优惠后的商品金额计算；打包费只对外带订单按件收取；配送费由 ApplyDeliveryFee 预先计算；
+// 税额按商品、服务费、打包费和配送费的合计计算，价外税加在应付金额上，价内税只拆分展示
+func (o *Order) ApplyCharges(settings ChargeSettings) {
+	o.Subtotal = o.ItemsTotal()
+	net := o.Subtotal.Sub(o.DiscountAmount)
+
+	o.ServiceCharge = net.ApplyRate(settings.ServiceChargeRate)
+
+	o.PackagingFee = 0
+	if o.Takeaway {
+		quantity := 0
+		for _, item := range o.Items {
+			quantity += item.Quantity
+		}
+		o.PackagingFee = settings.PackagingFee.Multiply(quantity)
+	}
+
+	o.TaxRate = settings.TaxRate
+	o.TaxInclusive = settings.TaxInclusive
+	taxable := net.Add(o.ServiceCharge).Add(o.PackagingFee).Add(o.DeliveryFee)
+	if settings.TaxInclusive {
+		o.TaxAmount = taxable.IncludedRate(settings.TaxRate)
+	} else {
+		o.TaxAmount = taxable.ApplyRate(settings.TaxRate)
+	}
+
+	o.TotalPrice = o.grandTotal()
+}
+
+// grandTotal 应付金额：小计 - 优惠 + 服务费 + 打包费 + 配送费 + 价外税
+func (o *Order) grandTotal() shared.Price {
+	total := o.Subtotal.Sub(o.DiscountAmount).Add(o.ServiceCharge).Add(o.PackagingFee).Add(o.DeliveryFee)
+	if !o.TaxInclusive {
+		total = total.Add(o.TaxAmount)
+	}
+	return total
+}
@@ -0,0 +1,56 @@
+package order
+
+import (
+	"time"
+
+	"orderease/domain/product"
+	"orderease/domain/shared"
+)
+
+// OrderEventType 订单事件类型
+type OrderEventType string
+
+const (
+	OrderEventCreated       OrderEventType = "order_created"
+	OrderEventStatusChanged OrderEventType = "order_status_changed"
+	OrderEventUpdated       OrderEventType = "order_updated"
+	OrderEventDeleted       OrderEventType = "order_deleted"
+	OrderEventPaid          OrderEventType = "order_paid"
+	OrderEventRefunded      OrderEventType = "order_refunded"
+)
+
+// OrderEvent 订单领域事件
+// ID 由事件总线在发布时分配，按店铺单调递增，用于断线重连时的 Last-Event-ID 补发
+type OrderEvent struct {
+	ID         uint64
+	Type       OrderEventType
+	ShopID     uint64
+	OrderID    shared.ID
+	UserID     shared.ID
+	OldStatus  OrderStatus
+	NewStatus  OrderStatus
+	TotalPrice shared.Price
+	OccurredAt time.Time
+	// Stock 商品库存事件（低库存、售罄、补货）复用店铺事件流推送，订单事件为 nil
+	Stock *product.StockEvent
+}
+
+// EventPublisher 订单事件发布者接口（依赖反转）
+// 领域层只定义契约，具体的推送方式（SSE、WebSocket、消息队列）由基础设施层实现
+type EventPublisher interface {
+	Publish(event OrderEvent)
+}
+
+// NewOrderEvent 根据订单当前状态构建事件
+func NewOrderEvent(eventType OrderEventType, ord *Order, oldStatus OrderStatus) OrderEvent {
+	return OrderEvent{
+		Type:       eventType,
+		ShopID:     ord.ShopID,
+		OrderID:    ord.ID,
+		UserID:     ord.UserID,
+		OldStatus:  oldStatus,
+		NewStatus:  ord.Status,
+		TotalPrice: ord.TotalPrice,
+		OccurredAt: time.Now(),
+	}
+}
@@ -0,0 +1,134 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"orderease/domain/shared"
+)
+
+// FulfillmentType 订单的取餐方式
+type FulfillmentType string
+
+const (
+	FulfillmentDineIn   FulfillmentType = "dine_in"  // 堂食
+	FulfillmentPickup   FulfillmentType = "pickup"   // 到店自取
+	FulfillmentDelivery FulfillmentType = "delivery" // 配送
+)
+
+func (t FulfillmentType) IsValid() bool {
+	return t == FulfillmentDineIn || t == FulfillmentPickup || t == FulfillmentDelivery
+}
+
+func (t FulfillmentType) String() string {
+	switch t {
+	case FulfillmentDineIn:
+		return "堂食"
+	case FulfillmentPickup:
+		return "自取"
+	case FulfillmentDelivery:
+		return "配送"
+	default:
+		return "未知方式"
+	}
+}
+
+var ErrInvalidFulfillmentType = errors.New("无效的取餐方式")
+
+// FulfillmentSettings 店铺支持的取餐方式和配送费用设置
+type FulfillmentSettings struct {
+	Types              []FulfillmentType // 支持的取餐方式，为空表示全部支持
+	DeliveryMinAmount  shared.Price      // 起送金额，按商品小计计算，0 表示不限
+	DeliveryFee        shared.Price      // 配送费
+	FreeDeliveryAmount shared.Price      // 商品小计满该金额免配送费，0 表示不免
+}
+
+func (s FulfillmentSettings) Validate() error {
+	seen := make(map[FulfillmentType]bool, len(s.Types))
+	for _, t := range s.Types {
+		if !t.IsValid() {
+			return fmt.Errorf("%w: %s", ErrInvalidFulfillmentType, string(t))
+		}
+		if seen[t] {
+			return fmt.Errorf("取餐方式重复: %s", t)
+		}
+		seen[t] = true
+	}
+	if s.DeliveryMinAmount.IsNegative() {
+		return errors.New("起送金额不能为负数")
+	}
+	if s.DeliveryFee.IsNegative() {
+		return errors.New("配送费不能为负数")
+	}
+	if s.FreeDeliveryAmount.IsNegative() {
+		return errors.New("免配送费金额不能为负数")
+	}
+	return nil
+}
+
+// Allows 店铺是否支持该取餐方式
+func (s FulfillmentSettings) Allows(t FulfillmentType) bool {
+	if len(s.Types) == 0 {
+		return true
+	}
+	for _, allowed := range s.Types {
+		if allowed == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Check 下单时校验取餐方式是否可用，配送订单的商品小计需达到起送金额
+func (s FulfillmentSettings) Check(o *Order) error {
+	if !s.Allows(o.FulfillmentType) {
+		return fmt.Errorf("店铺暂不支持%s", o.FulfillmentType)
+	}
+	if o.FulfillmentType == FulfillmentDelivery && o.ItemsTotal() < s.DeliveryMinAmount {
+		return fmt.Errorf("配送订单需满 %s 元起送", s.DeliveryMinAmount)
+	}
+	return nil
+}
+
+// SetFulfillment 设置取餐方式并保存下单时的收货信息快照，之后修改用户资料不影响已有订单
+// 配送订单必须填写收货人、联系电话和配送地址，并按外带打包；其他方式不保存配送地址
+func (o *Order) SetFulfillment(t FulfillmentType, name, phone, address string) error {
+	if !t.IsValid() {
+		return ErrInvalidFulfillmentType
+	}
+
+	name, phone, address = strings.TrimSpace(name), strings.TrimSpace(phone), strings.TrimSpace(address)
+	if t == FulfillmentDelivery {
+		switch {
+		case name == "":
+			return errors.New("配送订单需要填写收货人")
+		case phone == "":
+			return errors.New("配送订单需要填写联系电话")
+		case address == "":
+			return errors.New("配送订单需要填写配送地址")
+		}
+		o.Takeaway = true
+	} else {
+		address = ""
+	}
+
+	o.FulfillmentType = t
+	o.RecipientName = name
+	o.RecipientPhone = phone
+	o.DeliveryAddress = address
+	return nil
+}
+
+// ApplyDeliveryFee 计算配送订单的配送费，需在 ApplyCharges 之前调用
+// 商品小计达到免配送费金额时不收取
+func (o *Order) ApplyDeliveryFee(settings FulfillmentSettings) {
+	o.DeliveryFee = 0
+	if o.FulfillmentType != FulfillmentDelivery {
+		return
+	}
+	if settings.FreeDeliveryAmount.IsPositive() && o.ItemsTotal() >= settings.FreeDeliveryAmount {
+		return
+	}
+	o.DeliveryFee = settings.DeliveryFee
+}
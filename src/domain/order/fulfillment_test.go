@@ -0,0 +1,121 @@
+package order
+
+import (
+	"testing"
+
+	"orderease/domain/shared"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrder_SetFulfillment(t *testing.T) {
+	t.Run("delivery requires recipient", func(t *testing.T) {
+		ord, err := NewOrder(shared.ID(123), 456, validOrderItems(), "")
+		require.NoError(t, err)
+
+		assert.EqualError(t, ord.SetFulfillment(FulfillmentDelivery, "", "13800000000", "中山路1号"), "配送订单需要填写收货人")
+		assert.EqualError(t, ord.SetFulfillment(FulfillmentDelivery, "张三", " ", "中山路1号"), "配送订单需要填写联系电话")
+		assert.EqualError(t, ord.SetFulfillment(FulfillmentDelivery, "张三", "13800000000", ""), "配送订单需要填写配送地址")
+		assert.ErrorIs(t, ord.SetFulfillment("express", "", "", ""), ErrInvalidFulfillmentType)
+
+		require.NoError(t, ord.SetFulfillment(FulfillmentDelivery, " 张三 ", "13800000000", "中山路1号"))
+		assert.Equal(t, FulfillmentDelivery, ord.FulfillmentType)
+		assert.Equal(t, "张三", ord.RecipientName)
+		assert.Equal(t, "中山路1号", ord.DeliveryAddress)
+		assert.True(t, ord.Takeaway, "配送订单按外带打包")
+	})
+
+	t.Run("pickup keeps contact but not address", func(t *testing.T) {
+		ord, err := NewOrder(shared.ID(123), 456, validOrderItems(), "")
+		require.NoError(t, err)
+
+		require.NoError(t, ord.SetFulfillment(FulfillmentPickup, "张三", "13800000000", "中山路1号"))
+		assert.Equal(t, "13800000000", ord.RecipientPhone)
+		assert.Empty(t, ord.DeliveryAddress)
+		assert.False(t, ord.Takeaway)
+	})
+}
+
+func TestFulfillmentSettings_Check(t *testing.T) {
+	settings := FulfillmentSettings{
+		Types:             []FulfillmentType{FulfillmentPickup, FulfillmentDelivery},
+		DeliveryMinAmount: shared.NewPrice(250),
+	}
+
+	ord, err := NewOrder(shared.ID(123), 456, validOrderItems(), "")
+	require.NoError(t, err)
+
+	ord.FulfillmentType = FulfillmentDineIn
+	assert.EqualError(t, settings.Check(ord), "店铺暂不支持堂食")
+
+	ord.FulfillmentType = FulfillmentDelivery
+	assert.EqualError(t, settings.Check(ord), "配送订单需满 250.00 元起送")
+
+	settings.DeliveryMinAmount = shared.NewPrice(200)
+	assert.NoError(t, settings.Check(ord))
+
+	assert.NoError(t, FulfillmentSettings{}.Check(ord), "未配置时支持全部取餐方式")
+}
+
+func TestFulfillmentSettings_Validate(t *testing.T) {
+	assert.ErrorIs(t, FulfillmentSettings{Types: []FulfillmentType{"express"}}.Validate(), ErrInvalidFulfillmentType)
+	assert.EqualError(t, FulfillmentSettings{Types: []FulfillmentType{FulfillmentPickup, FulfillmentPickup}}.Validate(), "取餐方式重复: 自取")
+	assert.EqualError(t, FulfillmentSettings{DeliveryFee: shared.NewPrice(-1)}.Validate(), "配送费不能为负数")
+}
+
+func TestOrder_ApplyDeliveryFee(t *testing.T) {
+	tests := []struct {
+		name        string
+		fulfillment FulfillmentType
+		settings    FulfillmentSettings
+		wantFee     shared.Price
+		wantTotal   shared.Price
+	}{
+		{
+			name:        "delivery fee",
+			fulfillment: FulfillmentDelivery,
+			settings:    FulfillmentSettings{DeliveryFee: shared.NewPrice(5)},
+			wantFee:     shared.NewPrice(5),
+			wantTotal:   shared.NewPrice(205),
+		},
+		{
+			name:        "free delivery over amount",
+			fulfillment: FulfillmentDelivery,
+			settings:    FulfillmentSettings{DeliveryFee: shared.NewPrice(5), FreeDeliveryAmount: shared.NewPrice(200)},
+			wantTotal:   shared.NewPrice(200),
+		},
+		{
+			name:        "no delivery fee for pickup",
+			fulfillment: FulfillmentPickup,
+			settings:    FulfillmentSettings{DeliveryFee: shared.NewPrice(5)},
+			wantTotal:   shared.NewPrice(200),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ord, err := NewOrder(shared.ID(123), 456, validOrderItems(), "")
+			require.NoError(t, err)
+			ord.FulfillmentType = tt.fulfillment
+
+			ord.ApplyDeliveryFee(tt.settings)
+			ord.ApplyCharges(ChargeSettings{})
+
+			assert.Equal(t, tt.wantFee, ord.DeliveryFee)
+			assert.Equal(t, tt.wantTotal, ord.TotalPrice)
+		})
+	}
+
+	t.Run("delivery fee is taxed", func(t *testing.T) {
+		ord, err := NewOrder(shared.ID(123), 456, validOrderItems(), "")
+		require.NoError(t, err)
+		ord.FulfillmentType = FulfillmentDelivery
+
+		ord.ApplyDeliveryFee(FulfillmentSettings{DeliveryFee: shared.NewPrice(10)})
+		ord.ApplyCharges(ChargeSettings{TaxRate: shared.NewRate(10)})
+
+		assert.Equal(t, shared.NewPrice(21), ord.TaxAmount) // (200 + 10) * 10%
+		assert.Equal(t, shared.NewPrice(231), ord.TotalPrice)
+	})
+}
@@ -3,6 +3,8 @@ package order
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"orderease/domain/product"
@@ -47,18 +49,23 @@ type OrderStatusTransition struct {
 	Name            string
 	NextStatus      OrderStatus
 	NextStatusLabel string
+	RequireReason   bool // 执行该动作时必须填写原因（如取消、拒单）
 }
 
 type OrderStatusConfig struct {
-	Value   OrderStatus
-	Label   string
-	Type    string
-	IsFinal bool
-	Actions []OrderStatusTransition
+	Value          OrderStatus
+	Label          string
+	Type           string
+	IsFinal        bool
+	ReleaseStock   bool // 进入该状态时归还订单占用的库存（取消、拒单等未成交的终态）
+	ReadyForPickup bool // 订单已可取餐，该状态的订单在取餐叫号屏上显示
+	Actions        []OrderStatusTransition
 }
 
 type OrderStatusFlow struct {
 	Statuses []OrderStatusConfig
+	// RequirePayment 订单必须先完成支付，才能从待处理进入下一个状态
+	RequirePayment bool
 }
 
 func (flow *OrderStatusFlow) CanTransition(from, to OrderStatus) bool {
@@ -77,6 +84,62 @@ func (flow *OrderStatusFlow) CanTransition(from, to OrderStatus) bool {
 	return false
 }
 
+// FindTransition 查找从 from 到 to 的流转动作
+func (flow *OrderStatusFlow) FindTransition(from, to OrderStatus) (OrderStatusTransition, bool) {
+	for _, status := range flow.Statuses {
+		if status.Value != from {
+			continue
+		}
+		for _, action := range status.Actions {
+			if action.NextStatus == to {
+				return action, true
+			}
+		}
+	}
+	return OrderStatusTransition{}, false
+}
+
+// IsFinalStatus 按店铺流转配置判断是否为终态，配置中不存在的状态按系统默认判断
+func (flow *OrderStatusFlow) IsFinalStatus(status OrderStatus) bool {
+	for _, s := range flow.Statuses {
+		if s.Value == status {
+			return s.IsFinal
+		}
+	}
+	return status.IsFinal()
+}
+
+// ReleasesStock 订单进入该状态时是否归还库存
+func (flow *OrderStatusFlow) ReleasesStock(status OrderStatus) bool {
+	for _, s := range flow.Statuses {
+		if s.Value == status {
+			return s.IsFinal && s.ReleaseStock
+		}
+	}
+	return false
+}
+
+// StatusLabel 返回店铺流转配置中的状态名称，未配置时使用系统默认名称
+func (flow *OrderStatusFlow) StatusLabel(status OrderStatus) string {
+	for _, s := range flow.Statuses {
+		if s.Value == status && s.Label != "" {
+			return s.Label
+		}
+	}
+	return status.String()
+}
+
+// ReadyForPickupStatuses 可以取餐的状态，用于取餐叫号屏
+func (flow *OrderStatusFlow) ReadyForPickupStatuses() []OrderStatus {
+	var statuses []OrderStatus
+	for _, status := range flow.Statuses {
+		if status.ReadyForPickup {
+			statuses = append(statuses, status.Value)
+		}
+	}
+	return statuses
+}
+
 func (flow *OrderStatusFlow) GetUnfinishedStatuses() []OrderStatus {
 	var statuses []OrderStatus
 	for _, status := range flow.Statuses {
@@ -88,15 +151,38 @@ func (flow *OrderStatusFlow) GetUnfinishedStatuses() []OrderStatus {
 }
 
 type Order struct {
-	ID         shared.ID
-	UserID     shared.ID
-	ShopID     uint64
-	TotalPrice shared.Price
-	Status     OrderStatus
-	Remark     string
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-	Items      []OrderItem
+	ID             shared.ID
+	UserID         shared.ID
+	ShopID         uint64
+	Subtotal       shared.Price // 商品小计（含选项加价）
+	DiscountAmount shared.Price // 优惠减免合计
+	ServiceCharge  shared.Price // 服务费
+	PackagingFee   shared.Price // 打包费
+	DeliveryFee    shared.Price // 配送费
+	TaxAmount      shared.Price // 税额，价内税时已包含在应付金额中
+	TaxRate        shared.Rate  // 下单时的税率
+	TaxInclusive   bool         // 商品价格是否已含税
+	Takeaway       bool         // 外带订单，按件收取打包费
+	// FulfillmentType 取餐方式；收货人信息为下单时的快照，修改用户资料不影响已有订单
+	FulfillmentType FulfillmentType
+	RecipientName   string
+	RecipientPhone  string
+	DeliveryAddress string       // 配送地址，仅配送订单
+	ScheduledTime   *time.Time   // 预约取餐/送达时间，为空表示尽快制作
+	PickupNumber    string       // 取餐号，如 A001，每个营业日重新编号
+	BusinessDate    string       // 取餐号所属的营业日 YYYY-MM-DD
+	TableID         shared.ID    // 堂食桌台，非堂食订单为空
+	TableSessionID  shared.ID    // 所属的桌台用餐，同一用餐的订单合并结账
+	TotalPrice      shared.Price // 应付金额：小计 - 优惠 + 服务费 + 打包费 + 配送费 + 价外税
+	PaymentStatus   PaymentStatus
+	PaidAmount      shared.Price // 已支付金额
+	RefundedAmount  shared.Price // 已退款金额
+	Status          OrderStatus
+	Remark          string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Items           []OrderItem
+	Discounts       []OrderDiscount
 }
 
 type OrderItem struct {
@@ -119,16 +205,37 @@ type OrderItemOption struct {
 	OptionID        shared.ID
 	OptionName      string
 	CategoryName    string
-	PriceAdjustment float64
+	PriceAdjustment shared.Price
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 }
 
+// OrderDiscount 订单优惠快照，记录下单时命中的优惠及减免金额
+type OrderDiscount struct {
+	ID          shared.ID
+	OrderID     shared.ID
+	PromotionID shared.ID
+	Name        string
+	Code        string // 券码，自动促销为空
+	Type        string
+	Amount      shared.Price
+	CreatedAt   time.Time
+}
+
+// StatusActor 订单状态变更的操作人
+type StatusActor struct {
+	Type string // admin/shop/staff/user
+	ID   uint64
+	Name string
+}
+
 type OrderStatusLog struct {
 	ID          shared.ID
 	OrderID     shared.ID
 	OldStatus   OrderStatus
 	NewStatus   OrderStatus
+	Actor       StatusActor
+	Reason      string
 	ChangedTime time.Time
 }
 
@@ -169,15 +276,17 @@ func NewOrder(userID shared.ID, shopID uint64, items []OrderItem, remark string)
 	}
 
 	return &Order{
-		ID:         shared.ID(0),
-		UserID:     userID,
-		ShopID:     shopID,
-		TotalPrice: totalPrice,
-		Status:     OrderStatusPending,
-		Remark:     remark,
-		CreatedAt:  now,
-		UpdatedAt:  now,
-		Items:      items,
+		ID:            shared.ID(0),
+		UserID:        userID,
+		ShopID:        shopID,
+		Subtotal:      totalPrice,
+		TotalPrice:    totalPrice,
+		PaymentStatus: PaymentStatusUnpaid,
+		Status:        OrderStatusPending,
+		Remark:        remark,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Items:         items,
 	}, nil
 }
 
@@ -188,21 +297,48 @@ func (o *Order) CanTransitionTo(newStatus OrderStatus, flow OrderStatusFlow) err
 	return fmt.Errorf("当前状态 %s 不允许转换到状态 %s", o.Status, newStatus)
 }
 
-func (o *Order) TransitionTo(newStatus OrderStatus, flow OrderStatusFlow) error {
+// TransitionTo 按店铺流转配置变更状态，配置了必填原因的动作（如取消、拒单）必须提供 reason
+// 店铺要求先付款时，未支付的订单不能离开待处理状态
+func (o *Order) TransitionTo(newStatus OrderStatus, flow OrderStatusFlow, reason string) error {
 	if err := o.CanTransitionTo(newStatus, flow); err != nil {
 		return err
 	}
 
+	if flow.RequiresPaymentFor(o.Status, newStatus) && !o.PaymentStatus.IsPaid() {
+		return ErrOrderNotPaid
+	}
+
+	if action, ok := flow.FindTransition(o.Status, newStatus); ok && action.RequireReason && strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("%s订单必须填写原因", action.Name)
+	}
+
 	o.Status = newStatus
 	o.UpdatedAt = time.Now()
 
 	return nil
 }
 
+// ItemQuantities 按商品汇总订单项数量
+func (o *Order) ItemQuantities() map[shared.ID]int {
+	quantities := make(map[shared.ID]int, len(o.Items))
+	for _, item := range o.Items {
+		quantities[item.ProductID] += item.Quantity
+	}
+	return quantities
+}
+
 func (o *Order) IsFinal() bool {
 	return o.Status.IsFinal()
 }
 
+// FulfillmentTime 订单需要出餐的时间：预约订单为预约时间，其他订单为下单时间
+func (o *Order) FulfillmentTime() time.Time {
+	if o.ScheduledTime != nil {
+		return *o.ScheduledTime
+	}
+	return o.CreatedAt
+}
+
 func (o *Order) IsUnfinished(flow OrderStatusFlow) bool {
 	unfinishedStatuses := flow.GetUnfinishedStatuses()
 	for _, status := range unfinishedStatuses {
@@ -217,7 +353,7 @@ func NewOrderItem(productID shared.ID, quantity int, price shared.Price, options
 	totalPrice := price.Multiply(quantity)
 
 	for _, option := range options {
-		totalPrice = totalPrice.Add(shared.Price(option.PriceAdjustment * float64(quantity)))
+		totalPrice = totalPrice.Add(option.PriceAdjustment.Multiply(quantity))
 	}
 
 	return OrderItem{
@@ -230,7 +366,7 @@ func NewOrderItem(productID shared.ID, quantity int, price shared.Price, options
 	}
 }
 
-func NewOrderItemOption(categoryID, optionID shared.ID, optionName, categoryName string, priceAdjustment float64) OrderItemOption {
+func NewOrderItemOption(categoryID, optionID shared.ID, optionName, categoryName string, priceAdjustment shared.Price) OrderItemOption {
 	return OrderItemOption{
 		ID:              shared.ID(0),
 		CategoryID:      categoryID,
@@ -275,13 +411,81 @@ func (oi *OrderItem) CalculatePrice(finder ProductFinder) (shared.Price, error)
 		oi.Options[j].PriceAdjustment = opt.PriceAdjustment
 
 		// 累加选项价格
-		itemTotal = itemTotal.Add(shared.Price(opt.PriceAdjustment * float64(oi.Quantity)))
+		itemTotal = itemTotal.Add(opt.PriceAdjustment.Multiply(oi.Quantity))
 	}
 
 	oi.TotalPrice = itemTotal
 	return itemTotal, nil
 }
 
+// ApplyOptionRules 按商品的参数类别规则校验所选选项：选项必须属于该商品，单选类别只能选一项，
+// 多选类别满足最少和最多选择数量；未选择的类别使用默认选项，仍不满足必选时返回错误
+// 校验通过后选项按类别顺序重新排列，类别ID以商品设置为准
+func (oi *OrderItem) ApplyOptionRules(prod *product.Product) error {
+	categoryOf := make(map[shared.ID]*product.ProductOptionCategory)
+	optionNames := make(map[shared.ID]string)
+	for i := range prod.OptionCategories {
+		cat := &prod.OptionCategories[i]
+		for _, opt := range cat.Options {
+			categoryOf[opt.ID] = cat
+			optionNames[opt.ID] = opt.Name
+		}
+	}
+
+	selected := make(map[shared.ID][]shared.ID, len(prod.OptionCategories))
+	seen := make(map[shared.ID]bool, len(oi.Options))
+	for _, opt := range oi.Options {
+		cat, ok := categoryOf[opt.OptionID]
+		if !ok {
+			return fmt.Errorf("所选参数不属于商品 %s", prod.Name)
+		}
+		if seen[opt.OptionID] {
+			return fmt.Errorf("%s 的选项 %s 重复选择", cat.Name, optionNames[opt.OptionID])
+		}
+		seen[opt.OptionID] = true
+		selected[cat.ID] = append(selected[cat.ID], opt.OptionID)
+	}
+
+	categories := make([]*product.ProductOptionCategory, len(prod.OptionCategories))
+	for i := range prod.OptionCategories {
+		categories[i] = &prod.OptionCategories[i]
+	}
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categories[i].DisplayOrder < categories[j].DisplayOrder
+	})
+
+	options := make([]OrderItemOption, 0, len(oi.Options))
+	for _, cat := range categories {
+		optionIDs := selected[cat.ID]
+		if len(optionIDs) == 0 {
+			for _, opt := range cat.DefaultOptions() {
+				optionIDs = append(optionIDs, opt.ID)
+			}
+		}
+
+		count := len(optionIDs)
+		if max := cat.MaxSelections(); max > 0 && count > max {
+			if !cat.IsMultiple {
+				return fmt.Errorf("%s 只能选择一项", cat.Name)
+			}
+			return fmt.Errorf("%s 最多选择 %d 项", cat.Name, max)
+		}
+		if required := cat.RequiredSelections(); count < required {
+			if !cat.IsMultiple || required == 1 {
+				return fmt.Errorf("请选择%s", cat.Name)
+			}
+			return fmt.Errorf("%s 至少选择 %d 项", cat.Name, required)
+		}
+
+		for _, optionID := range optionIDs {
+			options = append(options, OrderItemOption{CategoryID: cat.ID, OptionID: optionID})
+		}
+	}
+
+	oi.Options = options
+	return nil
+}
+
 // ValidateProduct 验证商品（库存、归属）
 func (oi *OrderItem) ValidateProduct(prod *product.Product, shopID uint64) error {
 	if prod.ShopID != shopID {
@@ -305,6 +509,10 @@ func (o *Order) ValidateItems(finder ProductFinder) error {
 			return err
 		}
 
+		if err := o.Items[i].ApplyOptionRules(prod); err != nil {
+			return err
+		}
+
 		o.Items[i].SetProductSnapshot(prod)
 	}
 	return nil
@@ -322,6 +530,37 @@ func (o *Order) CalculateTotal(finder ProductFinder) error {
 		totalPrice = totalPrice.Add(itemTotal)
 	}
 
-	o.TotalPrice = totalPrice
+	o.Subtotal = totalPrice
+	o.TotalPrice = o.grandTotal()
 	return nil
 }
+
+// ItemsTotal 按订单项汇总商品小计（含选项加价，未扣除优惠）
+func (o *Order) ItemsTotal() shared.Price {
+	subtotal := shared.Price(0)
+	for _, item := range o.Items {
+		subtotal = subtotal.Add(item.TotalPrice)
+	}
+	return subtotal
+}
+
+// ApplyDiscounts 按顺序应用优惠并重新计算应付金额
+// 优惠合计不超过商品小计，超出部分从靠后的优惠中扣减，减免为0的优惠不再保留
+func (o *Order) ApplyDiscounts(discounts []OrderDiscount) {
+	o.Subtotal = o.ItemsTotal()
+	remaining := o.Subtotal
+
+	applied := make([]OrderDiscount, 0, len(discounts))
+	for _, discount := range discounts {
+		discount.Amount = discount.Amount.Min(remaining)
+		if !discount.Amount.IsPositive() {
+			continue
+		}
+		remaining = remaining.Sub(discount.Amount)
+		applied = append(applied, discount)
+	}
+
+	o.Discounts = applied
+	o.DiscountAmount = o.Subtotal.Sub(remaining)
+	o.TotalPrice = o.grandTotal()
+}
@@ -54,15 +54,15 @@ func TestNewOrderItem(t *testing.T) {
 			name:      "single item without options",
 			productID: shared.ID(123),
 			quantity:  2,
-			price:     shared.Price(100),
+			price:     shared.NewPrice(100),
 			options:   []OrderItemOption{},
-			wantTotal: shared.Price(200),
+			wantTotal: shared.NewPrice(200),
 			validate: func(t *testing.T, item OrderItem) {
 				assert.Equal(t, shared.ID(0), item.ID)
 				assert.Equal(t, shared.ID(123), item.ProductID)
 				assert.Equal(t, 2, item.Quantity)
-				assert.Equal(t, shared.Price(100), item.Price)
-				assert.Equal(t, shared.Price(200), item.TotalPrice)
+				assert.Equal(t, shared.NewPrice(100), item.Price)
+				assert.Equal(t, shared.NewPrice(200), item.TotalPrice)
 				assert.Empty(t, item.Options)
 			},
 		},
@@ -70,51 +70,51 @@ func TestNewOrderItem(t *testing.T) {
 			name:      "single item with one option",
 			productID: shared.ID(456),
 			quantity:  1,
-			price:     shared.Price(50),
+			price:     shared.NewPrice(50),
 			options: []OrderItemOption{
-				{PriceAdjustment: 10},
+				{PriceAdjustment: shared.NewPrice(10)},
 			},
-			wantTotal: shared.Price(60),
+			wantTotal: shared.NewPrice(60),
 		},
 		{
 			name:      "single item with multiple options",
 			productID: shared.ID(789),
 			quantity:  3,
-			price:     shared.Price(100),
+			price:     shared.NewPrice(100),
 			options: []OrderItemOption{
-				{PriceAdjustment: 10},
-				{PriceAdjustment: 5},
+				{PriceAdjustment: shared.NewPrice(10)},
+				{PriceAdjustment: shared.NewPrice(5)},
 			},
-			wantTotal: shared.Price(345), // (100 + 10 + 5) * 3
+			wantTotal: shared.NewPrice(345), // (100 + 10 + 5) * 3
 		},
 		{
 			name:      "item with negative price adjustment",
 			productID: shared.ID(111),
 			quantity:  2,
-			price:     shared.Price(100),
+			price:     shared.NewPrice(100),
 			options: []OrderItemOption{
-				{PriceAdjustment: -10},
+				{PriceAdjustment: shared.NewPrice(-10)},
 			},
-			wantTotal: shared.Price(180), // (100 - 10) * 2
+			wantTotal: shared.NewPrice(180), // (100 - 10) * 2
 		},
 		{
 			name:      "zero quantity",
 			productID: shared.ID(222),
 			quantity:  0,
-			price:     shared.Price(100),
+			price:     shared.NewPrice(100),
 			options:   []OrderItemOption{},
-			wantTotal: shared.Price(0),
+			wantTotal: shared.NewPrice(0),
 		},
 		{
 			name:      "item with zero price adjustment options",
 			productID: shared.ID(333),
 			quantity:  5,
-			price:     shared.Price(20),
+			price:     shared.NewPrice(20),
 			options: []OrderItemOption{
-				{PriceAdjustment: 0},
-				{PriceAdjustment: 0},
+				{PriceAdjustment: shared.NewPrice(0)},
+				{PriceAdjustment: shared.NewPrice(0)},
 			},
-			wantTotal: shared.Price(100), // (20 + 0 + 0) * 5
+			wantTotal: shared.NewPrice(100), // (20 + 0 + 0) * 5
 		},
 	}
 
@@ -137,7 +137,7 @@ func TestNewOrderItemOption(t *testing.T) {
 		optionID        shared.ID
 		optionName      string
 		categoryName    string
-		priceAdjustment float64
+		priceAdjustment shared.Price
 		validate        func(*testing.T, OrderItemOption)
 	}{
 		{
@@ -146,14 +146,14 @@ func TestNewOrderItemOption(t *testing.T) {
 			optionID:        shared.ID(2),
 			optionName:      "大",
 			categoryName:    "尺寸",
-			priceAdjustment: 5.0,
+			priceAdjustment: shared.NewPrice(5.0),
 			validate: func(t *testing.T, o OrderItemOption) {
 				assert.Equal(t, shared.ID(0), o.ID)
 				assert.Equal(t, shared.ID(1), o.CategoryID)
 				assert.Equal(t, shared.ID(2), o.OptionID)
 				assert.Equal(t, "大", o.OptionName)
 				assert.Equal(t, "尺寸", o.CategoryName)
-				assert.Equal(t, 5.0, o.PriceAdjustment)
+				assert.Equal(t, shared.NewPrice(5.0), o.PriceAdjustment)
 				assert.False(t, o.CreatedAt.IsZero())
 				assert.False(t, o.UpdatedAt.IsZero())
 			},
@@ -164,7 +164,7 @@ func TestNewOrderItemOption(t *testing.T) {
 			optionID:        shared.ID(4),
 			optionName:      "小",
 			categoryName:    "尺寸",
-			priceAdjustment: -2.5,
+			priceAdjustment: shared.NewPrice(-2.5),
 		},
 		{
 			name:            "zero price adjustment",
@@ -172,7 +172,7 @@ func TestNewOrderItemOption(t *testing.T) {
 			optionID:        shared.ID(6),
 			optionName:      "中",
 			categoryName:    "尺寸",
-			priceAdjustment: 0,
+			priceAdjustment: shared.NewPrice(0),
 		},
 	}
 
@@ -214,13 +214,13 @@ func TestOrderItem_CalculatePrice(t *testing.T) {
 			orderItem: OrderItem{
 				ProductID: shared.ID(123),
 				Quantity:  2,
-				Price:     shared.Price(100),
+				Price:     shared.NewPrice(100),
 				Options:   []OrderItemOption{},
 			},
 			setupMock: func(m *MockProductFinder) {
 				// No options, no mock calls needed
 			},
-			wantPrice: shared.Price(200),
+			wantPrice: shared.NewPrice(200),
 			wantErr:   false,
 		},
 		{
@@ -228,7 +228,7 @@ func TestOrderItem_CalculatePrice(t *testing.T) {
 			orderItem: OrderItem{
 				ProductID: shared.ID(123),
 				Quantity:  2,
-				Price:     shared.Price(100),
+				Price:     shared.NewPrice(100),
 				Options: []OrderItemOption{
 					{OptionID: shared.ID(1)},
 					{OptionID: shared.ID(2)},
@@ -239,7 +239,7 @@ func TestOrderItem_CalculatePrice(t *testing.T) {
 					ID:           shared.ID(1),
 					CategoryID:   shared.ID(10),
 					Name:         "大",
-					PriceAdjustment: 10,
+					PriceAdjustment: shared.NewPrice(10),
 				}, nil)
 				m.On("FindOptionCategory", shared.ID(10)).Return(&product.ProductOptionCategory{
 					ID:   shared.ID(10),
@@ -249,22 +249,22 @@ func TestOrderItem_CalculatePrice(t *testing.T) {
 					ID:           shared.ID(2),
 					CategoryID:   shared.ID(20),
 					Name:         "加冰",
-					PriceAdjustment: 5,
+					PriceAdjustment: shared.NewPrice(5),
 				}, nil)
 				m.On("FindOptionCategory", shared.ID(20)).Return(&product.ProductOptionCategory{
 					ID:   shared.ID(20),
 					Name: "温度",
 				}, nil)
 			},
-			wantPrice: shared.Price(230), // (100 + 10 + 5) * 2
+			wantPrice: shared.NewPrice(230), // (100 + 10 + 5) * 2
 			wantErr:   false,
 			validateItem: func(t *testing.T, item *OrderItem) {
 				assert.Equal(t, "大", item.Options[0].OptionName)
 				assert.Equal(t, "尺寸", item.Options[0].CategoryName)
-				assert.Equal(t, 10.0, item.Options[0].PriceAdjustment)
+				assert.Equal(t, shared.NewPrice(10.0), item.Options[0].PriceAdjustment)
 				assert.Equal(t, "加冰", item.Options[1].OptionName)
 				assert.Equal(t, "温度", item.Options[1].CategoryName)
-				assert.Equal(t, 5.0, item.Options[1].PriceAdjustment)
+				assert.Equal(t, shared.NewPrice(5.0), item.Options[1].PriceAdjustment)
 			},
 		},
 		{
@@ -272,7 +272,7 @@ func TestOrderItem_CalculatePrice(t *testing.T) {
 			orderItem: OrderItem{
 				ProductID: shared.ID(123),
 				Quantity:  2,
-				Price:     shared.Price(100),
+				Price:     shared.NewPrice(100),
 				Options: []OrderItemOption{
 					{OptionID: shared.ID(999)},
 				},
@@ -288,7 +288,7 @@ func TestOrderItem_CalculatePrice(t *testing.T) {
 			orderItem: OrderItem{
 				ProductID: shared.ID(123),
 				Quantity:  2,
-				Price:     shared.Price(100),
+				Price:     shared.NewPrice(100),
 				Options: []OrderItemOption{
 					{OptionID: shared.ID(1)},
 				},
@@ -298,7 +298,7 @@ func TestOrderItem_CalculatePrice(t *testing.T) {
 					ID:           shared.ID(1),
 					CategoryID:   shared.ID(999),
 					Name:         "大",
-					PriceAdjustment: 10,
+					PriceAdjustment: shared.NewPrice(10),
 				}, nil)
 				m.On("FindOptionCategory", shared.ID(999)).Return(nil, assert.AnError)
 			},
@@ -446,13 +446,13 @@ func TestOrderItem_SetProductSnapshot(t *testing.T) {
 				Name:        "测试商品",
 				Description: "这是一个测试商品",
 				ImageURL:    "http://example.com/image.jpg",
-				Price:       shared.Price(99.99),
+				Price:       shared.NewPrice(99.99),
 			},
 			validate: func(t *testing.T, item *OrderItem) {
 				assert.Equal(t, "测试商品", item.ProductName)
 				assert.Equal(t, "这是一个测试商品", item.ProductDescription)
 				assert.Equal(t, "http://example.com/image.jpg", item.ProductImageURL)
-				assert.Equal(t, shared.Price(99.99), item.Price)
+				assert.Equal(t, shared.NewPrice(99.99), item.Price)
 			},
 		},
 		{
@@ -461,13 +461,13 @@ func TestOrderItem_SetProductSnapshot(t *testing.T) {
 				Name:        "",
 				Description: "",
 				ImageURL:    "",
-				Price:       shared.Price(0),
+				Price:       shared.NewPrice(0),
 			},
 			validate: func(t *testing.T, item *OrderItem) {
 				assert.Equal(t, "", item.ProductName)
 				assert.Equal(t, "", item.ProductDescription)
 				assert.Equal(t, "", item.ProductImageURL)
-				assert.Equal(t, shared.Price(0), item.Price)
+				assert.Equal(t, shared.NewPrice(0), item.Price)
 			},
 		},
 	}
@@ -502,3 +502,100 @@ func TestOrderItem_OptionsSnapshot(t *testing.T) {
 		assert.True(t, option.UpdatedAt.Before(after) || option.UpdatedAt.Equal(after))
 	})
 }
+
+func TestOrderItem_ApplyOptionRules(t *testing.T) {
+	prod := &product.Product{
+		ID:   shared.ID(1),
+		Name: "奶茶",
+		OptionCategories: []product.ProductOptionCategory{
+			{
+				ID: shared.ID(20), Name: "加料", IsMultiple: true, MinSelect: 1, MaxSelect: 2, DisplayOrder: 2,
+				Options: []product.ProductOption{
+					{ID: shared.ID(201), CategoryID: shared.ID(20), Name: "珍珠"},
+					{ID: shared.ID(202), CategoryID: shared.ID(20), Name: "椰果"},
+					{ID: shared.ID(203), CategoryID: shared.ID(20), Name: "布丁"},
+				},
+			},
+			{
+				ID: shared.ID(10), Name: "甜度", IsRequired: true, DisplayOrder: 1,
+				Options: []product.ProductOption{
+					{ID: shared.ID(101), CategoryID: shared.ID(10), Name: "全糖", IsDefault: true},
+					{ID: shared.ID(102), CategoryID: shared.ID(10), Name: "半糖"},
+				},
+			},
+			{
+				ID: shared.ID(30), Name: "温度", IsRequired: true, DisplayOrder: 3,
+				Options: []product.ProductOption{
+					{ID: shared.ID(301), CategoryID: shared.ID(30), Name: "热"},
+					{ID: shared.ID(302), CategoryID: shared.ID(30), Name: "冰"},
+				},
+			},
+		},
+	}
+
+	opt := func(categoryID, optionID uint64) OrderItemOption {
+		return OrderItemOption{CategoryID: shared.ID(categoryID), OptionID: shared.ID(optionID)}
+	}
+
+	tests := []struct {
+		name    string
+		options []OrderItemOption
+		want    []OrderItemOption
+		errMsg  string
+	}{
+		{
+			name:    "defaults applied and sorted by category order",
+			options: []OrderItemOption{opt(30, 302), opt(20, 201)},
+			want:    []OrderItemOption{opt(10, 101), opt(20, 201), opt(30, 302)},
+		},
+		{
+			name:    "category id taken from product",
+			options: []OrderItemOption{opt(99, 102), opt(20, 201), opt(20, 203), opt(30, 301)},
+			want:    []OrderItemOption{opt(10, 102), opt(20, 201), opt(20, 203), opt(30, 301)},
+		},
+		{
+			name:    "option from another product",
+			options: []OrderItemOption{opt(30, 302), opt(20, 201), opt(40, 401)},
+			errMsg:  "所选参数不属于商品 奶茶",
+		},
+		{
+			name:    "duplicate option",
+			options: []OrderItemOption{opt(30, 302), opt(20, 201), opt(20, 201)},
+			errMsg:  "加料 的选项 珍珠 重复选择",
+		},
+		{
+			name:    "single select with two options",
+			options: []OrderItemOption{opt(30, 301), opt(30, 302), opt(20, 201)},
+			errMsg:  "温度 只能选择一项",
+		},
+		{
+			name:    "multiple select over max",
+			options: []OrderItemOption{opt(30, 302), opt(20, 201), opt(20, 202), opt(20, 203)},
+			errMsg:  "加料 最多选择 2 项",
+		},
+		{
+			name:    "required category without default",
+			options: []OrderItemOption{opt(20, 201)},
+			errMsg:  "请选择温度",
+		},
+		{
+			name:    "multiple select under min",
+			options: []OrderItemOption{opt(30, 302)},
+			errMsg:  "请选择加料",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &OrderItem{ProductID: prod.ID, Quantity: 1, Options: tt.options}
+
+			err := item.ApplyOptionRules(prod)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, item.Options)
+		})
+	}
+}
@@ -298,3 +298,16 @@ func TestOrderStatus_TransitionWorkflow(t *testing.T) {
 		assert.False(t, flow.CanTransition(OrderStatusAccepted, OrderStatusPending))
 	})
 }
+
+func TestOrderStatusFlow_StatusLabel(t *testing.T) {
+	flow := OrderStatusFlow{
+		Statuses: []OrderStatusConfig{
+			{Value: OrderStatusPending, Label: "等待商家确认"},
+			{Value: OrderStatusAccepted},
+		},
+	}
+
+	assert.Equal(t, "等待商家确认", flow.StatusLabel(OrderStatusPending))
+	assert.Equal(t, OrderStatusAccepted.String(), flow.StatusLabel(OrderStatusAccepted), "未配置名称时使用默认名称")
+	assert.Equal(t, OrderStatusCanceled.String(), flow.StatusLabel(OrderStatusCanceled))
+}
@@ -30,8 +30,8 @@ func TestNewOrder(t *testing.T) {
 				{
 					ProductID:  shared.ID(789),
 					Quantity:   2,
-					Price:      shared.Price(100),
-					TotalPrice: shared.Price(200),
+					Price:      shared.NewPrice(100),
+					TotalPrice: shared.NewPrice(200),
 				},
 			},
 			remark:  "测试订单",
@@ -42,7 +42,7 @@ func TestNewOrder(t *testing.T) {
 				assert.Equal(t, uint64(456), o.ShopID)
 				assert.Equal(t, OrderStatusPending, o.Status)
 				assert.Equal(t, "测试订单", o.Remark)
-				assert.Equal(t, shared.Price(200), o.TotalPrice)
+				assert.Equal(t, shared.NewPrice(200), o.TotalPrice)
 				assert.Len(t, o.Items, 1)
 				assert.False(t, o.CreatedAt.IsZero())
 				assert.False(t, o.UpdatedAt.IsZero())
@@ -80,7 +80,7 @@ func TestNewOrder(t *testing.T) {
 			userID:  shared.ID(123),
 			shopID:  456,
 			items: []OrderItem{
-				{ProductID: shared.ID(0), Quantity: 1, Price: shared.Price(100)},
+				{ProductID: shared.ID(0), Quantity: 1, Price: shared.NewPrice(100)},
 			},
 			remark:  "",
 			wantErr: true,
@@ -91,7 +91,7 @@ func TestNewOrder(t *testing.T) {
 			userID:  shared.ID(123),
 			shopID:  456,
 			items: []OrderItem{
-				{ProductID: shared.ID(789), Quantity: 0, Price: shared.Price(100)},
+				{ProductID: shared.ID(789), Quantity: 0, Price: shared.NewPrice(100)},
 			},
 			remark:  "",
 			wantErr: true,
@@ -102,7 +102,7 @@ func TestNewOrder(t *testing.T) {
 			userID:  shared.ID(123),
 			shopID:  456,
 			items: []OrderItem{
-				{ProductID: shared.ID(789), Quantity: -1, Price: shared.Price(100)},
+				{ProductID: shared.ID(789), Quantity: -1, Price: shared.NewPrice(100)},
 			},
 			remark:  "",
 			wantErr: true,
@@ -113,13 +113,13 @@ func TestNewOrder(t *testing.T) {
 			userID: shared.ID(123),
 			shopID: 456,
 			items: []OrderItem{
-				{ProductID: shared.ID(1), Quantity: 2, Price: shared.Price(100), TotalPrice: shared.Price(200)},
-				{ProductID: shared.ID(2), Quantity: 1, Price: shared.Price(50), TotalPrice: shared.Price(50)},
+				{ProductID: shared.ID(1), Quantity: 2, Price: shared.NewPrice(100), TotalPrice: shared.NewPrice(200)},
+				{ProductID: shared.ID(2), Quantity: 1, Price: shared.NewPrice(50), TotalPrice: shared.NewPrice(50)},
 			},
 			remark:  "",
 			wantErr: false,
 			validate: func(t *testing.T, o *Order) {
-				assert.Equal(t, shared.Price(250), o.TotalPrice)
+				assert.Equal(t, shared.NewPrice(250), o.TotalPrice)
 				assert.Len(t, o.Items, 2)
 			},
 		},
@@ -158,6 +158,7 @@ func TestOrder_TransitionTo(t *testing.T) {
 		initialStatus OrderStatus
 		newStatus   OrderStatus
 		flow        OrderStatusFlow
+		reason      string
 		wantErr     bool
 		errMsg      string
 		validate    func(*testing.T, *Order)
@@ -211,6 +212,30 @@ func TestOrder_TransitionTo(t *testing.T) {
 			wantErr:      true,
 			errMsg:       "不允许转换到状态",
 		},
+		{
+			name:         "cancel without required reason",
+			initialStatus: OrderStatusPending,
+			newStatus:    OrderStatusCanceled,
+			flow:         createReasonRequiredFlow(),
+			reason:       "  ",
+			wantErr:      true,
+			errMsg:       "取消订单必须填写原因",
+		},
+		{
+			name:         "cancel with required reason",
+			initialStatus: OrderStatusPending,
+			newStatus:    OrderStatusCanceled,
+			flow:         createReasonRequiredFlow(),
+			reason:       "顾客来电取消",
+			wantErr:      false,
+		},
+		{
+			name:         "reason optional for other actions",
+			initialStatus: OrderStatusPending,
+			newStatus:    OrderStatusAccepted,
+			flow:         createReasonRequiredFlow(),
+			wantErr:      false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -221,7 +246,7 @@ func TestOrder_TransitionTo(t *testing.T) {
 				UpdatedAt: before,
 			}
 
-			err := o.TransitionTo(tt.newStatus, tt.flow)
+			err := o.TransitionTo(tt.newStatus, tt.flow, tt.reason)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -469,13 +494,13 @@ func TestOrder_CalculateTotal(t *testing.T) {
 			order: func() *Order {
 				return &Order{
 					Items: []OrderItem{
-						{Price: shared.Price(100), Quantity: 2, Options: []OrderItemOption{}},
-						{Price: shared.Price(50), Quantity: 1, Options: []OrderItemOption{}},
+						{Price: shared.NewPrice(100), Quantity: 2, Options: []OrderItemOption{}},
+						{Price: shared.NewPrice(50), Quantity: 1, Options: []OrderItemOption{}},
 					},
 				}
 			},
 			setupMock: func(m *MockProductFinder) {},
-			wantTotal: shared.Price(250),
+			wantTotal: shared.NewPrice(250),
 			wantErr:   false,
 		},
 		{
@@ -484,7 +509,7 @@ func TestOrder_CalculateTotal(t *testing.T) {
 				return &Order{
 					Items: []OrderItem{
 						{
-							Price:    shared.Price(100),
+							Price:    shared.NewPrice(100),
 							Quantity: 2,
 							Options: []OrderItemOption{
 								{OptionID: shared.ID(1)},
@@ -498,14 +523,14 @@ func TestOrder_CalculateTotal(t *testing.T) {
 					ID:              shared.ID(1),
 					CategoryID:      shared.ID(10),
 					Name:            "大",
-					PriceAdjustment: 10,
+					PriceAdjustment: shared.NewPrice(10),
 				}, nil)
 				m.On("FindOptionCategory", shared.ID(10)).Return(&product.ProductOptionCategory{
 					ID:   shared.ID(10),
 					Name: "尺寸",
 				}, nil)
 			},
-			wantTotal: shared.Price(220), // (100 + 10) * 2
+			wantTotal: shared.NewPrice(220), // (100 + 10) * 2
 			wantErr:   false,
 		},
 	}
@@ -543,6 +568,177 @@ func TestOrder_Timestamps(t *testing.T) {
 	assert.True(t, ord.UpdatedAt.Before(after) || ord.UpdatedAt.Equal(after))
 }
 
+func TestOrder_ApplyDiscounts(t *testing.T) {
+	tests := []struct {
+		name         string
+		discounts    []OrderDiscount
+		wantDiscount shared.Price
+		wantTotal    shared.Price
+		wantAmounts  []shared.Price
+	}{
+		{
+			name:         "no discounts",
+			discounts:    nil,
+			wantDiscount: 0,
+			wantTotal:    shared.NewPrice(200),
+			wantAmounts:  []shared.Price{},
+		},
+		{
+			name:         "stacked discounts",
+			discounts:    []OrderDiscount{{Name: "券", Amount: shared.NewPrice(20)}, {Name: "促销", Amount: shared.NewPrice(15.5)}},
+			wantDiscount: shared.NewPrice(35.5),
+			wantTotal:    shared.NewPrice(164.5),
+			wantAmounts:  []shared.Price{shared.NewPrice(20), shared.NewPrice(15.5)},
+		},
+		{
+			name:         "capped at subtotal",
+			discounts:    []OrderDiscount{{Name: "券", Amount: shared.NewPrice(150)}, {Name: "促销", Amount: shared.NewPrice(80)}, {Name: "多余", Amount: shared.NewPrice(5)}},
+			wantDiscount: shared.NewPrice(200),
+			wantTotal:    0,
+			wantAmounts:  []shared.Price{shared.NewPrice(150), shared.NewPrice(50)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ord, err := NewOrder(shared.ID(123), 456, validOrderItems(), "")
+			assert.NoError(t, err)
+
+			ord.ApplyDiscounts(tt.discounts)
+
+			assert.Equal(t, tt.wantDiscount, ord.DiscountAmount)
+			assert.Equal(t, tt.wantTotal, ord.TotalPrice)
+			amounts := make([]shared.Price, len(ord.Discounts))
+			for i, d := range ord.Discounts {
+				amounts[i] = d.Amount
+			}
+			assert.Equal(t, tt.wantAmounts, amounts)
+		})
+	}
+}
+
+func TestOrder_ApplyCharges(t *testing.T) {
+	tests := []struct {
+		name          string
+		discount      shared.Price
+		takeaway      bool
+		settings      ChargeSettings
+		wantService   shared.Price
+		wantPackaging shared.Price
+		wantTax       shared.Price
+		wantTotal     shared.Price
+	}{
+		{
+			name:      "no charges",
+			wantTotal: shared.NewPrice(200),
+		},
+		{
+			name:        "exclusive tax on discounted amount and service charge",
+			discount:    shared.NewPrice(20),
+			settings:    ChargeSettings{TaxRate: shared.NewRate(6), ServiceChargeRate: shared.NewRate(10)},
+			wantService: shared.NewPrice(18),    // 180 * 10%
+			wantTax:     shared.NewPrice(11.88), // (180 + 18) * 6%
+			wantTotal:   shared.NewPrice(209.88),
+		},
+		{
+			name:      "inclusive tax does not change total",
+			settings:  ChargeSettings{TaxRate: shared.NewRate(6), TaxInclusive: true},
+			wantTax:   shared.NewPrice(11.32), // 200 / 1.06 * 6%
+			wantTotal: shared.NewPrice(200),
+		},
+		{
+			name:          "packaging fee per item for takeaway",
+			takeaway:      true,
+			settings:      ChargeSettings{PackagingFee: shared.NewPrice(1.5)},
+			wantPackaging: shared.NewPrice(3),
+			wantTotal:     shared.NewPrice(203),
+		},
+		{
+			name:      "no packaging fee for dine-in",
+			settings:  ChargeSettings{PackagingFee: shared.NewPrice(1.5)},
+			wantTotal: shared.NewPrice(200),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ord, err := NewOrder(shared.ID(123), 456, validOrderItems(), "")
+			assert.NoError(t, err)
+			ord.Takeaway = tt.takeaway
+			if tt.discount.IsPositive() {
+				ord.ApplyDiscounts([]OrderDiscount{{Name: "券", Amount: tt.discount}})
+			}
+
+			ord.ApplyCharges(tt.settings)
+
+			assert.Equal(t, shared.NewPrice(200), ord.Subtotal)
+			assert.Equal(t, tt.discount, ord.DiscountAmount)
+			assert.Equal(t, tt.wantService, ord.ServiceCharge)
+			assert.Equal(t, tt.wantPackaging, ord.PackagingFee)
+			assert.Equal(t, tt.wantTax, ord.TaxAmount)
+			assert.Equal(t, tt.wantTotal, ord.TotalPrice)
+		})
+	}
+}
+
+func TestOrder_ApplyPayments(t *testing.T) {
+	tests := []struct {
+		name       string
+		paid       shared.Price
+		refunded   shared.Price
+		wantStatus PaymentStatus
+	}{
+		{"nothing paid", 0, 0, PaymentStatusUnpaid},
+		{"underpaid", shared.NewPrice(150), 0, PaymentStatusUnpaid},
+		{"fully paid", shared.NewPrice(200), 0, PaymentStatusPaid},
+		{"partially refunded", shared.NewPrice(200), shared.NewPrice(50), PaymentStatusPartiallyRefunded},
+		{"fully refunded", shared.NewPrice(200), shared.NewPrice(200), PaymentStatusRefunded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ord, err := NewOrder(shared.ID(123), 456, validOrderItems(), "")
+			assert.NoError(t, err)
+			assert.Equal(t, PaymentStatusUnpaid, ord.PaymentStatus)
+
+			ord.ApplyPayments(tt.paid, tt.refunded)
+
+			assert.Equal(t, tt.wantStatus, ord.PaymentStatus)
+			assert.Equal(t, tt.paid, ord.PaidAmount)
+			assert.Equal(t, tt.refunded, ord.RefundedAmount)
+		})
+	}
+}
+
+func TestOrder_TransitionTo_RequirePayment(t *testing.T) {
+	flow := createReasonRequiredFlow()
+	flow.RequirePayment = true
+	flow.Statuses[1].ReleaseStock = true
+
+	t.Run("unpaid order cannot be accepted", func(t *testing.T) {
+		ord, _ := NewOrder(shared.ID(123), 456, validOrderItems(), "")
+		err := ord.TransitionTo(OrderStatusAccepted, flow, "")
+		assert.ErrorIs(t, err, ErrOrderNotPaid)
+		assert.Equal(t, OrderStatusPending, ord.Status)
+	})
+
+	t.Run("unpaid order can still be canceled", func(t *testing.T) {
+		ord, _ := NewOrder(shared.ID(123), 456, validOrderItems(), "")
+		assert.NoError(t, ord.TransitionTo(OrderStatusCanceled, flow, "顾客取消"))
+	})
+
+	t.Run("paid order can be accepted", func(t *testing.T) {
+		ord, _ := NewOrder(shared.ID(123), 456, validOrderItems(), "")
+		ord.ApplyPayments(ord.TotalPrice, 0)
+		assert.NoError(t, ord.TransitionTo(OrderStatusAccepted, flow, ""))
+	})
+
+	t.Run("payment not required by default", func(t *testing.T) {
+		ord, _ := NewOrder(shared.ID(123), 456, validOrderItems(), "")
+		assert.NoError(t, ord.TransitionTo(OrderStatusAccepted, createReasonRequiredFlow(), ""))
+	})
+}
+
 // Helper functions
 
 func validOrderItems() []OrderItem {
@@ -550,8 +746,8 @@ func validOrderItems() []OrderItem {
 		{
 			ProductID:  shared.ID(789),
 			Quantity:   2,
-			Price:      shared.Price(100),
-			TotalPrice: shared.Price(200),
+			Price:      shared.NewPrice(100),
+			TotalPrice: shared.NewPrice(200),
 		},
 	}
 }
@@ -601,3 +797,25 @@ func createTestFlow() OrderStatusFlow {
 		},
 	}
 }
+
+func createReasonRequiredFlow() OrderStatusFlow {
+	return OrderStatusFlow{
+		Statuses: []OrderStatusConfig{
+			{
+				Value:   OrderStatusPending,
+				Label:   "待处理",
+				IsFinal: false,
+				Actions: []OrderStatusTransition{
+					{Name: "接单", NextStatus: OrderStatusAccepted},
+					{Name: "取消", NextStatus: OrderStatusCanceled, RequireReason: true},
+				},
+			},
+			{
+				Value:   OrderStatusCanceled,
+				Label:   "已取消",
+				IsFinal: true,
+				Actions: []OrderStatusTransition{},
+			},
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package order
+
+import (
+	"errors"
+
+	"orderease/domain/shared"
+)
+
+// PaymentStatus 订单支付状态，由支付服务根据支付单和退款汇总维护
+type PaymentStatus string
+
+const (
+	PaymentStatusUnpaid            PaymentStatus = "unpaid"
+	PaymentStatusPaid              PaymentStatus = "paid"
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentStatusRefunded          PaymentStatus = "refunded"
+)
+
+// ErrOrderNotPaid 店铺要求先支付时，未支付的订单不能进入处理流程
+var ErrOrderNotPaid = errors.New("订单尚未支付，请先完成支付")
+
+// IsPaid 订单已付清（部分退款的订单仍视为已支付）
+func (s PaymentStatus) IsPaid() bool {
+	return s == PaymentStatusPaid || s == PaymentStatusPartiallyRefunded
+}
+
+func (s PaymentStatus) String() string {
+	switch s {
+	case PaymentStatusPaid:
+		return "已支付"
+	case PaymentStatusPartiallyRefunded:
+		return "部分退款"
+	case PaymentStatusRefunded:
+		return "已退款"
+	default:
+		return "未支付"
+	}
+}
+
+// RequiresPaymentFor 店铺开启先付款后，待处理的订单进入流程中的下一个状态（如接单）前必须完成支付
+// 取消、拒单等归还库存的状态不受限制
+func (flow *OrderStatusFlow) RequiresPaymentFor(from, to OrderStatus) bool {
+	if !flow.RequirePayment || from != OrderStatusPending || to == OrderStatusPending {
+		return false
+	}
+	return !flow.ReleasesStock(to)
+}
+
+// ApplyPayments 按已支付金额和已退款金额更新订单的支付状态
+// 已支付金额达到应付金额才算付清，全额退款后为已退款
+func (o *Order) ApplyPayments(paid, refunded shared.Price) {
+	o.PaidAmount = paid
+	o.RefundedAmount = refunded
+
+	switch {
+	case paid.IsPositive() && refunded >= paid:
+		o.PaymentStatus = PaymentStatusRefunded
+	case paid.IsPositive() && paid >= o.TotalPrice && refunded.IsPositive():
+		o.PaymentStatus = PaymentStatusPartiallyRefunded
+	case paid.IsPositive() && paid >= o.TotalPrice:
+		o.PaymentStatus = PaymentStatusPaid
+	default:
+		o.PaymentStatus = PaymentStatusUnpaid
+	}
+}
@@ -0,0 +1,185 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"orderease/domain/shared"
+)
+
+var (
+	ErrRefundNotAllowed  = errors.New("只有已完成的订单可以退款")
+	ErrFullyRefunded     = errors.New("订单已全额退款")
+	ErrOrderNotDeletable = errors.New("订单已支付或已退款，不能删除，请使用退款")
+	ErrOrderNotEditable  = errors.New("订单已结束或已退款，不能修改")
+)
+
+// OrderRefund 订单退款记录（售后）
+// 按订单项和数量退款，记录退款金额、原因和操作人；订单保留，退款金额从营业额中扣除
+type OrderRefund struct {
+	ID           shared.ID
+	OrderID      shared.ID
+	ShopID       uint64
+	Amount       shared.Price
+	Reason       string
+	RestoreStock bool // 退回的商品重新入库
+	Actor        StatusActor
+	Items        []OrderRefundItem
+	CreatedAt    time.Time
+}
+
+// OrderRefundItem 退款的订单项和数量，金额按订单实付金额分摊
+type OrderRefundItem struct {
+	ID          shared.ID
+	RefundID    shared.ID
+	OrderItemID shared.ID
+	ProductID   shared.ID
+	ProductName string
+	Quantity    int
+	Amount      shared.Price
+}
+
+// RefundLine 申请退款的订单项和数量
+type RefundLine struct {
+	OrderItemID shared.ID
+	Quantity    int
+}
+
+// RefundedQuantities 按订单项汇总已退款的数量
+func RefundedQuantities(refunds []OrderRefund) map[shared.ID]int {
+	quantities := make(map[shared.ID]int)
+	for _, refund := range refunds {
+		for _, item := range refund.Items {
+			quantities[item.OrderItemID] += item.Quantity
+		}
+	}
+	return quantities
+}
+
+// RefundableAmount 订单剩余可退金额
+func (o *Order) RefundableAmount() shared.Price {
+	return o.TotalPrice.Sub(o.RefundedAmount)
+}
+
+// NewRefund 为已完成的订单创建退款，previous 为该订单已有的退款记录
+// lines 为空时全额退款：退回所有未退的商品和剩余金额；否则按订单项和数量部分退款
+// 订单项的退款金额按实付金额分摊（含优惠、服务费、打包费和税），所有商品都退完时退回剩余全部金额，避免分摊的尾差
+func (o *Order) NewRefund(flow OrderStatusFlow, lines []RefundLine, previous []OrderRefund, reason string, restoreStock bool, actor StatusActor) (*OrderRefund, error) {
+	if !flow.IsFinalStatus(o.Status) || flow.ReleasesStock(o.Status) {
+		return nil, ErrRefundNotAllowed
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("退款必须填写原因")
+	}
+
+	remaining := o.RefundableAmount()
+	if !remaining.IsPositive() {
+		return nil, ErrFullyRefunded
+	}
+
+	refunded := RefundedQuantities(previous)
+	requested := make(map[shared.ID]int)
+	if len(lines) == 0 {
+		for _, item := range o.Items {
+			if left := item.Quantity - refunded[item.ID]; left > 0 {
+				requested[item.ID] = left
+			}
+		}
+	} else {
+		for _, line := range lines {
+			if line.Quantity <= 0 {
+				return nil, errors.New("退款数量必须大于0")
+			}
+			requested[line.OrderItemID] += line.Quantity
+		}
+	}
+
+	refund := &OrderRefund{
+		OrderID:      o.ID,
+		ShopID:       o.ShopID,
+		Reason:       reason,
+		RestoreStock: restoreStock,
+		Actor:        actor,
+		CreatedAt:    time.Now(),
+	}
+
+	allRefunded := true
+	for _, item := range o.Items {
+		quantity := requested[item.ID]
+		delete(requested, item.ID)
+
+		left := item.Quantity - refunded[item.ID]
+		if quantity > left {
+			return nil, fmt.Errorf("商品 %s 最多可退 %d 件", item.ProductName, left)
+		}
+		if quantity < left {
+			allRefunded = false
+		}
+		if quantity == 0 {
+			continue
+		}
+
+		refund.Items = append(refund.Items, OrderRefundItem{
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    quantity,
+			Amount:      o.itemRefundAmount(item, quantity),
+		})
+		refund.Amount = refund.Amount.Add(refund.Items[len(refund.Items)-1].Amount)
+	}
+	if len(requested) > 0 {
+		return nil, errors.New("订单项不存在")
+	}
+
+	if allRefunded {
+		// 尾差计入最后一个订单项，保证订单项金额之和等于退款金额
+		if n := len(refund.Items); n > 0 {
+			refund.Items[n-1].Amount = refund.Items[n-1].Amount.Add(remaining.Sub(refund.Amount))
+		}
+		refund.Amount = remaining
+	} else {
+		refund.Amount = refund.Amount.Min(remaining)
+	}
+	if !refund.Amount.IsPositive() {
+		return nil, errors.New("退款金额必须大于0")
+	}
+
+	return refund, nil
+}
+
+// itemRefundAmount 订单项退款金额：先按数量分摊订单项金额，再按订单实付金额与商品小计的比例折算
+func (o *Order) itemRefundAmount(item OrderItem, quantity int) shared.Price {
+	amount := item.TotalPrice.Prorate(int64(quantity), int64(item.Quantity))
+	if !o.Subtotal.IsPositive() {
+		return amount
+	}
+	return o.TotalPrice.Prorate(amount.Cents(), o.Subtotal.Cents())
+}
+
+// ApplyRefund 累加订单的已退款金额，用于未在系统内支付（线下结算）的订单
+// 在系统内支付的订单由支付服务按支付单退款后重新汇总
+func (o *Order) ApplyRefund(refund *OrderRefund) {
+	o.RefundedAmount = o.RefundedAmount.Add(refund.Amount)
+	o.UpdatedAt = time.Now()
+}
+
+// CheckDeletable 已支付或已退款的订单需要保留支付和退款历史，不能删除
+func (o *Order) CheckDeletable() error {
+	if o.PaidAmount.IsPositive() || o.RefundedAmount.IsPositive() {
+		return ErrOrderNotDeletable
+	}
+	return nil
+}
+
+// CheckEditable 已结束或已退款的订单不能再修改订单项，避免库存和退款金额对不上
+func (o *Order) CheckEditable(flow OrderStatusFlow) error {
+	if flow.IsFinalStatus(o.Status) || o.RefundedAmount.IsPositive() {
+		return ErrOrderNotEditable
+	}
+	return nil
+}
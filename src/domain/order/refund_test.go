@@ -0,0 +1,133 @@
+package order
+
+import (
+	"testing"
+
+	"orderease/domain/shared"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// completedRefundOrder 两个订单项共 30 元，优惠 3 元后实付 27 元
+func completedRefundOrder() *Order {
+	return &Order{
+		ID:             shared.ID(1),
+		ShopID:         456,
+		Subtotal:       shared.NewPrice(30),
+		DiscountAmount: shared.NewPrice(3),
+		TotalPrice:     shared.NewPrice(27),
+		Status:         OrderStatusComplete,
+		Items: []OrderItem{
+			{ID: shared.ID(11), ProductID: shared.ID(101), ProductName: "奶茶", Quantity: 2, Price: shared.NewPrice(10), TotalPrice: shared.NewPrice(20)},
+			{ID: shared.ID(12), ProductID: shared.ID(102), ProductName: "蛋糕", Quantity: 1, Price: shared.NewPrice(10), TotalPrice: shared.NewPrice(10)},
+		},
+	}
+}
+
+func TestOrder_NewRefund(t *testing.T) {
+	flow := createTestFlow()
+	actor := StatusActor{Type: "staff", ID: 7, Name: "manager01"}
+
+	t.Run("only completed orders", func(t *testing.T) {
+		ord := completedRefundOrder()
+		ord.Status = OrderStatusAccepted
+		_, err := ord.NewRefund(flow, nil, nil, "退菜", false, actor)
+		assert.ErrorIs(t, err, ErrRefundNotAllowed)
+
+		releasing := createTestFlow()
+		releasing.Statuses[5].ReleaseStock = true
+		ord.Status = OrderStatusCanceled
+		_, err = ord.NewRefund(releasing, nil, nil, "退菜", false, actor)
+		assert.ErrorIs(t, err, ErrRefundNotAllowed)
+	})
+
+	t.Run("reason required", func(t *testing.T) {
+		_, err := completedRefundOrder().NewRefund(flow, nil, nil, "  ", false, actor)
+		assert.EqualError(t, err, "退款必须填写原因")
+	})
+
+	t.Run("partial refund is prorated by amount paid", func(t *testing.T) {
+		ord := completedRefundOrder()
+		refund, err := ord.NewRefund(flow, []RefundLine{{OrderItemID: 11, Quantity: 1}}, nil, " 做错了 ", true, actor)
+		require.NoError(t, err)
+		assert.Equal(t, "做错了", refund.Reason)
+		assert.True(t, refund.RestoreStock)
+		assert.Equal(t, actor, refund.Actor)
+		require.Len(t, refund.Items, 1)
+		assert.Equal(t, shared.ID(101), refund.Items[0].ProductID)
+		assert.Equal(t, 1, refund.Items[0].Quantity)
+		assert.Equal(t, shared.NewPrice(9), refund.Amount) // 10 × 27 / 30
+	})
+
+	t.Run("quantity limited by previous refunds", func(t *testing.T) {
+		ord := completedRefundOrder()
+		previous := []OrderRefund{{Items: []OrderRefundItem{{OrderItemID: 11, Quantity: 2}}}}
+		_, err := ord.NewRefund(flow, []RefundLine{{OrderItemID: 11, Quantity: 1}}, previous, "退菜", false, actor)
+		assert.EqualError(t, err, "商品 奶茶 最多可退 0 件")
+
+		_, err = ord.NewRefund(flow, []RefundLine{{OrderItemID: 99, Quantity: 1}}, nil, "退菜", false, actor)
+		assert.EqualError(t, err, "订单项不存在")
+
+		_, err = ord.NewRefund(flow, []RefundLine{{OrderItemID: 11, Quantity: 0}}, nil, "退菜", false, actor)
+		assert.EqualError(t, err, "退款数量必须大于0")
+	})
+
+	t.Run("full refund returns the remaining amount", func(t *testing.T) {
+		ord := completedRefundOrder()
+		ord.RefundedAmount = shared.NewPrice(9)
+		previous := []OrderRefund{{Amount: shared.NewPrice(9), Items: []OrderRefundItem{{OrderItemID: 11, Quantity: 1}}}}
+
+		refund, err := ord.NewRefund(flow, nil, previous, "整单退款", false, actor)
+		require.NoError(t, err)
+		assert.Equal(t, shared.NewPrice(18), refund.Amount)
+		require.Len(t, refund.Items, 2)
+		assert.Equal(t, 1, refund.Items[0].Quantity)
+		assert.Equal(t, 1, refund.Items[1].Quantity)
+		assert.Equal(t, refund.Amount, refund.Items[0].Amount.Add(refund.Items[1].Amount))
+
+		ord.ApplyRefund(refund)
+		assert.Equal(t, ord.TotalPrice, ord.RefundedAmount)
+		_, err = ord.NewRefund(flow, nil, append(previous, *refund), "再退", false, actor)
+		assert.ErrorIs(t, err, ErrFullyRefunded)
+	})
+
+	t.Run("last items absorb rounding", func(t *testing.T) {
+		ord := completedRefundOrder()
+		ord.TotalPrice = shared.NewPrice(10)
+		ord.Items = []OrderItem{{ID: 11, ProductID: 101, Quantity: 3, TotalPrice: shared.NewPrice(30)}}
+
+		first, err := ord.NewRefund(flow, []RefundLine{{OrderItemID: 11, Quantity: 2}}, nil, "退菜", false, actor)
+		require.NoError(t, err)
+		assert.Equal(t, shared.PriceFromCents(667), first.Amount)
+		ord.ApplyRefund(first)
+
+		last, err := ord.NewRefund(flow, []RefundLine{{OrderItemID: 11, Quantity: 1}}, []OrderRefund{*first}, "退菜", false, actor)
+		require.NoError(t, err)
+		assert.Equal(t, shared.PriceFromCents(333), last.Amount)
+	})
+}
+
+func TestOrder_CheckDeletable(t *testing.T) {
+	ord := completedRefundOrder()
+	assert.NoError(t, ord.CheckDeletable())
+
+	ord.PaidAmount = ord.TotalPrice
+	assert.ErrorIs(t, ord.CheckDeletable(), ErrOrderNotDeletable)
+
+	ord.PaidAmount = 0
+	ord.RefundedAmount = shared.NewPrice(1)
+	assert.ErrorIs(t, ord.CheckDeletable(), ErrOrderNotDeletable)
+}
+
+func TestOrder_CheckEditable(t *testing.T) {
+	flow := createTestFlow()
+	ord := completedRefundOrder()
+	assert.ErrorIs(t, ord.CheckEditable(flow), ErrOrderNotEditable)
+
+	ord.Status = OrderStatusPending
+	assert.NoError(t, ord.CheckEditable(flow))
+
+	ord.RefundedAmount = shared.NewPrice(1)
+	assert.ErrorIs(t, ord.CheckEditable(flow), ErrOrderNotEditable)
+}
@@ -7,16 +7,38 @@ import (
 
 type OrderRepository interface {
 	Save(order *Order) error
-	FindByID(id shared.ID) (*Order, error)
-	// FindByIDAndShopID(id shared.ID, shopID uint64) (*Order, error)
+	FindByIDAndShopID(id shared.ID, shopID uint64) (*Order, error)
 	FindByShopID(shopID uint64, page, pageSize int) ([]Order, int64, error)
 	FindByUserID(userID shared.ID, shopID uint64, page, pageSize int) ([]Order, int64, error)
-	FindUnfinishedByShopID(shopID uint64, flow OrderStatusFlow, page, pageSize int) ([]Order, int64, error)
-	Search(shopID uint64, userID string, statuses []OrderStatus, startTime, endTime time.Time, page, pageSize int) ([]Order, int64, error)
-	Delete(id shared.ID) error
+	FindUnfinishedByShopID(shopID uint64, flow OrderStatusFlow, filter UnfinishedFilter, page, pageSize int) ([]Order, int64, error)
+	Search(shopID uint64, userID, pickupNumber string, statuses []OrderStatus, startTime, endTime time.Time, page, pageSize int) ([]Order, int64, error)
+	// AdvanceSearch 按高级查询条件分页查询店铺订单，按下单时间从新到旧
+	AdvanceSearch(shopID uint64, filter AdvanceSearchFilter, page, pageSize int) ([]Order, int64, error)
+	// FindReadyForPickup 查询营业日内处于可取餐状态的订单，按进入该状态的先后排列
+	FindReadyForPickup(shopID uint64, businessDate string, statuses []OrderStatus) ([]Order, error)
+	// FindByTableSessionID 查询一次桌台用餐的全部订单，按下单时间排列
+	FindByTableSessionID(sessionID shared.ID, shopID uint64) ([]Order, error)
+	Delete(id shared.ID, shopID uint64) error
 	Update(order *Order) error
 }
 
+// UnfinishedFilter 未完成订单的排序和按出餐时间筛选，出餐时间见 Order.FulfillmentTime
+type UnfinishedFilter struct {
+	SortByFulfillment bool      // 按出餐时间从早到晚排序，默认按下单时间从新到旧
+	FulfillFrom       time.Time // 出餐时间不早于该时间，为零值时不限
+	FulfillTo         time.Time // 出餐时间早于该时间，为零值时不限
+}
+
+// AdvanceSearchFilter 高级查询条件，字段为空时不限
+type AdvanceSearchFilter struct {
+	UserID           string
+	Statuses         []int
+	FulfillmentTypes []FulfillmentType
+	Recipient        string // 按收货人姓名、电话或配送地址模糊搜索
+	StartTime        string
+	EndTime          string
+}
+
 type OrderItemRepository interface {
 	Save(item *OrderItem) error
 	FindByOrderID(orderID shared.ID) ([]OrderItem, error)
@@ -34,3 +56,14 @@ type OrderStatusLogRepository interface {
 	FindByOrderID(orderID shared.ID) ([]OrderStatusLog, error)
 	DeleteByOrderID(orderID shared.ID) error
 }
+
+type OrderDiscountRepository interface {
+	Save(discount *OrderDiscount) error
+	FindByOrderID(orderID shared.ID) ([]OrderDiscount, error)
+	DeleteByOrderID(orderID shared.ID) error
+}
+
+type OrderRefundRepository interface {
+	Save(refund *OrderRefund) error
+	FindByOrderID(orderID shared.ID, shopID uint64) ([]OrderRefund, error)
+}
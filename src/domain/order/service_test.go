@@ -25,9 +25,9 @@ func TestOrderDomainService_ValidateOrderCreation(t *testing.T) {
 				return &Order{
 					ShopID: 456,
 					Items: []OrderItem{
-						{ProductID: shared.ID(1), Quantity: 2, Price: shared.Price(100)},
+						{ProductID: shared.ID(1), Quantity: 2, Price: shared.NewPrice(100)},
 					},
-					TotalPrice: shared.Price(200),
+					TotalPrice: shared.NewPrice(200),
 				}
 			},
 			setupMock: func(m *MockProductFinder) {
@@ -36,13 +36,13 @@ func TestOrderDomainService_ValidateOrderCreation(t *testing.T) {
 					ShopID: 456,
 					Name:   "商品1",
 					Stock:  10,
-					Price:  shared.Price(100),
+					Price:  shared.NewPrice(100),
 				}, nil)
 			},
 			wantErr: false,
 			validate: func(t *testing.T, o *Order) {
 				assert.Equal(t, "商品1", o.Items[0].ProductName)
-				assert.Equal(t, shared.Price(200), o.TotalPrice)
+				assert.Equal(t, shared.NewPrice(200), o.TotalPrice)
 			},
 		},
 		{
@@ -104,7 +104,7 @@ func TestOrderDomainService_ValidateOrderCreation(t *testing.T) {
 			errContains: "商品不属于该店铺",
 		},
 		{
-			name: "calculate total fails - option not found",
+			name: "validation fails - option not from product",
 			order: func() *Order {
 				return &Order{
 					ShopID: 456,
@@ -112,7 +112,7 @@ func TestOrderDomainService_ValidateOrderCreation(t *testing.T) {
 						{
 							ProductID: shared.ID(1),
 							Quantity:  2,
-							Price:     shared.Price(100),
+							Price:     shared.NewPrice(100),
 							Options: []OrderItemOption{
 								{OptionID: shared.ID(999)},
 							},
@@ -127,10 +127,9 @@ func TestOrderDomainService_ValidateOrderCreation(t *testing.T) {
 					Stock:  10,
 					Name:   "商品1",
 				}, nil)
-				m.On("FindOption", shared.ID(999)).Return(nil, errors.New("option not found"))
 			},
 			wantErr:     true,
-			errContains: "商品参数选项不存在",
+			errContains: "所选参数不属于商品 商品1",
 		},
 		{
 			name: "valid order with multiple items and options",
@@ -141,7 +140,7 @@ func TestOrderDomainService_ValidateOrderCreation(t *testing.T) {
 						{
 							ProductID: shared.ID(1),
 							Quantity:  2,
-							Price:     shared.Price(100),
+							Price:     shared.NewPrice(100),
 							Options: []OrderItemOption{
 								{OptionID: shared.ID(10)},
 							},
@@ -149,7 +148,7 @@ func TestOrderDomainService_ValidateOrderCreation(t *testing.T) {
 						{
 							ProductID: shared.ID(2),
 							Quantity:  1,
-							Price:     shared.Price(50),
+							Price:     shared.NewPrice(50),
 							Options: []OrderItemOption{
 								{OptionID: shared.ID(20)},
 							},
@@ -164,13 +163,16 @@ func TestOrderDomainService_ValidateOrderCreation(t *testing.T) {
 					ShopID: 456,
 					Stock:  10,
 					Name:   "商品1",
-					Price:  shared.Price(100),
+					Price:  shared.NewPrice(100),
+					OptionCategories: []product.ProductOptionCategory{
+						{ID: shared.ID(100), ProductID: shared.ID(1), Name: "尺寸", Options: []product.ProductOption{{ID: shared.ID(10), CategoryID: shared.ID(100)}}},
+					},
 				}, nil)
 				m.On("FindOption", shared.ID(10)).Return(&product.ProductOption{
 					ID:              shared.ID(10),
 					CategoryID:      shared.ID(100),
 					Name:            "大",
-					PriceAdjustment: 10,
+					PriceAdjustment: shared.NewPrice(10),
 				}, nil)
 				m.On("FindOptionCategory", shared.ID(100)).Return(&product.ProductOptionCategory{
 					ID:   shared.ID(100),
@@ -183,13 +185,16 @@ func TestOrderDomainService_ValidateOrderCreation(t *testing.T) {
 					ShopID: 456,
 					Stock:  5,
 					Name:   "商品2",
-					Price:  shared.Price(50),
+					Price:  shared.NewPrice(50),
+					OptionCategories: []product.ProductOptionCategory{
+						{ID: shared.ID(200), ProductID: shared.ID(2), Name: "温度", Options: []product.ProductOption{{ID: shared.ID(20), CategoryID: shared.ID(200)}}},
+					},
 				}, nil)
 				m.On("FindOption", shared.ID(20)).Return(&product.ProductOption{
 					ID:              shared.ID(20),
 					CategoryID:      shared.ID(200),
 					Name:            "加冰",
-					PriceAdjustment: 5,
+					PriceAdjustment: shared.NewPrice(5),
 				}, nil)
 				m.On("FindOptionCategory", shared.ID(200)).Return(&product.ProductOptionCategory{
 					ID:   shared.ID(200),
@@ -199,7 +204,7 @@ func TestOrderDomainService_ValidateOrderCreation(t *testing.T) {
 			wantErr: false,
 			validate: func(t *testing.T, o *Order) {
 				// (100 + 10) * 2 + (50 + 5) * 1 = 220 + 55 = 275
-				assert.Equal(t, shared.Price(275), o.TotalPrice)
+				assert.Equal(t, shared.NewPrice(275), o.TotalPrice)
 				assert.Equal(t, "商品1", o.Items[0].ProductName)
 				assert.Equal(t, "商品2", o.Items[1].ProductName)
 				assert.Equal(t, "大", o.Items[0].Options[0].OptionName)
@@ -259,13 +264,13 @@ func TestOrderDomainService_ValidationOrder(t *testing.T) {
 			ShopID: 456,
 			Stock:  10,
 			Name:   "商品1",
-			Price:  shared.Price(100),
+			Price:  shared.NewPrice(100),
 		}, nil)
 
 		ord := &Order{
 			ShopID: 456,
 			Items: []OrderItem{
-				{ProductID: shared.ID(1), Quantity: 2, Price: shared.Price(100)},
+				{ProductID: shared.ID(1), Quantity: 2, Price: shared.NewPrice(100)},
 			},
 		}
 
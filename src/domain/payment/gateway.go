@@ -0,0 +1,48 @@
+package payment
+
+import (
+	"time"
+
+	"orderease/domain/shared"
+)
+
+// Headers 支付回调的请求头，http.Header 满足该接口
+type Headers interface {
+	Get(key string) string
+}
+
+// Intent 网关下单结果，PayParams 原样返回给前端用于拉起支付（如微信 JSAPI 参数、支付宝跳转链接）
+type Intent struct {
+	ProviderTradeNo string
+	PayParams       map[string]string
+}
+
+// Notification 验签通过后的支付结果通知
+type Notification struct {
+	PaymentID       shared.ID
+	ProviderTradeNo string
+	Success         bool
+	Amount          shared.Price
+	FailureReason   string
+	PaidAt          time.Time
+}
+
+// RefundResult 网关退款结果
+type RefundResult struct {
+	ProviderRefundNo string
+}
+
+// Gateway 支付网关接口（依赖反转）
+// 领域层只定义契约，微信支付、支付宝、模拟支付等由基础设施层实现
+type Gateway interface {
+	// Method 网关对应的支付方式
+	Method() Method
+	// CreateIntent 在网关下单，返回拉起支付所需的参数
+	CreateIntent(p *Payment) (*Intent, error)
+	// ParseNotification 验证回调签名并解析支付结果，签名无效时返回 ErrInvalidSignature
+	ParseNotification(headers Headers, body []byte) (*Notification, error)
+	// Refund 向网关发起退款
+	Refund(p *Payment, r *Refund) (*RefundResult, error)
+	// AckBody 回调处理成功后返回给网关的响应内容，网关收到后停止重复通知
+	AckBody() []byte
+}
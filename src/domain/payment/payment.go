@@ -0,0 +1,246 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"orderease/domain/shared"
+	"orderease/utils"
+)
+
+// Method 支付方式
+type Method string
+
+const (
+	MethodWechatPay    Method = "wechat_pay"     // 微信支付
+	MethodAlipay       Method = "alipay"         // 支付宝
+	MethodMock         Method = "mock"           // 本地模拟支付，仅用于开发和测试
+	MethodCash         Method = "cash"           // 现金，由店员收款后确认
+	MethodPayAtCounter Method = "pay_at_counter" // 到店付款（柜台刷卡、扫码等），由店员收款后确认
+)
+
+func (m Method) IsValid() bool {
+	switch m {
+	case MethodWechatPay, MethodAlipay, MethodMock, MethodCash, MethodPayAtCounter:
+		return true
+	}
+	return false
+}
+
+// IsOffline 线下支付不经过支付网关，由店员确认收款和退款
+func (m Method) IsOffline() bool {
+	return m == MethodCash || m == MethodPayAtCounter
+}
+
+func (m Method) String() string {
+	switch m {
+	case MethodWechatPay:
+		return "微信支付"
+	case MethodAlipay:
+		return "支付宝"
+	case MethodMock:
+		return "模拟支付"
+	case MethodCash:
+		return "现金"
+	case MethodPayAtCounter:
+		return "到店付款"
+	default:
+		return string(m)
+	}
+}
+
+// Status 支付单状态
+type Status string
+
+const (
+	StatusPending   Status = "pending"   // 待支付
+	StatusSucceeded Status = "succeeded" // 支付成功
+	StatusFailed    Status = "failed"    // 支付失败
+	StatusClosed    Status = "closed"    // 已关闭（重新发起支付或订单金额变更）
+)
+
+// RefundStatus 退款状态
+type RefundStatus string
+
+const (
+	// RefundStatusPending 已登记、等待支付网关退回，金额已从可退金额中扣除
+	RefundStatusPending   RefundStatus = "pending"
+	RefundStatusSucceeded RefundStatus = "succeeded"
+	RefundStatusFailed    RefundStatus = "failed"
+)
+
+var (
+	ErrInvalidMethod     = errors.New("无效的支付方式")
+	ErrInvalidSignature  = errors.New("支付回调签名验证失败")
+	ErrAmountMismatch    = errors.New("支付金额与订单金额不一致")
+	ErrNotPending        = errors.New("支付单不是待支付状态")
+	ErrNotSucceeded      = errors.New("支付单未支付成功，不能退款")
+	ErrRefundExceeds     = errors.New("退款金额超过可退金额")
+	ErrGatewayNotEnabled = errors.New("该支付方式未启用")
+)
+
+// Payment 订单支付单
+// 每次发起支付生成一张支付单，线上支付在网关回调后成功，线下支付（现金、到店付款）由店员确认收款后成功
+type Payment struct {
+	ID      shared.ID
+	ShopID  shared.ID
+	OrderID shared.ID
+	UserID  shared.ID
+	Method  Method
+	Amount  shared.Price
+	// RefundedAmount 已退款金额合计
+	RefundedAmount shared.Price
+	Status         Status
+	// ProviderTradeNo 支付网关的交易号，线下支付为空
+	ProviderTradeNo string
+	FailureReason   string
+	// ConfirmedBy 线下支付确认收款的操作人
+	ConfirmedBy string
+	PaidAt      time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Refund 支付单退款记录
+type Refund struct {
+	ID               shared.ID
+	ShopID           shared.ID
+	PaymentID        shared.ID
+	OrderID          shared.ID
+	Amount           shared.Price
+	Reason           string
+	Status           RefundStatus
+	ProviderRefundNo string
+	FailureReason    string
+	Operator         string
+	CreatedAt        time.Time
+}
+
+func NewPayment(shopID, orderID, userID shared.ID, method Method, amount shared.Price) (*Payment, error) {
+	if shopID.IsZero() {
+		return nil, errors.New("店铺ID不能为空")
+	}
+	if orderID.IsZero() {
+		return nil, errors.New("订单ID不能为空")
+	}
+	if !method.IsValid() {
+		return nil, ErrInvalidMethod
+	}
+	if !amount.IsPositive() {
+		return nil, errors.New("支付金额必须大于0")
+	}
+
+	now := time.Now()
+	return &Payment{
+		ID:        shared.ID(utils.GenerateSnowflakeID()),
+		ShopID:    shopID,
+		OrderID:   orderID,
+		UserID:    userID,
+		Method:    method,
+		Amount:    amount,
+		Status:    StatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// Succeed 标记支付成功，支付金额必须与支付单一致
+// 已经成功的支付单重复通知时直接返回 false，保证回调幂等
+func (p *Payment) Succeed(tradeNo string, amount shared.Price, paidAt time.Time) (bool, error) {
+	if p.Status == StatusSucceeded {
+		return false, nil
+	}
+	if amount != p.Amount {
+		return false, fmt.Errorf("%w: 应付 %s 元，实付 %s 元", ErrAmountMismatch, p.Amount, amount)
+	}
+	// 已关闭或失败的支付单仍以网关的成功通知为准，顾客实际已经付款
+	if paidAt.IsZero() {
+		paidAt = time.Now()
+	}
+	p.Status = StatusSucceeded
+	p.ProviderTradeNo = tradeNo
+	p.FailureReason = ""
+	p.PaidAt = paidAt
+	p.UpdatedAt = time.Now()
+	return true, nil
+}
+
+// Fail 标记支付失败，只有待支付的支付单会被修改
+func (p *Payment) Fail(reason string) bool {
+	if p.Status != StatusPending {
+		return false
+	}
+	p.Status = StatusFailed
+	p.FailureReason = strings.TrimSpace(reason)
+	p.UpdatedAt = time.Now()
+	return true
+}
+
+// Refundable 剩余可退金额
+func (p *Payment) Refundable() shared.Price {
+	if p.Status != StatusSucceeded {
+		return 0
+	}
+	return p.Amount.Sub(p.RefundedAmount)
+}
+
+// NewRefund 为支付单创建退款，退款金额不能超过剩余可退金额
+func (p *Payment) NewRefund(amount shared.Price, reason, operator string) (*Refund, error) {
+	if p.Status != StatusSucceeded {
+		return nil, ErrNotSucceeded
+	}
+	if !amount.IsPositive() {
+		return nil, errors.New("退款金额必须大于0")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("退款必须填写原因")
+	}
+	if amount > p.Refundable() {
+		return nil, fmt.Errorf("%w，最多可退 %s 元", ErrRefundExceeds, p.Refundable())
+	}
+
+	return &Refund{
+		ID:        shared.ID(utils.GenerateSnowflakeID()),
+		ShopID:    p.ShopID,
+		PaymentID: p.ID,
+		OrderID:   p.OrderID,
+		Amount:    amount,
+		Reason:    reason,
+		Operator:  operator,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// ApplyRefund 退款成功后累加已退金额
+func (p *Payment) ApplyRefund(r *Refund) {
+	r.Status = RefundStatusSucceeded
+	p.RefundedAmount = p.RefundedAmount.Add(r.Amount)
+	p.UpdatedAt = time.Now()
+}
+
+// ReserveRefund 登记待网关退回的退款，先累加已退金额，避免网关处理期间重复退款超出支付金额
+func (p *Payment) ReserveRefund(r *Refund) {
+	r.Status = RefundStatusPending
+	p.RefundedAmount = p.RefundedAmount.Add(r.Amount)
+	p.UpdatedAt = time.Now()
+}
+
+// Succeed 网关退款成功，已退金额在登记时已经累加
+func (r *Refund) Succeed(providerRefundNo string) {
+	r.Status = RefundStatusSucceeded
+	r.ProviderRefundNo = providerRefundNo
+}
+
+// FailRefund 网关退款失败，退回登记时扣除的可退金额
+func (p *Payment) FailRefund(r *Refund, reason string) {
+	if r.Status != RefundStatusPending {
+		return
+	}
+	r.Status = RefundStatusFailed
+	r.FailureReason = strings.TrimSpace(reason)
+	p.RefundedAmount = p.RefundedAmount.Sub(r.Amount)
+	p.UpdatedAt = time.Now()
+}
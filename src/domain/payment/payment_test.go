@@ -0,0 +1,156 @@
+package payment
+
+import (
+	"testing"
+	"time"
+
+	"orderease/domain/shared"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPayment(t *testing.T, amount float64) *Payment {
+	p, err := NewPayment(shared.ID(1), shared.ID(2), shared.ID(3), MethodMock, shared.NewPrice(amount))
+	require.NoError(t, err)
+	return p
+}
+
+func TestNewPayment(t *testing.T) {
+	tests := []struct {
+		name    string
+		shopID  shared.ID
+		orderID shared.ID
+		method  Method
+		amount  shared.Price
+		wantErr string
+	}{
+		{"valid", 1, 2, MethodWechatPay, shared.NewPrice(10), ""},
+		{"cash", 1, 2, MethodCash, shared.NewPrice(10), ""},
+		{"missing shop", 0, 2, MethodCash, shared.NewPrice(10), "店铺ID不能为空"},
+		{"missing order", 1, 0, MethodCash, shared.NewPrice(10), "订单ID不能为空"},
+		{"invalid method", 1, 2, "bitcoin", shared.NewPrice(10), "无效的支付方式"},
+		{"zero amount", 1, 2, MethodCash, 0, "支付金额必须大于0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPayment(tt.shopID, tt.orderID, 0, tt.method, tt.amount)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.False(t, p.ID.IsZero())
+			assert.Equal(t, StatusPending, p.Status)
+			assert.Equal(t, tt.amount, p.Amount)
+		})
+	}
+}
+
+func TestMethod_IsOffline(t *testing.T) {
+	assert.True(t, MethodCash.IsOffline())
+	assert.True(t, MethodPayAtCounter.IsOffline())
+	assert.False(t, MethodWechatPay.IsOffline())
+	assert.False(t, MethodMock.IsOffline())
+}
+
+func TestPayment_Succeed(t *testing.T) {
+	t.Run("amount mismatch", func(t *testing.T) {
+		p := newTestPayment(t, 20)
+		_, err := p.Succeed("T1", shared.NewPrice(19.99), time.Now())
+		assert.ErrorIs(t, err, ErrAmountMismatch)
+		assert.Equal(t, StatusPending, p.Status)
+	})
+
+	t.Run("duplicate notification is ignored", func(t *testing.T) {
+		p := newTestPayment(t, 20)
+		changed, err := p.Succeed("T1", shared.NewPrice(20), time.Time{})
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, StatusSucceeded, p.Status)
+		assert.Equal(t, "T1", p.ProviderTradeNo)
+		assert.False(t, p.PaidAt.IsZero())
+
+		changed, err = p.Succeed("T1", shared.NewPrice(20), time.Now())
+		require.NoError(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("closed payment still succeeds", func(t *testing.T) {
+		p := newTestPayment(t, 20)
+		p.Status = StatusClosed
+		changed, err := p.Succeed("T1", shared.NewPrice(20), time.Now())
+		require.NoError(t, err)
+		assert.True(t, changed)
+	})
+}
+
+func TestPayment_Fail(t *testing.T) {
+	p := newTestPayment(t, 20)
+	assert.True(t, p.Fail(" 用户取消支付 "))
+	assert.Equal(t, StatusFailed, p.Status)
+	assert.Equal(t, "用户取消支付", p.FailureReason)
+
+	assert.False(t, p.Fail("again"))
+}
+
+func TestPayment_NewRefund(t *testing.T) {
+	p := newTestPayment(t, 20)
+
+	_, err := p.NewRefund(shared.NewPrice(5), "退菜", "owner")
+	assert.ErrorIs(t, err, ErrNotSucceeded)
+
+	_, err = p.Succeed("T1", p.Amount, time.Now())
+	require.NoError(t, err)
+
+	_, err = p.NewRefund(0, "退菜", "owner")
+	assert.EqualError(t, err, "退款金额必须大于0")
+
+	_, err = p.NewRefund(shared.NewPrice(5), "  ", "owner")
+	assert.EqualError(t, err, "退款必须填写原因")
+
+	r, err := p.NewRefund(shared.NewPrice(15), "退菜", "owner")
+	require.NoError(t, err)
+	p.ApplyRefund(r)
+	assert.Equal(t, RefundStatusSucceeded, r.Status)
+	assert.Equal(t, p.ID, r.PaymentID)
+	assert.Equal(t, shared.NewPrice(5), p.Refundable())
+
+	_, err = p.NewRefund(shared.NewPrice(5.01), "退菜", "owner")
+	assert.ErrorIs(t, err, ErrRefundExceeds)
+
+	r, err = p.NewRefund(shared.NewPrice(5), "退菜", "owner")
+	require.NoError(t, err)
+	p.ApplyRefund(r)
+	assert.Equal(t, shared.Price(0), p.Refundable())
+}
+
+func TestPayment_ReserveRefund(t *testing.T) {
+	p := newTestPayment(t, 20)
+	_, err := p.Succeed("T1", p.Amount, time.Now())
+	require.NoError(t, err)
+
+	r, err := p.NewRefund(shared.NewPrice(15), "退菜", "owner")
+	require.NoError(t, err)
+	p.ReserveRefund(r)
+	assert.Equal(t, RefundStatusPending, r.Status)
+	assert.Equal(t, shared.NewPrice(5), p.Refundable(), "待退回的金额不能再次退款")
+
+	p.FailRefund(r, "余额不足")
+	assert.Equal(t, RefundStatusFailed, r.Status)
+	assert.Equal(t, "余额不足", r.FailureReason)
+	assert.Equal(t, shared.NewPrice(20), p.Refundable())
+
+	// 已失败的退款不会重复退回可退金额
+	p.FailRefund(r, "余额不足")
+	assert.Equal(t, shared.NewPrice(20), p.Refundable())
+
+	r, err = p.NewRefund(shared.NewPrice(20), "退菜", "owner")
+	require.NoError(t, err)
+	p.ReserveRefund(r)
+	r.Succeed("R1")
+	assert.Equal(t, RefundStatusSucceeded, r.Status)
+	assert.Equal(t, "R1", r.ProviderRefundNo)
+	assert.Equal(t, shared.Price(0), p.Refundable())
+}
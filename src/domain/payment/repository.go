@@ -0,0 +1,21 @@
+package payment
+
+import (
+	"orderease/domain/shared"
+)
+
+type PaymentRepository interface {
+	Save(p *Payment) error
+	Update(p *Payment) error
+	FindByID(id shared.ID) (*Payment, error)
+	FindByIDAndShopID(id shared.ID, shopID shared.ID) (*Payment, error)
+	FindByOrderID(orderID shared.ID, shopID shared.ID) ([]Payment, error)
+	// CloseByOrderID 关闭订单下所有待支付的支付单
+	CloseByOrderID(orderID shared.ID, shopID shared.ID) error
+}
+
+type RefundRepository interface {
+	Save(r *Refund) error
+	Update(r *Refund) error
+	FindByOrderID(orderID shared.ID, shopID shared.ID) ([]Refund, error)
+}
@@ -0,0 +1,51 @@
+package product
+
+import (
+	"time"
+
+	"orderease/domain/shared"
+)
+
+// StockEventType 库存事件类型，与订单事件共用店铺事件流
+type StockEventType string
+
+const (
+	StockEventLowStock   StockEventType = "product_low_stock"    // 库存降到预警值及以下
+	StockEventOutOfStock StockEventType = "product_out_of_stock" // 库存降为0
+	StockEventRestocked  StockEventType = "product_restocked"    // 从0补货，或回到预警值以上
+)
+
+// StockEvent 商品库存跨越预警值时产生的事件
+type StockEvent struct {
+	Type        StockEventType
+	ShopID      uint64
+	ProductID   shared.ID
+	ProductName string
+	Stock       int
+	Threshold   int
+	Status      ProductStatus // 事件发生后的商品状态，自动上下架时已是新状态
+	OccurredAt  time.Time
+}
+
+// StockEventPublisher 库存事件发布者接口
+type StockEventPublisher interface {
+	PublishStockEvent(event StockEvent)
+}
+
+// DetectStockEvent 根据库存变化前后的数量判断是否跨越了0或预警值
+// 只在跨越时返回事件，库存在预警值以下继续减少不会重复提醒
+func DetectStockEvent(before, after, threshold int) (StockEventType, bool) {
+	switch {
+	case before > 0 && after <= 0:
+		return StockEventOutOfStock, true
+	case before <= 0 && after > 0:
+		return StockEventRestocked, true
+	case threshold <= 0:
+		return "", false
+	case before > threshold && after <= threshold:
+		return StockEventLowStock, true
+	case before <= threshold && after > threshold:
+		return StockEventRestocked, true
+	}
+	return "", false
+}
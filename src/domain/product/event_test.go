@@ -0,0 +1,38 @@
+package product
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectStockEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		before    int
+		after     int
+		threshold int
+		want      StockEventType
+		wantOK    bool
+	}{
+		{"sold out", 2, 0, 5, StockEventOutOfStock, true},
+		{"sold out without threshold", 1, 0, 0, StockEventOutOfStock, true},
+		{"restock from zero", 0, 10, 5, StockEventRestocked, true},
+		{"restock from zero still low", 0, 3, 5, StockEventRestocked, true},
+		{"drop to threshold", 6, 5, 5, StockEventLowStock, true},
+		{"drop below threshold", 10, 2, 5, StockEventLowStock, true},
+		{"already low", 4, 3, 5, "", false},
+		{"back above threshold", 5, 6, 5, StockEventRestocked, true},
+		{"stays above threshold", 20, 10, 5, "", false},
+		{"no threshold", 10, 1, 0, "", false},
+		{"stays zero", 0, 0, 5, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DetectStockEvent(tt.before, tt.after, tt.threshold)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package product
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"orderease/domain/shared"
@@ -56,6 +57,10 @@ type Product struct {
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 	OptionCategories []ProductOptionCategory
+	// LowStockThreshold 库存预警值，库存小于等于该值时提醒补货，0 表示不预警
+	LowStockThreshold int
+	// AutoOffline 因库存为0被自动下架，补货后只自动上架这类商品
+	AutoOffline bool
 }
 
 type ProductOptionCategory struct {
@@ -64,6 +69,8 @@ type ProductOptionCategory struct {
 	Name         string
 	IsRequired   bool
 	IsMultiple   bool
+	MinSelect    int // 多选类别的最少选择数量
+	MaxSelect    int // 多选类别的最多选择数量，0 表示不限
 	DisplayOrder int
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
@@ -74,7 +81,7 @@ type ProductOption struct {
 	ID              shared.ID
 	CategoryID      shared.ID
 	Name            string
-	PriceAdjustment float64
+	PriceAdjustment shared.Price
 	DisplayOrder    int
 	IsDefault       bool
 	CreatedAt       time.Time
@@ -162,6 +169,22 @@ func (p *Product) HasStock(quantity int) bool {
 	return p.Stock >= quantity
 }
 
+func (p *Product) SetLowStockThreshold(threshold int) error {
+	if threshold < 0 {
+		return errors.New("库存预警值不能为负数")
+	}
+	p.LowStockThreshold = threshold
+	return nil
+}
+
+// IsLowStock 库存为0，或设置了预警值且库存不高于预警值
+func (p *Product) IsLowStock() bool {
+	if p.Stock <= 0 {
+		return true
+	}
+	return p.LowStockThreshold > 0 && p.Stock <= p.LowStockThreshold
+}
+
 func NewProductOptionCategory(productID shared.ID, name string, isRequired, isMultiple bool, displayOrder int) (*ProductOptionCategory, error) {
 	if productID.IsZero() {
 		return nil, errors.New("商品ID不能为空")
@@ -185,7 +208,71 @@ func NewProductOptionCategory(productID shared.ID, name string, isRequired, isMu
 	}, nil
 }
 
-func NewProductOption(categoryID shared.ID, name string, priceAdjustment float64, isDefault bool, displayOrder int) (*ProductOption, error) {
+// SetSelectionLimits 设置多选类别的最少和最多选择数量，max 为 0 表示不限
+func (c *ProductOptionCategory) SetSelectionLimits(min, max int) error {
+	if min < 0 || max < 0 {
+		return errors.New("选择数量不能为负数")
+	}
+	if !c.IsMultiple && (min > 0 || max > 0) {
+		return fmt.Errorf("单选类别 %s 不能设置选择数量", c.Name)
+	}
+	if max > 0 && min > max {
+		return fmt.Errorf("类别 %s 的最少选择数量不能大于最多选择数量", c.Name)
+	}
+	c.MinSelect = min
+	c.MaxSelect = max
+	return nil
+}
+
+// RequiredSelections 下单时至少需要选择的数量，必选的类别至少选择一项
+func (c *ProductOptionCategory) RequiredSelections() int {
+	if c.IsRequired && c.MinSelect < 1 {
+		return 1
+	}
+	return c.MinSelect
+}
+
+// MaxSelections 下单时最多可以选择的数量，0 表示不限
+func (c *ProductOptionCategory) MaxSelections() int {
+	if !c.IsMultiple {
+		return 1
+	}
+	return c.MaxSelect
+}
+
+// DefaultOptions 未选择该类别时默认选中的选项，单选类别只取第一个默认选项
+func (c *ProductOptionCategory) DefaultOptions() []ProductOption {
+	var defaults []ProductOption
+	for _, opt := range c.Options {
+		if opt.IsDefault {
+			defaults = append(defaults, opt)
+		}
+	}
+	if max := c.MaxSelections(); max > 0 && len(defaults) > max {
+		defaults = defaults[:max]
+	}
+	return defaults
+}
+
+// ValidateOptions 校验类别的选项设置：默认选项不超过可选数量，选项足够满足最少选择数量
+func (c *ProductOptionCategory) ValidateOptions() error {
+	defaults := 0
+	for _, opt := range c.Options {
+		if opt.IsDefault {
+			defaults++
+		}
+	}
+
+	if max := c.MaxSelections(); max > 0 && defaults > max {
+		return fmt.Errorf("类别 %s 最多只能有 %d 个默认选项", c.Name, max)
+	}
+	if required := c.RequiredSelections(); len(c.Options) < required {
+		return fmt.Errorf("类别 %s 的选项少于最少选择数量 %d", c.Name, required)
+	}
+	return nil
+}
+
+func NewProductOption(categoryID shared.ID, name string, priceAdjustment shared.Price, isDefault bool, displayOrder int) (*ProductOption, error) {
 	if categoryID.IsZero() {
 		return nil, errors.New("类别ID不能为空")
 	}
@@ -113,7 +113,7 @@ func TestNewProductOption(t *testing.T) {
 		name            string
 		categoryID      shared.ID
 		optionName      string
-		priceAdjustment float64
+		priceAdjustment shared.Price
 		isDefault       bool
 		displayOrder    int
 		wantErr         bool
@@ -124,7 +124,7 @@ func TestNewProductOption(t *testing.T) {
 			name:            "valid default option",
 			categoryID:      shared.ID(123),
 			optionName:      "大",
-			priceAdjustment: 5.0,
+			priceAdjustment: shared.NewPrice(5.0),
 			isDefault:       true,
 			displayOrder:    1,
 			wantErr:         false,
@@ -132,7 +132,7 @@ func TestNewProductOption(t *testing.T) {
 				assert.Equal(t, shared.ID(0), o.ID)
 				assert.Equal(t, shared.ID(123), o.CategoryID)
 				assert.Equal(t, "大", o.Name)
-				assert.Equal(t, 5.0, o.PriceAdjustment)
+				assert.Equal(t, shared.NewPrice(5.0), o.PriceAdjustment)
 				assert.True(t, o.IsDefault)
 				assert.Equal(t, 1, o.DisplayOrder)
 				assert.False(t, o.CreatedAt.IsZero())
@@ -143,7 +143,7 @@ func TestNewProductOption(t *testing.T) {
 			name:            "valid non-default option with negative adjustment",
 			categoryID:      shared.ID(456),
 			optionName:      "小",
-			priceAdjustment: -2.5,
+			priceAdjustment: shared.NewPrice(-2.5),
 			isDefault:       false,
 			displayOrder:    0,
 			wantErr:         false,
@@ -152,7 +152,7 @@ func TestNewProductOption(t *testing.T) {
 			name:            "zero price adjustment valid",
 			categoryID:      shared.ID(123),
 			optionName:      "中",
-			priceAdjustment: 0,
+			priceAdjustment: shared.NewPrice(0),
 			isDefault:       false,
 			displayOrder:    2,
 			wantErr:         false,
@@ -161,7 +161,7 @@ func TestNewProductOption(t *testing.T) {
 			name:            "empty categoryID",
 			categoryID:      shared.ID(0),
 			optionName:      "大",
-			priceAdjustment: 5.0,
+			priceAdjustment: shared.NewPrice(5.0),
 			isDefault:       true,
 			displayOrder:    1,
 			wantErr:         true,
@@ -171,7 +171,7 @@ func TestNewProductOption(t *testing.T) {
 			name:            "empty name",
 			categoryID:      shared.ID(123),
 			optionName:      "",
-			priceAdjustment: 5.0,
+			priceAdjustment: shared.NewPrice(5.0),
 			isDefault:       true,
 			displayOrder:    1,
 			wantErr:         true,
@@ -212,7 +212,7 @@ func TestProductOptionCategory_Timestamps(t *testing.T) {
 
 func TestProductOption_Timestamps(t *testing.T) {
 	before := time.Now()
-	option, err := NewProductOption(shared.ID(123), "测试", 1.5, false, 1)
+	option, err := NewProductOption(shared.ID(123), "测试", shared.NewPrice(1.5), false, 1)
 	after := time.Now()
 
 	assert.NoError(t, err)
@@ -221,3 +221,70 @@ func TestProductOption_Timestamps(t *testing.T) {
 	assert.True(t, option.UpdatedAt.After(before) || option.UpdatedAt.Equal(before))
 	assert.True(t, option.UpdatedAt.Before(after) || option.UpdatedAt.Equal(after))
 }
+
+func TestProductOptionCategory_SetSelectionLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		isMultiple bool
+		min        int
+		max        int
+		errMsg     string
+	}{
+		{"multiple with limits", true, 1, 3, ""},
+		{"multiple unlimited", true, 2, 0, ""},
+		{"negative", true, -1, 2, "选择数量不能为负数"},
+		{"single with limits", false, 1, 1, "单选类别 加料 不能设置选择数量"},
+		{"min greater than max", true, 3, 2, "最少选择数量不能大于最多选择数量"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category, err := NewProductOptionCategory(shared.ID(123), "加料", false, tt.isMultiple, 1)
+			assert.NoError(t, err)
+
+			err = category.SetSelectionLimits(tt.min, tt.max)
+			if tt.errMsg != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.min, category.MinSelect)
+			assert.Equal(t, tt.max, category.MaxSelect)
+		})
+	}
+}
+
+func TestProductOptionCategory_ValidateOptions(t *testing.T) {
+	options := func(defaults ...bool) []ProductOption {
+		opts := make([]ProductOption, len(defaults))
+		for i, isDefault := range defaults {
+			opts[i] = ProductOption{ID: shared.ID(i + 1), Name: "选项", IsDefault: isDefault}
+		}
+		return opts
+	}
+
+	tests := []struct {
+		name     string
+		category ProductOptionCategory
+		errMsg   string
+	}{
+		{"single with one default", ProductOptionCategory{Name: "温度", Options: options(true, false)}, ""},
+		{"single with two defaults", ProductOptionCategory{Name: "温度", Options: options(true, true)}, "类别 温度 最多只能有 1 个默认选项"},
+		{"multiple defaults within max", ProductOptionCategory{Name: "加料", IsMultiple: true, MaxSelect: 2, Options: options(true, true, false)}, ""},
+		{"multiple defaults over max", ProductOptionCategory{Name: "加料", IsMultiple: true, MaxSelect: 1, Options: options(true, true)}, "类别 加料 最多只能有 1 个默认选项"},
+		{"required without options", ProductOptionCategory{Name: "温度", IsRequired: true}, "类别 温度 的选项少于最少选择数量 1"},
+		{"not enough options for min", ProductOptionCategory{Name: "加料", IsMultiple: true, MinSelect: 3, Options: options(false, false)}, "类别 加料 的选项少于最少选择数量 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.category.ValidateOptions()
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -26,7 +26,7 @@ func TestNewProduct(t *testing.T) {
 			shopID:      123,
 			productName: "测试商品",
 			description: "这是一个测试商品",
-			price:       shared.Price(99.99),
+			price:       shared.NewPrice(99.99),
 			stock:       100,
 			wantErr:     false,
 			validate: func(t *testing.T, p *Product) {
@@ -34,7 +34,7 @@ func TestNewProduct(t *testing.T) {
 				assert.Equal(t, uint64(123), p.ShopID)
 				assert.Equal(t, "测试商品", p.Name)
 				assert.Equal(t, "这是一个测试商品", p.Description)
-				assert.Equal(t, shared.Price(99.99), p.Price)
+				assert.Equal(t, shared.NewPrice(99.99), p.Price)
 				assert.Equal(t, 100, p.Stock)
 				assert.Equal(t, ProductStatusPending, p.Status)
 				assert.False(t, p.CreatedAt.IsZero())
@@ -46,7 +46,7 @@ func TestNewProduct(t *testing.T) {
 			shopID:      0,
 			productName: "测试商品",
 			description: "描述",
-			price:       shared.Price(99.99),
+			price:       shared.NewPrice(99.99),
 			stock:       100,
 			wantErr:     true,
 			errMsg:      "店铺ID不能为空",
@@ -56,7 +56,7 @@ func TestNewProduct(t *testing.T) {
 			shopID:      123,
 			productName: "",
 			description: "描述",
-			price:       shared.Price(99.99),
+			price:       shared.NewPrice(99.99),
 			stock:       100,
 			wantErr:     true,
 			errMsg:      "商品名称不能为空",
@@ -66,7 +66,7 @@ func TestNewProduct(t *testing.T) {
 			shopID:      123,
 			productName: "测试商品",
 			description: "描述",
-			price:       shared.Price(0),
+			price:       shared.NewPrice(0),
 			stock:       100,
 			wantErr:     true,
 			errMsg:      "商品价格不能为零",
@@ -76,7 +76,7 @@ func TestNewProduct(t *testing.T) {
 			shopID:      123,
 			productName: "测试商品",
 			description: "描述",
-			price:       shared.Price(99.99),
+			price:       shared.NewPrice(99.99),
 			stock:       -1,
 			wantErr:     true,
 			errMsg:      "商品库存不能为负数",
@@ -86,7 +86,7 @@ func TestNewProduct(t *testing.T) {
 			shopID:      123,
 			productName: "测试商品",
 			description: "描述",
-			price:       shared.Price(99.99),
+			price:       shared.NewPrice(99.99),
 			stock:       0,
 			wantErr:     false,
 		},
@@ -95,7 +95,7 @@ func TestNewProduct(t *testing.T) {
 			shopID:      123,
 			productName: "测试商品",
 			description: "",
-			price:       shared.Price(99.99),
+			price:       shared.NewPrice(99.99),
 			stock:       100,
 			wantErr:     false,
 		},
@@ -403,7 +403,7 @@ func TestProduct_HasStock(t *testing.T) {
 func TestProduct_StatusWorkflow(t *testing.T) {
 	// Test typical product status workflow
 	t.Run("typical workflow: pending -> online -> offline -> online", func(t *testing.T) {
-		p, _ := NewProduct(123, "测试商品", "描述", shared.Price(99.99), 100)
+		p, _ := NewProduct(123, "测试商品", "描述", shared.NewPrice(99.99), 100)
 
 		// Initial status should be pending
 		assert.Equal(t, ProductStatusPending, p.Status)
@@ -424,3 +424,31 @@ func TestProduct_StatusWorkflow(t *testing.T) {
 		assert.Equal(t, ProductStatusOnline, p.Status)
 	})
 }
+
+func TestProduct_IsLowStock(t *testing.T) {
+	tests := []struct {
+		name      string
+		stock     int
+		threshold int
+		expected  bool
+	}{
+		{"sold out without threshold", 0, 0, true},
+		{"in stock without threshold", 3, 0, false},
+		{"at threshold", 5, 5, true},
+		{"below threshold", 2, 5, true},
+		{"above threshold", 6, 5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Product{Stock: tt.stock}
+			assert.NoError(t, p.SetLowStockThreshold(tt.threshold))
+			assert.Equal(t, tt.expected, p.IsLowStock())
+		})
+	}
+
+	t.Run("negative threshold", func(t *testing.T) {
+		p := &Product{Stock: 10}
+		assert.EqualError(t, p.SetLowStockThreshold(-1), "库存预警值不能为负数")
+	})
+}
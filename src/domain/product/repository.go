@@ -6,27 +6,28 @@ import (
 
 type ProductRepository interface {
 	Save(product *Product) error
-	FindByID(id shared.ID) (*Product, error)
 	FindByIDAndShopID(id shared.ID, shopID uint64) (*Product, error)
 	FindByShopID(shopID uint64, page, pageSize int, search string, excludeOffline bool) ([]Product, int64, error)
-	FindByIDs(ids []shared.ID) ([]Product, error)
-	Delete(id shared.ID) error
+	FindByIDs(ids []shared.ID, shopID uint64) ([]Product, error)
+	// FindLowStock 查询已售罄或库存不高于预警值的商品，库存少的在前
+	FindLowStock(shopID uint64) ([]Product, error)
+	Delete(id shared.ID, shopID uint64) error
 	Update(product *Product) error
-	CountByProductID(productID shared.ID) (int64, error)
-	FindOptionByID(id shared.ID) (*ProductOption, error)
-	FindOptionCategoryByID(id shared.ID) (*ProductOptionCategory, error)
+	CountByProductID(productID shared.ID, shopID uint64) (int64, error)
+	FindOptionByID(id shared.ID, shopID uint64) (*ProductOption, error)
+	FindOptionCategoryByID(id shared.ID, shopID uint64) (*ProductOptionCategory, error)
 }
 
 type ProductOptionCategoryRepository interface {
 	Save(category *ProductOptionCategory) error
-	FindByID(id shared.ID) (*ProductOptionCategory, error)
+	FindByID(id shared.ID, shopID uint64) (*ProductOptionCategory, error)
 	FindByProductID(productID shared.ID) ([]ProductOptionCategory, error)
 	DeleteByProductID(productID shared.ID) error
 }
 
 type ProductOptionRepository interface {
 	Save(option *ProductOption) error
-	FindByID(id shared.ID) (*ProductOption, error)
+	FindByID(id shared.ID, shopID uint64) (*ProductOption, error)
 	FindByCategoryID(categoryID shared.ID) ([]ProductOption, error)
 	DeleteByCategoryID(categoryID shared.ID) error
 }
@@ -34,6 +35,6 @@ type ProductOptionRepository interface {
 type ProductTagRepository interface {
 	Save(productID shared.ID, tagID int, shopID uint64) error
 	FindByProductID(productID shared.ID) ([]int, error)
-	FindByTagID(tagID int) ([]shared.ID, error)
+	FindByTagID(tagID int, shopID uint64) ([]shared.ID, error)
 	DeleteByProductID(productID shared.ID) error
 }
@@ -0,0 +1,50 @@
+package product
+
+import (
+	"errors"
+	"strings"
+)
+
+// StockMovementType 库存流水类型
+type StockMovementType string
+
+const (
+	StockMovementInitial      StockMovementType = "initial"       // 新建商品时的初始库存
+	StockMovementSale         StockMovementType = "sale"          // 下单扣减
+	StockMovementCancelReturn StockMovementType = "cancel_return" // 取消、删除订单或减少数量时归还
+	StockMovementRefundReturn StockMovementType = "refund_return" // 订单退款时退回的商品重新入库
+	StockMovementAdjustment   StockMovementType = "adjustment"    // 手工增减
+	StockMovementStocktake    StockMovementType = "stocktake"     // 盘点，按实盘数量校正
+	StockMovementImport       StockMovementType = "import"        // 数据导入
+)
+
+func (t StockMovementType) IsValid() bool {
+	switch t {
+	case StockMovementInitial, StockMovementSale, StockMovementCancelReturn, StockMovementRefundReturn,
+		StockMovementAdjustment, StockMovementStocktake, StockMovementImport:
+		return true
+	}
+	return false
+}
+
+// ValidateManualStockChange 校验后台手工提交的库存变更
+// 手工增减的 quantity 为变化量，盘点的 quantity 为实盘数量，两者都必须填写原因
+func ValidateManualStockChange(movementType StockMovementType, quantity int, reason string) error {
+	switch movementType {
+	case StockMovementAdjustment:
+		if quantity == 0 {
+			return errors.New("调整数量不能为0")
+		}
+	case StockMovementStocktake:
+		if quantity < 0 {
+			return errors.New("盘点数量不能为负数")
+		}
+	default:
+		return errors.New("只能手工提交调整或盘点")
+	}
+
+	if strings.TrimSpace(reason) == "" {
+		return errors.New("库存调整必须填写原因")
+	}
+	return nil
+}
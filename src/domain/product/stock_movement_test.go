@@ -0,0 +1,38 @@
+package product
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateManualStockChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		typ      StockMovementType
+		quantity int
+		reason   string
+		wantErr  string
+	}{
+		{"adjustment increase", StockMovementAdjustment, 5, "供应商补货", ""},
+		{"adjustment decrease", StockMovementAdjustment, -2, "破损报废", ""},
+		{"adjustment zero", StockMovementAdjustment, 0, "无变化", "调整数量不能为0"},
+		{"stocktake", StockMovementStocktake, 12, "月末盘点", ""},
+		{"stocktake to zero", StockMovementStocktake, 0, "月末盘点", ""},
+		{"stocktake negative", StockMovementStocktake, -1, "月末盘点", "盘点数量不能为负数"},
+		{"missing reason", StockMovementAdjustment, 3, "  ", "库存调整必须填写原因"},
+		{"sale not manual", StockMovementSale, -1, "下单", "只能手工提交调整或盘点"},
+		{"unknown type", StockMovementType("gift"), 1, "赠送", "只能手工提交调整或盘点"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateManualStockChange(tt.typ, tt.quantity, tt.reason)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
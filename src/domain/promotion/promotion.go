@@ -0,0 +1,249 @@
+package promotion
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"orderease/domain/shared"
+	"orderease/utils"
+)
+
+// DiscountType 优惠计算方式
+type DiscountType string
+
+const (
+	DiscountFixedAmount DiscountType = "fixed_amount" // 立减固定金额
+	DiscountPercentage  DiscountType = "percentage"   // 按比例减免
+	DiscountNthItem     DiscountType = "nth_item"     // 第N件按比例减免，如第二杯半价
+)
+
+func (t DiscountType) IsValid() bool {
+	switch t {
+	case DiscountFixedAmount, DiscountPercentage, DiscountNthItem:
+		return true
+	}
+	return false
+}
+
+var (
+	ErrInactive           = errors.New("优惠已停用")
+	ErrNotStarted         = errors.New("优惠尚未生效")
+	ErrExpired            = errors.New("优惠已过期")
+	ErrUsageLimitReached  = errors.New("优惠已达到使用次数上限")
+	ErrUserLimitReached   = errors.New("已达到每人使用次数上限")
+	ErrNotApplicable      = errors.New("订单中没有适用该优惠的商品")
+	ErrMinSpendNotReached = errors.New("未达到优惠最低消费")
+)
+
+// Promotion 店铺优惠
+// 填写了券码的是优惠券，下单时需要提供券码；券码为空的是自动促销，满足条件的订单自动享受
+type Promotion struct {
+	ID     shared.ID
+	ShopID shared.ID
+	Name   string
+	Code   string
+	Type   DiscountType
+	// Amount 立减金额，仅 fixed_amount 使用
+	Amount shared.Price
+	// Percent 减免比例，20 表示减免 20%（八折），50 配合 NthItem=2 即第二件半价
+	Percent int
+	// NthItem 每满几件减免一件，仅 nth_item 使用
+	NthItem int
+	// MaxDiscount 最高减免金额，0 表示不限
+	MaxDiscount shared.Price
+	// MinSpend 适用商品的最低消费金额，0 表示不限
+	MinSpend shared.Price
+	// UsageLimit 总使用次数，0 表示不限
+	UsageLimit int
+	// PerUserLimit 每个用户可使用次数，0 表示不限
+	PerUserLimit int
+	UsedCount    int
+	// ProductIDs、TagIDs 限定适用商品，都为空时适用全部商品；同时填写时满足其一即可
+	ProductIDs []shared.ID
+	TagIDs     []int
+	// StartAt、EndAt 有效期，零值表示不限
+	StartAt   time.Time
+	EndAt     time.Time
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Line 参与优惠计算的订单行
+type Line struct {
+	ProductID shared.ID
+	TagIDs    []int
+	Quantity  int
+	UnitPrice shared.Price // 含选项加价的单价
+}
+
+func (l Line) Total() shared.Price {
+	return l.UnitPrice.Multiply(l.Quantity)
+}
+
+// NormalizeCode 券码不区分大小写，统一去除空白并转为大写
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func NewPromotion(shopID shared.ID, name string, discountType DiscountType) (*Promotion, error) {
+	if shopID.IsZero() {
+		return nil, errors.New("店铺ID不能为空")
+	}
+
+	now := time.Now()
+
+	p := &Promotion{
+		ID:        shared.ID(utils.GenerateSnowflakeID()),
+		ShopID:    shopID,
+		Name:      strings.TrimSpace(name),
+		Type:      discountType,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	return p, nil
+}
+
+// IsCoupon 是否为需要券码的优惠券
+func (p *Promotion) IsCoupon() bool {
+	return p.Code != ""
+}
+
+// Validate 校验优惠配置
+func (p *Promotion) Validate() error {
+	if p.Name == "" {
+		return errors.New("优惠名称不能为空")
+	}
+	if len(p.Code) > 32 {
+		return errors.New("券码不能超过32个字符")
+	}
+
+	switch p.Type {
+	case DiscountFixedAmount:
+		if !p.Amount.IsPositive() {
+			return errors.New("立减金额必须大于0")
+		}
+	case DiscountPercentage:
+		if p.Percent <= 0 || p.Percent > 100 {
+			return errors.New("减免比例必须在1到100之间")
+		}
+	case DiscountNthItem:
+		if p.NthItem < 2 {
+			return errors.New("第N件优惠的件数必须大于等于2")
+		}
+		if p.Percent <= 0 || p.Percent > 100 {
+			return errors.New("减免比例必须在1到100之间")
+		}
+	default:
+		return errors.New("无效的优惠类型")
+	}
+
+	if p.MinSpend.IsNegative() || p.MaxDiscount.IsNegative() {
+		return errors.New("金额不能为负数")
+	}
+	if p.UsageLimit < 0 || p.PerUserLimit < 0 {
+		return errors.New("使用次数上限不能为负数")
+	}
+	if !p.StartAt.IsZero() && !p.EndAt.IsZero() && !p.EndAt.After(p.StartAt) {
+		return errors.New("结束时间必须晚于开始时间")
+	}
+	return nil
+}
+
+// CheckAvailable 检查优惠在 now 时刻是否可用（启用状态、有效期、总使用次数）
+func (p *Promotion) CheckAvailable(now time.Time) error {
+	if !p.Active {
+		return ErrInactive
+	}
+	if !p.StartAt.IsZero() && now.Before(p.StartAt) {
+		return ErrNotStarted
+	}
+	if !p.EndAt.IsZero() && !now.Before(p.EndAt) {
+		return ErrExpired
+	}
+	if p.UsageLimit > 0 && p.UsedCount >= p.UsageLimit {
+		return ErrUsageLimitReached
+	}
+	return nil
+}
+
+// AppliesTo 订单行是否在优惠的适用范围内
+func (p *Promotion) AppliesTo(line Line) bool {
+	if len(p.ProductIDs) == 0 && len(p.TagIDs) == 0 {
+		return true
+	}
+	for _, id := range p.ProductIDs {
+		if id == line.ProductID {
+			return true
+		}
+	}
+	for _, tagID := range p.TagIDs {
+		for _, lineTagID := range line.TagIDs {
+			if tagID == lineTagID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Calculate 计算优惠对订单行的减免金额，不检查有效期和使用次数
+// 减免金额不超过适用商品的小计，也不超过 MaxDiscount
+func (p *Promotion) Calculate(lines []Line) (shared.Price, error) {
+	var eligible []Line
+	subtotal := shared.Price(0)
+	for _, line := range lines {
+		if line.Quantity > 0 && p.AppliesTo(line) {
+			eligible = append(eligible, line)
+			subtotal = subtotal.Add(line.Total())
+		}
+	}
+	if len(eligible) == 0 {
+		return 0, ErrNotApplicable
+	}
+	if subtotal < p.MinSpend {
+		return 0, fmt.Errorf("%w %s 元，还差 %s 元", ErrMinSpendNotReached, p.MinSpend, p.MinSpend.Sub(subtotal))
+	}
+
+	var discount shared.Price
+	switch p.Type {
+	case DiscountFixedAmount:
+		discount = p.Amount
+	case DiscountPercentage:
+		discount = subtotal.Percent(p.Percent)
+	case DiscountNthItem:
+		discount = nthItemDiscount(eligible, p.NthItem, p.Percent)
+		if discount.IsZero() {
+			return 0, fmt.Errorf("%w：需购买满 %d 件", ErrNotApplicable, p.NthItem)
+		}
+	default:
+		return 0, errors.New("无效的优惠类型")
+	}
+
+	if p.MaxDiscount.IsPositive() {
+		discount = discount.Min(p.MaxDiscount)
+	}
+	return discount.Min(subtotal), nil
+}
+
+// nthItemDiscount 所有适用商品按单价从高到低排列，每满 n 件对第 n 件减免 percent%
+// 减免落在每组中较便宜的一件上，与门店"第二杯半价"的惯例一致
+func nthItemDiscount(lines []Line, n, percent int) shared.Price {
+	var units []shared.Price
+	for _, line := range lines {
+		for i := 0; i < line.Quantity; i++ {
+			units = append(units, line.UnitPrice)
+		}
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i] > units[j] })
+
+	discount := shared.Price(0)
+	for i := n - 1; i < len(units); i += n {
+		discount = discount.Add(units[i].Percent(percent))
+	}
+	return discount
+}
@@ -0,0 +1,167 @@
+package promotion
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"orderease/domain/shared"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeCode(t *testing.T) {
+	assert.Equal(t, "SAVE10", NormalizeCode("  save10 "))
+	assert.Equal(t, "", NormalizeCode("   "))
+}
+
+func TestPromotion_Validate(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		p       Promotion
+		wantErr string
+	}{
+		{"fixed amount", Promotion{Name: "立减5元", Type: DiscountFixedAmount, Amount: shared.NewPrice(5)}, ""},
+		{"percentage", Promotion{Name: "八折", Type: DiscountPercentage, Percent: 20}, ""},
+		{"nth item", Promotion{Name: "第二杯半价", Type: DiscountNthItem, NthItem: 2, Percent: 50}, ""},
+		{"missing name", Promotion{Type: DiscountFixedAmount, Amount: shared.NewPrice(5)}, "优惠名称不能为空"},
+		{"invalid type", Promotion{Name: "x", Type: "gift"}, "无效的优惠类型"},
+		{"zero amount", Promotion{Name: "x", Type: DiscountFixedAmount}, "立减金额必须大于0"},
+		{"percent over 100", Promotion{Name: "x", Type: DiscountPercentage, Percent: 120}, "减免比例必须在1到100之间"},
+		{"nth item below 2", Promotion{Name: "x", Type: DiscountNthItem, NthItem: 1, Percent: 50}, "第N件优惠的件数必须大于等于2"},
+		{"negative min spend", Promotion{Name: "x", Type: DiscountPercentage, Percent: 10, MinSpend: shared.NewPrice(-1)}, "金额不能为负数"},
+		{"negative limit", Promotion{Name: "x", Type: DiscountPercentage, Percent: 10, PerUserLimit: -1}, "使用次数上限不能为负数"},
+		{"end before start", Promotion{Name: "x", Type: DiscountPercentage, Percent: 10, StartAt: now, EndAt: now.Add(-time.Hour)}, "结束时间必须晚于开始时间"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPromotion_CheckAvailable(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		p       Promotion
+		wantErr error
+	}{
+		{"available", Promotion{Active: true}, nil},
+		{"inside window", Promotion{Active: true, StartAt: now.Add(-time.Hour), EndAt: now.Add(time.Hour)}, nil},
+		{"inactive", Promotion{Active: false}, ErrInactive},
+		{"not started", Promotion{Active: true, StartAt: now.Add(time.Hour)}, ErrNotStarted},
+		{"expired", Promotion{Active: true, EndAt: now}, ErrExpired},
+		{"usage limit reached", Promotion{Active: true, UsageLimit: 3, UsedCount: 3}, ErrUsageLimitReached},
+		{"usage limit not reached", Promotion{Active: true, UsageLimit: 3, UsedCount: 2}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, tt.p.CheckAvailable(now))
+		})
+	}
+}
+
+func TestPromotion_Calculate(t *testing.T) {
+	coffee := Line{ProductID: shared.ID(1), TagIDs: []int{10}, Quantity: 2, UnitPrice: shared.NewPrice(18)}
+	latte := Line{ProductID: shared.ID(2), TagIDs: []int{10}, Quantity: 1, UnitPrice: shared.NewPrice(22)}
+	cake := Line{ProductID: shared.ID(3), TagIDs: []int{20}, Quantity: 1, UnitPrice: shared.NewPrice(9.9)}
+	lines := []Line{coffee, latte, cake}
+
+	tests := []struct {
+		name    string
+		p       Promotion
+		lines   []Line
+		want    shared.Price
+		wantErr error
+	}{
+		{
+			name:  "fixed amount",
+			p:     Promotion{Type: DiscountFixedAmount, Amount: shared.NewPrice(5)},
+			lines: lines,
+			want:  shared.NewPrice(5),
+		},
+		{
+			name:  "fixed amount capped at eligible subtotal",
+			p:     Promotion{Type: DiscountFixedAmount, Amount: shared.NewPrice(20), ProductIDs: []shared.ID{3}},
+			lines: lines,
+			want:  shared.NewPrice(9.9),
+		},
+		{
+			name:  "percentage rounds to cents",
+			p:     Promotion{Type: DiscountPercentage, Percent: 15, ProductIDs: []shared.ID{3}},
+			lines: lines,
+			want:  shared.PriceFromCents(149), // 9.90 * 15% = 1.485
+		},
+		{
+			name:  "percentage capped by max discount",
+			p:     Promotion{Type: DiscountPercentage, Percent: 50, MaxDiscount: shared.NewPrice(10)},
+			lines: lines,
+			want:  shared.NewPrice(10),
+		},
+		{
+			name:  "tag scope",
+			p:     Promotion{Type: DiscountPercentage, Percent: 10, TagIDs: []int{10}},
+			lines: lines,
+			want:  shared.NewPrice(5.8), // (36 + 22) * 10%
+		},
+		{
+			name:    "min spend counts only eligible items",
+			p:       Promotion{Type: DiscountFixedAmount, Amount: shared.NewPrice(3), MinSpend: shared.NewPrice(20), TagIDs: []int{20}},
+			lines:   lines,
+			wantErr: ErrMinSpendNotReached,
+		},
+		{
+			name:  "min spend reached",
+			p:     Promotion{Type: DiscountFixedAmount, Amount: shared.NewPrice(10), MinSpend: shared.NewPrice(60)},
+			lines: lines,
+			want:  shared.NewPrice(10),
+		},
+		{
+			name:    "no eligible items",
+			p:       Promotion{Type: DiscountFixedAmount, Amount: shared.NewPrice(3), ProductIDs: []shared.ID{99}},
+			lines:   lines,
+			wantErr: ErrNotApplicable,
+		},
+		{
+			name:  "second item half price discounts the cheaper one",
+			p:     Promotion{Type: DiscountNthItem, NthItem: 2, Percent: 50, TagIDs: []int{10}},
+			lines: lines,
+			want:  shared.NewPrice(9), // 22, 18, 18 -> 第二件 18 半价
+		},
+		{
+			name:  "every second item",
+			p:     Promotion{Type: DiscountNthItem, NthItem: 2, Percent: 50},
+			lines: []Line{{ProductID: shared.ID(1), Quantity: 4, UnitPrice: shared.NewPrice(15)}},
+			want:  shared.NewPrice(15),
+		},
+		{
+			name:    "nth item not enough quantity",
+			p:       Promotion{Type: DiscountNthItem, NthItem: 2, Percent: 50, ProductIDs: []shared.ID{3}},
+			lines:   lines,
+			wantErr: ErrNotApplicable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.p.Calculate(tt.lines)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got error %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package promotion
+
+import (
+	"orderease/domain/shared"
+)
+
+type PromotionRepository interface {
+	Save(promotion *Promotion) error
+	FindByIDAndShopID(id shared.ID, shopID shared.ID) (*Promotion, error)
+	FindByCode(shopID shared.ID, code string) (*Promotion, error)
+	FindByShopID(shopID shared.ID) ([]Promotion, error)
+	// FindAutomatic 查询店铺已启用的自动促销（不含优惠券）
+	FindAutomatic(shopID shared.ID) ([]Promotion, error)
+	Update(promotion *Promotion) error
+	Delete(id shared.ID, shopID shared.ID) error
+}
@@ -1,89 +1,32 @@
 package shared
 
-import (
-	"database/sql/driver"
-	"encoding/json"
-	"fmt"
-	"strconv"
-)
+import "orderease/utils/money"
 
-type Price float64
+// Price 金额，以分为单位保存，实现见 utils/money
+type Price = money.Price
 
-func (p Price) String() string {
-	return fmt.Sprintf("%.2f", p)
-}
-
-func (p *Price) Scan(value interface{}) error {
-	switch v := value.(type) {
-	case float64:
-		*p = Price(v)
-	case int64:
-		*p = Price(float64(v))
-	case []uint8:
-		if f, err := strconv.ParseFloat(string(v), 64); err == nil {
-			*p = Price(f)
-		} else {
-			return fmt.Errorf("failed to parse Price from string: %v", err)
-		}
-	default:
-		return fmt.Errorf("unsupported Scan, storing driver.Value type %T into type *Price", value)
-	}
-	return nil
-}
-
-func (p Price) Value() (driver.Value, error) {
-	return float64(p), nil
-}
-
-func (p *Price) UnmarshalJSON(data []byte) error {
-	var value interface{}
-	if err := json.Unmarshal(data, &value); err != nil {
-		return err
-	}
-
-	switch v := value.(type) {
-	case float64:
-		*p = Price(v)
-	case float32:
-		*p = Price(float64(v))
-	case string:
-		if f, err := strconv.ParseFloat(v, 64); err == nil {
-			*p = Price(f)
-		} else {
-			return fmt.Errorf("invalid price format: %s", v)
-		}
-	case int:
-		*p = Price(float64(v))
-	case int64:
-		*p = Price(float64(v))
-	case int32:
-		*p = Price(float64(v))
-	default:
-		return fmt.Errorf("invalid price type: %T", value)
-	}
-	return nil
-}
-
-func (p Price) ToFloat64() float64 {
-	return float64(p)
-}
+// PriceScale 一元对应的分数
+const PriceScale = money.PriceScale
 
+// NewPrice 按元构造金额，四舍五入到分
 func NewPrice(value float64) Price {
-	return Price(value)
+	return money.NewPrice(value)
 }
 
-func (p Price) Add(other Price) Price {
-	return p + other
+// PriceFromCents 按分构造金额
+func PriceFromCents(cents int64) Price {
+	return money.PriceFromCents(cents)
 }
 
-func (p Price) Multiply(quantity int) Price {
-	return p * Price(quantity)
+// ParsePrice 解析十进制金额字符串
+func ParsePrice(s string) (Price, error) {
+	return money.ParsePrice(s)
 }
 
-func (p Price) IsZero() bool {
-	return p == 0
-}
+// Rate 百分比费率（税率、服务费比例），实现见 utils/money
+type Rate = money.Rate
 
-func (p Price) IsPositive() bool {
-	return p > 0
+// NewRate 按百分数构造费率，如 NewRate(6) 表示 6%
+func NewRate(percent float64) Rate {
+	return money.NewRate(percent)
 }
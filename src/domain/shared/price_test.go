@@ -14,10 +14,14 @@ func TestNewPrice(t *testing.T) {
 		value float64
 		want  Price
 	}{
-		{"positive price", 100.5, Price(100.5)},
-		{"zero price", 0, Price(0)},
-		{"negative price", -50.25, Price(-50.25)},
-		{"large price", 999999.99, Price(999999.99)},
+		{"positive price", 100.5, PriceFromCents(10050)},
+		{"zero price", 0, PriceFromCents(0)},
+		{"negative price", -50.25, PriceFromCents(-5025)},
+		{"large price", 999999.99, PriceFromCents(99999999)},
+		{"round half up", 1.005, PriceFromCents(101)},
+		{"round down", 2.344, PriceFromCents(234)},
+		{"negative round half away from zero", -1.005, PriceFromCents(-101)},
+		{"float noise", 0.1 + 0.2, PriceFromCents(30)},
 	}
 
 	for _, tt := range tests {
@@ -28,6 +32,43 @@ func TestNewPrice(t *testing.T) {
 	}
 }
 
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Price
+		wantErr bool
+	}{
+		{"integer", "29", PriceFromCents(2900), false},
+		{"one decimal", "29.9", PriceFromCents(2990), false},
+		{"two decimals", "29.90", PriceFromCents(2990), false},
+		{"round half up", "0.125", PriceFromCents(13), false},
+		{"round down", "0.124", PriceFromCents(12), false},
+		{"leading dot", ".5", PriceFromCents(50), false},
+		{"negative", "-3.50", PriceFromCents(-350), false},
+		{"plus sign", "+3", PriceFromCents(300), false},
+		{"spaces", " 12.30 ", PriceFromCents(1230), false},
+		{"exponent", "1.5e2", PriceFromCents(15000), false},
+		{"empty", "", 0, true},
+		{"dot only", ".", 0, true},
+		{"letters", "abc", 0, true},
+		{"mixed", "100abc", 0, true},
+		{"two dots", "1.2.3", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePrice(tt.s)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestPrice_Add(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -35,10 +76,10 @@ func TestPrice_Add(t *testing.T) {
 		other Price
 		want  Price
 	}{
-		{"add positive", Price(100), Price(50), Price(150)},
-		{"add zero", Price(100), Price(0), Price(100)},
-		{"add negative", Price(100), Price(-30), Price(70)},
-		{"add decimals", Price(100.5), Price(50.25), Price(150.75)},
+		{"add positive", NewPrice(100), NewPrice(50), NewPrice(150)},
+		{"add zero", NewPrice(100), NewPrice(0), NewPrice(100)},
+		{"add negative", NewPrice(100), NewPrice(-30), NewPrice(70)},
+		{"add decimals", NewPrice(100.5), NewPrice(50.25), NewPrice(150.75)},
 	}
 
 	for _, tt := range tests {
@@ -56,11 +97,11 @@ func TestPrice_Multiply(t *testing.T) {
 		quantity int
 		want     Price
 	}{
-		{"multiply positive", Price(100), 2, Price(200)},
-		{"multiply by zero", Price(100), 0, Price(0)},
-		{"multiply by one", Price(100), 1, Price(100)},
-		{"multiply negative", Price(100), -3, Price(-300)},
-		{"multiply decimals", Price(10.5), 3, Price(31.5)},
+		{"multiply positive", NewPrice(100), 2, NewPrice(200)},
+		{"multiply by zero", NewPrice(100), 0, NewPrice(0)},
+		{"multiply by one", NewPrice(100), 1, NewPrice(100)},
+		{"multiply negative", NewPrice(100), -3, NewPrice(-300)},
+		{"multiply decimals", NewPrice(10.5), 3, NewPrice(31.5)},
 	}
 
 	for _, tt := range tests {
@@ -71,15 +112,109 @@ func TestPrice_Multiply(t *testing.T) {
 	}
 }
 
+func TestPrice_Percent(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       Price
+		percent int
+		want    Price
+	}{
+		{"half", NewPrice(15), 50, NewPrice(7.5)},
+		{"round half up", PriceFromCents(999), 15, PriceFromCents(150)},
+		{"round down", PriceFromCents(333), 10, PriceFromCents(33)},
+		{"full", NewPrice(29.9), 100, NewPrice(29.9)},
+		{"zero percent", NewPrice(29.9), 0, 0},
+		{"negative", PriceFromCents(-999), 15, PriceFromCents(-150)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.p.Percent(tt.percent))
+		})
+	}
+}
+
+func TestPrice_Prorate(t *testing.T) {
+	tests := []struct {
+		name  string
+		p     Price
+		part  int64
+		whole int64
+		want  Price
+	}{
+		{"one of three", NewPrice(30), 1, 3, NewPrice(10)},
+		{"round half up", PriceFromCents(1000), 1, 3, PriceFromCents(333)},
+		{"two of three", PriceFromCents(1000), 2, 3, PriceFromCents(667)},
+		{"whole", NewPrice(29.9), 7, 7, NewPrice(29.9)},
+		{"by amount", NewPrice(90), NewPrice(12).Cents(), NewPrice(100).Cents(), NewPrice(10.8)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.p.Prorate(tt.part, tt.whole))
+		})
+	}
+}
+
+func TestPrice_ApplyRate(t *testing.T) {
+	tests := []struct {
+		name         string
+		p            Price
+		rate         Rate
+		wantExcluded Price
+		wantIncluded Price
+	}{
+		{"six percent", NewPrice(100), NewRate(6), NewPrice(6), PriceFromCents(566)},                 // 100 / 1.06 * 0.06 = 5.66
+		{"two decimal rate", NewPrice(100), NewRate(8.25), PriceFromCents(825), PriceFromCents(762)}, // 100 / 1.0825 * 0.0825 = 7.62
+		{"rounds to cents", PriceFromCents(1999), NewRate(13), PriceFromCents(260), PriceFromCents(230)},
+		{"zero rate", NewPrice(100), 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantExcluded, tt.p.ApplyRate(tt.rate))
+			assert.Equal(t, tt.wantIncluded, tt.p.IncludedRate(tt.rate))
+		})
+	}
+}
+
+func TestRate_JSON(t *testing.T) {
+	var r Rate
+	assert.NoError(t, json.Unmarshal([]byte(`8.25`), &r))
+	assert.Equal(t, Rate(825), r)
+	assert.NoError(t, json.Unmarshal([]byte(`"6"`), &r))
+	assert.Equal(t, NewRate(6), r)
+
+	data, err := json.Marshal(NewRate(8.25))
+	assert.NoError(t, err)
+	assert.Equal(t, `8.25`, string(data))
+
+	assert.True(t, NewRate(100).IsValid())
+	assert.False(t, NewRate(100.01).IsValid())
+	assert.False(t, NewRate(-1).IsValid())
+}
+
+func TestPrice_NoFloatDrift(t *testing.T) {
+	// 浮点累加 9.9 + 9.9 + 10.1 得到 29.900000000000002
+	total := NewPrice(9.9).Add(NewPrice(9.9)).Add(NewPrice(10.1))
+	assert.Equal(t, "29.90", total.String())
+
+	sum := Price(0)
+	for i := 0; i < 10; i++ {
+		sum = sum.Add(NewPrice(0.1))
+	}
+	assert.Equal(t, NewPrice(1), sum)
+}
+
 func TestPrice_IsZero(t *testing.T) {
 	tests := []struct {
 		name string
 		p    Price
 		want bool
 	}{
-		{"zero price", Price(0), true},
-		{"positive price", Price(100), false},
-		{"negative price", Price(-50), false},
+		{"zero price", NewPrice(0), true},
+		{"positive price", NewPrice(100), false},
+		{"negative price", NewPrice(-50), false},
 	}
 
 	for _, tt := range tests {
@@ -96,9 +231,9 @@ func TestPrice_IsPositive(t *testing.T) {
 		p    Price
 		want bool
 	}{
-		{"positive price", Price(100), true},
-		{"zero price", Price(0), false},
-		{"negative price", Price(-50), false},
+		{"positive price", NewPrice(100), true},
+		{"zero price", NewPrice(0), false},
+		{"negative price", NewPrice(-50), false},
 	}
 
 	for _, tt := range tests {
@@ -111,13 +246,13 @@ func TestPrice_IsPositive(t *testing.T) {
 
 func TestPrice_ToFloat64(t *testing.T) {
 	tests := []struct {
-		name  string
-		p     Price
-		want  float64
+		name string
+		p    Price
+		want float64
 	}{
-		{"positive", Price(100.5), 100.5},
-		{"zero", Price(0), 0},
-		{"negative", Price(-50.25), -50.25},
+		{"positive", NewPrice(100.5), 100.5},
+		{"zero", NewPrice(0), 0},
+		{"negative", NewPrice(-50.25), -50.25},
 	}
 
 	for _, tt := range tests {
@@ -134,12 +269,13 @@ func TestPrice_String(t *testing.T) {
 		p    Price
 		want string
 	}{
-		{"integer", Price(100), "100.00"},
-		{"decimal", Price(100.5), "100.50"},
-		{"two decimals", Price(100.56), "100.56"},
-		{"more decimals", Price(100.567), "100.57"},
-		{"zero", Price(0), "0.00"},
-		{"negative", Price(-50.5), "-50.50"},
+		{"integer", NewPrice(100), "100.00"},
+		{"decimal", NewPrice(100.5), "100.50"},
+		{"two decimals", NewPrice(100.56), "100.56"},
+		{"more decimals", NewPrice(100.567), "100.57"},
+		{"zero", NewPrice(0), "0.00"},
+		{"negative", NewPrice(-50.5), "-50.50"},
+		{"negative cents", NewPrice(-0.05), "-0.05"},
 	}
 
 	for _, tt := range tests {
@@ -159,22 +295,21 @@ func TestPrice_UnmarshalJSON(t *testing.T) {
 		errMsg  string
 	}{
 		// 有效类型
-		{"valid float64", `100.5`, Price(100.5), false, ""},
-		{"valid float32", `100.5`, Price(100.5), false, ""},
-		{"valid string", `"100.5"`, Price(100.5), false, ""},
-		{"valid int", `100`, Price(100), false, ""},
-		{"valid int64", `100`, Price(100), false, ""},
-		{"valid int32", `100`, Price(100), false, ""},
-		{"zero float64", `0`, Price(0), false, ""},
-		{"zero string", `"0"`, Price(0), false, ""},
+		{"valid decimal", `100.5`, NewPrice(100.5), false, ""},
+		{"valid string", `"100.5"`, NewPrice(100.5), false, ""},
+		{"valid int", `100`, NewPrice(100), false, ""},
+		{"valid exponent", `1e2`, NewPrice(100), false, ""},
+		{"round half up", `29.905`, NewPrice(29.91), false, ""},
+		{"zero number", `0`, NewPrice(0), false, ""},
+		{"zero string", `"0"`, NewPrice(0), false, ""},
 		// 无效类型
-		{"invalid type - bool", `true`, Price(0), true, "invalid price type"},
-		{"invalid type - object", `{}`, Price(0), true, "invalid price type"},
-		{"invalid type - array", `[]`, Price(0), true, "invalid price type"},
-		{"invalid type - null", `null`, Price(0), true, "invalid price type"},
+		{"invalid type - bool", `true`, NewPrice(0), true, "invalid price type"},
+		{"invalid type - object", `{}`, NewPrice(0), true, "invalid price type"},
+		{"invalid type - array", `[]`, NewPrice(0), true, "invalid price type"},
+		{"invalid type - null", `null`, NewPrice(0), true, "invalid price type"},
 		// 无效字符串格式
-		{"invalid string - letters", `"abc"`, Price(0), true, "invalid price format"},
-		{"invalid string - mixed", `"100abc"`, Price(0), true, "invalid price format"},
+		{"invalid string - letters", `"abc"`, NewPrice(0), true, "invalid price format"},
+		{"invalid string - mixed", `"100abc"`, NewPrice(0), true, "invalid price format"},
 	}
 
 	for _, tt := range tests {
@@ -203,17 +338,17 @@ func TestPrice_Scan(t *testing.T) {
 		errMsg  string
 	}{
 		// 有效类型
-		{"float64", float64(100.5), Price(100.5), false, ""},
-		{"int64", int64(100), Price(100), false, ""},
-		{"[]uint8 valid", []uint8("100.5"), Price(100.5), false, ""},
-		{"[]uint8 zero", []uint8("0"), Price(0), false, ""},
-		{"[]uint8 negative", []uint8("-50.25"), Price(-50.25), false, ""},
+		{"float64", float64(100.5), NewPrice(100.5), false, ""},
+		{"int64", int64(100), NewPrice(100), false, ""},
+		{"[]uint8 decimal", []uint8("100.50"), NewPrice(100.5), false, ""},
+		{"[]uint8 zero", []uint8("0.00"), NewPrice(0), false, ""},
+		{"[]uint8 negative", []uint8("-50.25"), NewPrice(-50.25), false, ""},
+		{"string", "29.90", NewPrice(29.9), false, ""},
 		// 无效类型
-		{"unsupported type - string", "100.5", Price(0), true, "unsupported Scan"},
-		{"unsupported type - bool", true, Price(0), true, "unsupported Scan"},
-		{"unsupported type - nil", nil, Price(0), true, "unsupported Scan"},
+		{"unsupported type - bool", true, NewPrice(0), true, "unsupported Scan"},
+		{"unsupported type - nil", nil, NewPrice(0), true, "unsupported Scan"},
 		// 无效字符串格式
-		{"[]uint8 invalid format", []uint8("abc"), Price(0), true, "failed to parse Price"},
+		{"[]uint8 invalid format", []uint8("abc"), NewPrice(0), true, "failed to parse Price"},
 	}
 
 	for _, tt := range tests {
@@ -239,9 +374,9 @@ func TestPrice_Value(t *testing.T) {
 		want    driver.Value
 		wantErr bool
 	}{
-		{"positive", Price(100.5), float64(100.5), false},
-		{"zero", Price(0), float64(0), false},
-		{"negative", Price(-50.25), float64(-50.25), false},
+		{"positive", NewPrice(100.5), "100.50", false},
+		{"zero", NewPrice(0), "0.00", false},
+		{"negative", NewPrice(-50.25), "-50.25", false},
 	}
 
 	for _, tt := range tests {
@@ -261,11 +396,12 @@ func TestPrice_JSONMarshalUnmarshal(t *testing.T) {
 	tests := []struct {
 		name  string
 		price Price
+		json  string
 	}{
-		{"positive", Price(100.5)},
-		{"zero", Price(0)},
-		{"negative", Price(-50.25)},
-		{"large", Price(999999.99)},
+		{"positive", NewPrice(100.5), "100.50"},
+		{"zero", NewPrice(0), "0.00"},
+		{"negative", NewPrice(-50.25), "-50.25"},
+		{"large", NewPrice(999999.99), "999999.99"},
 	}
 
 	for _, tt := range tests {
@@ -273,6 +409,7 @@ func TestPrice_JSONMarshalUnmarshal(t *testing.T) {
 			// Marshal
 			data, err := json.Marshal(tt.price)
 			assert.NoError(t, err)
+			assert.Equal(t, tt.json, string(data))
 
 			// Unmarshal
 			var got Price
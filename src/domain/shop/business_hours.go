@@ -0,0 +1,289 @@
+package shop
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultTimezone 未设置时区的店铺使用的时区
+const DefaultTimezone = "Asia/Shanghai"
+
+// businessHoursLookahead 计算下次营业时间时最多向后查找的天数
+const businessHoursLookahead = 366
+
+const specialDateLayout = "2006-01-02"
+
+var (
+	ErrOrderingPaused = errors.New("店铺已暂停接单")
+	ErrShopClosed     = errors.New("店铺当前不在营业时间")
+)
+
+// TimeRange 一个营业时段，时间格式为 HH:MM，结束时间可以是 24:00
+// 结束时间不晚于开始时间表示跨过午夜，在次日结束
+type TimeRange struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// DailyHours 每周某一天的营业时段，Weekday 0 表示周日
+type DailyHours struct {
+	Weekday time.Weekday `json:"weekday"`
+	Periods []TimeRange  `json:"periods"`
+}
+
+// SpecialHours 节假日、临时休息等特殊日期的营业安排，覆盖当天的每周营业时间
+type SpecialHours struct {
+	Date    string      `json:"date"` // YYYY-MM-DD，按店铺时区
+	Closed  bool        `json:"closed"`
+	Periods []TimeRange `json:"periods"`
+	Note    string      `json:"note"`
+}
+
+// BusinessHours 店铺营业时间
+// 未设置每周营业时间时视为全天营业；设置后未列出的星期几不营业
+type BusinessHours struct {
+	Weekly  []DailyHours   `json:"weekly"`
+	Special []SpecialHours `json:"special"`
+}
+
+// ParseBusinessHours 解析店铺保存的营业时间 JSON，为空时返回全天营业
+func ParseBusinessHours(raw string) (BusinessHours, error) {
+	var hours BusinessHours
+	if raw == "" || raw == "null" {
+		return hours, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &hours); err != nil {
+		return BusinessHours{}, fmt.Errorf("营业时间格式错误: %v", err)
+	}
+	return hours, nil
+}
+
+// parseClock 解析 HH:MM，返回当天的分钟数
+func parseClock(value string, allowEndOfDay bool) (int, error) {
+	if allowEndOfDay && value == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("营业时间 %s 格式错误，应为 HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (r TimeRange) minutes() (open, close int, err error) {
+	if open, err = parseClock(r.Open, false); err != nil {
+		return 0, 0, err
+	}
+	if close, err = parseClock(r.Close, true); err != nil {
+		return 0, 0, err
+	}
+	return open, close, nil
+}
+
+func validatePeriods(periods []TimeRange) error {
+	for _, period := range periods {
+		open, close, err := period.minutes()
+		if err != nil {
+			return err
+		}
+		if open == close {
+			return fmt.Errorf("营业时段 %s-%s 的开始和结束时间不能相同", period.Open, period.Close)
+		}
+	}
+	return nil
+}
+
+// Validate 校验营业时间设置
+func (h BusinessHours) Validate() error {
+	weekdays := make(map[time.Weekday]bool, len(h.Weekly))
+	for _, day := range h.Weekly {
+		if day.Weekday < time.Sunday || day.Weekday > time.Saturday {
+			return fmt.Errorf("无效的星期: %d", day.Weekday)
+		}
+		if weekdays[day.Weekday] {
+			return fmt.Errorf("星期 %d 的营业时间重复设置", day.Weekday)
+		}
+		weekdays[day.Weekday] = true
+		if err := validatePeriods(day.Periods); err != nil {
+			return err
+		}
+	}
+
+	dates := make(map[string]bool, len(h.Special))
+	for _, special := range h.Special {
+		if _, err := time.Parse(specialDateLayout, special.Date); err != nil {
+			return fmt.Errorf("特殊日期 %s 格式错误，应为 YYYY-MM-DD", special.Date)
+		}
+		if dates[special.Date] {
+			return fmt.Errorf("特殊日期 %s 重复设置", special.Date)
+		}
+		dates[special.Date] = true
+		if special.Closed && len(special.Periods) > 0 {
+			return fmt.Errorf("特殊日期 %s 为休息日，不能设置营业时段", special.Date)
+		}
+		if !special.Closed && len(special.Periods) == 0 {
+			return fmt.Errorf("特殊日期 %s 未设置营业时段", special.Date)
+		}
+		if err := validatePeriods(special.Periods); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rangesOn 某一天的营业时段，特殊日期优先于每周营业时间
+func (h BusinessHours) rangesOn(day time.Time) []TimeRange {
+	date := day.Format(specialDateLayout)
+	for _, special := range h.Special {
+		if special.Date == date {
+			if special.Closed {
+				return nil
+			}
+			return special.Periods
+		}
+	}
+
+	if len(h.Weekly) == 0 {
+		return []TimeRange{{Open: "00:00", Close: "24:00"}}
+	}
+	for _, daily := range h.Weekly {
+		if daily.Weekday == day.Weekday() {
+			return daily.Periods
+		}
+	}
+	return nil
+}
+
+type openPeriod struct {
+	start time.Time
+	end   time.Time
+}
+
+// periodsOn 某一天开始的营业时段，跨午夜的时段在次日结束
+func (h BusinessHours) periodsOn(day time.Time) []openPeriod {
+	ranges := h.rangesOn(day)
+	periods := make([]openPeriod, 0, len(ranges))
+	for _, r := range ranges {
+		open, close, err := r.minutes()
+		if err != nil || open == close {
+			continue
+		}
+		closeDay := day.Day()
+		if close < open {
+			closeDay++
+		}
+		periods = append(periods, openPeriod{
+			start: time.Date(day.Year(), day.Month(), day.Day(), open/60, open%60, 0, 0, day.Location()),
+			end:   time.Date(day.Year(), day.Month(), closeDay, close/60, close%60, 0, 0, day.Location()),
+		})
+	}
+	return periods
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// IsOpenAt 指定时间是否在营业时段内，时间按 t 所在时区计算
+func (h BusinessHours) IsOpenAt(t time.Time) bool {
+	today := startOfDay(t)
+	// 前一天跨午夜的时段可能延续到今天
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		for _, period := range h.periodsOn(day) {
+			if !t.Before(period.start) && t.Before(period.end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NextOpenAt 指定时间之后最近的营业开始时间，当前正在营业时返回 t 本身
+// 一年内都没有营业时段时返回 false
+func (h BusinessHours) NextOpenAt(t time.Time) (time.Time, bool) {
+	if h.IsOpenAt(t) {
+		return t, true
+	}
+
+	today := startOfDay(t)
+	for i := 0; i <= businessHoursLookahead; i++ {
+		var next time.Time
+		for _, period := range h.periodsOn(today.AddDate(0, 0, i)) {
+			if period.start.After(t) && (next.IsZero() || period.start.Before(next)) {
+				next = period.start
+			}
+		}
+		if !next.IsZero() {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Location 店铺所在时区，营业时间按该时区计算
+func (s *Shop) Location() *time.Location {
+	timezone := s.Timezone
+	if timezone == "" {
+		timezone = DefaultTimezone
+	}
+	if loc, err := time.LoadLocation(timezone); err == nil {
+		return loc
+	}
+	return time.FixedZone(DefaultTimezone, 8*60*60)
+}
+
+// SetTimezone 设置店铺时区，使用 IANA 时区名称，如 Asia/Shanghai
+func (s *Shop) SetTimezone(timezone string) error {
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "" || timezone == "Local" {
+		return fmt.Errorf("无效的时区: %s", timezone)
+	}
+	s.Timezone = timezone
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateBusinessHours 修改营业时间
+func (s *Shop) UpdateBusinessHours(hours BusinessHours) error {
+	if err := hours.Validate(); err != nil {
+		return err
+	}
+	s.BusinessHours = hours
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// SetOrderingPaused 手动暂停或恢复接单，暂停期间不受营业时间影响一律不接单
+func (s *Shop) SetOrderingPaused(paused bool) {
+	s.OrderingPaused = paused
+	s.UpdatedAt = time.Now()
+}
+
+// IsOpen 当前是否接单：未暂停接单且在营业时间内
+func (s *Shop) IsOpen(now time.Time) bool {
+	return !s.OrderingPaused && s.BusinessHours.IsOpenAt(now.In(s.Location()))
+}
+
+// NextOpenAt 下次开始接单的时间，正在接单时返回 now
+// 暂停接单时无法确定恢复时间，返回 false
+func (s *Shop) NextOpenAt(now time.Time) (time.Time, bool) {
+	if s.OrderingPaused {
+		return time.Time{}, false
+	}
+	return s.BusinessHours.NextOpenAt(now.In(s.Location()))
+}
+
+// CheckAcceptingOrders 店铺暂停接单或不在营业时间内时返回错误
+func (s *Shop) CheckAcceptingOrders(now time.Time) error {
+	if s.OrderingPaused {
+		return ErrOrderingPaused
+	}
+	if s.IsOpen(now) {
+		return nil
+	}
+	if next, ok := s.NextOpenAt(now); ok {
+		return fmt.Errorf("%w，下次营业时间 %s", ErrShopClosed, next.Format("2006-01-02 15:04"))
+	}
+	return ErrShopClosed
+}
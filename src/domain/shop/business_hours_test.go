@@ -0,0 +1,154 @@
+package shop
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBusinessHours() BusinessHours {
+	return BusinessHours{
+		Weekly: []DailyHours{
+			{Weekday: time.Monday, Periods: []TimeRange{{Open: "10:00", Close: "14:00"}, {Open: "17:00", Close: "22:00"}}},
+			{Weekday: time.Friday, Periods: []TimeRange{{Open: "18:00", Close: "02:00"}}},
+		},
+		Special: []SpecialHours{
+			{Date: "2025-01-13", Closed: true, Note: "店休"},
+			{Date: "2025-01-14", Periods: []TimeRange{{Open: "12:00", Close: "24:00"}}},
+		},
+	}
+}
+
+func TestBusinessHours_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		hours  BusinessHours
+		errMsg string
+	}{
+		{"valid", testBusinessHours(), ""},
+		{"empty means always open", BusinessHours{}, ""},
+		{"invalid weekday", BusinessHours{Weekly: []DailyHours{{Weekday: 7}}}, "无效的星期: 7"},
+		{"duplicate weekday", BusinessHours{Weekly: []DailyHours{{Weekday: 1}, {Weekday: 1}}}, "星期 1 的营业时间重复设置"},
+		{"invalid clock", BusinessHours{Weekly: []DailyHours{{Weekday: 1, Periods: []TimeRange{{Open: "9点", Close: "18:00"}}}}}, "营业时间 9点 格式错误，应为 HH:MM"},
+		{"24:00 only as close", BusinessHours{Weekly: []DailyHours{{Weekday: 1, Periods: []TimeRange{{Open: "24:00", Close: "02:00"}}}}}, "营业时间 24:00 格式错误，应为 HH:MM"},
+		{"empty period", BusinessHours{Weekly: []DailyHours{{Weekday: 1, Periods: []TimeRange{{Open: "10:00", Close: "10:00"}}}}}, "营业时段 10:00-10:00 的开始和结束时间不能相同"},
+		{"invalid date", BusinessHours{Special: []SpecialHours{{Date: "2025/01/01", Closed: true}}}, "特殊日期 2025/01/01 格式错误，应为 YYYY-MM-DD"},
+		{"duplicate date", BusinessHours{Special: []SpecialHours{{Date: "2025-01-01", Closed: true}, {Date: "2025-01-01", Closed: true}}}, "特殊日期 2025-01-01 重复设置"},
+		{"closed with periods", BusinessHours{Special: []SpecialHours{{Date: "2025-01-01", Closed: true, Periods: []TimeRange{{Open: "10:00", Close: "12:00"}}}}}, "特殊日期 2025-01-01 为休息日，不能设置营业时段"},
+		{"open without periods", BusinessHours{Special: []SpecialHours{{Date: "2025-01-01"}}}, "特殊日期 2025-01-01 未设置营业时段"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.hours.Validate()
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBusinessHours_IsOpenAt(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, time.January, day, hour, minute, 0, 0, loc)
+	}
+
+	hours := testBusinessHours()
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"monday lunch", at(6, 10, 0), true},
+		{"monday lunch closing time", at(6, 14, 0), false},
+		{"monday afternoon break", at(6, 15, 30), false},
+		{"monday dinner", at(6, 21, 59), true},
+		{"tuesday not listed", at(7, 12, 0), false},
+		{"friday overnight", at(10, 23, 0), true},
+		{"saturday early morning from friday", at(11, 1, 30), true},
+		{"saturday after overnight period", at(11, 2, 0), false},
+		{"special closed monday", at(13, 12, 0), false},
+		{"special open tuesday", at(14, 23, 59), true},
+		{"special tuesday morning", at(14, 11, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, hours.IsOpenAt(tt.t))
+		})
+	}
+
+	assert.True(t, BusinessHours{}.IsOpenAt(at(7, 3, 0)), "未设置营业时间时全天营业")
+}
+
+func TestBusinessHours_NextOpenAt(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, time.January, day, hour, minute, 0, 0, loc)
+	}
+
+	hours := testBusinessHours()
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{"open now", at(6, 11, 0), at(6, 11, 0)},
+		{"before opening", at(6, 8, 0), at(6, 10, 0)},
+		{"afternoon break", at(6, 15, 0), at(6, 17, 0)},
+		{"after closing goes to friday", at(6, 23, 0), at(10, 18, 0)},
+		{"skips special closed day", at(11, 3, 0), at(14, 12, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, ok := hours.NextOpenAt(tt.t)
+			assert.True(t, ok)
+			assert.True(t, tt.want.Equal(next), "want %s, got %s", tt.want, next)
+		})
+	}
+
+	neverOpen := BusinessHours{Weekly: []DailyHours{{Weekday: time.Monday}}}
+	_, ok := neverOpen.NextOpenAt(at(6, 12, 0))
+	assert.False(t, ok)
+}
+
+func TestShop_CheckAcceptingOrders(t *testing.T) {
+	// 2025-01-06 周一 09:00（上海）= 01:00 UTC
+	now := time.Date(2025, time.January, 6, 1, 0, 0, 0, time.UTC)
+
+	s := &Shop{Timezone: "Asia/Shanghai", BusinessHours: testBusinessHours()}
+	assert.False(t, s.IsOpen(now))
+	err := s.CheckAcceptingOrders(now)
+	assert.ErrorIs(t, err, ErrShopClosed)
+	assert.EqualError(t, err, "店铺当前不在营业时间，下次营业时间 2025-01-06 10:00")
+
+	// 同一时刻在 UTC 时区的店铺看来是周一 01:00，下次营业时间按店铺时区显示
+	s.Timezone = "UTC"
+	assert.EqualError(t, s.CheckAcceptingOrders(now), "店铺当前不在营业时间，下次营业时间 2025-01-06 10:00")
+	assert.NoError(t, s.CheckAcceptingOrders(now.Add(10*time.Hour)))
+
+	s.SetOrderingPaused(true)
+	assert.ErrorIs(t, s.CheckAcceptingOrders(now.Add(10*time.Hour)), ErrOrderingPaused)
+	_, ok := s.NextOpenAt(now)
+	assert.False(t, ok)
+}
+
+func TestShop_SetTimezone(t *testing.T) {
+	s := &Shop{}
+	assert.Equal(t, DefaultTimezone, s.Location().String())
+
+	assert.NoError(t, s.SetTimezone("Europe/London"))
+	assert.Equal(t, "Europe/London", s.Location().String())
+
+	assert.EqualError(t, s.SetTimezone("Mars/Base"), "无效的时区: Mars/Base")
+	assert.EqualError(t, s.SetTimezone(""), "无效的时区: ")
+	assert.Equal(t, "Europe/London", s.Timezone)
+}
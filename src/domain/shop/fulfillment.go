@@ -0,0 +1,51 @@
+package shop
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"orderease/domain/order"
+	"orderease/domain/shared"
+)
+
+// fulfillmentSettingsJSON Settings 中 "fulfillment" 字段的格式
+type fulfillmentSettingsJSON struct {
+	Types              []order.FulfillmentType `json:"types"`
+	DeliveryMinAmount  shared.Price            `json:"delivery_min_amount"`
+	DeliveryFee        shared.Price            `json:"delivery_fee"`
+	FreeDeliveryAmount shared.Price            `json:"free_delivery_amount"`
+}
+
+// ParseFulfillmentSettings 从店铺设置 JSON 的 "fulfillment" 字段解析取餐方式和配送费用设置
+// 未配置 fulfillment 时支持全部取餐方式，配送不设起送金额也不收配送费
+func ParseFulfillmentSettings(settings string) (order.FulfillmentSettings, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(settings), &fields); err != nil {
+		return order.FulfillmentSettings{}, nil
+	}
+	raw, ok := fields["fulfillment"]
+	if !ok || string(raw) == "null" {
+		return order.FulfillmentSettings{}, nil
+	}
+
+	var fulfillment fulfillmentSettingsJSON
+	if err := json.Unmarshal(raw, &fulfillment); err != nil {
+		return order.FulfillmentSettings{}, fmt.Errorf("店铺取餐方式设置格式错误: %v", err)
+	}
+
+	result := order.FulfillmentSettings{
+		Types:              fulfillment.Types,
+		DeliveryMinAmount:  fulfillment.DeliveryMinAmount,
+		DeliveryFee:        fulfillment.DeliveryFee,
+		FreeDeliveryAmount: fulfillment.FreeDeliveryAmount,
+	}
+	if err := result.Validate(); err != nil {
+		return order.FulfillmentSettings{}, err
+	}
+	return result, nil
+}
+
+// FulfillmentSettings 店铺支持的取餐方式和配送费用设置
+func (s *Shop) FulfillmentSettings() (order.FulfillmentSettings, error) {
+	return ParseFulfillmentSettings(s.Settings)
+}
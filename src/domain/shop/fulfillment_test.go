@@ -0,0 +1,48 @@
+package shop
+
+import (
+	"testing"
+
+	"orderease/domain/order"
+	"orderease/domain/shared"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFulfillmentSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     order.FulfillmentSettings
+		errMsg   string
+	}{
+		{"empty settings", "", order.FulfillmentSettings{}, ""},
+		{"no fulfillment", `{"charges":{}}`, order.FulfillmentSettings{}, ""},
+		{
+			"full",
+			`{"fulfillment":{"types":["pickup","delivery"],"delivery_min_amount":30,"delivery_fee":5,"free_delivery_amount":88}}`,
+			order.FulfillmentSettings{
+				Types:              []order.FulfillmentType{order.FulfillmentPickup, order.FulfillmentDelivery},
+				DeliveryMinAmount:  shared.NewPrice(30),
+				DeliveryFee:        shared.NewPrice(5),
+				FreeDeliveryAmount: shared.NewPrice(88),
+			},
+			"",
+		},
+		{"invalid type", `{"fulfillment":{"types":["express"]}}`, order.FulfillmentSettings{}, "无效的取餐方式: express"},
+		{"negative minimum", `{"fulfillment":{"delivery_min_amount":-1}}`, order.FulfillmentSettings{}, "起送金额不能为负数"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFulfillmentSettings(tt.settings)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package shop
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultPickupPrefix 未设置前缀时取餐号的前缀
+const defaultPickupPrefix = "A"
+
+// maxPickupPrefixLength 取餐号前缀的最大长度
+const maxPickupPrefixLength = 3
+
+// PickupSettings 取餐号设置，来自店铺设置 JSON 的 "pickup" 字段
+type PickupSettings struct {
+	Prefix   string `json:"prefix"`    // 取餐号前缀，1-3 个大写字母，默认 A
+	DayStart string `json:"day_start"` // 营业日开始时间 HH:MM，取餐号从这一刻起重新从 1 开始，默认 00:00
+}
+
+// ParsePickupSettings 从店铺设置 JSON 的 "pickup" 字段解析取餐号设置
+// 未配置时使用默认前缀，每天零点重新编号
+func ParsePickupSettings(settings string) (PickupSettings, error) {
+	pickup := PickupSettings{Prefix: defaultPickupPrefix, DayStart: "00:00"}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(settings), &fields); err != nil {
+		return pickup, nil
+	}
+	raw, ok := fields["pickup"]
+	if !ok || string(raw) == "null" {
+		return pickup, nil
+	}
+
+	if err := json.Unmarshal(raw, &pickup); err != nil {
+		return PickupSettings{}, fmt.Errorf("店铺取餐号设置格式错误: %v", err)
+	}
+	if pickup.Prefix == "" {
+		pickup.Prefix = defaultPickupPrefix
+	}
+	if pickup.DayStart == "" {
+		pickup.DayStart = "00:00"
+	}
+	if err := pickup.Validate(); err != nil {
+		return PickupSettings{}, err
+	}
+	return pickup, nil
+}
+
+// Validate 校验取餐号设置
+func (p PickupSettings) Validate() error {
+	if len(p.Prefix) > maxPickupPrefixLength {
+		return fmt.Errorf("取餐号前缀最多 %d 个字母", maxPickupPrefixLength)
+	}
+	for _, c := range p.Prefix {
+		if c < 'A' || c > 'Z' {
+			return errors.New("取餐号前缀只能使用大写字母")
+		}
+	}
+	if _, err := parseClock(p.DayStart, false); err != nil {
+		return fmt.Errorf("营业日开始时间 %s 格式错误，应为 HH:MM", p.DayStart)
+	}
+	return nil
+}
+
+// FormatNumber 按前缀格式化取餐号，如 A001，超过 999 后位数随之增加
+func (p PickupSettings) FormatNumber(seq int) string {
+	return fmt.Sprintf("%s%03d", p.Prefix, seq)
+}
+
+// PickupSettings 店铺的取餐号设置
+func (s *Shop) PickupSettings() (PickupSettings, error) {
+	return ParsePickupSettings(s.Settings)
+}
+
+// BusinessDate 指定时间所属的营业日（YYYY-MM-DD），按店铺时区计算
+// 营业日开始时间之前的时间属于前一个营业日，例如营业日从 04:00 开始时凌晨两点仍算前一天
+func (s *Shop) BusinessDate(t time.Time, settings PickupSettings) string {
+	dayStart, err := parseClock(settings.DayStart, false)
+	if err != nil {
+		dayStart = 0
+	}
+	local := t.In(s.Location()).Add(-time.Duration(dayStart) * time.Minute)
+	return local.Format(specialDateLayout)
+}
@@ -0,0 +1,63 @@
+package shop
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePickupSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     PickupSettings
+		errMsg   string
+	}{
+		{"defaults", "", PickupSettings{Prefix: "A", DayStart: "00:00"}, ""},
+		{"no pickup", `{"charges":{}}`, PickupSettings{Prefix: "A", DayStart: "00:00"}, ""},
+		{"custom", `{"pickup":{"prefix":"TK","day_start":"04:30"}}`, PickupSettings{Prefix: "TK", DayStart: "04:30"}, ""},
+		{"empty prefix uses default", `{"pickup":{"day_start":"05:00"}}`, PickupSettings{Prefix: "A", DayStart: "05:00"}, ""},
+		{"lowercase prefix", `{"pickup":{"prefix":"a"}}`, PickupSettings{}, "取餐号前缀只能使用大写字母"},
+		{"prefix too long", `{"pickup":{"prefix":"ABCD"}}`, PickupSettings{}, "取餐号前缀最多 3 个字母"},
+		{"invalid day start", `{"pickup":{"day_start":"24:00"}}`, PickupSettings{}, "营业日开始时间 24:00 格式错误，应为 HH:MM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePickupSettings(tt.settings)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestShop_BusinessDate(t *testing.T) {
+	s := &Shop{Timezone: "Asia/Shanghai"}
+	midnight := PickupSettings{Prefix: "A", DayStart: "00:00"}
+	early := PickupSettings{Prefix: "A", DayStart: "04:00"}
+
+	// 2025-01-06 17:30 UTC = 2025-01-07 01:30（上海）
+	t1 := time.Date(2025, time.January, 6, 17, 30, 0, 0, time.UTC)
+	assert.Equal(t, "2025-01-07", s.BusinessDate(t1, midnight))
+	assert.Equal(t, "2025-01-06", s.BusinessDate(t1, early), "营业日开始前的凌晨属于前一天")
+
+	// 2025-01-06 20:00 UTC = 2025-01-07 04:00（上海）
+	t2 := time.Date(2025, time.January, 6, 20, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2025-01-07", s.BusinessDate(t2, early))
+
+	s.Timezone = "UTC"
+	assert.Equal(t, "2025-01-06", s.BusinessDate(t1, midnight))
+}
+
+func TestPickupSettings_FormatNumber(t *testing.T) {
+	p := PickupSettings{Prefix: "B"}
+	assert.Equal(t, "B001", p.FormatNumber(1))
+	assert.Equal(t, "B042", p.FormatNumber(42))
+	assert.Equal(t, "B1000", p.FormatNumber(1000))
+}
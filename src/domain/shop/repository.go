@@ -15,8 +15,18 @@ type ShopRepository interface {
 
 type TagRepository interface {
 	Save(tag *Tag) error
-	FindByID(id int) (*Tag, error)
+	FindByIDAndShopID(id int, shopID shared.ID) (*Tag, error)
 	FindByShopID(shopID shared.ID) ([]Tag, error)
-	Delete(id int) error
+	Delete(id int, shopID shared.ID) error
 	Update(tag *Tag) error
 }
+
+type StaffRepository interface {
+	Save(staff *Staff) error
+	FindByID(id shared.ID) (*Staff, error)
+	FindByIDAndShopID(id shared.ID, shopID shared.ID) (*Staff, error)
+	FindByUsername(username string) (*Staff, error)
+	FindByShopID(shopID shared.ID) ([]Staff, error)
+	Update(staff *Staff) error
+	Delete(id shared.ID, shopID shared.ID) error
+}
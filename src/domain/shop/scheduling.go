@@ -0,0 +1,151 @@
+package shop
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultSlotMinutes 未设置时段长度时每个预约时段的分钟数
+const defaultSlotMinutes = 15
+
+// maxScheduleDaysAhead 最多允许提前预约的天数
+const maxScheduleDaysAhead = 30
+
+var ErrSchedulingDisabled = errors.New("店铺未开放预约下单")
+
+// SchedulingSettings 预约下单设置，来自店铺设置 JSON 的 "scheduling" 字段
+type SchedulingSettings struct {
+	Enabled      bool `json:"enabled"`
+	SlotMinutes  int  `json:"slot_minutes"`   // 每个预约时段的分钟数，需能整除一天，默认 15
+	LeadMinutes  int  `json:"lead_minutes"`   // 最短备餐时间，预约时间至少在下单后这么多分钟
+	SlotCapacity int  `json:"slot_capacity"`  // 每个时段最多接受的预约订单数，0 表示不限
+	MaxDaysAhead int  `json:"max_days_ahead"` // 最多预约到之后第几天，0 表示只能预约当天
+}
+
+// ScheduleSlot 一个预约时段，按店铺时区
+type ScheduleSlot struct {
+	Start time.Time
+	End   time.Time
+}
+
+// ParseSchedulingSettings 从店铺设置 JSON 的 "scheduling" 字段解析预约下单设置
+// 未配置 scheduling 时不开放预约
+func ParseSchedulingSettings(settings string) (SchedulingSettings, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(settings), &fields); err != nil {
+		return SchedulingSettings{}, nil
+	}
+	raw, ok := fields["scheduling"]
+	if !ok || string(raw) == "null" {
+		return SchedulingSettings{}, nil
+	}
+
+	var scheduling SchedulingSettings
+	if err := json.Unmarshal(raw, &scheduling); err != nil {
+		return SchedulingSettings{}, fmt.Errorf("店铺预约设置格式错误: %v", err)
+	}
+	if scheduling.SlotMinutes == 0 {
+		scheduling.SlotMinutes = defaultSlotMinutes
+	}
+	if err := scheduling.Validate(); err != nil {
+		return SchedulingSettings{}, err
+	}
+	return scheduling, nil
+}
+
+// Validate 校验预约下单设置
+func (s SchedulingSettings) Validate() error {
+	if s.SlotMinutes < 5 || s.SlotMinutes > 24*60 || (24*60)%s.SlotMinutes != 0 {
+		return errors.New("预约时段长度需在 5 到 1440 分钟之间，且能整除一天")
+	}
+	if s.LeadMinutes < 0 {
+		return errors.New("最短备餐时间不能为负数")
+	}
+	if s.SlotCapacity < 0 {
+		return errors.New("每个时段的预约数量不能为负数")
+	}
+	if s.MaxDaysAhead < 0 || s.MaxDaysAhead > maxScheduleDaysAhead {
+		return fmt.Errorf("最多只能提前 %d 天预约", maxScheduleDaysAhead)
+	}
+	return nil
+}
+
+// SchedulingSettings 店铺的预约下单设置
+func (s *Shop) SchedulingSettings() (SchedulingSettings, error) {
+	return ParseSchedulingSettings(s.Settings)
+}
+
+// slotAt 包含指定时间的预约时段，时段从店铺时区的零点开始划分
+func (settings SchedulingSettings) slotAt(t time.Time) ScheduleSlot {
+	day := startOfDay(t)
+	minutes := t.Hour()*60 + t.Minute()
+	start := minutes - minutes%settings.SlotMinutes
+	return ScheduleSlot{
+		Start: time.Date(day.Year(), day.Month(), day.Day(), 0, start, 0, 0, t.Location()),
+		End:   time.Date(day.Year(), day.Month(), day.Day(), 0, start+settings.SlotMinutes, 0, 0, t.Location()),
+	}
+}
+
+// ScheduleSlots 当前可以预约的时段：在营业时间内、晚于最短备餐时间、不超过可预约天数
+// 时段的剩余容量由调用方按已有订单计算
+func (s *Shop) ScheduleSlots(now time.Time, settings SchedulingSettings) []ScheduleSlot {
+	if !settings.Enabled || s.OrderingPaused {
+		return nil
+	}
+
+	local := now.In(s.Location())
+	earliest := local.Add(time.Duration(settings.LeadMinutes) * time.Minute)
+	today := startOfDay(local)
+
+	var slots []ScheduleSlot
+	for day := 0; day <= settings.MaxDaysAhead; day++ {
+		date := today.AddDate(0, 0, day)
+		for minute := 0; minute < 24*60; minute += settings.SlotMinutes {
+			start := time.Date(date.Year(), date.Month(), date.Day(), 0, minute, 0, 0, date.Location())
+			if start.Before(earliest) || !s.BusinessHours.IsOpenAt(start) {
+				continue
+			}
+			slots = append(slots, ScheduleSlot{
+				Start: start,
+				End:   start.Add(time.Duration(settings.SlotMinutes) * time.Minute),
+			})
+		}
+	}
+	return slots
+}
+
+// ValidateScheduledTime 校验预约时间，返回预约时间所在的时段，用于检查时段容量
+// 预约时间需在营业时间内、晚于最短备餐时间且不超过可预约天数；暂停接单时不接受预约
+func (s *Shop) ValidateScheduledTime(scheduled, now time.Time, settings SchedulingSettings) (ScheduleSlot, error) {
+	if s.OrderingPaused {
+		return ScheduleSlot{}, ErrOrderingPaused
+	}
+	if !settings.Enabled {
+		return ScheduleSlot{}, ErrSchedulingDisabled
+	}
+
+	loc := s.Location()
+	local := scheduled.In(loc)
+	if local.Before(now.Add(time.Duration(settings.LeadMinutes) * time.Minute)) {
+		if settings.LeadMinutes > 0 {
+			return ScheduleSlot{}, fmt.Errorf("预约时间需在下单 %d 分钟之后", settings.LeadMinutes)
+		}
+		return ScheduleSlot{}, errors.New("预约时间不能早于当前时间")
+	}
+
+	lastDay := startOfDay(now.In(loc)).AddDate(0, 0, settings.MaxDaysAhead+1)
+	if !local.Before(lastDay) {
+		if settings.MaxDaysAhead == 0 {
+			return ScheduleSlot{}, errors.New("只能预约当天")
+		}
+		return ScheduleSlot{}, fmt.Errorf("最多只能预约 %d 天后", settings.MaxDaysAhead)
+	}
+
+	if !s.BusinessHours.IsOpenAt(local) {
+		return ScheduleSlot{}, errors.New("预约时间不在营业时间内")
+	}
+
+	return settings.slotAt(local), nil
+}
@@ -0,0 +1,121 @@
+package shop
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedulingSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     SchedulingSettings
+		errMsg   string
+	}{
+		{"empty settings", "", SchedulingSettings{}, ""},
+		{"no scheduling", `{"charges":{}}`, SchedulingSettings{}, ""},
+		{"default slot minutes", `{"scheduling":{"enabled":true}}`, SchedulingSettings{Enabled: true, SlotMinutes: 15}, ""},
+		{
+			"full",
+			`{"scheduling":{"enabled":true,"slot_minutes":30,"lead_minutes":20,"slot_capacity":5,"max_days_ahead":2}}`,
+			SchedulingSettings{Enabled: true, SlotMinutes: 30, LeadMinutes: 20, SlotCapacity: 5, MaxDaysAhead: 2},
+			"",
+		},
+		{"slot does not divide day", `{"scheduling":{"slot_minutes":7}}`, SchedulingSettings{}, "预约时段长度需在 5 到 1440 分钟之间，且能整除一天"},
+		{"negative lead", `{"scheduling":{"lead_minutes":-1}}`, SchedulingSettings{}, "最短备餐时间不能为负数"},
+		{"negative capacity", `{"scheduling":{"slot_capacity":-1}}`, SchedulingSettings{}, "每个时段的预约数量不能为负数"},
+		{"too many days", `{"scheduling":{"max_days_ahead":31}}`, SchedulingSettings{}, "最多只能提前 30 天预约"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSchedulingSettings(tt.settings)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestShop_ValidateScheduledTime(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, time.January, day, hour, minute, 0, 0, loc)
+	}
+
+	// 2025-01-06 周一 09:00（上海）
+	now := at(6, 9, 0)
+	s := &Shop{Timezone: "Asia/Shanghai", BusinessHours: testBusinessHours()}
+	settings := SchedulingSettings{Enabled: true, SlotMinutes: 30, LeadMinutes: 30, MaxDaysAhead: 5}
+
+	tests := []struct {
+		name      string
+		scheduled time.Time
+		wantStart time.Time
+		errMsg    string
+	}{
+		{"lunch slot", at(6, 10, 40), at(6, 10, 30), ""},
+		{"utc input uses shop timezone", at(6, 12, 5).UTC(), at(6, 12, 0), ""},
+		{"friday overnight", at(11, 1, 15), at(11, 1, 0), ""},
+		{"within lead time", at(6, 9, 20), time.Time{}, "预约时间需在下单 30 分钟之后"},
+		{"outside business hours", at(6, 15, 0), time.Time{}, "预约时间不在营业时间内"},
+		{"beyond days ahead", at(12, 10, 0), time.Time{}, "最多只能预约 5 天后"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slot, err := s.ValidateScheduledTime(tt.scheduled, now, settings)
+			if tt.errMsg != "" {
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.wantStart.Equal(slot.Start), "want %s, got %s", tt.wantStart, slot.Start)
+			assert.Equal(t, 30*time.Minute, slot.End.Sub(slot.Start))
+		})
+	}
+
+	_, err = s.ValidateScheduledTime(at(6, 8, 0), now, SchedulingSettings{Enabled: true, SlotMinutes: 15})
+	assert.EqualError(t, err, "预约时间不能早于当前时间")
+
+	_, err = s.ValidateScheduledTime(at(7, 11, 0), now, SchedulingSettings{Enabled: true, SlotMinutes: 15})
+	assert.EqualError(t, err, "只能预约当天")
+
+	_, err = s.ValidateScheduledTime(at(6, 11, 0), now, SchedulingSettings{SlotMinutes: 15})
+	assert.ErrorIs(t, err, ErrSchedulingDisabled)
+
+	s.SetOrderingPaused(true)
+	_, err = s.ValidateScheduledTime(at(6, 11, 0), now, settings)
+	assert.ErrorIs(t, err, ErrOrderingPaused)
+}
+
+func TestShop_ScheduleSlots(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, time.January, day, hour, minute, 0, 0, loc)
+	}
+
+	s := &Shop{Timezone: "Asia/Shanghai", BusinessHours: testBusinessHours()}
+	settings := SchedulingSettings{Enabled: true, SlotMinutes: 60, LeadMinutes: 30}
+
+	// 周一 12:40 下单，最早 13:10，当天剩余 17:00-22:00 五个时段
+	slots := s.ScheduleSlots(at(6, 12, 40), settings)
+	require.Len(t, slots, 5)
+	assert.True(t, at(6, 17, 0).Equal(slots[0].Start))
+	assert.True(t, at(6, 22, 0).Equal(slots[4].End))
+
+	settings.MaxDaysAhead = 1
+	slots = s.ScheduleSlots(at(6, 12, 40), settings)
+	assert.Len(t, slots, 5, "周二未设置营业时间")
+
+	settings.Enabled = false
+	assert.Empty(t, s.ScheduleSlots(at(6, 12, 40), settings))
+}
@@ -1,12 +1,16 @@
 package shop
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"orderease/domain/order"
 	"orderease/domain/shared"
 	"orderease/utils"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Shop struct {
@@ -24,6 +28,13 @@ type Shop struct {
 	ValidUntil      time.Time
 	Settings        string
 	OrderStatusFlow order.OrderStatusFlow
+	// AutoOfflineOnZeroStock 商品库存为0时自动下架，补货后自动上架
+	AutoOfflineOnZeroStock bool
+	// Timezone 店铺时区（IANA 名称），营业时间按该时区计算，为空时使用 DefaultTimezone
+	Timezone      string
+	BusinessHours BusinessHours
+	// OrderingPaused 手动暂停接单
+	OrderingPaused bool
 }
 
 func NewShop(name, ownerUsername, ownerPassword string, validUntil time.Time) (*Shop, error) {
@@ -51,6 +62,7 @@ func NewShop(name, ownerUsername, ownerPassword string, validUntil time.Time) (*
 		OwnerUsername: ownerUsername,
 		OwnerPassword: ownerPassword,
 		ValidUntil:    validUntil,
+		Timezone:      DefaultTimezone,
 		CreatedAt:     now,
 		UpdatedAt:     now,
 		OrderStatusFlow: order.OrderStatusFlow{
@@ -107,6 +119,14 @@ func (s *Shop) UpdateValidUntil(validUntil time.Time) error {
 	return nil
 }
 
+// CheckPassword 校验店主密码（数据库中存储的是 bcrypt 哈希）
+func (s *Shop) CheckPassword(password string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(s.OwnerPassword), []byte(password)); err != nil {
+		return errors.New("密码错误")
+	}
+	return nil
+}
+
 func (s *Shop) UpdatePassword(newPassword string) error {
 	if newPassword == "" {
 		return errors.New("新密码不能为空")
@@ -116,6 +136,48 @@ func (s *Shop) UpdatePassword(newPassword string) error {
 	return nil
 }
 
+// chargeSettingsJSON Settings 中 "charges" 字段的格式
+type chargeSettingsJSON struct {
+	TaxRate           shared.Rate  `json:"tax_rate"`
+	TaxInclusive      bool         `json:"tax_inclusive"`
+	ServiceChargeRate shared.Rate  `json:"service_charge_rate"`
+	PackagingFee      shared.Price `json:"packaging_fee"`
+}
+
+// ParseChargeSettings 从店铺设置 JSON 的 "charges" 字段解析税费和附加费设置
+// 未配置 charges（包括设置为空或不是 JSON 对象的历史数据）时不收取任何费用
+func ParseChargeSettings(settings string) (order.ChargeSettings, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(settings), &fields); err != nil {
+		return order.ChargeSettings{}, nil
+	}
+	raw, ok := fields["charges"]
+	if !ok || string(raw) == "null" {
+		return order.ChargeSettings{}, nil
+	}
+
+	var charges chargeSettingsJSON
+	if err := json.Unmarshal(raw, &charges); err != nil {
+		return order.ChargeSettings{}, fmt.Errorf("店铺费用设置格式错误: %v", err)
+	}
+
+	result := order.ChargeSettings{
+		TaxRate:           charges.TaxRate,
+		TaxInclusive:      charges.TaxInclusive,
+		ServiceChargeRate: charges.ServiceChargeRate,
+		PackagingFee:      charges.PackagingFee,
+	}
+	if err := result.Validate(); err != nil {
+		return order.ChargeSettings{}, err
+	}
+	return result, nil
+}
+
+// ChargeSettings 店铺的税费和附加费设置
+func (s *Shop) ChargeSettings() (order.ChargeSettings, error) {
+	return ParseChargeSettings(s.Settings)
+}
+
 func getDefaultOrderStatuses() []order.OrderStatusConfig {
 	return []order.OrderStatusConfig{
 		{
@@ -125,7 +187,7 @@ func getDefaultOrderStatuses() []order.OrderStatusConfig {
 			IsFinal: false,
 			Actions: []order.OrderStatusTransition{
 				{Name: "接单", NextStatus: 1, NextStatusLabel: "已接单"},
-				{Name: "取消", NextStatus: 10, NextStatusLabel: "已取消"},
+				{Name: "取消", NextStatus: 10, NextStatusLabel: "已取消", RequireReason: true},
 			},
 		},
 		{
@@ -135,7 +197,7 @@ func getDefaultOrderStatuses() []order.OrderStatusConfig {
 			IsFinal: false,
 			Actions: []order.OrderStatusTransition{
 				{Name: "完成", NextStatus: 9, NextStatusLabel: "已完成"},
-				{Name: "取消", NextStatus: 10, NextStatusLabel: "已取消"},
+				{Name: "取消", NextStatus: 10, NextStatusLabel: "已取消", RequireReason: true},
 			},
 		},
 		{
@@ -146,11 +208,12 @@ func getDefaultOrderStatuses() []order.OrderStatusConfig {
 			Actions: []order.OrderStatusTransition{},
 		},
 		{
-			Value:   10,
-			Label:   "已取消",
-			Type:    "info",
-			IsFinal: true,
-			Actions: []order.OrderStatusTransition{},
+			Value:        10,
+			Label:        "已取消",
+			Type:         "info",
+			IsFinal:      true,
+			ReleaseStock: true,
+			Actions:      []order.OrderStatusTransition{},
 		},
 	}
 }
@@ -5,8 +5,10 @@ import (
 	"time"
 
 	"orderease/domain/order"
+	"orderease/domain/shared"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestNewShop(t *testing.T) {
@@ -458,6 +460,35 @@ func TestShop_UpdatePassword(t *testing.T) {
 	}
 }
 
+func TestShop_CheckPassword(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("OldPass#123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{name: "correct password", password: "OldPass#123", wantErr: false},
+		{name: "wrong password", password: "WrongPass#123", wantErr: true},
+		{name: "empty password", password: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Shop{OwnerPassword: string(hashed)}
+			err := s.CheckPassword(tt.password)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "密码错误")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestShop_DefaultOrderStatusFlow(t *testing.T) {
 	shop, err := NewShop("测试店铺", "user", "pass", time.Now().AddDate(1, 0, 0))
 	assert.NoError(t, err)
@@ -470,3 +501,41 @@ func TestShop_DefaultOrderStatusFlow(t *testing.T) {
 		assert.NotEmpty(t, status.Label)
 	}
 }
+
+func TestParseChargeSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     order.ChargeSettings
+		wantErr  string
+	}{
+		{"empty", "", order.ChargeSettings{}, ""},
+		{"not an object", `"abc"`, order.ChargeSettings{}, ""},
+		{"no charges", `{"theme": "dark"}`, order.ChargeSettings{}, ""},
+		{
+			name:     "full",
+			settings: `{"charges": {"tax_rate": 6, "tax_inclusive": true, "service_charge_rate": "10", "packaging_fee": 0.5}}`,
+			want: order.ChargeSettings{
+				TaxRate:           shared.NewRate(6),
+				TaxInclusive:      true,
+				ServiceChargeRate: shared.NewRate(10),
+				PackagingFee:      shared.NewPrice(0.5),
+			},
+		},
+		{"bad format", `{"charges": {"tax_rate": "abc"}}`, order.ChargeSettings{}, "店铺费用设置格式错误"},
+		{"tax rate over 100", `{"charges": {"tax_rate": 120}}`, order.ChargeSettings{}, "税率必须在0到100之间"},
+		{"negative packaging fee", `{"charges": {"packaging_fee": -1}}`, order.ChargeSettings{}, "打包费不能为负数"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseChargeSettings(tt.settings)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package shop
+
+import (
+	"errors"
+	"time"
+
+	"orderease/domain/shared"
+	"orderease/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// StaffRole 店铺员工角色
+type StaffRole string
+
+const (
+	StaffRoleOwner   StaffRole = "owner"
+	StaffRoleManager StaffRole = "manager"
+	StaffRoleCashier StaffRole = "cashier"
+	StaffRoleKitchen StaffRole = "kitchen"
+)
+
+// 店铺后台权限，按路由分组授予
+const (
+	PermShopView        = "shop:view"        // 查看店铺信息
+	PermShopManage      = "shop:manage"      // 修改店铺、订单流转配置、临时令牌
+	PermProductManage   = "product:manage"   // /product/*
+	PermOrderView       = "order:view"       // 订单列表、详情、搜索、实时事件
+	PermOrderUnfinished = "order:unfinished" // 未完成订单
+	PermOrderStatus     = "order:status"     // 订单状态流转
+	PermOrderCreate     = "order:create"     // 代客下单
+	PermOrderEdit       = "order:edit"       // 修改、删除订单
+	PermTagManage       = "tag:manage"       // /tag/*
+	PermUserManage      = "user:manage"      // /user/*
+	PermStaffManage     = "staff:manage"     // 员工账号管理
+	PermAuditView       = "audit:view"       // 操作审计日志
+	PermPromotionManage = "promotion:manage" // 优惠券和促销活动
+	PermPaymentManage   = "payment:manage"   // 发起支付、确认线下收款、查看支付记录
+	PermPaymentRefund   = "payment:refund"   // 支付单退款、订单售后退款
+	PermRevenueView     = "revenue:view"     // 营业额统计
+	PermTableManage     = "table:manage"     // 桌台和桌台二维码管理
+)
+
+var allPermissions = []string{
+	PermShopView, PermShopManage, PermProductManage,
+	PermOrderView, PermOrderUnfinished, PermOrderStatus, PermOrderCreate, PermOrderEdit,
+	PermTagManage, PermUserManage, PermStaffManage, PermAuditView, PermPromotionManage,
+	PermPaymentManage, PermPaymentRefund, PermRevenueView, PermTableManage,
+}
+
+var rolePermissions = map[StaffRole][]string{
+	StaffRoleOwner: allPermissions,
+	StaffRoleManager: {
+		PermShopView, PermProductManage,
+		PermOrderView, PermOrderUnfinished, PermOrderStatus, PermOrderCreate, PermOrderEdit,
+		PermTagManage, PermUserManage, PermAuditView, PermPromotionManage,
+		PermPaymentManage, PermPaymentRefund, PermRevenueView, PermTableManage,
+	},
+	StaffRoleCashier: {
+		PermShopView,
+		PermOrderView, PermOrderUnfinished, PermOrderStatus, PermOrderCreate, PermOrderEdit,
+		PermPaymentManage,
+	},
+	StaffRoleKitchen: {
+		PermOrderUnfinished, PermOrderStatus,
+	},
+}
+
+// IsValid 是否为可以分配给员工子账号的角色
+// owner 只用于店主登录签发的令牌，员工不能拥有店铺管理和员工管理权限
+func (r StaffRole) IsValid() bool {
+	if r == StaffRoleOwner {
+		return false
+	}
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Permissions 返回角色拥有的权限列表
+func (r StaffRole) Permissions() []string {
+	perms := rolePermissions[r]
+	result := make([]string, len(perms))
+	copy(result, perms)
+	return result
+}
+
+// Staff 店铺员工子账号
+type Staff struct {
+	ID        shared.ID
+	ShopID    shared.ID
+	Username  string
+	Password  string
+	Name      string
+	Role      StaffRole
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewStaff(shopID shared.ID, username, password, name string, role StaffRole) (*Staff, error) {
+	if shopID.IsZero() {
+		return nil, errors.New("店铺ID不能为空")
+	}
+
+	if username == "" {
+		return nil, errors.New("员工用户名不能为空")
+	}
+
+	if password == "" {
+		return nil, errors.New("员工密码不能为空")
+	}
+
+	if !role.IsValid() {
+		return nil, errors.New("无效的员工角色")
+	}
+
+	now := time.Now()
+
+	return &Staff{
+		ID:        shared.ID(utils.GenerateSnowflakeID()),
+		ShopID:    shopID,
+		Username:  username,
+		Password:  password,
+		Name:      name,
+		Role:      role,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// CheckPassword 校验员工密码（数据库中存储的是 bcrypt 哈希）
+func (s *Staff) CheckPassword(password string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(s.Password), []byte(password)); err != nil {
+		return errors.New("密码错误")
+	}
+	return nil
+}
+
+func (s *Staff) UpdatePassword(newPassword string) error {
+	if newPassword == "" {
+		return errors.New("新密码不能为空")
+	}
+	s.Password = newPassword
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *Staff) UpdateRole(role StaffRole) error {
+	if !role.IsValid() {
+		return errors.New("无效的员工角色")
+	}
+	s.Role = role
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *Staff) SetActive(active bool) {
+	s.Active = active
+	s.UpdatedAt = time.Now()
+}
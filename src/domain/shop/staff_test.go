@@ -0,0 +1,130 @@
+package shop
+
+import (
+	"testing"
+
+	"orderease/domain/shared"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNewStaff(t *testing.T) {
+	tests := []struct {
+		name     string
+		shopID   shared.ID
+		username string
+		password string
+		role     StaffRole
+		wantErr  bool
+		errMsg   string
+	}{
+		{
+			name:     "valid cashier",
+			shopID:   shared.ID(1),
+			username: "cashier01",
+			password: "Pass#1234",
+			role:     StaffRoleCashier,
+			wantErr:  false,
+		},
+		{
+			name:     "missing shop id",
+			shopID:   shared.ID(0),
+			username: "cashier01",
+			password: "Pass#1234",
+			role:     StaffRoleCashier,
+			wantErr:  true,
+			errMsg:   "店铺ID不能为空",
+		},
+		{
+			name:     "empty username",
+			shopID:   shared.ID(1),
+			username: "",
+			password: "Pass#1234",
+			role:     StaffRoleCashier,
+			wantErr:  true,
+			errMsg:   "员工用户名不能为空",
+		},
+		{
+			name:     "invalid role",
+			shopID:   shared.ID(1),
+			username: "cashier01",
+			password: "Pass#1234",
+			role:     StaffRole("boss"),
+			wantErr:  true,
+			errMsg:   "无效的员工角色",
+		},
+		{
+			name:     "owner role",
+			shopID:   shared.ID(1),
+			username: "owner01",
+			password: "Pass#1234",
+			role:     StaffRoleOwner,
+			wantErr:  true,
+			errMsg:   "无效的员工角色",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			staff, err := NewStaff(tt.shopID, tt.username, tt.password, "", tt.role)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.errMsg, err.Error())
+				assert.Nil(t, staff)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.False(t, staff.ID.IsZero())
+			assert.Equal(t, tt.role, staff.Role)
+			assert.True(t, staff.Active)
+		})
+	}
+}
+
+func TestStaffRole_Permissions(t *testing.T) {
+	assert.ElementsMatch(t, allPermissions, StaffRoleOwner.Permissions())
+
+	manager := StaffRoleManager.Permissions()
+	assert.NotContains(t, manager, PermShopManage)
+	assert.NotContains(t, manager, PermStaffManage)
+	assert.Contains(t, manager, PermProductManage)
+	assert.Contains(t, manager, PermRevenueView)
+	assert.Contains(t, manager, PermTableManage)
+
+	cashier := StaffRoleCashier.Permissions()
+	assert.Contains(t, cashier, PermOrderCreate)
+	assert.NotContains(t, cashier, PermProductManage)
+	assert.NotContains(t, cashier, PermUserManage)
+	assert.Contains(t, cashier, PermPaymentManage)
+	assert.NotContains(t, cashier, PermPaymentRefund)
+	assert.NotContains(t, cashier, PermRevenueView)
+	assert.NotContains(t, cashier, PermTableManage)
+
+	assert.ElementsMatch(t, []string{PermOrderUnfinished, PermOrderStatus}, StaffRoleKitchen.Permissions())
+
+	assert.Empty(t, StaffRole("boss").Permissions())
+	assert.False(t, StaffRole("boss").IsValid())
+	assert.False(t, StaffRoleOwner.IsValid(), "owner 只用于店主令牌，不能分配给员工")
+}
+
+func TestStaff_CheckPassword(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("Pass#1234"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	staff := &Staff{Password: string(hashed)}
+	assert.NoError(t, staff.CheckPassword("Pass#1234"))
+	assert.EqualError(t, staff.CheckPassword("wrong"), "密码错误")
+}
+
+func TestStaff_UpdateRole(t *testing.T) {
+	staff := &Staff{Role: StaffRoleCashier}
+
+	assert.NoError(t, staff.UpdateRole(StaffRoleKitchen))
+	assert.Equal(t, StaffRoleKitchen, staff.Role)
+
+	assert.Error(t, staff.UpdateRole(StaffRole("boss")))
+	assert.Error(t, staff.UpdateRole(StaffRoleOwner))
+	assert.Equal(t, StaffRoleKitchen, staff.Role)
+}
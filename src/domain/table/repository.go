@@ -0,0 +1,26 @@
+package table
+
+import (
+	"orderease/domain/shared"
+)
+
+type TableRepository interface {
+	Save(t *Table) error
+	Update(t *Table) error
+	FindByIDAndShopID(id shared.ID, shopID uint64) (*Table, error)
+	// FindByIDForUpdate 在事务内锁定桌台，同一桌台的开台、换台、并台依次执行
+	FindByIDForUpdate(id shared.ID, shopID uint64) (*Table, error)
+	FindByShopID(shopID uint64) ([]Table, error)
+	Delete(id shared.ID, shopID uint64) error
+}
+
+type SessionRepository interface {
+	Save(s *Session) error
+	Update(s *Session) error
+	FindByIDAndShopID(id shared.ID, shopID uint64) (*Session, error)
+	// FindOpenByTableID 查询桌台进行中的用餐，没有时返回 nil
+	FindOpenByTableID(tableID shared.ID, shopID uint64) (*Session, error)
+	FindOpenByShopID(shopID uint64) ([]Session, error)
+	// FindByGuestUserID 查询扫码点餐顾客所属的用餐，不是扫码点餐的顾客时返回 nil
+	FindByGuestUserID(userID shared.ID, shopID uint64) (*Session, error)
+}
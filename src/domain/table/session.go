@@ -0,0 +1,119 @@
+package table
+
+import (
+	"errors"
+	"time"
+
+	"orderease/domain/shared"
+)
+
+// SessionStatus 用餐状态
+type SessionStatus string
+
+const (
+	SessionStatusOpen   SessionStatus = "open"   // 用餐中，新订单归入该用餐
+	SessionStatusClosed SessionStatus = "closed" // 已结束
+)
+
+// CloseReason 用餐结束的原因
+type CloseReason string
+
+const (
+	CloseReasonPaid   CloseReason = "paid"   // 全部订单已付清
+	CloseReasonMerged CloseReason = "merged" // 并入其他桌台的用餐
+	CloseReasonManual CloseReason = "manual" // 店员手动结束
+)
+
+// Session 一次用餐（开台到结账），同一桌台同时只有一个进行中的用餐
+// 用餐期间在该桌台下的订单都归入同一张账单，全部付清后自动结束
+type Session struct {
+	ID          shared.ID
+	ShopID      uint64
+	TableID     shared.ID
+	Status      SessionStatus
+	Guests      int // 用餐人数，0 表示未填写
+	OpenedAt    time.Time
+	ClosedAt    *time.Time
+	CloseReason CloseReason
+	MergedInto  shared.ID // 并台后目标用餐的ID
+	GuestUserID shared.ID // 扫码点餐的顾客身份，每次用餐单独创建，不同用餐的顾客互相看不到订单
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func NewSession(t *Table, guests int) (*Session, error) {
+	if !t.Active {
+		return nil, ErrTableInactive
+	}
+	if guests < 0 {
+		return nil, errors.New("用餐人数不能为负数")
+	}
+
+	now := time.Now()
+	return &Session{
+		ID:        shared.NewID(),
+		ShopID:    t.ShopID,
+		TableID:   t.ID,
+		Status:    SessionStatusOpen,
+		Guests:    guests,
+		OpenedAt:  now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+func (s *Session) IsOpen() bool {
+	return s.Status == SessionStatusOpen
+}
+
+// Close 结束用餐
+func (s *Session) Close(reason CloseReason) error {
+	if !s.IsOpen() {
+		return ErrSessionClosed
+	}
+	now := time.Now()
+	s.Status = SessionStatusClosed
+	s.ClosedAt = &now
+	s.CloseReason = reason
+	s.UpdatedAt = now
+	return nil
+}
+
+// TransferTo 换台，目标桌台不能有进行中的用餐，由调用方检查
+func (s *Session) TransferTo(t *Table) error {
+	if !s.IsOpen() {
+		return ErrSessionClosed
+	}
+	if !t.Active {
+		return ErrTableInactive
+	}
+	if t.ShopID != s.ShopID {
+		return ErrTableNotFound
+	}
+	if t.ID == s.TableID {
+		return errors.New("目标桌台与当前桌台相同")
+	}
+	s.TableID = t.ID
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// MergeInto 并台，本次用餐的订单并入目标用餐后结束，人数累加到目标用餐
+func (s *Session) MergeInto(target *Session) error {
+	if !s.IsOpen() || !target.IsOpen() {
+		return ErrSessionClosed
+	}
+	if s.ShopID != target.ShopID {
+		return ErrSessionNotFound
+	}
+	if s.ID == target.ID {
+		return errors.New("不能与同一桌台并台")
+	}
+	if err := s.Close(CloseReasonMerged); err != nil {
+		return err
+	}
+	s.MergedInto = target.ID
+	target.Guests += s.Guests
+	target.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,160 @@
+package table
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"orderease/domain/shared"
+)
+
+// maxNameLength 桌号的最大长度
+const maxNameLength = 20
+
+// qrCodeVersion 二维码内容的格式版本，格式变化时递增
+const qrCodeVersion = "t1"
+
+var (
+	ErrTableNotFound   = errors.New("桌台不存在")
+	ErrTableInactive   = errors.New("桌台已停用")
+	ErrInvalidQRCode   = errors.New("无效的桌台二维码")
+	ErrSessionNotFound = errors.New("桌台没有进行中的用餐")
+	ErrSessionOpen     = errors.New("桌台正在用餐")
+	ErrSessionClosed   = errors.New("用餐已结束")
+	ErrGuestExpired    = errors.New("本次用餐已结束，请重新扫码")
+)
+
+// Table 店铺的堂食桌台
+// 每张桌台有一个固定的签名二维码，顾客扫码后登录店铺并在该桌台下单；重新生成二维码后旧二维码失效
+type Table struct {
+	ID        shared.ID
+	ShopID    uint64
+	Name      string // 桌号，如 A1，同一店铺内不能重复
+	Seats     int    // 座位数，0 表示未设置
+	QRVersion int    // 二维码版本，重新生成二维码时递增
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func validateTable(name string, seats int) error {
+	if name == "" {
+		return errors.New("桌号不能为空")
+	}
+	if len([]rune(name)) > maxNameLength {
+		return fmt.Errorf("桌号最多 %d 个字符", maxNameLength)
+	}
+	if seats < 0 {
+		return errors.New("座位数不能为负数")
+	}
+	return nil
+}
+
+func NewTable(shopID uint64, name string, seats int) (*Table, error) {
+	if shopID == 0 {
+		return nil, errors.New("店铺ID不能为空")
+	}
+	name = strings.TrimSpace(name)
+	if err := validateTable(name, seats); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &Table{
+		ID:        shared.NewID(),
+		ShopID:    shopID,
+		Name:      name,
+		Seats:     seats,
+		QRVersion: 1,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// Update 修改桌号和座位数
+func (t *Table) Update(name string, seats int) error {
+	name = strings.TrimSpace(name)
+	if err := validateTable(name, seats); err != nil {
+		return err
+	}
+	t.Name = name
+	t.Seats = seats
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+func (t *Table) SetActive(active bool) {
+	t.Active = active
+	t.UpdatedAt = time.Now()
+}
+
+// RegenerateQRCode 使已打印的二维码失效，之后需要使用新的二维码
+func (t *Table) RegenerateQRCode() {
+	t.QRVersion++
+	t.UpdatedAt = time.Now()
+}
+
+// QRCode 桌台二维码的内容：格式版本.店铺ID.桌台ID.二维码版本.签名
+// 签名使用服务端密钥的 HMAC-SHA256，二维码内容本身不包含任何密钥
+func (t *Table) QRCode(secret []byte) string {
+	payload := fmt.Sprintf("%s.%d.%d.%d", qrCodeVersion, t.ShopID, t.ID.ToUint64(), t.QRVersion)
+	return payload + "." + signQRPayload(secret, payload)
+}
+
+func signQRPayload(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// QRClaims 二维码中经过签名的桌台信息
+type QRClaims struct {
+	ShopID    uint64
+	TableID   shared.ID
+	QRVersion int
+}
+
+// ParseQRCode 校验二维码签名并解析桌台信息
+// 只校验签名，桌台是否存在、是否停用、二维码是否已重新生成由调用方按桌台当前信息检查
+func ParseQRCode(secret []byte, code string) (QRClaims, error) {
+	parts := strings.Split(strings.TrimSpace(code), ".")
+	if len(parts) != 5 || parts[0] != qrCodeVersion {
+		return QRClaims{}, ErrInvalidQRCode
+	}
+
+	payload := strings.Join(parts[:4], ".")
+	if !hmac.Equal([]byte(parts[4]), []byte(signQRPayload(secret, payload))) {
+		return QRClaims{}, ErrInvalidQRCode
+	}
+
+	shopID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return QRClaims{}, ErrInvalidQRCode
+	}
+	tableID, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return QRClaims{}, ErrInvalidQRCode
+	}
+	version, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return QRClaims{}, ErrInvalidQRCode
+	}
+	return QRClaims{ShopID: shopID, TableID: shared.ID(tableID), QRVersion: version}, nil
+}
+
+// CheckQRClaims 二维码需属于该桌台且是当前版本，桌台停用后不能扫码点餐
+func (t *Table) CheckQRClaims(claims QRClaims) error {
+	if claims.ShopID != t.ShopID || claims.TableID != t.ID || claims.QRVersion != t.QRVersion {
+		return ErrInvalidQRCode
+	}
+	if !t.Active {
+		return ErrTableInactive
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package table
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTable(t *testing.T) {
+	tbl, err := NewTable(1001, " A1 ", 4)
+	require.NoError(t, err)
+	assert.Equal(t, "A1", tbl.Name)
+	assert.Equal(t, 1, tbl.QRVersion)
+	assert.True(t, tbl.Active)
+
+	_, err = NewTable(1001, "  ", 4)
+	assert.EqualError(t, err, "桌号不能为空")
+	_, err = NewTable(1001, strings.Repeat("桌", 21), 4)
+	assert.EqualError(t, err, "桌号最多 20 个字符")
+	_, err = NewTable(1001, "A1", -1)
+	assert.EqualError(t, err, "座位数不能为负数")
+}
+
+func TestTable_QRCode(t *testing.T) {
+	secret := []byte("secret")
+	tbl, err := NewTable(1001, "A1", 4)
+	require.NoError(t, err)
+
+	code := tbl.QRCode(secret)
+	claims, err := ParseQRCode(secret, code)
+	require.NoError(t, err)
+	assert.Equal(t, QRClaims{ShopID: 1001, TableID: tbl.ID, QRVersion: 1}, claims)
+	assert.NoError(t, tbl.CheckQRClaims(claims))
+
+	t.Run("wrong secret", func(t *testing.T) {
+		_, err := ParseQRCode([]byte("other"), code)
+		assert.ErrorIs(t, err, ErrInvalidQRCode)
+	})
+
+	t.Run("tampered table", func(t *testing.T) {
+		parts := strings.Split(code, ".")
+		parts[2] = "42"
+		_, err := ParseQRCode(secret, strings.Join(parts, "."))
+		assert.ErrorIs(t, err, ErrInvalidQRCode)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, c := range []string{"", "t1.1001", "t2" + code[2:]} {
+			_, err := ParseQRCode(secret, c)
+			assert.ErrorIs(t, err, ErrInvalidQRCode, c)
+		}
+	})
+
+	t.Run("regenerated code invalidates old one", func(t *testing.T) {
+		tbl.RegenerateQRCode()
+		assert.ErrorIs(t, tbl.CheckQRClaims(claims), ErrInvalidQRCode)
+
+		fresh, err := ParseQRCode(secret, tbl.QRCode(secret))
+		require.NoError(t, err)
+		assert.NoError(t, tbl.CheckQRClaims(fresh))
+
+		tbl.SetActive(false)
+		assert.ErrorIs(t, tbl.CheckQRClaims(fresh), ErrTableInactive)
+	})
+}
+
+func TestSession_TransferAndMerge(t *testing.T) {
+	a1, _ := NewTable(1001, "A1", 4)
+	a2, _ := NewTable(1001, "A2", 4)
+	a3, _ := NewTable(1001, "A3", 4)
+
+	s1, err := NewSession(a1, 2)
+	require.NoError(t, err)
+	s2, err := NewSession(a2, 3)
+	require.NoError(t, err)
+
+	assert.EqualError(t, s1.TransferTo(a1), "目标桌台与当前桌台相同")
+	require.NoError(t, s1.TransferTo(a3))
+	assert.Equal(t, a3.ID, s1.TableID)
+
+	assert.EqualError(t, s1.MergeInto(s1), "不能与同一桌台并台")
+	require.NoError(t, s1.MergeInto(s2))
+	assert.Equal(t, SessionStatusClosed, s1.Status)
+	assert.Equal(t, CloseReasonMerged, s1.CloseReason)
+	assert.Equal(t, s2.ID, s1.MergedInto)
+	assert.Equal(t, 5, s2.Guests)
+
+	assert.ErrorIs(t, s1.Close(CloseReasonManual), ErrSessionClosed)
+	assert.ErrorIs(t, s1.TransferTo(a1), ErrSessionClosed)
+
+	a1.SetActive(false)
+	_, err = NewSession(a1, 0)
+	assert.ErrorIs(t, err, ErrTableInactive)
+}
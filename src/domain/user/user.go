@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"orderease/domain/shared"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 type UserRole string
@@ -82,6 +84,14 @@ func (u *User) UpdateBasicInfo(name, phone, address string) error {
 	return nil
 }
 
+// CheckPassword 校验用户密码（数据库中存储的是 bcrypt 哈希）
+func (u *User) CheckPassword(password string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)); err != nil {
+		return errors.New("密码错误")
+	}
+	return nil
+}
+
 func (u *User) UpdatePassword(newPassword string) error {
 	if newPassword == "" {
 		return errors.New("新密码不能为空")
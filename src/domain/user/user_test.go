@@ -7,6 +7,7 @@ import (
 	"orderease/domain/shared"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestUserRole_IsValid(t *testing.T) {
@@ -279,6 +280,35 @@ func TestUser_UpdatePassword(t *testing.T) {
 	}
 }
 
+func TestUser_CheckPassword(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("OldPass#123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{name: "correct password", password: "OldPass#123", wantErr: false},
+		{name: "wrong password", password: "WrongPass#123", wantErr: true},
+		{name: "empty password", password: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &User{Password: string(hashed)}
+			err := u.CheckPassword(tt.password)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "密码错误")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestUser_IsSystemUser(t *testing.T) {
 	tests := []struct {
 		name     string
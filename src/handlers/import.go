@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"orderease/models"
 	"orderease/utils/log2"
+	"orderease/utils/money"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -284,6 +285,8 @@ func createImportConverter(value reflect.Value) (func(string) (interface{}, erro
 		return parseTime, nil
 	case fieldType == reflect.TypeOf(models.Price(0)):
 		return parsePrice, nil
+	case fieldType == reflect.TypeOf(models.Rate(0)):
+		return parseRate, nil
 	case fieldType == reflect.TypeOf(snowflake.ID(0)):
 		return parseSnowflakeID, nil
 	case fieldType == reflect.TypeOf(datatypes.JSON{}):
@@ -468,9 +471,13 @@ func parseTime(s string) (interface{}, error) {
 	return time.Parse(time.RFC3339, s)
 }
 
+// parsePrice 按十进制解析金额，兼容旧版导出的浮点格式（如 29.900000000000002）
 func parsePrice(s string) (interface{}, error) {
-	f, err := strconv.ParseFloat(s, 64)
-	return models.Price(f), err
+	return money.ParsePrice(s)
+}
+
+func parseRate(s string) (interface{}, error) {
+	return money.ParseRate(s)
 }
 
 func parseSnowflakeID(s string) (interface{}, error) {
@@ -119,7 +119,7 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 
 	tx := h.DB.Begin()
 
-	totalPrice := float64(0.0)
+	totalPrice := models.Price(0)
 	// 更新商品库存并保存商品快照
 	for i := range order.Items {
 		var product models.Product
@@ -145,7 +145,7 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 		order.Items[i].Price = models.Price(product.Price) // 使用当前价格
 
 		// 处理订单项参数选项
-		itemTotalPrice := float64(order.Items[i].Quantity) * product.Price
+		itemTotalPrice := product.Price.Multiply(order.Items[i].Quantity)
 		for j := range order.Items[i].Options {
 			// 获取参数选项信息
 			var option models.ProductOption
@@ -179,11 +179,11 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 			order.Items[i].Options[j].PriceAdjustment = option.PriceAdjustment
 
 			// 计算参数选项对总价的影响
-			itemTotalPrice += float64(order.Items[i].Quantity) * option.PriceAdjustment
+			itemTotalPrice += option.PriceAdjustment.Multiply(order.Items[i].Quantity)
 		}
 
 		// 设置订单项总价
-		order.Items[i].TotalPrice = models.Price(itemTotalPrice)
+		order.Items[i].TotalPrice = itemTotalPrice
 
 		// 更新库存
 		product.Stock -= order.Items[i].Quantity
@@ -196,7 +196,7 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 		}
 	}
 
-	order.TotalPrice = models.Price(totalPrice)
+	order.TotalPrice = totalPrice
 	// 雪花ID生成逻辑
 	order.ID = utils.GenerateSnowflakeID()
 	// 设置订单初始状态
@@ -534,7 +534,7 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 		return
 	}
 
-	totalPrice := float64(0.0)
+	totalPrice := models.Price(0)
 
 	// 创建新的订单项
 	var orderItems []models.OrderItem
@@ -557,7 +557,7 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 		// 处理选中的选项
 		var options []models.OrderItemOption
 
-		itemTotalPrice := float64(orderItem.Quantity) * product.Price
+		itemTotalPrice := product.Price.Multiply(orderItem.Quantity)
 
 		for _, optionReq := range itemReq.Options {
 			// 获取参数选项信息
@@ -587,11 +587,11 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 				CategoryName:    category.Name,
 				PriceAdjustment: option.PriceAdjustment,
 			})
-			itemTotalPrice += float64(orderItem.Quantity) * option.PriceAdjustment
+			itemTotalPrice += option.PriceAdjustment.Multiply(orderItem.Quantity)
 		}
 		// 设置订单项总价
 		orderItem.Options = options
-		orderItem.TotalPrice = models.Price(itemTotalPrice)
+		orderItem.TotalPrice = itemTotalPrice
 		orderItems = append(orderItems, orderItem)
 		totalPrice += itemTotalPrice
 	}
@@ -604,7 +604,7 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 		return
 	}
 
-	order.TotalPrice = models.Price(totalPrice)
+	order.TotalPrice = totalPrice
 
 	// 更新订单信息
 	if err := tx.Save(&order).Error; err != nil {
@@ -638,7 +638,7 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 		responseItem := CreateOrderItemRequest{
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
-			Price:     float64(item.Price),
+			Price:     item.Price.ToFloat64(),
 			Options:   responseOptions,
 		}
 		responseItems = append(responseItems, responseItem)
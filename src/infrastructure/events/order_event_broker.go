@@ -0,0 +1,166 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"orderease/config"
+	"orderease/domain/order"
+	"orderease/domain/product"
+	"orderease/utils/log2"
+)
+
+const (
+	// defaultReplayBufferSize 每个店铺保留的最近事件数量，用于 Last-Event-ID 补发
+	defaultReplayBufferSize = 200
+	// subscriberChannelSize 单个订阅者的缓冲区大小，写满视为慢消费者
+	subscriberChannelSize = 64
+)
+
+// Subscription 某个店铺订单事件的订阅
+// 当订阅者消费过慢导致缓冲区写满时，事件总线会关闭 Events 通道，
+// 客户端应携带最后收到的事件ID重连，以便从补发缓冲区中恢复
+type Subscription struct {
+	ShopID uint64
+	Events <-chan order.OrderEvent
+
+	ch chan order.OrderEvent
+}
+
+type shopStream struct {
+	lastID      uint64
+	buffer      []order.OrderEvent
+	subscribers map[*Subscription]struct{}
+}
+
+// OrderEventBroker 基于内存的订单事件总线
+// 按店铺隔离事件流，并为每个店铺维护一个有界的补发缓冲区
+type OrderEventBroker struct {
+	mu         sync.Mutex
+	bufferSize int
+	baseID     uint64
+	shops      map[uint64]*shopStream
+}
+
+// NewOrderEventBroker 创建订单事件总线
+func NewOrderEventBroker() *OrderEventBroker {
+	bufferSize := config.AppConfig.Events.ReplayBufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultReplayBufferSize
+	}
+	return newOrderEventBroker(bufferSize)
+}
+
+func newOrderEventBroker(bufferSize int) *OrderEventBroker {
+	return &OrderEventBroker{
+		bufferSize: bufferSize,
+		// 以启动时间作为事件ID起点，服务重启后新事件ID仍大于客户端持有的旧ID
+		baseID: uint64(time.Now().UnixMicro()),
+		shops:  make(map[uint64]*shopStream),
+	}
+}
+
+func (b *OrderEventBroker) stream(shopID uint64) *shopStream {
+	s, ok := b.shops[shopID]
+	if !ok {
+		s = &shopStream{
+			lastID:      b.baseID,
+			subscribers: make(map[*Subscription]struct{}),
+		}
+		b.shops[shopID] = s
+	}
+	return s
+}
+
+// Publish 发布订单事件，实现 order.EventPublisher 接口
+func (b *OrderEventBroker) Publish(event order.OrderEvent) {
+	if event.ShopID == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.stream(event.ShopID)
+	s.lastID++
+	event.ID = s.lastID
+
+	if len(s.buffer) >= b.bufferSize {
+		s.buffer = append(s.buffer[:0], s.buffer[len(s.buffer)-b.bufferSize+1:]...)
+	}
+	s.buffer = append(s.buffer, event)
+
+	for sub := range s.subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+			log2.Warnf("订单事件订阅者消费过慢，断开订阅, shopID: %d", event.ShopID)
+			delete(s.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// PublishStockEvent 发布商品库存事件，实现 product.StockEventPublisher 接口
+// 库存事件与订单事件共用店铺事件流和事件ID，后台只需订阅一条连接
+func (b *OrderEventBroker) PublishStockEvent(event product.StockEvent) {
+	b.Publish(order.OrderEvent{
+		Type:       order.OrderEventType(event.Type),
+		ShopID:     event.ShopID,
+		OccurredAt: event.OccurredAt,
+		Stock:      &event,
+	})
+}
+
+// Subscribe 订阅店铺订单事件
+// lastEventID 为客户端最后收到的事件ID（0 表示不补发），返回需要补发的事件；
+// 若请求的事件已被挤出缓冲区，gap 为 true，客户端应重新全量拉取订单
+func (b *OrderEventBroker) Subscribe(shopID uint64, lastEventID uint64) (sub *Subscription, replay []order.OrderEvent, gap bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.stream(shopID)
+
+	if lastEventID > 0 && lastEventID < s.lastID {
+		if len(s.buffer) == 0 || s.buffer[0].ID > lastEventID+1 {
+			gap = true
+		}
+		for _, event := range s.buffer {
+			if event.ID > lastEventID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	ch := make(chan order.OrderEvent, subscriberChannelSize)
+	sub = &Subscription{ShopID: shopID, Events: ch, ch: ch}
+	s.subscribers[sub] = struct{}{}
+
+	return sub, replay, gap
+}
+
+// Unsubscribe 取消订阅
+func (b *OrderEventBroker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s, ok := b.shops[sub.ShopID]
+	if !ok {
+		return
+	}
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// SubscriberCount 返回店铺当前的订阅者数量
+func (b *OrderEventBroker) SubscriberCount(shopID uint64) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if s, ok := b.shops[shopID]; ok {
+		return len(s.subscribers)
+	}
+	return 0
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"testing"
+
+	"orderease/domain/order"
+	"orderease/domain/shared"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func publishN(b *OrderEventBroker, shopID uint64, n int) {
+	for i := 0; i < n; i++ {
+		b.Publish(order.OrderEvent{Type: order.OrderEventCreated, ShopID: shopID, OrderID: shared.ID(i + 1)})
+	}
+}
+
+func TestOrderEventBroker_PublishAndSubscribe(t *testing.T) {
+	b := newOrderEventBroker(10)
+
+	sub, replay, gap := b.Subscribe(1, 0)
+	other, _, _ := b.Subscribe(2, 0)
+	assert.Empty(t, replay)
+	assert.False(t, gap)
+
+	publishN(b, 1, 1)
+
+	event := <-sub.Events
+	assert.Equal(t, uint64(1), event.ShopID)
+	assert.Equal(t, b.baseID+1, event.ID)
+	assert.Len(t, other.Events, 0, "其他店铺不应收到事件")
+
+	b.Unsubscribe(sub)
+	_, ok := <-sub.Events
+	assert.False(t, ok)
+	assert.Equal(t, 0, b.SubscriberCount(1))
+}
+
+func TestOrderEventBroker_Replay(t *testing.T) {
+	tests := []struct {
+		name       string
+		published  int
+		lastOffset uint64
+		wantReplay int
+		wantGap    bool
+	}{
+		{name: "no last event id", published: 5, lastOffset: 0, wantReplay: 0, wantGap: false},
+		{name: "replay missed events", published: 5, lastOffset: 2, wantReplay: 3, wantGap: false},
+		{name: "up to date", published: 5, lastOffset: 5, wantReplay: 0, wantGap: false},
+		{name: "evicted from buffer", published: 8, lastOffset: 1, wantReplay: 4, wantGap: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newOrderEventBroker(4)
+			publishN(b, 1, tt.published)
+
+			lastEventID := uint64(0)
+			if tt.lastOffset > 0 {
+				lastEventID = b.baseID + tt.lastOffset
+			}
+			_, replay, gap := b.Subscribe(1, lastEventID)
+
+			assert.Len(t, replay, tt.wantReplay)
+			assert.Equal(t, tt.wantGap, gap)
+			for _, event := range replay {
+				assert.Greater(t, event.ID, lastEventID)
+			}
+		})
+	}
+}
+
+func TestOrderEventBroker_SlowSubscriberDropped(t *testing.T) {
+	b := newOrderEventBroker(10)
+	sub, _, _ := b.Subscribe(1, 0)
+
+	publishN(b, 1, subscriberChannelSize+1)
+
+	received := 0
+	for range sub.Events {
+		received++
+	}
+	assert.Equal(t, subscriberChannelSize, received)
+	assert.Equal(t, 0, b.SubscriberCount(1))
+}
+
+func TestOrderEventBroker_IgnoresZeroShop(t *testing.T) {
+	b := newOrderEventBroker(10)
+	publishN(b, 0, 1)
+	assert.Empty(t, b.shops)
+}
@@ -0,0 +1,22 @@
+package gateways
+
+import (
+	"orderease/config"
+	"orderease/domain/payment"
+	"orderease/utils/log2"
+)
+
+// NewGateways 按配置创建已启用的支付网关
+// 接入微信支付、支付宝时在此实现 payment.Gateway 并按配置注册
+func NewGateways() []payment.Gateway {
+	var result []payment.Gateway
+
+	if config.MockPaymentEnabled() {
+		log2.Warnf("模拟支付网关已启用，生产环境请关闭")
+		result = append(result, NewMockGateway(config.AppConfig.Payment.Mock.Secret))
+	} else if config.AppConfig.Payment.Mock.Enabled {
+		log2.Warnf("模拟支付未配置签名密钥（环境变量 PAYMENT_MOCK_SECRET），已跳过")
+	}
+
+	return result
+}
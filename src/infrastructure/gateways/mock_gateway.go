@@ -0,0 +1,120 @@
+package gateways
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"orderease/domain/payment"
+	"orderease/domain/shared"
+)
+
+// MockSignatureHeader 模拟支付回调的签名请求头
+const MockSignatureHeader = "X-Mock-Signature"
+
+// mockNotification 模拟支付回调的报文格式
+type mockNotification struct {
+	PaymentID string       `json:"payment_id"`
+	TradeNo   string       `json:"trade_no"`
+	Status    string       `json:"status"` // SUCCESS/FAIL
+	Amount    shared.Price `json:"amount"`
+	Reason    string       `json:"reason,omitempty"`
+	PaidAt    int64        `json:"paid_at,omitempty"`
+}
+
+// MockGateway 本地模拟支付网关
+// 与微信支付、支付宝一样通过签名回调通知支付结果，签名为报文的 HMAC-SHA256，放在 X-Mock-Signature 请求头中
+type MockGateway struct {
+	secret []byte
+}
+
+func NewMockGateway(secret string) *MockGateway {
+	return &MockGateway{secret: []byte(secret)}
+}
+
+func (g *MockGateway) Method() payment.Method {
+	return payment.MethodMock
+}
+
+func (g *MockGateway) CreateIntent(p *payment.Payment) (*payment.Intent, error) {
+	tradeNo := "MOCK" + p.ID.String()
+	return &payment.Intent{
+		ProviderTradeNo: tradeNo,
+		PayParams: map[string]string{
+			"trade_no":   tradeNo,
+			"payment_id": p.ID.String(),
+			"amount":     p.Amount.String(),
+		},
+	}, nil
+}
+
+func (g *MockGateway) ParseNotification(headers payment.Headers, body []byte) (*payment.Notification, error) {
+	if !hmac.Equal([]byte(headers.Get(MockSignatureHeader)), []byte(g.Sign(body))) {
+		return nil, payment.ErrInvalidSignature
+	}
+
+	var msg mockNotification
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("解析支付回调失败: %v", err)
+	}
+	paymentID, err := shared.ParseIDFromString(msg.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("支付回调缺少支付单号: %v", err)
+	}
+
+	n := &payment.Notification{
+		PaymentID:       paymentID,
+		ProviderTradeNo: msg.TradeNo,
+		Success:         msg.Status == "SUCCESS",
+		Amount:          msg.Amount,
+		FailureReason:   msg.Reason,
+	}
+	if msg.PaidAt > 0 {
+		n.PaidAt = time.Unix(msg.PaidAt, 0)
+	}
+	return n, nil
+}
+
+// Refund 模拟网关的退款立即成功
+func (g *MockGateway) Refund(p *payment.Payment, r *payment.Refund) (*payment.RefundResult, error) {
+	return &payment.RefundResult{ProviderRefundNo: "MOCKR" + r.ID.String()}, nil
+}
+
+func (g *MockGateway) AckBody() []byte {
+	return []byte(`{"code":"SUCCESS"}`)
+}
+
+// Sign 计算报文签名
+func (g *MockGateway) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Simulate 生成一条已签名的支付结果回调，模拟顾客在支付网关完成或放弃支付
+func (g *MockGateway) Simulate(p *payment.Payment, success bool) (payment.Headers, []byte, error) {
+	msg := mockNotification{
+		PaymentID: p.ID.String(),
+		TradeNo:   "MOCK" + p.ID.String(),
+		Status:    "SUCCESS",
+		Amount:    p.Amount,
+		PaidAt:    time.Now().Unix(),
+	}
+	if !success {
+		msg.Status = "FAIL"
+		msg.Reason = "用户取消支付"
+		msg.PaidAt = 0
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	headers := http.Header{}
+	headers.Set(MockSignatureHeader, g.Sign(body))
+	return headers, body, nil
+}
@@ -1,10 +1,17 @@
 package persistence
 
 import (
+	"encoding/json"
+	"time"
+
+	"orderease/domain/cart"
 	"orderease/domain/order"
+	"orderease/domain/payment"
 	"orderease/domain/product"
+	"orderease/domain/promotion"
 	"orderease/domain/shared"
 	"orderease/domain/shop"
+	"orderease/domain/table"
 	"orderease/domain/user"
 	"orderease/models"
 
@@ -17,16 +24,43 @@ func OrderToDomain(m models.Order) *order.Order {
 		items[i] = *OrderItemToDomain(item)
 	}
 
+	discounts := make([]order.OrderDiscount, len(m.Discounts))
+	for i, discount := range m.Discounts {
+		discounts[i] = *OrderDiscountToDomain(discount)
+	}
+
 	return &order.Order{
-		ID:         shared.ID(m.ID),
-		UserID:     shared.ID(m.UserID),
-		ShopID:     uint64(m.ShopID),
-		TotalPrice: shared.Price(m.TotalPrice),
-		Status:     order.OrderStatus(m.Status),
-		Remark:     m.Remark,
-		CreatedAt:  m.CreatedAt,
-		UpdatedAt:  m.UpdatedAt,
-		Items:      items,
+		ID:              shared.ID(m.ID),
+		UserID:          shared.ID(m.UserID),
+		ShopID:          uint64(m.ShopID),
+		Subtotal:        m.Subtotal,
+		DiscountAmount:  m.DiscountAmount,
+		ServiceCharge:   m.ServiceCharge,
+		PackagingFee:    m.PackagingFee,
+		DeliveryFee:     m.DeliveryFee,
+		TaxAmount:       m.TaxAmount,
+		TaxRate:         m.TaxRate,
+		TaxInclusive:    m.TaxInclusive,
+		Takeaway:        m.Takeaway,
+		FulfillmentType: order.FulfillmentType(m.FulfillmentType),
+		RecipientName:   m.RecipientName,
+		RecipientPhone:  m.RecipientPhone,
+		DeliveryAddress: m.DeliveryAddress,
+		ScheduledTime:   m.ScheduledTime,
+		PickupNumber:    m.PickupNumber,
+		BusinessDate:    m.BusinessDate,
+		TableID:         shared.ID(m.TableID),
+		TableSessionID:  shared.ID(m.TableSessionID),
+		TotalPrice:      shared.Price(m.TotalPrice),
+		PaymentStatus:   order.PaymentStatus(m.PaymentStatus),
+		PaidAmount:      m.PaidAmount,
+		RefundedAmount:  m.RefundedAmount,
+		Status:          order.OrderStatus(m.Status),
+		Remark:          m.Remark,
+		CreatedAt:       m.CreatedAt,
+		UpdatedAt:       m.UpdatedAt,
+		Items:           items,
+		Discounts:       discounts,
 	}
 }
 
@@ -37,15 +71,36 @@ func OrderToModel(d *order.Order) *models.Order {
 	}
 
 	return &models.Order{
-		ID:         d.ID.Value(),
-		UserID:     d.UserID.Value(),
-		ShopID:     snowflake.ID(d.ShopID),
-		TotalPrice: models.Price(d.TotalPrice),
-		Status:     int(d.Status),
-		Remark:     d.Remark,
-		CreatedAt:  d.CreatedAt,
-		UpdatedAt:  d.UpdatedAt,
-		Items:      items,
+		ID:              d.ID.Value(),
+		UserID:          d.UserID.Value(),
+		ShopID:          snowflake.ID(d.ShopID),
+		Subtotal:        d.Subtotal,
+		DiscountAmount:  d.DiscountAmount,
+		ServiceCharge:   d.ServiceCharge,
+		PackagingFee:    d.PackagingFee,
+		DeliveryFee:     d.DeliveryFee,
+		TaxAmount:       d.TaxAmount,
+		TaxRate:         d.TaxRate,
+		TaxInclusive:    d.TaxInclusive,
+		Takeaway:        d.Takeaway,
+		FulfillmentType: string(d.FulfillmentType),
+		RecipientName:   d.RecipientName,
+		RecipientPhone:  d.RecipientPhone,
+		DeliveryAddress: d.DeliveryAddress,
+		ScheduledTime:   d.ScheduledTime,
+		PickupNumber:    d.PickupNumber,
+		BusinessDate:    d.BusinessDate,
+		TableID:         d.TableID.Value(),
+		TableSessionID:  d.TableSessionID.Value(),
+		TotalPrice:      models.Price(d.TotalPrice),
+		PaymentStatus:   string(d.PaymentStatus),
+		PaidAmount:      d.PaidAmount,
+		RefundedAmount:  d.RefundedAmount,
+		Status:          int(d.Status),
+		Remark:          d.Remark,
+		CreatedAt:       d.CreatedAt,
+		UpdatedAt:       d.UpdatedAt,
+		Items:           items,
 	}
 }
 
@@ -117,12 +172,44 @@ func OrderItemOptionToModel(d order.OrderItemOption) models.OrderItemOption {
 	}
 }
 
-func OrderStatusLogToDomain(m models.OrderStatusLog) *order.OrderStatusLog {
-	return &order.OrderStatusLog{
+func OrderDiscountToDomain(m models.OrderDiscount) *order.OrderDiscount {
+	return &order.OrderDiscount{
 		ID:          shared.ID(m.ID),
 		OrderID:     shared.ID(m.OrderID),
-		OldStatus:   order.OrderStatus(m.OldStatus),
-		NewStatus:   order.OrderStatus(m.NewStatus),
+		PromotionID: shared.ID(m.PromotionID),
+		Name:        m.Name,
+		Code:        m.Code,
+		Type:        m.Type,
+		Amount:      m.Amount,
+		CreatedAt:   m.CreatedAt,
+	}
+}
+
+func OrderDiscountToModel(d order.OrderDiscount) models.OrderDiscount {
+	return models.OrderDiscount{
+		ID:          d.ID.Value(),
+		OrderID:     d.OrderID.Value(),
+		PromotionID: d.PromotionID.Value(),
+		Name:        d.Name,
+		Code:        d.Code,
+		Type:        d.Type,
+		Amount:      d.Amount,
+		CreatedAt:   d.CreatedAt,
+	}
+}
+
+func OrderStatusLogToDomain(m models.OrderStatusLog) *order.OrderStatusLog {
+	return &order.OrderStatusLog{
+		ID:        shared.ID(m.ID),
+		OrderID:   shared.ID(m.OrderID),
+		OldStatus: order.OrderStatus(m.OldStatus),
+		NewStatus: order.OrderStatus(m.NewStatus),
+		Actor: order.StatusActor{
+			Type: m.ActorType,
+			ID:   m.ActorID,
+			Name: m.ActorName,
+		},
+		Reason:      m.Reason,
 		ChangedTime: m.ChangedTime,
 	}
 }
@@ -133,10 +220,74 @@ func OrderStatusLogToModel(d *order.OrderStatusLog) *models.OrderStatusLog {
 		OrderID:     d.OrderID.Value(),
 		OldStatus:   int(d.OldStatus),
 		NewStatus:   int(d.NewStatus),
+		ActorType:   d.Actor.Type,
+		ActorID:     d.Actor.ID,
+		ActorName:   d.Actor.Name,
+		Reason:      d.Reason,
 		ChangedTime: d.ChangedTime,
 	}
 }
 
+func OrderRefundToDomain(m models.OrderRefund) *order.OrderRefund {
+	items := make([]order.OrderRefundItem, len(m.Items))
+	for i, item := range m.Items {
+		items[i] = order.OrderRefundItem{
+			ID:          shared.ID(item.ID),
+			RefundID:    shared.ID(item.RefundID),
+			OrderItemID: shared.ID(item.OrderItemID),
+			ProductID:   shared.ID(item.ProductID),
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			Amount:      item.Amount,
+		}
+	}
+
+	return &order.OrderRefund{
+		ID:           shared.ID(m.ID),
+		OrderID:      shared.ID(m.OrderID),
+		ShopID:       uint64(m.ShopID),
+		Amount:       m.Amount,
+		Reason:       m.Reason,
+		RestoreStock: m.RestoreStock,
+		Actor: order.StatusActor{
+			Type: m.ActorType,
+			ID:   m.ActorID,
+			Name: m.ActorName,
+		},
+		Items:     items,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+func OrderRefundToModel(d *order.OrderRefund) *models.OrderRefund {
+	items := make([]models.OrderRefundItem, len(d.Items))
+	for i, item := range d.Items {
+		items[i] = models.OrderRefundItem{
+			ID:          item.ID.Value(),
+			RefundID:    d.ID.Value(),
+			OrderItemID: item.OrderItemID.Value(),
+			ProductID:   item.ProductID.Value(),
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			Amount:      item.Amount,
+		}
+	}
+
+	return &models.OrderRefund{
+		ID:           d.ID.Value(),
+		OrderID:      d.OrderID.Value(),
+		ShopID:       snowflake.ID(d.ShopID),
+		Amount:       d.Amount,
+		Reason:       d.Reason,
+		RestoreStock: d.RestoreStock,
+		ActorType:    d.Actor.Type,
+		ActorID:      d.Actor.ID,
+		ActorName:    d.Actor.Name,
+		CreatedAt:    d.CreatedAt,
+		Items:        items,
+	}
+}
+
 func ProductToDomain(m models.Product) *product.Product {
 	categories := make([]product.ProductOptionCategory, len(m.OptionCategories))
 	for i, cat := range m.OptionCategories {
@@ -144,17 +295,19 @@ func ProductToDomain(m models.Product) *product.Product {
 	}
 
 	return &product.Product{
-		ID:               shared.ID(m.ID),
-		ShopID:           uint64(m.ShopID),
-		Name:             m.Name,
-		Description:      m.Description,
-		Price:            shared.Price(m.Price),
-		Stock:            m.Stock,
-		ImageURL:         m.ImageURL,
-		Status:           product.ProductStatus(m.Status),
-		CreatedAt:        m.CreatedAt,
-		UpdatedAt:        m.UpdatedAt,
-		OptionCategories: categories,
+		ID:                shared.ID(m.ID),
+		ShopID:            uint64(m.ShopID),
+		Name:              m.Name,
+		Description:       m.Description,
+		Price:             shared.Price(m.Price),
+		Stock:             m.Stock,
+		ImageURL:          m.ImageURL,
+		Status:            product.ProductStatus(m.Status),
+		CreatedAt:         m.CreatedAt,
+		UpdatedAt:         m.UpdatedAt,
+		OptionCategories:  categories,
+		LowStockThreshold: m.LowStockThreshold,
+		AutoOffline:       m.AutoOffline,
 	}
 }
 
@@ -165,17 +318,19 @@ func ProductToModel(d *product.Product) *models.Product {
 	}
 
 	return &models.Product{
-		ID:               d.ID.Value(),
-		ShopID:           snowflake.ID(d.ShopID),
-		Name:             d.Name,
-		Description:      d.Description,
-		Price:            float64(d.Price),
-		Stock:            d.Stock,
-		ImageURL:         d.ImageURL,
-		Status:           string(d.Status),
-		CreatedAt:        d.CreatedAt,
-		UpdatedAt:        d.UpdatedAt,
-		OptionCategories: categories,
+		ID:                d.ID.Value(),
+		ShopID:            snowflake.ID(d.ShopID),
+		Name:              d.Name,
+		Description:       d.Description,
+		Price:             models.Price(d.Price),
+		Stock:             d.Stock,
+		ImageURL:          d.ImageURL,
+		Status:            string(d.Status),
+		CreatedAt:         d.CreatedAt,
+		UpdatedAt:         d.UpdatedAt,
+		OptionCategories:  categories,
+		LowStockThreshold: d.LowStockThreshold,
+		AutoOffline:       d.AutoOffline,
 	}
 }
 
@@ -191,6 +346,8 @@ func ProductOptionCategoryToDomain(m models.ProductOptionCategory) *product.Prod
 		Name:         m.Name,
 		IsRequired:   m.IsRequired,
 		IsMultiple:   m.IsMultiple,
+		MinSelect:    m.MinSelect,
+		MaxSelect:    m.MaxSelect,
 		DisplayOrder: m.DisplayOrder,
 		CreatedAt:    m.CreatedAt,
 		UpdatedAt:    m.UpdatedAt,
@@ -210,6 +367,8 @@ func ProductOptionCategoryToModel(d product.ProductOptionCategory) models.Produc
 		Name:         d.Name,
 		IsRequired:   d.IsRequired,
 		IsMultiple:   d.IsMultiple,
+		MinSelect:    d.MinSelect,
+		MaxSelect:    d.MaxSelect,
 		DisplayOrder: d.DisplayOrder,
 		CreatedAt:    d.CreatedAt,
 		UpdatedAt:    d.UpdatedAt,
@@ -244,6 +403,9 @@ func ProductOptionToModel(d product.ProductOption) models.ProductOption {
 }
 
 func ShopToDomain(m models.Shop) *shop.Shop {
+	// 营业时间写入前已校验，解析失败的历史数据按全天营业处理
+	hours, _ := shop.ParseBusinessHours(string(m.BusinessHours))
+
 	return &shop.Shop{
 		ID:            shared.ID(m.ID),
 		Name:          m.Name,
@@ -259,12 +421,19 @@ func ShopToDomain(m models.Shop) *shop.Shop {
 		ValidUntil:    m.ValidUntil,
 		Settings:      string(m.Settings),
 		OrderStatusFlow: order.OrderStatusFlow{
-			Statuses: convertOrderStatuses(m.OrderStatusFlow.Statuses),
+			Statuses:       convertOrderStatuses(m.OrderStatusFlow.Statuses),
+			RequirePayment: m.OrderStatusFlow.RequirePayment,
 		},
+		AutoOfflineOnZeroStock: m.AutoOfflineOnZeroStock,
+		Timezone:               m.Timezone,
+		BusinessHours:          hours,
+		OrderingPaused:         m.OrderingPaused,
 	}
 }
 
 func ShopToModel(d *shop.Shop) *models.Shop {
+	hours, _ := json.Marshal(d.BusinessHours)
+
 	return &models.Shop{
 		ID:            snowflake.ID(d.ID),
 		Name:          d.Name,
@@ -280,8 +449,41 @@ func ShopToModel(d *shop.Shop) *models.Shop {
 		ValidUntil:    d.ValidUntil,
 		Settings:      []byte(d.Settings),
 		OrderStatusFlow: models.OrderStatusFlow{
-			Statuses: convertOrderStatusesToModel(d.OrderStatusFlow.Statuses),
+			Statuses:       convertOrderStatusesToModel(d.OrderStatusFlow.Statuses),
+			RequirePayment: d.OrderStatusFlow.RequirePayment,
 		},
+		AutoOfflineOnZeroStock: d.AutoOfflineOnZeroStock,
+		Timezone:               d.Timezone,
+		BusinessHours:          hours,
+		OrderingPaused:         d.OrderingPaused,
+	}
+}
+
+func StaffToDomain(m models.ShopStaff) *shop.Staff {
+	return &shop.Staff{
+		ID:        shared.ID(m.ID),
+		ShopID:    shared.ID(m.ShopID),
+		Username:  m.Username,
+		Password:  m.Password,
+		Name:      m.Name,
+		Role:      shop.StaffRole(m.Role),
+		Active:    m.Active,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
+
+func StaffToModel(d *shop.Staff) *models.ShopStaff {
+	return &models.ShopStaff{
+		ID:        snowflake.ID(d.ID),
+		ShopID:    snowflake.ID(d.ShopID),
+		Username:  d.Username,
+		Password:  d.Password,
+		Name:      d.Name,
+		Role:      string(d.Role),
+		Active:    d.Active,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
 	}
 }
 
@@ -294,14 +496,17 @@ func convertOrderStatuses(statuses []models.OrderStatus) []order.OrderStatusConf
 				Name:            a.Name,
 				NextStatus:      order.OrderStatus(a.NextStatus),
 				NextStatusLabel: a.NextStatusLabel,
+				RequireReason:   a.RequireReason,
 			}
 		}
 		result[i] = order.OrderStatusConfig{
-			Value:   order.OrderStatus(s.Value),
-			Label:   s.Label,
-			Type:    s.Type,
-			IsFinal: s.IsFinal,
-			Actions: actions,
+			Value:          order.OrderStatus(s.Value),
+			Label:          s.Label,
+			Type:           s.Type,
+			IsFinal:        s.IsFinal,
+			ReleaseStock:   s.ReleaseStock,
+			ReadyForPickup: s.ReadyForPickup,
+			Actions:        actions,
 		}
 	}
 	return result
@@ -316,14 +521,17 @@ func convertOrderStatusesToModel(statuses []order.OrderStatusConfig) []models.Or
 				Name:            a.Name,
 				NextStatus:      int(a.NextStatus),
 				NextStatusLabel: a.NextStatusLabel,
+				RequireReason:   a.RequireReason,
 			}
 		}
 		result[i] = models.OrderStatus{
-			Value:   int(s.Value),
-			Label:   s.Label,
-			Type:    s.Type,
-			IsFinal: s.IsFinal,
-			Actions: actions,
+			Value:          int(s.Value),
+			Label:          s.Label,
+			Type:           s.Type,
+			IsFinal:        s.IsFinal,
+			ReleaseStock:   s.ReleaseStock,
+			ReadyForPickup: s.ReadyForPickup,
+			Actions:        actions,
 		}
 	}
 	return result
@@ -378,3 +586,278 @@ func UserToModel(d *user.User) *models.User {
 		UpdatedAt: d.UpdatedAt,
 	}
 }
+
+func PromotionToDomain(m models.Promotion) *promotion.Promotion {
+	productIDs := make([]shared.ID, len(m.Scope.ProductIDs))
+	for i, id := range m.Scope.ProductIDs {
+		productIDs[i] = shared.ID(id)
+	}
+
+	p := &promotion.Promotion{
+		ID:           shared.ID(m.ID),
+		ShopID:       shared.ID(m.ShopID),
+		Name:         m.Name,
+		Type:         promotion.DiscountType(m.Type),
+		Amount:       m.Amount,
+		Percent:      m.Percent,
+		NthItem:      m.NthItem,
+		MaxDiscount:  m.MaxDiscount,
+		MinSpend:     m.MinSpend,
+		UsageLimit:   m.UsageLimit,
+		PerUserLimit: m.PerUserLimit,
+		UsedCount:    m.UsedCount,
+		ProductIDs:   productIDs,
+		TagIDs:       m.Scope.TagIDs,
+		Active:       m.Active,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+	}
+	if m.Code != nil {
+		p.Code = *m.Code
+	}
+	if m.StartAt != nil {
+		p.StartAt = *m.StartAt
+	}
+	if m.EndAt != nil {
+		p.EndAt = *m.EndAt
+	}
+	return p
+}
+
+func PromotionToModel(d *promotion.Promotion) *models.Promotion {
+	productIDs := make([]snowflake.ID, len(d.ProductIDs))
+	for i, id := range d.ProductIDs {
+		productIDs[i] = id.Value()
+	}
+
+	m := &models.Promotion{
+		ID:           d.ID.Value(),
+		ShopID:       d.ShopID.Value(),
+		Name:         d.Name,
+		Type:         string(d.Type),
+		Amount:       d.Amount,
+		Percent:      d.Percent,
+		NthItem:      d.NthItem,
+		MaxDiscount:  d.MaxDiscount,
+		MinSpend:     d.MinSpend,
+		UsageLimit:   d.UsageLimit,
+		PerUserLimit: d.PerUserLimit,
+		UsedCount:    d.UsedCount,
+		Scope:        models.PromotionScope{ProductIDs: productIDs, TagIDs: d.TagIDs},
+		StartAt:      optionalTime(d.StartAt),
+		EndAt:        optionalTime(d.EndAt),
+		Active:       d.Active,
+		CreatedAt:    d.CreatedAt,
+		UpdatedAt:    d.UpdatedAt,
+	}
+	// 自动促销的券码存为 NULL，不占用店铺内券码唯一索引
+	if d.Code != "" {
+		code := d.Code
+		m.Code = &code
+	}
+	return m
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func PaymentToDomain(m models.Payment) *payment.Payment {
+	p := &payment.Payment{
+		ID:              shared.ID(m.ID),
+		ShopID:          shared.ID(m.ShopID),
+		OrderID:         shared.ID(m.OrderID),
+		UserID:          shared.ID(m.UserID),
+		Method:          payment.Method(m.Method),
+		Amount:          m.Amount,
+		RefundedAmount:  m.RefundedAmount,
+		Status:          payment.Status(m.Status),
+		ProviderTradeNo: m.ProviderTradeNo,
+		FailureReason:   m.FailureReason,
+		ConfirmedBy:     m.ConfirmedBy,
+		CreatedAt:       m.CreatedAt,
+		UpdatedAt:       m.UpdatedAt,
+	}
+	if m.PaidAt != nil {
+		p.PaidAt = *m.PaidAt
+	}
+	return p
+}
+
+func PaymentToModel(d *payment.Payment) *models.Payment {
+	return &models.Payment{
+		ID:              d.ID.Value(),
+		ShopID:          d.ShopID.Value(),
+		OrderID:         d.OrderID.Value(),
+		UserID:          d.UserID.Value(),
+		Method:          string(d.Method),
+		Amount:          d.Amount,
+		RefundedAmount:  d.RefundedAmount,
+		Status:          string(d.Status),
+		ProviderTradeNo: d.ProviderTradeNo,
+		FailureReason:   d.FailureReason,
+		ConfirmedBy:     d.ConfirmedBy,
+		PaidAt:          optionalTime(d.PaidAt),
+		CreatedAt:       d.CreatedAt,
+		UpdatedAt:       d.UpdatedAt,
+	}
+}
+
+func PaymentRefundToDomain(m models.PaymentRefund) *payment.Refund {
+	return &payment.Refund{
+		ID:               shared.ID(m.ID),
+		ShopID:           shared.ID(m.ShopID),
+		PaymentID:        shared.ID(m.PaymentID),
+		OrderID:          shared.ID(m.OrderID),
+		Amount:           m.Amount,
+		Reason:           m.Reason,
+		Status:           payment.RefundStatus(m.Status),
+		ProviderRefundNo: m.ProviderRefundNo,
+		FailureReason:    m.FailureReason,
+		Operator:         m.Operator,
+		CreatedAt:        m.CreatedAt,
+	}
+}
+
+func PaymentRefundToModel(d *payment.Refund) *models.PaymentRefund {
+	return &models.PaymentRefund{
+		ID:               d.ID.Value(),
+		ShopID:           d.ShopID.Value(),
+		PaymentID:        d.PaymentID.Value(),
+		OrderID:          d.OrderID.Value(),
+		Amount:           d.Amount,
+		Reason:           d.Reason,
+		Status:           string(d.Status),
+		ProviderRefundNo: d.ProviderRefundNo,
+		FailureReason:    d.FailureReason,
+		Operator:         d.Operator,
+		CreatedAt:        d.CreatedAt,
+	}
+}
+
+func CartToDomain(m models.Cart) *cart.Cart {
+	items := make([]cart.CartItem, len(m.Items))
+	for i, item := range m.Items {
+		options := make([]cart.CartItemOption, len(item.Options))
+		for j, opt := range item.Options {
+			options[j] = cart.CartItemOption{
+				CategoryID: shared.ID(opt.CategoryID),
+				OptionID:   shared.ID(opt.OptionID),
+			}
+		}
+		items[i] = cart.CartItem{
+			ID:        shared.ID(item.ID),
+			CartID:    shared.ID(item.CartID),
+			ProductID: shared.ID(item.ProductID),
+			Quantity:  item.Quantity,
+			Options:   options,
+			CreatedAt: item.CreatedAt,
+			UpdatedAt: item.UpdatedAt,
+		}
+	}
+
+	return &cart.Cart{
+		ID:        shared.ID(m.ID),
+		UserID:    shared.ID(m.UserID),
+		ShopID:    uint64(m.ShopID),
+		Items:     items,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
+
+// CartToModel 转换购物车，选项记录的ID由仓储在保存时生成
+func CartToModel(d *cart.Cart) *models.Cart {
+	items := make([]models.CartItem, len(d.Items))
+	for i, item := range d.Items {
+		options := make([]models.CartItemOption, len(item.Options))
+		for j, opt := range item.Options {
+			options[j] = models.CartItemOption{
+				CartItemID: item.ID.Value(),
+				CategoryID: opt.CategoryID.Value(),
+				OptionID:   opt.OptionID.Value(),
+			}
+		}
+		items[i] = models.CartItem{
+			ID:        item.ID.Value(),
+			CartID:    d.ID.Value(),
+			ProductID: item.ProductID.Value(),
+			Quantity:  item.Quantity,
+			CreatedAt: item.CreatedAt,
+			UpdatedAt: item.UpdatedAt,
+			Options:   options,
+		}
+	}
+
+	return &models.Cart{
+		ID:        d.ID.Value(),
+		UserID:    d.UserID.Value(),
+		ShopID:    snowflake.ID(d.ShopID),
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+		Items:     items,
+	}
+}
+
+func TableToDomain(m models.DiningTable) *table.Table {
+	return &table.Table{
+		ID:        shared.ID(m.ID),
+		ShopID:    uint64(m.ShopID),
+		Name:      m.Name,
+		Seats:     m.Seats,
+		QRVersion: m.QRVersion,
+		Active:    m.Active,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
+
+func TableToModel(d *table.Table) *models.DiningTable {
+	return &models.DiningTable{
+		ID:        d.ID.Value(),
+		ShopID:    snowflake.ID(d.ShopID),
+		Name:      d.Name,
+		Seats:     d.Seats,
+		QRVersion: d.QRVersion,
+		Active:    d.Active,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+	}
+}
+
+func TableSessionToDomain(m models.TableSession) *table.Session {
+	return &table.Session{
+		ID:          shared.ID(m.ID),
+		ShopID:      uint64(m.ShopID),
+		TableID:     shared.ID(m.TableID),
+		Status:      table.SessionStatus(m.Status),
+		Guests:      m.Guests,
+		OpenedAt:    m.OpenedAt,
+		ClosedAt:    m.ClosedAt,
+		CloseReason: table.CloseReason(m.CloseReason),
+		MergedInto:  shared.ID(m.MergedInto),
+		GuestUserID: shared.ID(m.GuestUserID),
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+func TableSessionToModel(d *table.Session) *models.TableSession {
+	return &models.TableSession{
+		ID:          d.ID.Value(),
+		ShopID:      snowflake.ID(d.ShopID),
+		TableID:     d.TableID.Value(),
+		Status:      string(d.Status),
+		Guests:      d.Guests,
+		OpenedAt:    d.OpenedAt,
+		ClosedAt:    d.ClosedAt,
+		CloseReason: string(d.CloseReason),
+		MergedInto:  d.MergedInto.Value(),
+		GuestUserID: d.GuestUserID.Value(),
+		CreatedAt:   d.CreatedAt,
+		UpdatedAt:   d.UpdatedAt,
+	}
+}
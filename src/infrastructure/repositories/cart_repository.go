@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"errors"
+	"orderease/domain/cart"
+	"orderease/domain/shared"
+	"orderease/infrastructure/persistence"
+	"orderease/models"
+	"orderease/utils"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+)
+
+type CartRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCartRepository(db *gorm.DB) cart.CartRepository {
+	return &CartRepositoryImpl{db: db}
+}
+
+func (r *CartRepositoryImpl) FindByUserAndShop(userID shared.ID, shopID uint64) (*cart.Cart, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	var model models.Cart
+	if err := scoped.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC, id ASC")
+	}).Preload("Items.Options").
+		Where("user_id = ?", userID.Value()).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log2.Errorf("查询购物车失败: %v", err)
+		return nil, errors.New("查询购物车失败")
+	}
+	return persistence.CartToDomain(model), nil
+}
+
+// Save 保存购物车，购物车项整体替换
+func (r *CartRepositoryImpl) Save(c *cart.Cart) error {
+	isNew := c.ID.IsZero()
+	if isNew {
+		c.ID = shared.ID(utils.GenerateSnowflakeID())
+	}
+	for i := range c.Items {
+		if c.Items[i].ID.IsZero() {
+			c.Items[i].ID = shared.ID(utils.GenerateSnowflakeID())
+		}
+		c.Items[i].CartID = c.ID
+	}
+
+	model := persistence.CartToModel(c)
+	for i := range model.Items {
+		for j := range model.Items[i].Options {
+			model.Items[i].Options[j].ID = utils.GenerateSnowflakeID()
+		}
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if isNew {
+			if err := tx.Omit("Items").Create(model).Error; err != nil {
+				return err
+			}
+		} else {
+			scoped, err := shopScoped(tx.Model(&models.Cart{}), c.ShopID)
+			if err != nil {
+				return err
+			}
+			result := scoped.Where("id = ?", model.ID).Update("updated_at", model.UpdatedAt)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+
+			itemIDs := tx.Model(&models.CartItem{}).Select("id").Where("cart_id = ?", model.ID)
+			if err := tx.Where("cart_item_id IN (?)", itemIDs).Delete(&models.CartItemOption{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("cart_id = ?", model.ID).Delete(&models.CartItem{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(model.Items) == 0 {
+			return nil
+		}
+		return tx.Create(&model.Items).Error
+	})
+	if err != nil {
+		if isNew {
+			c.ID = shared.ID(0)
+		}
+		log2.Errorf("保存购物车失败: %v", err)
+		return errors.New("保存购物车失败")
+	}
+	return nil
+}
@@ -6,10 +6,13 @@ import (
 	"orderease/domain/shared"
 	"orderease/infrastructure/persistence"
 	"orderease/models"
+	"orderease/utils"
 	"orderease/utils/log2"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRepositoryImpl struct {
@@ -20,9 +23,10 @@ func NewOrderRepository(db *gorm.DB) order.OrderRepository {
 	return &OrderRepositoryImpl{db: db}
 }
 
+// Save 只保存订单本身，订单项和选项由对应仓储单独保存
 func (r *OrderRepositoryImpl) Save(ord *order.Order) error {
 	model := persistence.OrderToModel(ord)
-	if err := r.db.Create(model).Error; err != nil {
+	if err := r.db.Omit(clause.Associations).Create(model).Error; err != nil {
 		log2.Errorf("保存订单失败: %v", err)
 		return errors.New("保存订单失败")
 	}
@@ -30,21 +34,14 @@ func (r *OrderRepositoryImpl) Save(ord *order.Order) error {
 	return nil
 }
 
-func (r *OrderRepositoryImpl) FindByID(id shared.ID) (*order.Order, error) {
-	var model models.Order
-	if err := r.db.Preload("Items").Preload("Items.Options").First(&model, id.Value()).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("订单不存在")
-		}
-		log2.Errorf("查询订单失败: %v", err)
-		return nil, errors.New("查询订单失败")
+func (r *OrderRepositoryImpl) FindByIDAndShopID(id shared.ID, shopID uint64) (*order.Order, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, err
 	}
-	return persistence.OrderToDomain(model), nil
-}
 
-func (r *OrderRepositoryImpl) FindByIDAndShopID(id shared.ID, shopID uint64) (*order.Order, error) {
 	var model models.Order
-	if err := r.db.Where("shop_id = ?", shopID).Preload("Items").Preload("Items.Options").First(&model, id.Value()).Error; err != nil {
+	if err := scoped.Preload("Items").Preload("Items.Options").Preload("Discounts").First(&model, id.Value()).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("订单不存在")
 		}
@@ -55,15 +52,20 @@ func (r *OrderRepositoryImpl) FindByIDAndShopID(id shared.ID, shopID uint64) (*o
 }
 
 func (r *OrderRepositoryImpl) FindByShopID(shopID uint64, page, pageSize int) ([]order.Order, int64, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	var total int64
-	if err := r.db.Model(&models.Order{}).Where("shop_id = ?", shopID).Count(&total).Error; err != nil {
+	if err := scoped.Model(&models.Order{}).Count(&total).Error; err != nil {
 		log2.Errorf("查询订单总数失败: %v", err)
 		return nil, 0, errors.New("查询订单总数失败")
 	}
 
 	var modelsList []models.Order
 	offset := (page - 1) * pageSize
-	if err := r.db.Where("shop_id = ?", shopID).Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&modelsList).Error; err != nil {
+	if err := scoped.Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&modelsList).Error; err != nil {
 		log2.Errorf("查询订单列表失败: %v", err)
 		return nil, 0, errors.New("查询订单列表失败")
 	}
@@ -76,8 +78,13 @@ func (r *OrderRepositoryImpl) FindByShopID(shopID uint64, page, pageSize int) ([
 }
 
 func (r *OrderRepositoryImpl) FindByUserID(userID shared.ID, shopID uint64, page, pageSize int) ([]order.Order, int64, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	var total int64
-	query := r.db.Model(&models.Order{}).Where("user_id = ? AND shop_id = ?", userID.Value(), shopID)
+	query := scoped.Model(&models.Order{}).Where("user_id = ?", userID.Value())
 	if err := query.Count(&total).Error; err != nil {
 		log2.Errorf("查询订单总数失败: %v", err)
 		return nil, 0, errors.New("查询订单总数失败")
@@ -97,22 +104,43 @@ func (r *OrderRepositoryImpl) FindByUserID(userID shared.ID, shopID uint64, page
 	return orders, total, nil
 }
 
-func (r *OrderRepositoryImpl) FindUnfinishedByShopID(shopID uint64, flow order.OrderStatusFlow, page, pageSize int) ([]order.Order, int64, error) {
+// fulfillmentTimeColumn 订单的出餐时间：预约订单为预约时间，其他订单为下单时间
+const fulfillmentTimeColumn = "COALESCE(scheduled_time, created_at)"
+
+func (r *OrderRepositoryImpl) FindUnfinishedByShopID(shopID uint64, flow order.OrderStatusFlow, filter order.UnfinishedFilter, page, pageSize int) ([]order.Order, int64, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	unfinishedStatuses := flow.GetUnfinishedStatuses()
 	statusInts := make([]int, len(unfinishedStatuses))
 	for i, s := range unfinishedStatuses {
 		statusInts[i] = int(s)
 	}
 
+	query := scoped.Model(&models.Order{}).Where("status IN (?)", statusInts)
+	if !filter.FulfillFrom.IsZero() {
+		query = query.Where(fulfillmentTimeColumn+" >= ?", filter.FulfillFrom)
+	}
+	if !filter.FulfillTo.IsZero() {
+		query = query.Where(fulfillmentTimeColumn+" < ?", filter.FulfillTo)
+	}
+
 	var total int64
-	if err := r.db.Model(&models.Order{}).Where("shop_id = ? AND status IN (?)", shopID, statusInts).Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		log2.Errorf("查询未完成订单总数失败: %v", err)
 		return nil, 0, errors.New("查询未完成订单总数失败")
 	}
 
+	orderBy := "created_at DESC"
+	if filter.SortByFulfillment {
+		orderBy = fulfillmentTimeColumn + " ASC, created_at ASC"
+	}
+
 	var modelsList []models.Order
 	offset := (page - 1) * pageSize
-	if err := r.db.Where("shop_id = ? AND status IN (?)", shopID, statusInts).Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&modelsList).Error; err != nil {
+	if err := query.Offset(offset).Limit(pageSize).Order(orderBy).Find(&modelsList).Error; err != nil {
 		log2.Errorf("查询未完成订单列表失败: %v", err)
 		return nil, 0, errors.New("查询未完成订单列表失败")
 	}
@@ -124,13 +152,22 @@ func (r *OrderRepositoryImpl) FindUnfinishedByShopID(shopID uint64, flow order.O
 	return orders, total, nil
 }
 
-func (r *OrderRepositoryImpl) Search(shopID uint64, userID string, statuses []order.OrderStatus, startTime, endTime time.Time, page, pageSize int) ([]order.Order, int64, error) {
-	query := r.db.Model(&models.Order{}).Where("shop_id = ?", shopID)
+func (r *OrderRepositoryImpl) Search(shopID uint64, userID, pickupNumber string, statuses []order.OrderStatus, startTime, endTime time.Time, page, pageSize int) ([]order.Order, int64, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := scoped.Model(&models.Order{})
 
 	if userID != "" {
 		query = query.Where("user_id = ?", userID)
 	}
 
+	if pickupNumber != "" {
+		query = query.Where("pickup_number = ?", pickupNumber)
+	}
+
 	if len(statuses) > 0 {
 		statusInts := make([]int, len(statuses))
 		for i, s := range statuses {
@@ -167,17 +204,126 @@ func (r *OrderRepositoryImpl) Search(shopID uint64, userID string, statuses []or
 	return orders, total, nil
 }
 
-func (r *OrderRepositoryImpl) Delete(id shared.ID) error {
-	if err := r.db.Delete(&models.Order{}, id.Value()).Error; err != nil {
+func (r *OrderRepositoryImpl) AdvanceSearch(shopID uint64, filter order.AdvanceSearchFilter, page, pageSize int) ([]order.Order, int64, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := scoped.Model(&models.Order{})
+
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN (?)", filter.Statuses)
+	}
+
+	if len(filter.FulfillmentTypes) > 0 {
+		query = query.Where("fulfillment_type IN (?)", filter.FulfillmentTypes)
+	}
+
+	if recipient := strings.TrimSpace(filter.Recipient); recipient != "" {
+		keyword := "%" + recipient + "%"
+		query = query.Where("recipient_name LIKE ? OR recipient_phone LIKE ? OR delivery_address LIKE ?", keyword, keyword, keyword)
+	}
+
+	if filter.StartTime != "" {
+		query = query.Where("created_at >= ?", filter.StartTime)
+	}
+
+	if filter.EndTime != "" {
+		query = query.Where("created_at <= ?", filter.EndTime)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log2.Errorf("查询订单总数失败: %v", err)
+		return nil, 0, errors.New("查询订单总数失败")
+	}
+
+	var modelsList []models.Order
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询订单列表失败: %v", err)
+		return nil, 0, errors.New("查询订单列表失败")
+	}
+
+	orders := make([]order.Order, len(modelsList))
+	for i, m := range modelsList {
+		orders[i] = *persistence.OrderToDomain(m)
+	}
+	return orders, total, nil
+}
+func (r *OrderRepositoryImpl) FindReadyForPickup(shopID uint64, businessDate string, statuses []order.OrderStatus) ([]order.Order, error) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	statusInts := make([]int, len(statuses))
+	for i, s := range statuses {
+		statusInts[i] = int(s)
+	}
+
+	var modelsList []models.Order
+	if err := scoped.Where("business_date = ? AND status IN (?)", businessDate, statusInts).
+		Order("updated_at ASC").Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询待取餐订单失败: %v", err)
+		return nil, errors.New("查询待取餐订单失败")
+	}
+
+	orders := make([]order.Order, len(modelsList))
+	for i, m := range modelsList {
+		orders[i] = *persistence.OrderToDomain(m)
+	}
+	return orders, nil
+}
+
+func (r *OrderRepositoryImpl) FindByTableSessionID(sessionID shared.ID, shopID uint64) ([]order.Order, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	var modelsList []models.Order
+	if err := scoped.Where("table_session_id = ?", sessionID.Value()).
+		Order("created_at ASC").Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询桌台订单失败: %v", err)
+		return nil, errors.New("查询桌台订单失败")
+	}
+
+	orders := make([]order.Order, len(modelsList))
+	for i, m := range modelsList {
+		orders[i] = *persistence.OrderToDomain(m)
+	}
+	return orders, nil
+}
+
+func (r *OrderRepositoryImpl) Delete(id shared.ID, shopID uint64) error {
+	if err := deleteScoped(r.db, shopID, &models.Order{}, id.Value()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("订单不存在")
+		}
 		log2.Errorf("删除订单失败: %v", err)
 		return errors.New("删除订单失败")
 	}
 	return nil
 }
 
+// Update 更新订单信息，支付状态和金额由支付服务单独维护，不会被覆盖
 func (r *OrderRepositoryImpl) Update(ord *order.Order) error {
 	model := persistence.OrderToModel(ord)
-	if err := r.db.Save(model).Error; err != nil {
+	db := r.db.Omit(clause.Associations, "payment_status", "paid_amount", "refunded_amount")
+	if err := saveScoped(db, ord.ShopID, ord.ID.ToUint64(), model); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("订单不存在")
+		}
 		log2.Errorf("更新订单失败: %v", err)
 		return errors.New("更新订单失败")
 	}
@@ -194,7 +340,10 @@ func NewOrderItemRepository(db *gorm.DB) order.OrderItemRepository {
 
 func (r *OrderItemRepositoryImpl) Save(item *order.OrderItem) error {
 	model := persistence.OrderItemToModel(*item)
-	if err := r.db.Create(&model).Error; err != nil {
+	if model.ID == 0 {
+		model.ID = utils.GenerateSnowflakeID()
+	}
+	if err := r.db.Omit(clause.Associations).Create(&model).Error; err != nil {
 		log2.Errorf("保存订单项失败: %v", err)
 		return errors.New("保存订单项失败")
 	}
@@ -234,6 +383,9 @@ func NewOrderItemOptionRepository(db *gorm.DB) order.OrderItemOptionRepository {
 
 func (r *OrderItemOptionRepositoryImpl) Save(option *order.OrderItemOption) error {
 	model := persistence.OrderItemOptionToModel(*option)
+	if model.ID == 0 {
+		model.ID = utils.GenerateSnowflakeID()
+	}
 	if err := r.db.Create(&model).Error; err != nil {
 		log2.Errorf("保存订单项选项失败: %v", err)
 		return errors.New("保存订单项选项失败")
@@ -264,6 +416,49 @@ func (r *OrderItemOptionRepositoryImpl) DeleteByOrderItemID(orderItemID shared.I
 	return nil
 }
 
+type OrderDiscountRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewOrderDiscountRepository(db *gorm.DB) order.OrderDiscountRepository {
+	return &OrderDiscountRepositoryImpl{db: db}
+}
+
+func (r *OrderDiscountRepositoryImpl) Save(discount *order.OrderDiscount) error {
+	model := persistence.OrderDiscountToModel(*discount)
+	if model.ID == 0 {
+		model.ID = utils.GenerateSnowflakeID()
+	}
+	if err := r.db.Create(&model).Error; err != nil {
+		log2.Errorf("保存订单优惠失败: %v", err)
+		return errors.New("保存订单优惠失败")
+	}
+	discount.ID = shared.ID(model.ID)
+	return nil
+}
+
+func (r *OrderDiscountRepositoryImpl) FindByOrderID(orderID shared.ID) ([]order.OrderDiscount, error) {
+	var modelsList []models.OrderDiscount
+	if err := r.db.Where("order_id = ?", orderID.Value()).Order("id ASC").Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询订单优惠失败: %v", err)
+		return nil, errors.New("查询订单优惠失败")
+	}
+
+	discounts := make([]order.OrderDiscount, len(modelsList))
+	for i, m := range modelsList {
+		discounts[i] = *persistence.OrderDiscountToDomain(m)
+	}
+	return discounts, nil
+}
+
+func (r *OrderDiscountRepositoryImpl) DeleteByOrderID(orderID shared.ID) error {
+	if err := r.db.Where("order_id = ?", orderID.Value()).Delete(&models.OrderDiscount{}).Error; err != nil {
+		log2.Errorf("删除订单优惠失败: %v", err)
+		return errors.New("删除订单优惠失败")
+	}
+	return nil
+}
+
 type OrderStatusLogRepositoryImpl struct {
 	db *gorm.DB
 }
@@ -274,6 +469,9 @@ func NewOrderStatusLogRepository(db *gorm.DB) order.OrderStatusLogRepository {
 
 func (r *OrderStatusLogRepositoryImpl) Save(log *order.OrderStatusLog) error {
 	model := persistence.OrderStatusLogToModel(log)
+	if model.ID == 0 {
+		model.ID = utils.GenerateSnowflakeID()
+	}
 	if err := r.db.Create(model).Error; err != nil {
 		log2.Errorf("保存订单状态日志失败: %v", err)
 		return errors.New("保存订单状态日志失败")
@@ -284,7 +482,7 @@ func (r *OrderStatusLogRepositoryImpl) Save(log *order.OrderStatusLog) error {
 
 func (r *OrderStatusLogRepositoryImpl) FindByOrderID(orderID shared.ID) ([]order.OrderStatusLog, error) {
 	var modelsList []models.OrderStatusLog
-	if err := r.db.Where("order_id = ?", orderID.Value()).Order("changed_time DESC").Find(&modelsList).Error; err != nil {
+	if err := r.db.Where("order_id = ?", orderID.Value()).Order("changed_time ASC, id ASC").Find(&modelsList).Error; err != nil {
 		log2.Errorf("查询订单状态日志失败: %v", err)
 		return nil, errors.New("查询订单状态日志失败")
 	}
@@ -303,3 +501,51 @@ func (r *OrderStatusLogRepositoryImpl) DeleteByOrderID(orderID shared.ID) error
 	}
 	return nil
 }
+
+type OrderRefundRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewOrderRefundRepository(db *gorm.DB) order.OrderRefundRepository {
+	return &OrderRefundRepositoryImpl{db: db}
+}
+
+// Save 保存退款记录及退款的订单项
+func (r *OrderRefundRepositoryImpl) Save(refund *order.OrderRefund) error {
+	if refund.ID.IsZero() {
+		refund.ID = shared.ID(utils.GenerateSnowflakeID())
+	}
+	for i := range refund.Items {
+		if refund.Items[i].ID.IsZero() {
+			refund.Items[i].ID = shared.ID(utils.GenerateSnowflakeID())
+		}
+		refund.Items[i].RefundID = refund.ID
+	}
+
+	model := persistence.OrderRefundToModel(refund)
+	if err := r.db.Create(model).Error; err != nil {
+		log2.Errorf("保存退款记录失败: %v", err)
+		return errors.New("保存退款记录失败")
+	}
+	return nil
+}
+
+func (r *OrderRefundRepositoryImpl) FindByOrderID(orderID shared.ID, shopID uint64) ([]order.OrderRefund, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	var modelsList []models.OrderRefund
+	if err := scoped.Preload("Items").Where("order_id = ?", orderID.Value()).
+		Order("created_at ASC, id ASC").Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询退款记录失败: %v", err)
+		return nil, errors.New("查询退款记录失败")
+	}
+
+	refunds := make([]order.OrderRefund, len(modelsList))
+	for i, m := range modelsList {
+		refunds[i] = *persistence.OrderRefundToDomain(m)
+	}
+	return refunds, nil
+}
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"errors"
+	"orderease/domain/payment"
+	"orderease/domain/shared"
+	"orderease/infrastructure/persistence"
+	"orderease/models"
+	"orderease/utils/log2"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PaymentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) payment.PaymentRepository {
+	return &PaymentRepositoryImpl{db: db}
+}
+
+func (r *PaymentRepositoryImpl) Save(p *payment.Payment) error {
+	model := persistence.PaymentToModel(p)
+	if err := r.db.Create(model).Error; err != nil {
+		log2.Errorf("保存支付单失败: %v", err)
+		return errors.New("保存支付单失败")
+	}
+	p.ID = shared.ID(model.ID)
+	return nil
+}
+
+func (r *PaymentRepositoryImpl) Update(p *payment.Payment) error {
+	model := persistence.PaymentToModel(p)
+	if err := saveScoped(r.db, p.ShopID.ToUint64(), p.ID.ToUint64(), model); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("支付单不存在")
+		}
+		log2.Errorf("更新支付单失败: %v", err)
+		return errors.New("更新支付单失败")
+	}
+	return nil
+}
+
+// FindByID 按支付单号查询，不限定店铺
+// 仅用于支付网关回调：回调验签通过后才知道支付单号，此时还没有店铺上下文
+func (r *PaymentRepositoryImpl) FindByID(id shared.ID) (*payment.Payment, error) {
+	var model models.Payment
+	if err := r.db.First(&model, id.Value()).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("支付单不存在")
+		}
+		log2.Errorf("查询支付单失败: %v", err)
+		return nil, errors.New("查询支付单失败")
+	}
+	return persistence.PaymentToDomain(model), nil
+}
+
+func (r *PaymentRepositoryImpl) FindByIDAndShopID(id shared.ID, shopID shared.ID) (*payment.Payment, error) {
+	scoped, err := shopScoped(r.db, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	var model models.Payment
+	if err := scoped.First(&model, id.Value()).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("支付单不存在")
+		}
+		log2.Errorf("查询支付单失败: %v", err)
+		return nil, errors.New("查询支付单失败")
+	}
+	return persistence.PaymentToDomain(model), nil
+}
+
+func (r *PaymentRepositoryImpl) FindByOrderID(orderID shared.ID, shopID shared.ID) ([]payment.Payment, error) {
+	scoped, err := shopScoped(r.db, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	var modelsList []models.Payment
+	if err := scoped.Where("order_id = ?", orderID.Value()).Order("created_at ASC, id ASC").Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询订单支付单失败: %v", err)
+		return nil, errors.New("查询订单支付单失败")
+	}
+
+	payments := make([]payment.Payment, len(modelsList))
+	for i, m := range modelsList {
+		payments[i] = *persistence.PaymentToDomain(m)
+	}
+	return payments, nil
+}
+
+func (r *PaymentRepositoryImpl) CloseByOrderID(orderID shared.ID, shopID shared.ID) error {
+	scoped, err := shopScoped(r.db, shopID.ToUint64())
+	if err != nil {
+		return err
+	}
+
+	if err := scoped.Model(&models.Payment{}).
+		Where("order_id = ? AND status = ?", orderID.Value(), string(payment.StatusPending)).
+		Updates(map[string]interface{}{"status": string(payment.StatusClosed), "updated_at": time.Now()}).Error; err != nil {
+		log2.Errorf("关闭待支付的支付单失败: %v", err)
+		return errors.New("关闭待支付的支付单失败")
+	}
+	return nil
+}
+
+type PaymentRefundRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPaymentRefundRepository(db *gorm.DB) payment.RefundRepository {
+	return &PaymentRefundRepositoryImpl{db: db}
+}
+
+func (r *PaymentRefundRepositoryImpl) Save(refund *payment.Refund) error {
+	model := persistence.PaymentRefundToModel(refund)
+	if err := r.db.Create(model).Error; err != nil {
+		log2.Errorf("保存退款记录失败: %v", err)
+		return errors.New("保存退款记录失败")
+	}
+	refund.ID = shared.ID(model.ID)
+	return nil
+}
+
+func (r *PaymentRefundRepositoryImpl) Update(refund *payment.Refund) error {
+	model := persistence.PaymentRefundToModel(refund)
+	if err := saveScoped(r.db, refund.ShopID.ToUint64(), refund.ID.ToUint64(), model); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("退款记录不存在")
+		}
+		log2.Errorf("更新退款记录失败: %v", err)
+		return errors.New("更新退款记录失败")
+	}
+	return nil
+}
+
+func (r *PaymentRefundRepositoryImpl) FindByOrderID(orderID shared.ID, shopID shared.ID) ([]payment.Refund, error) {
+	scoped, err := shopScoped(r.db, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	var modelsList []models.PaymentRefund
+	if err := scoped.Where("order_id = ?", orderID.Value()).Order("created_at ASC, id ASC").Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询退款记录失败: %v", err)
+		return nil, errors.New("查询退款记录失败")
+	}
+
+	refunds := make([]payment.Refund, len(modelsList))
+	for i, m := range modelsList {
+		refunds[i] = *persistence.PaymentRefundToDomain(m)
+	}
+	return refunds, nil
+}
@@ -30,21 +30,14 @@ func (r *ProductRepositoryImpl) Save(prod *product.Product) error {
 	return nil
 }
 
-func (r *ProductRepositoryImpl) FindByID(id shared.ID) (*product.Product, error) {
-	var model models.Product
-	if err := r.db.Preload("OptionCategories").Preload("OptionCategories.Options").First(&model, id.Value()).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("商品不存在")
-		}
-		log2.Errorf("查询商品失败: %v", err)
-		return nil, errors.New("查询商品失败")
+func (r *ProductRepositoryImpl) FindByIDAndShopID(id shared.ID, shopID uint64) (*product.Product, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, err
 	}
-	return persistence.ProductToDomain(model), nil
-}
 
-func (r *ProductRepositoryImpl) FindByIDAndShopID(id shared.ID, shopID uint64) (*product.Product, error) {
 	var model models.Product
-	if err := r.db.Where("shop_id = ?", shopID).Preload("OptionCategories").Preload("OptionCategories.Options").First(&model, id.Value()).Error; err != nil {
+	if err := scoped.Preload("OptionCategories").Preload("OptionCategories.Options").First(&model, id.Value()).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("商品不存在")
 		}
@@ -55,7 +48,12 @@ func (r *ProductRepositoryImpl) FindByIDAndShopID(id shared.ID, shopID uint64) (
 }
 
 func (r *ProductRepositoryImpl) FindByShopID(shopID uint64, page, pageSize int, search string, excludeOffline bool) ([]product.Product, int64, error) {
-	query := r.db.Model(&models.Product{}).Where("shop_id = ?", shopID)
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := scoped.Model(&models.Product{})
 
 	if excludeOffline {
 		query = query.Where("status != ?", product.ProductStatusOffline)
@@ -85,7 +83,12 @@ func (r *ProductRepositoryImpl) FindByShopID(shopID uint64, page, pageSize int,
 	return products, total, nil
 }
 
-func (r *ProductRepositoryImpl) FindByIDs(ids []shared.ID) ([]product.Product, error) {
+func (r *ProductRepositoryImpl) FindByIDs(ids []shared.ID, shopID uint64) ([]product.Product, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(ids) == 0 {
 		return []product.Product{}, nil
 	}
@@ -96,7 +99,7 @@ func (r *ProductRepositoryImpl) FindByIDs(ids []shared.ID) ([]product.Product, e
 	}
 
 	var modelsList []models.Product
-	if err := r.db.Where("id IN (?)", idValues).Find(&modelsList).Error; err != nil {
+	if err := scoped.Where("id IN (?)", idValues).Find(&modelsList).Error; err != nil {
 		log2.Errorf("查询商品失败: %v", err)
 		return nil, errors.New("查询商品失败")
 	}
@@ -108,35 +111,85 @@ func (r *ProductRepositoryImpl) FindByIDs(ids []shared.ID) ([]product.Product, e
 	return products, nil
 }
 
-func (r *ProductRepositoryImpl) Delete(id shared.ID) error {
-	if err := r.db.Delete(&models.Product{}, id.Value()).Error; err != nil {
+func (r *ProductRepositoryImpl) FindLowStock(shopID uint64) ([]product.Product, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	var modelsList []models.Product
+	if err := scoped.Where("stock <= 0 OR (low_stock_threshold > 0 AND stock <= low_stock_threshold)").
+		Order("stock ASC, id ASC").
+		Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询低库存商品失败: %v", err)
+		return nil, errors.New("查询低库存商品失败")
+	}
+
+	products := make([]product.Product, len(modelsList))
+	for i, m := range modelsList {
+		products[i] = *persistence.ProductToDomain(m)
+	}
+	return products, nil
+}
+
+func (r *ProductRepositoryImpl) Delete(id shared.ID, shopID uint64) error {
+	if err := deleteScoped(r.db, shopID, &models.Product{}, id.Value()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("商品不存在")
+		}
 		log2.Errorf("删除商品失败: %v", err)
 		return errors.New("删除商品失败")
 	}
 	return nil
 }
 
+// Update 更新商品信息，不包含库存
+// 库存只能通过库存流水变更，避免用旧值覆盖并发下单扣减后的库存
 func (r *ProductRepositoryImpl) Update(prod *product.Product) error {
 	model := persistence.ProductToModel(prod)
-	if err := r.db.Save(model).Error; err != nil {
+	if err := saveScoped(r.db.Omit("stock"), prod.ShopID, prod.ID.ToUint64(), model); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("商品不存在")
+		}
 		log2.Errorf("更新商品失败: %v", err)
 		return errors.New("更新商品失败")
 	}
 	return nil
 }
 
-func (r *ProductRepositoryImpl) CountByProductID(productID shared.ID) (int64, error) {
+func (r *ProductRepositoryImpl) CountByProductID(productID shared.ID, shopID uint64) (int64, error) {
+	orders, err := shopOrderIDs(r.db, shopID)
+	if err != nil {
+		return 0, err
+	}
+
 	var count int64
-	if err := r.db.Model(&models.OrderItem{}).Where("product_id = ?", productID.Value()).Count(&count).Error; err != nil {
+	if err := r.db.Model(&models.OrderItem{}).
+		Where("product_id = ? AND order_id IN (?)", productID.Value(), orders).
+		Count(&count).Error; err != nil {
 		log2.Errorf("查询商品订单关联数失败: %v", err)
 		return 0, errors.New("查询商品订单关联数失败")
 	}
 	return count, nil
 }
 
-func (r *ProductRepositoryImpl) FindOptionByID(id shared.ID) (*product.ProductOption, error) {
+func (r *ProductRepositoryImpl) FindOptionByID(id shared.ID, shopID uint64) (*product.ProductOption, error) {
+	return findOptionInShop(r.db, id, shopID)
+}
+
+func (r *ProductRepositoryImpl) FindOptionCategoryByID(id shared.ID, shopID uint64) (*product.ProductOptionCategory, error) {
+	return findOptionCategoryInShop(r.db, id, shopID)
+}
+
+// findOptionInShop 参数选项表没有 shop_id，通过所属类别和商品限定在店铺范围内
+func findOptionInShop(db *gorm.DB, id shared.ID, shopID uint64) (*product.ProductOption, error) {
+	categories, err := shopOptionCategoryIDs(db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
 	var model models.ProductOption
-	if err := r.db.First(&model, id.Value()).Error; err != nil {
+	if err := db.Where("category_id IN (?)", categories).First(&model, id.Value()).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("商品参数选项不存在")
 		}
@@ -146,9 +199,15 @@ func (r *ProductRepositoryImpl) FindOptionByID(id shared.ID) (*product.ProductOp
 	return persistence.ProductOptionToDomain(model), nil
 }
 
-func (r *ProductRepositoryImpl) FindOptionCategoryByID(id shared.ID) (*product.ProductOptionCategory, error) {
+// findOptionCategoryInShop 参数类别表没有 shop_id，通过所属商品限定在店铺范围内
+func findOptionCategoryInShop(db *gorm.DB, id shared.ID, shopID uint64) (*product.ProductOptionCategory, error) {
+	products, err := shopProductIDs(db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
 	var model models.ProductOptionCategory
-	if err := r.db.Preload("Options").First(&model, id.Value()).Error; err != nil {
+	if err := db.Preload("Options").Where("product_id IN (?)", products).First(&model, id.Value()).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("商品参数类别不存在")
 		}
@@ -176,16 +235,8 @@ func (r *ProductOptionCategoryRepositoryImpl) Save(category *product.ProductOpti
 	return nil
 }
 
-func (r *ProductOptionCategoryRepositoryImpl) FindByID(id shared.ID) (*product.ProductOptionCategory, error) {
-	var model models.ProductOptionCategory
-	if err := r.db.Preload("Options").First(&model, id.Value()).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("商品参数类别不存在")
-		}
-		log2.Errorf("查询商品参数类别失败: %v", err)
-		return nil, errors.New("查询商品参数类别失败")
-	}
-	return persistence.ProductOptionCategoryToDomain(model), nil
+func (r *ProductOptionCategoryRepositoryImpl) FindByID(id shared.ID, shopID uint64) (*product.ProductOptionCategory, error) {
+	return findOptionCategoryInShop(r.db, id, shopID)
 }
 
 func (r *ProductOptionCategoryRepositoryImpl) FindByProductID(productID shared.ID) ([]product.ProductOptionCategory, error) {
@@ -228,16 +279,8 @@ func (r *ProductOptionRepositoryImpl) Save(option *product.ProductOption) error
 	return nil
 }
 
-func (r *ProductOptionRepositoryImpl) FindByID(id shared.ID) (*product.ProductOption, error) {
-	var model models.ProductOption
-	if err := r.db.First(&model, id.Value()).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("商品参数选项不存在")
-		}
-		log2.Errorf("查询商品参数选项失败: %v", err)
-		return nil, errors.New("查询商品参数选项失败")
-	}
-	return persistence.ProductOptionToDomain(model), nil
+func (r *ProductOptionRepositoryImpl) FindByID(id shared.ID, shopID uint64) (*product.ProductOption, error) {
+	return findOptionInShop(r.db, id, shopID)
 }
 
 func (r *ProductOptionRepositoryImpl) FindByCategoryID(categoryID shared.ID) ([]product.ProductOption, error) {
@@ -297,9 +340,14 @@ func (r *ProductTagRepositoryImpl) FindByProductID(productID shared.ID) ([]int,
 	return tagIDs, nil
 }
 
-func (r *ProductTagRepositoryImpl) FindByTagID(tagID int) ([]shared.ID, error) {
+func (r *ProductTagRepositoryImpl) FindByTagID(tagID int, shopID uint64) ([]shared.ID, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
 	var productTags []models.ProductTag
-	if err := r.db.Where("tag_id = ?", tagID).Find(&productTags).Error; err != nil {
+	if err := scoped.Where("tag_id = ?", tagID).Find(&productTags).Error; err != nil {
 		log2.Errorf("查询标签商品失败: %v", err)
 		return nil, errors.New("查询标签商品失败")
 	}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"errors"
+	"orderease/domain/promotion"
+	"orderease/domain/shared"
+	"orderease/infrastructure/persistence"
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+)
+
+type PromotionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPromotionRepository(db *gorm.DB) promotion.PromotionRepository {
+	return &PromotionRepositoryImpl{db: db}
+}
+
+func (r *PromotionRepositoryImpl) Save(p *promotion.Promotion) error {
+	model := persistence.PromotionToModel(p)
+	if err := r.db.Create(model).Error; err != nil {
+		log2.Errorf("保存优惠失败: %v", err)
+		return errors.New("保存优惠失败")
+	}
+	p.ID = shared.ID(model.ID)
+	return nil
+}
+
+func (r *PromotionRepositoryImpl) FindByIDAndShopID(id shared.ID, shopID shared.ID) (*promotion.Promotion, error) {
+	scoped, err := shopScoped(r.db, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	var model models.Promotion
+	if err := scoped.First(&model, id.Value()).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("优惠不存在")
+		}
+		log2.Errorf("查询优惠失败: %v", err)
+		return nil, errors.New("查询优惠失败")
+	}
+	return persistence.PromotionToDomain(model), nil
+}
+
+// FindByCode 按券码查询优惠券，券码不区分大小写
+func (r *PromotionRepositoryImpl) FindByCode(shopID shared.ID, code string) (*promotion.Promotion, error) {
+	scoped, err := shopScoped(r.db, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	var model models.Promotion
+	if err := scoped.Where("code = ?", promotion.NormalizeCode(code)).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("优惠券不存在")
+		}
+		log2.Errorf("查询优惠券失败: %v", err)
+		return nil, errors.New("查询优惠券失败")
+	}
+	return persistence.PromotionToDomain(model), nil
+}
+
+func (r *PromotionRepositoryImpl) FindByShopID(shopID shared.ID) ([]promotion.Promotion, error) {
+	scoped, err := shopScoped(r.db, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	var modelsList []models.Promotion
+	if err := scoped.Order("created_at DESC").Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询优惠列表失败: %v", err)
+		return nil, errors.New("查询优惠列表失败")
+	}
+	return toPromotions(modelsList), nil
+}
+
+func (r *PromotionRepositoryImpl) FindAutomatic(shopID shared.ID) ([]promotion.Promotion, error) {
+	scoped, err := shopScoped(r.db, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	var modelsList []models.Promotion
+	if err := scoped.Where("code IS NULL AND active = ?", true).
+		Order("created_at ASC, id ASC").Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询自动促销失败: %v", err)
+		return nil, errors.New("查询自动促销失败")
+	}
+	return toPromotions(modelsList), nil
+}
+
+// Update 更新优惠配置，已使用次数由下单和取消订单时单独维护，不会被覆盖
+func (r *PromotionRepositoryImpl) Update(p *promotion.Promotion) error {
+	model := persistence.PromotionToModel(p)
+	if err := saveScoped(r.db.Omit("used_count"), p.ShopID.ToUint64(), p.ID.ToUint64(), model); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("优惠不存在")
+		}
+		log2.Errorf("更新优惠失败: %v", err)
+		return errors.New("更新优惠失败")
+	}
+	return nil
+}
+
+func (r *PromotionRepositoryImpl) Delete(id shared.ID, shopID shared.ID) error {
+	if err := deleteScoped(r.db, shopID.ToUint64(), &models.Promotion{}, id.Value()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("优惠不存在")
+		}
+		log2.Errorf("删除优惠失败: %v", err)
+		return errors.New("删除优惠失败")
+	}
+	return nil
+}
+
+func toPromotions(modelsList []models.Promotion) []promotion.Promotion {
+	promotions := make([]promotion.Promotion, len(modelsList))
+	for i, m := range modelsList {
+		promotions[i] = *persistence.PromotionToDomain(m)
+	}
+	return promotions
+}
@@ -137,9 +137,14 @@ func (r *TagRepositoryImpl) Save(tag *shop.Tag) error {
 	return nil
 }
 
-func (r *TagRepositoryImpl) FindByID(id int) (*shop.Tag, error) {
+func (r *TagRepositoryImpl) FindByIDAndShopID(id int, shopID shared.ID) (*shop.Tag, error) {
+	scoped, err := shopScoped(r.db, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
 	var model models.Tag
-	if err := r.db.First(&model, id).Error; err != nil {
+	if err := scoped.First(&model, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("标签不存在")
 		}
@@ -150,8 +155,13 @@ func (r *TagRepositoryImpl) FindByID(id int) (*shop.Tag, error) {
 }
 
 func (r *TagRepositoryImpl) FindByShopID(shopID shared.ID) ([]shop.Tag, error) {
+	scoped, err := shopScoped(r.db, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
 	var modelsList []models.Tag
-	if err := r.db.Where("shop_id = ?", shopID.Value()).Find(&modelsList).Error; err != nil {
+	if err := scoped.Find(&modelsList).Error; err != nil {
 		log2.Errorf("查询标签失败: %v", err)
 		return nil, errors.New("查询标签失败")
 	}
@@ -163,8 +173,11 @@ func (r *TagRepositoryImpl) FindByShopID(shopID shared.ID) ([]shop.Tag, error) {
 	return tags, nil
 }
 
-func (r *TagRepositoryImpl) Delete(id int) error {
-	if err := r.db.Delete(&models.Tag{}, id).Error; err != nil {
+func (r *TagRepositoryImpl) Delete(id int, shopID shared.ID) error {
+	if err := deleteScoped(r.db, shopID.ToUint64(), &models.Tag{}, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("标签不存在")
+		}
 		log2.Errorf("删除标签失败: %v", err)
 		return errors.New("删除标签失败")
 	}
@@ -173,9 +186,114 @@ func (r *TagRepositoryImpl) Delete(id int) error {
 
 func (r *TagRepositoryImpl) Update(tag *shop.Tag) error {
 	model := persistence.TagToModel(tag)
-	if err := r.db.Save(model).Error; err != nil {
+	if err := saveScoped(r.db, tag.ShopID.ToUint64(), uint64(tag.ID), model); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("标签不存在")
+		}
 		log2.Errorf("更新标签失败: %v", err)
 		return errors.New("更新标签失败")
 	}
 	return nil
 }
+
+type StaffRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewStaffRepository(db *gorm.DB) shop.StaffRepository {
+	return &StaffRepositoryImpl{db: db}
+}
+
+func (r *StaffRepositoryImpl) Save(staff *shop.Staff) error {
+	model := persistence.StaffToModel(staff)
+	if err := r.db.Create(model).Error; err != nil {
+		log2.Errorf("保存员工失败: %v", err)
+		return errors.New("保存员工失败")
+	}
+	staff.ID = shared.ID(model.ID)
+	return nil
+}
+
+// FindByID 按员工ID查询，仅用于员工本人登录态校验（刷新令牌、修改密码），店铺后台管理请使用 FindByIDAndShopID
+func (r *StaffRepositoryImpl) FindByID(id shared.ID) (*shop.Staff, error) {
+	var model models.ShopStaff
+	if err := r.db.First(&model, id.Value()).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("员工不存在")
+		}
+		log2.Errorf("查询员工失败: %v", err)
+		return nil, errors.New("查询员工失败")
+	}
+	return persistence.StaffToDomain(model), nil
+}
+
+func (r *StaffRepositoryImpl) FindByIDAndShopID(id shared.ID, shopID shared.ID) (*shop.Staff, error) {
+	scoped, err := shopScoped(r.db, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	var model models.ShopStaff
+	if err := scoped.First(&model, id.Value()).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("员工不存在")
+		}
+		log2.Errorf("查询员工失败: %v", err)
+		return nil, errors.New("查询员工失败")
+	}
+	return persistence.StaffToDomain(model), nil
+}
+
+func (r *StaffRepositoryImpl) FindByUsername(username string) (*shop.Staff, error) {
+	var model models.ShopStaff
+	if err := r.db.Where("username = ?", username).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("员工不存在")
+		}
+		log2.Errorf("查询员工失败: %v", err)
+		return nil, errors.New("查询员工失败")
+	}
+	return persistence.StaffToDomain(model), nil
+}
+
+func (r *StaffRepositoryImpl) FindByShopID(shopID shared.ID) ([]shop.Staff, error) {
+	scoped, err := shopScoped(r.db, shopID.ToUint64())
+	if err != nil {
+		return nil, err
+	}
+
+	var modelsList []models.ShopStaff
+	if err := scoped.Order("created_at ASC").Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询员工列表失败: %v", err)
+		return nil, errors.New("查询员工列表失败")
+	}
+
+	staffs := make([]shop.Staff, len(modelsList))
+	for i, m := range modelsList {
+		staffs[i] = *persistence.StaffToDomain(m)
+	}
+	return staffs, nil
+}
+
+func (r *StaffRepositoryImpl) Update(staff *shop.Staff) error {
+	model := persistence.StaffToModel(staff)
+	if err := saveScoped(r.db, staff.ShopID.ToUint64(), staff.ID.ToUint64(), model); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("员工不存在")
+		}
+		log2.Errorf("更新员工失败: %v", err)
+		return errors.New("更新员工失败")
+	}
+	return nil
+}
+
+func (r *StaffRepositoryImpl) Delete(id shared.ID, shopID shared.ID) error {
+	if err := deleteScoped(r.db, shopID.ToUint64(), &models.ShopStaff{}, id.Value()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("员工不存在")
+		}
+		log2.Errorf("删除员工失败: %v", err)
+		return errors.New("删除员工失败")
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package repositories
+
+import (
+	"errors"
+	"orderease/domain/shared"
+	"orderease/domain/table"
+	"orderease/infrastructure/persistence"
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TableRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTableRepository(db *gorm.DB) table.TableRepository {
+	return &TableRepositoryImpl{db: db}
+}
+
+func (r *TableRepositoryImpl) Save(t *table.Table) error {
+	model := persistence.TableToModel(t)
+	if err := r.db.Create(model).Error; err != nil {
+		log2.Errorf("保存桌台失败: %v", err)
+		return errors.New("保存桌台失败")
+	}
+	return nil
+}
+
+func (r *TableRepositoryImpl) Update(t *table.Table) error {
+	model := persistence.TableToModel(t)
+	if err := saveScoped(r.db, t.ShopID, t.ID.ToUint64(), model); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return table.ErrTableNotFound
+		}
+		log2.Errorf("更新桌台失败: %v", err)
+		return errors.New("更新桌台失败")
+	}
+	return nil
+}
+
+func (r *TableRepositoryImpl) find(db *gorm.DB, id shared.ID, shopID uint64) (*table.Table, error) {
+	scoped, err := shopScoped(db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	var model models.DiningTable
+	if err := scoped.First(&model, id.Value()).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, table.ErrTableNotFound
+		}
+		log2.Errorf("查询桌台失败: %v", err)
+		return nil, errors.New("查询桌台失败")
+	}
+	return persistence.TableToDomain(model), nil
+}
+
+func (r *TableRepositoryImpl) FindByIDAndShopID(id shared.ID, shopID uint64) (*table.Table, error) {
+	return r.find(r.db, id, shopID)
+}
+
+func (r *TableRepositoryImpl) FindByIDForUpdate(id shared.ID, shopID uint64) (*table.Table, error) {
+	return r.find(r.db.Clauses(clause.Locking{Strength: "UPDATE"}), id, shopID)
+}
+
+func (r *TableRepositoryImpl) FindByShopID(shopID uint64) ([]table.Table, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	var modelsList []models.DiningTable
+	if err := scoped.Order("name ASC").Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询桌台列表失败: %v", err)
+		return nil, errors.New("查询桌台列表失败")
+	}
+
+	tables := make([]table.Table, len(modelsList))
+	for i, m := range modelsList {
+		tables[i] = *persistence.TableToDomain(m)
+	}
+	return tables, nil
+}
+
+func (r *TableRepositoryImpl) Delete(id shared.ID, shopID uint64) error {
+	if err := deleteScoped(r.db, shopID, &models.DiningTable{}, id.Value()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return table.ErrTableNotFound
+		}
+		log2.Errorf("删除桌台失败: %v", err)
+		return errors.New("删除桌台失败")
+	}
+	return nil
+}
+
+type TableSessionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTableSessionRepository(db *gorm.DB) table.SessionRepository {
+	return &TableSessionRepositoryImpl{db: db}
+}
+
+func (r *TableSessionRepositoryImpl) Save(s *table.Session) error {
+	model := persistence.TableSessionToModel(s)
+	if err := r.db.Create(model).Error; err != nil {
+		log2.Errorf("保存桌台用餐失败: %v", err)
+		return errors.New("保存桌台用餐失败")
+	}
+	return nil
+}
+
+func (r *TableSessionRepositoryImpl) Update(s *table.Session) error {
+	model := persistence.TableSessionToModel(s)
+	if err := saveScoped(r.db, s.ShopID, s.ID.ToUint64(), model); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return table.ErrSessionNotFound
+		}
+		log2.Errorf("更新桌台用餐失败: %v", err)
+		return errors.New("更新桌台用餐失败")
+	}
+	return nil
+}
+
+func (r *TableSessionRepositoryImpl) FindByIDAndShopID(id shared.ID, shopID uint64) (*table.Session, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	var model models.TableSession
+	if err := scoped.First(&model, id.Value()).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("用餐记录不存在")
+		}
+		log2.Errorf("查询桌台用餐失败: %v", err)
+		return nil, errors.New("查询桌台用餐失败")
+	}
+	return persistence.TableSessionToDomain(model), nil
+}
+
+func (r *TableSessionRepositoryImpl) FindOpenByTableID(tableID shared.ID, shopID uint64) (*table.Session, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	var modelsList []models.TableSession
+	if err := scoped.Where("table_id = ? AND status = ?", tableID.Value(), string(table.SessionStatusOpen)).
+		Limit(1).Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询桌台用餐失败: %v", err)
+		return nil, errors.New("查询桌台用餐失败")
+	}
+	if len(modelsList) == 0 {
+		return nil, nil
+	}
+	return persistence.TableSessionToDomain(modelsList[0]), nil
+}
+
+func (r *TableSessionRepositoryImpl) FindOpenByShopID(shopID uint64) ([]table.Session, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	var modelsList []models.TableSession
+	if err := scoped.Where("status = ?", string(table.SessionStatusOpen)).
+		Order("opened_at ASC").Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询桌台用餐失败: %v", err)
+		return nil, errors.New("查询桌台用餐失败")
+	}
+
+	sessions := make([]table.Session, len(modelsList))
+	for i, m := range modelsList {
+		sessions[i] = *persistence.TableSessionToDomain(m)
+	}
+	return sessions, nil
+}
+
+func (r *TableSessionRepositoryImpl) FindByGuestUserID(userID shared.ID, shopID uint64) (*table.Session, error) {
+	scoped, err := shopScoped(r.db, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	var modelsList []models.TableSession
+	if err := scoped.Where("guest_user_id = ?", userID.Value()).Limit(1).Find(&modelsList).Error; err != nil {
+		log2.Errorf("查询桌台用餐失败: %v", err)
+		return nil, errors.New("查询桌台用餐失败")
+	}
+	if len(modelsList) == 0 {
+		return nil, nil
+	}
+	return persistence.TableSessionToDomain(modelsList[0]), nil
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"errors"
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"gorm.io/gorm"
+)
+
+// errShopScopeRequired 店铺数据的读写必须带上店铺ID
+var errShopScopeRequired = errors.New("缺少店铺ID")
+
+// shopScoped 为店铺数据的查询追加 shop_id 条件
+// 店铺ID为空时直接拒绝（fail closed），避免生成不带租户条件的语句而读写到其他店铺的数据
+func shopScoped(db *gorm.DB, shopID uint64) (*gorm.DB, error) {
+	if shopID == 0 {
+		log2.Errorf("拒绝执行未指定店铺的数据访问")
+		return nil, errShopScopeRequired
+	}
+	return db.Where("shop_id = ?", shopID).Session(&gorm.Session{}), nil
+}
+
+// saveScoped 在店铺范围内更新整条记录
+// 记录不属于该店铺时返回 gorm.ErrRecordNotFound，且不会像 Save 那样回退为插入
+func saveScoped(db *gorm.DB, shopID uint64, id uint64, model interface{}) error {
+	scoped, err := shopScoped(db, shopID)
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	if err := scoped.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	// 显式 Select("*") 时 Save 只执行 UPDATE，不会在未命中时插入新记录
+	return scoped.Select("*").Save(model).Error
+}
+
+// deleteScoped 在店铺范围内按主键删除，未命中时返回 gorm.ErrRecordNotFound
+func deleteScoped(db *gorm.DB, shopID uint64, model interface{}, id interface{}) error {
+	scoped, err := shopScoped(db, shopID)
+	if err != nil {
+		return err
+	}
+
+	result := scoped.Delete(model, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// shopProductIDs 店铺商品ID子查询，用于商品参数类别等不带 shop_id 的子表
+func shopProductIDs(db *gorm.DB, shopID uint64) (*gorm.DB, error) {
+	scoped, err := shopScoped(db, shopID)
+	if err != nil {
+		return nil, err
+	}
+	return scoped.Model(&models.Product{}).Select("id"), nil
+}
+
+// shopOptionCategoryIDs 店铺商品参数类别ID子查询，用于商品参数选项
+func shopOptionCategoryIDs(db *gorm.DB, shopID uint64) (*gorm.DB, error) {
+	products, err := shopProductIDs(db, shopID)
+	if err != nil {
+		return nil, err
+	}
+	return db.Model(&models.ProductOptionCategory{}).Select("id").Where("product_id IN (?)", products), nil
+}
+
+// shopOrderIDs 店铺订单ID子查询，用于订单项等不带 shop_id 的子表
+func shopOrderIDs(db *gorm.DB, shopID uint64) (*gorm.DB, error) {
+	scoped, err := shopScoped(db, shopID)
+	if err != nil {
+		return nil, err
+	}
+	return scoped.Model(&models.Order{}).Select("id"), nil
+}
@@ -0,0 +1,336 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"orderease/domain/order"
+	"orderease/domain/shared"
+	"orderease/domain/shop"
+	"orderease/models"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	shopA uint64 = 1001
+	shopB uint64 = 2002
+)
+
+func setupTenantDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.OrderItemOption{},
+		&models.OrderDiscount{},
+		&models.Product{},
+		&models.ProductOptionCategory{},
+		&models.ProductOption{},
+		&models.ProductTag{},
+		&models.Tag{},
+		&models.ShopStaff{},
+	))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// 内存数据库每个连接相互独立，固定为单连接
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}
+
+func TestShopScoped_RejectsMissingShopID(t *testing.T) {
+	db := setupTenantDB(t)
+
+	_, err := shopScoped(db, 0)
+	assert.ErrorIs(t, err, errShopScopeRequired)
+
+	orderRepo := NewOrderRepository(db)
+	_, err = orderRepo.FindByIDAndShopID(shared.ID(1), 0)
+	assert.Error(t, err)
+	_, _, err = orderRepo.FindByShopID(0, 1, 10)
+	assert.Error(t, err)
+	assert.Error(t, orderRepo.Delete(shared.ID(1), 0))
+
+	productRepo := NewProductRepository(db)
+	_, err = productRepo.FindByIDAndShopID(shared.ID(1), 0)
+	assert.Error(t, err)
+	_, _, err = productRepo.FindByShopID(0, 1, 10, "", false)
+	assert.Error(t, err)
+	_, err = productRepo.CountByProductID(shared.ID(1), 0)
+	assert.Error(t, err)
+	_, err = productRepo.FindOptionByID(shared.ID(1), 0)
+	assert.Error(t, err)
+	_, err = productRepo.FindOptionCategoryByID(shared.ID(1), 0)
+	assert.Error(t, err)
+	_, err = NewProductOptionRepository(db).FindByID(shared.ID(1), 0)
+	assert.Error(t, err)
+	_, err = NewProductOptionCategoryRepository(db).FindByID(shared.ID(1), 0)
+	assert.Error(t, err)
+	_, _, err = orderRepo.AdvanceSearch(0, order.AdvanceSearchFilter{}, 1, 10)
+	assert.Error(t, err)
+
+	tagRepo := NewTagRepository(db)
+	_, err = tagRepo.FindByShopID(shared.ID(0))
+	assert.Error(t, err)
+}
+
+func TestOrderRepository_TenantIsolation(t *testing.T) {
+	db := setupTenantDB(t)
+	repo := NewOrderRepository(db)
+
+	orderID := shared.ID(5001)
+	require.NoError(t, db.Create(&models.Order{
+		ID:        snowflake.ID(orderID),
+		UserID:    snowflake.ID(9001),
+		ShopID:    snowflake.ID(shopA),
+		Status:    int(order.OrderStatusPending),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}).Error)
+
+	t.Run("owner shop can read", func(t *testing.T) {
+		ord, err := repo.FindByIDAndShopID(orderID, shopA)
+		require.NoError(t, err)
+		assert.Equal(t, orderID, ord.ID)
+	})
+
+	t.Run("other shop gets not found", func(t *testing.T) {
+		ord, err := repo.FindByIDAndShopID(orderID, shopB)
+		assert.Nil(t, ord)
+		assert.EqualError(t, err, "订单不存在")
+
+		orders, total, err := repo.FindByShopID(shopB, 1, 10)
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, orders)
+
+		orders, total, err = repo.AdvanceSearch(shopB, order.AdvanceSearchFilter{UserID: "9001"}, 1, 10)
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, orders)
+
+		orders, total, err = repo.AdvanceSearch(shopA, order.AdvanceSearchFilter{UserID: "9001"}, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, orders, 1)
+		assert.Equal(t, orderID, orders[0].ID)
+	})
+
+	t.Run("other shop cannot update", func(t *testing.T) {
+		ord, err := repo.FindByIDAndShopID(orderID, shopA)
+		require.NoError(t, err)
+
+		ord.ShopID = shopB
+		ord.Remark = "越权修改"
+		assert.EqualError(t, repo.Update(ord), "订单不存在")
+
+		var model models.Order
+		require.NoError(t, db.First(&model, orderID.Value()).Error)
+		assert.Equal(t, snowflake.ID(shopA), model.ShopID)
+		assert.Empty(t, model.Remark)
+
+		var count int64
+		db.Model(&models.Order{}).Count(&count)
+		assert.Equal(t, int64(1), count, "越权更新不能回退为插入")
+	})
+
+	t.Run("other shop cannot delete", func(t *testing.T) {
+		assert.EqualError(t, repo.Delete(orderID, shopB), "订单不存在")
+
+		_, err := repo.FindByIDAndShopID(orderID, shopA)
+		assert.NoError(t, err)
+	})
+
+	t.Run("owner shop can delete", func(t *testing.T) {
+		assert.NoError(t, repo.Delete(orderID, shopA))
+
+		_, err := repo.FindByIDAndShopID(orderID, shopA)
+		assert.EqualError(t, err, "订单不存在")
+	})
+}
+
+func TestProductRepository_TenantIsolation(t *testing.T) {
+	db := setupTenantDB(t)
+	repo := NewProductRepository(db)
+
+	productID := shared.ID(6001)
+	require.NoError(t, db.Create(&models.Product{
+		ID:     snowflake.ID(productID),
+		ShopID: snowflake.ID(shopA),
+		Name:   "招牌奶茶",
+		Price:  shared.NewPrice(12),
+		Stock:  10,
+		Status: models.ProductStatusOnline,
+	}).Error)
+
+	_, err := repo.FindByIDAndShopID(productID, shopB)
+	assert.EqualError(t, err, "商品不存在")
+
+	products, err := repo.FindByIDs([]shared.ID{productID}, shopB)
+	require.NoError(t, err)
+	assert.Empty(t, products)
+
+	prod, err := repo.FindByIDAndShopID(productID, shopA)
+	require.NoError(t, err)
+
+	prod.ShopID = shopB
+	prod.Stock = 0
+	assert.EqualError(t, repo.Update(prod), "商品不存在")
+	assert.EqualError(t, repo.Delete(productID, shopB), "商品不存在")
+
+	prod, err = repo.FindByIDAndShopID(productID, shopA)
+	require.NoError(t, err)
+	assert.Equal(t, 10, prod.Stock)
+
+	// 库存只通过库存流水变更，Update 不覆盖库存
+	prod.Name = "冰美式"
+	prod.Stock = 8
+	assert.NoError(t, repo.Update(prod))
+	prod, err = repo.FindByIDAndShopID(productID, shopA)
+	require.NoError(t, err)
+	assert.Equal(t, "冰美式", prod.Name)
+	assert.Equal(t, 10, prod.Stock)
+}
+
+func TestProductOptionRepositories_TenantIsolation(t *testing.T) {
+	db := setupTenantDB(t)
+	productRepo := NewProductRepository(db)
+	categoryRepo := NewProductOptionCategoryRepository(db)
+	optionRepo := NewProductOptionRepository(db)
+
+	productID := shared.ID(6101)
+	categoryID := shared.ID(6201)
+	optionID := shared.ID(6301)
+	orderID := shared.ID(6401)
+	require.NoError(t, db.Create(&models.Product{
+		ID:     snowflake.ID(productID),
+		ShopID: snowflake.ID(shopA),
+		Name:   "招牌奶茶",
+		Price:  shared.NewPrice(12),
+		Stock:  10,
+		Status: models.ProductStatusOnline,
+	}).Error)
+	require.NoError(t, db.Create(&models.ProductOptionCategory{
+		ID:        snowflake.ID(categoryID),
+		ProductID: snowflake.ID(productID),
+		Name:      "甜度",
+	}).Error)
+	require.NoError(t, db.Create(&models.ProductOption{
+		ID:         snowflake.ID(optionID),
+		CategoryID: snowflake.ID(categoryID),
+		Name:       "少糖",
+	}).Error)
+	require.NoError(t, db.Create(&models.Order{
+		ID:        snowflake.ID(orderID),
+		UserID:    snowflake.ID(9001),
+		ShopID:    snowflake.ID(shopA),
+		Status:    int(order.OrderStatusPending),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}).Error)
+	require.NoError(t, db.Create(&models.OrderItem{
+		ID:        snowflake.ID(6501),
+		OrderID:   snowflake.ID(orderID),
+		ProductID: snowflake.ID(productID),
+		Quantity:  1,
+	}).Error)
+
+	t.Run("owner shop can read", func(t *testing.T) {
+		cat, err := categoryRepo.FindByID(categoryID, shopA)
+		require.NoError(t, err)
+		assert.Equal(t, categoryID, cat.ID)
+		cat, err = productRepo.FindOptionCategoryByID(categoryID, shopA)
+		require.NoError(t, err)
+		assert.Equal(t, categoryID, cat.ID)
+
+		opt, err := optionRepo.FindByID(optionID, shopA)
+		require.NoError(t, err)
+		assert.Equal(t, optionID, opt.ID)
+		opt, err = productRepo.FindOptionByID(optionID, shopA)
+		require.NoError(t, err)
+		assert.Equal(t, optionID, opt.ID)
+
+		count, err := productRepo.CountByProductID(productID, shopA)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("other shop gets not found", func(t *testing.T) {
+		_, err := categoryRepo.FindByID(categoryID, shopB)
+		assert.EqualError(t, err, "商品参数类别不存在")
+		_, err = productRepo.FindOptionCategoryByID(categoryID, shopB)
+		assert.EqualError(t, err, "商品参数类别不存在")
+
+		_, err = optionRepo.FindByID(optionID, shopB)
+		assert.EqualError(t, err, "商品参数选项不存在")
+		_, err = productRepo.FindOptionByID(optionID, shopB)
+		assert.EqualError(t, err, "商品参数选项不存在")
+
+		count, err := productRepo.CountByProductID(productID, shopB)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
+
+func TestTagRepository_TenantIsolation(t *testing.T) {
+	db := setupTenantDB(t)
+	repo := NewTagRepository(db)
+
+	tag, err := shop.NewTag(shared.ParseIDFromUint64(shopA), "热销", "")
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(tag))
+
+	_, err = repo.FindByIDAndShopID(tag.ID, shared.ParseIDFromUint64(shopB))
+	assert.EqualError(t, err, "标签不存在")
+
+	tags, err := repo.FindByShopID(shared.ParseIDFromUint64(shopB))
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	hijacked := *tag
+	hijacked.ShopID = shared.ParseIDFromUint64(shopB)
+	hijacked.Name = "被篡改"
+	assert.EqualError(t, repo.Update(&hijacked), "标签不存在")
+	assert.EqualError(t, repo.Delete(tag.ID, shared.ParseIDFromUint64(shopB)), "标签不存在")
+
+	found, err := repo.FindByIDAndShopID(tag.ID, shared.ParseIDFromUint64(shopA))
+	require.NoError(t, err)
+	assert.Equal(t, "热销", found.Name)
+
+	assert.NoError(t, repo.Delete(tag.ID, shared.ParseIDFromUint64(shopA)))
+}
+
+func TestStaffRepository_TenantIsolation(t *testing.T) {
+	db := setupTenantDB(t)
+	repo := NewStaffRepository(db)
+
+	staff, err := shop.NewStaff(shared.ParseIDFromUint64(shopA), "cashier_a", "Pass#1234", "收银员", shop.StaffRoleCashier)
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(staff))
+
+	_, err = repo.FindByIDAndShopID(staff.ID, shared.ParseIDFromUint64(shopB))
+	assert.EqualError(t, err, "员工不存在")
+
+	staffs, err := repo.FindByShopID(shared.ParseIDFromUint64(shopB))
+	require.NoError(t, err)
+	assert.Empty(t, staffs)
+
+	assert.EqualError(t, repo.Delete(staff.ID, shared.ParseIDFromUint64(shopB)), "员工不存在")
+
+	found, err := repo.FindByIDAndShopID(staff.ID, shared.ParseIDFromUint64(shopA))
+	require.NoError(t, err)
+	assert.Equal(t, "cashier_a", found.Username)
+}
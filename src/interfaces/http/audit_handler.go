@@ -0,0 +1,167 @@
+package http
+
+import (
+	"net/http"
+	"orderease/application/dto"
+	"orderease/application/services"
+	"orderease/domain/shared"
+	imiddleware "orderease/interfaces/middleware"
+	"orderease/models"
+	"orderease/utils/log2"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// GetAuditLogs 分页查询操作审计日志
+// 店主和员工只能查询本店铺的日志；管理员不传 shop_id 时查询全部
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	if page < 1 {
+		errorResponse(c, http.StatusBadRequest, "页码必须大于0")
+		return
+	}
+
+	if pageSize < 1 || pageSize > 100 {
+		errorResponse(c, http.StatusBadRequest, "每页数量必须在1-100之间")
+		return
+	}
+
+	query := dto.AuditLogQuery{
+		ActorType:  c.Query("actor_type"),
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		Action:     c.Query("action"),
+		Page:       page,
+		PageSize:   pageSize,
+	}
+
+	if actorID := c.Query("actor_id"); actorID != "" {
+		id, err := strconv.ParseUint(actorID, 10, 64)
+		if err != nil {
+			errorResponse(c, http.StatusBadRequest, "无效的操作人ID")
+			return
+		}
+		query.ActorID = id
+	}
+
+	var err error
+	if query.StartTime, err = parseAuditTime(c.Query("start_time")); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的开始时间")
+		return
+	}
+	if query.EndTime, err = parseAuditTime(c.Query("end_time")); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的结束时间")
+		return
+	}
+
+	shopID, ok := h.auditShopID(c)
+	if !ok {
+		return
+	}
+	query.ShopID = shopID
+
+	response, err := h.auditService.QueryAuditLogs(query)
+	if err != nil {
+		log2.Errorf("查询审计日志失败: %v", err)
+		errorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(c, response)
+}
+
+// auditShopID 店铺账号强制限定为本店铺，管理员可选按店铺过滤
+func (h *AuditHandler) auditShopID(c *gin.Context) (shared.ID, bool) {
+	requestUser, exists := c.Get("userInfo")
+	if !exists {
+		errorResponse(c, http.StatusUnauthorized, "未找到用户信息")
+		return shared.ID(0), false
+	}
+
+	userInfo, ok := requestUser.(imiddleware.UserInfo)
+	if !ok {
+		errorResponse(c, http.StatusInternalServerError, "用户信息格式错误")
+		return shared.ID(0), false
+	}
+
+	if !userInfo.IsAdmin {
+		return shared.ParseIDFromUint64(userInfo.UserID), true
+	}
+
+	shopIDStr := c.Query("shop_id")
+	if shopIDStr == "" {
+		return shared.ID(0), true
+	}
+
+	shopID, err := shared.ParseIDFromString(shopIDStr)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return shared.ID(0), false
+	}
+	return shopID, true
+}
+
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// recordAudit 补充操作人、IP 和 User-Agent 后写入审计日志
+func recordAudit(c *gin.Context, auditService *services.AuditService, entry services.AuditEntry) {
+	if auditService == nil {
+		return
+	}
+
+	entry.Actor = auditActor(c)
+	entry.IP = c.ClientIP()
+	entry.UserAgent = c.Request.UserAgent()
+	auditService.Record(entry)
+}
+
+// callerShopID 店主和店铺员工所属的店铺，管理员和前端用户返回 0
+// 用于记录不属于某个店铺实体的操作（如用户管理），使店铺的审计日志能查到本店账号的操作
+func callerShopID(c *gin.Context) shared.ID {
+	requestUser, exists := c.Get("userInfo")
+	if !exists {
+		return shared.ID(0)
+	}
+	userInfo, ok := requestUser.(imiddleware.UserInfo)
+	if !ok || userInfo.IsAdmin {
+		return shared.ID(0)
+	}
+	return shared.ParseIDFromUint64(userInfo.UserID)
+}
+
+// auditActor 从认证信息中解析操作人（管理员、店主、店铺员工或前端用户）
+func auditActor(c *gin.Context) services.AuditActor {
+	requestUser, exists := c.Get("userInfo")
+	if !exists {
+		return services.AuditActor{Type: "anonymous"}
+	}
+
+	switch userInfo := requestUser.(type) {
+	case imiddleware.UserInfo:
+		principalType, principalID := userInfo.Principal()
+		return services.AuditActor{Type: principalType, ID: principalID, Name: userInfo.UserName}
+	case models.UserInfo:
+		return services.AuditActor{Type: services.PrincipalUser, ID: userInfo.UserID, Name: userInfo.Username}
+	default:
+		return services.AuditActor{Type: "unknown"}
+	}
+}
@@ -1,12 +1,16 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"orderease/application/services"
 	"orderease/domain/shared"
+	shopdomain "orderease/domain/shop"
+	imiddleware "orderease/interfaces/middleware"
 	"orderease/models"
 	"orderease/utils"
 	"orderease/utils/log2"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -18,23 +22,50 @@ type AuthHandler struct {
 	shopService      *services.ShopService
 	userService      *services.UserService
 	tempTokenService *services.TempTokenService
+	tokenBlacklist   *services.TokenBlacklistService
+	refreshTokens    *services.RefreshTokenService
+	staffService     *services.StaffService
+	tableService     *services.TableService
 }
 
-func NewAuthHandler(db *gorm.DB, shopService *services.ShopService, userService *services.UserService, tempTokenService *services.TempTokenService) *AuthHandler {
+func NewAuthHandler(db *gorm.DB, shopService *services.ShopService, userService *services.UserService, tempTokenService *services.TempTokenService, tokenBlacklist *services.TokenBlacklistService, refreshTokens *services.RefreshTokenService, staffService *services.StaffService, tableService *services.TableService) *AuthHandler {
 	return &AuthHandler{
 		db:               db,
 		shopService:      shopService,
 		userService:      userService,
 		tempTokenService: tempTokenService,
+		tokenBlacklist:   tokenBlacklist,
+		refreshTokens:    refreshTokens,
+		staffService:     staffService,
+		tableService:     tableService,
 	}
 }
 
+// sessionDevice 从请求中提取登录设备信息，未提供设备标签时使用 User-Agent
+func sessionDevice(c *gin.Context, label string) services.SessionDevice {
+	return services.SessionDevice{
+		Label:     label,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// requestClaims 解析当前请求携带的访问令牌（认证中间件已校验过有效性）
+func requestClaims(c *gin.Context) *utils.Claims {
+	claims, err := utils.ParseToken(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+	if err != nil {
+		return nil
+	}
+	return claims
+}
+
 // Login 通用登录接口（管理员和店主）
 func (h *AuthHandler) Login(c *gin.Context) {
 	type LoginRequest struct {
-		Username string `json:"username" binding:"required"`
-		Password string `json:"password" binding:"required"`
-		IsAdmin  bool   `json:"is_admin"`
+		Username    string `json:"username" binding:"required"`
+		Password    string `json:"password" binding:"required"`
+		IsAdmin     bool   `json:"is_admin"`
+		DeviceLabel string `json:"device_label"`
 	}
 
 	var req LoginRequest
@@ -55,7 +86,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			return
 		}
 
-		token, expiredAt, err := utils.GenerateToken(admin.ID, admin.Username, true)
+		pair, err := h.refreshTokens.IssueTokenPair(services.SessionPrincipal{
+			Type:     services.PrincipalAdmin,
+			ID:       admin.ID,
+			Username: admin.Username,
+		}, sessionDevice(c, req.DeviceLabel))
 		if err != nil {
 			log2.Errorf("生成token失败: %v", err)
 			errorResponse(c, http.StatusInternalServerError, "登录失败")
@@ -64,10 +99,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 		log2.Infof("管理员登录成功: %s", admin.Username)
 		successResponse(c, gin.H{
-			"role":      "admin",
-			"user_info": gin.H{"id": admin.ID, "username": admin.Username},
-			"token":     token,
-			"expiredAt": expiredAt.Unix(),
+			"role":             "admin",
+			"user_info":        gin.H{"id": admin.ID, "username": admin.Username},
+			"token":            pair.AccessToken,
+			"expiredAt":        pair.AccessExpiresAt.Unix(),
+			"refresh_token":    pair.RefreshToken,
+			"refreshExpiredAt": pair.RefreshExpiresAt.Unix(),
 		})
 		return
 	}
@@ -75,8 +112,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	// 管理员登录失败，尝试店主登录
 	var shop models.Shop
 	if err := h.db.Where("owner_username = ?", req.Username).First(&shop).Error; err != nil {
-		log2.Errorf("登录失败，用户名: %s, 错误: %v", req.Username, err)
-		errorResponse(c, http.StatusUnauthorized, "用户名或密码错误")
+		// 不是店主，尝试店铺员工登录
+		h.staffLogin(c, req.Username, req.Password, req.DeviceLabel)
 		return
 	}
 
@@ -92,7 +129,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, expiredAt, err := utils.GenerateToken(uint64(shop.ID), shop.OwnerUsername, false)
+	pair, err := h.refreshTokens.IssueTokenPair(services.SessionPrincipal{
+		Type:     services.PrincipalShop,
+		ID:       uint64(shop.ID),
+		Username: shop.OwnerUsername,
+	}, sessionDevice(c, req.DeviceLabel))
 	if err != nil {
 		log2.Errorf("生成token失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, "登录失败")
@@ -101,17 +142,68 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	log2.Infof("店主登录成功: %s", shop.OwnerUsername)
 	successResponse(c, gin.H{
-		"role":      "shop",
-		"user_info": gin.H{"id": shop.ID, "shop_name": shop.Name, "username": shop.OwnerUsername},
-		"token":     token,
-		"expiredAt": expiredAt.Unix(),
+		"role": "shop",
+		"user_info": gin.H{
+			"id":          shop.ID,
+			"shop_name":   shop.Name,
+			"username":    shop.OwnerUsername,
+			"staff_role":  shopdomain.StaffRoleOwner,
+			"permissions": shopdomain.StaffRoleOwner.Permissions(),
+		},
+		"token":            pair.AccessToken,
+		"expiredAt":        pair.AccessExpiresAt.Unix(),
+		"refresh_token":    pair.RefreshToken,
+		"refreshExpiredAt": pair.RefreshExpiresAt.Unix(),
+	})
+}
+
+// staffLogin 店铺员工子账号登录
+// 员工与店主使用同一个登录入口和同一套店铺后台接口，令牌中的 UserID 为所属店铺ID
+func (h *AuthHandler) staffLogin(c *gin.Context, username, password, deviceLabel string) {
+	staff, shop, err := h.staffService.Authenticate(username, password)
+	if err != nil {
+		log2.Errorf("登录失败，用户名: %s, 错误: %v", username, err)
+		errorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	pair, err := h.refreshTokens.IssueTokenPair(services.SessionPrincipal{
+		Type:     services.PrincipalStaff,
+		ID:       staff.ID.ToUint64(),
+		Username: staff.Username,
+		ShopID:   shop.ID.ToUint64(),
+		Role:     staff.Role,
+	}, sessionDevice(c, deviceLabel))
+	if err != nil {
+		log2.Errorf("生成token失败: %v", err)
+		errorResponse(c, http.StatusInternalServerError, "登录失败")
+		return
+	}
+
+	log2.Infof("店铺员工登录成功: %s, 店铺: %s, 角色: %s", staff.Username, shop.Name, staff.Role)
+	successResponse(c, gin.H{
+		"role": "shop",
+		"user_info": gin.H{
+			"id":          shop.ID,
+			"shop_name":   shop.Name,
+			"username":    staff.Username,
+			"staff_id":    staff.ID,
+			"staff_name":  staff.Name,
+			"staff_role":  staff.Role,
+			"permissions": staff.Role.Permissions(),
+		},
+		"token":            pair.AccessToken,
+		"expiredAt":        pair.AccessExpiresAt.Unix(),
+		"refresh_token":    pair.RefreshToken,
+		"refreshExpiredAt": pair.RefreshExpiresAt.Unix(),
 	})
 }
 
 func (h *AuthHandler) FrontendUserLogin(c *gin.Context) {
 	type FrontendUserLoginRequest struct {
-		Username string `json:"username" binding:"required"`
-		Password string `json:"password" binding:"required"`
+		Username    string `json:"username" binding:"required"`
+		Password    string `json:"password" binding:"required"`
+		DeviceLabel string `json:"device_label"`
 	}
 	req := FrontendUserLoginRequest{}
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -133,7 +225,11 @@ func (h *AuthHandler) FrontendUserLogin(c *gin.Context) {
 	}
 
 	// 生成token
-	token, expiredAt, err := utils.GenerateToken(uint64(user.ID), user.Name, false)
+	pair, err := h.refreshTokens.IssueTokenPair(services.SessionPrincipal{
+		Type:     services.PrincipalUser,
+		ID:       uint64(user.ID),
+		Username: user.Name,
+	}, sessionDevice(c, req.DeviceLabel))
 	if err != nil {
 		log2.Errorf("生成token失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, "登录失败")
@@ -148,8 +244,10 @@ func (h *AuthHandler) FrontendUserLogin(c *gin.Context) {
 			"name": user.Name,
 			"type": user.Type,
 		},
-		"token":     token,
-		"expiredAt": expiredAt.Unix(),
+		"token":            pair.AccessToken,
+		"expiredAt":        pair.AccessExpiresAt.Unix(),
+		"refresh_token":    pair.RefreshToken,
+		"refreshExpiredAt": pair.RefreshExpiresAt.Unix(),
 	}
 	successResponse(c, responseData)
 }
@@ -223,43 +321,87 @@ func (h *AuthHandler) FrontendUserRegister(c *gin.Context) {
 	successResponse(c, responseData)
 }
 
-// RefreshShopToken 刷新店主令牌
-func (h *AuthHandler) RefreshShopToken(c *gin.Context) {
-	type RefreshTokenRequest struct {
-		RefreshToken string `json:"refresh_token" binding:"required"`
-	}
-
-	var req RefreshTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "无效的请求数据:"+err.Error())
-		return
-	}
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+	DeviceLabel  string `json:"device_label"`
+}
 
-	// TODO: 实现 token 刷新逻辑
-	successResponse(c, gin.H{
-		"code":    200,
-		"message": "令牌刷新成功",
-		"token":   "new_token",
+// RefreshShopToken 刷新店主令牌（含店铺员工）
+func (h *AuthHandler) RefreshShopToken(c *gin.Context) {
+	h.rotateRefreshToken(c, map[string]services.PrincipalValidator{
+		services.PrincipalShop: func(principalID uint64) (services.SessionPrincipal, error) {
+			var shop models.Shop
+			if err := h.db.Where("id = ?", principalID).First(&shop).Error; err != nil {
+				return services.SessionPrincipal{}, errors.New("店铺不存在")
+			}
+			if shop.IsExpired() {
+				return services.SessionPrincipal{}, errors.New("店铺已到期")
+			}
+			return services.SessionPrincipal{Type: services.PrincipalShop, ID: principalID, Username: shop.OwnerUsername}, nil
+		},
+		services.PrincipalStaff: func(principalID uint64) (services.SessionPrincipal, error) {
+			staff, err := h.staffService.GetActiveStaff(shared.ParseIDFromUint64(principalID))
+			if err != nil {
+				return services.SessionPrincipal{}, err
+			}
+			return services.SessionPrincipal{
+				Type:     services.PrincipalStaff,
+				ID:       principalID,
+				Username: staff.Username,
+				ShopID:   staff.ShopID.ToUint64(),
+				Role:     staff.Role,
+			}, nil
+		},
 	})
 }
 
 // RefreshAdminToken 刷新管理员令牌
 func (h *AuthHandler) RefreshAdminToken(c *gin.Context) {
-	type RefreshTokenRequest struct {
-		RefreshToken string `json:"refresh_token" binding:"required"`
-	}
+	h.rotateRefreshToken(c, map[string]services.PrincipalValidator{
+		services.PrincipalAdmin: func(principalID uint64) (services.SessionPrincipal, error) {
+			var admin models.Admin
+			if err := h.db.Where("id = ?", principalID).First(&admin).Error; err != nil {
+				return services.SessionPrincipal{}, errors.New("管理员不存在")
+			}
+			return services.SessionPrincipal{Type: services.PrincipalAdmin, ID: principalID, Username: admin.Username}, nil
+		},
+	})
+}
+
+// RefreshUserToken 刷新前端用户令牌
+func (h *AuthHandler) RefreshUserToken(c *gin.Context) {
+	h.rotateRefreshToken(c, map[string]services.PrincipalValidator{
+		services.PrincipalUser: func(principalID uint64) (services.SessionPrincipal, error) {
+			var user models.User
+			if err := h.db.Where("id = ?", principalID).First(&user).Error; err != nil {
+				return services.SessionPrincipal{}, errors.New("用户不存在")
+			}
+			return services.SessionPrincipal{Type: services.PrincipalUser, ID: principalID, Username: user.Name}, nil
+		},
+	})
+}
 
-	var req RefreshTokenRequest
+// rotateRefreshToken 轮换刷新令牌并返回新的令牌对
+func (h *AuthHandler) rotateRefreshToken(c *gin.Context, validators map[string]services.PrincipalValidator) {
+	var req refreshTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		errorResponse(c, http.StatusBadRequest, "无效的请求数据:"+err.Error())
 		return
 	}
 
-	// TODO: 实现 token 刷新逻辑
+	pair, err := h.refreshTokens.Rotate(req.RefreshToken, sessionDevice(c, req.DeviceLabel), validators)
+	if err != nil {
+		errorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
 	successResponse(c, gin.H{
-		"code":    200,
-		"message": "令牌刷新成功",
-		"token":   "new_token",
+		"code":             200,
+		"message":          "令牌刷新成功",
+		"token":            pair.AccessToken,
+		"expiredAt":        pair.AccessExpiresAt.Unix(),
+		"refresh_token":    pair.RefreshToken,
+		"refreshExpiredAt": pair.RefreshExpiresAt.Unix(),
 	})
 }
 
@@ -294,7 +436,11 @@ func (h *AuthHandler) TempTokenLogin(c *gin.Context) {
 	}
 
 	// 生成JWT令牌
-	token, expiredAt, err := utils.GenerateToken(uint64(user.ID), user.Name, false)
+	pair, err := h.refreshTokens.IssueTokenPair(services.SessionPrincipal{
+		Type:     services.PrincipalUser,
+		ID:       uint64(user.ID),
+		Username: user.Name,
+	}, sessionDevice(c, ""))
 	if err != nil {
 		log2.Errorf("生成令牌失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, "生成令牌失败")
@@ -311,16 +457,95 @@ func (h *AuthHandler) TempTokenLogin(c *gin.Context) {
 
 	log2.Infof("临时令牌登录成功: shopID=%s, user=%s", req.ShopID, user.Name)
 	successResponse(c, gin.H{
-		"role":      "user",
-		"user_info": gin.H{"id": user.ID, "name": user.Name, "shop_id": req.ShopID, "shop_name": shop.Name},
-		"token":     token,
-		"expiredAt": expiredAt.Unix(),
+		"role":             "user",
+		"user_info":        gin.H{"id": user.ID, "name": user.Name, "shop_id": req.ShopID, "shop_name": shop.Name},
+		"token":            pair.AccessToken,
+		"expiredAt":        pair.AccessExpiresAt.Unix(),
+		"refresh_token":    pair.RefreshToken,
+		"refreshExpiredAt": pair.RefreshExpiresAt.Unix(),
+	})
+}
+
+// TableLogin 顾客扫描桌台二维码登录店铺，之后携带二维码内容下单，订单归入该桌台
+// 登录时加入桌台进行中的用餐，每次用餐使用单独的顾客身份
+func (h *AuthHandler) TableLogin(c *gin.Context) {
+	type TableLoginRequest struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	var req TableLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的请求数据"+err.Error())
+		return
+	}
+
+	t, err := h.tableService.ResolveTableCode(req.Code)
+	if err != nil {
+		log2.Errorf("桌台二维码验证失败: %v", err)
+		errorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	shopID := shared.ParseIDFromUint64(t.ShopID)
+
+	session, user, err := h.tableService.JoinTable(t)
+	if err != nil {
+		log2.Errorf("加入桌台用餐失败: %v", err)
+		errorResponse(c, http.StatusInternalServerError, "登录失败")
+		return
+	}
+
+	pair, err := h.refreshTokens.IssueTokenPair(services.SessionPrincipal{
+		Type:     services.PrincipalUser,
+		ID:       uint64(user.ID),
+		Username: user.Name,
+	}, sessionDevice(c, t.Name))
+	if err != nil {
+		log2.Errorf("生成令牌失败: %v", err)
+		errorResponse(c, http.StatusInternalServerError, "生成令牌失败")
+		return
+	}
+
+	var shop models.Shop
+	if err := h.db.Select("id", "name").Where("id = ?", shopID.Value()).First(&shop).Error; err != nil {
+		log2.Errorf("获取店铺信息失败: %v", err)
+		errorResponse(c, http.StatusInternalServerError, "获取店铺信息失败")
+		return
+	}
+
+	log2.Infof("桌台扫码登录成功: shopID=%s, table=%s", shopID, t.Name)
+	successResponse(c, gin.H{
+		"role":             "user",
+		"user_info":        gin.H{"id": user.ID, "name": user.Name, "shop_id": shopID.String(), "shop_name": shop.Name},
+		"table":            gin.H{"id": t.ID, "name": t.Name, "session_id": session.ID},
+		"table_code":       strings.TrimSpace(req.Code),
+		"token":            pair.AccessToken,
+		"expiredAt":        pair.AccessExpiresAt.Unix(),
+		"refresh_token":    pair.RefreshToken,
+		"refreshExpiredAt": pair.RefreshExpiresAt.Unix(),
 	})
 }
 
 // Logout 登出
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// TODO: 将 token 加入黑名单
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if token == "" {
+		errorResponse(c, http.StatusUnauthorized, "未提供认证令牌")
+		return
+	}
+
+	if err := h.tokenBlacklist.Revoke(token); err != nil {
+		errorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// 同时吊销该会话的刷新令牌
+	if claims := requestClaims(c); claims != nil {
+		if err := h.refreshTokens.RevokeSession(claims.SessionID); err != nil {
+			errorResponse(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
 	successResponse(c, gin.H{
 		"code":    200,
 		"message": "登出成功",
@@ -328,6 +553,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 }
 
 // ChangePassword 修改密码
+// 根据认证入口区分管理员、店主和前端用户，修改成功后吊销该账号的其他会话
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	type ChangePasswordRequest struct {
 		OldPassword string `json:"old_password" binding:"required"`
@@ -340,13 +566,94 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	// TODO: 实现修改密码逻辑
+	requestUser, exists := c.Get("userInfo")
+	if !exists {
+		errorResponse(c, http.StatusUnauthorized, "未找到用户信息")
+		return
+	}
+
+	var (
+		principalType string
+		principalID   uint64
+		err           error
+	)
+	switch userInfo := requestUser.(type) {
+	case imiddleware.UserInfo:
+		principalType, principalID = userInfo.Principal()
+		switch principalType {
+		case services.PrincipalAdmin:
+			err = h.changeAdminPassword(principalID, req.OldPassword, req.NewPassword)
+		case services.PrincipalStaff:
+			err = h.staffService.ChangePassword(shared.ParseIDFromUint64(principalID), req.OldPassword, req.NewPassword)
+		default:
+			err = h.shopService.ChangeOwnerPassword(shared.ParseIDFromUint64(principalID), req.OldPassword, req.NewPassword)
+		}
+	case models.UserInfo:
+		principalType = services.PrincipalUser
+		principalID = userInfo.UserID
+		err = h.userService.ChangePassword(shared.ParseIDFromUint64(userInfo.UserID), req.OldPassword, req.NewPassword)
+	default:
+		errorResponse(c, http.StatusInternalServerError, "用户信息格式错误")
+		return
+	}
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 当前会话保留，其他设备上的登录全部失效
+	currentJTI, currentSessionID := "", ""
+	if claims := requestClaims(c); claims != nil {
+		currentJTI, currentSessionID = claims.ID, claims.SessionID
+	}
+	err = h.tokenBlacklist.RevokeOtherSessions(principalType, principalID, currentJTI)
+	if err == nil {
+		err = h.refreshTokens.RevokeOtherSessions(principalType, principalID, currentSessionID)
+	}
+	if err != nil {
+		log2.Errorf("修改密码后吊销其他会话失败, %s: %d, 错误: %v", principalType, principalID, err)
+		errorResponse(c, http.StatusInternalServerError, "密码已修改，但注销其他会话失败，请重试")
+		return
+	}
+
+	log2.Infof("修改密码成功: %s %d", principalType, principalID)
 	successResponse(c, gin.H{
 		"code":    200,
 		"message": "密码修改成功",
 	})
 }
 
+// changeAdminPassword 管理员修改密码
+func (h *AuthHandler) changeAdminPassword(adminID uint64, oldPassword, newPassword string) error {
+	var admin models.Admin
+	if err := h.db.Where("id = ?", adminID).First(&admin).Error; err != nil {
+		log2.Errorf("查询管理员失败: %v", err)
+		return errors.New("管理员不存在")
+	}
+
+	if !admin.CheckPassword(oldPassword) {
+		return errors.New("原密码错误")
+	}
+	if oldPassword == newPassword {
+		return errors.New("新密码不能与原密码相同")
+	}
+	if err := utils.ValidatePassword(newPassword); err != nil {
+		return err
+	}
+
+	admin.Password = newPassword
+	if err := admin.HashPassword(); err != nil {
+		log2.Errorf("管理员密码加密失败: %v", err)
+		return errors.New("修改密码失败")
+	}
+	if err := h.db.Model(&admin).Update("password", admin.Password).Error; err != nil {
+		log2.Errorf("更新管理员密码失败: %v", err)
+		return errors.New("修改密码失败")
+	}
+
+	return nil
+}
+
 // GetShopTempToken 获取店铺临时令牌
 func (h *AuthHandler) GetShopTempToken(c *gin.Context) {
 	shopIDStr := c.Query("shop_id")
@@ -0,0 +1,187 @@
+package http
+
+import (
+	"net/http"
+	"orderease/application/dto"
+	"orderease/application/services"
+	"orderease/domain/shared"
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CartHandler 前端顾客的购物车，购物车属于当前登录的顾客
+type CartHandler struct {
+	cartService  *services.CartService
+	shopService  *services.ShopService
+	auditService *services.AuditService
+}
+
+func NewCartHandler(cartService *services.CartService, shopService *services.ShopService, auditService *services.AuditService) *CartHandler {
+	return &CartHandler{
+		cartService:  cartService,
+		shopService:  shopService,
+		auditService: auditService,
+	}
+}
+
+// GetCart 查询购物车，价格和库存按商品当前信息计算
+func (h *CartHandler) GetCart(c *gin.Context) {
+	userID, shopID, ok := h.cartOwner(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+
+	resp, err := h.cartService.GetCart(userID, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(c, resp)
+}
+
+// AddItem 加入购物车
+func (h *CartHandler) AddItem(c *gin.Context) {
+	var req dto.AddCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的购物车数据: "+err.Error())
+		return
+	}
+
+	userID, shopID, ok := h.cartOwner(c, req.ShopID.String())
+	if !ok {
+		return
+	}
+	req.ShopID = shopID
+
+	resp, err := h.cartService.AddItem(userID, &req)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(c, resp)
+}
+
+// UpdateItem 修改购物车项的数量和选项
+func (h *CartHandler) UpdateItem(c *gin.Context) {
+	var req dto.UpdateCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的购物车数据: "+err.Error())
+		return
+	}
+
+	if req.ItemID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "缺少购物车项ID")
+		return
+	}
+
+	userID, shopID, ok := h.cartOwner(c, req.ShopID.String())
+	if !ok {
+		return
+	}
+	req.ShopID = shopID
+
+	resp, err := h.cartService.UpdateItem(userID, &req)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(c, resp)
+}
+
+// RemoveItem 从购物车删除商品
+func (h *CartHandler) RemoveItem(c *gin.Context) {
+	itemID, err := shared.ParseIDFromString(c.Query("item_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "缺少购物车项ID")
+		return
+	}
+
+	userID, shopID, ok := h.cartOwner(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+
+	resp, err := h.cartService.RemoveItem(userID, shopID, itemID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(c, resp)
+}
+
+// ClearCart 清空购物车
+func (h *CartHandler) ClearCart(c *gin.Context) {
+	userID, shopID, ok := h.cartOwner(c, c.Query("shop_id"))
+	if !ok {
+		return
+	}
+
+	if err := h.cartService.ClearCart(userID, shopID); err != nil {
+		errorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(c, gin.H{"message": "购物车已清空"})
+}
+
+// Checkout 购物车结算，创建订单后清空购物车
+func (h *CartHandler) Checkout(c *gin.Context) {
+	var req dto.CheckoutCartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的结算数据: "+err.Error())
+		return
+	}
+
+	userID, shopID, ok := h.cartOwner(c, req.ShopID.String())
+	if !ok {
+		return
+	}
+	req.ShopID = shopID
+
+	response, err := h.cartService.Checkout(userID, &req, statusActor(c))
+	if err != nil {
+		log2.Errorf("购物车结算失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     response.ShopID,
+		EntityType: services.AuditEntityOrder,
+		EntityID:   response.ID.String(),
+		Action:     services.AuditActionCreate,
+		After:      response,
+	})
+
+	successResponse(c, response)
+}
+
+// cartOwner 解析当前顾客和店铺，失败时已写入错误响应
+func (h *CartHandler) cartOwner(c *gin.Context, shopIDStr string) (shared.ID, shared.ID, bool) {
+	requestUser, _ := c.Get("userInfo")
+	userInfo, isCustomer := requestUser.(models.UserInfo)
+	if !isCustomer {
+		errorResponse(c, http.StatusForbidden, "只有顾客可以使用购物车")
+		return 0, 0, false
+	}
+
+	shopID, err := shared.ParseIDFromString(shopIDStr)
+	if err != nil || shopID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return 0, 0, false
+	}
+
+	shop, err := h.shopService.GetShop(shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return 0, 0, false
+	}
+
+	return shared.ParseIDFromUint64(userInfo.UserID), shop.ID, true
+}
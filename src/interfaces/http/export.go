@@ -262,6 +262,19 @@ func getFieldValueByColumn(v reflect.Value, column string) fieldConverter {
 }
 
 func convertValueToString(fieldValue reflect.Value) fieldConverter {
+	// 金额按分保存，导出为两位小数的元，与导入时的格式一致
+	if fieldValue.Type() == reflect.TypeOf(models.Price(0)) {
+		return func(v reflect.Value) (string, error) {
+			return v.Interface().(models.Price).String(), nil
+		}
+	}
+	// 费率导出为两位小数的百分数，如 8.25
+	if fieldValue.Type() == reflect.TypeOf(models.Rate(0)) {
+		return func(v reflect.Value) (string, error) {
+			return v.Interface().(models.Rate).String(), nil
+		}
+	}
+
 	switch fieldValue.Kind() {
 	case reflect.String:
 		return func(v reflect.Value) (string, error) {
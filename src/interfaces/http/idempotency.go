@@ -0,0 +1,109 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"orderease/application/services"
+	"orderease/utils/log2"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotency-Replayed"
+	maxIdempotencyKeyLength   = 100
+)
+
+// idempotencyRecorder 在写出响应的同时保留响应体，用于保存首次请求的结果
+type idempotencyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotent 支持 Idempotency-Key 请求头的写接口，未携带请求头时不做处理
+// 同一登录主体用同一个键重试时回放首次成功的响应；请求内容不同返回 422，首次请求仍在处理返回 409
+func idempotent(idempotencyService *services.IdempotencyService, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			errorResponse(c, http.StatusBadRequest, "幂等键过长")
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			errorResponse(c, http.StatusBadRequest, "读取请求内容失败")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+
+		actor := auditActor(c)
+		record, replay, err := idempotencyService.Begin(services.IdempotentRequest{
+			PrincipalType: actor.Type,
+			PrincipalID:   actor.ID,
+			Scope:         scope,
+			Key:           key,
+			RequestHash:   hex.EncodeToString(sum[:]),
+		})
+		switch {
+		case errors.Is(err, services.ErrIdempotencyKeyMismatch):
+			errorResponse(c, http.StatusUnprocessableEntity, err.Error())
+			c.Abort()
+			return
+		case errors.Is(err, services.ErrIdempotencyKeyInProgress):
+			errorResponse(c, http.StatusConflict, err.Error())
+			c.Abort()
+			return
+		case err != nil:
+			errorResponse(c, http.StatusInternalServerError, err.Error())
+			c.Abort()
+			return
+		}
+
+		if replay {
+			log2.Infof("回放幂等请求: %s %s, 幂等键: %s", actor.Type, scope, key)
+			c.Header(idempotencyReplayedHeader, "true")
+			c.Data(record.StatusCode, "application/json; charset=utf-8", []byte(record.ResponseBody))
+			c.Abort()
+			return
+		}
+
+		recorder := &idempotencyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		completed := false
+		// 处理失败或发生 panic 时释放幂等键，客户端可以重试
+		defer func() {
+			if !completed {
+				idempotencyService.Release(record)
+			}
+		}()
+
+		c.Next()
+
+		if status := recorder.Status(); status >= http.StatusOK && status < http.StatusMultipleChoices {
+			completed = idempotencyService.Complete(record, status, recorder.body.Bytes()) == nil
+		}
+	}
+}
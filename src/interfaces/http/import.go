@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"orderease/application/services"
+	"orderease/domain/product"
 	"orderease/models"
 	"orderease/utils/log2"
+	"orderease/utils/money"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -122,6 +125,14 @@ func (h *ImportHandler) ImportData(c *gin.Context) {
 		}
 	}
 
+	// 导入的库存作为商品的期初余额记入库存流水，后续变化都以此为起点对账
+	if err := recordImportedStock(tx, auditActor(c)); err != nil {
+		tx.Rollback()
+		log2.Errorf("记录导入库存流水失败: %v", err)
+		errorResponse(c, http.StatusInternalServerError, "记录库存流水失败")
+		return
+	}
+
 	log2.Infof("开始处理图片文件")
 	// 处理图片文件
 	if err := processImageFiles(zipReader); err != nil {
@@ -136,6 +147,36 @@ func (h *ImportHandler) ImportData(c *gin.Context) {
 	successResponse(c, gin.H{"message": "数据导入成功"})
 }
 
+// recordImportedStock 为导入的每个商品记录一条 import 类型的库存流水
+func recordImportedStock(tx *gorm.DB, actor services.AuditActor) error {
+	var products []models.Product
+	if err := tx.Select("id", "shop_id", "stock").Find(&products).Error; err != nil {
+		return err
+	}
+	if len(products) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	movements := make([]models.StockMovement, len(products))
+	for i, prod := range products {
+		movements[i] = models.StockMovement{
+			ID:           utils.GenerateSnowflakeID(),
+			ShopID:       prod.ShopID,
+			ProductID:    prod.ID,
+			Type:         string(product.StockMovementImport),
+			Quantity:     prod.Stock,
+			BalanceAfter: prod.Stock,
+			ActorType:    actor.Type,
+			ActorID:      actor.ID,
+			ActorName:    actor.Name,
+			Reason:       "数据导入",
+			CreatedAt:    now,
+		}
+	}
+	return tx.CreateInBatches(movements, 100).Error
+}
+
 // 处理图片文件
 func processImageFiles(zipReader *zip.Reader) error {
 	// 清空uploads目录中的内容，但保留uploads目录本身
@@ -294,6 +335,8 @@ func createImportConverter(value reflect.Value) (func(string) (interface{}, erro
 		return parseTime, nil
 	case fieldType == reflect.TypeOf(models.Price(0)):
 		return parsePrice, nil
+	case fieldType == reflect.TypeOf(models.Rate(0)):
+		return parseRate, nil
 	case fieldType == reflect.TypeOf(snowflake.ID(0)):
 		return parseSnowflakeID, nil
 	case fieldType == reflect.TypeOf(datatypes.JSON{}):
@@ -478,9 +521,13 @@ func parseTime(s string) (interface{}, error) {
 	return time.Parse(time.RFC3339, s)
 }
 
+// parsePrice 按十进制解析金额，兼容旧版导出的浮点格式（如 29.900000000000002）
 func parsePrice(s string) (interface{}, error) {
-	f, err := strconv.ParseFloat(s, 64)
-	return models.Price(f), err
+	return money.ParsePrice(s)
+}
+
+func parseRate(s string) (interface{}, error) {
+	return money.ParseRate(s)
 }
 
 func parseSnowflakeID(s string) (interface{}, error) {
@@ -0,0 +1,297 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"orderease/application/dto"
+	"orderease/application/services"
+	"orderease/config"
+	"orderease/domain/order"
+	"orderease/domain/shared"
+	"orderease/infrastructure/events"
+	"orderease/utils/log2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// defaultHeartbeatInterval 默认心跳间隔
+	defaultHeartbeatInterval = 15 * time.Second
+	// sseRetryMillis 建议客户端断线重连的等待时间
+	sseRetryMillis = 3000
+	// wsWriteTimeout WebSocket 单次写入超时
+	wsWriteTimeout = 10 * time.Second
+)
+
+var orderEventUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     checkEventOrigin,
+}
+
+// checkEventOrigin WebSocket 不受浏览器同源策略限制，按配置的跨域白名单（server.allowedOrigins）校验来源
+// 没有 Origin 头的非浏览器客户端和同源页面直接放行，白名单包含 "*" 时不限制来源
+func checkEventOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	origin = strings.TrimSuffix(origin, "/")
+	for _, allowed := range config.AppConfig.Server.AllowedOrigins {
+		allowed = strings.TrimSuffix(strings.TrimSpace(allowed), "/")
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	log2.Warnf("拒绝来源不在白名单内的 WebSocket 连接: %s", origin)
+	return false
+}
+
+type OrderEventHandler struct {
+	broker      *events.OrderEventBroker
+	shopService *services.ShopService
+}
+
+func NewOrderEventHandler(
+	broker *events.OrderEventBroker,
+	shopService *services.ShopService,
+) *OrderEventHandler {
+	return &OrderEventHandler{
+		broker:      broker,
+		shopService: shopService,
+	}
+}
+
+// StreamOrderEvents 通过 SSE 推送店铺订单事件
+// 支持 Last-Event-ID 请求头（或 last_event_id 参数）断线补发
+func (h *OrderEventHandler) StreamOrderEvents(c *gin.Context) {
+	shopID, ok := h.resolveShopID(c)
+	if !ok {
+		return
+	}
+
+	sub, replay, gap := h.broker.Subscribe(shopID.ToUint64(), parseLastEventID(c))
+	defer h.broker.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetryMillis)
+	if gap {
+		// 补发缓冲区已不完整，通知客户端重新拉取订单列表
+		fmt.Fprint(c.Writer, "event: resync\ndata: {}\n\n")
+	}
+	for _, event := range replay {
+		if err := writeSSEEvent(c, event); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval())
+	defer heartbeat.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				// 消费过慢被事件总线断开，客户端将携带 Last-Event-ID 重连
+				return
+			}
+			if err := writeSSEEvent(c, event); err != nil {
+				log2.Errorf("推送订单事件失败: %v", err)
+				return
+			}
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprintf(c.Writer, "event: heartbeat\ndata: {\"time\":%d}\n\n", time.Now().Unix()); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// StreamOrderEventsWS 通过 WebSocket 推送店铺订单事件
+// 补发规则与 SSE 相同，心跳使用 ping 帧
+func (h *OrderEventHandler) StreamOrderEventsWS(c *gin.Context) {
+	shopID, ok := h.resolveShopID(c)
+	if !ok {
+		return
+	}
+	lastEventID := parseLastEventID(c)
+
+	conn, err := orderEventUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log2.Errorf("WebSocket 升级失败: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	sub, replay, gap := h.broker.Subscribe(shopID.ToUint64(), lastEventID)
+	defer h.broker.Unsubscribe(sub)
+
+	// 读协程只负责感知客户端断开
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if gap {
+		if err := writeWSMessage(conn, gin.H{"type": "resync"}); err != nil {
+			return
+		}
+	}
+	for _, event := range replay {
+		if err := writeWSMessage(conn, toOrderEventResponse(event)); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval())
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "slow consumer"),
+					time.Now().Add(wsWriteTimeout))
+				return
+			}
+			if err := writeWSMessage(conn, toOrderEventResponse(event)); err != nil {
+				log2.Errorf("推送订单事件失败: %v", err)
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *OrderEventHandler) resolveShopID(c *gin.Context) (shared.ID, bool) {
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return shared.ID(0), false
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return shared.ID(0), false
+	}
+	if validShopID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return shared.ID(0), false
+	}
+
+	return validShopID, true
+}
+
+func (h *OrderEventHandler) validateShopID(c *gin.Context, shopID shared.ID) (shared.ID, error) {
+	requestUser, exists := c.Get("userInfo")
+	if !exists {
+		return shared.ID(0), nil
+	}
+
+	userInfo := requestUser.(interface {
+		IsAdminUser() bool
+		GetUserID() uint64
+	})
+
+	if !userInfo.IsAdminUser() {
+		return shared.ParseIDFromUint64(userInfo.GetUserID()), nil
+	}
+
+	shop, err := h.shopService.GetShop(shopID)
+	if err != nil {
+		return shared.ID(0), err
+	}
+
+	return shop.ID, nil
+}
+
+func parseLastEventID(c *gin.Context) uint64 {
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		// 浏览器 EventSource 首次连接和 WebSocket 无法自定义请求头，允许通过参数传入
+		lastEventID = c.Query("last_event_id")
+	}
+	id, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+func heartbeatInterval() time.Duration {
+	if seconds := config.AppConfig.Events.HeartbeatInterval; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultHeartbeatInterval
+}
+
+func toOrderEventResponse(event order.OrderEvent) dto.OrderEventResponse {
+	response := dto.OrderEventResponse{
+		ID:         event.ID,
+		Type:       string(event.Type),
+		ShopID:     shared.ParseIDFromUint64(event.ShopID),
+		OrderID:    event.OrderID,
+		UserID:     event.UserID,
+		OldStatus:  event.OldStatus,
+		NewStatus:  event.NewStatus,
+		TotalPrice: event.TotalPrice,
+		OccurredAt: event.OccurredAt,
+	}
+	if event.Stock != nil {
+		response.Product = &dto.StockEventResponse{
+			ProductID:   event.Stock.ProductID,
+			ProductName: event.Stock.ProductName,
+			Stock:       event.Stock.Stock,
+			Threshold:   event.Stock.Threshold,
+			Status:      event.Stock.Status,
+		}
+	}
+	return response
+}
+
+func writeSSEEvent(c *gin.Context, event order.OrderEvent) error {
+	data, err := json.Marshal(toOrderEventResponse(event))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+func writeWSMessage(conn *websocket.Conn, v interface{}) error {
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return conn.WriteJSON(v)
+}
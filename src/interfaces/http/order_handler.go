@@ -6,8 +6,10 @@ import (
 	"orderease/application/services"
 	"orderease/domain/order"
 	"orderease/domain/shared"
+	"orderease/models"
 	"orderease/utils/log2"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,15 +17,21 @@ import (
 type OrderHandler struct {
 	orderService *services.OrderService
 	shopService  *services.ShopService
+	tableService *services.TableService
+	auditService *services.AuditService
 }
 
 func NewOrderHandler(
 	orderService *services.OrderService,
 	shopService *services.ShopService,
+	tableService *services.TableService,
+	auditService *services.AuditService,
 ) *OrderHandler {
 	return &OrderHandler{
 		orderService: orderService,
 		shopService:  shopService,
+		tableService: tableService,
+		auditService: auditService,
 	}
 }
 
@@ -41,6 +49,13 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	}
 	req.ShopID = shopID
 
+	// 顾客只能为自己下单，只能通过桌台二维码指定桌台
+	if customerID, isCustomer := h.customerID(c); isCustomer {
+		req.UserID = customerID
+		req.TableID = 0
+	}
+	req.Actor = statusActor(c)
+
 	response, err := h.orderService.CreateOrder(&req)
 	if err != nil {
 		log2.Errorf("创建订单失败: %v", err)
@@ -48,6 +63,54 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     response.ShopID,
+		EntityType: services.AuditEntityOrder,
+		EntityID:   response.ID.String(),
+		Action:     services.AuditActionCreate,
+		After:      response,
+	})
+
+	successResponse(c, response)
+}
+
+// GetScheduleSlots 查询店铺可以预约的取餐/送达时段
+func (h *OrderHandler) GetScheduleSlots(c *gin.Context) {
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.orderService.GetScheduleSlots(validShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(c, response)
+}
+
+// GetPickupBoard 取餐叫号屏，公开接口，只返回可取餐的取餐号
+func (h *OrderHandler) GetPickupBoard(c *gin.Context) {
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil || shopID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	response, err := h.orderService.GetPickupBoard(shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	successResponse(c, response)
 }
 
@@ -84,6 +147,62 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 		return
 	}
 
+	if customerID, isCustomer := h.customerID(c); isCustomer && !h.customerOwnsOrder(customerID, validShopID, response) {
+		errorResponse(c, http.StatusNotFound, "订单不存在")
+		return
+	}
+
+	successResponse(c, response)
+}
+
+// GetOrderTimeline 获取订单状态时间线（操作人、原因及店铺配置的状态名称）
+func (h *OrderHandler) GetOrderTimeline(c *gin.Context) {
+	idStr := c.Query("id")
+	if idStr == "" {
+		errorResponse(c, http.StatusBadRequest, "缺少订单ID")
+		return
+	}
+
+	id, err := shared.ParseIDFromString(idStr)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的订单ID")
+		return
+	}
+
+	shopIDStr := c.Query("shop_id")
+	shopID, err := shared.ParseIDFromString(shopIDStr)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if customerID, isCustomer := h.customerID(c); isCustomer {
+		ord, err := h.orderService.GetOrder(id, validShopID)
+		if err != nil || !h.customerOwnsOrder(customerID, validShopID, ord) {
+			errorResponse(c, http.StatusNotFound, "订单不存在")
+			return
+		}
+	}
+
+	shop, err := h.shopService.GetShop(validShopID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "获取店铺信息失败")
+		return
+	}
+
+	response, err := h.orderService.GetOrderTimeline(id, validShopID, shop.OrderStatusFlow)
+	if err != nil {
+		log2.Errorf("查询订单时间线失败: %v", err)
+		errorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
 	successResponse(c, response)
 }
 
@@ -114,6 +233,18 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 		return
 	}
 
+	// 顾客只能看到自己的订单
+	if customerID, isCustomer := h.customerID(c); isCustomer {
+		response, err := h.customerOrders(customerID, validShopID, page, pageSize)
+		if err != nil {
+			log2.Errorf("查询订单列表失败: %v", err)
+			errorResponse(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		successResponse(c, response)
+		return
+	}
+
 	response, err := h.orderService.GetOrders(validShopID, page, pageSize)
 	if err != nil {
 		log2.Errorf("查询订单列表失败: %v", err)
@@ -156,7 +287,12 @@ func (h *OrderHandler) GetOrdersByUser(c *gin.Context) {
 		pageSize = 100
 	}
 
-	response, err := h.orderService.GetOrdersByUser(userID, validShopID, page, pageSize)
+	var response *dto.OrderListResponse
+	if customerID, isCustomer := h.customerID(c); isCustomer {
+		response, err = h.customerOrders(customerID, validShopID, page, pageSize)
+	} else {
+		response, err = h.orderService.GetOrdersByUser(userID, validShopID, page, pageSize)
+	}
 	if err != nil {
 		log2.Errorf("查询用户订单失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, err.Error())
@@ -193,13 +329,29 @@ func (h *OrderHandler) GetUnfinishedOrders(c *gin.Context) {
 		return
 	}
 
+	// sort=fulfillment 按出餐时间（预约时间，未预约为下单时间）从早到晚排序
+	// fulfill_from、fulfill_to 按出餐时间筛选，RFC3339 格式
+	filter := order.UnfinishedFilter{SortByFulfillment: c.Query("sort") == "fulfillment"}
+	if value := c.Query("fulfill_from"); value != "" {
+		if filter.FulfillFrom, err = time.Parse(time.RFC3339, value); err != nil {
+			errorResponse(c, http.StatusBadRequest, "无效的开始时间")
+			return
+		}
+	}
+	if value := c.Query("fulfill_to"); value != "" {
+		if filter.FulfillTo, err = time.Parse(time.RFC3339, value); err != nil {
+			errorResponse(c, http.StatusBadRequest, "无效的结束时间")
+			return
+		}
+	}
+
 	shop, err := h.shopService.GetShop(validShopID)
 	if err != nil {
 		errorResponse(c, http.StatusInternalServerError, "获取店铺信息失败")
 		return
 	}
 
-	response, err := h.orderService.GetUnfinishedOrders(validShopID, shop.OrderStatusFlow, page, pageSize)
+	response, err := h.orderService.GetUnfinishedOrders(validShopID, shop.OrderStatusFlow, filter, page, pageSize)
 	if err != nil {
 		log2.Errorf("查询未完成订单列表失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, err.Error())
@@ -245,6 +397,7 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		ID         string    `json:"id" binding:"required"`
 		ShopID     shared.ID `json:"shop_id" binding:"required"`
 		NextStatus int       `json:"next_status" binding:"required"`
+		Reason     string    `json:"reason"`
 	}
 
 	var req UpdateOrderStatusRequest
@@ -272,13 +425,25 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
+	before, _ := h.orderService.GetOrder(id, validShopID)
+
 	newStatus := order.OrderStatus(req.NextStatus)
-	if err := h.orderService.UpdateOrderStatus(id, validShopID, newStatus, shop.OrderStatusFlow); err != nil {
+	if err := h.orderService.UpdateOrderStatus(id, validShopID, newStatus, shop.OrderStatusFlow, statusActor(c), req.Reason); err != nil {
 		log2.Errorf("更新订单状态失败: %v", err)
 		errorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	after, _ := h.orderService.GetOrder(id, validShopID)
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityOrder,
+		EntityID:   id.String(),
+		Action:     services.AuditActionStatusChange,
+		Before:     before,
+		After:      after,
+	})
+
 	successResponse(c, gin.H{
 		"message": "订单状态更新成功",
 	})
@@ -310,21 +475,111 @@ func (h *OrderHandler) DeleteOrder(c *gin.Context) {
 		return
 	}
 
-	if err := h.orderService.DeleteOrder(id, validShopID); err != nil {
+	if customerID, isCustomer := h.customerID(c); isCustomer {
+		ord, err := h.orderService.GetOrder(id, validShopID)
+		if err != nil || !h.customerOwnsOrder(customerID, validShopID, ord) {
+			errorResponse(c, http.StatusNotFound, "订单不存在")
+			return
+		}
+		// 扫码点餐的订单归入桌台账单，只能由店员处理
+		if h.isTableGuest(customerID, validShopID) {
+			errorResponse(c, http.StatusForbidden, "扫码点餐的订单不能删除，请联系店员")
+			return
+		}
+	}
+
+	shop, err := h.shopService.GetShop(validShopID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "获取店铺信息失败")
+		return
+	}
+
+	before, _ := h.orderService.GetOrder(id, validShopID)
+
+	if err := h.orderService.DeleteOrder(id, validShopID, shop.OrderStatusFlow, statusActor(c)); err != nil {
 		log2.Errorf("删除订单失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityOrder,
+		EntityID:   id.String(),
+		Action:     services.AuditActionDelete,
+		Before:     before,
+	})
+
 	successResponse(c, gin.H{"message": "订单删除成功"})
 }
 
+// customerID 返回前端顾客的用户ID，店主、员工和管理员返回 false
+func (h *OrderHandler) customerID(c *gin.Context) (shared.ID, bool) {
+	requestUser, exists := c.Get("userInfo")
+	if !exists {
+		return shared.ID(0), false
+	}
+
+	userInfo, ok := requestUser.(models.UserInfo)
+	if !ok {
+		return shared.ID(0), false
+	}
+
+	return shared.ParseIDFromUint64(userInfo.UserID), true
+}
+
+// customerOwnsOrder 顾客只能访问自己的订单，扫码点餐的顾客还只能访问所属用餐中的订单
+func (h *OrderHandler) customerOwnsOrder(customerID, shopID shared.ID, ord *dto.OrderDetailResponse) bool {
+	if ord.UserID != customerID {
+		return false
+	}
+	session, err := h.tableService.GuestSession(customerID, shopID)
+	if err != nil {
+		log2.Errorf("查询扫码点餐用餐失败: %v", err)
+		return false
+	}
+	return session == nil || ord.TableSessionID == session.ID
+}
+
+// isTableGuest 顾客是否为扫码点餐的顾客，查询失败时按扫码点餐处理
+func (h *OrderHandler) isTableGuest(customerID, shopID shared.ID) bool {
+	session, err := h.tableService.GuestSession(customerID, shopID)
+	return err != nil || session != nil
+}
+
+// customerOrders 顾客自己的订单，扫码点餐的顾客只返回所属用餐中的订单
+func (h *OrderHandler) customerOrders(customerID, shopID shared.ID, page, pageSize int) (*dto.OrderListResponse, error) {
+	session, err := h.tableService.GuestSession(customerID, shopID)
+	if err != nil {
+		return nil, err
+	}
+	if session != nil {
+		return h.orderService.GetTableGuestOrders(customerID, shopID, session.ID, page, pageSize)
+	}
+	return h.orderService.GetOrdersByUser(customerID, shopID, page, pageSize)
+}
+
+// statusActor 订单状态日志中记录的操作人
+func statusActor(c *gin.Context) order.StatusActor {
+	actor := auditActor(c)
+	return order.StatusActor{Type: actor.Type, ID: actor.ID, Name: actor.Name}
+}
+
 func (h *OrderHandler) validateShopID(c *gin.Context, shopID shared.ID) (shared.ID, error) {
 	requestUser, exists := c.Get("userInfo")
 	if !exists {
 		return shared.ID(0), nil
 	}
 
+	// 前端顾客可以访问任意店铺，但必须是存在的店铺，订单归属另行校验
+	if _, isCustomer := requestUser.(models.UserInfo); isCustomer {
+		shop, err := h.shopService.GetShop(shopID)
+		if err != nil {
+			return shared.ID(0), err
+		}
+		return shop.ID, nil
+	}
+
 	userInfo := requestUser.(interface {
 		IsAdminUser() bool
 		GetUserID() uint64
@@ -434,6 +689,12 @@ func (h *OrderHandler) GetAdvanceSearchOrders(c *gin.Context) {
 	if req.PageSize < 1 || req.PageSize > 100 {
 		req.PageSize = 10
 	}
+	for _, fulfillment := range req.FulfillmentType {
+		if !fulfillment.IsValid() {
+			errorResponse(c, http.StatusBadRequest, order.ErrInvalidFulfillmentType.Error())
+			return
+		}
+	}
 
 	validShopID, err := h.validateShopID(c, req.ShopID)
 	if err != nil {
@@ -477,13 +738,31 @@ func (h *OrderHandler) UpdateOrder(c *gin.Context) {
 		return
 	}
 	req.ShopID = validShopID
+	req.Actor = statusActor(c)
+
+	shop, err := h.shopService.GetShop(validShopID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "获取店铺信息失败")
+		return
+	}
+
+	before, _ := h.orderService.GetOrder(req.ID, validShopID)
 
-	response, err := h.orderService.UpdateOrder(&req)
+	response, err := h.orderService.UpdateOrder(&req, shop.OrderStatusFlow)
 	if err != nil {
 		log2.Errorf("更新订单失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityOrder,
+		EntityID:   req.ID.String(),
+		Action:     services.AuditActionUpdate,
+		Before:     before,
+		After:      response,
+	})
+
 	successResponse(c, response)
 }
@@ -0,0 +1,274 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"orderease/application/dto"
+	"orderease/application/services"
+	"orderease/domain/payment"
+	"orderease/domain/shared"
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentHandler struct {
+	paymentService *services.PaymentService
+	shopService    *services.ShopService
+	auditService   *services.AuditService
+}
+
+func NewPaymentHandler(paymentService *services.PaymentService, shopService *services.ShopService, auditService *services.AuditService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+		shopService:    shopService,
+		auditService:   auditService,
+	}
+}
+
+// CreatePayment 为订单发起支付，顾客只能为自己的订单发起支付
+func (h *PaymentHandler) CreatePayment(c *gin.Context) {
+	var req dto.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的支付数据: "+err.Error())
+		return
+	}
+
+	if req.OrderID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "缺少订单ID")
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = shopID
+	req.UserID, _ = h.customerID(c)
+
+	shop, err := h.shopService.GetShop(shopID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "获取店铺信息失败")
+		return
+	}
+
+	resp, err := h.paymentService.CreatePayment(&req, shop.OrderStatusFlow)
+	if err != nil {
+		log2.Errorf("发起支付失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(c, resp)
+}
+
+// ConfirmPayment 店员确认收到现金或到店付款
+func (h *PaymentHandler) ConfirmPayment(c *gin.Context) {
+	var req dto.ConfirmPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的支付数据: "+err.Error())
+		return
+	}
+
+	if req.PaymentID.IsZero() && req.OrderID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "缺少支付单ID或订单ID")
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = shopID
+
+	shop, err := h.shopService.GetShop(shopID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "获取店铺信息失败")
+		return
+	}
+
+	resp, err := h.paymentService.ConfirmOfflinePayment(&req, shop.OrderStatusFlow, auditActor(c))
+	if err != nil {
+		log2.Errorf("确认收款失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityPayment,
+		EntityID:   resp.ID.String(),
+		Action:     services.AuditActionConfirmPay,
+		After:      resp,
+	})
+
+	successResponse(c, resp)
+}
+
+// RefundPayment 为支付成功的支付单退款，支持部分退款
+func (h *PaymentHandler) RefundPayment(c *gin.Context) {
+	var req dto.RefundPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的退款数据: "+err.Error())
+		return
+	}
+
+	if req.PaymentID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "缺少支付单ID")
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = shopID
+
+	refund, err := h.paymentService.RefundPayment(&req, auditActor(c))
+	if err != nil {
+		log2.Errorf("退款失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityPayment,
+		EntityID:   req.PaymentID.String(),
+		Action:     services.AuditActionRefund,
+		After:      refund,
+	})
+
+	successResponse(c, refund)
+}
+
+// GetOrderPayments 获取订单的支付汇总、支付单和退款记录
+func (h *PaymentHandler) GetOrderPayments(c *gin.Context) {
+	orderID, err := shared.ParseIDFromString(c.Query("order_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "缺少订单ID")
+		return
+	}
+
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	customerID, _ := h.customerID(c)
+	resp, err := h.paymentService.GetOrderPayments(orderID, validShopID, customerID)
+	if err != nil {
+		errorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	successResponse(c, resp)
+}
+
+// MockPay 模拟顾客完成或放弃支付，仅在启用模拟支付网关时可用
+func (h *PaymentHandler) MockPay(c *gin.Context) {
+	var req dto.MockPayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的支付数据: "+err.Error())
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = shopID
+
+	customerID, _ := h.customerID(c)
+	resp, err := h.paymentService.SimulatePayment(&req, customerID)
+	if err != nil {
+		log2.Errorf("模拟支付失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(c, resp)
+}
+
+// HandleNotification 接收支付网关的支付结果回调
+// 签名验证失败返回 401；处理失败返回 500，由网关按其重试策略重新通知
+func (h *PaymentHandler) HandleNotification(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "读取回调内容失败")
+		return
+	}
+
+	ack, err := h.paymentService.HandleNotification(payment.Method(c.Param("method")), c.Request.Header, body)
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidSignature) {
+			log2.Warnf("支付回调签名无效, 支付方式: %s, 来源: %s", c.Param("method"), c.ClientIP())
+			errorResponse(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", ack)
+}
+
+// customerID 返回前端顾客的用户ID，店主、员工和管理员返回 false
+func (h *PaymentHandler) customerID(c *gin.Context) (shared.ID, bool) {
+	requestUser, exists := c.Get("userInfo")
+	if !exists {
+		return shared.ID(0), false
+	}
+
+	userInfo, ok := requestUser.(models.UserInfo)
+	if !ok {
+		return shared.ID(0), false
+	}
+
+	return shared.ParseIDFromUint64(userInfo.UserID), true
+}
+
+func (h *PaymentHandler) validateShopID(c *gin.Context, shopID shared.ID) (shared.ID, error) {
+	requestUser, exists := c.Get("userInfo")
+	if !exists {
+		return shared.ID(0), nil
+	}
+
+	// 前端顾客可以访问任意存在的店铺，支付单归属由服务层按用户校验
+	if _, isCustomer := requestUser.(models.UserInfo); isCustomer {
+		shop, err := h.shopService.GetShop(shopID)
+		if err != nil {
+			return shared.ID(0), err
+		}
+		return shop.ID, nil
+	}
+
+	userInfo := requestUser.(interface {
+		IsAdminUser() bool
+		GetUserID() uint64
+	})
+
+	if !userInfo.IsAdminUser() {
+		return shared.ParseIDFromUint64(userInfo.GetUserID()), nil
+	}
+
+	shop, err := h.shopService.GetShop(shopID)
+	if err != nil {
+		return shared.ID(0), err
+	}
+
+	return shop.ID, nil
+}
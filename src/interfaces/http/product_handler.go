@@ -5,6 +5,7 @@ import (
 	"os"
 	"orderease/application/dto"
 	"orderease/application/services"
+	"orderease/domain/product"
 	"orderease/domain/shared"
 	"orderease/utils/log2"
 	"strconv"
@@ -15,15 +16,18 @@ import (
 type ProductHandler struct {
 	productService *services.ProductService
 	shopService   *services.ShopService
+	auditService  *services.AuditService
 }
 
 func NewProductHandler(
 	productService *services.ProductService,
 	shopService *services.ShopService,
+	auditService *services.AuditService,
 ) *ProductHandler {
 	return &ProductHandler{
 		productService: productService,
 		shopService:   shopService,
+		auditService:  auditService,
 	}
 }
 
@@ -41,13 +45,21 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	}
 	req.ShopID = shopID
 
-	response, err := h.productService.CreateProduct(&req)
+	response, err := h.productService.CreateProduct(&req, auditActor(c))
 	if err != nil {
 		log2.Errorf("创建商品失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityProduct,
+		EntityID:   response.ID.String(),
+		Action:     services.AuditActionCreate,
+		After:      response,
+	})
+
 	successResponse(c, response)
 }
 
@@ -147,13 +159,24 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	response, err := h.productService.UpdateProduct(id, validShopID, &req)
+	before, _ := h.productService.GetProduct(id, validShopID)
+
+	response, err := h.productService.UpdateProduct(id, validShopID, &req, auditActor(c))
 	if err != nil {
 		log2.Errorf("更新商品失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityProduct,
+		EntityID:   id.String(),
+		Action:     services.AuditActionUpdate,
+		Before:     before,
+		After:      response,
+	})
+
 	successResponse(c, response)
 }
 
@@ -178,12 +201,22 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
+	before, _ := h.productService.GetProduct(id, validShopID)
+
 	if err := h.productService.DeleteProduct(id, validShopID); err != nil {
 		log2.Errorf("删除商品失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityProduct,
+		EntityID:   id.String(),
+		Action:     services.AuditActionDelete,
+		Before:     before,
+	})
+
 	successResponse(c, gin.H{"message": "商品删除成功"})
 }
 
@@ -208,12 +241,24 @@ func (h *ProductHandler) UpdateProductStatus(c *gin.Context) {
 		return
 	}
 
+	before, _ := h.productService.GetProduct(req.ID, validShopID)
+
 	if err := h.productService.UpdateProductStatus(&req, validShopID); err != nil {
 		log2.Errorf("更新商品状态失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	after, _ := h.productService.GetProduct(req.ID, validShopID)
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityProduct,
+		EntityID:   req.ID.String(),
+		Action:     services.AuditActionStatusChange,
+		Before:     before,
+		After:      after,
+	})
+
 	successResponse(c, gin.H{
 		"message": "商品状态更新成功",
 		"product": gin.H{
@@ -223,6 +268,132 @@ func (h *ProductHandler) UpdateProductStatus(c *gin.Context) {
 	})
 }
 
+// AdjustStock 手工调整库存或提交盘点结果
+func (h *ProductHandler) AdjustStock(c *gin.Context) {
+	var req dto.AdjustStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	shopIDStr := c.Query("shop_id")
+	shopID, err := shared.ParseIDFromString(shopIDStr)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.productService.AdjustStock(validShopID, &req, auditActor(c))
+	if err != nil {
+		log2.Errorf("调整商品库存失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityProduct,
+		EntityID:   req.ID.String(),
+		Action:     services.AuditActionAdjustStock,
+		After:      response,
+	})
+
+	successResponse(c, response)
+}
+
+// GetStockMovements 分页查询商品的库存流水
+func (h *ProductHandler) GetStockMovements(c *gin.Context) {
+	id, err := shared.ParseIDFromString(c.Query("id"))
+	if err != nil || id.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "缺少商品ID")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	if page < 1 {
+		errorResponse(c, http.StatusBadRequest, "页码必须大于0")
+		return
+	}
+
+	if pageSize < 1 || pageSize > 100 {
+		errorResponse(c, http.StatusBadRequest, "每页数量必须在1-100之间")
+		return
+	}
+
+	query := dto.StockMovementQuery{
+		ProductID: id,
+		Type:      product.StockMovementType(c.Query("type")),
+		Page:      page,
+		PageSize:  pageSize,
+	}
+	if query.Type != "" && !query.Type.IsValid() {
+		errorResponse(c, http.StatusBadRequest, "无效的流水类型")
+		return
+	}
+
+	if query.StartTime, err = parseAuditTime(c.Query("start_time")); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的开始时间")
+		return
+	}
+	if query.EndTime, err = parseAuditTime(c.Query("end_time")); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的结束时间")
+		return
+	}
+
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	query.ShopID, err = h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.productService.GetStockMovements(query)
+	if err != nil {
+		log2.Errorf("查询库存流水失败: %v", err)
+		errorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(c, response)
+}
+
+// GetLowStockProducts 查询店铺内已售罄或库存不高于预警值的商品
+func (h *ProductHandler) GetLowStockProducts(c *gin.Context) {
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.productService.GetLowStockProducts(validShopID)
+	if err != nil {
+		log2.Errorf("查询低库存商品失败: %v", err)
+		errorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(c, response)
+}
+
 func (h *ProductHandler) validateShopID(c *gin.Context, shopID shared.ID) (shared.ID, error) {
 	requestUser, exists := c.Get("userInfo")
 	if !exists {
@@ -323,6 +494,8 @@ func (h *ProductHandler) UploadProductImage(c *gin.Context) {
 	}
 	defer file.Close()
 
+	before, _ := h.productService.GetProduct(id, validShopID)
+
 	// 调用 service 层上传图片
 	filename, err := h.productService.UploadProductImage(id, validShopID, file, fileHeader.Filename)
 	if err != nil {
@@ -331,6 +504,16 @@ func (h *ProductHandler) UploadProductImage(c *gin.Context) {
 		return
 	}
 
+	after, _ := h.productService.GetProduct(id, validShopID)
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityProduct,
+		EntityID:   id.String(),
+		Action:     services.AuditActionUploadImage,
+		Before:     before,
+		After:      after,
+	})
+
 	successResponse(c, gin.H{
 		"message": "图片上传成功",
 		"url":     filename,
@@ -0,0 +1,217 @@
+package http
+
+import (
+	"net/http"
+	"orderease/application/dto"
+	"orderease/application/services"
+	"orderease/domain/shared"
+	"orderease/utils/log2"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PromotionHandler struct {
+	promotionService *services.PromotionService
+	shopService      *services.ShopService
+	auditService     *services.AuditService
+}
+
+func NewPromotionHandler(promotionService *services.PromotionService, shopService *services.ShopService, auditService *services.AuditService) *PromotionHandler {
+	return &PromotionHandler{
+		promotionService: promotionService,
+		shopService:      shopService,
+		auditService:     auditService,
+	}
+}
+
+// CreatePromotion 创建优惠券或自动促销
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	var req dto.CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的优惠数据: "+err.Error())
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = shopID
+
+	promotion, err := h.promotionService.CreatePromotion(&req)
+	if err != nil {
+		log2.Errorf("创建优惠失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityPromotion,
+		EntityID:   promotion.ID.String(),
+		Action:     services.AuditActionCreate,
+		After:      promotion,
+	})
+
+	successResponse(c, promotion)
+}
+
+// UpdatePromotion 更新优惠配置，也用于启用、停用优惠
+func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	var req dto.UpdatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的优惠数据: "+err.Error())
+		return
+	}
+
+	if req.ID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "缺少优惠ID")
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = shopID
+
+	before, _ := h.promotionService.GetPromotion(req.ID, shopID)
+
+	promotion, err := h.promotionService.UpdatePromotion(&req)
+	if err != nil {
+		log2.Errorf("更新优惠失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityPromotion,
+		EntityID:   req.ID.String(),
+		Action:     services.AuditActionUpdate,
+		Before:     before,
+		After:      promotion,
+	})
+
+	successResponse(c, promotion)
+}
+
+// DeletePromotion 删除优惠，已下单的优惠快照不受影响
+func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
+	id, err := shared.ParseIDFromString(c.Query("id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "缺少优惠ID")
+		return
+	}
+
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	before, _ := h.promotionService.GetPromotion(id, validShopID)
+
+	if err := h.promotionService.DeletePromotion(id, validShopID); err != nil {
+		log2.Errorf("删除优惠失败: %v", err)
+		errorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityPromotion,
+		EntityID:   id.String(),
+		Action:     services.AuditActionDelete,
+		Before:     before,
+	})
+
+	successResponse(c, gin.H{"message": "优惠删除成功"})
+}
+
+// GetPromotion 获取优惠详情
+func (h *PromotionHandler) GetPromotion(c *gin.Context) {
+	id, err := shared.ParseIDFromString(c.Query("id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "缺少优惠ID")
+		return
+	}
+
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	promotion, err := h.promotionService.GetPromotion(id, validShopID)
+	if err != nil {
+		errorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	successResponse(c, promotion)
+}
+
+// GetPromotions 获取店铺的优惠列表
+func (h *PromotionHandler) GetPromotions(c *gin.Context) {
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	promotions, err := h.promotionService.GetPromotions(validShopID)
+	if err != nil {
+		log2.Errorf("获取优惠列表失败: %v", err)
+		errorResponse(c, http.StatusInternalServerError, "获取优惠列表失败")
+		return
+	}
+
+	successResponse(c, gin.H{
+		"total": len(promotions),
+		"data":  promotions,
+	})
+}
+
+func (h *PromotionHandler) validateShopID(c *gin.Context, shopID shared.ID) (shared.ID, error) {
+	requestUser, exists := c.Get("userInfo")
+	if !exists {
+		return shared.ID(0), nil
+	}
+
+	userInfo := requestUser.(interface {
+		IsAdminUser() bool
+		GetUserID() uint64
+	})
+
+	if !userInfo.IsAdminUser() {
+		return shared.ParseIDFromUint64(userInfo.GetUserID()), nil
+	}
+
+	shop, err := h.shopService.GetShop(shopID)
+	if err != nil {
+		return shared.ID(0), err
+	}
+
+	return shop.ID, nil
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"net/http"
+	"orderease/application/dto"
+	"orderease/application/services"
+	"orderease/domain/shared"
+	"orderease/utils/log2"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RefundHandler struct {
+	refundService *services.RefundService
+	shopService   *services.ShopService
+	auditService  *services.AuditService
+}
+
+func NewRefundHandler(refundService *services.RefundService, shopService *services.ShopService, auditService *services.AuditService) *RefundHandler {
+	return &RefundHandler{
+		refundService: refundService,
+		shopService:   shopService,
+		auditService:  auditService,
+	}
+}
+
+// RefundOrder 已完成订单的全额或部分退款
+func (h *RefundHandler) RefundOrder(c *gin.Context) {
+	var req dto.RefundOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的退款数据: "+err.Error())
+		return
+	}
+
+	if req.OrderID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "缺少订单ID")
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = shopID
+
+	shop, err := h.shopService.GetShop(shopID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "获取店铺信息失败")
+		return
+	}
+
+	refund, err := h.refundService.RefundOrder(&req, shop.OrderStatusFlow, statusActor(c))
+	if err != nil {
+		log2.Errorf("订单退款失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityOrder,
+		EntityID:   req.OrderID.String(),
+		Action:     services.AuditActionRefund,
+		After:      refund,
+	})
+
+	successResponse(c, refund)
+}
+
+// GetOrderRefunds 获取订单的退款记录和剩余可退金额
+func (h *RefundHandler) GetOrderRefunds(c *gin.Context) {
+	orderID, err := shared.ParseIDFromString(c.Query("order_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "缺少订单ID")
+		return
+	}
+
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.refundService.GetOrderRefunds(orderID, validShopID)
+	if err != nil {
+		errorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	successResponse(c, resp)
+}
+
+// GetRevenueSummary 营业额汇总，start_time、end_time 为 RFC3339 格式，按下单时间筛选
+func (h *RefundHandler) GetRevenueSummary(c *gin.Context) {
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	startTime, err := parseAuditTime(c.Query("start_time"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的开始时间")
+		return
+	}
+	endTime, err := parseAuditTime(c.Query("end_time"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的结束时间")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	shop, err := h.shopService.GetShop(validShopID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "获取店铺信息失败")
+		return
+	}
+
+	resp, err := h.refundService.GetRevenueSummary(validShopID, shop.OrderStatusFlow, startTime, endTime)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(c, resp)
+}
+
+func (h *RefundHandler) validateShopID(c *gin.Context, shopID shared.ID) (shared.ID, error) {
+	requestUser, exists := c.Get("userInfo")
+	if !exists {
+		return shared.ID(0), nil
+	}
+
+	userInfo := requestUser.(interface {
+		IsAdminUser() bool
+		GetUserID() uint64
+	})
+
+	if !userInfo.IsAdminUser() {
+		return shared.ParseIDFromUint64(userInfo.GetUserID()), nil
+	}
+
+	shop, err := h.shopService.GetShop(shopID)
+	if err != nil {
+		return shared.ID(0), err
+	}
+
+	return shop.ID, nil
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"orderease/application/services"
 	"orderease/config"
+	"orderease/domain/shop"
 	imiddleware "orderease/interfaces/middleware"
 	"orderease/middleware"
 	"strconv"
@@ -12,24 +13,46 @@ import (
 )
 
 type Router struct {
-	orderHandler   *OrderHandler
-	productHandler *ProductHandler
-	shopHandler    *ShopHandler
-	userHandler    *UserHandler
-	authHandler    *AuthHandler
-	exportHandler  *ExportHandler
-	importHandler  *ImportHandler
+	orderHandler      *OrderHandler
+	orderEventHandler *OrderEventHandler
+	productHandler    *ProductHandler
+	shopHandler       *ShopHandler
+	userHandler       *UserHandler
+	authHandler       *AuthHandler
+	exportHandler     *ExportHandler
+	importHandler     *ImportHandler
+	sessionHandler    *SessionHandler
+	staffHandler      *StaffHandler
+	auditHandler      *AuditHandler
+	promotionHandler  *PromotionHandler
+	paymentHandler    *PaymentHandler
+	refundHandler     *RefundHandler
+	cartHandler       *CartHandler
+	tableHandler      *TableHandler
+	tokenBlacklist    *services.TokenBlacklistService
+	idempotency       *services.IdempotencyService
 }
 
 func NewRouter(db *gorm.DB, services *services.ServiceContainer) *Router {
 	return &Router{
-		orderHandler:   NewOrderHandler(services.OrderService, services.ShopService),
-		productHandler: NewProductHandler(services.ProductService, services.ShopService),
-		shopHandler:    NewShopHandler(services.ShopService),
-		userHandler:    NewUserHandler(services.UserService),
-		authHandler:    NewAuthHandler(db, services.ShopService, services.UserService, services.TempTokenService),
-		exportHandler:  NewExportHandler(db),
-		importHandler:  NewImportHandler(db),
+		orderHandler:      NewOrderHandler(services.OrderService, services.ShopService, services.TableService, services.AuditService),
+		orderEventHandler: NewOrderEventHandler(services.OrderEventBroker, services.ShopService),
+		productHandler:    NewProductHandler(services.ProductService, services.ShopService, services.AuditService),
+		shopHandler:       NewShopHandler(services.ShopService, services.AuditService),
+		userHandler:       NewUserHandler(services.UserService, services.AuditService),
+		authHandler:       NewAuthHandler(db, services.ShopService, services.UserService, services.TempTokenService, services.TokenBlacklistService, services.RefreshTokenService, services.StaffService, services.TableService),
+		exportHandler:     NewExportHandler(db),
+		importHandler:     NewImportHandler(db),
+		sessionHandler:    NewSessionHandler(services.RefreshTokenService),
+		staffHandler:      NewStaffHandler(services.StaffService, services.ShopService, services.AuditService),
+		auditHandler:      NewAuditHandler(services.AuditService),
+		promotionHandler:  NewPromotionHandler(services.PromotionService, services.ShopService, services.AuditService),
+		paymentHandler:    NewPaymentHandler(services.PaymentService, services.ShopService, services.AuditService),
+		refundHandler:     NewRefundHandler(services.RefundService, services.ShopService, services.AuditService),
+		cartHandler:       NewCartHandler(services.CartService, services.ShopService, services.AuditService),
+		tableHandler:      NewTableHandler(services.TableService, services.ShopService, services.AuditService),
+		tokenBlacklist:    services.TokenBlacklistService,
+		idempotency:       services.IdempotencyService,
 	}
 }
 
@@ -51,6 +74,7 @@ func (r *Router) setupAuthRoutes(api *gin.RouterGroup) {
 	api.POST("/shop/refresh-token", r.authHandler.RefreshShopToken)
 	api.POST("/admin/refresh-token", r.authHandler.RefreshAdminToken)
 	api.POST("/shop/temp-login", r.authHandler.TempTokenLogin)
+	api.POST("/shop/table-login", r.authHandler.TableLogin)
 }
 
 // 无认证路由（公开查询）
@@ -68,7 +92,11 @@ func (r *Router) setupNoAuthRoutes(api *gin.RouterGroup) {
 		noAuth.GET("/order/list", r.orderHandler.GetOrders)
 		noAuth.GET("/order/detail", r.orderHandler.GetOrder)
 		noAuth.GET("/order/user/list", r.orderHandler.GetOrdersByUser)
+		noAuth.GET("/order/pickup-board", r.orderHandler.GetPickupBoard)
 		noAuth.GET("/tag/list", r.shopHandler.GetShopTags)
+
+		// 支付网关回调，由网关签名验证来源
+		noAuth.POST("/payment/notify/:method", r.paymentHandler.HandleNotification)
 	}
 
 	// 用户认证相关公开路由
@@ -77,88 +105,145 @@ func (r *Router) setupNoAuthRoutes(api *gin.RouterGroup) {
 		user.POST("/login", r.authHandler.FrontendUserLogin)
 		user.POST("/register", r.authHandler.FrontendUserRegister)
 		user.GET("/check-username", r.userHandler.CheckFrontendUsernameExists)
+		user.POST("/refresh-token", r.authHandler.RefreshUserToken)
 	}
 }
 
-// 店主路由（需要认证）
+// 店主路由（需要认证，店铺员工共用）
 func (r *Router) setupShopOwnerRoutes(api *gin.RouterGroup) {
 	shopOwner := api.Group("/shopOwner")
-	shopOwner.Use(imiddleware.AuthMiddleware())
+	shopOwner.Use(imiddleware.AuthMiddleware(r.tokenBlacklist))
+	// 员工子账号按角色授权，店主拥有全部权限
+	perm := imiddleware.RequirePermission
 	{
 		// 认证管理
 		shopOwner.POST("/logout", r.authHandler.Logout)
 		shopOwner.POST("/change-password", r.authHandler.ChangePassword)
+		shopOwner.GET("/sessions", r.sessionHandler.GetSessions)
+		shopOwner.DELETE("/sessions/:id", r.sessionHandler.RevokeSession)
+		shopOwner.POST("/sessions/revoke-others", r.sessionHandler.RevokeOtherSessions)
 
 		// 店铺管理
 		// shopOwner.POST("/shop/create", r.shopHandler.CreateShop)
-		shopOwner.PUT("/shop/update", r.shopHandler.UpdateShop)
-		shopOwner.DELETE("/shop/delete", r.shopHandler.DeleteShop)
-		shopOwner.GET("/shop/detail", r.shopHandler.GetShopInfo)
-		shopOwner.PUT("/shop/update-order-status-flow", r.shopHandler.UpdateOrderStatusFlow)
-		shopOwner.GET("/shop/temp-token", r.authHandler.GetShopTempToken)
-		shopOwner.GET("/shop/image", r.shopHandler.GetShopImage)
-		shopOwner.POST("/shop/upload-image", r.shopHandler.UploadShopImage)
+		shopOwner.PUT("/shop/update", perm(shop.PermShopManage), r.shopHandler.UpdateShop)
+		shopOwner.DELETE("/shop/delete", perm(shop.PermShopManage), r.shopHandler.DeleteShop)
+		shopOwner.GET("/shop/detail", perm(shop.PermShopView), r.shopHandler.GetShopInfo)
+		shopOwner.PUT("/shop/update-order-status-flow", perm(shop.PermShopManage), r.shopHandler.UpdateOrderStatusFlow)
+		shopOwner.PUT("/shop/ordering-pause", perm(shop.PermShopManage), r.shopHandler.UpdateOrderingPause)
+		shopOwner.GET("/shop/temp-token", perm(shop.PermShopManage), r.authHandler.GetShopTempToken)
+		shopOwner.GET("/shop/image", perm(shop.PermShopView), r.shopHandler.GetShopImage)
+		shopOwner.POST("/shop/upload-image", perm(shop.PermShopManage), r.shopHandler.UploadShopImage)
 
 		// 商品管理
-		shopOwner.POST("/product/create", r.productHandler.CreateProduct)
-		shopOwner.PUT("/product/update", r.productHandler.UpdateProduct)
-		shopOwner.DELETE("/product/delete", r.productHandler.DeleteProduct)
-		shopOwner.PUT("/product/status", r.productHandler.UpdateProductStatus)
-		shopOwner.PUT("/product/toggle-status", r.productHandler.ToggleProductStatus)
-		shopOwner.GET("/product/detail", r.productHandler.GetProduct)
-		shopOwner.GET("/product/list", r.productHandler.GetProducts)
-		shopOwner.GET("/product/image", r.productHandler.GetProductImage)
-		shopOwner.POST("/product/upload-image", r.productHandler.UploadProductImage)
+		shopOwner.POST("/product/create", perm(shop.PermProductManage), r.productHandler.CreateProduct)
+		shopOwner.PUT("/product/update", perm(shop.PermProductManage), r.productHandler.UpdateProduct)
+		shopOwner.DELETE("/product/delete", perm(shop.PermProductManage), r.productHandler.DeleteProduct)
+		shopOwner.PUT("/product/status", perm(shop.PermProductManage), r.productHandler.UpdateProductStatus)
+		shopOwner.PUT("/product/toggle-status", perm(shop.PermProductManage), r.productHandler.ToggleProductStatus)
+		shopOwner.GET("/product/detail", perm(shop.PermProductManage), r.productHandler.GetProduct)
+		shopOwner.GET("/product/list", perm(shop.PermProductManage), r.productHandler.GetProducts)
+		shopOwner.GET("/product/image", perm(shop.PermProductManage), r.productHandler.GetProductImage)
+		shopOwner.POST("/product/upload-image", perm(shop.PermProductManage), r.productHandler.UploadProductImage)
+		shopOwner.POST("/product/stock/adjust", perm(shop.PermProductManage), r.productHandler.AdjustStock)
+		shopOwner.GET("/product/stock/movements", perm(shop.PermProductManage), r.productHandler.GetStockMovements)
+		shopOwner.GET("/product/low-stock", perm(shop.PermProductManage), r.productHandler.GetLowStockProducts)
 
 		// 订单管理
-		shopOwner.POST("/order/create", r.orderHandler.CreateOrder)
-		shopOwner.PUT("/order/update", r.orderHandler.UpdateOrder)
-		shopOwner.PUT("/order/status", r.orderHandler.UpdateOrderStatus)
-		shopOwner.PUT("/order/toggle-status", r.orderHandler.ToggleOrderStatus)
-		shopOwner.DELETE("/order/delete", r.orderHandler.DeleteOrder)
-		shopOwner.GET("/order/detail", r.orderHandler.GetOrder)
-		shopOwner.GET("/order/list", r.orderHandler.GetOrders)
-		shopOwner.GET("/order/user-orders", r.orderHandler.GetOrdersByUser)
-		shopOwner.GET("/order/unfinished", r.orderHandler.GetUnfinishedOrders)
-		shopOwner.POST("/order/search", r.orderHandler.SearchOrders)
-		shopOwner.POST("/order/advance-search", r.orderHandler.GetAdvanceSearchOrders)
-		shopOwner.GET("/order/status-flow", r.orderHandler.GetOrderStatusFlow)
-		shopOwner.GET("/order/user/list", r.orderHandler.GetOrdersByUser)
+		shopOwner.POST("/order/create", perm(shop.PermOrderCreate), idempotent(r.idempotency, services.ScopeOrderCreate), r.orderHandler.CreateOrder)
+		shopOwner.PUT("/order/update", perm(shop.PermOrderEdit), r.orderHandler.UpdateOrder)
+		shopOwner.PUT("/order/status", perm(shop.PermOrderStatus), r.orderHandler.UpdateOrderStatus)
+		shopOwner.PUT("/order/toggle-status", perm(shop.PermOrderStatus), r.orderHandler.ToggleOrderStatus)
+		shopOwner.DELETE("/order/delete", perm(shop.PermOrderEdit), r.orderHandler.DeleteOrder)
+		shopOwner.GET("/order/detail", perm(shop.PermOrderView), r.orderHandler.GetOrder)
+		shopOwner.GET("/order/timeline", perm(shop.PermOrderView), r.orderHandler.GetOrderTimeline)
+		shopOwner.GET("/order/list", perm(shop.PermOrderView), r.orderHandler.GetOrders)
+		shopOwner.GET("/order/user-orders", perm(shop.PermOrderView), r.orderHandler.GetOrdersByUser)
+		shopOwner.GET("/order/unfinished", perm(shop.PermOrderUnfinished), r.orderHandler.GetUnfinishedOrders)
+		shopOwner.GET("/order/schedule-slots", perm(shop.PermOrderCreate), r.orderHandler.GetScheduleSlots)
+		shopOwner.POST("/order/search", perm(shop.PermOrderView), r.orderHandler.SearchOrders)
+		shopOwner.POST("/order/advance-search", perm(shop.PermOrderView), r.orderHandler.GetAdvanceSearchOrders)
+		shopOwner.GET("/order/status-flow", perm(shop.PermOrderStatus), r.orderHandler.GetOrderStatusFlow)
+		shopOwner.GET("/order/user/list", perm(shop.PermOrderView), r.orderHandler.GetOrdersByUser)
+		shopOwner.GET("/order/events", perm(shop.PermOrderView), r.orderEventHandler.StreamOrderEvents)
+		shopOwner.GET("/order/events/ws", perm(shop.PermOrderView), r.orderEventHandler.StreamOrderEventsWS)
 
 		// 标签管理
-		shopOwner.POST("/tag/create", r.shopHandler.CreateTag)
-		shopOwner.PUT("/tag/update", r.shopHandler.UpdateTag)
-		shopOwner.DELETE("/tag/delete", r.shopHandler.DeleteTag)
-		shopOwner.GET("/tag/list", r.shopHandler.GetShopTags)
-		shopOwner.GET("/tag/detail", r.shopHandler.GetTag)
-		shopOwner.GET("/tag/bound-tags", r.shopHandler.GetBoundTags)
-		shopOwner.GET("/tag/unbound-tags", r.shopHandler.GetUnboundTags)
-		shopOwner.POST("/tag/batch-tag", r.shopHandler.BatchTagProducts)
-		shopOwner.DELETE("/tag/batch-untag", r.shopHandler.BatchUntagProducts)
-		shopOwner.POST("/tag/batch-tag-product", r.shopHandler.BatchTagProduct)
-		shopOwner.GET("/tag/bound-products", r.shopHandler.GetTagBoundProducts)
-		shopOwner.GET("/tag/unbound-products", r.shopHandler.GetUnboundProductsForTag)
-		shopOwner.GET("/tag/unbound-list", r.shopHandler.GetUnboundTagsList)
-		shopOwner.GET("/tag/online-products", r.shopHandler.GetTagOnlineProducts)
+		shopOwner.POST("/tag/create", perm(shop.PermTagManage), r.shopHandler.CreateTag)
+		shopOwner.PUT("/tag/update", perm(shop.PermTagManage), r.shopHandler.UpdateTag)
+		shopOwner.DELETE("/tag/delete", perm(shop.PermTagManage), r.shopHandler.DeleteTag)
+		shopOwner.GET("/tag/list", perm(shop.PermTagManage), r.shopHandler.GetShopTags)
+		shopOwner.GET("/tag/detail", perm(shop.PermTagManage), r.shopHandler.GetTag)
+		shopOwner.GET("/tag/bound-tags", perm(shop.PermTagManage), r.shopHandler.GetBoundTags)
+		shopOwner.GET("/tag/unbound-tags", perm(shop.PermTagManage), r.shopHandler.GetUnboundTags)
+		shopOwner.POST("/tag/batch-tag", perm(shop.PermTagManage), r.shopHandler.BatchTagProducts)
+		shopOwner.DELETE("/tag/batch-untag", perm(shop.PermTagManage), r.shopHandler.BatchUntagProducts)
+		shopOwner.POST("/tag/batch-tag-product", perm(shop.PermTagManage), r.shopHandler.BatchTagProduct)
+		shopOwner.GET("/tag/bound-products", perm(shop.PermTagManage), r.shopHandler.GetTagBoundProducts)
+		shopOwner.GET("/tag/unbound-products", perm(shop.PermTagManage), r.shopHandler.GetUnboundProductsForTag)
+		shopOwner.GET("/tag/unbound-list", perm(shop.PermTagManage), r.shopHandler.GetUnboundTagsList)
+		shopOwner.GET("/tag/online-products", perm(shop.PermTagManage), r.shopHandler.GetTagOnlineProducts)
 
 		// 用户管理
-		shopOwner.POST("/user/create", r.userHandler.CreateUser)
-		shopOwner.PUT("/user/update", r.userHandler.UpdateUser)
-		shopOwner.DELETE("/user/delete", r.userHandler.DeleteUser)
-		shopOwner.GET("/user/detail", r.userHandler.GetUser)
-		shopOwner.GET("/user/list", r.userHandler.GetUsers)
-		shopOwner.GET("/user/simple-list", r.userHandler.GetUserSimpleList)
+		shopOwner.POST("/user/create", perm(shop.PermUserManage), r.userHandler.CreateUser)
+		shopOwner.PUT("/user/update", perm(shop.PermUserManage), r.userHandler.UpdateUser)
+		shopOwner.DELETE("/user/delete", perm(shop.PermUserManage), r.userHandler.DeleteUser)
+		shopOwner.GET("/user/detail", perm(shop.PermUserManage), r.userHandler.GetUser)
+		shopOwner.GET("/user/list", perm(shop.PermUserManage), r.userHandler.GetUsers)
+		shopOwner.GET("/user/simple-list", perm(shop.PermUserManage), r.userHandler.GetUserSimpleList)
+
+		// 员工管理
+		shopOwner.POST("/staff/create", perm(shop.PermStaffManage), r.staffHandler.CreateStaff)
+		shopOwner.PUT("/staff/update", perm(shop.PermStaffManage), r.staffHandler.UpdateStaff)
+		shopOwner.DELETE("/staff/delete", perm(shop.PermStaffManage), r.staffHandler.DeleteStaff)
+		shopOwner.GET("/staff/list", perm(shop.PermStaffManage), r.staffHandler.GetStaffList)
+
+		// 优惠券和促销
+		shopOwner.POST("/promotion/create", perm(shop.PermPromotionManage), r.promotionHandler.CreatePromotion)
+		shopOwner.PUT("/promotion/update", perm(shop.PermPromotionManage), r.promotionHandler.UpdatePromotion)
+		shopOwner.DELETE("/promotion/delete", perm(shop.PermPromotionManage), r.promotionHandler.DeletePromotion)
+		shopOwner.GET("/promotion/detail", perm(shop.PermPromotionManage), r.promotionHandler.GetPromotion)
+		shopOwner.GET("/promotion/list", perm(shop.PermPromotionManage), r.promotionHandler.GetPromotions)
+
+		// 支付和退款
+		shopOwner.POST("/payment/create", perm(shop.PermPaymentManage), r.paymentHandler.CreatePayment)
+		shopOwner.POST("/payment/confirm", perm(shop.PermPaymentManage), r.paymentHandler.ConfirmPayment)
+		shopOwner.POST("/payment/refund", perm(shop.PermPaymentRefund), r.paymentHandler.RefundPayment)
+		shopOwner.GET("/payment/list", perm(shop.PermPaymentManage), r.paymentHandler.GetOrderPayments)
+
+		// 订单售后退款和营业额
+		shopOwner.POST("/order/refund", perm(shop.PermPaymentRefund), r.refundHandler.RefundOrder)
+		shopOwner.GET("/order/refund/list", perm(shop.PermOrderView), r.refundHandler.GetOrderRefunds)
+		shopOwner.GET("/order/revenue", perm(shop.PermRevenueView), r.refundHandler.GetRevenueSummary)
+
+		// 堂食桌台
+		shopOwner.POST("/table/create", perm(shop.PermTableManage), r.tableHandler.CreateTable)
+		shopOwner.PUT("/table/update", perm(shop.PermTableManage), r.tableHandler.UpdateTable)
+		shopOwner.DELETE("/table/delete", perm(shop.PermTableManage), r.tableHandler.DeleteTable)
+		shopOwner.POST("/table/regenerate-qr", perm(shop.PermTableManage), r.tableHandler.RegenerateQRCode)
+		shopOwner.GET("/table/list", perm(shop.PermOrderView), r.tableHandler.GetTables)
+		shopOwner.POST("/table/session/open", perm(shop.PermOrderCreate), r.tableHandler.OpenSession)
+		shopOwner.POST("/table/transfer", perm(shop.PermOrderEdit), r.tableHandler.TransferTable)
+		shopOwner.POST("/table/merge", perm(shop.PermOrderEdit), r.tableHandler.MergeTables)
+		shopOwner.GET("/table/session/bill", perm(shop.PermOrderView), r.tableHandler.GetSessionBill)
+		shopOwner.POST("/table/session/close", perm(shop.PermOrderEdit), r.tableHandler.CloseSession)
+		shopOwner.POST("/table/session/settle", perm(shop.PermPaymentManage), r.tableHandler.SettleSession)
+
+		// 操作审计
+		shopOwner.GET("/audit", perm(shop.PermAuditView), r.auditHandler.GetAuditLogs)
 	}
 }
 
 // 管理员路由（需要管理员权限）
 func (r *Router) setupAdminRoutes(api *gin.RouterGroup) {
 	admin := api.Group("/admin")
-	admin.Use(imiddleware.AuthMiddleware(), imiddleware.AdminMiddleware())
+	admin.Use(imiddleware.AuthMiddleware(r.tokenBlacklist), imiddleware.AdminMiddleware())
 	{
 		// 认证管理
 		admin.POST("/logout", r.authHandler.Logout)
 		admin.POST("/change-password", r.authHandler.ChangePassword)
+		admin.GET("/sessions", r.sessionHandler.GetSessions)
+		admin.DELETE("/sessions/:id", r.sessionHandler.RevokeSession)
+		admin.POST("/sessions/revoke-others", r.sessionHandler.RevokeOtherSessions)
 
 		// 店铺管理
 		admin.POST("/shop/create", r.shopHandler.CreateShop)
@@ -167,6 +252,7 @@ func (r *Router) setupAdminRoutes(api *gin.RouterGroup) {
 		admin.GET("/shop/list", r.shopHandler.GetShopList)
 		admin.GET("/shop/detail", r.shopHandler.GetShopInfo)
 		admin.PUT("/shop/update-order-status-flow", r.shopHandler.UpdateOrderStatusFlow)
+		admin.PUT("/shop/ordering-pause", r.shopHandler.UpdateOrderingPause)
 		admin.GET("/shop/check-name", r.shopHandler.CheckShopNameExists)
 		admin.GET("/shop/image", r.shopHandler.GetShopImage)
 		admin.POST("/shop/upload-image", r.shopHandler.UploadShopImage)
@@ -182,21 +268,28 @@ func (r *Router) setupAdminRoutes(api *gin.RouterGroup) {
 		admin.GET("/product/detail", r.productHandler.GetProduct)
 		admin.GET("/product/image", r.productHandler.GetProductImage)
 		admin.POST("/product/upload-image", r.productHandler.UploadProductImage)
+		admin.POST("/product/stock/adjust", r.productHandler.AdjustStock)
+		admin.GET("/product/stock/movements", r.productHandler.GetStockMovements)
+		admin.GET("/product/low-stock", r.productHandler.GetLowStockProducts)
 
 		// 订单管理
-		admin.POST("/order/create", r.orderHandler.CreateOrder)
+		admin.POST("/order/create", idempotent(r.idempotency, services.ScopeOrderCreate), r.orderHandler.CreateOrder)
 		admin.PUT("/order/update", r.orderHandler.UpdateOrder)
 		admin.PUT("/order/status", r.orderHandler.UpdateOrderStatus)
 		admin.PUT("/order/toggle-status", r.orderHandler.ToggleOrderStatus)
 		admin.DELETE("/order/delete", r.orderHandler.DeleteOrder)
 		admin.GET("/order/list", r.orderHandler.GetOrders)
 		admin.GET("/order/detail", r.orderHandler.GetOrder)
+		admin.GET("/order/timeline", r.orderHandler.GetOrderTimeline)
 		admin.POST("/order/search", r.orderHandler.SearchOrders)
 		admin.POST("/order/advance-search", r.orderHandler.GetAdvanceSearchOrders)
 		admin.GET("/order/status-flow", r.orderHandler.GetOrderStatusFlow)
 		admin.GET("/order/user/list", r.orderHandler.GetOrdersByUser)
 		admin.GET("/order/unfinished", r.orderHandler.GetUnfinishedOrders)
+		admin.GET("/order/schedule-slots", r.orderHandler.GetScheduleSlots)
 		admin.GET("/order/user-orders", r.orderHandler.GetOrdersByUser)
+		admin.GET("/order/events", r.orderEventHandler.StreamOrderEvents)
+		admin.GET("/order/events/ws", r.orderEventHandler.StreamOrderEventsWS)
 
 		// 标签管理
 		admin.POST("/tag/create", r.shopHandler.CreateTag)
@@ -225,6 +318,46 @@ func (r *Router) setupAdminRoutes(api *gin.RouterGroup) {
 		admin.GET("/user/list", r.userHandler.GetUsers)
 		admin.GET("/user/simple-list", r.userHandler.GetUserSimpleList)
 		admin.GET("/user/detail", r.userHandler.GetUser)
+
+		// 员工管理
+		admin.POST("/staff/create", r.staffHandler.CreateStaff)
+		admin.PUT("/staff/update", r.staffHandler.UpdateStaff)
+		admin.DELETE("/staff/delete", r.staffHandler.DeleteStaff)
+		admin.GET("/staff/list", r.staffHandler.GetStaffList)
+
+		// 优惠券和促销
+		admin.POST("/promotion/create", r.promotionHandler.CreatePromotion)
+		admin.PUT("/promotion/update", r.promotionHandler.UpdatePromotion)
+		admin.DELETE("/promotion/delete", r.promotionHandler.DeletePromotion)
+		admin.GET("/promotion/detail", r.promotionHandler.GetPromotion)
+		admin.GET("/promotion/list", r.promotionHandler.GetPromotions)
+
+		// 支付和退款
+		admin.POST("/payment/create", r.paymentHandler.CreatePayment)
+		admin.POST("/payment/confirm", r.paymentHandler.ConfirmPayment)
+		admin.POST("/payment/refund", r.paymentHandler.RefundPayment)
+		admin.GET("/payment/list", r.paymentHandler.GetOrderPayments)
+
+		// 订单售后退款和营业额
+		admin.POST("/order/refund", r.refundHandler.RefundOrder)
+		admin.GET("/order/refund/list", r.refundHandler.GetOrderRefunds)
+		admin.GET("/order/revenue", r.refundHandler.GetRevenueSummary)
+
+		// 堂食桌台
+		admin.POST("/table/create", r.tableHandler.CreateTable)
+		admin.PUT("/table/update", r.tableHandler.UpdateTable)
+		admin.DELETE("/table/delete", r.tableHandler.DeleteTable)
+		admin.POST("/table/regenerate-qr", r.tableHandler.RegenerateQRCode)
+		admin.GET("/table/list", r.tableHandler.GetTables)
+		admin.POST("/table/session/open", r.tableHandler.OpenSession)
+		admin.POST("/table/transfer", r.tableHandler.TransferTable)
+		admin.POST("/table/merge", r.tableHandler.MergeTables)
+		admin.GET("/table/session/bill", r.tableHandler.GetSessionBill)
+		admin.POST("/table/session/close", r.tableHandler.CloseSession)
+		admin.POST("/table/session/settle", r.tableHandler.SettleSession)
+
+		// 操作审计
+		admin.GET("/audit", r.auditHandler.GetAuditLogs)
 	}
 }
 
@@ -232,9 +365,13 @@ func (r *Router) setupAdminRoutes(api *gin.RouterGroup) {
 func (r *Router) setupFrontendRoutes(frontend *gin.RouterGroup) {
 	// 前端路由需要认证和限流
 	frontend.Use(middleware.RateLimitMiddleware())
-	frontend.Use(middleware.FrontendAuthMiddleware())
+	frontend.Use(middleware.FrontendAuthMiddleware(r.tokenBlacklist))
 
 	{
+		// 认证管理
+		frontend.POST("/user/logout", r.authHandler.Logout)
+		frontend.POST("/user/change-password", r.authHandler.ChangePassword)
+
 		// 店铺管理
 		frontend.GET("/shop/detail", r.shopHandler.GetShopInfo)
 		frontend.GET("/shop/list", r.shopHandler.GetShopList)
@@ -247,12 +384,33 @@ func (r *Router) setupFrontendRoutes(frontend *gin.RouterGroup) {
 		frontend.GET("/product/image", r.productHandler.GetProductImage)
 
 		// 订单管理
-		frontend.POST("/order/create", r.orderHandler.CreateOrder)
+		frontend.POST("/order/create", idempotent(r.idempotency, services.ScopeOrderCreate), r.orderHandler.CreateOrder)
+		frontend.GET("/order/schedule-slots", r.orderHandler.GetScheduleSlots)
 		frontend.GET("/order/list", r.orderHandler.GetOrders)
 		frontend.GET("/order/detail", r.orderHandler.GetOrder)
+		frontend.GET("/order/timeline", r.orderHandler.GetOrderTimeline)
 		frontend.DELETE("/order/delete", r.orderHandler.DeleteOrder)
 		frontend.GET("/order/user/list", r.orderHandler.GetOrdersByUser)
 
+		// 购物车
+		frontend.GET("/cart/detail", r.cartHandler.GetCart)
+		frontend.POST("/cart/item/add", r.cartHandler.AddItem)
+		frontend.PUT("/cart/item/update", r.cartHandler.UpdateItem)
+		frontend.DELETE("/cart/item/delete", r.cartHandler.RemoveItem)
+		frontend.DELETE("/cart/clear", r.cartHandler.ClearCart)
+		frontend.POST("/cart/checkout", idempotent(r.idempotency, services.ScopeCartCheckout), r.cartHandler.Checkout)
+
+		// 支付
+		frontend.POST("/payment/create", r.paymentHandler.CreatePayment)
+		frontend.GET("/payment/list", r.paymentHandler.GetOrderPayments)
+		// 模拟支付可由顾客直接把订单标记为已支付，只在开发测试环境启用模拟支付网关时注册
+		if config.MockPaymentEnabled() {
+			frontend.POST("/payment/mock-pay", r.paymentHandler.MockPay)
+		}
+
+		// 扫码点餐的桌台账单
+		frontend.GET("/table/bill", r.tableHandler.GetTableBill)
+
 		// 标签管理
 		frontend.GET("/tag/list", r.shopHandler.GetShopTags)
 		frontend.GET("/tag/detail", r.shopHandler.GetTag)
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"net/http"
+	"orderease/application/services"
+	imiddleware "orderease/interfaces/middleware"
+	"orderease/utils/log2"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	refreshTokens *services.RefreshTokenService
+}
+
+func NewSessionHandler(refreshTokens *services.RefreshTokenService) *SessionHandler {
+	return &SessionHandler{
+		refreshTokens: refreshTokens,
+	}
+}
+
+// GetSessions 获取当前账号的登录会话列表
+func (h *SessionHandler) GetSessions(c *gin.Context) {
+	principalType, principalID, ok := sessionPrincipal(c)
+	if !ok {
+		return
+	}
+
+	currentSessionID := ""
+	if claims := requestClaims(c); claims != nil {
+		currentSessionID = claims.SessionID
+	}
+
+	sessions, err := h.refreshTokens.ListSessions(principalType, principalID, currentSessionID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	successResponse(c, gin.H{
+		"total": len(sessions),
+		"data":  sessions,
+	})
+}
+
+// RevokeSession 注销指定会话
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	principalType, principalID, ok := sessionPrincipal(c)
+	if !ok {
+		return
+	}
+
+	sessionID := c.Param("id")
+	if sessionID == "" {
+		errorResponse(c, http.StatusBadRequest, "缺少会话ID")
+		return
+	}
+
+	if err := h.refreshTokens.RevokePrincipalSession(principalType, principalID, sessionID); err != nil {
+		if err.Error() == "会话不存在" {
+			errorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log2.Infof("注销会话成功: %s %d, session: %s", principalType, principalID, sessionID)
+	successResponse(c, gin.H{"message": "会话已注销"})
+}
+
+// RevokeOtherSessions 注销除当前会话外的所有会话
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	principalType, principalID, ok := sessionPrincipal(c)
+	if !ok {
+		return
+	}
+
+	claims := requestClaims(c)
+	if claims == nil || claims.SessionID == "" {
+		// 旧令牌无法识别当前会话，避免把自己也注销掉
+		errorResponse(c, http.StatusBadRequest, "当前令牌不支持会话管理，请重新登录")
+		return
+	}
+
+	if err := h.refreshTokens.RevokeOtherSessions(principalType, principalID, claims.SessionID); err != nil {
+		errorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log2.Infof("注销其他会话成功: %s %d", principalType, principalID)
+	successResponse(c, gin.H{"message": "其他会话已注销"})
+}
+
+// sessionPrincipal 从认证信息中确定会话所属的主体（管理员、店主或店铺员工）
+func sessionPrincipal(c *gin.Context) (string, uint64, bool) {
+	requestUser, exists := c.Get("userInfo")
+	if !exists {
+		errorResponse(c, http.StatusUnauthorized, "未找到用户信息")
+		return "", 0, false
+	}
+
+	userInfo, ok := requestUser.(imiddleware.UserInfo)
+	if !ok {
+		errorResponse(c, http.StatusInternalServerError, "用户信息格式错误")
+		return "", 0, false
+	}
+
+	principalType, principalID := userInfo.Principal()
+	return principalType, principalID, true
+}
@@ -6,6 +6,7 @@ import (
 	"orderease/application/services"
 	"orderease/domain/order"
 	"orderease/domain/shared"
+	"orderease/models"
 	"orderease/utils/log2"
 	"os"
 	"strconv"
@@ -15,12 +16,14 @@ import (
 )
 
 type ShopHandler struct {
-	shopService *services.ShopService
+	shopService  *services.ShopService
+	auditService *services.AuditService
 }
 
-func NewShopHandler(shopService *services.ShopService) *ShopHandler {
+func NewShopHandler(shopService *services.ShopService, auditService *services.AuditService) *ShopHandler {
 	return &ShopHandler{
-		shopService: shopService,
+		shopService:  shopService,
+		auditService: auditService,
 	}
 }
 
@@ -45,6 +48,14 @@ func (h *ShopHandler) CreateShop(c *gin.Context) {
 
 	log2.Infof("create shop success, ID: %s", response.ID.String())
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     response.ID,
+		EntityType: services.AuditEntityShop,
+		EntityID:   response.ID.String(),
+		Action:     services.AuditActionCreate,
+		After:      response,
+	})
+
 	successResponse(c, gin.H{
 		"code": 200,
 		"data": response,
@@ -100,6 +111,8 @@ func (h *ShopHandler) UpdateShop(c *gin.Context) {
 		return
 	}
 
+	before, _ := h.shopService.GetShop(req.ID)
+
 	response, err := h.shopService.UpdateShop(&req)
 	if err != nil {
 		log2.Errorf("更新店铺失败: %v", err)
@@ -107,6 +120,15 @@ func (h *ShopHandler) UpdateShop(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     req.ID,
+		EntityType: services.AuditEntityShop,
+		EntityID:   req.ID.String(),
+		Action:     services.AuditActionUpdate,
+		Before:     before,
+		After:      response,
+	})
+
 	successResponse(c, gin.H{
 		"code": 200,
 		"data": response,
@@ -121,12 +143,22 @@ func (h *ShopHandler) DeleteShop(c *gin.Context) {
 		return
 	}
 
+	before, _ := h.shopService.GetShop(shopID)
+
 	if err := h.shopService.DeleteShop(shopID); err != nil {
 		log2.Errorf("删除店铺失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityShop,
+		EntityID:   shopID.String(),
+		Action:     services.AuditActionDelete,
+		Before:     before,
+	})
+
 	successResponse(c, gin.H{"message": "店铺删除成功"})
 }
 
@@ -160,18 +192,72 @@ func (h *ShopHandler) UpdateOrderStatusFlow(c *gin.Context) {
 		return
 	}
 
+	var before interface{}
+	if shop, err := h.shopService.GetShop(req.ShopID); err == nil {
+		before = shop.OrderStatusFlow
+	}
+
 	if err := h.shopService.UpdateOrderStatusFlow(req.ShopID, req.OrderStatusFlow); err != nil {
 		log2.Errorf("更新店铺订单流转状态配置失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     req.ShopID,
+		EntityType: services.AuditEntityOrderStatusFlow,
+		EntityID:   req.ShopID.String(),
+		Action:     services.AuditActionUpdate,
+		Before:     before,
+		After:      req.OrderStatusFlow,
+	})
+
 	successResponse(c, gin.H{
 		"code":    200,
 		"message": "店铺订单流转状态配置更新成功",
 	})
 }
 
+// UpdateOrderingPause 手动暂停或恢复接单，暂停期间不受营业时间影响一律拒绝下单
+func (h *ShopHandler) UpdateOrderingPause(c *gin.Context) {
+	var req struct {
+		ShopID shared.ID `json:"shop_id"`
+		Paused bool      `json:"paused"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的请求数据")
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil || shopID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+	req.ShopID = shopID
+
+	before, _ := h.shopService.GetShop(req.ShopID)
+
+	response, err := h.shopService.SetOrderingPaused(req.ShopID, req.Paused)
+	if err != nil {
+		log2.Errorf("更新店铺接单状态失败: %v", err)
+		errorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     req.ShopID,
+		EntityType: services.AuditEntityShop,
+		EntityID:   req.ShopID.String(),
+		Action:     services.AuditActionUpdate,
+		Before:     before,
+		After:      response,
+	})
+
+	successResponse(c, response)
+}
+
 func (h *ShopHandler) GetShopTags(c *gin.Context) {
 	shopIDStr := c.Param("shop_id")
 	if shopIDStr == "" {
@@ -200,6 +286,13 @@ func (h *ShopHandler) CreateTag(c *gin.Context) {
 		return
 	}
 
+	validShopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = validShopID
+
 	response, err := h.shopService.CreateTag(&req)
 	if err != nil {
 		log2.Errorf("创建标签失败: %v", err)
@@ -207,6 +300,14 @@ func (h *ShopHandler) CreateTag(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityTag,
+		EntityID:   strconv.Itoa(response.ID),
+		Action:     services.AuditActionCreate,
+		After:      response,
+	})
+
 	successResponse(c, response)
 }
 
@@ -228,6 +329,15 @@ func (h *ShopHandler) UpdateTag(c *gin.Context) {
 		return
 	}
 
+	validShopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = validShopID
+
+	before, _ := h.shopService.GetTag(id, validShopID)
+
 	response, err := h.shopService.UpdateTag(id, &req)
 	if err != nil {
 		log2.Errorf("更新标签失败: %v", err)
@@ -235,6 +345,15 @@ func (h *ShopHandler) UpdateTag(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityTag,
+		EntityID:   idStr,
+		Action:     services.AuditActionUpdate,
+		Before:     before,
+		After:      response,
+	})
+
 	successResponse(c, response)
 }
 
@@ -250,12 +369,27 @@ func (h *ShopHandler) DeleteTag(c *gin.Context) {
 		return
 	}
 
-	if err := h.shopService.DeleteTag(id); err != nil {
+	validShopID, ok := h.tagShopID(c)
+	if !ok {
+		return
+	}
+
+	before, _ := h.shopService.GetTag(id, validShopID)
+
+	if err := h.shopService.DeleteTag(id, validShopID); err != nil {
 		log2.Errorf("删除标签失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityTag,
+		EntityID:   idStr,
+		Action:     services.AuditActionDelete,
+		Before:     before,
+	})
+
 	successResponse(c, gin.H{"message": "标签删除成功"})
 }
 
@@ -271,7 +405,12 @@ func (h *ShopHandler) GetTag(c *gin.Context) {
 		return
 	}
 
-	response, err := h.shopService.GetTag(id)
+	validShopID, ok := h.tagShopID(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.shopService.GetTag(id, validShopID)
 	if err != nil {
 		log2.Errorf("查询标签失败: %v", err)
 		errorResponse(c, http.StatusNotFound, err.Error())
@@ -367,6 +506,8 @@ func (h *ShopHandler) UploadShopImage(c *gin.Context) {
 	}
 	defer file.Close()
 
+	before, _ := h.shopService.GetShop(shopID)
+
 	// 调用 service 层上传图片
 	filename, err := h.shopService.UploadShopImage(shopID, file, fileHeader.Filename)
 	if err != nil {
@@ -375,6 +516,16 @@ func (h *ShopHandler) UploadShopImage(c *gin.Context) {
 		return
 	}
 
+	after, _ := h.shopService.GetShop(shopID)
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityShop,
+		EntityID:   shopID.String(),
+		Action:     services.AuditActionUploadImage,
+		Before:     before,
+		After:      after,
+	})
+
 	successResponse(c, gin.H{
 		"message": "图片上传成功",
 		"url":     filename,
@@ -520,6 +671,14 @@ func (h *ShopHandler) BatchTagProducts(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityTag,
+		EntityID:   strconv.Itoa(req.TagID),
+		Action:     services.AuditActionBindTag,
+		After:      gin.H{"product_ids": req.ProductIDs},
+	})
+
 	successResponse(c, gin.H{
 		"message": "批量打标签成功",
 	})
@@ -566,6 +725,14 @@ func (h *ShopHandler) BatchUntagProducts(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityTag,
+		EntityID:   strconv.Itoa(req.TagID),
+		Action:     services.AuditActionUnbindTag,
+		Before:     gin.H{"product_ids": req.ProductIDs},
+	})
+
 	successResponse(c, gin.H{
 		"message": "批量解绑标签成功",
 	})
@@ -606,12 +773,24 @@ func (h *ShopHandler) BatchTagProduct(c *gin.Context) {
 		return
 	}
 
+	before, _ := h.shopService.GetBoundTags(req.ProductID, validShopID.ToUint64())
+
 	if err := h.shopService.BatchTagProduct(req.ProductID, req.TagIDs, validShopID.ToUint64()); err != nil {
 		log2.Errorf("批量设置商品标签失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, "批量设置商品标签失败")
 		return
 	}
 
+	after, _ := h.shopService.GetBoundTags(req.ProductID, validShopID.ToUint64())
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityProduct,
+		EntityID:   req.ProductID,
+		Action:     services.AuditActionBindTag,
+		Before:     before,
+		After:      after,
+	})
+
 	successResponse(c, gin.H{
 		"message": "批量设置商品标签成功",
 	})
@@ -814,12 +993,34 @@ func (h *ShopHandler) GetTagOnlineProducts(c *gin.Context) {
 	})
 }
 
+// tagShopID 从查询参数中解析标签所属店铺，店主只能访问自己店铺的标签
+func (h *ShopHandler) tagShopID(c *gin.Context) (shared.ID, bool) {
+	shopID, _ := shared.ParseIDFromString(c.Query("shop_id"))
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return shared.ID(0), false
+	}
+	if validShopID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return shared.ID(0), false
+	}
+
+	return validShopID, true
+}
+
 func (h *ShopHandler) validateShopID(c *gin.Context, shopID shared.ID) (shared.ID, error) {
 	requestUser, exists := c.Get("userInfo")
 	if !exists {
 		return shopID, nil
 	}
 
+	// 前端顾客只会访问店铺的公开信息（标签、商品），按请求中的店铺查询
+	if _, isCustomer := requestUser.(models.UserInfo); isCustomer {
+		return shopID, nil
+	}
+
 	userInfo := requestUser.(interface {
 		IsAdminUser() bool
 		GetUserID() uint64
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"net/http"
+	"orderease/application/dto"
+	"orderease/application/services"
+	"orderease/domain/shared"
+	"orderease/utils/log2"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StaffHandler struct {
+	staffService *services.StaffService
+	shopService  *services.ShopService
+	auditService *services.AuditService
+}
+
+func NewStaffHandler(staffService *services.StaffService, shopService *services.ShopService, auditService *services.AuditService) *StaffHandler {
+	return &StaffHandler{
+		staffService: staffService,
+		shopService:  shopService,
+		auditService: auditService,
+	}
+}
+
+// CreateStaff 创建店铺员工子账号
+func (h *StaffHandler) CreateStaff(c *gin.Context) {
+	var req dto.CreateStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的员工数据: "+err.Error())
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = shopID
+
+	staff, err := h.staffService.CreateStaff(&req)
+	if err != nil {
+		log2.Errorf("创建员工失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityStaff,
+		EntityID:   staff.ID.String(),
+		Action:     services.AuditActionCreate,
+		After:      staff,
+	})
+
+	successResponse(c, staff)
+}
+
+// UpdateStaff 更新员工信息（姓名、角色、启用状态、重置密码）
+func (h *StaffHandler) UpdateStaff(c *gin.Context) {
+	var req dto.UpdateStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的员工数据: "+err.Error())
+		return
+	}
+
+	if req.ID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "缺少员工ID")
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = shopID
+
+	before, _ := h.staffService.GetStaff(req.ID, shopID)
+
+	staff, err := h.staffService.UpdateStaff(&req)
+	if err != nil {
+		log2.Errorf("更新员工失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityStaff,
+		EntityID:   req.ID.String(),
+		Action:     services.AuditActionUpdate,
+		Before:     before,
+		After:      staff,
+	})
+
+	successResponse(c, staff)
+}
+
+// DeleteStaff 删除员工并注销其全部会话
+func (h *StaffHandler) DeleteStaff(c *gin.Context) {
+	id, err := shared.ParseIDFromString(c.Query("id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "缺少员工ID")
+		return
+	}
+
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	before, _ := h.staffService.GetStaff(id, validShopID)
+
+	if err := h.staffService.DeleteStaff(id, validShopID); err != nil {
+		log2.Errorf("删除员工失败: %v", err)
+		errorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityStaff,
+		EntityID:   id.String(),
+		Action:     services.AuditActionDelete,
+		Before:     before,
+	})
+
+	successResponse(c, gin.H{"message": "员工删除成功"})
+}
+
+// GetStaffList 获取店铺员工列表
+func (h *StaffHandler) GetStaffList(c *gin.Context) {
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	staffs, err := h.staffService.GetStaffList(validShopID)
+	if err != nil {
+		log2.Errorf("获取员工列表失败: %v", err)
+		errorResponse(c, http.StatusInternalServerError, "获取员工列表失败")
+		return
+	}
+
+	successResponse(c, gin.H{
+		"total": len(staffs),
+		"data":  staffs,
+	})
+}
+
+func (h *StaffHandler) validateShopID(c *gin.Context, shopID shared.ID) (shared.ID, error) {
+	requestUser, exists := c.Get("userInfo")
+	if !exists {
+		return shared.ID(0), nil
+	}
+
+	userInfo := requestUser.(interface {
+		IsAdminUser() bool
+		GetUserID() uint64
+	})
+
+	if !userInfo.IsAdminUser() {
+		return shared.ParseIDFromUint64(userInfo.GetUserID()), nil
+	}
+
+	shop, err := h.shopService.GetShop(shopID)
+	if err != nil {
+		return shared.ID(0), err
+	}
+
+	return shop.ID, nil
+}
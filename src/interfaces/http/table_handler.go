@@ -0,0 +1,461 @@
+package http
+
+import (
+	"net/http"
+	"orderease/application/dto"
+	"orderease/application/services"
+	"orderease/domain/shared"
+	"orderease/models"
+	"orderease/utils/log2"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TableHandler struct {
+	tableService *services.TableService
+	shopService  *services.ShopService
+	auditService *services.AuditService
+}
+
+func NewTableHandler(tableService *services.TableService, shopService *services.ShopService, auditService *services.AuditService) *TableHandler {
+	return &TableHandler{
+		tableService: tableService,
+		shopService:  shopService,
+		auditService: auditService,
+	}
+}
+
+// tableTarget 只包含桌台或用餐ID的请求
+type tableTarget struct {
+	ID        shared.ID `json:"id"`
+	SessionID shared.ID `json:"session_id"`
+	ShopID    shared.ID `json:"shop_id"`
+}
+
+// CreateTable 创建桌台
+func (h *TableHandler) CreateTable(c *gin.Context) {
+	var req dto.CreateTableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的桌台数据: "+err.Error())
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = shopID
+
+	t, err := h.tableService.CreateTable(&req)
+	if err != nil {
+		log2.Errorf("创建桌台失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityTable,
+		EntityID:   t.ID.String(),
+		Action:     services.AuditActionCreate,
+		After:      t,
+	})
+
+	successResponse(c, t)
+}
+
+// UpdateTable 修改桌号、座位数或启用状态
+func (h *TableHandler) UpdateTable(c *gin.Context) {
+	var req dto.UpdateTableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的桌台数据: "+err.Error())
+		return
+	}
+
+	if req.ID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "缺少桌台ID")
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = shopID
+
+	t, err := h.tableService.UpdateTable(&req)
+	if err != nil {
+		log2.Errorf("更新桌台失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityTable,
+		EntityID:   req.ID.String(),
+		Action:     services.AuditActionUpdate,
+		After:      t,
+	})
+
+	successResponse(c, t)
+}
+
+// DeleteTable 删除桌台
+func (h *TableHandler) DeleteTable(c *gin.Context) {
+	id, err := shared.ParseIDFromString(c.Query("id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "缺少桌台ID")
+		return
+	}
+
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.tableService.DeleteTable(id, validShopID); err != nil {
+		log2.Errorf("删除桌台失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     validShopID,
+		EntityType: services.AuditEntityTable,
+		EntityID:   id.String(),
+		Action:     services.AuditActionDelete,
+	})
+
+	successResponse(c, gin.H{"message": "桌台删除成功"})
+}
+
+// GetTables 获取店铺桌台列表，包含二维码内容和进行中的用餐
+func (h *TableHandler) GetTables(c *gin.Context) {
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tables, err := h.tableService.GetTables(validShopID)
+	if err != nil {
+		log2.Errorf("获取桌台列表失败: %v", err)
+		errorResponse(c, http.StatusInternalServerError, "获取桌台列表失败")
+		return
+	}
+
+	successResponse(c, gin.H{
+		"total": len(tables),
+		"data":  tables,
+	})
+}
+
+// RegenerateQRCode 重新生成桌台二维码，旧二维码失效
+func (h *TableHandler) RegenerateQRCode(c *gin.Context) {
+	var req tableTarget
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "缺少桌台ID")
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	t, err := h.tableService.RegenerateQRCode(req.ID, shopID)
+	if err != nil {
+		log2.Errorf("重新生成桌台二维码失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityTable,
+		EntityID:   req.ID.String(),
+		Action:     services.AuditActionRegenerateQR,
+		After:      gin.H{"qr_code": t.QRCode},
+	})
+
+	successResponse(c, t)
+}
+
+// OpenSession 开台
+func (h *TableHandler) OpenSession(c *gin.Context) {
+	var req dto.OpenTableSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的开台数据: "+err.Error())
+		return
+	}
+
+	if req.TableID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "缺少桌台ID")
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = shopID
+
+	session, err := h.tableService.OpenSession(&req)
+	if err != nil {
+		log2.Errorf("开台失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(c, session)
+}
+
+// TransferTable 换台
+func (h *TableHandler) TransferTable(c *gin.Context) {
+	h.moveTable(c, services.AuditActionTransferTable, h.tableService.TransferTable)
+}
+
+// MergeTables 并台
+func (h *TableHandler) MergeTables(c *gin.Context) {
+	h.moveTable(c, services.AuditActionMergeTable, h.tableService.MergeTables)
+}
+
+func (h *TableHandler) moveTable(c *gin.Context, action string, move func(*dto.MoveTableRequest) (*dto.TableSessionResponse, error)) {
+	var req dto.MoveTableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的桌台数据: "+err.Error())
+		return
+	}
+
+	if req.TableID.IsZero() || req.TargetTableID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "缺少桌台ID或目标桌台ID")
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = shopID
+
+	session, err := move(&req)
+	if err != nil {
+		log2.Errorf("换台或并台失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityTable,
+		EntityID:   req.TableID.String(),
+		Action:     action,
+		After:      gin.H{"target_table_id": req.TargetTableID, "session": session},
+	})
+
+	successResponse(c, session)
+}
+
+// GetSessionBill 查询一次用餐的账单
+func (h *TableHandler) GetSessionBill(c *gin.Context) {
+	sessionID, err := shared.ParseIDFromString(c.Query("session_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "缺少用餐ID")
+		return
+	}
+
+	shopID, err := shared.ParseIDFromString(c.Query("shop_id"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的店铺ID")
+		return
+	}
+
+	validShopID, err := h.validateShopID(c, shopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	shop, err := h.shopService.GetShop(validShopID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "获取店铺信息失败")
+		return
+	}
+
+	bill, err := h.tableService.GetSessionBill(sessionID, validShopID, shop.OrderStatusFlow)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(c, bill)
+}
+
+// CloseSession 手动结束订单都已结清的用餐
+func (h *TableHandler) CloseSession(c *gin.Context) {
+	var req tableTarget
+	if err := c.ShouldBindJSON(&req); err != nil || req.SessionID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "缺少用餐ID")
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	shop, err := h.shopService.GetShop(shopID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "获取店铺信息失败")
+		return
+	}
+
+	session, err := h.tableService.CloseSession(req.SessionID, shopID, shop.OrderStatusFlow)
+	if err != nil {
+		log2.Errorf("结束用餐失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityTable,
+		EntityID:   session.TableID.String(),
+		Action:     services.AuditActionCloseSession,
+		After:      session,
+	})
+
+	successResponse(c, session)
+}
+
+// SettleSession 整桌结账，确认全部未付清订单的线下收款
+func (h *TableHandler) SettleSession(c *gin.Context) {
+	var req dto.SettleTableSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "无效的结账数据: "+err.Error())
+		return
+	}
+
+	if req.SessionID.IsZero() {
+		errorResponse(c, http.StatusBadRequest, "缺少用餐ID")
+		return
+	}
+
+	shopID, err := h.validateShopID(c, req.ShopID)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ShopID = shopID
+
+	shop, err := h.shopService.GetShop(shopID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "获取店铺信息失败")
+		return
+	}
+
+	bill, err := h.tableService.SettleSession(&req, shop.OrderStatusFlow, auditActor(c))
+	if err != nil {
+		log2.Errorf("整桌结账失败: %v", err)
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     shopID,
+		EntityType: services.AuditEntityTable,
+		EntityID:   bill.Session.TableID.String(),
+		Action:     services.AuditActionConfirmPay,
+		After:      bill,
+	})
+
+	successResponse(c, bill)
+}
+
+// GetTableBill 顾客扫码后查看所在桌台当前用餐的账单，扫码点餐的顾客只能查看自己所属的用餐
+func (h *TableHandler) GetTableBill(c *gin.Context) {
+	code := c.Query("table_code")
+	if code == "" {
+		errorResponse(c, http.StatusBadRequest, "缺少桌台二维码")
+		return
+	}
+
+	t, err := h.tableService.ResolveTableCode(code)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	shop, err := h.shopService.GetShop(shared.ParseIDFromUint64(t.ShopID))
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "获取店铺信息失败")
+		return
+	}
+
+	customerID, _ := h.customerID(c)
+	bill, err := h.tableService.GetTableBill(t, customerID, shop.OrderStatusFlow)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	successResponse(c, bill)
+}
+
+// customerID 返回前端顾客的用户ID，店主、员工和管理员返回 false
+func (h *TableHandler) customerID(c *gin.Context) (shared.ID, bool) {
+	requestUser, exists := c.Get("userInfo")
+	if !exists {
+		return shared.ID(0), false
+	}
+
+	userInfo, ok := requestUser.(models.UserInfo)
+	if !ok {
+		return shared.ID(0), false
+	}
+
+	return shared.ParseIDFromUint64(userInfo.UserID), true
+}
+
+func (h *TableHandler) validateShopID(c *gin.Context, shopID shared.ID) (shared.ID, error) {
+	requestUser, exists := c.Get("userInfo")
+	if !exists {
+		return shared.ID(0), nil
+	}
+
+	userInfo := requestUser.(interface {
+		IsAdminUser() bool
+		GetUserID() uint64
+	})
+
+	if !userInfo.IsAdminUser() {
+		return shared.ParseIDFromUint64(userInfo.GetUserID()), nil
+	}
+
+	shop, err := h.shopService.GetShop(shopID)
+	if err != nil {
+		return shared.ID(0), err
+	}
+
+	return shop.ID, nil
+}
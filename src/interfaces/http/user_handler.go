@@ -12,12 +12,14 @@ import (
 )
 
 type UserHandler struct {
-	userService *services.UserService
+	userService  *services.UserService
+	auditService *services.AuditService
 }
 
-func NewUserHandler(userService *services.UserService) *UserHandler {
+func NewUserHandler(userService *services.UserService, auditService *services.AuditService) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:  userService,
+		auditService: auditService,
 	}
 }
 
@@ -35,6 +37,14 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     callerShopID(c),
+		EntityType: services.AuditEntityUser,
+		EntityID:   response.ID.String(),
+		Action:     services.AuditActionCreate,
+		After:      response,
+	})
+
 	successResponse(c, response)
 }
 
@@ -101,6 +111,8 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	}
 
 	// req.ID = id
+	before, _ := h.userService.GetUser(req.ID)
+
 	response, err := h.userService.UpdateUser(&req)
 	if err != nil {
 		log2.Errorf("更新用户失败: %v", err)
@@ -108,6 +120,15 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     callerShopID(c),
+		EntityType: services.AuditEntityUser,
+		EntityID:   req.ID.String(),
+		Action:     services.AuditActionUpdate,
+		Before:     before,
+		After:      response,
+	})
+
 	successResponse(c, response)
 }
 
@@ -124,12 +145,22 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	before, _ := h.userService.GetUser(id)
+
 	if err := h.userService.DeleteUser(id); err != nil {
 		log2.Errorf("删除用户失败: %v", err)
 		errorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	recordAudit(c, h.auditService, services.AuditEntry{
+		ShopID:     callerShopID(c),
+		EntityType: services.AuditEntityUser,
+		EntityID:   id.String(),
+		Action:     services.AuditActionDelete,
+		Before:     before,
+	})
+
 	successResponse(c, gin.H{"message": "用户删除成功"})
 }
 
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"orderease/application/services"
 	"orderease/utils"
 	"orderease/utils/log2"
 	"strings"
@@ -13,6 +14,10 @@ type UserInfo struct {
 	UserID   uint64
 	IsAdmin  bool
 	UserName string
+	// 店铺员工子账号登录时 UserID 为店铺ID，StaffID 为员工ID
+	StaffID     uint64
+	Role        string
+	Permissions []string
 }
 
 func (u UserInfo) IsAdminUser() bool {
@@ -23,7 +28,36 @@ func (u UserInfo) GetUserID() uint64 {
 	return u.UserID
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+// Principal 返回令牌所属的登录主体，用于会话管理和令牌吊销
+func (u UserInfo) Principal() (string, uint64) {
+	switch {
+	case u.IsAdmin:
+		return services.PrincipalAdmin, u.UserID
+	case u.StaffID != 0:
+		return services.PrincipalStaff, u.StaffID
+	default:
+		return services.PrincipalShop, u.UserID
+	}
+}
+
+// HasPermission 检查是否拥有店铺后台权限
+// 管理员拥有全部权限；店主和员工令牌都带有角色，没有角色的令牌（前端用户、扫码点餐用户）没有任何后台权限
+func (u UserInfo) HasPermission(permission string) bool {
+	if u.IsAdmin {
+		return true
+	}
+	if u.Role == "" {
+		return false
+	}
+	for _, p := range u.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func AuthMiddleware(tokenBlacklist *services.TokenBlacklistService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -48,9 +82,19 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		userInfo := UserInfo{
-			UserID:   claims.UserID,
-			IsAdmin:  claims.IsAdmin,
-			UserName: claims.Username,
+			UserID:      claims.UserID,
+			IsAdmin:     claims.IsAdmin,
+			UserName:    claims.Username,
+			StaffID:     claims.StaffID,
+			Role:        claims.Role,
+			Permissions: claims.Permissions,
+		}
+
+		principalType, principalID := userInfo.Principal()
+		if tokenBlacklist.IsRevoked(principalType, principalID, claims, tokenString) {
+			errorResponse(c, http.StatusUnauthorized, "认证令牌已失效")
+			c.Abort()
+			return
 		}
 
 		c.Set("userInfo", userInfo)
@@ -84,6 +128,34 @@ func AdminMiddleware() gin.HandlerFunc {
 	}
 }
 
+// RequirePermission 店铺后台路由权限校验，权限来自令牌中的角色声明
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userInfo, exists := c.Get("userInfo")
+		if !exists {
+			errorResponse(c, http.StatusUnauthorized, "未找到用户信息")
+			c.Abort()
+			return
+		}
+
+		user, ok := userInfo.(UserInfo)
+		if !ok {
+			errorResponse(c, http.StatusInternalServerError, "用户信息格式错误")
+			c.Abort()
+			return
+		}
+
+		if !user.HasPermission(permission) {
+			log2.Warnf("权限不足: 店铺 %d, 员工 %d, 角色 %s, 需要 %s", user.UserID, user.StaffID, user.Role, permission)
+			errorResponse(c, http.StatusForbidden, "没有访问该功能的权限")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 func errorResponse(c *gin.Context, code int, message string) {
 	log2.Errorf("错误响应: %d - %s", code, message)
 	c.JSON(code, gin.H{"error": message})
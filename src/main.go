@@ -18,6 +18,8 @@ import (
 	"time"
 
 	_ "orderease/docs"
+	// 店铺按各自时区计算营业时间，运行镜像中没有时区数据库，内嵌到程序中
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
@@ -107,7 +109,8 @@ func main() {
 	r.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Idempotency-Replayed")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(stdhttp.StatusOK)
@@ -181,6 +184,10 @@ func main() {
 	cleanupTask := tasks.NewCleanupTask(db)
 	cleanupTask.StartCleanupTask()
 
+	// 初始化过期令牌黑名单清理任务
+	tokenCleanupTask := tasks.NewTokenCleanupTask(db)
+	tokenCleanupTask.StartTokenCleanup()
+
 	// @Deprecated: 旧的临时令牌服务已禁用，使用新的 application/services/temp_token_service.go
 	// 旧的定时刷新任务已注释，等新服务测试通过后可以删除旧代码
 	// tempTokenService := oldservices.NewTempTokenService()
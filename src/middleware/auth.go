@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"orderease/application/services"
 	"orderease/utils"
 	"orderease/utils/log2"
 	"strings"
@@ -59,7 +60,7 @@ func BackendAuthMiddleware(isAdmin bool) gin.HandlerFunc {
 }
 
 // FrontendAuthMiddleware 前端用户认证中间件
-func FrontendAuthMiddleware() gin.HandlerFunc {
+func FrontendAuthMiddleware(tokenBlacklist *services.TokenBlacklistService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		db := database.GetDB()
 		token := c.GetHeader("Authorization")
@@ -71,14 +72,6 @@ func FrontendAuthMiddleware() gin.HandlerFunc {
 		// 去掉Bearer前缀
 		token = strings.TrimPrefix(token, "Bearer ")
 
-		// 检查token是否在黑名单中
-		var blacklistedToken models.BlacklistedToken
-		if err := db.Where("token = ?", token).First(&blacklistedToken).Error; err == nil {
-			// token在黑名单中
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token已失效"})
-			return
-		}
-
 		// 验证token
 		claims, err := utils.ParseToken(token)
 		if err != nil {
@@ -87,6 +80,12 @@ func FrontendAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// 检查token是否在黑名单中
+		if tokenBlacklist.IsRevoked(services.PrincipalUser, claims.UserID, claims, token) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token已失效"})
+			return
+		}
+
 		// 验证用户是否存在
 		var user models.User
 		if err := db.Where("id = ?", claims.UserID).First(&user).Error; err != nil {
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// AuditLog 后台操作审计日志，记录谁在什么时候对哪条数据做了什么修改
+type AuditLog struct {
+	ID         snowflake.ID `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	ShopID     snowflake.ID `gorm:"column:shop_id;index:idx_audit_shop_time;type:bigint unsigned" json:"shop_id"`        // 管理员操作全局数据时为0
+	ActorType  string       `gorm:"column:actor_type;type:varchar(20);not null;index:idx_audit_actor" json:"actor_type"` // admin/shop/staff/user
+	ActorID    uint64       `gorm:"column:actor_id;not null;index:idx_audit_actor" json:"actor_id"`
+	ActorName  string       `gorm:"column:actor_name;type:varchar(100)" json:"actor_name"`
+	EntityType string       `gorm:"column:entity_type;type:varchar(32);not null;index:idx_audit_entity" json:"entity_type"`
+	EntityID   string       `gorm:"column:entity_id;type:varchar(64);index:idx_audit_entity" json:"entity_id"`
+	Action     string       `gorm:"column:action;type:varchar(32);not null" json:"action"`
+	Before     string       `gorm:"column:before_data;type:text" json:"before"`
+	After      string       `gorm:"column:after_data;type:text" json:"after"`
+	Diff       string       `gorm:"column:diff;type:text" json:"diff"`
+	IP         string       `gorm:"column:ip;type:varchar(64)" json:"ip"`
+	UserAgent  string       `gorm:"column:user_agent;type:varchar(255)" json:"user_agent"`
+	CreatedAt  time.Time    `gorm:"column:created_at;index:idx_audit_shop_time" json:"created_at"`
+}
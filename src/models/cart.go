@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// Cart 顾客在店铺的购物车，每个顾客每个店铺一个
+type Cart struct {
+	ID        snowflake.ID `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	UserID    snowflake.ID `gorm:"column:user_id;not null;uniqueIndex:idx_cart_user_shop;type:bigint unsigned" json:"user_id"`
+	ShopID    snowflake.ID `gorm:"column:shop_id;not null;uniqueIndex:idx_cart_user_shop;index;type:bigint unsigned" json:"shop_id"`
+	CreatedAt time.Time    `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time    `gorm:"column:updated_at" json:"updated_at"`
+	Items     []CartItem   `gorm:"foreignKey:CartID;constraint:OnDelete:CASCADE" json:"items"`
+}
+
+// CartItem 购物车项，只保存商品和数量，价格在查看和结算时按商品当前价格计算
+type CartItem struct {
+	ID        snowflake.ID     `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	CartID    snowflake.ID     `gorm:"column:cart_id;index;not null;type:bigint unsigned" json:"cart_id"`
+	ProductID snowflake.ID     `gorm:"column:product_id;not null;type:bigint unsigned" json:"product_id"`
+	Quantity  int              `gorm:"column:quantity;not null" json:"quantity"`
+	CreatedAt time.Time        `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time        `gorm:"column:updated_at" json:"updated_at"`
+	Options   []CartItemOption `gorm:"foreignKey:CartItemID;constraint:OnDelete:CASCADE" json:"options"`
+}
+
+// CartItemOption 购物车项选择的商品参数选项
+type CartItemOption struct {
+	ID         snowflake.ID `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	CartItemID snowflake.ID `gorm:"column:cart_item_id;index;not null;type:bigint unsigned" json:"cart_item_id"`
+	CategoryID snowflake.ID `gorm:"column:category_id;not null;type:bigint unsigned" json:"category_id"`
+	OptionID   snowflake.ID `gorm:"column:option_id;not null;type:bigint unsigned" json:"option_id"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// IdempotencyKey 写接口的幂等键，保存首次请求的哈希和响应，用于重试时回放
+// 同一登录主体在同一接口下的幂等键唯一，过期后可以重新使用
+type IdempotencyKey struct {
+	// 幂等键只在服务端使用，可以不使用雪花ID
+	ID            uint      `gorm:"column:id;primarykey" json:"id"`
+	PrincipalType string    `gorm:"column:principal_type;type:varchar(20);not null;uniqueIndex:idx_idempotency_key_scope" json:"principal_type"` // admin/shop/staff/user
+	PrincipalID   uint64    `gorm:"column:principal_id;not null;uniqueIndex:idx_idempotency_key_scope" json:"principal_id"`
+	Scope         string    `gorm:"column:scope;type:varchar(50);not null;uniqueIndex:idx_idempotency_key_scope" json:"scope"` // 接口标识，如 order_create
+	Key           string    `gorm:"column:idempotency_key;type:varchar(100);not null;uniqueIndex:idx_idempotency_key_scope" json:"idempotency_key"`
+	RequestHash   string    `gorm:"column:request_hash;type:varchar(64);not null" json:"-"`
+	Status        string    `gorm:"column:status;type:varchar(20);not null" json:"status"` // processing/completed
+	StatusCode    int       `gorm:"column:status_code" json:"status_code"`
+	ResponseBody  string    `gorm:"column:response_body;type:text" json:"-"`
+	ExpiresAt     time.Time `gorm:"column:expires_at;not null;index" json:"expires_at"`
+	CreatedAt     time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
@@ -7,16 +7,38 @@ import (
 )
 
 type Order struct {
-	ID         snowflake.ID `gorm:"primarykey;autoIncrement:false;column:id;type:bigint unsigned" json:"id,omitempty"`
-	UserID     snowflake.ID `gorm:"column:user_id;index;type:bigint unsigned" json:"user_id"`
-	ShopID     snowflake.ID `gorm:"column:shop_id;index;type:bigint unsigned" json:"shop_id"`
-	TotalPrice Price        `gorm:"column:total_price;type:double" json:"total_price"`
-	Status     int          `gorm:"column:status" json:"status"`
-	Remark     string       `gorm:"column:remark" json:"remark"`
-	CreatedAt  time.Time    `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt  time.Time    `gorm:"column:updated_at" json:"updated_at"`
-	Items      []OrderItem  `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"items"`
-	User       User         `gorm:"foreignKey:UserID" json:"user"`
+	ID              snowflake.ID    `gorm:"primarykey;autoIncrement:false;column:id;type:bigint unsigned" json:"id,omitempty"`
+	UserID          snowflake.ID    `gorm:"column:user_id;index;type:bigint unsigned" json:"user_id"`
+	ShopID          snowflake.ID    `gorm:"column:shop_id;index;type:bigint unsigned" json:"shop_id"`
+	Subtotal        Price           `gorm:"column:subtotal;type:decimal(10,2);not null;default:0" json:"subtotal"`               // 商品小计
+	DiscountAmount  Price           `gorm:"column:discount_amount;type:decimal(10,2);not null;default:0" json:"discount_amount"` // 优惠减免合计
+	ServiceCharge   Price           `gorm:"column:service_charge;type:decimal(10,2);not null;default:0" json:"service_charge"`   // 服务费
+	PackagingFee    Price           `gorm:"column:packaging_fee;type:decimal(10,2);not null;default:0" json:"packaging_fee"`     // 打包费
+	DeliveryFee     Price           `gorm:"column:delivery_fee;type:decimal(10,2);not null;default:0" json:"delivery_fee"`       // 配送费
+	TaxAmount       Price           `gorm:"column:tax_amount;type:decimal(10,2);not null;default:0" json:"tax_amount"`           // 税额
+	TaxRate         Rate            `gorm:"column:tax_rate;type:decimal(5,2);not null;default:0" json:"tax_rate"`                // 下单时的税率（%）
+	TaxInclusive    bool            `gorm:"column:tax_inclusive;not null;default:false" json:"tax_inclusive"`                    // 价内税，税额已包含在商品价格中
+	Takeaway        bool            `gorm:"column:takeaway;not null;default:false" json:"takeaway"`                              // 外带
+	FulfillmentType string          `gorm:"column:fulfillment_type;size:20;index;not null;default:''" json:"fulfillment_type"`   // 取餐方式 dine_in/pickup/delivery
+	RecipientName   string          `gorm:"column:recipient_name;size:100" json:"recipient_name"`                                // 下单时的收货人快照
+	RecipientPhone  string          `gorm:"column:recipient_phone;size:32" json:"recipient_phone"`                               // 下单时的联系电话快照
+	DeliveryAddress string          `gorm:"column:delivery_address;size:255" json:"delivery_address"`                            // 下单时的配送地址快照，仅配送订单
+	ScheduledTime   *time.Time      `gorm:"column:scheduled_time;index" json:"scheduled_time"`                                   // 预约取餐/送达时间，为空表示尽快制作
+	PickupNumber    string          `gorm:"column:pickup_number;size:16;index" json:"pickup_number"`                             // 取餐号，每个营业日重新编号
+	BusinessDate    string          `gorm:"column:business_date;size:10;index" json:"business_date"`                             // 取餐号所属的营业日
+	TableID         snowflake.ID    `gorm:"column:table_id;index;type:bigint unsigned" json:"table_id"`                          // 堂食桌台，非堂食订单为 0
+	TableSessionID  snowflake.ID    `gorm:"column:table_session_id;index;type:bigint unsigned" json:"table_session_id"`          // 所属的桌台用餐
+	TotalPrice      Price           `gorm:"column:total_price;type:decimal(10,2)" json:"total_price"`                            // 应付金额
+	PaymentStatus   string          `gorm:"column:payment_status;size:20;not null;default:'unpaid'" json:"payment_status"`       // unpaid/paid/partially_refunded/refunded
+	PaidAmount      Price           `gorm:"column:paid_amount;type:decimal(10,2);not null;default:0" json:"paid_amount"`         // 已支付金额
+	RefundedAmount  Price           `gorm:"column:refunded_amount;type:decimal(10,2);not null;default:0" json:"refunded_amount"` // 已退款金额
+	Status          int             `gorm:"column:status" json:"status"`
+	Remark          string          `gorm:"column:remark" json:"remark"`
+	CreatedAt       time.Time       `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt       time.Time       `gorm:"column:updated_at" json:"updated_at"`
+	Items           []OrderItem     `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"items"`
+	Discounts       []OrderDiscount `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"discounts"`
+	User            User            `gorm:"foreignKey:UserID" json:"user"`
 }
 
 type OrderItem struct {
@@ -24,8 +46,8 @@ type OrderItem struct {
 	OrderID    snowflake.ID `gorm:"column:order_id;type:bigint unsigned" json:"order_id"`
 	ProductID  snowflake.ID `gorm:"column:product_id" json:"product_id"`
 	Quantity   int          `gorm:"column:quantity" json:"quantity"`
-	Price      Price        `gorm:"column:price;type:double" json:"price"`
-	TotalPrice Price        `gorm:"column:total_price;type:double" json:"total_price"`
+	Price      Price        `gorm:"column:price;type:decimal(10,2)" json:"price"`
+	TotalPrice Price        `gorm:"column:total_price;type:decimal(10,2)" json:"total_price"`
 	// 添加商品快照字段
 	ProductName        string `gorm:"column:product_name;size:255" json:"product_name"`           // 商品名称
 	ProductDescription string `gorm:"column:product_description" json:"product_description"`      // 商品描述
@@ -42,13 +64,25 @@ type OrderItemOption struct {
 	OrderItemID     snowflake.ID `gorm:"column:order_item_id;index;not null;type:bigint unsigned" json:"order_item_id"`
 	CategoryID      snowflake.ID `gorm:"column:category_id;index;not null;type:bigint unsigned" json:"category_id"` // 类别ID快照
 	OptionID        snowflake.ID `gorm:"column:option_id" json:"option_id"`
-	OptionName      string       `gorm:"column:option_name;size:100" json:"option_name"`     // 选项名称快照
-	CategoryName    string       `gorm:"column:category_name;size:100" json:"category_name"` // 类别名称快照
-	PriceAdjustment float64      `gorm:"column:price_adjustment" json:"price_adjustment"`    // 价格调整快照
+	OptionName      string       `gorm:"column:option_name;size:100" json:"option_name"`                     // 选项名称快照
+	CategoryName    string       `gorm:"column:category_name;size:100" json:"category_name"`                 // 类别名称快照
+	PriceAdjustment Price        `gorm:"column:price_adjustment;type:decimal(10,2)" json:"price_adjustment"` // 价格调整快照
 	CreatedAt       time.Time    `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt       time.Time    `gorm:"column:updated_at" json:"updated_at"`
 }
 
+// OrderDiscount 订单优惠快照，与订单项选项快照一样记录下单时的名称和金额，优惠修改或删除后不受影响
+type OrderDiscount struct {
+	ID          snowflake.ID `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	OrderID     snowflake.ID `gorm:"column:order_id;index;not null;type:bigint unsigned" json:"order_id"`
+	PromotionID snowflake.ID `gorm:"column:promotion_id;index;type:bigint unsigned" json:"promotion_id"`
+	Name        string       `gorm:"column:name;size:100" json:"name"` // 优惠名称快照
+	Code        string       `gorm:"column:code;size:32" json:"code"`  // 券码快照，自动促销为空
+	Type        string       `gorm:"column:type;size:20" json:"type"`
+	Amount      Price        `gorm:"column:amount;type:decimal(10,2)" json:"amount"` // 减免金额
+	CreatedAt   time.Time    `gorm:"column:created_at" json:"created_at"`
+}
+
 const (
 	OrderStatusPending  = 1  // 待处理
 	OrderStatusAccepted = 2  // 已接单
@@ -85,7 +119,8 @@ var DefaultOrderStatusFlow = `{
         {
           "name": "取消",
           "nextStatus": 10,
-          "nextStatusLabel": "已取消"
+          "nextStatusLabel": "已取消",
+          "requireReason": true
         }
       ]
     },
@@ -103,7 +138,8 @@ var DefaultOrderStatusFlow = `{
         {
           "name": "取消",
           "nextStatus": 10,
-          "nextStatusLabel": "已取消"
+          "nextStatusLabel": "已取消",
+          "requireReason": true
         }
       ]
     },
@@ -119,6 +155,7 @@ var DefaultOrderStatusFlow = `{
       "label": "已取消",
       "type": "info",
       "isFinal": true,
+      "releaseStock": true,
       "actions": []
     }
   ]
@@ -130,14 +167,44 @@ type OrderStatusLog struct {
 	OrderID     snowflake.ID `gorm:"type:bigint unsigned" json:"order_id"`
 	OldStatus   int          `json:"old_status"`
 	NewStatus   int          `json:"new_status"`
+	ActorType   string       `gorm:"size:20" json:"actor_type"`
+	ActorID     uint64       `json:"actor_id"`
+	ActorName   string       `gorm:"size:100" json:"actor_name"`
+	Reason      string       `gorm:"size:500" json:"reason"`
 	ChangedTime time.Time    `json:"changed_time"`
 }
 
+// OrderRefund 订单退款记录（售后），订单保留，退款金额从营业额中扣除
+type OrderRefund struct {
+	ID           snowflake.ID      `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	OrderID      snowflake.ID      `gorm:"column:order_id;index;not null;type:bigint unsigned" json:"order_id"`
+	ShopID       snowflake.ID      `gorm:"column:shop_id;index;not null;type:bigint unsigned" json:"shop_id"`
+	Amount       Price             `gorm:"column:amount;type:decimal(10,2);not null" json:"amount"`
+	Reason       string            `gorm:"column:reason;size:500" json:"reason"`
+	RestoreStock bool              `gorm:"column:restore_stock;not null;default:false" json:"restore_stock"` // 退回的商品是否重新入库
+	ActorType    string            `gorm:"column:actor_type;size:20" json:"actor_type"`
+	ActorID      uint64            `gorm:"column:actor_id" json:"actor_id"`
+	ActorName    string            `gorm:"column:actor_name;size:100" json:"actor_name"`
+	CreatedAt    time.Time         `gorm:"column:created_at;index" json:"created_at"`
+	Items        []OrderRefundItem `gorm:"foreignKey:RefundID;constraint:OnDelete:CASCADE" json:"items"`
+}
+
+// OrderRefundItem 退款的订单项和数量
+type OrderRefundItem struct {
+	ID          snowflake.ID `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	RefundID    snowflake.ID `gorm:"column:refund_id;index;not null;type:bigint unsigned" json:"refund_id"`
+	OrderItemID snowflake.ID `gorm:"column:order_item_id;index;not null;type:bigint unsigned" json:"order_item_id"`
+	ProductID   snowflake.ID `gorm:"column:product_id;type:bigint unsigned" json:"product_id"`
+	ProductName string       `gorm:"column:product_name;size:255" json:"product_name"` // 商品名称快照
+	Quantity    int          `gorm:"column:quantity;not null" json:"quantity"`
+	Amount      Price        `gorm:"column:amount;type:decimal(10,2);not null" json:"amount"`
+}
+
 type OrderElement struct {
 	ID         snowflake.ID `gorm:"primarykey;autoIncrement:false;column:id;type:bigint unsigned" json:"id,omitempty"`
 	UserID     snowflake.ID `gorm:"column:user_id" json:"user_id"`
 	ShopID     snowflake.ID `gorm:"column:shop_id;index;not null" json:"shop_id"`
-	TotalPrice Price        `gorm:"column:total_price;type:double" json:"total_price"`
+	TotalPrice Price        `gorm:"column:total_price;type:decimal(10,2)" json:"total_price"`
 	Status     int          `gorm:"column:status" json:"status"`
 	Remark     string       `gorm:"column:remark" json:"remark"`
 	CreatedAt  time.Time    `gorm:"column:created_at" json:"created_at"`
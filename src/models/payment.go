@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// Payment 订单支付单，一笔订单可以多次发起支付，同一时间只保留一张待支付的支付单
+type Payment struct {
+	ID              snowflake.ID `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	ShopID          snowflake.ID `gorm:"column:shop_id;index;not null;type:bigint unsigned" json:"shop_id"`
+	OrderID         snowflake.ID `gorm:"column:order_id;index;not null;type:bigint unsigned" json:"order_id"`
+	UserID          snowflake.ID `gorm:"column:user_id;type:bigint unsigned" json:"user_id"`
+	Method          string       `gorm:"column:method;size:20;not null" json:"method"` // wechat_pay/alipay/mock/cash/pay_at_counter
+	Amount          Price        `gorm:"column:amount;type:decimal(10,2);not null" json:"amount"`
+	RefundedAmount  Price        `gorm:"column:refunded_amount;type:decimal(10,2);not null;default:0" json:"refunded_amount"`
+	Status          string       `gorm:"column:status;size:20;not null" json:"status"` // pending/succeeded/failed/closed
+	ProviderTradeNo string       `gorm:"column:provider_trade_no;size:64;index" json:"provider_trade_no"`
+	FailureReason   string       `gorm:"column:failure_reason;size:255" json:"failure_reason"`
+	ConfirmedBy     string       `gorm:"column:confirmed_by;size:100" json:"confirmed_by"` // 线下支付确认收款的操作人
+	PaidAt          *time.Time   `gorm:"column:paid_at" json:"paid_at"`
+	CreatedAt       time.Time    `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt       time.Time    `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// PaymentRefund 支付单退款记录
+type PaymentRefund struct {
+	ID               snowflake.ID `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	ShopID           snowflake.ID `gorm:"column:shop_id;index;not null;type:bigint unsigned" json:"shop_id"`
+	PaymentID        snowflake.ID `gorm:"column:payment_id;index;not null;type:bigint unsigned" json:"payment_id"`
+	OrderID          snowflake.ID `gorm:"column:order_id;index;not null;type:bigint unsigned" json:"order_id"`
+	Amount           Price        `gorm:"column:amount;type:decimal(10,2);not null" json:"amount"`
+	Reason           string       `gorm:"column:reason;size:500" json:"reason"`
+	Status           string       `gorm:"column:status;size:20;not null" json:"status"` // pending/succeeded/failed
+	ProviderRefundNo string       `gorm:"column:provider_refund_no;size:64" json:"provider_refund_no"`
+	FailureReason    string       `gorm:"column:failure_reason;size:255" json:"failure_reason"`
+	Operator         string       `gorm:"column:operator;size:100" json:"operator"`
+	CreatedAt        time.Time    `gorm:"column:created_at" json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// PickupSequence 店铺每个营业日已发放的最后一个取餐号，下单时在事务内原子递增
+type PickupSequence struct {
+	ShopID       snowflake.ID `gorm:"column:shop_id;primaryKey;autoIncrement:false;type:bigint unsigned" json:"shop_id"`
+	BusinessDate string       `gorm:"column:business_date;primaryKey;size:10" json:"business_date"` // 营业日 YYYY-MM-DD，按店铺时区
+	LastNumber   int          `gorm:"column:last_number;not null;default:0" json:"last_number"`
+	UpdatedAt    time.Time    `gorm:"column:updated_at" json:"updated_at"`
+}
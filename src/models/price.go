@@ -1,69 +1,10 @@
 package models
 
-import (
-	"database/sql/driver"
-	"encoding/json"
-	"fmt"
-	"strconv"
-)
+import "orderease/utils/money"
 
-type Price float64 // gorm:"type:double"
+// Price 金额，以分为单位保存，对应 DECIMAL(10,2) 列
+// 与领域层 shared.Price 是同一类型，扫描、写库、JSON 和取整规则都在 utils/money 中定义
+type Price = money.Price
 
-// String implements the Stringer interface.
-func (p Price) String() string {
-	return fmt.Sprintf("%.2f", p)
-}
-
-// GORM's Scanner interface for mapping database value to custom type
-func (p *Price) Scan(value interface{}) error {
-	switch v := value.(type) {
-	case float64:
-		*p = Price(v)
-	case int64:
-		*p = Price(float64(v))
-	case []uint8:
-		if f, err := strconv.ParseFloat(string(v), 64); err == nil {
-			*p = Price(f)
-		} else {
-			return fmt.Errorf("failed to parse Price from string: %v", err)
-		}
-	default:
-		return fmt.Errorf("unsupported Scan, storing driver.Value type %T into type *Price", value)
-	}
-	return nil
-}
-
-// GORM's Valuer interface for serializing custom type to database value
-func (p Price) Value() (driver.Value, error) {
-	return float64(p), nil
-}
-
-// Custom unmarshal method for JSON, if you're also using JSON
-func (p *Price) UnmarshalJSON(data []byte) error {
-	var value interface{}
-	if err := json.Unmarshal(data, &value); err != nil {
-		return err
-	}
-
-	switch v := value.(type) {
-	case float64:
-		*p = Price(v)
-	case float32:
-		*p = Price(float64(v))
-	case string:
-		if f, err := strconv.ParseFloat(v, 64); err == nil {
-			*p = Price(f)
-		} else {
-			return fmt.Errorf("invalid price format: %s", v)
-		}
-	case int:
-		*p = Price(float64(v))
-	case int64:
-		*p = Price(float64(v))
-	case int32:
-		*p = Price(float64(v))
-	default:
-		return fmt.Errorf("invalid price type: %T", value)
-	}
-	return nil
-}
+// Rate 百分比费率，对应 DECIMAL(5,2) 列，如 8.25 表示 8.25%
+type Rate = money.Rate
@@ -18,13 +18,16 @@ type Product struct {
 	ShopID      snowflake.ID `gorm:"column:shop_id;index;type:bigint unsigned" json:"shop_id"` // 新增店铺ID
 	Name        string       `gorm:"column:name" json:"name"`
 	Description string       `gorm:"column:description" json:"description"`
-	Price       float64      `gorm:"column:price;type:double" json:"price"`
+	Price       Price        `gorm:"column:price;type:decimal(10,2)" json:"price"`
 	Stock       int          `gorm:"column:stock" json:"stock"`
 	ImageURL    string       `gorm:"column:image_url" json:"image_url"`
 	CreatedAt   time.Time    `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt   time.Time    `gorm:"column:updated_at" json:"updated_at"`
 	Status      string       `gorm:"column:status" json:"status"`
 
+	LowStockThreshold int  `gorm:"column:low_stock_threshold;not null;default:0" json:"low_stock_threshold"` // 库存预警值，0 表示不预警
+	AutoOffline       bool `gorm:"column:auto_offline;not null;default:false" json:"auto_offline"`           // 库存为0时被自动下架
+
 	// 可选：添加参数类别关联，方便查询
 	OptionCategories []ProductOptionCategory `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE" json:"option_categories,omitempty"`
 }
@@ -13,6 +13,8 @@ type ProductOptionCategory struct {
 	Name         string       `gorm:"column:name;size:100" json:"name"`                    // 类别名称，如"大小"、"甜度"
 	IsRequired   bool         `gorm:"column:is_required" json:"is_required"`               // 是否必填
 	IsMultiple   bool         `gorm:"column:is_multiple" json:"is_multiple"`               // 是否允许多选
+	MinSelect    int          `gorm:"column:min_select;default:0" json:"min_select"`       // 多选类别的最少选择数量
+	MaxSelect    int          `gorm:"column:max_select;default:0" json:"max_select"`       // 多选类别的最多选择数量，0 表示不限
 	DisplayOrder int          `gorm:"column:display_order;default:0" json:"display_order"` // 显示顺序
 	CreatedAt    time.Time    `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt    time.Time    `gorm:"column:updated_at" json:"updated_at"`
@@ -26,10 +28,10 @@ type ProductOptionCategory struct {
 type ProductOption struct {
 	ID              snowflake.ID `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
 	CategoryID      snowflake.ID `gorm:"column:category_id;index;not null;type:bigint unsigned" json:"category_id"`
-	Name            string       `gorm:"column:name;size:100" json:"name"`                    // 选项名称，如"小杯"、"无糖"
-	PriceAdjustment float64      `gorm:"column:price_adjustment" json:"price_adjustment"`     // 价格调整值，可以是正或负
-	DisplayOrder    int          `gorm:"column:display_order;default:0" json:"display_order"` // 显示顺序
-	IsDefault       bool         `gorm:"column:is_default;default:false" json:"is_default"`   // 是否为默认选项
+	Name            string       `gorm:"column:name;size:100" json:"name"`                                   // 选项名称，如"小杯"、"无糖"
+	PriceAdjustment Price        `gorm:"column:price_adjustment;type:decimal(10,2)" json:"price_adjustment"` // 价格调整值，可以是正或负
+	DisplayOrder    int          `gorm:"column:display_order;default:0" json:"display_order"`                // 显示顺序
+	IsDefault       bool         `gorm:"column:is_default;default:false" json:"is_default"`                  // 是否为默认选项
 	CreatedAt       time.Time    `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt       time.Time    `gorm:"column:updated_at" json:"updated_at"`
 
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// PromotionScope 优惠适用范围，以 JSON 保存；都为空表示适用全部商品
+type PromotionScope struct {
+	ProductIDs []snowflake.ID `json:"product_ids,omitempty"`
+	TagIDs     []int          `json:"tag_ids,omitempty"`
+}
+
+// Value 实现 driver.Valuer 接口，将适用范围转换为 JSON 字符串存入数据库
+func (s PromotionScope) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现 sql.Scanner 接口，将数据库中的 JSON 字符串转换为适用范围
+func (s *PromotionScope) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*s = PromotionScope{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return errors.New("type assertion to []byte failed")
+	}
+}
+
+// Promotion 店铺优惠，填写券码的是优惠券，券码为空的是自动促销
+type Promotion struct {
+	ID           snowflake.ID   `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	ShopID       snowflake.ID   `gorm:"column:shop_id;index;uniqueIndex:idx_promotion_shop_code;not null;type:bigint unsigned" json:"shop_id"`
+	Name         string         `gorm:"column:name;size:100;not null" json:"name"`
+	Code         *string        `gorm:"column:code;size:32;uniqueIndex:idx_promotion_shop_code" json:"code"` // 自动促销为 NULL，同一店铺内券码唯一
+	Type         string         `gorm:"column:type;size:20;not null" json:"type"`                            // fixed_amount/percentage/nth_item
+	Amount       Price          `gorm:"column:amount;type:decimal(10,2);not null;default:0" json:"amount"`
+	Percent      int            `gorm:"column:percent;not null;default:0" json:"percent"`
+	NthItem      int            `gorm:"column:nth_item;not null;default:0" json:"nth_item"`
+	MaxDiscount  Price          `gorm:"column:max_discount;type:decimal(10,2);not null;default:0" json:"max_discount"`
+	MinSpend     Price          `gorm:"column:min_spend;type:decimal(10,2);not null;default:0" json:"min_spend"`
+	UsageLimit   int            `gorm:"column:usage_limit;not null;default:0" json:"usage_limit"`
+	PerUserLimit int            `gorm:"column:per_user_limit;not null;default:0" json:"per_user_limit"`
+	UsedCount    int            `gorm:"column:used_count;not null;default:0" json:"used_count"`
+	Scope        PromotionScope `gorm:"column:scope;type:text" json:"scope"`
+	StartAt      *time.Time     `gorm:"column:start_at" json:"start_at"`
+	EndAt        *time.Time     `gorm:"column:end_at" json:"end_at"`
+	Active       bool           `gorm:"column:active;not null;default:true" json:"active"`
+	CreatedAt    time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// PromotionRedemption 优惠使用记录，用于统计每人使用次数，订单取消时据此归还使用次数
+type PromotionRedemption struct {
+	ID          snowflake.ID `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	ShopID      snowflake.ID `gorm:"column:shop_id;index;type:bigint unsigned" json:"shop_id"`
+	PromotionID snowflake.ID `gorm:"column:promotion_id;index:idx_redemption_promotion_user;not null;type:bigint unsigned" json:"promotion_id"`
+	UserID      snowflake.ID `gorm:"column:user_id;index:idx_redemption_promotion_user;type:bigint unsigned" json:"user_id"`
+	OrderID     snowflake.ID `gorm:"column:order_id;index;not null;type:bigint unsigned" json:"order_id"`
+	CreatedAt   time.Time    `gorm:"column:created_at" json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// RefreshToken 刷新令牌（服务端只保存哈希）
+// 同一次登录派生出的刷新令牌属于同一个 FamilyID，每次刷新都会轮换出新令牌，
+// 已轮换的旧令牌再次被使用时视为泄露，整个家族一并吊销
+type RefreshToken struct {
+	// 刷新令牌只在服务端使用，可以不使用雪花ID
+	ID            uint       `gorm:"column:id;primarykey" json:"id"`
+	FamilyID      string     `gorm:"column:family_id;type:varchar(64);not null;index" json:"family_id"`
+	TokenHash     string     `gorm:"column:token_hash;type:varchar(64);not null;uniqueIndex" json:"-"`
+	PrincipalType string     `gorm:"column:principal_type;type:varchar(20);not null;index:idx_refresh_token_principal" json:"principal_type"` // admin/shop/user
+	PrincipalID   uint64     `gorm:"column:principal_id;not null;index:idx_refresh_token_principal" json:"principal_id"`
+	Username      string     `gorm:"column:username;type:varchar(100)" json:"username"`
+	DeviceLabel   string     `gorm:"column:device_label;type:varchar(100)" json:"device_label"`
+	IP            string     `gorm:"column:ip;type:varchar(64)" json:"ip"`
+	UserAgent     string     `gorm:"column:user_agent;type:varchar(255)" json:"user_agent"`
+	AccessJTI     string     `gorm:"column:access_jti;type:varchar(64)" json:"-"` // 与该刷新令牌一同签发的访问令牌
+	LastUsedAt    time.Time  `gorm:"column:last_used_at;not null" json:"last_used_at"`
+	ExpiresAt     time.Time  `gorm:"column:expires_at;not null;index" json:"expires_at"`
+	RotatedAt     *time.Time `gorm:"column:rotated_at" json:"rotated_at"` // 已被轮换，不可再次使用
+	RevokedAt     *time.Time `gorm:"column:revoked_at" json:"revoked_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at" json:"created_at"`
+}
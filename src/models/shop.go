@@ -18,20 +18,24 @@ type OrderStatusAction struct {
 	Name            string `json:"name" binding:"required"`
 	NextStatus      int    `json:"nextStatus" binding:"required"`
 	NextStatusLabel string `json:"nextStatusLabel" binding:"required"`
+	RequireReason   bool   `json:"requireReason"` // 执行该动作时必须填写原因
 }
 
 // OrderStatus 订单状态
 type OrderStatus struct {
-	Value   int                 `json:"value" binding:"required"`
-	Label   string              `json:"label" binding:"required"`
-	Type    string              `json:"type" binding:"required"`
-	IsFinal bool                `json:"isFinal" binding:"required"`
-	Actions []OrderStatusAction `json:"actions" binding:"required"`
+	Value          int                 `json:"value" binding:"required"`
+	Label          string              `json:"label" binding:"required"`
+	Type           string              `json:"type" binding:"required"`
+	IsFinal        bool                `json:"isFinal" binding:"required"`
+	ReleaseStock   bool                `json:"releaseStock"`   // 进入该状态时归还库存
+	ReadyForPickup bool                `json:"readyForPickup"` // 可以取餐，该状态的订单在取餐叫号屏上显示
+	Actions        []OrderStatusAction `json:"actions" binding:"required"`
 }
 
 // OrderStatusFlow 订单流转状态配置
 type OrderStatusFlow struct {
-	Statuses []OrderStatus `json:"statuses"`
+	Statuses       []OrderStatus `json:"statuses"`
+	RequirePayment bool          `json:"requirePayment"` // 订单必须先支付才能从待处理进入下一个状态
 }
 
 // Value 实现 driver.Valuer 接口，将 OrderStatusFlow 转换为 JSON 字符串存入数据库
@@ -65,8 +69,13 @@ type Shop struct {
 	ValidUntil      time.Time       `gorm:"column:valid_until;index" json:"valid_until"`                 // 有效期
 	Settings        json.RawMessage `gorm:"column:settings;type:json" json:"settings"`                   // 店铺设置
 	OrderStatusFlow OrderStatusFlow `gorm:"column:order_status_flow;type:json" json:"order_status_flow"` // 订单流转状态配置
-	Products        []Product       `gorm:"foreignKey:ShopID" json:"products"`
-	Tags            []Tag           `gorm:"foreignKey:ShopID" json:"tags"`
+
+	AutoOfflineOnZeroStock bool            `gorm:"column:auto_offline_on_zero_stock;not null;default:false" json:"auto_offline_on_zero_stock"` // 库存为0时自动下架商品
+	Timezone               string          `gorm:"column:timezone;size:64" json:"timezone"`                                                    // 店铺时区，营业时间按该时区计算
+	BusinessHours          json.RawMessage `gorm:"column:business_hours;type:json" json:"business_hours"`                                      // 每周营业时间和特殊日期
+	OrderingPaused         bool            `gorm:"column:ordering_paused;not null;default:false" json:"ordering_paused"`                       // 手动暂停接单
+	Products               []Product       `gorm:"foreignKey:ShopID" json:"products"`
+	Tags                   []Tag           `gorm:"foreignKey:ShopID" json:"tags"`
 }
 
 func (s *Shop) CheckPassword(password string) error {
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ShopStaff 店铺员工子账号
+type ShopStaff struct {
+	ID        snowflake.ID `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	ShopID    snowflake.ID `gorm:"column:shop_id;index;not null;type:bigint unsigned" json:"shop_id"`
+	Username  string       `gorm:"column:username;size:50;not null;uniqueIndex" json:"username"` // 员工登录用户名，全局唯一
+	Password  string       `gorm:"column:password;size:255;not null" json:"-"`
+	Name      string       `gorm:"column:name;size:50" json:"name"`
+	Role      string       `gorm:"column:role;size:20;not null" json:"role"` // owner/manager/cashier/kitchen
+	Active    bool         `gorm:"column:active;not null;default:true" json:"active"`
+	CreatedAt time.Time    `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time    `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ShopStaff) TableName() string {
+	return "shop_staff"
+}
+
+// BeforeSave 保存前加密明文密码
+func (s *ShopStaff) BeforeSave(tx *gorm.DB) error {
+	if s.Password != "" && !strings.HasPrefix(s.Password, "$2a$") {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(s.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		s.Password = string(hashed)
+	}
+	return nil
+}
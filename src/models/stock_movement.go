@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// StockMovement 库存流水，商品库存的每一次变化都对应一条记录
+// Product.Stock 是流水累加后的余额缓存，可按 BalanceAfter 逐条对账
+type StockMovement struct {
+	ID           snowflake.ID `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	ShopID       snowflake.ID `gorm:"column:shop_id;index:idx_stock_movement_shop_time;type:bigint unsigned" json:"shop_id"`
+	ProductID    snowflake.ID `gorm:"column:product_id;index:idx_stock_movement_product;type:bigint unsigned" json:"product_id"`
+	Type         string       `gorm:"column:type;type:varchar(20);not null" json:"type"` // sale/cancel_return/adjustment/stocktake/import/initial
+	Quantity     int          `gorm:"column:quantity;not null" json:"quantity"`          // 变化量，正数入库，负数出库
+	BalanceAfter int          `gorm:"column:balance_after;not null" json:"balance_after"`
+	OrderID      snowflake.ID `gorm:"column:order_id;index;type:bigint unsigned" json:"order_id"` // 非订单引起的变化为0
+	ActorType    string       `gorm:"column:actor_type;type:varchar(20)" json:"actor_type"`
+	ActorID      uint64       `gorm:"column:actor_id" json:"actor_id"`
+	ActorName    string       `gorm:"column:actor_name;type:varchar(100)" json:"actor_name"`
+	Reason       string       `gorm:"column:reason;type:varchar(500)" json:"reason"`
+	CreatedAt    time.Time    `gorm:"column:created_at;index:idx_stock_movement_shop_time;index:idx_stock_movement_product" json:"created_at"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+// DiningTable 店铺的堂食桌台，表名避免使用 SQL 关键字 table
+type DiningTable struct {
+	ID        snowflake.ID `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	ShopID    snowflake.ID `gorm:"column:shop_id;not null;type:bigint unsigned;uniqueIndex:idx_dining_table_shop_name" json:"shop_id"`
+	Name      string       `gorm:"column:name;size:20;not null;uniqueIndex:idx_dining_table_shop_name" json:"name"` // 桌号，店铺内唯一
+	Seats     int          `gorm:"column:seats;not null;default:0" json:"seats"`
+	QRVersion int          `gorm:"column:qr_version;not null;default:1" json:"qr_version"` // 二维码版本，重新生成后旧二维码失效
+	Active    bool         `gorm:"column:active;not null;default:true" json:"active"`
+	CreatedAt time.Time    `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time    `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (DiningTable) TableName() string {
+	return "dining_tables"
+}
+
+// TableSession 桌台的一次用餐，归入该用餐的订单合并结账
+type TableSession struct {
+	ID          snowflake.ID `gorm:"column:id;primarykey;autoIncrement:false;type:bigint unsigned" json:"id"`
+	ShopID      snowflake.ID `gorm:"column:shop_id;not null;index;type:bigint unsigned" json:"shop_id"`
+	TableID     snowflake.ID `gorm:"column:table_id;not null;index;type:bigint unsigned" json:"table_id"`
+	Status      string       `gorm:"column:status;size:20;not null;index" json:"status"` // open/closed
+	Guests      int          `gorm:"column:guests;not null;default:0" json:"guests"`
+	OpenedAt    time.Time    `gorm:"column:opened_at;not null" json:"opened_at"`
+	ClosedAt    *time.Time   `gorm:"column:closed_at" json:"closed_at"`
+	CloseReason string       `gorm:"column:close_reason;size:20" json:"close_reason"` // paid/merged/manual
+	MergedInto  snowflake.ID `gorm:"column:merged_into;type:bigint unsigned" json:"merged_into"`
+	GuestUserID snowflake.ID `gorm:"column:guest_user_id;index;type:bigint unsigned" json:"guest_user_id"` // 扫码点餐的顾客身份
+	CreatedAt   time.Time    `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time    `gorm:"column:updated_at" json:"updated_at"`
+}
@@ -7,6 +7,17 @@ type BlacklistedToken struct {
 	// 请求黑名单数据量小，可以不使用雪花ID
 	ID        uint      `gorm:"column:id;primarykey" json:"id"`
 	Token     string    `gorm:"column:token;type:varchar(500);not null;uniqueIndex" json:"token"`
+	JTI       string    `gorm:"column:jti;type:varchar(64);index" json:"jti"`       // 令牌唯一标识，旧令牌无此字段时按 token 匹配
 	ExpiredAt time.Time `gorm:"column:expired_at;not null;index" json:"expired_at"` // token原本的过期时间
 	CreatedAt time.Time `gorm:"column:created_at;not null" json:"created_at"`       // 加入黑名单的时间
 }
+
+// TokenRevocation 按主体批量吊销令牌（如修改密码后使该账号的其他会话失效）
+type TokenRevocation struct {
+	ID            uint      `gorm:"column:id;primarykey" json:"id"`
+	PrincipalType string    `gorm:"column:principal_type;type:varchar(20);not null;uniqueIndex:idx_token_revocation_principal" json:"principal_type"` // admin/shop/user
+	PrincipalID   uint64    `gorm:"column:principal_id;not null;uniqueIndex:idx_token_revocation_principal" json:"principal_id"`
+	RevokedBefore time.Time `gorm:"column:revoked_before;not null;index" json:"revoked_before"` // 在此时间之前签发的令牌全部失效
+	ExceptJTI     string    `gorm:"column:except_jti;type:varchar(64)" json:"except_jti"`       // 发起吊销的当前会话不受影响
+	UpdatedAt     time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
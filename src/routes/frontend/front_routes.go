@@ -1,7 +1,9 @@
 package frontend
 
 import (
+	"orderease/application/services"
 	"orderease/config"
+	"orderease/database"
 	"orderease/handlers"
 	"orderease/middleware"
 
@@ -16,7 +18,7 @@ func SetupFrontRoutes(r *gin.Engine, h *handlers.Handler) {
 	// 需要认证的路由组
 	protected := r.Group(basePath)
 	protected.Use(middleware.RateLimitMiddleware())
-	protected.Use(middleware.FrontendAuthMiddleware())
+	protected.Use(middleware.FrontendAuthMiddleware(services.NewTokenBlacklistService(database.GetDB())))
 
 	// 产品相关路由
 	setupProductRoutes(protected, h)
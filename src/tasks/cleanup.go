@@ -57,6 +57,11 @@ func (t *CleanupTask) Cleanup() error {
 			return err
 		}
 
+		// 3. 清理过期的幂等键
+		if err := tx.Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+
 		return nil
 	})
 }
@@ -5,6 +5,7 @@ import (
 	"orderease/utils/log2"
 	"time"
 
+	"github.com/spf13/viper"
 	"gorm.io/gorm"
 )
 
@@ -45,5 +46,15 @@ func (t *TokenCleanupTask) StartTokenCleanup() {
 
 // cleanupExpiredTokens 清理过期的token记录
 func (t *TokenCleanupTask) cleanupExpiredTokens() error {
-	return t.db.Where("expired_at < ?", time.Now()).Delete(&models.BlacklistedToken{}).Error
+	if err := t.db.Where("expired_at < ?", time.Now()).Delete(&models.BlacklistedToken{}).Error; err != nil {
+		return err
+	}
+
+	if err := t.db.Where("expires_at < ?", time.Now()).Delete(&models.RefreshToken{}).Error; err != nil {
+		return err
+	}
+
+	// 吊销时间早于令牌最长有效期的记录已不会再命中任何有效令牌
+	expiration := time.Duration(viper.GetInt("jwt.expiration")) * time.Second
+	return t.db.Where("revoked_before < ?", time.Now().Add(-expiration)).Delete(&models.TokenRevocation{}).Error
 }
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -16,31 +18,64 @@ type Claims struct {
 	UserID   uint64 `json:"user_id"`
 	Username string `json:"username"`
 	IsAdmin  bool   `json:"is_admin"`
+	// SessionID 登录会话（刷新令牌家族）标识，旧令牌为空
+	SessionID string `json:"sid,omitempty"`
+	// StaffID 店铺员工子账号ID，店主本人登录时为 0；员工令牌的 UserID 仍为所属店铺ID
+	StaffID uint64 `json:"staff_id,omitempty"`
+	// Role 店铺后台角色，Permissions 为该角色被授予的权限，旧令牌为空
+	Role        string   `json:"role,omitempty"`
+	Permissions []string `json:"perms,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GenerateToken 生成JWT token
 func GenerateToken(userID uint64, username string, isAdmin bool) (string, time.Time, error) {
+	token, _, expirationTime, err := GenerateSessionToken(userID, username, isAdmin, "")
+	return token, expirationTime, err
+}
+
+// GenerateSessionToken 生成绑定登录会话的JWT token，同时返回令牌的 jti
+func GenerateSessionToken(userID uint64, username string, isAdmin bool, sessionID string) (string, string, time.Time, error) {
+	return GenerateClaimsToken(&Claims{
+		UserID:    userID,
+		Username:  username,
+		IsAdmin:   isAdmin,
+		SessionID: sessionID,
+	})
+}
+
+// GenerateClaimsToken 按给定的业务声明生成JWT token，jti、签发时间和过期时间由此处统一填充
+func GenerateClaimsToken(claims *Claims) (string, string, time.Time, error) {
 	expirationSeconds := viper.GetInt("jwt.expiration")
 	expirationTime := time.Now().Add(time.Duration(expirationSeconds) * time.Second)
 
-	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		IsAdmin:  isAdmin,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        newTokenID(),
+		ExpiresAt: jwt.NewNumericDate(expirationTime),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString(getJWTSecret())
 	if err != nil {
-		return "", time.Time{}, err
+		return "", "", time.Time{}, err
 	}
 
-	return tokenString, expirationTime, nil
+	return tokenString, claims.ID, expirationTime, nil
+}
+
+// newTokenID 生成令牌唯一标识（jti），用于登出时按令牌吊销
+func newTokenID() string {
+	return RandomHex(16)
+}
+
+// RandomHex 生成 n 字节的加密安全随机数，以十六进制字符串返回
+func RandomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
 }
 
 // ParseToken 解析JWT token
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateSessionToken(t *testing.T) {
+	viper.Set("jwt.secret", "test-secret")
+	viper.Set("jwt.expiration", 900)
+
+	token, jti, expiredAt, err := GenerateSessionToken(42, "owner", false, "session-1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, jti)
+
+	claims, err := ParseToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), claims.UserID)
+	assert.Equal(t, "owner", claims.Username)
+	assert.Equal(t, "session-1", claims.SessionID)
+	assert.Equal(t, jti, claims.ID)
+	assert.Equal(t, expiredAt.Unix(), claims.ExpiresAt.Unix())
+
+	// 每个令牌的 jti 都不同，登出时才能只吊销当前令牌
+	_, otherJTI, _, err := GenerateSessionToken(42, "owner", false, "session-1")
+	assert.NoError(t, err)
+	assert.NotEqual(t, jti, otherJTI)
+}
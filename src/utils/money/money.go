@@ -0,0 +1,225 @@
+// Package money 金额类型，领域层（shared.Price）和持久化模型（models.Price）共用这一实现
+// 放在独立的包中，避免 models 与 domain/shared 之间的循环引用
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Price 金额，以分为单位的整数保存，避免浮点累加出现 29.900000000000002 这类误差
+// JSON、CSV 和数据库（DECIMAL(10,2)）中统一使用两位小数的十进制表示
+// 金额的取整规则只在本文件定义：超过两位的小数按第三位四舍五入（远离零）
+type Price int64
+
+// PriceScale 一元对应的分数
+const PriceScale = 100
+
+// NewPrice 按元构造金额，四舍五入到分
+// 先按最短十进制表示格式化再解析，1.005 这类浮点数不会因二进制误差被舍成 1.00
+func NewPrice(value float64) Price {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0
+	}
+	p, err := ParsePrice(strconv.FormatFloat(value, 'f', -1, 64))
+	if err != nil {
+		return Price(math.Round(value * PriceScale))
+	}
+	return p
+}
+
+// PriceFromCents 按分构造金额
+func PriceFromCents(cents int64) Price {
+	return Price(cents)
+}
+
+// ParsePrice 解析十进制金额字符串（如 "29.9"、"-3.50"），不经过浮点运算
+func ParsePrice(s string) (Price, error) {
+	s = strings.TrimSpace(s)
+	if strings.ContainsAny(s, "eE") {
+		// 科学计数法只可能来自 JSON 数字，按浮点解析后取整
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, fmt.Errorf("invalid price format: %s", s)
+		}
+		return NewPrice(f), nil
+	}
+
+	digits := s
+	negative := false
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		negative = digits[0] == '-'
+		digits = digits[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(digits, ".")
+	if (intPart == "" && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, fmt.Errorf("invalid price format: %s", s)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	yuan, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || yuan > math.MaxInt64/PriceScale-1 {
+		return 0, fmt.Errorf("price out of range: %s", s)
+	}
+
+	fracPart += "000"
+	cents := yuan*PriceScale + int64(fracPart[0]-'0')*10 + int64(fracPart[1]-'0')
+	if fracPart[2] >= '5' {
+		cents++
+	}
+	if negative {
+		cents = -cents
+	}
+	return Price(cents), nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String 返回两位小数的十进制表示，如 "29.90"
+func (p Price) String() string {
+	cents := int64(p)
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/PriceScale, cents%PriceScale)
+}
+
+func (p *Price) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case float64:
+		*p = NewPrice(v)
+	case int64:
+		// 整数列值表示整元（SQLite 会把 12.00 存成整数）
+		*p = Price(v * PriceScale)
+	case []uint8:
+		parsed, err := ParsePrice(string(v))
+		if err != nil {
+			return fmt.Errorf("failed to parse Price from string: %v", err)
+		}
+		*p = parsed
+	case string:
+		parsed, err := ParsePrice(v)
+		if err != nil {
+			return fmt.Errorf("failed to parse Price from string: %v", err)
+		}
+		*p = parsed
+	default:
+		return fmt.Errorf("unsupported Scan, storing driver.Value type %T into type *Price", value)
+	}
+	return nil
+}
+
+// Value 以十进制字符串写入 DECIMAL 列，不丢失精度
+func (p Price) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+// MarshalJSON 输出两位小数的 JSON 数字，如 29.90
+func (p Price) MarshalJSON() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalJSON 支持数字和字符串，直接按十进制文本解析
+func (p *Price) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		parsed, err := ParsePrice(v.String())
+		if err != nil {
+			return err
+		}
+		*p = parsed
+	case string:
+		parsed, err := ParsePrice(v)
+		if err != nil {
+			return fmt.Errorf("invalid price format: %s", v)
+		}
+		*p = parsed
+	default:
+		return fmt.Errorf("invalid price type: %T", value)
+	}
+	return nil
+}
+
+// Cents 以分为单位的金额
+func (p Price) Cents() int64 {
+	return int64(p)
+}
+
+// ToFloat64 以元为单位的浮点值，仅用于展示和统计，不要再参与金额计算
+func (p Price) ToFloat64() float64 {
+	return float64(p) / PriceScale
+}
+
+func (p Price) Add(other Price) Price {
+	return p + other
+}
+
+func (p Price) Sub(other Price) Price {
+	return p - other
+}
+
+func (p Price) Multiply(quantity int) Price {
+	return p * Price(quantity)
+}
+
+// Percent 按百分比计算金额（如折扣金额），结果四舍五入到分（远离零）
+func (p Price) Percent(percent int) Price {
+	return p.mulDiv(int64(percent), 100)
+}
+
+// Prorate 按 part/whole 的比例分摊金额（如按退款数量分摊订单项金额），结果四舍五入到分，whole 必须大于0
+func (p Price) Prorate(part, whole int64) Price {
+	return p.mulDiv(part, whole)
+}
+
+// mulDiv 计算 p × num / den，结果四舍五入到分（远离零），den 必须大于0
+func (p Price) mulDiv(num, den int64) Price {
+	product := int64(p) * num
+	if product < 0 {
+		return Price(-((-product + den/2) / den))
+	}
+	return Price((product + den/2) / den)
+}
+
+// Min 返回两个金额中较小的一个
+func (p Price) Min(other Price) Price {
+	if other < p {
+		return other
+	}
+	return p
+}
+
+func (p Price) IsZero() bool {
+	return p == 0
+}
+
+func (p Price) IsPositive() bool {
+	return p > 0
+}
+
+func (p Price) IsNegative() bool {
+	return p < 0
+}
@@ -0,0 +1,74 @@
+package money
+
+import "database/sql/driver"
+
+// Rate 百分比费率（税率、服务费比例），以 0.01% 为单位的整数保存，如 825 表示 8.25%
+// 与金额一样在 JSON、CSV 和数据库（DECIMAL(5,2)）中使用两位小数的十进制表示，解析和取整规则复用 Price
+type Rate int64
+
+// RateScale 1% 对应的单位数
+const RateScale = 100
+
+// FullRate 100%
+const FullRate = Rate(100 * RateScale)
+
+// NewRate 按百分数构造费率，如 NewRate(6) 表示 6%
+func NewRate(percent float64) Rate {
+	return Rate(NewPrice(percent))
+}
+
+// ParseRate 解析两位小数的百分数字符串，如 "8.25"
+func ParseRate(s string) (Rate, error) {
+	p, err := ParsePrice(s)
+	return Rate(p), err
+}
+
+// String 返回两位小数的百分数，如 "8.25"
+func (r Rate) String() string {
+	return Price(r).String()
+}
+
+func (r *Rate) Scan(value interface{}) error {
+	var p Price
+	if err := p.Scan(value); err != nil {
+		return err
+	}
+	*r = Rate(p)
+	return nil
+}
+
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	var p Price
+	if err := p.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*r = Rate(p)
+	return nil
+}
+
+func (r Rate) IsZero() bool {
+	return r == 0
+}
+
+// IsValid 费率在 0% 到 100% 之间
+func (r Rate) IsValid() bool {
+	return r >= 0 && r <= FullRate
+}
+
+// ApplyRate 按费率计算价外金额（如价外税、服务费），结果四舍五入到分
+func (p Price) ApplyRate(r Rate) Price {
+	return p.mulDiv(int64(r), int64(FullRate))
+}
+
+// IncludedRate 从含税金额中拆出按费率计算的部分（价内税），即 p × r / (100% + r)，结果四舍五入到分
+func (p Price) IncludedRate(r Rate) Price {
+	return p.mulDiv(int64(r), int64(FullRate+r))
+}